@@ -112,7 +112,7 @@ function buildNotification(wire: apperr.WireError): Omit<Notification, 'id'> {
                 severity: 'error',
                 surface: 'toast',
                 title: 'Stack not allowed',
-                message: `${d['reason'] ?? 'Plan is invalid'} (max 5 steps · 3 inferences).`,
+                message: `${d['reason'] ?? 'Plan is invalid'}.`,
                 ...withDetails(wire),
             };
         case apperr.ErrorCode.CodeBusy:
@@ -521,3 +521,32 @@ func TestActionService_BuildPlanAndPrompts_SettingsError_ReturnsError(t *testing
 		t.Fatal("expected error when settingsService fails, got nil")
 	}
 }
+
+func TestActionService_BuildPlanAndPrompts_ShowsResolvedParams(t *testing.T) {
+	svc := buildTestService(t)
+
+	tests := []struct {
+		name   string
+		step   apperr.ChainStep
+		want   string
+		wantIn string
+	}{
+		{name: "default", step: apperr.ChainStep{ActionID: "summarize.summary"}, want: "120", wantIn: "about 120 words"},
+		{name: "override", step: apperr.ChainStep{ActionID: "summarize.summary", Params: map[string]string{"word_count": "60"}}, want: "60", wantIn: "about 60 words"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview, err := svc.BuildPlanAndPrompts(apperr.PromptPreviewRequest{Steps: []apperr.ChainStep{tt.step}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			applied := preview.Groups[0].AppliedActions[0]
+			if got := applied.Params["word_count"]; got != tt.want {
+				t.Errorf("resolved word_count: got %q, want %q", got, tt.want)
+			}
+			if !strings.Contains(preview.Groups[0].UserPrompt, tt.wantIn) {
+				t.Errorf("user prompt should contain %q", tt.wantIn)
+			}
+		})
+	}
+}
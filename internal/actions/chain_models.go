@@ -3,18 +3,56 @@ package actions
 import "go_text/internal/apperr"
 
 // ChainPlan is the output of the Planner: an ordered slice of merge groups.
+// Inferences counts the groups that call the model; local groups do not.
 type ChainPlan struct {
 	Groups     []Group
 	Inferences int
 }
 
+// FanOutPlan is the Planner output for a fan-out request: the shared prefix
+// (zero groups when the request has no prefix steps) and one plan per branch.
+type FanOutPlan struct {
+	Prefix   ChainPlan
+	Branches []BranchPlan
+}
+
+// BranchPlan is one planned fan-out branch.
+type BranchPlan struct {
+	Name string
+	Plan ChainPlan
+}
+
+// Path returns the groups a branch runs through: the shared prefix followed by
+// the branch's own groups.
+func (f FanOutPlan) Path(branch int) ChainPlan {
+	groups := append(append([]Group(nil), f.Prefix.Groups...), f.Branches[branch].Plan.Groups...)
+	return ChainPlan{Groups: groups, Inferences: countInferences(groups)}
+}
+
+// PlanLimits caps the size of a plan. Zero fields fall back to the defaults
+// (settings.DefaultMaxPlanSteps / settings.DefaultMaxPlanInferences).
+type PlanLimits struct {
+	MaxSteps      int
+	MaxInferences int
+}
+
 // Group is one inference group: same family, all steps are mergeable (or a single
-// non-mergeable action). Groups execute sequentially; each produces one LLM call.
+// non-mergeable action). Groups execute sequentially; each produces one LLM call,
+// except local groups, which run their steps in-process (see runLocalGroup).
 type Group struct {
 	Family string
 	Steps  []apperr.ChainStep // in canonical sub-order
 }
 
+// actionIDs returns the IDs of g's actions in order.
+func (g Group) actionIDs() []string {
+	ids := make([]string, len(g.Steps))
+	for i, s := range g.Steps {
+		ids[i] = s.ActionID
+	}
+	return ids
+}
+
 // ChatStepRequest is the input to runStep: the fully built prompts plus
 // the metadata needed for the tasklog entry.
 type ChatStepRequest struct {
@@ -0,0 +1,160 @@
+package actions
+
+import (
+	"fmt"
+	"slices"
+
+	"go_text/internal/apperr"
+)
+
+// maxCheckpoints caps how many failed or cancelled runs stay resumable; the
+// least recently run are dropped first.
+const maxCheckpoints = 50
+
+// Checkpoint is the resumable state of a linear chain run: the request as it
+// was planned (its "auto" input language resolved), the action IDs of each
+// planned group, the time spent on the run so far, and every group completed.
+type Checkpoint struct {
+	RunID      string
+	Request    apperr.ChainRequest
+	Plan       [][]string
+	DurationMs int64
+	Groups     []CheckpointGroup
+}
+
+// CheckpointGroup is one completed group of a checkpointed run: the text it
+// produced and the language that text is in, whether its conditions skipped
+// it, the LLM calls it made, and the warning and edit list it produced, if any.
+type CheckpointGroup struct {
+	Index      int
+	Text       string
+	Lang       string
+	Skipped    bool
+	Inferences int
+	Warning    *apperr.ChainWarning
+	EditList   *apperr.EditList
+}
+
+// CheckpointRepositoryAPI is the contract for the SQLite checkpoint repository.
+type CheckpointRepositoryAPI interface {
+	// Save starts the checkpoint of cp.RunID without groups, replacing an earlier
+	// one, then prunes to the maxEntries most recent checkpoints.
+	Save(cp Checkpoint, maxEntries int64) error
+	// SaveGroup stores one completed group of the run, replacing an earlier
+	// attempt at the same index.
+	SaveGroup(runID string, group CheckpointGroup) error
+	// Finish adds durationMs to the time spent on the run.
+	Finish(runID string, durationMs int64) error
+	// Get returns the checkpoint of runID with its groups in order, or a
+	// validation error when the run has none.
+	Get(runID string) (*Checkpoint, error)
+	Delete(runID string) error
+}
+
+// SetCheckpointRepository wires the SQLite-backed checkpoint repository after
+// the DB is open. Called from ApplicationContextHolder.Init; until then runs
+// are not checkpointed and ResumeChain fails.
+func (a *ActionService) SetCheckpointRepository(repo CheckpointRepositoryAPI) {
+	a.checkpoints = repo
+}
+
+// planSignature returns the action IDs of each group of plan, the shape a
+// resumed run's fresh plan must keep for its stored groups to still apply.
+func planSignature(plan ChainPlan) [][]string {
+	sig := make([][]string, len(plan.Groups))
+	for i, g := range plan.Groups {
+		sig[i] = g.actionIDs()
+	}
+	return sig
+}
+
+// startCheckpoint saves the checkpoint of req, a linear run about to execute
+// plan, and returns the hook that stores each group it completes. It returns
+// nil, and the run goes unsaved, for runs without a run ID, runs kept out of
+// history, before the repository is wired, or when saving fails.
+// Like history recording, checkpointing never breaks a run: errors are logged.
+func (a *ActionService) startCheckpoint(req apperr.ChainRequest, plan ChainPlan, from groupRun) func(int, groupRun) {
+	const op = "ActionService.startCheckpoint"
+	if a.checkpoints == nil || req.RunID == "" || req.SkipHistory {
+		return nil
+	}
+	if from.completed == 0 {
+		cp := Checkpoint{RunID: req.RunID, Request: req, Plan: planSignature(plan)}
+		if err := a.checkpoints.Save(cp, maxCheckpoints); err != nil {
+			a.logger.Warning(fmt.Sprintf("[%s] save run %s: %v", op, req.RunID, err))
+			return nil
+		}
+	}
+	return a.checkpointGroups(req.RunID, from)
+}
+
+// checkpointGroups returns the hook runGroups calls after each group it
+// completes, storing what that group added to the run started at from.
+func (a *ActionService) checkpointGroups(runID string, from groupRun) func(int, groupRun) {
+	const op = "ActionService.checkpointGroups"
+	prev := from
+	return func(i int, run groupRun) {
+		g := CheckpointGroup{
+			Index:      i,
+			Text:       run.text,
+			Lang:       run.lang,
+			Skipped:    len(run.skipped) > len(prev.skipped),
+			Inferences: run.inferences - prev.inferences,
+		}
+		if len(run.warnings) > len(prev.warnings) {
+			g.Warning = &run.warnings[len(run.warnings)-1]
+		}
+		if len(run.editLists) > len(prev.editLists) {
+			g.EditList = &run.editLists[len(run.editLists)-1]
+		}
+		prev = run
+		if err := a.checkpoints.SaveGroup(runID, g); err != nil {
+			a.logger.Warning(fmt.Sprintf("[%s] save group %d of run %s: %v", op, i, runID, err))
+		}
+	}
+}
+
+// finishCheckpoint drops the checkpoint of a run that succeeded, which leaves
+// nothing to resume, and adds the pass's duration to one that did not.
+func (a *ActionService) finishCheckpoint(runID string, run groupRun, passMs int64) {
+	const op = "ActionService.finishCheckpoint"
+	var err error
+	if run.err == nil {
+		err = a.checkpoints.Delete(runID)
+	} else {
+		err = a.checkpoints.Finish(runID, passMs)
+	}
+	if err != nil {
+		a.logger.Warning(fmt.Sprintf("[%s] run %s: %v", op, runID, err))
+	}
+}
+
+// resumeFrom returns the state reached by the groups stored in cp, applied in
+// order to start, the state of the run before its first group. Stored groups
+// after a gap in the indices are ignored.
+func (cp *Checkpoint) resumeFrom(start groupRun) groupRun {
+	run := start
+	for i, g := range cp.Groups {
+		if g.Index != i {
+			break
+		}
+		run.text, run.lang = g.Text, g.Lang
+		if g.Skipped {
+			run.skipped = append(run.skipped, i)
+		}
+		run.inferences += g.Inferences
+		if g.Warning != nil {
+			run.warnings = append(run.warnings, *g.Warning)
+		}
+		if g.EditList != nil {
+			run.editLists = append(run.editLists, *g.EditList)
+		}
+		run.completed++
+	}
+	return run
+}
+
+// matchesPlan reports whether plan still has the groups cp was saved with.
+func (cp *Checkpoint) matchesPlan(plan ChainPlan) bool {
+	return slices.EqualFunc(planSignature(plan), cp.Plan, slices.Equal[[]string])
+}
@@ -0,0 +1,208 @@
+package actions
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/db"
+	"go_text/internal/db/store"
+)
+
+// SqliteCheckpointRepository is the SQLite-backed implementation of CheckpointRepositoryAPI.
+type SqliteCheckpointRepository struct {
+	database *db.Database
+}
+
+// NewSqliteCheckpointRepository constructs a checkpoint repository backed by database.
+func NewSqliteCheckpointRepository(database *db.Database) *SqliteCheckpointRepository {
+	if database == nil {
+		panic("SqliteCheckpointRepository: database cannot be nil")
+	}
+	return &SqliteCheckpointRepository{database: database}
+}
+
+func (r *SqliteCheckpointRepository) bg() context.Context { return context.Background() }
+
+// marshalOptional encodes v as JSON, or "" when v is nil.
+func marshalOptional[T any](v *T) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// unmarshalOptional decodes s, or returns nil when s is "".
+func unmarshalOptional[T any](s string) (*T, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var v T
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Save starts the checkpoint of cp.RunID, dropping the groups of an earlier
+// one, then prunes to the maxEntries most recent checkpoints, in one transaction.
+func (r *SqliteCheckpointRepository) Save(cp Checkpoint, maxEntries int64) error {
+	const op = "SqliteCheckpointRepository.Save"
+	ctx := r.bg()
+	now := time.Now().Unix()
+
+	request, err := json.Marshal(cp.Request)
+	if err != nil {
+		return fmt.Errorf("%s: encode request: %w", op, err)
+	}
+	plan, err := json.Marshal(cp.Plan)
+	if err != nil {
+		return fmt.Errorf("%s: encode plan: %w", op, err)
+	}
+
+	tx, err := r.database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	q := r.database.Queries.WithTx(tx)
+	if err := q.UpsertChainCheckpoint(ctx, store.UpsertChainCheckpointParams{
+		RunID:     cp.RunID,
+		Request:   string(request),
+		Plan:      string(plan),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		return fmt.Errorf("%s: upsert: %w", op, err)
+	}
+	if err := q.DeleteChainCheckpointGroups(ctx, cp.RunID); err != nil {
+		return fmt.Errorf("%s: drop groups: %w", op, err)
+	}
+	if maxEntries > 0 {
+		if err := q.PruneChainCheckpoints(ctx, maxEntries); err != nil {
+			return fmt.Errorf("%s: prune: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return nil
+}
+
+// SaveGroup stores one completed group of runID.
+func (r *SqliteCheckpointRepository) SaveGroup(runID string, group CheckpointGroup) error {
+	const op = "SqliteCheckpointRepository.SaveGroup"
+	warning, err := marshalOptional(group.Warning)
+	if err != nil {
+		return fmt.Errorf("%s: encode warning: %w", op, err)
+	}
+	editList, err := marshalOptional(group.EditList)
+	if err != nil {
+		return fmt.Errorf("%s: encode edit list: %w", op, err)
+	}
+	skipped := int64(0)
+	if group.Skipped {
+		skipped = 1
+	}
+	if err := r.database.Queries.UpsertChainCheckpointGroup(r.bg(), store.UpsertChainCheckpointGroupParams{
+		RunID:      runID,
+		GroupIndex: int64(group.Index),
+		OutputText: group.Text,
+		OutputLang: group.Lang,
+		Skipped:    skipped,
+		Inferences: int64(group.Inferences),
+		Warning:    warning,
+		EditList:   editList,
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Finish adds durationMs to the time spent on runID.
+func (r *SqliteCheckpointRepository) Finish(runID string, durationMs int64) error {
+	const op = "SqliteCheckpointRepository.Finish"
+	if err := r.database.Queries.FinishChainCheckpoint(r.bg(), store.FinishChainCheckpointParams{
+		DurationMs: durationMs,
+		UpdatedAt:  time.Now().Unix(),
+		RunID:      runID,
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Get returns the checkpoint of runID with its groups in order.
+func (r *SqliteCheckpointRepository) Get(runID string) (*Checkpoint, error) {
+	const op = "SqliteCheckpointRepository.Get"
+	ctx := r.bg()
+	row, err := r.database.Queries.GetChainCheckpoint(ctx, runID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Validation("runId", "name a failed or cancelled run that can be resumed", runID)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	cp := &Checkpoint{RunID: row.RunID, DurationMs: row.DurationMs}
+	if err := json.Unmarshal([]byte(row.Request), &cp.Request); err != nil {
+		return nil, fmt.Errorf("%s: decode request of run %s: %w", op, runID, err)
+	}
+	if err := json.Unmarshal([]byte(row.Plan), &cp.Plan); err != nil {
+		return nil, fmt.Errorf("%s: decode plan of run %s: %w", op, runID, err)
+	}
+
+	rows, err := r.database.Queries.ListChainCheckpointGroups(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: list groups: %w", op, err)
+	}
+	cp.Groups = make([]CheckpointGroup, 0, len(rows))
+	for _, g := range rows {
+		warning, err := unmarshalOptional[apperr.ChainWarning](g.Warning)
+		if err != nil {
+			return nil, fmt.Errorf("%s: decode warning of group %d: %w", op, g.GroupIndex, err)
+		}
+		editList, err := unmarshalOptional[apperr.EditList](g.EditList)
+		if err != nil {
+			return nil, fmt.Errorf("%s: decode edit list of group %d: %w", op, g.GroupIndex, err)
+		}
+		cp.Groups = append(cp.Groups, CheckpointGroup{
+			Index:      int(g.GroupIndex),
+			Text:       g.OutputText,
+			Lang:       g.OutputLang,
+			Skipped:    g.Skipped != 0,
+			Inferences: int(g.Inferences),
+			Warning:    warning,
+			EditList:   editList,
+		})
+	}
+	return cp, nil
+}
+
+// Delete removes the checkpoint of runID and its groups; an unknown runID is a no-op.
+func (r *SqliteCheckpointRepository) Delete(runID string) error {
+	const op = "SqliteCheckpointRepository.Delete"
+	if err := r.database.Queries.DeleteChainCheckpoint(r.bg(), runID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// PruneOlderThan deletes checkpoints (and their groups) last updated before
+// cutoff (Unix seconds) and returns how many were removed.
+func (r *SqliteCheckpointRepository) PruneOlderThan(cutoff int64) (int64, error) {
+	const op = "SqliteCheckpointRepository.PruneOlderThan"
+	n, err := r.database.Queries.PruneChainCheckpointsOlderThan(r.bg(), cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,162 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"go_text/internal/apperr"
+	"go_text/internal/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCheckpointRepo(t *testing.T) *SqliteCheckpointRepository {
+	t.Helper()
+	d, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+	return NewSqliteCheckpointRepository(d)
+}
+
+func testCheckpoint(runID string) Checkpoint {
+	return Checkpoint{
+		RunID: runID,
+		Request: apperr.ChainRequest{
+			RunID:           runID,
+			InputText:       "input text",
+			Steps:           []apperr.ChainStep{{ActionID: "proofread"}, {ActionID: "summarize.short"}},
+			InputLanguageID: "English",
+		},
+		Plan: [][]string{{"proofread"}, {"summarize.short"}},
+	}
+}
+
+func TestSqliteCheckpointRepository_SaveAndGet(t *testing.T) {
+	t.Parallel()
+	repo := newCheckpointRepo(t)
+
+	require.NoError(t, repo.Save(testCheckpoint("run-1"), 10))
+	warning := &apperr.ChainWarning{Code: apperr.CodeLanguageMismatch, Message: "wrong language", GroupIndex: 0, Family: "rewrite"}
+	edits := &apperr.EditList{GroupIndex: 1, ActionID: "summarize.short", Input: "fixed", Edits: []apperr.TextEdit{{Start: 0, End: 5, Replacement: "done"}}}
+	require.NoError(t, repo.SaveGroup("run-1", CheckpointGroup{Index: 0, Text: "fixed", Lang: "English", Inferences: 2, Warning: warning}))
+	require.NoError(t, repo.SaveGroup("run-1", CheckpointGroup{Index: 1, Text: "done", Lang: "English", Skipped: true, EditList: edits}))
+
+	got, err := repo.Get("run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "input text", got.Request.InputText)
+	assert.Equal(t, "English", got.Request.InputLanguageID)
+	assert.Equal(t, [][]string{{"proofread"}, {"summarize.short"}}, got.Plan)
+	require.Len(t, got.Groups, 2)
+	assert.Equal(t, CheckpointGroup{Index: 0, Text: "fixed", Lang: "English", Inferences: 2, Warning: warning}, got.Groups[0])
+	assert.Equal(t, CheckpointGroup{Index: 1, Text: "done", Lang: "English", Skipped: true, EditList: edits}, got.Groups[1])
+}
+
+func TestSqliteCheckpointRepository_SaveGroup_ReplacesSameIndex(t *testing.T) {
+	t.Parallel()
+	repo := newCheckpointRepo(t)
+
+	require.NoError(t, repo.Save(testCheckpoint("run-1"), 10))
+	require.NoError(t, repo.SaveGroup("run-1", CheckpointGroup{Index: 0, Text: "first"}))
+	require.NoError(t, repo.SaveGroup("run-1", CheckpointGroup{Index: 0, Text: "second"}))
+
+	got, err := repo.Get("run-1")
+	require.NoError(t, err)
+	require.Len(t, got.Groups, 1)
+	assert.Equal(t, "second", got.Groups[0].Text)
+}
+
+func TestSqliteCheckpointRepository_Save_RestartsRun(t *testing.T) {
+	t.Parallel()
+	repo := newCheckpointRepo(t)
+
+	require.NoError(t, repo.Save(testCheckpoint("run-1"), 10))
+	require.NoError(t, repo.SaveGroup("run-1", CheckpointGroup{Index: 0, Text: "old"}))
+	require.NoError(t, repo.Finish("run-1", 1500))
+
+	restarted := testCheckpoint("run-1")
+	restarted.Request.InputText = "new input"
+	require.NoError(t, repo.Save(restarted, 10))
+
+	got, err := repo.Get("run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "new input", got.Request.InputText)
+	assert.Empty(t, got.Groups, "groups of the earlier run are dropped")
+	assert.Zero(t, got.DurationMs)
+}
+
+func TestSqliteCheckpointRepository_Finish_AddsDuration(t *testing.T) {
+	t.Parallel()
+	repo := newCheckpointRepo(t)
+
+	require.NoError(t, repo.Save(testCheckpoint("run-1"), 10))
+	require.NoError(t, repo.Finish("run-1", 1200))
+	require.NoError(t, repo.Finish("run-1", 300))
+
+	got, err := repo.Get("run-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), got.DurationMs)
+}
+
+func TestSqliteCheckpointRepository_Delete(t *testing.T) {
+	t.Parallel()
+	repo := newCheckpointRepo(t)
+
+	require.NoError(t, repo.Save(testCheckpoint("run-1"), 10))
+	require.NoError(t, repo.SaveGroup("run-1", CheckpointGroup{Index: 0, Text: "fixed"}))
+	require.NoError(t, repo.Delete("run-1"))
+	require.NoError(t, repo.Delete("run-1"), "deleting an unknown run is a no-op")
+
+	_, err := repo.Get("run-1")
+	var ae *apperr.AppError
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, apperr.CodeValidation, ae.Code)
+	assert.Equal(t, "runId", ae.Details["field"])
+
+	// The groups went with their run, so a new run under the ID starts clean.
+	require.NoError(t, repo.Save(testCheckpoint("run-1"), 10))
+	got, err := repo.Get("run-1")
+	require.NoError(t, err)
+	assert.Empty(t, got.Groups)
+}
+
+func TestSqliteCheckpointRepository_Save_PrunesOldest(t *testing.T) {
+	t.Parallel()
+	repo := newCheckpointRepo(t)
+
+	for i := range 4 {
+		require.NoError(t, repo.Save(testCheckpoint(fmt.Sprintf("run-%d", i)), 3))
+		// Saves within the same second tie on updated_at; order them explicitly.
+		_, err := repo.database.DB.Exec(`UPDATE chain_checkpoints SET updated_at = ? WHERE run_id = ?`, i, fmt.Sprintf("run-%d", i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.Save(testCheckpoint("run-4"), 3))
+
+	for i, want := range []bool{false, false, true, true, true} {
+		_, err := repo.Get(fmt.Sprintf("run-%d", i))
+		assert.Equal(t, want, err == nil, "run-%d kept", i)
+	}
+}
+
+func TestSqliteCheckpointRepository_PruneOlderThan(t *testing.T) {
+	t.Parallel()
+	repo := newCheckpointRepo(t)
+
+	for i := range 3 {
+		require.NoError(t, repo.Save(testCheckpoint(fmt.Sprintf("run-%d", i)), 10))
+		_, err := repo.database.DB.Exec(`UPDATE chain_checkpoints SET updated_at = ? WHERE run_id = ?`, 100*(i+1), fmt.Sprintf("run-%d", i))
+		require.NoError(t, err)
+	}
+
+	n, err := repo.PruneOlderThan(250)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	for i, want := range []bool{false, false, true} {
+		_, err := repo.Get(fmt.Sprintf("run-%d", i))
+		assert.Equal(t, want, err == nil, "run-%d kept", i)
+	}
+}
@@ -0,0 +1,302 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/gate"
+	"go_text/internal/llms"
+	"go_text/internal/logging"
+	"go_text/internal/prompts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"resty.dev/v3"
+)
+
+// flakyServer answers completions with "out-1", "out-2", … in call order, except
+// while *failing is set, when it answers HTTP 500 without counting the call.
+func flakyServer(t *testing.T, failing *atomic.Bool, calls *int64) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":{"message":"server error","type":"server_error"}}`))
+			return
+		}
+		n := atomic.AddInt64(calls, 1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"role": "assistant", "content": fmt.Sprintf("out-%d", n)}},
+			},
+		})
+	}))
+}
+
+// newResumableChainService wires a real ActionService to serverURL with a
+// SQLite checkpoint repository and a recording history service.
+func newResumableChainService(t *testing.T, serverURL string, hist *recordingHistoryService) (*ActionService, *SqliteCheckpointRepository) {
+	t.Helper()
+	wlog, err := logging.New(logging.DefaultConfig(), false)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	settingsSvc := &orchestratorSettings{cfg: testSettingsCfg(serverURL)}
+	factory := llms.NewProviderFactory(resty.New().SetTimeout(10 * time.Second))
+	llmSvc := llms.NewLLMApiService(wlog, factory, settingsSvc)
+	svc := NewActionService(wlog, prompts.NewPromptService(wlog), llmSvc, settingsSvc, &noopTaskLog{}, hist)
+	repo := newCheckpointRepo(t)
+	svc.SetCheckpointRepository(repo)
+	return svc, repo
+}
+
+// failSecondGroup runs a two-group chain as runID against a server that fails
+// from the second group on, and returns the failed run's result.
+func failSecondGroup(t *testing.T, svc *ActionService, failing *atomic.Bool, runID string) *apperr.ChainResult {
+	t.Helper()
+	id0, id1 := twoFamilySteps(t, svc)
+	var once atomic.Bool
+	result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:     runID,
+		InputText: "original",
+		Steps:     []apperr.ChainStep{{ActionID: id0}, {ActionID: id1}},
+	}, func(p apperr.StepProgress) {
+		if p.Status == "done" && once.CompareAndSwap(false, true) {
+			failing.Store(true)
+		}
+	})
+	var ae *apperr.AppError
+	require.True(t, errors.As(err, &ae))
+	require.Equal(t, apperr.CodeStepFailed, ae.Code)
+	require.NotNil(t, result.FailedIndex)
+	require.Equal(t, 1, *result.FailedIndex)
+	return result
+}
+
+func TestActionService_ResumeChain_ContinuesFromFailedGroup(t *testing.T) {
+	t.Parallel()
+	var failing atomic.Bool
+	var calls int64
+	srv := flakyServer(t, &failing, &calls)
+	defer srv.Close()
+
+	hist := &recordingHistoryService{}
+	svc, repo := newResumableChainService(t, srv.URL, hist)
+	failSecondGroup(t, svc, &failing, "run-resume")
+
+	cp, err := repo.Get("run-resume")
+	require.NoError(t, err)
+	require.Len(t, cp.Groups, 1)
+	assert.Equal(t, "out-1", cp.Groups[0].Text)
+
+	failing.Store(false)
+	var progress []apperr.StepProgress
+	result, err := svc.ResumeChain(context.Background(), "run-resume", func(p apperr.StepProgress) {
+		progress = append(progress, p)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "out-2", result.FinalText, "group 1 runs on the stored output of group 0")
+	assert.Equal(t, 2, result.Completed)
+	assert.Nil(t, result.FailedIndex)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&calls), "group 0 is not run again")
+
+	require.Len(t, progress, 2)
+	assert.Equal(t, 1, progress[0].GroupIndex)
+	assert.Equal(t, 2, progress[0].TotalGroups)
+	assert.Equal(t, "run-resume", progress[0].RunID)
+
+	require.Len(t, hist.recorded, 2)
+	for _, e := range hist.recorded {
+		assert.Equal(t, "run-resume", e.ID, "both passes record the same entry")
+	}
+	assert.Equal(t, "partial", hist.recorded[0].Status)
+	resumed := hist.recorded[1]
+	assert.Equal(t, "success", resumed.Status)
+	assert.Equal(t, "original", resumed.InputText)
+	assert.Equal(t, "out-2", resumed.OutputText)
+	assert.Equal(t, 2, resumed.Inferences)
+	assert.Equal(t, -1, resumed.FailedIndex)
+	assert.Len(t, resumed.Applied, 2)
+
+	_, err = repo.Get("run-resume")
+	assert.Error(t, err, "a finished run leaves no checkpoint")
+}
+
+func TestActionService_ResumeChain_FailsAgainAndKeepsCheckpoint(t *testing.T) {
+	t.Parallel()
+	var failing atomic.Bool
+	var calls int64
+	srv := flakyServer(t, &failing, &calls)
+	defer srv.Close()
+
+	svc, repo := newResumableChainService(t, srv.URL, &recordingHistoryService{})
+	failSecondGroup(t, svc, &failing, "run-again")
+
+	result, err := svc.ResumeChain(context.Background(), "run-again", nil)
+	var ae *apperr.AppError
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, apperr.CodeStepFailed, ae.Code)
+	require.NotNil(t, result)
+	assert.Equal(t, "out-1", result.FinalText)
+	assert.Equal(t, 1, result.Completed)
+	require.NotNil(t, result.FailedIndex)
+	assert.Equal(t, 1, *result.FailedIndex)
+
+	cp, err := repo.Get("run-again")
+	require.NoError(t, err, "the run stays resumable")
+	assert.Len(t, cp.Groups, 1)
+}
+
+func TestActionService_ResumeChain_AfterCancel(t *testing.T) {
+	t.Parallel()
+	var failing atomic.Bool
+	var calls int64
+	srv := flakyServer(t, &failing, &calls)
+	defer srv.Close()
+
+	svc, _ := newResumableChainService(t, srv.URL, &recordingHistoryService{})
+	id0, id1 := twoFamilySteps(t, svc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := svc.RunChain(ctx, apperr.ChainRequest{
+		RunID:     "run-cancel",
+		InputText: "original",
+		Steps:     []apperr.ChainStep{{ActionID: id0}, {ActionID: id1}},
+	}, func(p apperr.StepProgress) {
+		if p.Status == "done" {
+			cancel()
+		}
+	})
+	var ae *apperr.AppError
+	require.True(t, errors.As(err, &ae))
+	require.Equal(t, apperr.CodeCancelled, ae.Code)
+
+	result, err := svc.ResumeChain(context.Background(), "run-cancel", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "out-2", result.FinalText)
+	assert.Equal(t, 2, result.Completed)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&calls))
+}
+
+func TestActionService_ResumeChain_Errors(t *testing.T) {
+	t.Parallel()
+	svc, repo := newResumableChainService(t, "http://unused", &recordingHistoryService{})
+	id0, id1 := twoFamilySteps(t, svc)
+
+	changed := testCheckpoint("run-changed")
+	changed.Request.Steps = []apperr.ChainStep{{ActionID: id0}, {ActionID: id1}}
+	changed.Plan = [][]string{{"retired.action"}, {id1}}
+	require.NoError(t, repo.Save(changed, maxCheckpoints))
+
+	tests := []struct {
+		name  string
+		runID string
+	}{
+		{"empty run ID", ""},
+		{"unknown run", "never-ran"},
+		{"plan changed since the run", "run-changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.ResumeChain(context.Background(), tt.runID, nil)
+			assert.Nil(t, result)
+			var ae *apperr.AppError
+			require.True(t, errors.As(err, &ae))
+			assert.Equal(t, apperr.CodeValidation, ae.Code)
+			assert.Equal(t, "runId", ae.Details["field"])
+		})
+	}
+}
+
+func TestActionService_ResumeChain_NoRepository(t *testing.T) {
+	t.Parallel()
+	svc, _ := newResumableChainService(t, "http://unused", &recordingHistoryService{})
+	svc.SetCheckpointRepository(nil)
+
+	_, err := svc.ResumeChain(context.Background(), "run-1", nil)
+	var ae *apperr.AppError
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, apperr.CodeInternal, ae.Code)
+}
+
+func TestRunChain_Checkpoints(t *testing.T) {
+	t.Parallel()
+	srv := completionServerFor(t, []string{"done"})
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		req  apperr.ChainRequest
+	}{
+		{"succeeded run is dropped", apperr.ChainRequest{RunID: "run-ok", InputText: "text"}},
+		{"run kept out of history", apperr.ChainRequest{RunID: "run-skip", InputText: "text", SkipHistory: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newResumableChainService(t, srv.URL, &recordingHistoryService{})
+			tt.req.Steps = []apperr.ChainStep{{ActionID: oneFamilyStep(t, svc)}}
+			_, err := svc.RunChain(context.Background(), tt.req, nil)
+			require.NoError(t, err)
+			_, err = repo.Get(tt.req.RunID)
+			assert.Error(t, err, "no checkpoint left")
+		})
+	}
+}
+
+func TestActionHandler_ResumeChain(t *testing.T) {
+	t.Parallel()
+	var failing atomic.Bool
+	var calls int64
+	srv := flakyServer(t, &failing, &calls)
+	defer srv.Close()
+
+	svc, _ := newResumableChainService(t, srv.URL, &recordingHistoryService{})
+	failSecondGroup(t, svc, &failing, "h-resume")
+	failing.Store(false)
+
+	h := NewActionHandler(nil, svc, &mockVerificationService{}, gate.New())
+	res := h.ResumeChain("h-resume")
+	require.Nil(t, res.Error)
+	require.NotNil(t, res.Data)
+	assert.Equal(t, "out-2", res.Data.FinalText)
+
+	res = h.ResumeChain("h-resume")
+	require.NotNil(t, res.Error, "a finished run cannot be resumed")
+	assert.Equal(t, apperr.CodeValidation, res.Error.Code)
+}
+
+func TestActionHandler_ResumeChain_Errors(t *testing.T) {
+	t.Parallel()
+	held := gate.New()
+	require.True(t, held.TryAcquire())
+
+	tests := []struct {
+		name     string
+		svc      ActionServiceAPI
+		gate     *gate.InferenceGate
+		runID    string
+		wantCode apperr.ErrorCode
+	}{
+		{"empty run ID", &mockActionService{}, gate.New(), "", apperr.CodeValidation},
+		{"busy", &mockActionService{}, held, "run-1", apperr.CodeBusy},
+		{"panic", &panicActionService{}, gate.New(), "run-1", apperr.CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewActionHandler(nil, tt.svc, &mockVerificationService{}, tt.gate)
+			res := h.ResumeChain(tt.runID)
+			assert.Nil(t, res.Data)
+			require.NotNil(t, res.Error)
+			assert.Equal(t, tt.wantCode, res.Error.Code)
+		})
+	}
+}
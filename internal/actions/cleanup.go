@@ -0,0 +1,233 @@
+package actions
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	v3 "go_text/internal/prompts/v3"
+	"go_text/internal/tasklog"
+)
+
+// Rules reported on tasklog.StrippedText.
+const (
+	stripPreamble  = "preamble"
+	stripLabel     = "label"
+	stripPostamble = "postamble"
+	stripCodeFence = "code-fence"
+	stripQuotes    = "wrapping-quotes"
+)
+
+const (
+	// maxChatterRunes bounds a preamble line or postamble paragraph; anything
+	// longer is treated as content, whatever it starts with.
+	maxChatterRunes = 300
+	fence           = "```"
+)
+
+var (
+	// preambleRe matches an opening line that announces the result by name:
+	// "Sure! Here's the rewritten text:", "Below is the summary:". An intro that
+	// names anything else ("Here are the key points:") is content.
+	preambleRe = regexp.MustCompile(`(?i)^(?:(?:sure|certainly|of course|absolutely|okay|ok|alright|great)\b[!.,]?\s*)?` +
+		`(?:here(?:'s|’s| is| are)|below (?:is|are)|the following (?:is|are))\s+(?:(?:the|your|a|an|my)\s+)?(?:[\w-]+\s+){0,2}` +
+		`(?:text|version|translation|summary|rewrite|result|output|prompt|revision|draft)s?\b[^:]*:$`)
+	// ackRe matches an opening line that only acknowledges the request: "Sure!".
+	ackRe = regexp.MustCompile(`(?i)^(?:sure|certainly|of course|absolutely|okay|ok|alright)(?:[!.,]?\s*(?:here you go|no problem))?[!.]?$`)
+	// postambleRe matches a closing paragraph in which the model comments on its
+	// own work: "Note: ...", "I have corrected the spelling.". Offers of help
+	// ("Let me know if ...") are left alone, as they are often part of the text.
+	postambleRe = regexp.MustCompile(`(?i)^\(?(?:notes?\s*:|(?:i|i've|i have)(?: also)? ` +
+		`(?:made|corrected|fixed|kept|preserved|changed|translated|rewritten|adjusted|removed|replaced)\b)`)
+	// fenceRe matches output wrapped as a whole in one fenced block.
+	fenceRe = regexp.MustCompile("(?s)^(```[\\w+#.-]*)[ \\t]*\\n(.*?)\\n?```$")
+)
+
+// familyLabels are answer labels a family's output sometimes opens with on a
+// line of their own ("Translation:"). Structure outputs are left alone: their
+// headings and labels are usually the requested content.
+var familyLabels = map[string][]string{
+	v3.FamilyRewrite:   {"rewritten text", "revised text", "corrected text", "proofread text", "edited text"},
+	v3.FamilySummarize: {"summary"},
+	v3.FamilyTranslate: {"translation", "translated text"},
+	v3.FamilyPromptEng: {"prompt", "improved prompt", "optimized prompt"},
+}
+
+// emailActions are actions whose output is a message to a reader, where opening
+// and closing lines are part of the text.
+var emailActions = map[string]bool{
+	"structure.doc.email":       true,
+	"rewrite.style.semi-formal": true,
+	"rewrite.style.support":     true,
+}
+
+// wrappingQuotes are the opening and closing quote pairs unwrapQuotes removes.
+var wrappingQuotes = [][2]rune{{'"', '"'}, {'“', '”'}, {'„', '“'}, {'«', '»'}, {'‘', '’'}, {'\'', '\''}}
+
+// cleanOutput strips the chatter small models add around a result despite
+// userGuardrailSuffix, for a group of family running actionIDs on input: opening
+// preamble and label lines, a closing postamble paragraph, a fence wrapping the
+// whole output (kept when the input is code) and wrapping quotes (kept when the
+// input is quoted the same way). Opening and closing lines are left alone for
+// structure outputs and email-style actions, and kept whenever input has an
+// equivalent one. The output is never reduced to nothing. It returns the
+// cleaned text and what was removed, in order.
+func cleanOutput(out, input, family string, actionIDs []string) (string, []tasklog.StrippedText) {
+	text := strings.TrimSpace(out)
+	var stripped []tasklog.StrippedText
+	strip := func(rule, removed, rest string) {
+		stripped = append(stripped, tasklog.StrippedText{Rule: rule, Text: removed})
+		text = strings.TrimSpace(rest)
+	}
+
+	if chatterRulesApply(family, actionIDs) {
+		for {
+			first, rest, ok := strings.Cut(text, "\n")
+			line := strings.TrimSpace(first)
+			if !ok || strings.TrimSpace(rest) == "" || containsEquivalent(input, line) {
+				break
+			}
+			rule := openingRule(line, family)
+			if rule == "" {
+				break
+			}
+			strip(rule, line, rest)
+		}
+
+		if i := strings.LastIndex(text, "\n\n"); i >= 0 {
+			last := strings.TrimSpace(text[i:])
+			if utf8.RuneCountInString(last) <= maxChatterRunes && postambleRe.MatchString(last) && !hasClosing(input, last) {
+				strip(stripPostamble, last, text[:i])
+			}
+		}
+	}
+
+	if !looksLikeCode(input) {
+		if m := fenceRe.FindStringSubmatch(text); m != nil && !strings.Contains(m[2], fence) && strings.TrimSpace(m[2]) != "" {
+			strip(stripCodeFence, m[1], m[2])
+		}
+	}
+
+	if inner, pair, ok := unwrapQuotes(text); ok && strings.TrimSpace(inner) != "" {
+		if _, inputPair, quoted := unwrapQuotes(strings.TrimSpace(input)); !quoted || inputPair != pair {
+			strip(stripQuotes, string(pair[:]), inner)
+		}
+	}
+	return text, stripped
+}
+
+// chatterRulesApply reports whether opening and closing lines of a group's
+// output may be stripped: not for structure outputs, whose intro and closing
+// lines are usually requested content, nor for email-style actions.
+func chatterRulesApply(family string, actionIDs []string) bool {
+	if family == v3.FamilyStructure {
+		return false
+	}
+	for _, id := range actionIDs {
+		if emailActions[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsEquivalent reports whether input contains line, ignoring case,
+// punctuation and spacing.
+func containsEquivalent(input, line string) bool {
+	want := normalizeLine(line)
+	return want != "" && strings.Contains(normalizeLine(input), want)
+}
+
+// hasClosing reports whether input has a closing equivalent to last: the same
+// words, or a closing paragraph of its own that postambleRe matches.
+func hasClosing(input, last string) bool {
+	if containsEquivalent(input, last) {
+		return true
+	}
+	input = strings.TrimSpace(input)
+	if i := strings.LastIndex(input, "\n\n"); i >= 0 {
+		return postambleRe.MatchString(strings.TrimSpace(input[i:]))
+	}
+	return false
+}
+
+// normalizeLine lowercases s and reduces every run of non-alphanumeric runes to
+// one space.
+func normalizeLine(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		space = true
+	}
+	return b.String()
+}
+
+// openingRule returns the rule an opening line of a family's output matches, or "".
+func openingRule(line, family string) string {
+	if utf8.RuneCountInString(line) > maxChatterRunes {
+		return ""
+	}
+	if preambleRe.MatchString(line) || ackRe.MatchString(line) {
+		return stripPreamble
+	}
+	label := strings.ToLower(strings.Trim(line, "#* \t"))
+	label, ok := strings.CutSuffix(label, ":")
+	if !ok {
+		return ""
+	}
+	for _, l := range familyLabels[family] {
+		if strings.TrimRight(label, "* ") == l {
+			return stripLabel
+		}
+	}
+	return ""
+}
+
+// unwrapQuotes returns text without the quote pair wrapping it as a whole. A text
+// that merely starts and ends with quoted parts ("a" and "b") is not wrapped.
+func unwrapQuotes(text string) (string, [2]rune, bool) {
+	runes := []rune(text)
+	if len(runes) < 2 {
+		return "", [2]rune{}, false
+	}
+	for _, pair := range wrappingQuotes {
+		if runes[0] != pair[0] || runes[len(runes)-1] != pair[1] {
+			continue
+		}
+		inner := string(runes[1 : len(runes)-1])
+		if strings.ContainsRune(inner, pair[0]) || strings.ContainsRune(inner, pair[1]) {
+			return "", [2]rune{}, false
+		}
+		return inner, pair, true
+	}
+	return "", [2]rune{}, false
+}
+
+// looksLikeCode reports whether input is source code, whose fences are content:
+// it contains a fence itself, or most of its lines end like statements or blocks.
+func looksLikeCode(input string) bool {
+	if strings.Contains(input, fence) {
+		return true
+	}
+	lines, code := 0, 0
+	for _, l := range strings.Split(input, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		lines++
+		if strings.HasSuffix(l, ";") || strings.HasSuffix(l, "{") || strings.HasSuffix(l, "}") || strings.HasPrefix(l, "//") {
+			code++
+		}
+	}
+	return lines >= 2 && code*2 >= lines
+}
@@ -0,0 +1,202 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go_text/internal/tasklog"
+)
+
+func TestCleanOutput(t *testing.T) {
+	const input = "the quick brown fox jumps over the lazy dog"
+
+	tests := []struct {
+		name     string
+		out      string
+		input    string
+		family   string
+		actions  []string
+		want     string
+		wantRule []string
+	}{
+		{
+			name:     "preamble line",
+			out:      "Sure! Here's the rewritten text:\n\nThe quick brown fox jumped over the lazy dog.",
+			family:   "rewrite",
+			want:     "The quick brown fox jumped over the lazy dog.",
+			wantRule: []string{stripPreamble},
+		},
+		{
+			name:     "acknowledgement then preamble",
+			out:      "Certainly.\nBelow is the summary:\nA fox jumps over a dog.",
+			family:   "summarize",
+			want:     "A fox jumps over a dog.",
+			wantRule: []string{stripPreamble, stripPreamble},
+		},
+		{
+			name:     "family label",
+			out:      "**Translation:**\nDer schnelle braune Fuchs.",
+			family:   "translate",
+			want:     "Der schnelle braune Fuchs.",
+			wantRule: []string{stripLabel},
+		},
+		{
+			name:   "label of another family kept",
+			out:    "Summary:\n- fox\n- dog",
+			family: "structure",
+			want:   "Summary:\n- fox\n- dog",
+		},
+		{
+			name:     "postamble paragraph",
+			out:      "The quick brown fox jumped.\n\nNote: I changed the verb to the past tense.",
+			family:   "rewrite",
+			want:     "The quick brown fox jumped.",
+			wantRule: []string{stripPostamble},
+		},
+		{
+			name:     "meta-commentary postamble",
+			out:      "The quick brown fox jumped.\n\nI have corrected the tense and kept the wording.",
+			family:   "rewrite",
+			want:     "The quick brown fox jumped.",
+			wantRule: []string{stripPostamble},
+		},
+		{
+			name:   "offer of help kept in an email rewrite",
+			out:    "Hi Ana,\n\nThe report is attached.\n\nLet me know if you have any questions.",
+			input:  "hi ana, report attached. any questions just ask",
+			family: "rewrite",
+			want:   "Hi Ana,\n\nThe report is attached.\n\nLet me know if you have any questions.",
+		},
+		{
+			name:   "closing kept in structure output",
+			out:    "# Plan\n\n- Monday: kickoff\n\nI hope this helps everyone plan.",
+			family: "structure",
+			want:   "# Plan\n\n- Monday: kickoff\n\nI hope this helps everyone plan.",
+		},
+		{
+			name:   "note kept in structure output",
+			out:    "# Plan\n\n- Monday: kickoff\n\nNote: dates may change.",
+			family: "structure",
+			want:   "# Plan\n\n- Monday: kickoff\n\nNote: dates may change.",
+		},
+		{
+			name:   "intro line naming the content kept",
+			out:    "Here are the key points:\n- a\n- b",
+			family: "summarize",
+			want:   "Here are the key points:\n- a\n- b",
+		},
+		{
+			name:   "intro line kept in structure output",
+			out:    "Here is the summary:\n- a\n- b",
+			family: "structure",
+			want:   "Here is the summary:\n- a\n- b",
+		},
+		{
+			name:   "closing kept when the input has an equivalent one",
+			out:    "The fox jumped.\n\nNote: the dog was asleep.",
+			input:  "the fox jump\n\nnote - The dog was asleep!",
+			family: "rewrite",
+			want:   "The fox jumped.\n\nNote: the dog was asleep.",
+		},
+		{
+			name:   "closing kept when the input has a note of its own",
+			out:    "Der Fuchs sprang.\n\nNote: the dog was asleep.",
+			input:  "The fox jumped.\n\nNote: the dog slept.",
+			family: "translate",
+			want:   "Der Fuchs sprang.\n\nNote: the dog was asleep.",
+		},
+		{
+			name:     "whole output fenced",
+			out:      "```markdown\n# Fox\n\nThe fox jumps.\n```",
+			family:   "structure",
+			want:     "# Fox\n\nThe fox jumps.",
+			wantRule: []string{stripCodeFence},
+		},
+		{
+			name:   "fence kept for code input",
+			out:    "```go\nfunc main() {}\n```",
+			input:  "func main() {\n\tprintln(1);\n}",
+			family: "rewrite",
+			want:   "```go\nfunc main() {}\n```",
+		},
+		{
+			name:     "preamble, fence and postamble together",
+			out:      "Here is the result:\n```\nThe fox jumps.\n```\n\nI have fixed the verb.",
+			family:   "rewrite",
+			want:     "The fox jumps.",
+			wantRule: []string{stripPreamble, stripPostamble, stripCodeFence},
+		},
+		{
+			name:     "wrapping quotes",
+			out:      "“The quick brown fox jumped.”",
+			family:   "rewrite",
+			want:     "The quick brown fox jumped.",
+			wantRule: []string{stripQuotes},
+		},
+		{
+			name:   "quotes kept when the input is quoted",
+			out:    `"A fox jumped."`,
+			input:  `"the fox jumps"`,
+			family: "rewrite",
+			want:   `"A fox jumped."`,
+		},
+		{
+			name:   "separate quoted parts kept",
+			out:    `"Fox" and "dog"`,
+			family: "rewrite",
+			want:   `"Fox" and "dog"`,
+		},
+		{
+			name:   "opening line from the input kept",
+			out:    "Here is the plan:\nbuy milk",
+			input:  "Here is the plan:\nbuy mlik",
+			family: "rewrite",
+			want:   "Here is the plan:\nbuy milk",
+		},
+		{
+			name:   "opening line equivalent to one in the input kept",
+			out:    "Here's the translation:\nDer Fuchs.",
+			input:  "HERE’S THE TRANSLATION -\nThe fox.",
+			family: "translate",
+			want:   "Here's the translation:\nDer Fuchs.",
+		},
+		{
+			name:   "single-line output never emptied",
+			out:    "Here is the text:",
+			family: "rewrite",
+			want:   "Here is the text:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := tt.input
+			if in == "" {
+				in = input
+			}
+			got, stripped := cleanOutput(tt.out, in, tt.family, tt.actions)
+			assert.Equal(t, tt.want, got)
+			var rules []string
+			for _, s := range stripped {
+				rules = append(rules, s.Rule)
+			}
+			assert.Equal(t, tt.wantRule, rules)
+		})
+	}
+}
+
+func TestCleanOutput_RecordsStrippedText(t *testing.T) {
+	_, stripped := cleanOutput("Sure! Here's the text:\n```\nfox\n```", "fox", "rewrite", nil)
+	assert.Equal(t, []tasklog.StrippedText{
+		{Rule: stripPreamble, Text: "Sure! Here's the text:"},
+		{Rule: stripCodeFence, Text: "```"},
+	}, stripped)
+}
+
+func TestCleanOutput_EmailActionsKeepChatterLines(t *testing.T) {
+	const out = "Here is the draft:\nHi Ana,\n\nThe report is attached.\n\nNote: I will be out on Friday."
+	got, stripped := cleanOutput(out, "report attached, out friday", "rewrite", []string{"rewrite.proofread.basic", "rewrite.style.support"})
+	assert.Equal(t, out, got)
+	assert.Empty(t, stripped)
+}
@@ -25,6 +25,19 @@ const userGuardrailSuffix = "\n\nReminder: reply with only the requested result
 	"type inherently requires labeled sections (e.g. a translation table, an FAQ, or a " +
 	"negative-prompt/settings block)."
 
+// editListSuffix replaces userGuardrailSuffix for an edit-list group: the model
+// lists its corrections instead of returning the corrected text. See parseEditList.
+const editListSuffix = "\n\nDo not return the corrected text. Reply with only a JSON object of the form " +
+	`{"edits":[{"original":"...","replacement":"...","category":"...","explanation":"..."}]}` +
+	", listing every correction in the order it occurs in the text. \"original\" is copied exactly from the " +
+	"text, including punctuation and spacing, and is just long enough to locate the correction; \"replacement\" " +
+	"is the text that replaces it. \"category\" is one of grammar, spelling, punctuation, capitalization, style, " +
+	"clarity or consistency, and \"explanation\" briefly says why. If nothing needs correcting, reply with {\"edits\":[]}."
+
+// languageReminderFmt is appended to the user prompt when a group's output came
+// back in the wrong language and the group is retried once.
+const languageReminderFmt = "\n\nImportant: write the entire result in %s. Do not answer in any other language."
+
 // Composer builds the two-tier (system + user) prompt for one inference group.
 type Composer struct {
 	catalog map[string]apperr.ActionMeta
@@ -39,10 +52,16 @@ func NewComposer(catalog []apperr.ActionMeta) *Composer {
 	return &Composer{catalog: m}
 }
 
-// Compose returns the (system, user) prompt pair for one inference group.
+// Compose returns the (system, user) prompt pair for one inference group. For a
+// selection-scoped request inputText is the selection, and the text around it
+// follows as read-only context; see selectionContextBlock.
 func (c *Composer) Compose(g Group, inputText string, req apperr.ChainRequest, useMarkdown bool) (system, user string) {
 	system = c.systemPrompt(g)
-	user = c.userPrompt(g, inputText, req, useMarkdown) + userGuardrailSuffix
+	suffix := userGuardrailSuffix
+	if g.editList() {
+		suffix = editListSuffix
+	}
+	user = c.userPrompt(g, inputText, req, useMarkdown) + selectionContextBlock(req) + suffix
 	return
 }
 
@@ -101,13 +120,11 @@ func (c *Composer) userPrompt(g Group, inputText string, req apperr.ChainRequest
 func (c *Composer) rewriteUserPrompt(g Group, inputText, format string) string {
 	var sb strings.Builder
 	if len(g.Steps) == 1 {
-		meta := c.catalog[g.Steps[0].ActionID]
-		sb.WriteString(meta.Directive)
+		sb.WriteString(c.directive(g.Steps[0]))
 	} else {
 		sb.WriteString("Apply the following edits to the text in order:")
 		for i, s := range g.Steps {
-			meta := c.catalog[s.ActionID]
-			fmt.Fprintf(&sb, "\n%d) %s", i+1, meta.Directive)
+			fmt.Fprintf(&sb, "\n%d) %s", i+1, c.directive(s))
 		}
 	}
 	fmt.Fprintf(&sb, "\n\n"+userTextBlock+"\n\nFormat: %s", inputText, format)
@@ -124,8 +141,7 @@ func (c *Composer) structureUserPrompt(g Group, inputText, format string) string
 	var sb strings.Builder
 	sb.WriteString("Apply the following formatting operations to the text in order:")
 	for i, s := range g.Steps {
-		meta := c.catalog[s.ActionID]
-		instruction := extractInstructionPart(meta.Directive)
+		instruction := extractInstructionPart(c.directive(s))
 		fmt.Fprintf(&sb, "\n\n%d) %s", i+1, instruction)
 	}
 	fmt.Fprintf(&sb, "\n\n"+userTextBlock+"\n\nFormat: %s", inputText, format)
@@ -134,16 +150,26 @@ func (c *Composer) structureUserPrompt(g Group, inputText, format string) string
 
 // singleStepUserPrompt does direct token replacement on a directive template.
 // Used for Structure doc, Summarize, Translate, PromptEng (always single-step).
+// Parameters and runtime tokens are substituted in one pass, so neither user
+// text nor a parameter value is ever scanned for tokens.
 func (c *Composer) singleStepUserPrompt(s apperr.ChainStep, inputText string, req apperr.ChainRequest, format string) string {
 	meta := c.catalog[s.ActionID]
-	d := meta.Directive
-	d = strings.ReplaceAll(d, tokenUserText, inputText)
-	d = strings.ReplaceAll(d, tokenUserFormat, format)
-	d = strings.ReplaceAll(d, tokenInputLang, req.InputLanguageID)
-	d = strings.ReplaceAll(d, tokenOutputLang, req.OutputLanguageID)
-	d = strings.ReplaceAll(d, tokenTargetModel, s.TargetModel)
-	d = strings.ReplaceAll(d, tokenGoal, s.Goal)
-	return d
+	pairs := append(paramReplacements(meta, s),
+		tokenUserText, inputText,
+		tokenUserFormat, format,
+		tokenInputLang, req.InputLanguageID,
+		tokenOutputLang, req.OutputLanguageID,
+		tokenTargetModel, s.TargetModel,
+		tokenGoal, s.Goal,
+	)
+	return strings.NewReplacer(pairs...).Replace(meta.Directive)
+}
+
+// directive returns the step's directive template with its declared {{param}}
+// tokens resolved, for groups that append the user text themselves.
+func (c *Composer) directive(s apperr.ChainStep) string {
+	meta := c.catalog[s.ActionID]
+	return applyParams(meta.Directive, meta, s)
 }
 
 // extractInstructionPart strips the embedded context block from a Structure format
@@ -269,3 +269,91 @@ func TestComposer_FormatMarkdown(t *testing.T) {
 		t.Error("useMarkdown=false should set format to PlainText")
 	}
 }
+
+func TestComposer_SubstitutesDeclaredParams(t *testing.T) {
+	catalog := []apperr.ActionMeta{
+		{
+			ID: "summarize.summary", Family: v3.FamilySummarize,
+			Directive: "Task: Summarize in about {{word_count}} words.\n\n<<<UserText Start>>>\n{{user_text}}\n<<<UserText End>>>\n\nFormat: {{user_format}}",
+			Terminal:  true,
+			Params:    []apperr.ParamSpec{{Name: "word_count", Type: v3.ParamInt, Default: "120"}},
+		},
+		{
+			ID: "rewrite.tone.professional", Family: v3.FamilyRewrite,
+			Directive: "Address the reader as {{audience}}.",
+			Mergeable: true,
+			Params:    []apperr.ParamSpec{{Name: "audience", Type: v3.ParamString, Default: "a colleague"}},
+		},
+	}
+	c := NewComposer(catalog)
+
+	tests := []struct {
+		name    string
+		group   Group
+		input   string
+		want    string
+		notWant string
+	}{
+		{
+			name:    "default applied when unset",
+			group:   groupOf(v3.FamilySummarize, "summarize.summary"),
+			input:   "text",
+			want:    "about 120 words",
+			notWant: "{{word_count}}",
+		},
+		{
+			name: "step value overrides default",
+			group: Group{Family: v3.FamilySummarize, Steps: []apperr.ChainStep{
+				{ActionID: "summarize.summary", Params: map[string]string{"word_count": "40"}},
+			}},
+			input: "text",
+			want:  "about 40 words",
+		},
+		{
+			name:  "rewrite directive substituted",
+			group: groupOf(v3.FamilyRewrite, "rewrite.tone.professional"),
+			input: "text",
+			want:  "Address the reader as a colleague.",
+		},
+		{
+			name:  "tokens inside user text are left untouched",
+			group: groupOf(v3.FamilySummarize, "summarize.summary"),
+			input: "literal {{word_count}} in input",
+			want:  "literal {{word_count}} in input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, user := c.Compose(tt.group, tt.input, apperr.ChainRequest{}, false)
+			if !strings.Contains(user, tt.want) {
+				t.Errorf("user prompt should contain %q, got: %q", tt.want, user)
+			}
+			if tt.notWant != "" && strings.Contains(user, tt.notWant) {
+				t.Errorf("user prompt should not contain %q, got: %q", tt.notWant, user)
+			}
+		})
+	}
+}
+
+func TestComposer_SingleStep_NoSecondPassExpansion(t *testing.T) {
+	catalog := []apperr.ActionMeta{{
+		ID: "structure.doc.email", Family: v3.FamilyStructure,
+		Directive: "Task: Write an email to {{recipient}}.\n\n<<<UserText Start>>>\n{{user_text}}\n<<<UserText End>>>\n\nFormat: {{user_format}}",
+		Params:    []apperr.ParamSpec{{Name: "recipient", Type: v3.ParamString, Default: "the team"}},
+	}}
+	c := NewComposer(catalog)
+	group := Group{Family: v3.FamilyStructure, Steps: []apperr.ChainStep{
+		{ActionID: "structure.doc.email", Params: map[string]string{"recipient": "{{user_text}}"}},
+	}}
+
+	_, user := c.Compose(group, "secret body {{user_format}}", apperr.ChainRequest{}, false)
+	if !strings.Contains(user, "Write an email to {{user_text}}.") {
+		t.Errorf("param value was expanded: %q", user)
+	}
+	if strings.Count(user, "secret body") != 1 {
+		t.Errorf("user text injected outside its block: %q", user)
+	}
+	if !strings.Contains(user, "secret body {{user_format}}\n") {
+		t.Errorf("user text was rewritten: %q", user)
+	}
+}
@@ -0,0 +1,161 @@
+package actions
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go_text/internal/apperr"
+	"go_text/internal/prompts"
+	v3 "go_text/internal/prompts/v3"
+)
+
+// Subjects and checks accepted on apperr.StepCondition.
+const (
+	condSubjectInput    = "input"
+	condSubjectPrevious = "previous"
+
+	condMinWords    = "minWords"
+	condMaxWords    = "maxWords"
+	condMinTokens   = "minTokens"
+	condMaxTokens   = "maxTokens"
+	condLanguage    = "language"
+	condNotLanguage = "notLanguage"
+	condMatches     = "matches"
+	condNotMatches  = "notMatches"
+)
+
+// conditionProblem returns why c cannot be evaluated, or "" when it is well formed.
+func conditionProblem(c apperr.StepCondition) string {
+	switch c.Subject {
+	case "", condSubjectInput, condSubjectPrevious:
+	default:
+		return fmt.Sprintf("has unknown subject %q", c.Subject)
+	}
+	switch c.Check {
+	case condMinWords, condMaxWords, condMinTokens, condMaxTokens:
+		if n, err := strconv.Atoi(strings.TrimSpace(c.Value)); err != nil || n < 0 {
+			return fmt.Sprintf("check %q needs a non-negative count; got %q", c.Check, c.Value)
+		}
+	case condLanguage, condNotLanguage:
+		if strings.TrimSpace(c.Value) == "" {
+			return fmt.Sprintf("check %q needs a language", c.Check)
+		}
+	case condMatches, condNotMatches:
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return fmt.Sprintf("check %q has an invalid pattern: %v", c.Check, err)
+		}
+	default:
+		return fmt.Sprintf("has unknown check %q", c.Check)
+	}
+	return ""
+}
+
+// stepConditionProblem returns why one of step s's conditions is malformed, or "".
+func stepConditionProblem(s apperr.ChainStep) string {
+	for i, c := range s.When {
+		if reason := conditionProblem(c); reason != "" {
+			return fmt.Sprintf("condition %d of action %q %s", i, s.ActionID, reason)
+		}
+	}
+	return ""
+}
+
+// conditionEnv is the state step conditions are evaluated against before a group:
+// the chain input — its selection, when the run has one — and the text produced
+// so far, each with the language it is in.
+type conditionEnv struct {
+	input        string
+	inputLang    string
+	previous     string
+	previousLang string
+}
+
+// subject returns the text and language a condition with the given subject reads.
+func (e conditionEnv) subject(name string) (string, string) {
+	if name == condSubjectInput {
+		return e.input, e.inputLang
+	}
+	return e.previous, e.previousLang
+}
+
+// evalCondition reports whether c holds in env. When it does not, the returned
+// reason names the condition and the value it saw. Malformed conditions are
+// rejected by the planner, so one that slips through here simply does not hold.
+func evalCondition(c apperr.StepCondition, env conditionEnv) (bool, string) {
+	subject := c.Subject
+	if subject == "" {
+		subject = condSubjectPrevious
+	}
+	text, lang := env.subject(subject)
+
+	var ok bool
+	var got string
+	switch c.Check {
+	case condMinWords, condMaxWords, condMinTokens, condMaxTokens:
+		limit, err := strconv.Atoi(strings.TrimSpace(c.Value))
+		if err != nil {
+			return false, fmt.Sprintf("%s %s %q is not a count", subject, c.Check, c.Value)
+		}
+		n := len(strings.Fields(text))
+		if c.Check == condMinTokens || c.Check == condMaxTokens {
+			n = prompts.EstimateTokenCount(text)
+		}
+		if c.Check == condMinWords || c.Check == condMinTokens {
+			ok = n >= limit
+		} else {
+			ok = n <= limit
+		}
+		got = strconv.Itoa(n)
+	case condLanguage, condNotLanguage:
+		ok = strings.EqualFold(strings.TrimSpace(lang), strings.TrimSpace(c.Value)) == (c.Check == condLanguage)
+		got = lang
+	case condMatches, condNotMatches:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return false, fmt.Sprintf("%s %s %q is not a valid pattern", subject, c.Check, c.Value)
+		}
+		matched := re.MatchString(text)
+		ok = matched == (c.Check == condMatches)
+		got = "no match"
+		if matched {
+			got = "a match"
+		}
+	default:
+		return false, fmt.Sprintf("unknown check %q", c.Check)
+	}
+	if ok {
+		return true, ""
+	}
+	return false, fmt.Sprintf("%s %s %s (got %s)", subject, c.Check, c.Value, got)
+}
+
+// groupConditions returns the conditions gating group g in the given request:
+// every step's own When list, plus the implicit rule that a translate group only
+// runs when the text is not already in the output language.
+func groupConditions(g Group, req apperr.ChainRequest) []apperr.StepCondition {
+	var conds []apperr.StepCondition
+	if g.Family == v3.FamilyTranslate {
+		conds = append(conds, apperr.StepCondition{
+			Subject: condSubjectPrevious,
+			Check:   condNotLanguage,
+			Value:   req.OutputLanguageID,
+		})
+	}
+	for _, s := range g.Steps {
+		conds = append(conds, s.When...)
+	}
+	return conds
+}
+
+// skipReason evaluates g's conditions in order and returns the first one that
+// does not hold, or "" when the group should run.
+func skipReason(g Group, req apperr.ChainRequest, env conditionEnv) string {
+	for _, c := range groupConditions(g, req) {
+		if ok, reason := evalCondition(c, env); !ok {
+			return reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+func TestEvalCondition(t *testing.T) {
+	env := conditionEnv{
+		input:        "one two three four five",
+		inputLang:    "English",
+		previous:     "uno dos",
+		previousLang: "Spanish",
+	}
+	cond := func(subject, check, value string) apperr.StepCondition {
+		return apperr.StepCondition{Subject: subject, Check: check, Value: value}
+	}
+
+	tests := []struct {
+		name       string
+		cond       apperr.StepCondition
+		want       bool
+		wantReason string
+	}{
+		{name: "minWords on input holds", cond: cond("input", condMinWords, "5"), want: true},
+		{name: "minWords on input fails", cond: cond("input", condMinWords, "6"), wantReason: "input minWords 6 (got 5)"},
+		{name: "maxWords defaults to previous", cond: cond("", condMaxWords, "2"), want: true},
+		{name: "maxWords on previous fails", cond: cond("previous", condMaxWords, "1"), wantReason: "previous maxWords 1 (got 2)"},
+		{name: "minTokens holds for non-empty text", cond: cond("input", condMinTokens, "1"), want: true},
+		{name: "maxTokens fails", cond: cond("input", condMaxTokens, "0"), wantReason: "input maxTokens 0"},
+		{name: "language matches case-insensitively", cond: cond("input", condLanguage, "english"), want: true},
+		{name: "language of previous text", cond: cond("previous", condLanguage, "English"), wantReason: "previous language English (got Spanish)"},
+		{name: "notLanguage holds", cond: cond("input", condNotLanguage, "French"), want: true},
+		{name: "notLanguage fails", cond: cond("input", condNotLanguage, "English"), wantReason: "input notLanguage English (got English)"},
+		{name: "matches holds", cond: cond("input", condMatches, `\bthree\b`), want: true},
+		{name: "notMatches fails on a match", cond: cond("previous", condNotMatches, "^uno"), wantReason: "(got a match)"},
+		{name: "matches fails without a match", cond: cond("previous", condMatches, "tres"), wantReason: "(got no match)"},
+		{name: "unknown check never holds", cond: cond("input", "longerThan", "3"), wantReason: `unknown check "longerThan"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := evalCondition(tt.cond, env)
+			if got != tt.want {
+				t.Fatalf("evalCondition() = %v (%q), want %v", got, reason, tt.want)
+			}
+			if !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("reason = %q, want it to contain %q", reason, tt.wantReason)
+			}
+			if got && reason != "" {
+				t.Errorf("reason = %q, want empty when the condition holds", reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go_text/internal/apperr"
+	v3 "go_text/internal/prompts/v3"
+)
+
+// Reasons reported on apperr.RejectedEdit.
+const (
+	rejectEmpty    = "the original text is empty"
+	rejectNoChange = "the replacement equals the original"
+	rejectNotFound = "the original text does not occur in the input"
+	rejectOverlap  = "the original text overlaps another edit"
+)
+
+// editCategories are the categories an edit may carry; any other is reported as
+// "other".
+var editCategories = map[string]bool{
+	"grammar": true, "spelling": true, "punctuation": true, "capitalization": true,
+	"style": true, "clarity": true, "consistency": true, "other": true,
+}
+
+// editList reports whether g is a single step asking for an edit list instead of
+// text; mergeGroups never merges such a step with another.
+func (g Group) editList() bool {
+	return len(g.Steps) == 1 && g.Steps[0].Output == v3.OutputEdits
+}
+
+// proposedEdit is one entry of the model's reply to editListSuffix.
+type proposedEdit struct {
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	Category    string `json:"category"`
+	Explanation string `json:"explanation"`
+}
+
+// parseEditList reads the model's edit list: a JSON object with an "edits"
+// array, or the bare array. The list is decoded from the first opening bracket
+// that starts one, so a fence or prose around it — braces included — is skipped;
+// a JSON value that is not the list is skipped whole, not searched inside.
+func parseEditList(out string) ([]proposedEdit, error) {
+	reason, cause := "the reply contains no JSON", error(nil)
+	for i := 0; i < len(out); i++ {
+		if out[i] != '{' && out[i] != '[' {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(out[i:]))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if cause == nil {
+				reason, cause = "the reply is not valid JSON", err
+			}
+			continue
+		}
+		if raw[0] == '{' {
+			var wrapped struct {
+				Edits *[]proposedEdit `json:"edits"`
+			}
+			if err := json.Unmarshal(raw, &wrapped); err == nil && wrapped.Edits != nil {
+				return *wrapped.Edits, nil
+			}
+			reason, cause = `the reply has no "edits" list`, nil
+		} else {
+			var edits []proposedEdit
+			if err := json.Unmarshal(raw, &edits); err == nil {
+				return edits, nil
+			}
+		}
+		i += int(dec.InputOffset()) - 1
+	}
+	return nil, apperr.InvalidEdits(reason, cause)
+}
+
+// placeEdits finds each proposed edit in input. Edits are expected in text
+// order, so each original is looked for after the previous edit first, then
+// anywhere it does not overlap an edit already placed. It returns the placed
+// edits sorted by position and the ones that could not be placed.
+func placeEdits(input string, proposed []proposedEdit) ([]apperr.TextEdit, []apperr.RejectedEdit) {
+	edits := make([]apperr.TextEdit, 0, len(proposed))
+	var rejected []apperr.RejectedEdit
+	overlaps := func(start, end int) bool {
+		for _, e := range edits {
+			if start < e.End && e.Start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	cursor := 0
+	for _, p := range proposed {
+		reason := ""
+		start := -1
+		switch {
+		case p.Original == "":
+			reason = rejectEmpty
+		case p.Original == p.Replacement:
+			reason = rejectNoChange
+		default:
+			if i := strings.Index(input[cursor:], p.Original); i >= 0 && !overlaps(cursor+i, cursor+i+len(p.Original)) {
+				start = cursor + i
+				break
+			}
+			found := false
+			for from := 0; from <= len(input); {
+				i := strings.Index(input[from:], p.Original)
+				if i < 0 {
+					break
+				}
+				found = true
+				if !overlaps(from+i, from+i+len(p.Original)) {
+					start = from + i
+					break
+				}
+				from += i + 1
+			}
+			if start < 0 {
+				reason = rejectNotFound
+				if found {
+					reason = rejectOverlap
+				}
+			}
+		}
+		if start < 0 {
+			rejected = append(rejected, apperr.RejectedEdit{Original: p.Original, Replacement: p.Replacement, Reason: reason})
+			continue
+		}
+
+		category := strings.ToLower(strings.TrimSpace(p.Category))
+		if !editCategories[category] {
+			category = "other"
+		}
+		edits = append(edits, apperr.TextEdit{
+			Start:       start,
+			End:         start + len(p.Original),
+			Original:    p.Original,
+			Replacement: p.Replacement,
+			Category:    category,
+			Explanation: strings.TrimSpace(p.Explanation),
+		})
+		cursor = start + len(p.Original)
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+	return edits, rejected
+}
+
+// rebaseEditList makes l, placed in the selection of doc a run works on, refer to
+// the whole document: Input becomes doc with the selection replaced by l.Input,
+// and every edit is shifted by the selection's start, so accepted edits apply to
+// the text the user is editing. l is returned unchanged for a run on all of doc.
+func rebaseEditList(l *apperr.EditList, doc string, selection apperr.TextRange) *apperr.EditList {
+	if selection.Start == 0 && selection.End == len(doc) {
+		return l
+	}
+	l.Input = doc[:selection.Start] + l.Input + doc[selection.End:]
+	for i := range l.Edits {
+		l.Edits[i].Start += selection.Start
+		l.Edits[i].End += selection.Start
+	}
+	return l
+}
+
+// applyEdits returns text with edits applied. Every edit must cover exactly its
+// Original in text, and no two edits may overlap; a validation error names the
+// first one that does not.
+func applyEdits(text string, edits []apperr.TextEdit) (string, error) {
+	sorted := append([]apperr.TextEdit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var b strings.Builder
+	prev := 0
+	for _, e := range sorted {
+		if e.Start < prev || e.End < e.Start || e.End > len(text) {
+			return "", apperr.Validation("edits", "non-overlapping spans within the text",
+				fmt.Sprintf("an edit at %d–%d", e.Start, e.End))
+		}
+		if text[e.Start:e.End] != e.Original {
+			return "", apperr.Validation("edits", "spans that match their original text",
+				fmt.Sprintf("%q at %d–%d", e.Original, e.Start, e.End))
+		}
+		b.WriteString(text[prev:e.Start])
+		b.WriteString(e.Replacement)
+		prev = e.End
+	}
+	b.WriteString(text[prev:])
+	return b.String(), nil
+}
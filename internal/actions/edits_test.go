@@ -0,0 +1,195 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_text/internal/apperr"
+)
+
+func TestParseEditList(t *testing.T) {
+	want := []proposedEdit{{Original: "teh", Replacement: "the", Category: "spelling", Explanation: "Typo."}}
+	tests := []struct {
+		name     string
+		out      string
+		want     []proposedEdit
+		wantCode apperr.ErrorCode
+	}{
+		{
+			name: "object",
+			out:  `{"edits":[{"original":"teh","replacement":"the","category":"spelling","explanation":"Typo."}]}`,
+			want: want,
+		},
+		{
+			name: "fenced object",
+			out:  "```json\n{\"edits\":[{\"original\":\"teh\",\"replacement\":\"the\",\"category\":\"spelling\",\"explanation\":\"Typo.\"}]}\n```",
+			want: want,
+		},
+		{
+			name: "bare array",
+			out:  `[{"original":"teh","replacement":"the","category":"spelling","explanation":"Typo."}]`,
+			want: want,
+		},
+		{
+			name: "nothing to correct",
+			out:  `{"edits":[]}`,
+			want: []proposedEdit{},
+		},
+		{
+			name: "prose with braces and brackets around the object",
+			out: "Here are the fixes [see below] for {teh} typo:\n```json\n" +
+				`{"edits":[{"original":"teh","replacement":"the","category":"spelling","explanation":"Typo."}]}` +
+				"\n```\nLet me know {if} you need more.",
+			want: want,
+		},
+		{
+			name: "unrelated JSON before the list",
+			out: `Settings used: {"mode":"strict","tags":["a"]}` + "\n" +
+				`[{"original":"teh","replacement":"the","category":"spelling","explanation":"Typo."}]`,
+			want: want,
+		},
+		{
+			name:     "plain text",
+			out:      "The corrected text.",
+			wantCode: apperr.CodeInvalidEdits,
+		},
+		{
+			name:     "broken JSON",
+			out:      `{"edits":[{"original":"teh"`,
+			wantCode: apperr.CodeInvalidEdits,
+		},
+		{
+			name:     "object without edits",
+			out:      `{"corrections":[]}`,
+			wantCode: apperr.CodeInvalidEdits,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEditList(tt.out)
+			if tt.wantCode != "" {
+				var ae *apperr.AppError
+				require.ErrorAs(t, err, &ae)
+				assert.Equal(t, tt.wantCode, ae.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPlaceEdits(t *testing.T) {
+	const input = "Teh cat sat on teh mat. Its a nice mat."
+
+	edits, rejected := placeEdits(input, []proposedEdit{
+		{Original: "Teh", Replacement: "The", Category: "Capitalization"},
+		{Original: "teh", Replacement: "the", Category: "spelling", Explanation: " Typo. "},
+		{Original: "Its", Replacement: "It's", Category: "apostrophes"},
+		{Original: "on teh", Replacement: "upon the"},
+		{Original: "dog", Replacement: "cat"},
+		{Original: "nice", Replacement: "nice"},
+		{Original: "", Replacement: "x"},
+	})
+
+	assert.Equal(t, []apperr.TextEdit{
+		{Start: 0, End: 3, Original: "Teh", Replacement: "The", Category: "capitalization"},
+		{Start: 15, End: 18, Original: "teh", Replacement: "the", Category: "spelling", Explanation: "Typo."},
+		{Start: 24, End: 27, Original: "Its", Replacement: "It's", Category: "other"},
+	}, edits)
+	assert.Equal(t, []apperr.RejectedEdit{
+		{Original: "on teh", Replacement: "upon the", Reason: rejectOverlap},
+		{Original: "dog", Replacement: "cat", Reason: rejectNotFound},
+		{Original: "nice", Replacement: "nice", Reason: rejectNoChange},
+		{Original: "", Replacement: "x", Reason: rejectEmpty},
+	}, rejected)
+}
+
+func TestPlaceEdits_RepeatedOriginalOutOfOrder(t *testing.T) {
+	const input = "a teh b teh c"
+
+	// The second "teh" is listed first; the next one still finds the free first.
+	edits, rejected := placeEdits(input, []proposedEdit{
+		{Original: "teh c", Replacement: "the c"},
+		{Original: "teh", Replacement: "the"},
+	})
+
+	assert.Empty(t, rejected)
+	require.Len(t, edits, 2)
+	assert.Equal(t, 2, edits[0].Start)
+	assert.Equal(t, 8, edits[1].Start)
+}
+
+func TestApplyEdits(t *testing.T) {
+	const text = "Teh cat sat on teh mat."
+	the := apperr.TextEdit{Start: 0, End: 3, Original: "Teh", Replacement: "The"}
+	the2 := apperr.TextEdit{Start: 15, End: 18, Original: "teh", Replacement: "the"}
+
+	tests := []struct {
+		name     string
+		edits    []apperr.TextEdit
+		want     string
+		wantCode apperr.ErrorCode
+	}{
+		{name: "all", edits: []apperr.TextEdit{the, the2}, want: "The cat sat on the mat."},
+		{name: "subset", edits: []apperr.TextEdit{the2}, want: "Teh cat sat on the mat."},
+		{name: "out of order", edits: []apperr.TextEdit{the2, the}, want: "The cat sat on the mat."},
+		{name: "none", edits: nil, want: text},
+		{
+			name:     "span does not match",
+			edits:    []apperr.TextEdit{{Start: 4, End: 7, Original: "dog", Replacement: "cat"}},
+			wantCode: apperr.CodeValidation,
+		},
+		{
+			name:     "overlap",
+			edits:    []apperr.TextEdit{the, {Start: 1, End: 3, Original: "eh", Replacement: "he"}},
+			wantCode: apperr.CodeValidation,
+		},
+		{
+			name:     "out of range",
+			edits:    []apperr.TextEdit{{Start: 20, End: 40, Original: "x", Replacement: "y"}},
+			wantCode: apperr.CodeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyEdits(text, tt.edits)
+			if tt.wantCode != "" {
+				var ae *apperr.AppError
+				require.ErrorAs(t, err, &ae)
+				assert.Equal(t, tt.wantCode, ae.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRebaseEditList(t *testing.T) {
+	const doc = "Intro. Teh cat sta down. Outro."
+	sel := apperr.TextRange{Start: 7, End: 24}
+	list := &apperr.EditList{
+		Input: "Teh cat sta down.",
+		Edits: []apperr.TextEdit{
+			{Start: 0, End: 3, Original: "Teh", Replacement: "The"},
+			{Start: 8, End: 11, Original: "sta", Replacement: "sat"},
+		},
+	}
+
+	got := rebaseEditList(list, doc, sel)
+	assert.Equal(t, doc, got.Input)
+	for _, e := range got.Edits {
+		assert.Equal(t, e.Original, got.Input[e.Start:e.End])
+	}
+	applied, err := applyEdits(got.Input, got.Edits)
+	require.NoError(t, err)
+	assert.Equal(t, "Intro. The cat sat down. Outro.", applied)
+
+	whole := &apperr.EditList{Input: doc, Edits: []apperr.TextEdit{{Start: 7, End: 10, Original: "Teh", Replacement: "The"}}}
+	assert.Equal(t, 7, rebaseEditList(whole, doc, apperr.TextRange{Start: 0, End: len(doc)}).Edits[0].Start)
+}
@@ -0,0 +1,338 @@
+package actions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go_text/internal/apperr"
+)
+
+// Move rules reported on apperr.StepMove.
+const (
+	moveNone         = "none"
+	moveTerminalLast = "terminal-last"
+	moveFinisherLast = "finisher-last"
+	moveOrderRank    = "order-rank"
+	moveStrictOrder  = "strict-order"
+)
+
+// Check names reported on apperr.PlanCheck.
+const (
+	checkNonEmpty      = "non-empty"
+	checkKnownAction   = "known-action"
+	checkRequirement   = "requirement"
+	checkParam         = "param"
+	checkCondition     = "condition"
+	checkOutput        = "output"
+	checkExclusivity   = "exclusivity"
+	checkBranches      = "branches"
+	checkSharedStep    = "shared-step"
+	checkMaxSteps      = "max-steps"
+	checkMaxInferences = "max-inferences"
+)
+
+// Explain traces every planning stage for req under limits without failing fast:
+// each check is recorded as passed or failed, and the ordering and merge stages run
+// over the known steps even when an earlier check failed. Valid reports whether
+// PlanWithLimits — or PlanFanOut, for a request with branches — would accept the
+// same request.
+func (p *Planner) Explain(req apperr.ChainRequest, limits PlanLimits) apperr.PlanExplanation {
+	limits = limits.withDefaults()
+	if len(req.Branches) > 0 {
+		return p.explainFanOut(req, limits)
+	}
+	return p.explainSteps(req, limits, true)
+}
+
+// explainFanOut traces a fan-out request the way PlanFanOut plans it: the shared
+// steps on their own, then each branch on its own, with the exclusivity rule and
+// the caps applied to every whole path. The top-level trace covers the shared
+// steps and the branch checks; Branches holds one trace per branch.
+func (p *Planner) explainFanOut(req apperr.ChainRequest, limits PlanLimits) apperr.PlanExplanation {
+	prefixReq := req
+	prefixReq.Branches = nil
+	ex := p.explainSteps(prefixReq, limits, false)
+	if len(req.Steps) == 0 {
+		// A fan-out needs no shared steps, so the non-empty check does not apply.
+		ex.Checks = slices.DeleteFunc(ex.Checks, func(c apperr.PlanCheck) bool { return c.Name == checkNonEmpty })
+	}
+
+	branches := apperr.PlanCheck{Name: checkBranches, Passed: true,
+		Detail: fmt.Sprintf("%d of %d–%d", len(req.Branches), MinFanOutBranches, MaxFanOutBranches)}
+	seen := make(map[string]bool, len(req.Branches))
+	for _, b := range req.Branches {
+		switch {
+		case strings.TrimSpace(b.Name) == "":
+			branches.Passed, branches.Detail = false, "a branch has no name"
+		case seen[b.Name]:
+			branches.Passed, branches.Detail = false, fmt.Sprintf("branch name %q repeated", b.Name)
+		}
+		seen[b.Name] = true
+	}
+	if n := len(req.Branches); n < MinFanOutBranches || n > MaxFanOutBranches {
+		branches.Passed = false
+	}
+	ex.Checks = append(ex.Checks, branches)
+	for _, s := range req.Steps {
+		if p.catalog[s.ActionID].Terminal {
+			ex.Checks = append(ex.Checks, apperr.PlanCheck{Name: checkSharedStep, Subject: s.ActionID,
+				Detail: "terminal actions can only end a branch"})
+		}
+	}
+
+	valid := allPassed(ex.Checks)
+	ex.Branches = make([]apperr.ExplainedBranch, 0, len(req.Branches))
+	for _, b := range req.Branches {
+		branchReq := req
+		branchReq.Steps = b.Steps
+		branchReq.Branches = nil
+		bex := p.explainSteps(branchReq, limits, false)
+
+		// Exclusivity and the caps hold for the whole path, shared steps included.
+		path := append(append([]apperr.ChainStep(nil), req.Steps...), b.Steps...)
+		checks := slices.DeleteFunc(bex.Checks, func(c apperr.PlanCheck) bool { return c.Name == checkExclusivity })
+		checks = append(checks, p.exclusivityChecks(p.knownSteps(path))...)
+		bex.Steps = len(path)
+		bex.Inferences = ex.Inferences + bex.Inferences
+		checks = append(checks,
+			capCheck(checkMaxSteps, bex.Steps, limits.MaxSteps),
+			capCheck(checkMaxInferences, bex.Inferences, limits.MaxInferences))
+		bex.Checks = checks
+		bex.Valid = allPassed(bex.Checks)
+		valid = valid && bex.Valid
+		ex.Branches = append(ex.Branches, apperr.ExplainedBranch{Name: b.Name, Explanation: bex})
+	}
+	ex.Valid = valid
+	return ex
+}
+
+// knownSteps returns the steps of steps whose action is in the catalog.
+func (p *Planner) knownSteps(steps []apperr.ChainStep) []apperr.ChainStep {
+	var out []apperr.ChainStep
+	for _, s := range steps {
+		if _, ok := p.catalog[s.ActionID]; ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// explainSteps traces a linear request; see Explain. Without caps the step and
+// inference caps are not checked, for a fan-out that checks them per path.
+func (p *Planner) explainSteps(req apperr.ChainRequest, limits PlanLimits, caps bool) apperr.PlanExplanation {
+	ex := apperr.PlanExplanation{
+		StrictOrder:    req.StrictOrder,
+		OriginalOrder:  make([]string, 0, len(req.Steps)),
+		CanonicalOrder: []string{},
+		Moves:          []apperr.StepMove{},
+		Groups:         []apperr.ExplainedGroup{},
+		MergeDecisions: []apperr.MergeDecision{},
+		Checks:         []apperr.PlanCheck{},
+		MaxSteps:       limits.MaxSteps,
+		MaxInferences:  limits.MaxInferences,
+	}
+
+	if len(req.Steps) == 0 {
+		ex.Checks = append(ex.Checks, apperr.PlanCheck{Name: checkNonEmpty, Detail: "0 steps provided"})
+		return ex
+	}
+
+	// Stage 1: resolve actions and validate per-step requirements, params, conditions
+	// and output modes.
+	// Unknown steps are reported and then left out of the later stages.
+	type known struct {
+		step  apperr.ChainStep
+		index int
+	}
+	var steps []known
+	for i, s := range req.Steps {
+		ex.OriginalOrder = append(ex.OriginalOrder, s.ActionID)
+		meta, ok := p.catalog[s.ActionID]
+		if !ok {
+			ex.Checks = append(ex.Checks, apperr.PlanCheck{
+				Name: checkKnownAction, Subject: s.ActionID, Detail: "not in the action catalog",
+			})
+			continue
+		}
+		ex.Checks = append(ex.Checks, apperr.PlanCheck{Name: checkKnownAction, Subject: s.ActionID, Passed: true})
+		if len(meta.Requires) > 0 {
+			ex.Checks = append(ex.Checks, problemCheck(checkRequirement, s.ActionID, p.requirementProblem(req, s)))
+		}
+		if len(meta.Params) > 0 || len(s.Params) > 0 {
+			ex.Checks = append(ex.Checks, problemCheck(checkParam, s.ActionID, p.paramProblem(s)))
+		}
+		if len(s.When) > 0 {
+			ex.Checks = append(ex.Checks, problemCheck(checkCondition, s.ActionID, stepConditionProblem(s)))
+		}
+		if s.Output != "" {
+			ex.Checks = append(ex.Checks, problemCheck(checkOutput, s.ActionID, p.outputProblem(s)))
+		}
+		steps = append(steps, known{step: s, index: i})
+	}
+
+	// Stage 2: canonical order, and which rule moved each step.
+	given := make([]apperr.ChainStep, len(steps))
+	originalIndex := make([]int, len(steps)) // position in given → index in req.Steps
+	for i, k := range steps {
+		given[i] = k.step
+		originalIndex[i] = k.index
+	}
+	ordered := given
+	positions := make([]int, len(given)) // position in given → position in ordered
+	for i := range positions {
+		positions[i] = i
+	}
+	if !req.StrictOrder {
+		ordered, positions = p.sortCanonicalTraced(given)
+	}
+	for _, s := range ordered {
+		ex.CanonicalOrder = append(ex.CanonicalOrder, s.ActionID)
+	}
+	for i, s := range given {
+		meta := p.catalog[s.ActionID]
+		ex.Moves = append(ex.Moves, apperr.StepMove{
+			ActionID:  s.ActionID,
+			From:      originalIndex[i],
+			To:        positions[i],
+			Rule:      p.moveRule(given, positions, i, req.StrictOrder),
+			OrderRank: meta.OrderRank,
+			Terminal:  meta.Terminal,
+		})
+	}
+
+	// Stage 3: exclusivity and the step cap, both over the ordered steps.
+	ex.Checks = append(ex.Checks, p.exclusivityChecks(ordered)...)
+	ex.Steps = len(ordered)
+	if caps {
+		ex.Checks = append(ex.Checks, capCheck(checkMaxSteps, ex.Steps, limits.MaxSteps))
+	}
+
+	// Stage 4: merge decisions and the inference cap.
+	var groups []Group
+	for pos, s := range ordered {
+		meta := p.catalog[s.ActionID]
+		reason := mergeFirstStep
+		if len(groups) > 0 {
+			reason = p.mergeReason(groups[len(groups)-1], s)
+		}
+		if reason == mergeMerged {
+			last := &groups[len(groups)-1]
+			last.Steps = append(last.Steps, s)
+		} else {
+			groups = append(groups, Group{Family: meta.Family, Steps: []apperr.ChainStep{s}})
+		}
+		ex.MergeDecisions = append(ex.MergeDecisions, apperr.MergeDecision{
+			ActionID:   s.ActionID,
+			Position:   pos,
+			GroupIndex: len(groups) - 1,
+			Merged:     reason == mergeMerged,
+			Reason:     reason,
+		})
+	}
+	for i, g := range groups {
+		ids := make([]string, len(g.Steps))
+		for j, s := range g.Steps {
+			ids[j] = s.ActionID
+		}
+		ex.Groups = append(ex.Groups, apperr.ExplainedGroup{Index: i, Family: g.Family, ActionIDs: ids})
+	}
+	ex.Inferences = countInferences(groups)
+	if caps {
+		ex.Checks = append(ex.Checks, capCheck(checkMaxInferences, ex.Inferences, limits.MaxInferences))
+	}
+
+	ex.Valid = allPassed(ex.Checks)
+	return ex
+}
+
+// exclusivityChecks reports, for every exclusivity group used by steps, whether
+// only one of its actions is among them.
+func (p *Planner) exclusivityChecks(steps []apperr.ChainStep) []apperr.PlanCheck {
+	members := make(map[string][]string)
+	var exclusivityGroups []string
+	for _, s := range steps {
+		grp := p.catalog[s.ActionID].ExclusivityGroup
+		if grp == "" {
+			continue
+		}
+		if _, ok := members[grp]; !ok {
+			exclusivityGroups = append(exclusivityGroups, grp)
+		}
+		members[grp] = append(members[grp], s.ActionID)
+	}
+	checks := make([]apperr.PlanCheck, 0, len(exclusivityGroups))
+	for _, grp := range exclusivityGroups {
+		c := apperr.PlanCheck{Name: checkExclusivity, Subject: grp, Passed: len(members[grp]) == 1}
+		if !c.Passed {
+			c.Detail = fmt.Sprintf("group allows one action; got %s", strings.Join(members[grp], ", "))
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// allPassed reports whether every check passed.
+func allPassed(checks []apperr.PlanCheck) bool {
+	for _, c := range checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// sortCanonicalTraced is sortCanonical that also reports, for each input position,
+// the position the step landed on.
+func (p *Planner) sortCanonicalTraced(steps []apperr.ChainStep) ([]apperr.ChainStep, []int) {
+	out := make([]apperr.ChainStep, len(steps))
+	positions := make([]int, len(steps))
+	for to, from := range p.canonicalIndices(steps) {
+		out[to] = steps[from]
+		positions[from] = to
+	}
+	return out, positions
+}
+
+// moveRule names the rule that decided step i's place relative to the steps it
+// swapped with. A step passed by one of a lower order class moved under the
+// terminal-last or finisher-last rule of its class; the other side of that swap
+// is not counted as a move. Any other swap can only come from OrderRank.
+func (p *Planner) moveRule(steps []apperr.ChainStep, positions []int, i int, strict bool) string {
+	if strict {
+		return moveStrictOrder
+	}
+	rule := moveNone
+	class := orderClass(p.catalog[steps[i].ActionID])
+	for j := range steps {
+		if j == i || (j < i) == (positions[j] < positions[i]) {
+			continue
+		}
+		if other := orderClass(p.catalog[steps[j].ActionID]); other != class {
+			if class > other {
+				if class == classFinisher {
+					return moveFinisherLast
+				}
+				return moveTerminalLast
+			}
+			continue
+		}
+		rule = moveOrderRank
+	}
+	return rule
+}
+
+// problemCheck turns a "" / reason result from the planner's per-step validators
+// into a PlanCheck.
+func problemCheck(name, subject, problem string) apperr.PlanCheck {
+	return apperr.PlanCheck{Name: name, Subject: subject, Passed: problem == "", Detail: problem}
+}
+
+// capCheck reports got against a configured maximum.
+func capCheck(name string, got, limit int) apperr.PlanCheck {
+	return apperr.PlanCheck{
+		Name:   name,
+		Passed: got <= limit,
+		Detail: fmt.Sprintf("%d of at most %d", got, limit),
+	}
+}
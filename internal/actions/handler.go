@@ -11,6 +11,7 @@ import (
 	"go_text/internal/settings"
 	"go_text/internal/verification"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -120,6 +121,64 @@ func (h *ActionHandler) PreviewPrompt(req apperr.PromptPreviewRequest) (res appe
 	return apperr.PromptPreviewResult{Data: preview}
 }
 
+// ExplainPlan returns a structured trace of how req would be planned: canonical
+// order and the rule behind each move, merge decisions, and every validation check.
+// Plans the planner would reject still produce an explanation with Valid=false.
+func (h *ActionHandler) ExplainPlan(req apperr.ChainRequest) (res apperr.PlanExplanationResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.PlanExplanationResult{Error: &wire}
+		}
+	}()
+
+	ex, err := h.actionService.ExplainPlan(req)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.PlanExplanationResult{Error: &wire}
+	}
+	return apperr.PlanExplanationResult{Data: ex}
+}
+
+// DetectLanguage identifies which of the configured languages text is written in,
+// with a confidence in [0, 1]. An empty Language means no language was identified.
+func (h *ActionHandler) DetectLanguage(text string) (res apperr.LanguageDetectionResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.LanguageDetectionResult{Error: &wire}
+		}
+	}()
+
+	d, err := h.actionService.DetectLanguage(text)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.LanguageDetectionResult{Error: &wire}
+	}
+	return apperr.LanguageDetectionResult{Data: d}
+}
+
+// ApplyEdits applies the chosen edits of an edit list to req.Text and returns
+// the resulting text.
+func (h *ActionHandler) ApplyEdits(req apperr.ApplyEditsRequest) (res apperr.StringResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.StringResult{Error: &wire}
+		}
+	}()
+
+	text, err := h.actionService.ApplyEdits(req.Text, req.Edits)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.StringResult{Error: &wire}
+	}
+	return apperr.StringResult{Data: text}
+}
+
 // countPreviewSpecifiers counts how many of actionId/steps/stackId are set in the request.
 func countPreviewSpecifiers(req apperr.PromptPreviewRequest) int {
 	count := 0
@@ -135,7 +194,8 @@ func countPreviewSpecifiers(req apperr.PromptPreviewRequest) int {
 	return count
 }
 
-// resolveStackID resolves req.StackID to a Steps slice, mutating req in place.
+// resolveStackID resolves req.StackID to a Steps slice (carrying each saved step's
+// parameters), mutating req in place.
 // Returns a non-nil result carrying an error if resolution fails; nil on success.
 func (h *ActionHandler) resolveStackID(req *apperr.PromptPreviewRequest) *apperr.PromptPreviewResult {
 	if h.stackLookup == nil {
@@ -155,11 +215,14 @@ func (h *ActionHandler) resolveStackID(req *apperr.PromptPreviewRequest) *apperr
 		res := apperr.PromptPreviewResult{Error: &wire}
 		return &res
 	}
-	steps := make([]apperr.ChainStep, len(stackResult.Data.Steps))
-	for i, id := range stackResult.Data.Steps {
-		steps[i] = apperr.ChainStep{ActionID: id}
+	if stackResult.Data.Kind == apperr.StackKindFanOut {
+		ae := apperr.Validation("stackId", "a linear stack", "fan-out stack")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		res := apperr.PromptPreviewResult{Error: &wire}
+		return &res
 	}
-	req.Steps = steps
+	req.Steps = stackResult.Data.ChainSteps()
+	req.StrictOrder = stackResult.Data.StrictOrder
 	req.StackID = ""
 	return nil
 }
@@ -299,6 +362,67 @@ func (h *ActionHandler) ProcessPromptChain(req apperr.ChainRequest) (res apperr.
 		}
 	}()
 
+	return h.runChain(req.RunID, func(ctx context.Context, emitProgress func(apperr.StepProgress)) (*apperr.ChainResult, error) {
+		return h.actionService.RunChain(ctx, req, emitProgress)
+	})
+}
+
+// ResumeChain continues the failed or cancelled chain run runId from the group
+// it stopped at, reusing the outputs of the groups it completed; see
+// ActionService.ResumeChain. Single-flight, events and the Data/Error pairs are
+// those of ProcessPromptChain, and CancelChain(runId) cancels the resumed run.
+// A run that finished, fanned out or is no longer kept returns a validation error.
+func (h *ActionHandler) ResumeChain(runID string) (res apperr.ChainResultEnv) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.ChainResultEnv{Error: &wire}
+		}
+	}()
+
+	if runID == "" {
+		ae := apperr.Validation("runId", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.ChainResultEnv{Error: &wire}
+	}
+	return h.runChain(runID, func(ctx context.Context, emitProgress func(apperr.StepProgress)) (*apperr.ChainResult, error) {
+		return h.actionService.ResumeChain(ctx, runID, emitProgress)
+	})
+}
+
+// RerunHistoryEntry replays history entry id from its run snapshot, exactly or
+// with the provider or model in overrides, as a new run; see
+// ActionService.RerunEntry. The run ID is generated here: progress events carry
+// it, CancelChain(runId) cancels the run, and the result returns it.
+// Single-flight, events and the Data/Error pairs are those of ProcessPromptChain.
+func (h *ActionHandler) RerunHistoryEntry(id string, overrides apperr.RerunOverrides) (res apperr.ChainResultEnv) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.ChainResultEnv{Error: &wire}
+		}
+	}()
+
+	if id == "" {
+		ae := apperr.Validation("id", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.ChainResultEnv{Error: &wire}
+	}
+	runID := uuid.NewString()
+	return h.runChain(runID, func(ctx context.Context, emitProgress func(apperr.StepProgress)) (*apperr.ChainResult, error) {
+		return h.actionService.RerunEntry(ctx, id, runID, overrides, emitProgress)
+	})
+}
+
+// runChain runs one chain pass as runID under the inference gate: it registers
+// a cancel function for CancelChain, emits the progress and terminal events,
+// and wraps the outcome of run in the envelope.
+func (h *ActionHandler) runChain(
+	runID string,
+	run func(ctx context.Context, emitProgress func(apperr.StepProgress)) (*apperr.ChainResult, error),
+) apperr.ChainResultEnv {
 	if !h.gate.TryAcquire() {
 		ae := apperr.Busy()
 		wire := apperr.ToWire(h.liveZlog(), ae)
@@ -313,11 +437,11 @@ func (h *ActionHandler) ProcessPromptChain(req apperr.ChainRequest) (res apperr.
 	}
 	ctx, cancel := context.WithCancel(baseCtx)
 	h.mu.Lock()
-	h.runs[req.RunID] = cancel
+	h.runs[runID] = cancel
 	h.mu.Unlock()
 	defer func() {
 		h.mu.Lock()
-		delete(h.runs, req.RunID)
+		delete(h.runs, runID)
 		h.mu.Unlock()
 		cancel()
 	}()
@@ -326,7 +450,7 @@ func (h *ActionHandler) ProcessPromptChain(req apperr.ChainRequest) (res apperr.
 		h.emit("chain:progress", p)
 	}
 
-	result, err := h.actionService.RunChain(ctx, req, emitProgress)
+	result, err := run(ctx, emitProgress)
 	if err != nil {
 		wire := apperr.ToWire(h.liveZlog(), err)
 		h.emit("chain:error", wire)
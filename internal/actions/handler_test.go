@@ -3,6 +3,7 @@ package actions
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"go_text/internal/apperr"
@@ -196,6 +197,13 @@ type mockActionService struct {
 	catalog       []apperr.ActionMeta
 	previewResult *apperr.PromptPreview
 	previewErr    error
+	previewReq    apperr.PromptPreviewRequest
+	explainResult *apperr.PlanExplanation
+	explainErr    error
+	detectResult  *apperr.LanguageDetection
+	detectErr     error
+	applyResult   string
+	applyErr      error
 }
 
 func (m *mockActionService) GetModelsList() ([]string, error) { return nil, nil }
@@ -212,12 +220,28 @@ func (m *mockActionService) GetCompletionResponseForProvider(_ context.Context,
 	return "", nil
 }
 func (m *mockActionService) GetActionCatalog() []apperr.ActionMeta { return m.catalog }
-func (m *mockActionService) BuildPlanAndPrompts(_ apperr.PromptPreviewRequest) (*apperr.PromptPreview, error) {
+func (m *mockActionService) BuildPlanAndPrompts(req apperr.PromptPreviewRequest) (*apperr.PromptPreview, error) {
+	m.previewReq = req
 	return m.previewResult, m.previewErr
 }
 func (m *mockActionService) RunChain(_ context.Context, _ apperr.ChainRequest, _ func(apperr.StepProgress)) (*apperr.ChainResult, error) {
 	return nil, nil
 }
+func (m *mockActionService) ResumeChain(_ context.Context, _ string, _ func(apperr.StepProgress)) (*apperr.ChainResult, error) {
+	return nil, nil
+}
+func (m *mockActionService) RerunEntry(_ context.Context, _, _ string, _ apperr.RerunOverrides, _ func(apperr.StepProgress)) (*apperr.ChainResult, error) {
+	return nil, nil
+}
+func (m *mockActionService) ExplainPlan(_ apperr.ChainRequest) (*apperr.PlanExplanation, error) {
+	return m.explainResult, m.explainErr
+}
+func (m *mockActionService) DetectLanguage(_ string) (*apperr.LanguageDetection, error) {
+	return m.detectResult, m.detectErr
+}
+func (m *mockActionService) ApplyEdits(_ string, _ []apperr.TextEdit) (string, error) {
+	return m.applyResult, m.applyErr
+}
 
 func (m *mockActionService) withCatalog(catalog []apperr.ActionMeta) *mockActionService {
 	m.catalog = catalog
@@ -515,6 +539,42 @@ func TestActionHandler_PreviewPrompt_StackID_ResolvesAndSucceeds(t *testing.T) {
 	}
 }
 
+func TestActionHandler_PreviewPrompt_StackID_CarriesStepParamsAndOrderMode(t *testing.T) {
+	t.Parallel()
+	savedStack := &apperr.SavedStack{
+		ID:          "stack-1",
+		Name:        "Image",
+		Steps:       []string{"rewrite.proofread.basic", "prompteng.image"},
+		StrictOrder: true,
+		StepParams: []apperr.StepParams{
+			{},
+			{TargetModel: "SDXL", Goal: "restore", Params: map[string]string{"k": "v"}},
+		},
+	}
+	lookup := &mockStackLookup{result: apperr.StackResult{Data: savedStack}}
+	svc := &mockActionService{previewResult: defaultPreview()}
+	h := newPreviewHandler(svc, lookup)
+
+	res := h.PreviewPrompt(apperr.PromptPreviewRequest{StackID: "stack-1"})
+
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %v", res.Error)
+	}
+	want := []apperr.ChainStep{
+		{ActionID: "rewrite.proofread.basic"},
+		{ActionID: "prompteng.image", TargetModel: "SDXL", Goal: "restore", Params: map[string]string{"k": "v"}},
+	}
+	if !reflect.DeepEqual(svc.previewReq.Steps, want) {
+		t.Errorf("resolved steps = %+v, want %+v", svc.previewReq.Steps, want)
+	}
+	if !svc.previewReq.StrictOrder {
+		t.Error("StrictOrder should be carried over from the saved stack")
+	}
+	if svc.previewReq.StackID != "" {
+		t.Errorf("StackID should be cleared after resolution, got %q", svc.previewReq.StackID)
+	}
+}
+
 // ─── PreviewPrompt: validation errors ────────────────────────────────────────
 
 func TestActionHandler_PreviewPrompt_ZeroSpecifiers_ValidationError(t *testing.T) {
@@ -664,6 +724,171 @@ func (p *panicActionService) BuildPlanAndPrompts(_ apperr.PromptPreviewRequest)
 func (p *panicActionService) RunChain(_ context.Context, _ apperr.ChainRequest, _ func(apperr.StepProgress)) (*apperr.ChainResult, error) {
 	panic("panic RunChain")
 }
+func (p *panicActionService) ResumeChain(_ context.Context, _ string, _ func(apperr.StepProgress)) (*apperr.ChainResult, error) {
+	panic("panic ResumeChain")
+}
+func (p *panicActionService) RerunEntry(_ context.Context, _, _ string, _ apperr.RerunOverrides, _ func(apperr.StepProgress)) (*apperr.ChainResult, error) {
+	panic("panic RerunEntry")
+}
+func (p *panicActionService) ExplainPlan(_ apperr.ChainRequest) (*apperr.PlanExplanation, error) {
+	panic("panic ExplainPlan")
+}
+func (p *panicActionService) DetectLanguage(_ string) (*apperr.LanguageDetection, error) {
+	panic("panic DetectLanguage")
+}
+func (p *panicActionService) ApplyEdits(_ string, _ []apperr.TextEdit) (string, error) {
+	panic("panic ApplyEdits")
+}
+
+// ─── ExplainPlan ─────────────────────────────────────────────────────────────
+
+func TestActionHandler_ExplainPlan(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		svc      ActionServiceAPI
+		wantData bool
+		wantCode apperr.ErrorCode
+	}{
+		{
+			name:     "success",
+			svc:      &mockActionService{explainResult: &apperr.PlanExplanation{Valid: true}},
+			wantData: true,
+		},
+		{
+			name:     "service error",
+			svc:      &mockActionService{explainErr: errors.New("settings unavailable")},
+			wantCode: apperr.CodeInternal,
+		},
+		{
+			name:     "panic recovery",
+			svc:      &panicActionService{},
+			wantCode: apperr.CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := &ActionHandler{actionService: tt.svc, verificationService: &mockVerificationService{}, gate: gate.New()}
+
+			res := h.ExplainPlan(apperr.ChainRequest{Steps: []apperr.ChainStep{{ActionID: "rewrite.proofread.basic"}}})
+
+			if (res.Data != nil) != tt.wantData {
+				t.Errorf("Data present: got %v, want %v", res.Data != nil, tt.wantData)
+			}
+			if tt.wantCode == "" {
+				if res.Error != nil {
+					t.Errorf("unexpected error: %+v", res.Error)
+				}
+				return
+			}
+			if res.Error == nil || res.Error.Code != tt.wantCode {
+				t.Errorf("expected code=%s, got %+v", tt.wantCode, res.Error)
+			}
+		})
+	}
+}
+
+// ─── DetectLanguage ──────────────────────────────────────────────────────────
+
+func TestActionHandler_DetectLanguage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		svc      ActionServiceAPI
+		wantLang string
+		wantCode apperr.ErrorCode
+	}{
+		{
+			name:     "success",
+			svc:      &mockActionService{detectResult: &apperr.LanguageDetection{Language: "French", Confidence: 0.8}},
+			wantLang: "French",
+		},
+		{
+			name:     "validation error passes through",
+			svc:      &mockActionService{detectErr: apperr.Validation("text", "non-empty text", "empty string")},
+			wantCode: apperr.CodeValidation,
+		},
+		{
+			name:     "panic recovery",
+			svc:      &panicActionService{},
+			wantCode: apperr.CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := &ActionHandler{actionService: tt.svc, verificationService: &mockVerificationService{}, gate: gate.New()}
+
+			res := h.DetectLanguage("Bonjour tout le monde")
+
+			if tt.wantCode == "" {
+				if res.Error != nil {
+					t.Fatalf("unexpected error: %+v", res.Error)
+				}
+				if res.Data == nil || res.Data.Language != tt.wantLang {
+					t.Errorf("Data = %+v, want language %q", res.Data, tt.wantLang)
+				}
+				return
+			}
+			if res.Error == nil || res.Error.Code != tt.wantCode {
+				t.Errorf("expected code=%s, got %+v", tt.wantCode, res.Error)
+			}
+		})
+	}
+}
+
+// ─── ApplyEdits ──────────────────────────────────────────────────────────────
+
+func TestActionHandler_ApplyEdits(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		svc      ActionServiceAPI
+		wantText string
+		wantCode apperr.ErrorCode
+	}{
+		{
+			name:     "success",
+			svc:      &mockActionService{applyResult: "Their house"},
+			wantText: "Their house",
+		},
+		{
+			name:     "validation error passes through",
+			svc:      &mockActionService{applyErr: apperr.Validation("edits", "spans that match their original text", "x")},
+			wantCode: apperr.CodeValidation,
+		},
+		{
+			name:     "panic recovery",
+			svc:      &panicActionService{},
+			wantCode: apperr.CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := &ActionHandler{actionService: tt.svc, verificationService: &mockVerificationService{}, gate: gate.New()}
+
+			res := h.ApplyEdits(apperr.ApplyEditsRequest{
+				Text:  "There house",
+				Edits: []apperr.TextEdit{{Start: 0, End: 5, Original: "There", Replacement: "Their"}},
+			})
+
+			if tt.wantCode == "" {
+				if res.Error != nil {
+					t.Fatalf("unexpected error: %+v", res.Error)
+				}
+				if res.Data != tt.wantText {
+					t.Errorf("Data = %q, want %q", res.Data, tt.wantText)
+				}
+				return
+			}
+			if res.Error == nil || res.Error.Code != tt.wantCode {
+				t.Errorf("expected code=%s, got %+v", tt.wantCode, res.Error)
+			}
+		})
+	}
+}
 
 // ─── CancelAllRuns ───────────────────────────────────────────────────────────
 
@@ -2,8 +2,11 @@ package actions
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -13,21 +16,60 @@ import (
 	"go_text/internal/logging"
 	"go_text/internal/prompts"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"resty.dev/v3"
 )
 
 // recordingHistoryService captures Record() calls.
 // It always records (no enabled-check) — simulates history enabled at the service layer.
+// Record is mutex-guarded because fan-out branches record concurrently.
 type recordingHistoryService struct {
+	mu       sync.Mutex
 	recorded []apperr.HistoryEntry
 }
 
-func (r *recordingHistoryService) Record(e apperr.HistoryEntry)                   { r.recorded = append(r.recorded, e) }
+func (r *recordingHistoryService) Record(e apperr.HistoryEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recorded = append(r.recorded, e)
+}
+func (r *recordingHistoryService) ListByRun(_ string) ([]apperr.HistoryEntry, error) {
+	return nil, nil
+}
 func (r *recordingHistoryService) List(_, _ int64) ([]apperr.HistoryEntry, error) { return nil, nil }
-func (r *recordingHistoryService) Get(_ string) (*apperr.HistoryEntry, error)     { return nil, nil }
-func (r *recordingHistoryService) Delete(_ string) error                          { return nil }
-func (r *recordingHistoryService) Clear() error                                   { return nil }
-func (r *recordingHistoryService) Count() (int64, error)                          { return 0, nil }
+func (r *recordingHistoryService) Get(id string) (*apperr.HistoryEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.recorded {
+		if r.recorded[i].ID == id {
+			e := r.recorded[i]
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("entry %q not found", id)
+}
+func (r *recordingHistoryService) Delete(_ string) error { return nil }
+func (r *recordingHistoryService) Clear() error          { return nil }
+func (r *recordingHistoryService) Count() (int64, error) { return 0, nil }
+func (r *recordingHistoryService) Search(apperr.HistorySearchRequest) ([]apperr.HistorySearchHit, error) {
+	return nil, nil
+}
+func (r *recordingHistoryService) SetPinned(string, bool) error           { return nil }
+func (r *recordingHistoryService) SetFavorite(string, bool) error         { return nil }
+func (r *recordingHistoryService) AddTag(string, string) error            { return nil }
+func (r *recordingHistoryService) RemoveTag(string, string) error         { return nil }
+func (r *recordingHistoryService) ListTags() ([]apperr.HistoryTag, error) { return nil, nil }
+func (r *recordingHistoryService) DeleteTag(string) error                 { return nil }
+func (r *recordingHistoryService) ExportHistory(apperr.HistoryArchiveRequest) (*apperr.HistoryArchive, error) {
+	return nil, nil
+}
+func (r *recordingHistoryService) ImportHistory(string) (*apperr.HistoryImportSummary, error) {
+	return nil, nil
+}
+func (r *recordingHistoryService) ExportTrackedChanges(string, string, string) (string, error) {
+	return "", nil
+}
 
 // newChainServiceWithRecording wires a real ActionService with a recording history service.
 // Reuses orchestratorSettings and testSettingsCfg from orchestrator_test.go (same package).
@@ -97,6 +139,36 @@ func TestRunChain_RecordsHistory_Success_SingleStep(t *testing.T) {
 	if e.DurationMs < 0 {
 		t.Errorf("durationMs = %d, want >= 0", e.DurationMs)
 	}
+	// "test input" → "improved text": both words replaced.
+	if e.ChangeRatio != 1 {
+		t.Errorf("changeRatio = %v, want 1", e.ChangeRatio)
+	}
+}
+
+func TestRunChain_SkipHistory_RecordsNothing(t *testing.T) {
+	t.Parallel()
+	srv := completionServerFor(t, []string{"improved text"})
+	defer srv.Close()
+
+	hist := &recordingHistoryService{}
+	svc := newChainServiceWithRecording(t, srv.URL, hist)
+	actionID := oneFamilyStep(t, svc)
+
+	result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:       "run-hist-skip",
+		InputText:   "test input",
+		Steps:       []apperr.ChainStep{{ActionID: actionID}},
+		SkipHistory: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunChain error: %v", err)
+	}
+	if result.FinalText != "improved text" {
+		t.Errorf("FinalText = %q, want \"improved text\"", result.FinalText)
+	}
+	if len(hist.recorded) != 0 {
+		t.Errorf("expected no history entries, got %d", len(hist.recorded))
+	}
 }
 
 func TestRunChain_RecordsHistory_StepFailed_StatusError(t *testing.T) {
@@ -125,6 +197,9 @@ func TestRunChain_RecordsHistory_StepFailed_StatusError(t *testing.T) {
 	if e.ErrorCode == "" {
 		t.Error("expected non-empty errorCode on failure")
 	}
+	if e.ChangeRatio != 0 {
+		t.Errorf("changeRatio = %v, want 0 without output", e.ChangeRatio)
+	}
 }
 
 func TestRunChain_RecordsHistory_MultiStep_KindStack(t *testing.T) {
@@ -220,3 +295,50 @@ func TestRunChain_RecordsHistory_Cancelled_NoPanic(t *testing.T) {
 	}, nil)
 	// Must not panic. Entry may or may not be recorded depending on cancellation timing.
 }
+
+func TestRunChain_RecordsHistory_Steps(t *testing.T) {
+	t.Parallel()
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"role": "assistant", "content": fmt.Sprintf("step%d output", n)}},
+			},
+			"usage": map[string]any{"prompt_tokens": 10, "completion_tokens": 4, "total_tokens": 14},
+		})
+	}))
+	defer srv.Close()
+
+	hist := &recordingHistoryService{}
+	svc := newChainServiceWithRecording(t, srv.URL, hist)
+	id0, id1 := twoFamilySteps(t, svc)
+
+	_, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:     "run-hist-steps",
+		InputText: "input",
+		Steps:     []apperr.ChainStep{{ActionID: id0}, {ActionID: id1}},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, hist.recorded, 1)
+	entry := hist.recorded[0]
+	require.Equal(t, 2, entry.Inferences, "one call per group")
+	require.Len(t, entry.Steps, 2)
+
+	assert.Equal(t, "input", entry.Steps[0].InputText)
+	assert.Equal(t, "step1 output", entry.Steps[0].OutputText)
+	assert.Equal(t, "step1 output", entry.Steps[1].InputText, "each step starts from the previous output")
+	assert.Equal(t, "step2 output", entry.Steps[1].OutputText)
+	for i, s := range entry.Steps {
+		assert.Equal(t, i, s.GroupIndex)
+		assert.NotEmpty(t, s.Family)
+		assert.Len(t, s.ActionIDs, 1)
+		assert.Len(t, s.SystemHash, 64, "hex SHA-256")
+		assert.Len(t, s.UserHash, 64, "hex SHA-256")
+		assert.Equal(t, 10, s.PromptTokens)
+		assert.Equal(t, 4, s.CompletionTokens)
+	}
+	assert.ElementsMatch(t, []string{id0, id1}, append(entry.Steps[0].ActionIDs, entry.Steps[1].ActionIDs...))
+	assert.NotEqual(t, entry.Steps[0].UserHash, entry.Steps[1].UserHash)
+}
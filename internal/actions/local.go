@@ -0,0 +1,317 @@
+package actions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go_text/internal/apperr"
+	v3 "go_text/internal/prompts/v3"
+)
+
+// localTransform is the in-process implementation of one FamilyLocal action.
+// params holds the resolved value of every parameter the action declares.
+type localTransform func(text string, params map[string]string) string
+
+// localTransforms maps every FamilyLocal action ID in the catalog to its
+// implementation.
+var localTransforms = map[string]localTransform{
+	"local.whitespace.normalize": normalizeWhitespace,
+	"local.quotes.straight":      straightQuotes,
+	"local.quotes.smart":         smartQuotes,
+	"local.case.sentence":        sentenceCase,
+	"local.case.title":           titleCase,
+	"local.markdown.strip":       stripMarkdown,
+	"local.lines.dedupe":         dedupeLines,
+	"local.lines.sort":           sortLines,
+	"local.wrap":                 wrapLines,
+	"local.stats":                textStats,
+}
+
+// isLocal reports whether g runs in-process instead of calling the model.
+func (g Group) isLocal() bool { return g.Family == v3.FamilyLocal }
+
+// countInferences returns how many of groups call the model.
+func countInferences(groups []Group) int {
+	n := 0
+	for _, g := range groups {
+		if !g.isLocal() {
+			n++
+		}
+	}
+	return n
+}
+
+// runLocalGroup applies the steps of local group g to text in order. It only
+// fails for an action without an implementation, which is a catalog authoring bug.
+func runLocalGroup(g Group, catalog map[string]apperr.ActionMeta, text string) (string, error) {
+	for _, s := range g.Steps {
+		transform, ok := localTransforms[s.ActionID]
+		if !ok {
+			return "", fmt.Errorf("local action %q has no implementation", s.ActionID)
+		}
+		text = transform(text, resolveParams(catalog[s.ActionID], s))
+	}
+	return text, nil
+}
+
+var (
+	multiSpaceRe = regexp.MustCompile(`[ \t]{2,}`)
+	blankRunRe   = regexp.MustCompile(`\n{3,}`)
+)
+
+// normalizeWhitespace unifies line endings, replaces non-breaking and other
+// fixed-width spaces, drops zero-width characters and trailing spaces, collapses
+// runs of spaces after each line's indentation, and keeps at most one blank line
+// between paragraphs.
+func normalizeWhitespace(text string, _ map[string]string) string {
+	text = strings.NewReplacer(
+		"\r\n", "\n", "\r", "\n",
+		"\u00a0", " ", "\u2007", " ", "\u202f", " ",
+		"\u200b", "", "\u200c", "", "\u200d", "", "\ufeff", "",
+	).Replace(text)
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		l = strings.TrimRight(l, " \t")
+		body := strings.TrimLeft(l, " \t")
+		lines[i] = l[:len(l)-len(body)] + multiSpaceRe.ReplaceAllString(body, " ")
+	}
+	text = blankRunRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.Trim(text, "\n")
+}
+
+// straightQuotes replaces typographic quotes with ASCII ones.
+func straightQuotes(text string, _ map[string]string) string {
+	return strings.NewReplacer(
+		"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "«", `"`, "»", `"`,
+		"‘", "'", "’", "'", "‚", "'", "‛", "'",
+	).Replace(text)
+}
+
+// smartQuotes replaces straight quotes with typographic ones: a quote opens at
+// the start of the text or after whitespace or an opening bracket, and closes
+// otherwise, so apostrophes inside words become ’.
+func smartQuotes(text string, _ map[string]string) string {
+	var b strings.Builder
+	prev := ' '
+	for _, r := range text {
+		opening := unicode.IsSpace(prev) || strings.ContainsRune("([{<—–“‘", prev)
+		switch {
+		case r == '"' && opening:
+			b.WriteRune('“')
+		case r == '"':
+			b.WriteRune('”')
+		case r == '\'' && opening:
+			b.WriteRune('‘')
+		case r == '\'':
+			b.WriteRune('’')
+		default:
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	return b.String()
+}
+
+// sentenceCase lower-cases text and capitalizes the first letter of the text,
+// of every line and after sentence-ending punctuation.
+func sentenceCase(text string, _ map[string]string) string {
+	var b strings.Builder
+	capNext := true
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) && capNext:
+			b.WriteRune(unicode.ToUpper(r))
+			capNext = false
+		case r == '.' || r == '!' || r == '?' || r == '\n':
+			b.WriteRune(r)
+			capNext = true
+		default:
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				capNext = false
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// minorWords stay lower-case inside a title-cased line.
+var minorWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "but": true, "or": true, "nor": true,
+	"for": true, "so": true, "yet": true, "as": true, "at": true, "by": true, "in": true,
+	"of": true, "off": true, "on": true, "per": true, "to": true, "up": true, "via": true,
+}
+
+// titleCase capitalizes every word of every line, keeping minorWords lower-case
+// unless they start or end the line. Letters after the first are left as they
+// are, so acronyms survive.
+func titleCase(text string, _ map[string]string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		words := strings.Split(line, " ")
+		first, last := -1, -1
+		for j, w := range words {
+			if w != "" {
+				if first < 0 {
+					first = j
+				}
+				last = j
+			}
+		}
+		for j, w := range words {
+			if w == "" {
+				continue
+			}
+			lower := strings.ToLower(w)
+			if j != first && j != last && minorWords[strings.TrimFunc(lower, unicode.IsPunct)] {
+				words[j] = lower
+				continue
+			}
+			words[j] = capitalizeFirstLetter(w)
+		}
+		lines[i] = strings.Join(words, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// capitalizeFirstLetter upper-cases the first letter of w, skipping leading
+// punctuation such as an opening quote.
+func capitalizeFirstLetter(w string) string {
+	for i, r := range w {
+		if unicode.IsLetter(r) {
+			return w[:i] + string(unicode.ToUpper(r)) + w[i+utf8.RuneLen(r):]
+		}
+	}
+	return w
+}
+
+// markdownRules are applied in order by stripMarkdown; block-level rules come
+// first so that list and rule markers are not mistaken for emphasis.
+var markdownRules = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile("(?m)^[ \\t]*(?:```|~~~).*\\n?"), ""},
+	{regexp.MustCompile(`(?m)^[ \t]*(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`), ""},
+	{regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+(.*?)[ \t#]*$`), "$1"},
+	{regexp.MustCompile(`(?m)^[ \t]*>[ \t]?`), ""},
+	{regexp.MustCompile(`(?m)^([ \t]*)[-*+][ \t]+(?:\[[ xX]\][ \t]+)?`), "$1"},
+	{regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`), "$1"},
+	{regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`), "$1"},
+	{regexp.MustCompile(`\*\*(.+?)\*\*`), "$1"},
+	{regexp.MustCompile(`__(.+?)__`), "$1"},
+	{regexp.MustCompile(`~~(.+?)~~`), "$1"},
+	{regexp.MustCompile(`\*([^*\n]+)\*`), "$1"},
+	{regexp.MustCompile(`(^|[^\w])_([^_\n]+)_([^\w]|$)`), "$1$2$3"},
+	{regexp.MustCompile("`([^`\\n]+)`"), "$1"},
+}
+
+// stripMarkdown removes Markdown syntax and keeps the text it marks up: heading
+// and quote markers, bullets, rules, fences, emphasis, inline code, and link and
+// image targets. Numbered list markers are kept, as they carry meaning in plain
+// text too.
+func stripMarkdown(text string, _ map[string]string) string {
+	for _, rule := range markdownRules {
+		text = rule.re.ReplaceAllString(text, rule.repl)
+	}
+	return strings.Trim(blankRunRe.ReplaceAllString(text, "\n\n"), "\n")
+}
+
+// dedupeLines removes every repeat of a line, ignoring trailing whitespace, and
+// keeps the first occurrence in place. Blank lines are never removed.
+func dedupeLines(text string, _ map[string]string) string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, l := range strings.Split(text, "\n") {
+		key := strings.TrimRight(l, " \t")
+		if key != "" && seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
+
+// sortLines sorts the non-blank lines case-insensitively in the "order"
+// parameter's direction. Equal lines keep their relative order.
+func sortLines(text string, params map[string]string) string {
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	desc := params["order"] == "descending"
+	sort.SliceStable(lines, func(i, j int) bool {
+		a, b := strings.ToLower(lines[i]), strings.ToLower(lines[j])
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+	return strings.Join(lines, "\n")
+}
+
+// wrapLines breaks every line longer than the "width" parameter between words,
+// repeating the line's indentation on its continuation lines. A single word
+// longer than the width is left on a line of its own.
+func wrapLines(text string, params map[string]string) string {
+	width, err := strconv.Atoi(params["width"])
+	if err != nil || width <= 0 {
+		return text
+	}
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		if utf8.RuneCountInString(line) <= width {
+			out = append(out, line)
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		current := ""
+		for _, w := range strings.Fields(line) {
+			if current != "" && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(w) > width {
+				out = append(out, current)
+				current = ""
+			}
+			if current == "" {
+				current = indent + w
+			} else {
+				current += " " + w
+			}
+		}
+		out = append(out, current)
+	}
+	return strings.Join(out, "\n")
+}
+
+// textStats replaces text with a report of its counts. Lines and paragraphs
+// count non-blank ones only.
+func textStats(text string, _ map[string]string) string {
+	lines, paragraphs := 0, 0
+	inParagraph := false
+	for _, l := range strings.Split(text, "\n") {
+		if strings.TrimSpace(l) == "" {
+			inParagraph = false
+			continue
+		}
+		lines++
+		if !inParagraph {
+			paragraphs++
+			inParagraph = true
+		}
+	}
+	nonSpace := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			nonSpace++
+		}
+	}
+	return fmt.Sprintf("Words: %d\nCharacters: %d\nCharacters (no spaces): %d\nLines: %d\nParagraphs: %d",
+		len(strings.Fields(text)), utf8.RuneCountInString(text), nonSpace, lines, paragraphs)
+}
@@ -0,0 +1,112 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	v3 "go_text/internal/prompts/v3"
+)
+
+func TestLocalTransforms(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     localTransform
+		params map[string]string
+		in     string
+		want   string
+	}{
+		{
+			name: "normalize whitespace",
+			fn:   normalizeWhitespace,
+			in:   "\r\n  Hello   world \t\r\n\n\n\nsecond\u200b line  \n",
+			want: "  Hello world\n\nsecond line",
+		},
+		{
+			name: "straight quotes",
+			fn:   straightQuotes,
+			in:   "“Don’t,” she said. „Ja“",
+			want: `"Don't," she said. "Ja"`,
+		},
+		{
+			name: "smart quotes",
+			fn:   smartQuotes,
+			in:   `"Don't," she said ('really').`,
+			want: "“Don’t,” she said (‘really’).",
+		},
+		{
+			name: "sentence case",
+			fn:   sentenceCase,
+			in:   "HELLO THERE. how ARE you?\nfine",
+			want: "Hello there. How are you?\nFine",
+		},
+		{
+			name: "title case",
+			fn:   titleCase,
+			in:   "the lord of the rings\nan API for the web",
+			want: "The Lord of the Rings\nAn API for the Web",
+		},
+		{
+			name: "strip markdown",
+			fn:   stripMarkdown,
+			in: "# Title\n\nSome **bold**, *italic* and `code` with a [link](http://x.test).\n\n" +
+				"- first\n* second\n1. numbered\n\n> quoted\n\n---\n\n```go\nx := 1\n```\nkeep_snake_case",
+			want: "Title\n\nSome bold, italic and code with a link.\n\nfirst\nsecond\n1. numbered\n\nquoted\n\nx := 1\nkeep_snake_case",
+		},
+		{
+			name: "dedupe lines",
+			fn:   dedupeLines,
+			in:   "a\nb\na \n\n\nb\nc",
+			want: "a\nb\n\n\nc",
+		},
+		{
+			name:   "sort lines ascending",
+			fn:     sortLines,
+			params: map[string]string{"order": "ascending"},
+			in:     "pear\n\nApple\nbanana",
+			want:   "Apple\nbanana\npear",
+		},
+		{
+			name:   "sort lines descending",
+			fn:     sortLines,
+			params: map[string]string{"order": "descending"},
+			in:     "pear\nApple\nbanana",
+			want:   "pear\nbanana\nApple",
+		},
+		{
+			name:   "wrap keeps indentation",
+			fn:     wrapLines,
+			params: map[string]string{"width": "20"},
+			in:     "  the quick brown fox jumps over the lazy dog\nshort",
+			want:   "  the quick brown\n  fox jumps over the\n  lazy dog\nshort",
+		},
+		{
+			name: "stats",
+			fn:   textStats,
+			in:   "one two\nthree\n\nfour",
+			want: "Words: 4\nCharacters: 19\nCharacters (no spaces): 15\nLines: 3\nParagraphs: 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.fn(tt.in, tt.params))
+		})
+	}
+}
+
+// TestLocalTransforms_CoverCatalog guards runLocalGroup: every local action in the
+// catalog needs an implementation, and every implementation a catalog entry.
+func TestLocalTransforms_CoverCatalog(t *testing.T) {
+	local := make(map[string]bool)
+	for _, m := range v3.Catalog() {
+		if m.Family != v3.FamilyLocal {
+			continue
+		}
+		local[m.ID] = true
+		assert.Contains(t, localTransforms, m.ID, "local action without implementation")
+	}
+	for id := range localTransforms {
+		assert.True(t, local[id], "implementation %q has no local catalog entry", id)
+	}
+}
@@ -2,12 +2,22 @@ package actions
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+
+	"github.com/rs/zerolog"
 
 	"go_text/internal/apperr"
+	"go_text/internal/diff"
+	"go_text/internal/langdetect"
+	"go_text/internal/llms"
 	v3 "go_text/internal/prompts/v3"
 	"go_text/internal/settings"
 )
@@ -24,18 +34,33 @@ const (
 // RunChain executes req sequentially through planned inference groups.
 //
 //   - Settings are resolved once and fixed for the whole chain.
-//   - emitProgress is called with "running" before each group and "done"/"failed" after.
-//     Pass nil to skip event emission.
+//   - emitProgress is called with "running" before each group and "done"/"failed" after,
+//     or once with "skipped" for a group whose step conditions do not hold (see
+//     groupConditions). Pass nil to skip event emission.
 //   - On step failure both a partial *ChainResult and a *apperr.AppError (CodeStepFailed) are returned.
 //   - On context cancellation both a partial *ChainResult and a *apperr.AppError (CodeCancelled) are returned.
 //   - On success the error is nil.
+//
+// Each group's output is checked for the expected language (see
+// expectedOutputLanguage) according to AppBehaviorConfig.LanguageCheck: a
+// mismatch is retried once with a language reminder, and a persisting one is
+// either reported in ChainResult.Warnings or fails the run with
+// CodeLanguageMismatch.
+//
+// An "auto" input language is replaced by the language detected from the input
+// text before planning, and the detection is reported on the result.
+// With a Selection, the groups transform only the selected range and the model
+// sees the rest of the text as context; FinalText (and each branch output) is
+// the whole input with only the selection replaced.
+// A linear run with a RunID is checkpointed after every group so that, when it
+// fails or is cancelled, ResumeChain can continue it.
+// A request with Branches fans out instead; see runFanOut.
 func (a *ActionService) RunChain(
 	ctx context.Context,
 	req apperr.ChainRequest,
 	emitProgress func(apperr.StepProgress),
 ) (*apperr.ChainResult, error) {
 	const op = "ActionService.RunChain"
-	startTime := time.Now()
 
 	lg := a.logger.WithOp(op).With().
 		Str("component", "actions").
@@ -45,100 +70,504 @@ func (a *ActionService) RunChain(
 	if strings.TrimSpace(req.InputText) == "" {
 		return nil, apperr.Validation("inputText", "non-empty text", "empty string")
 	}
-	if len(req.Steps) == 0 {
+	if len(req.Steps) == 0 && len(req.Branches) == 0 {
 		return nil, apperr.Validation("steps", "at least one step", "empty slice")
 	}
-
-	plan, err := a.planner.Plan(req)
+	selection, err := selectionRange(req)
 	if err != nil {
-		return nil, fmt.Errorf("%s: plan: %w", op, err)
+		return nil, err
 	}
 
 	cfg, err := a.settingsService.GetSettings()
 	if err != nil {
 		return nil, fmt.Errorf("%s: resolve settings: %w", op, err)
 	}
+	return a.runChainWith(ctx, lg, req, selection, cfg, emitProgress)
+}
+
+// runChainWith runs req, already validated, with cfg: the settings resolved
+// for the run by RunChain, or a recorded run's by ReplayChain.
+func (a *ActionService) runChainWith(
+	ctx context.Context,
+	lg zerolog.Logger,
+	req apperr.ChainRequest,
+	selection apperr.TextRange,
+	cfg *settings.Settings,
+	emitProgress func(apperr.StepProgress),
+) (*apperr.ChainResult, error) {
+	const op = "ActionService.runChainWith"
+	detected, err := resolveAutoInputLanguage(&req, selection, cfg.LanguageConfig.Languages)
+	if err != nil {
+		return nil, err
+	}
+	if detected != nil {
+		lg.Info().
+			Str("language", detected.Language).
+			Float64("confidence", detected.Confidence).
+			Msg("input language detected")
+	}
+
+	if len(req.Branches) > 0 {
+		result, err := a.runFanOut(ctx, req, selection, cfg, emitProgress)
+		if result != nil {
+			result.DetectedInputLanguage = detected
+		}
+		return result, err
+	}
+
+	plan, err := a.planner.PlanWithLimits(req, PlanLimitsFromSettings(cfg.AppBehaviorConfig))
+	if err != nil {
+		return nil, fmt.Errorf("%s: plan: %w", op, err)
+	}
 
-	total := len(plan.Groups)
 	lg.Info().
-		Int("groups", total).
+		Int("groups", len(plan.Groups)).
 		Int("steps", len(req.Steps)).
 		Msg("chain run starting")
 
-	emit := func(i, total int, family, status string) {
-		if emitProgress == nil {
-			return
+	result, err := a.runPlan(ctx, lg, req, selection, plan, cfg, newGroupRun(req, selection), 0, emitProgress)
+	result.DetectedInputLanguage = detected
+	return result, err
+}
+
+// ResumeChain continues the failed or cancelled linear run runID from its
+// first group that did not complete: the group that failed, or the one a
+// cancellation stopped before. The stored request is planned again with the
+// current settings and must still give the groups the run was saved with;
+// completed groups are not run again, and the next group starts from the text
+// the last one produced. Progress events, the result and its errors are those
+// of RunChain, with group indices, Completed and the history entry (updated
+// in place) covering the whole run.
+func (a *ActionService) ResumeChain(
+	ctx context.Context,
+	runID string,
+	emitProgress func(apperr.StepProgress),
+) (*apperr.ChainResult, error) {
+	const op = "ActionService.ResumeChain"
+
+	lg := a.logger.WithOp(op).With().
+		Str("component", "actions").
+		Str("run_id", runID).
+		Logger()
+
+	if strings.TrimSpace(runID) == "" {
+		return nil, apperr.Validation("runId", "be non-empty", "empty string")
+	}
+	if a.checkpoints == nil {
+		return nil, apperr.Internal(errors.New("checkpoint repository not initialized"))
+	}
+	cp, err := a.checkpoints.Get(runID)
+	if err != nil {
+		return nil, err
+	}
+	req := cp.Request
+	req.RunID = runID
+	selection, err := selectionRange(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := a.settingsService.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("%s: resolve settings: %w", op, err)
+	}
+	plan, err := a.planner.PlanWithLimits(req, PlanLimitsFromSettings(cfg.AppBehaviorConfig))
+	if err != nil {
+		return nil, fmt.Errorf("%s: plan: %w", op, err)
+	}
+	if !cp.matchesPlan(plan) {
+		return nil, apperr.Validation("runId", "name a run whose steps still plan to the same groups", "a changed plan")
+	}
+
+	from := cp.resumeFrom(newGroupRun(req, selection))
+	lg.Info().
+		Int("groups", len(plan.Groups)).
+		Int("from_group", from.completed).
+		Msg("chain run resuming")
+
+	prior := time.Duration(cp.DurationMs) * time.Millisecond
+	return a.runPlan(ctx, lg, req, selection, plan, cfg, from, prior, emitProgress)
+}
+
+// runPlan runs the groups of plan, for the linear request req, from the state
+// from on, then records the run's history entry and updates its checkpoint.
+// prior is the time earlier passes over the run took, counted in the recorded
+// duration. The result is never nil; the error is the run's, as for RunChain.
+func (a *ActionService) runPlan(
+	ctx context.Context,
+	lg zerolog.Logger,
+	req apperr.ChainRequest,
+	selection apperr.TextRange,
+	plan ChainPlan,
+	cfg *settings.Settings,
+	from groupRun,
+	prior time.Duration,
+	emitProgress func(apperr.StepProgress),
+) (*apperr.ChainResult, error) {
+	startTime := time.Now()
+	saved := a.startCheckpoint(req, plan, from)
+	run := a.runGroups(ctx, req, plan, cfg, from,
+		progressEmitter(emitProgress, req.RunID, "", len(plan.Groups)), saved)
+
+	result := &apperr.ChainResult{
+		FinalText:   spliceSelection(req, selection, run.text),
+		Completed:   run.completed,
+		FailedIndex: run.failedIndex,
+		Warnings:    run.warnings,
+		EditLists:   run.editLists,
+	}
+	if run.err != nil {
+		result.Error = run.err.Message
+	}
+	a.recordChainHistory(req, selection, plan, cfg, result, run, "", prior+time.Since(startTime))
+	if saved != nil {
+		a.finishCheckpoint(req.RunID, run, time.Since(startTime).Milliseconds())
+	}
+	logChainFinished(lg, run.status(), run.completed, startTime, run.runErr())
+	if run.err != nil {
+		return result, run.err
+	}
+	return result, nil
+}
+
+// resolveAutoInputLanguage replaces an "auto" input language on req with the one
+// of languages detected from the selection of req.InputText the chain runs on and
+// returns the detection. It returns nil for any other input language, and a
+// validation error when no language can be identified, so the user picks one
+// instead of the run guessing.
+func resolveAutoInputLanguage(req *apperr.ChainRequest, selection apperr.TextRange, languages []string) (*apperr.LanguageDetection, error) {
+	if !strings.EqualFold(strings.TrimSpace(req.InputLanguageID), settings.AutoInputLanguage) {
+		return nil, nil
+	}
+	d := langdetect.Detect(req.InputText[selection.Start:selection.End], languages)
+	if d.Language == "" {
+		return nil, apperr.Validation("inputLanguageId", "a language detectable from the input text", settings.AutoInputLanguage)
+	}
+	req.InputLanguageID = d.Language
+	return &apperr.LanguageDetection{Language: d.Language, Confidence: d.Confidence}, nil
+}
+
+// runFanOut runs a fan-out request: the shared prefix once, then every branch on
+// its output, at most AppBehaviorConfig.MaxParallelBranches at a time. Branch
+// progress events carry the branch name, and each branch records its own history
+// entry (shared prefix included) linked by req.RunID. A prefix failure ends the
+// run before any branch starts; a branch failure leaves the other branches
+// running, and the first failing branch's error (in request order) is returned
+// alongside the full result. emitProgress may be called from several goroutines.
+func (a *ActionService) runFanOut(
+	ctx context.Context,
+	req apperr.ChainRequest,
+	selection apperr.TextRange,
+	cfg *settings.Settings,
+	emitProgress func(apperr.StepProgress),
+) (*apperr.ChainResult, error) {
+	const op = "ActionService.runFanOut"
+	startTime := time.Now()
+
+	lg := a.logger.WithOp(op).With().
+		Str("component", "actions").
+		Str("run_id", req.RunID).
+		Logger()
+
+	plan, err := a.planner.PlanFanOut(req, PlanLimitsFromSettings(cfg.AppBehaviorConfig))
+	if err != nil {
+		return nil, fmt.Errorf("%s: plan: %w", op, err)
+	}
+
+	parallel := cfg.AppBehaviorConfig.MaxParallelBranches
+	if parallel <= 0 {
+		parallel = settings.DefaultMaxParallelBranches
+	}
+	lg.Info().
+		Int("prefix_groups", len(plan.Prefix.Groups)).
+		Int("branches", len(plan.Branches)).
+		Int("parallel", parallel).
+		Msg("fan-out run starting")
+
+	prefixReq := req
+	prefixReq.Branches = nil
+	prefix := a.runGroups(ctx, prefixReq, plan.Prefix, cfg, newGroupRun(req, selection),
+		progressEmitter(emitProgress, req.RunID, "", len(plan.Prefix.Groups)), nil)
+	if prefix.err != nil {
+		result := &apperr.ChainResult{
+			FinalText:   spliceSelection(req, selection, prefix.text),
+			Completed:   prefix.completed,
+			FailedIndex: prefix.failedIndex,
+			Error:       prefix.err.Message,
+			Warnings:    prefix.warnings,
+			EditLists:   prefix.editLists,
 		}
-		emitProgress(apperr.StepProgress{
-			RunID:       req.RunID,
-			GroupIndex:  i,
-			TotalGroups: total,
-			Family:      family,
-			Status:      status,
-		})
+		a.recordChainHistory(prefixReq, selection, plan.Prefix, cfg, result, prefix, "", time.Since(startTime))
+		logChainFinished(lg, prefix.status(), prefix.completed, startTime, prefix.err)
+		return result, prefix.err
 	}
 
-	input := req.InputText
-	completed := 0
-	inferences := 0
+	runs := make([]groupRun, len(plan.Branches))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, branch := range plan.Branches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			branchReq := req
+			branchReq.Steps = append(append([]apperr.ChainStep(nil), req.Steps...), req.Branches[i].Steps...)
+			branchReq.Branches = nil
+			path := plan.Path(i)
+
+			recorded := false
+			defer func() {
+				if r := recover(); r != nil {
+					runs[i] = prefix
+					runs[i].err = apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+					if recorded {
+						return
+					}
+					// Nothing the branch produced can be trusted, so its entry is
+					// an error on the input text rather than a partial run.
+					failed := newGroupRun(req, selection)
+					failed.err = runs[i].err
+					a.recordChainHistory(branchReq, selection, path, cfg, &apperr.ChainResult{
+						FinalText: req.InputText,
+						Error:     failed.err.Message,
+					}, failed, branch.Name, time.Since(startTime))
+				}
+			}()
+
+			run := a.runGroups(ctx, branchReq, path, cfg, prefix,
+				progressEmitter(emitProgress, req.RunID, branch.Name, len(path.Groups)), nil)
+			runs[i] = run
 
-	logFinished := func(status string, runErr error) {
-		ev := lg.Info()
-		if status == chainStatusFailed {
-			ev = lg.Error()
+			branchResult := &apperr.ChainResult{
+				FinalText:   spliceSelection(req, selection, run.text),
+				Completed:   run.completed,
+				FailedIndex: run.failedIndex,
+			}
+			if run.err != nil {
+				branchResult.Error = run.err.Message
+			}
+			recorded = true
+			a.recordChainHistory(branchReq, selection, path, cfg, branchResult, run, branch.Name, time.Since(startTime))
+		}()
+	}
+	wg.Wait()
+
+	result := &apperr.ChainResult{
+		Completed: prefix.completed,
+		Outputs:   make([]apperr.BranchOutput, len(runs)),
+		Warnings:  prefix.warnings,
+		EditLists: prefix.editLists,
+	}
+	var firstErr *apperr.AppError
+	for i, run := range runs {
+		// Every branch starts from the prefix, so its first warnings are the prefix's.
+		for _, w := range run.warnings[min(len(prefix.warnings), len(run.warnings)):] {
+			w.Branch = plan.Branches[i].Name
+			result.Warnings = append(result.Warnings, w)
+		}
+		for _, l := range run.editLists[min(len(prefix.editLists), len(run.editLists)):] {
+			l.Branch = plan.Branches[i].Name
+			result.EditLists = append(result.EditLists, l)
 		}
-		if runErr != nil {
-			ev = ev.Err(runErr)
+		out := apperr.BranchOutput{
+			Name:        plan.Branches[i].Name,
+			Text:        spliceSelection(req, selection, run.text),
+			Completed:   run.completed,
+			FailedIndex: run.failedIndex,
 		}
-		ev.Str("status", status).
-			Int("completed", completed).
-			Int64("duration_ms", time.Since(startTime).Milliseconds()).
-			Msg(chainFinishedMsg)
+		if run.err != nil {
+			out.Error = run.err.Message
+			if firstErr == nil {
+				firstErr = run.err
+			}
+		}
+		result.Completed += run.completed - prefix.completed
+		result.Outputs[i] = out
+	}
+	result.FinalText = result.Outputs[0].Text
+
+	if firstErr == nil {
+		logChainFinished(lg, chainStatusDone, result.Completed, startTime, nil)
+		return result, nil
+	}
+	if ctx.Err() != nil {
+		firstErr = apperr.Cancelled(result.Completed)
+	}
+	result.Error = firstErr.Message
+	status := chainStatusFailed
+	if firstErr.Code == apperr.CodeCancelled {
+		status = chainStatusCancelled
+	}
+	logChainFinished(lg, status, result.Completed, startTime, firstErr)
+	return result, firstErr
+}
+
+// groupRun is the state of one pass through a plan's groups: the text so far and
+// the language it is in, how many groups completed, which of those were skipped
+// how many called the LLM, the warnings it accepted, the edit lists its edit-list
+// groups proposed, and how the pass ended. A finished pass is also a
+// valid starting point for running further groups of a longer plan, which is how
+// fan-out branches continue from the prefix.
+type groupRun struct {
+	input       string           // the selection of the input text the run started from
+	selection   apperr.TextRange // where input lies in the request's input text
+	text        string
+	lang        string
+	completed   int
+	skipped     []int // indices of completed groups whose conditions did not hold
+	inferences  int
+	warnings    []apperr.ChainWarning
+	editLists   []apperr.EditList
+	steps       []apperr.HistoryStep // completed groups of this pass that were not skipped
+	failedIndex *int
+	err         *apperr.AppError // nil on success; CodeCancelled, CodeStepFailed or CodeLanguageMismatch otherwise
+}
+
+// newGroupRun returns the starting state for running req from its first group
+// on the selection of its input text.
+func newGroupRun(req apperr.ChainRequest, selection apperr.TextRange) groupRun {
+	input := req.InputText[selection.Start:selection.End]
+	return groupRun{input: input, selection: selection, text: input, lang: req.InputLanguageID}
+}
+
+// runErr returns err as an error interface value, nil when the pass succeeded.
+func (r groupRun) runErr() error {
+	if r.err == nil {
+		return nil
 	}
+	return r.err
+}
+
+// status maps the pass outcome to the chain-run status logged on completion.
+func (r groupRun) status() string {
+	switch {
+	case r.err == nil:
+		return chainStatusDone
+	case r.err.Code == apperr.CodeCancelled:
+		return chainStatusCancelled
+	default:
+		return chainStatusFailed
+	}
+}
+
+// runGroups runs plan.Groups from index from.completed on, starting with
+// from.text. Cancellation is checked before each group so the current group
+// always finishes. A group whose conditions do not hold counts as completed
+// without an LLM call and leaves the text unchanged. Local groups run in-process
+// and do not count as inferences. A group whose output is in
+// the wrong language is retried once; see checkOutputLanguage. An edit-list
+// group's reply becomes an apperr.EditList, and its text the input with every
+// placed edit applied. saved, when set, is called with the pass so far after
+// each group completes; see startCheckpoint.
+func (a *ActionService) runGroups(
+	ctx context.Context,
+	req apperr.ChainRequest,
+	plan ChainPlan,
+	cfg *settings.Settings,
+	from groupRun,
+	emit func(i int, family, status, skipReason string),
+	saved func(i int, run groupRun),
+) groupRun {
+	run := from
+	// from may be shared by concurrent fan-out branches; never append to its slices.
+	run.skipped = slices.Clone(from.skipped)
+	run.warnings = slices.Clone(from.warnings)
+	run.editLists = slices.Clone(from.editLists)
+	run.steps = slices.Clone(from.steps)
+	checkMode := cfg.AppBehaviorConfig.LanguageCheck
+	if checkMode == "" {
+		checkMode = settings.DefaultLanguageCheck
+	}
+	for i := from.completed; i < len(plan.Groups); i++ {
+		group := plan.Groups[i]
 
-	for i, group := range plan.Groups {
-		// Cooperative cancellation: checked before each group so the current group always finishes.
 		select {
 		case <-ctx.Done():
-			cancelErr := apperr.Cancelled(completed)
-			partialResult := &apperr.ChainResult{
-				FinalText: input,
-				Completed: completed,
-				Error:     cancelErr.Message,
-			}
-			a.recordChainHistory(req, plan, cfg, partialResult, cancelErr, completed, inferences, time.Since(startTime))
-			logFinished(chainStatusCancelled, cancelErr)
-			return partialResult, cancelErr
+			run.err = apperr.Cancelled(run.completed)
+			return run
 		default:
 		}
 
-		emit(i, total, group.Family, "running")
-
-		// Same-language translate short-circuit: skip LLM call, output == input.
-		if group.Family == v3.FamilyTranslate &&
-			strings.EqualFold(req.InputLanguageID, req.OutputLanguageID) {
-			completed++
-			emit(i, total, group.Family, "done")
+		env := conditionEnv{
+			input:        run.input,
+			inputLang:    req.InputLanguageID,
+			previous:     run.text,
+			previousLang: run.lang,
+		}
+		if reason := skipReason(group, req, env); reason != "" {
+			run.completed++
+			run.skipped = append(run.skipped, i)
+			emit(i, group.Family, "skipped", reason)
+			if saved != nil {
+				saved(i, run)
+			}
 			continue
 		}
 
-		sys, user := a.composer.Compose(group, input, req, cfg.InferenceBaseConfig.UseMarkdownForOutput)
+		emit(i, group.Family, "running", "")
 
-		actionIDs := make([]string, len(group.Steps))
-		for j, s := range group.Steps {
-			actionIDs[j] = s.ActionID
+		if group.isLocal() {
+			started := time.Now()
+			out, err := runLocalGroup(group, a.planner.catalog, run.text)
+			if err != nil {
+				emit(i, group.Family, "failed", "")
+				idx := i
+				run.failedIndex = &idx
+				run.err = apperr.StepFailed(i, group.Family, apperr.Internal(err))
+				return run
+			}
+			run.steps = append(run.steps, apperr.HistoryStep{
+				GroupIndex: i,
+				Family:     group.Family,
+				ActionIDs:  group.actionIDs(),
+				InputText:  run.text,
+				OutputText: out,
+				DurationMs: time.Since(started).Milliseconds(),
+			})
+			run.text = out
+			run.completed++
+			emit(i, group.Family, "done", "")
+			if saved != nil {
+				saved(i, run)
+			}
+			continue
 		}
 
-		out, stepErr := a.runStep(ctx, cfg, ChatStepRequest{
+		sys, user := a.composer.Compose(group, run.text, req, cfg.InferenceBaseConfig.UseMarkdownForOutput)
+
+		stepReq := ChatStepRequest{
 			System:      sys,
 			User:        user,
 			GroupFamily: group.Family,
-			ActionIDs:   actionIDs,
-			InputText:   input,
+			ActionIDs:   group.actionIDs(),
+			InputText:   run.text,
 			InputLang:   req.InputLanguageID,
 			OutputLang:  req.OutputLanguageID,
 			RunID:       req.RunID,
-		})
+		}
+		started := time.Now()
+		out, usage, stepErr := a.runStep(ctx, cfg, stepReq)
+		var edits *apperr.EditList
+		if stepErr == nil && group.editList() {
+			edits, out, stepErr = resolveEditList(i, group, run.text, out)
+			if stepErr == nil {
+				edits = rebaseEditList(edits, req.InputText, run.selection)
+			}
+		}
+		var mismatch *apperr.AppError
+		if stepErr == nil && edits == nil && checkMode != settings.LanguageCheckOff {
+			var retried bool
+			var retryUsage llms.TokenUsage
+			expected := expectedOutputLanguage(group.Family, req, run.lang)
+			out, retried, retryUsage, mismatch, stepErr = a.checkOutputLanguage(ctx, cfg, stepReq, out, i, expected)
+			if retried {
+				run.inferences++
+				usage.PromptTokens += retryUsage.PromptTokens
+				usage.CompletionTokens += retryUsage.CompletionTokens
+			}
+		}
 		if stepErr != nil {
 			var ae *apperr.AppError
 			isAppErr := errors.As(stepErr, &ae)
@@ -151,60 +580,236 @@ func (a *ActionService) RunChain(
 			// context.Canceled — which this run's ctx (context.WithCancel per-run, see
 			// ActionHandler.ProcessPromptChain) is the only source of.
 			if isAppErr && ae.Code == apperr.CodeCancelled {
-				cancelErr := apperr.Cancelled(completed)
-				partialResult := &apperr.ChainResult{
-					FinalText: input,
-					Completed: completed,
-					Error:     cancelErr.Message,
-				}
-				a.recordChainHistory(req, plan, cfg, partialResult, cancelErr, completed, inferences, time.Since(startTime))
-				logFinished(chainStatusCancelled, cancelErr)
-				return partialResult, cancelErr
+				run.err = apperr.Cancelled(run.completed)
+				return run
 			}
 
-			emit(i, total, group.Family, "failed")
+			emit(i, group.Family, "failed", "")
 			idx := i
 			if !isAppErr {
 				ae = apperr.Internal(stepErr)
 			}
-			wrapped := apperr.StepFailed(i, group.Family, ae)
-			failedResult := &apperr.ChainResult{
-				FinalText:   input,
-				Completed:   completed,
-				FailedIndex: &idx,
-				Error:       wrapped.Message,
+			run.failedIndex = &idx
+			run.err = apperr.StepFailed(i, group.Family, ae)
+			return run
+		}
+
+		if mismatch != nil {
+			if checkMode == settings.LanguageCheckFail {
+				emit(i, group.Family, "failed", "")
+				idx := i
+				run.failedIndex = &idx
+				run.err = mismatch
+				return run
 			}
-			a.recordChainHistory(req, plan, cfg, failedResult, wrapped, completed, inferences, time.Since(startTime))
-			logFinished(chainStatusFailed, wrapped)
-			return failedResult, wrapped
+			run.warnings = append(run.warnings, apperr.ChainWarning{
+				Code:       mismatch.Code,
+				Message:    mismatch.Message,
+				GroupIndex: i,
+				Family:     group.Family,
+			})
+		}
+
+		if edits != nil {
+			run.editLists = append(run.editLists, *edits)
+		}
+		run.steps = append(run.steps, apperr.HistoryStep{
+			GroupIndex:       i,
+			Family:           group.Family,
+			ActionIDs:        stepReq.ActionIDs,
+			InputText:        run.text,
+			OutputText:       out,
+			SystemHash:       promptHash(sys),
+			UserHash:         promptHash(user),
+			DurationMs:       time.Since(started).Milliseconds(),
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+		})
+		run.text = out
+		if group.Family == v3.FamilyTranslate {
+			run.lang = req.OutputLanguageID
+		}
+		run.completed++
+		run.inferences++
+		emit(i, group.Family, "done", "")
+		if saved != nil {
+			saved(i, run)
 		}
+	}
+	return run
+}
+
+// resolveEditList turns the reply out of edit-list group i, run on input, into
+// its edit list and the text with every placed edit applied.
+func resolveEditList(i int, group Group, input, out string) (*apperr.EditList, string, error) {
+	proposed, err := parseEditList(out)
+	if err != nil {
+		return nil, "", err
+	}
+	edits, rejected := placeEdits(input, proposed)
+	text, err := applyEdits(input, edits)
+	if err != nil {
+		return nil, "", err
+	}
+	return &apperr.EditList{
+		GroupIndex: i,
+		ActionID:   group.Steps[0].ActionID,
+		Input:      input,
+		Edits:      edits,
+		Rejected:   rejected,
+	}, text, nil
+}
+
+const (
+	// minMismatchConfidence is the detection confidence below which an output is
+	// not treated as being in the wrong language; mixed-script outputs are given
+	// the benefit of the doubt.
+	minMismatchConfidence = 0.5
+	// minMismatchLetters is the shortest output, in letters, whose language is
+	// checked; a few words are too little to tell languages apart reliably.
+	minMismatchLetters = 40
+)
+
+// expectedOutputLanguage returns the language a group of family should answer
+// in: the output language for translate, the language of the text it was given
+// for families that rewrite that text, and "" for families whose output language
+// is not tied to either (prompt engineering).
+func expectedOutputLanguage(family string, req apperr.ChainRequest, current string) string {
+	switch family {
+	case v3.FamilyTranslate:
+		return req.OutputLanguageID
+	case v3.FamilyRewrite, v3.FamilyStructure, v3.FamilySummarize:
+		return current
+	default:
+		return ""
+	}
+}
 
-		input = out
-		completed++
-		inferences++
-		emit(i, total, group.Family, "done")
+// detectMismatch returns the language out is confidently written in when that is
+// not expected, or "" when out matches, is in a language closely related to
+// expected (Serbian for Croatian), is shorter than minMismatchLetters, cannot be
+// identified, or expected has no detection profile.
+func detectMismatch(out, expected string) string {
+	if strings.TrimSpace(expected) == "" || !langdetect.Supports(expected) {
+		return ""
+	}
+	if countLetters(out) < minMismatchLetters {
+		return ""
 	}
+	d := langdetect.Detect(out, nil)
+	if d.Language == "" || d.Confidence < minMismatchConfidence || langdetect.Related(d.Language, expected) {
+		return ""
+	}
+	return d.Language
+}
 
-	successResult := &apperr.ChainResult{FinalText: input, Completed: completed}
-	a.recordChainHistory(req, plan, cfg, successResult, nil, completed, inferences, time.Since(startTime))
-	logFinished(chainStatusDone, nil)
-	return successResult, nil
+// countLetters returns the number of letters in s.
+func countLetters(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n
 }
 
-// recordChainHistory builds and records one HistoryEntry per RunChain call.
+// checkOutputLanguage verifies that out, group index's output for stepReq, is in
+// expected. On a mismatch the group is run once more with a language reminder
+// appended to the user prompt, and retried reports that the extra call was made.
+// A mismatch that survives the retry is returned as a CodeLanguageMismatch error
+// alongside the retried output, for the caller to fail on or accept with a
+// warning. usage is the retry's token usage and err its own failure.
+func (a *ActionService) checkOutputLanguage(
+	ctx context.Context,
+	cfg *settings.Settings,
+	stepReq ChatStepRequest,
+	out string,
+	index int,
+	expected string,
+) (text string, retried bool, usage llms.TokenUsage, mismatch *apperr.AppError, err error) {
+	if detectMismatch(out, expected) == "" {
+		return out, false, usage, nil, nil
+	}
+	stepReq.User += fmt.Sprintf(languageReminderFmt, expected)
+	out, usage, err = a.runStep(ctx, cfg, stepReq)
+	if err != nil {
+		return "", true, usage, nil, err
+	}
+	if detected := detectMismatch(out, expected); detected != "" {
+		return out, true, usage, apperr.LanguageMismatch(index, stepReq.GroupFamily, expected, detected), nil
+	}
+	return out, true, usage, nil, nil
+}
+
+// promptHash returns the hex SHA-256 of prompt, recorded with each history step
+// so runs can be compared without storing the prompts themselves.
+func promptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// progressEmitter adapts emitProgress (nil-safe) to the per-group callback used
+// by runGroups, stamping the run ID, branch name and group total on each event.
+func progressEmitter(emitProgress func(apperr.StepProgress), runID, branch string, total int) func(i int, family, status, skipReason string) {
+	return func(i int, family, status, skipReason string) {
+		if emitProgress == nil {
+			return
+		}
+		emitProgress(apperr.StepProgress{
+			RunID:       runID,
+			GroupIndex:  i,
+			TotalGroups: total,
+			Family:      family,
+			Status:      status,
+			Branch:      branch,
+			SkipReason:  skipReason,
+		})
+	}
+}
+
+// logChainFinished logs the terminal chain-run line with its outcome status.
+func logChainFinished(lg zerolog.Logger, status string, completed int, startTime time.Time, runErr error) {
+	ev := lg.Info()
+	if status == chainStatusFailed {
+		ev = lg.Error()
+	}
+	if runErr != nil {
+		ev = ev.Err(runErr)
+	}
+	ev.Str("status", status).
+		Int("completed", completed).
+		Int64("duration_ms", time.Since(startTime).Milliseconds()).
+		Msg(chainFinishedMsg)
+}
+
+// recordChainHistory builds and records one HistoryEntry per RunChain call, or per
+// branch of a fan-out run. The entry of a linear run is keyed by req.RunID; branch
+// entries get generated IDs and are linked to their run through RunID instead.
+// Actions of skipped groups are listed with Skipped set. A selection-scoped run
+// records the whole input and output texts plus the selected range of the input.
+// Every group of the pass that ran is recorded as a step; see apperr.HistoryStep.
+// The entry keeps a snapshot of req and cfg that ReplayChain can run again.
+// Nothing is recorded when req.SkipHistory is set.
 // All errors are swallowed by historyService.Record — recording never breaks a run.
 func (a *ActionService) recordChainHistory(
 	req apperr.ChainRequest,
+	selection apperr.TextRange,
 	plan ChainPlan,
 	cfg *settings.Settings,
 	result *apperr.ChainResult,
-	runErr error,
-	completed int,
-	inferences int,
+	run groupRun,
+	branch string,
 	duration time.Duration,
 ) {
+	if req.SkipHistory {
+		return
+	}
+	completed := run.completed
+	runErr := run.runErr()
 	applied := make([]apperr.AppliedAction, 0)
 	for i := 0; i < completed && i < len(plan.Groups); i++ {
+		skipped := slices.Contains(run.skipped, i)
 		for _, step := range plan.Groups[i].Steps {
 			for _, m := range a.catalog {
 				if m.ID == step.ActionID {
@@ -212,6 +817,8 @@ func (a *ActionService) recordChainHistory(
 						ID:       m.ID,
 						Name:     m.Name,
 						Category: m.Category,
+						Params:   resolveParams(m, step),
+						Skipped:  skipped,
 					})
 					break
 				}
@@ -255,6 +862,10 @@ func (a *ActionService) recordChainHistory(
 	if result != nil {
 		outputText = result.FinalText
 	}
+	changeRatio := 0.0
+	if outputText != "" {
+		changeRatio = diff.ChangeRatio(req.InputText, outputText)
+	}
 
 	providerName := ""
 	model := ""
@@ -263,8 +874,17 @@ func (a *ActionService) recordChainHistory(
 		model = cfg.ModelConfig.Name
 	}
 
+	id := req.RunID
+	if branch != "" {
+		id = ""
+	}
+	var selected *apperr.TextRange
+	if req.Selection != nil {
+		selected = &selection
+	}
+
 	a.historyService.Record(apperr.HistoryEntry{
-		ID:           req.RunID,
+		ID:           id,
 		Kind:         kind,
 		Title:        title,
 		InputText:    req.InputText,
@@ -274,10 +894,18 @@ func (a *ActionService) recordChainHistory(
 		Model:        model,
 		InputLang:    req.InputLanguageID,
 		OutputLang:   req.OutputLanguageID,
+		Format:       outputFormat(cfg),
 		DurationMs:   duration.Milliseconds(),
-		Inferences:   inferences,
+		Inferences:   run.inferences,
 		Status:       status,
 		ErrorCode:    errorCode,
 		FailedIndex:  failedIndex,
+		RunID:        req.RunID,
+		Branch:       branch,
+		ChangeRatio:  changeRatio,
+		Selection:    selected,
+		RerunOf:      req.RerunOf,
+		Steps:        run.steps,
+		Snapshot:     a.runSnapshot(req, cfg),
 	})
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -17,6 +19,7 @@ import (
 	"go_text/internal/llms"
 	"go_text/internal/logging"
 	"go_text/internal/prompts"
+	v3 "go_text/internal/prompts/v3"
 	"go_text/internal/settings"
 	"go_text/internal/tasklog"
 
@@ -59,10 +62,31 @@ type noopHistoryService struct{}
 
 func (n *noopHistoryService) Record(_ apperr.HistoryEntry)                   {}
 func (n *noopHistoryService) List(_, _ int64) ([]apperr.HistoryEntry, error) { return nil, nil }
-func (n *noopHistoryService) Get(_ string) (*apperr.HistoryEntry, error)     { return nil, nil }
-func (n *noopHistoryService) Delete(_ string) error                          { return nil }
-func (n *noopHistoryService) Clear() error                                   { return nil }
-func (n *noopHistoryService) Count() (int64, error)                          { return 0, nil }
+func (n *noopHistoryService) ListByRun(_ string) ([]apperr.HistoryEntry, error) {
+	return nil, nil
+}
+func (n *noopHistoryService) Get(_ string) (*apperr.HistoryEntry, error) { return nil, nil }
+func (n *noopHistoryService) Delete(_ string) error                      { return nil }
+func (n *noopHistoryService) Clear() error                               { return nil }
+func (n *noopHistoryService) Count() (int64, error)                      { return 0, nil }
+func (n *noopHistoryService) Search(apperr.HistorySearchRequest) ([]apperr.HistorySearchHit, error) {
+	return nil, nil
+}
+func (n *noopHistoryService) SetPinned(string, bool) error           { return nil }
+func (n *noopHistoryService) SetFavorite(string, bool) error         { return nil }
+func (n *noopHistoryService) AddTag(string, string) error            { return nil }
+func (n *noopHistoryService) RemoveTag(string, string) error         { return nil }
+func (n *noopHistoryService) ListTags() ([]apperr.HistoryTag, error) { return nil, nil }
+func (n *noopHistoryService) DeleteTag(string) error                 { return nil }
+func (n *noopHistoryService) ExportHistory(apperr.HistoryArchiveRequest) (*apperr.HistoryArchive, error) {
+	return nil, nil
+}
+func (n *noopHistoryService) ImportHistory(string) (*apperr.HistoryImportSummary, error) {
+	return nil, nil
+}
+func (n *noopHistoryService) ExportTrackedChanges(string, string, string) (string, error) {
+	return "", nil
+}
 
 // orchestratorSettings is a stubSettingsService variant that returns a real
 // *settings.Settings pointing at the given provider URL.
@@ -157,14 +181,14 @@ func newTestChainServiceWithTaskLog(t *testing.T, serverURL string, taskLog task
 	return NewActionService(wlog, promptSvc, llmSvc, settingsSvc, taskLog, &noopHistoryService{})
 }
 
-// twoFamilySteps returns action IDs for two steps from different families
+// twoFamilySteps returns action IDs for two steps from different model families
 // so the Planner creates exactly 2 inference groups.
 func twoFamilySteps(t *testing.T, svc ActionServiceAPI) (id0, id1 string) {
 	t.Helper()
 	catalog := svc.GetActionCatalog()
 	families := map[string]string{}
 	for _, m := range catalog {
-		if _, seen := families[m.Family]; !seen && len(m.Requires) == 0 {
+		if _, seen := families[m.Family]; !seen && len(m.Requires) == 0 && m.Family != "local" {
 			families[m.Family] = m.ID
 		}
 		if len(families) >= 2 {
@@ -179,11 +203,11 @@ func twoFamilySteps(t *testing.T, svc ActionServiceAPI) (id0, id1 string) {
 	return families[fams[0]], families[fams[1]]
 }
 
-// oneFamilyStep returns one action ID that requires no extra params.
+// oneFamilyStep returns one model action ID that requires no extra params.
 func oneFamilyStep(t *testing.T, svc ActionServiceAPI) string {
 	t.Helper()
 	for _, m := range svc.GetActionCatalog() {
-		if len(m.Requires) == 0 {
+		if len(m.Requires) == 0 && m.Family != "local" {
 			return m.ID
 		}
 	}
@@ -503,6 +527,49 @@ func TestRunChain_RunID_FlowsIntoTaskLogEntry(t *testing.T) {
 	}
 }
 
+func TestRunChain_CleanOutput(t *testing.T) {
+	t.Parallel()
+	const reply = "Sure! Here's the rewritten text:\n\nHello, world.\n\nNote: I added a comma after the greeting."
+
+	for _, clean := range []bool{true, false} {
+		t.Run(fmt.Sprintf("cleanOutput=%v", clean), func(t *testing.T) {
+			t.Parallel()
+			server := completionServerFor(t, []string{reply})
+			defer server.Close()
+
+			wlog, err := logging.New(logging.DefaultConfig(), false)
+			require.NoError(t, err)
+			cfg := testSettingsCfg(server.URL)
+			cfg.AppBehaviorConfig.CleanOutput = clean
+			settingsSvc := &orchestratorSettings{cfg: cfg}
+			llmSvc := llms.NewLLMApiService(wlog, llms.NewProviderFactory(resty.New().SetTimeout(10*time.Second)), settingsSvc)
+			capture := &captureTaskLog{}
+			svc := NewActionService(wlog, prompts.NewPromptService(wlog), llmSvc, settingsSvc, capture, &noopHistoryService{})
+
+			result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+				RunID:     "run-clean",
+				InputText: "hello world",
+				Steps:     []apperr.ChainStep{{ActionID: "rewrite.proofread.basic"}},
+			}, nil)
+			require.NoError(t, err)
+
+			entries := capture.capturedEntries()
+			require.Len(t, entries, 1)
+			if !clean {
+				assert.Equal(t, reply, result.FinalText)
+				assert.Empty(t, entries[0].Stripped)
+				return
+			}
+			assert.Equal(t, "Hello, world.", result.FinalText)
+			assert.Equal(t, "Hello, world.", entries[0].OutputText)
+			assert.Equal(t, []tasklog.StrippedText{
+				{Rule: stripPreamble, Text: "Sure! Here's the rewritten text:"},
+				{Rule: stripPostamble, Text: "Note: I added a comma after the greeting."},
+			}, entries[0].Stripped)
+		})
+	}
+}
+
 // TestRunChain_RunID_FlowsIntoEachGroupsTaskLogEntry verifies RunID is threaded
 // consistently across every group in a multi-group chain, not just the first.
 func TestRunChain_RunID_FlowsIntoEachGroupsTaskLogEntry(t *testing.T) {
@@ -574,6 +641,557 @@ func TestRunChain_SameLanguageTranslate_NoLLMCall(t *testing.T) {
 	assert.Equal(t, int64(0), atomic.LoadInt64(&called), "no LLM call made")
 }
 
+func TestRunChain_ConditionalStep_SkippedInProgressAndHistory(t *testing.T) {
+	t.Parallel()
+	var called int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&called, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"role": "assistant", "content": "rewritten"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	hist := &recordingHistoryService{}
+	svc := newChainServiceWithRecording(t, server.URL, hist)
+	id0, id1 := twoFamilySteps(t, svc)
+
+	var events []apperr.StepProgress
+	req := apperr.ChainRequest{
+		RunID:     "run-conditional",
+		InputText: "far too short to summarize",
+		Steps: []apperr.ChainStep{
+			{ActionID: id0},
+			{ActionID: id1, When: []apperr.StepCondition{{Subject: "input", Check: "minWords", Value: "400"}}},
+		},
+	}
+	result, err := svc.RunChain(context.Background(), req, func(p apperr.StepProgress) { events = append(events, p) })
+
+	require.NoError(t, err)
+	assert.Equal(t, "rewritten", result.FinalText)
+	assert.Equal(t, 2, result.Completed, "a skipped group still counts as completed")
+	assert.Equal(t, int64(1), atomic.LoadInt64(&called), "only the unconditional group calls the LLM")
+
+	var skipped []apperr.StepProgress
+	for _, e := range events {
+		if e.Status == "skipped" {
+			skipped = append(skipped, e)
+		}
+	}
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "input minWords 400 (got 5)", skipped[0].SkipReason)
+	assert.Len(t, events, 3, "running+done for the run group, one skipped event")
+
+	require.Len(t, hist.recorded, 1)
+	e := hist.recorded[0]
+	assert.Equal(t, "success", e.Status)
+	assert.Equal(t, 1, e.Inferences)
+	for _, a := range e.Applied {
+		assert.Equal(t, a.ID == id1, a.Skipped, "applied action %q", a.ID)
+	}
+}
+
+func TestRunChain_LocalSteps_RunInProcess(t *testing.T) {
+	t.Parallel()
+	var called int64
+	var gotUser string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&called, 1)
+		body, _ := io.ReadAll(r.Body)
+		gotUser = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"role": "assistant", "content": "## Result\n\nThe **fixed** text."}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	hist := &recordingHistoryService{}
+	svc := newChainServiceWithRecording(t, server.URL, hist)
+	var events []apperr.StepProgress
+	result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:     "run-local",
+		InputText: "the   fixd  text",
+		Steps: []apperr.ChainStep{
+			{ActionID: "local.whitespace.normalize"},
+			{ActionID: "rewrite.proofread.basic"},
+			{ActionID: "local.markdown.strip"},
+		},
+	}, func(p apperr.StepProgress) { events = append(events, p) })
+
+	require.NoError(t, err)
+	assert.Equal(t, "Result\n\nThe fixed text.", result.FinalText)
+	assert.Equal(t, 3, result.Completed)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&called), "only the proofread group calls the model")
+	assert.Contains(t, gotUser, "the fixd text", "the model sees the normalized input")
+
+	var statuses []string
+	for _, e := range events {
+		statuses = append(statuses, e.Family+":"+e.Status)
+	}
+	assert.Equal(t, []string{
+		"local:running", "local:done",
+		"rewrite:running", "rewrite:done",
+		"local:running", "local:done",
+	}, statuses)
+
+	require.Len(t, hist.recorded, 1)
+	assert.Equal(t, 1, hist.recorded[0].Inferences)
+	assert.Len(t, hist.recorded[0].Applied, 3)
+}
+
+func TestRunChain_EditList(t *testing.T) {
+	t.Parallel()
+	server := completionServerFor(t, []string{"```json\n" + `{"edits":[` +
+		`{"original":"Teh","replacement":"The","category":"spelling","explanation":"Typo."},` +
+		`{"original":"sta","replacement":"sat","category":"spelling"},` +
+		`{"original":"dog","replacement":"cat","category":"grammar"}]}` + "\n```"})
+	defer server.Close()
+
+	svc := newTestChainService(t, server.URL)
+	result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:     "run-edits",
+		InputText: "Teh cat sta down.",
+		Steps:     []apperr.ChainStep{{ActionID: "rewrite.proofread.basic", Output: v3.OutputEdits}},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "The cat sat down.", result.FinalText)
+	require.Len(t, result.EditLists, 1)
+	list := result.EditLists[0]
+	assert.Equal(t, 0, list.GroupIndex)
+	assert.Equal(t, "rewrite.proofread.basic", list.ActionID)
+	assert.Equal(t, "Teh cat sta down.", list.Input)
+	assert.Equal(t, []apperr.TextEdit{
+		{Start: 0, End: 3, Original: "Teh", Replacement: "The", Category: "spelling", Explanation: "Typo."},
+		{Start: 8, End: 11, Original: "sta", Replacement: "sat", Category: "spelling"},
+	}, list.Edits)
+	assert.Equal(t, []apperr.RejectedEdit{{Original: "dog", Replacement: "cat", Reason: rejectNotFound}}, list.Rejected)
+
+	applied, err := svc.ApplyEdits(list.Input, list.Edits[1:])
+	require.NoError(t, err)
+	assert.Equal(t, "Teh cat sat down.", applied)
+}
+
+func TestRunChain_EditList_WithSelection(t *testing.T) {
+	t.Parallel()
+	server := completionServerFor(t, []string{`{"edits":[` +
+		`{"original":"Teh","replacement":"The","category":"spelling"},` +
+		`{"original":"sta","replacement":"sat","category":"spelling"}]}`})
+	defer server.Close()
+
+	const doc = "Teh intro stays.\n\nTeh cat sta down.\n\nOutro stays."
+	start := strings.Index(doc, "Teh cat")
+	end := start + len("Teh cat sta down.")
+
+	svc := newTestChainService(t, server.URL)
+	result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:     "run-edits-selection",
+		InputText: doc,
+		Steps:     []apperr.ChainStep{{ActionID: "rewrite.proofread.basic", Output: v3.OutputEdits}},
+		Selection: &apperr.ChainSelection{Start: start, End: end},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Teh intro stays.\n\nThe cat sat down.\n\nOutro stays.", result.FinalText, "only the selection is edited")
+	require.Len(t, result.EditLists, 1)
+	list := result.EditLists[0]
+	assert.Equal(t, doc, list.Input)
+	require.Len(t, list.Edits, 2)
+	assert.Equal(t, start, list.Edits[0].Start, "offsets count from the start of the document")
+	for _, e := range list.Edits {
+		assert.Equal(t, e.Original, doc[e.Start:e.End])
+	}
+
+	applied, err := svc.ApplyEdits(doc, list.Edits[1:])
+	require.NoError(t, err)
+	assert.Equal(t, "Teh intro stays.\n\nTeh cat sat down.\n\nOutro stays.", applied)
+}
+
+func TestRunChain_EditList_UnreadableReply(t *testing.T) {
+	t.Parallel()
+	server := completionServerFor(t, []string{"The cat sat down."})
+	defer server.Close()
+
+	svc := newTestChainService(t, server.URL)
+	result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:     "run-edits-bad",
+		InputText: "Teh cat sta down.",
+		Steps:     []apperr.ChainStep{{ActionID: "rewrite.proofread.basic", Output: v3.OutputEdits}},
+	}, nil)
+
+	var ae *apperr.AppError
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeStepFailed, ae.Code)
+	assert.Equal(t, string(apperr.CodeInvalidEdits), ae.Details["innerCode"])
+	require.NotNil(t, result)
+	assert.Equal(t, "Teh cat sta down.", result.FinalText)
+	assert.Empty(t, result.EditLists)
+}
+
+func TestRunChain_Selection_ReplacesOnlySelection(t *testing.T) {
+	t.Parallel()
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"role": "assistant", "content": "The middle part, polished."}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	const doc = "Intro stays.\n\nmiddle part,, rough\n\nOutro stays."
+	start := strings.Index(doc, "middle")
+	end := start + len("middle part,, rough")
+
+	hist := &recordingHistoryService{}
+	svc := newChainServiceWithRecording(t, server.URL, hist)
+	result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:     "run-selection",
+		InputText: doc,
+		Steps:     []apperr.ChainStep{{ActionID: "rewrite.proofread.basic"}},
+		Selection: &apperr.ChainSelection{Start: start, End: end},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Intro stays.\n\nThe middle part, polished.\n\nOutro stays.", result.FinalText)
+
+	var chat struct {
+		Messages []struct{ Content string } `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(gotBody), &chat))
+	user := chat.Messages[len(chat.Messages)-1].Content
+	assert.Contains(t, user, "<<<UserText Start>>>\nmiddle part,, rough\n<<<UserText End>>>")
+	assert.Contains(t, user, "<<<Context Before Start>>>\nIntro stays.")
+	assert.Contains(t, user, "Outro stays.\n<<<Context After End>>>")
+
+	require.Len(t, hist.recorded, 1)
+	entry := hist.recorded[0]
+	assert.Equal(t, doc, entry.InputText)
+	assert.Equal(t, result.FinalText, entry.OutputText)
+	assert.Equal(t, &apperr.TextRange{Start: start, End: end}, entry.Selection)
+}
+
+func TestRunChain_Selection_FanOutBranchesSpliced(t *testing.T) {
+	t.Parallel()
+	server := completionServerFor(t, []string{"X"})
+	defer server.Close()
+
+	svc := newTestChainService(t, server.URL)
+	result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:     "run-selection-fan",
+		InputText: "keep [this] keep",
+		Branches: []apperr.ChainBranch{
+			{Name: "a", Steps: []apperr.ChainStep{{ActionID: "rewrite.proofread.basic"}}},
+			{Name: "b", Steps: []apperr.ChainStep{{ActionID: "rewrite.tone.professional"}}},
+		},
+		Selection: &apperr.ChainSelection{Start: 5, End: 11, Unit: SelectionUnitRune},
+	}, nil)
+
+	require.NoError(t, err)
+	require.Len(t, result.Outputs, 2)
+	for _, out := range result.Outputs {
+		assert.Equal(t, "keep X keep", out.Text, out.Name)
+	}
+	assert.Equal(t, "keep X keep", result.FinalText)
+}
+
+func TestRunChain_Selection_InvalidRange(t *testing.T) {
+	t.Parallel()
+	svc := newTestChainService(t, "http://placeholder")
+	_, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		InputText: "short",
+		Steps:     []apperr.ChainStep{{ActionID: "rewrite.proofread.basic"}},
+		Selection: &apperr.ChainSelection{Start: 2, End: 40},
+	}, nil)
+
+	var ae *apperr.AppError
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeValidation, ae.Code)
+}
+
+func TestRunChain_Selection_ConditionsAndDetectionReadTheSelection(t *testing.T) {
+	t.Parallel()
+	// A long English document with a short French sentence selected in the middle.
+	filler := strings.Repeat("The quarterly report covers revenue, costs and hiring plans in detail. ", 60)
+	const selected = "Merci pour votre message, je regarde cela demain matin."
+	doc := filler + selected + " " + filler
+	sel := &apperr.ChainSelection{Start: len(filler), End: len(filler) + len(selected)}
+
+	t.Run("input condition counts the selection", func(t *testing.T) {
+		var called int64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt64(&called, 1)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"choices": []map[string]any{
+					{"message": map[string]any{"role": "assistant", "content": "Merci pour votre message."}},
+				},
+			})
+		}))
+		defer server.Close()
+
+		result, err := newTestChainService(t, server.URL).RunChain(context.Background(), apperr.ChainRequest{
+			RunID:     "run-selection-condition",
+			InputText: doc,
+			Steps: []apperr.ChainStep{{
+				ActionID: "rewrite.proofread.basic",
+				When:     []apperr.StepCondition{{Subject: "input", Check: "maxWords", Value: "20"}},
+			}},
+			Selection: sel,
+		}, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), atomic.LoadInt64(&called), "the selection is short enough to run")
+		assert.Equal(t, filler+"Merci pour votre message. "+filler, result.FinalText)
+	})
+
+	t.Run("auto input language is detected from the selection", func(t *testing.T) {
+		var called int64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt64(&called, 1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		result, err := newTestChainService(t, server.URL).RunChain(context.Background(), apperr.ChainRequest{
+			RunID:            "run-selection-auto-lang",
+			InputText:        doc,
+			Steps:            []apperr.ChainStep{{ActionID: "translate.text"}},
+			InputLanguageID:  "auto",
+			OutputLanguageID: "French",
+			Selection:        sel,
+		}, nil)
+
+		require.NoError(t, err)
+		require.NotNil(t, result.DetectedInputLanguage)
+		assert.Equal(t, "French", result.DetectedInputLanguage.Language)
+		assert.Equal(t, int64(0), atomic.LoadInt64(&called), "French to French needs no LLM call")
+		assert.Equal(t, doc, result.FinalText)
+	})
+}
+
+func TestRunChain_AutoInputLanguage(t *testing.T) {
+	t.Parallel()
+	var called int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&called, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	svc := newTestChainService(t, server.URL)
+	var translateID string
+	for _, m := range svc.GetActionCatalog() {
+		if m.Family == "translate" {
+			translateID = m.ID
+			break
+		}
+	}
+	require.NotEmpty(t, translateID, "catalog must have a translate action")
+
+	t.Run("detected language feeds the translate short-circuit", func(t *testing.T) {
+		result, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+			RunID:            "run-auto-lang",
+			InputText:        "Merci pour votre message. Je vais examiner le problème cet après-midi.",
+			Steps:            []apperr.ChainStep{{ActionID: translateID}},
+			InputLanguageID:  "auto",
+			OutputLanguageID: "French",
+		}, nil)
+
+		require.NoError(t, err)
+		require.NotNil(t, result.DetectedInputLanguage)
+		assert.Equal(t, "French", result.DetectedInputLanguage.Language)
+		assert.Greater(t, result.DetectedInputLanguage.Confidence, 0.0)
+		assert.Equal(t, int64(0), atomic.LoadInt64(&called), "French to French needs no LLM call")
+	})
+
+	t.Run("undetectable input is a validation error", func(t *testing.T) {
+		_, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+			RunID:            "run-auto-lang-none",
+			InputText:        "12345 67890",
+			Steps:            []apperr.ChainStep{{ActionID: translateID}},
+			InputLanguageID:  "auto",
+			OutputLanguageID: "French",
+		}, nil)
+
+		var ae *apperr.AppError
+		require.True(t, errors.As(err, &ae))
+		assert.Equal(t, apperr.CodeValidation, ae.Code)
+	})
+}
+
+func TestRunChain_OutputLanguageCheck(t *testing.T) {
+	t.Parallel()
+	const (
+		english = "Thank you for your message. I will look into the problem this afternoon and get back to you."
+		german  = "Vielen Dank für Ihre Nachricht. Ich werde mir das Problem heute Nachmittag ansehen und mich melden."
+	)
+
+	// languageServer answers each call with the next of responses (repeating the
+	// last) and records the user prompt of every call.
+	languageServer := func(t *testing.T, responses ...string) (*httptest.Server, *[]string) {
+		var mu sync.Mutex
+		var users []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Messages []struct {
+					Role    string `json:"role"`
+					Content string `json:"content"`
+				} `json:"messages"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			users = append(users, body.Messages[len(body.Messages)-1].Content)
+			text := responses[min(len(users), len(responses))-1]
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"choices": []map[string]any{
+					{"message": map[string]any{"role": "assistant", "content": text}},
+				},
+			})
+		}))
+		t.Cleanup(server.Close)
+		return server, &users
+	}
+	newService := func(t *testing.T, serverURL, mode string, hist *recordingHistoryService) ActionServiceAPI {
+		wlog, err := logging.New(logging.DefaultConfig(), false)
+		require.NoError(t, err)
+		cfg := testSettingsCfg(serverURL)
+		cfg.AppBehaviorConfig.LanguageCheck = mode
+		settingsSvc := &orchestratorSettings{cfg: cfg}
+		llmSvc := llms.NewLLMApiService(wlog, llms.NewProviderFactory(resty.New().SetTimeout(10*time.Second)), settingsSvc)
+		return NewActionService(wlog, prompts.NewPromptService(wlog), llmSvc, settingsSvc, &noopTaskLog{}, hist)
+	}
+	req := apperr.ChainRequest{
+		RunID:            "run-lang-check",
+		InputText:        english,
+		Steps:            []apperr.ChainStep{{ActionID: "translate.text"}},
+		InputLanguageID:  "English",
+		OutputLanguageID: "German",
+	}
+
+	t.Run("retry with reminder fixes the language", func(t *testing.T) {
+		server, calls := languageServer(t, english, german)
+		hist := &recordingHistoryService{}
+		result, err := newService(t, server.URL, settings.LanguageCheckFail, hist).RunChain(context.Background(), req, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, german, result.FinalText)
+		assert.Empty(t, result.Warnings)
+		require.Len(t, *calls, 2)
+		assert.NotContains(t, (*calls)[0], "write the entire result in German")
+		assert.Contains(t, (*calls)[1], "write the entire result in German")
+		require.Len(t, hist.recorded, 1)
+		assert.Equal(t, 2, hist.recorded[0].Inferences, "the retry counts as an inference")
+	})
+
+	t.Run("warn mode keeps the output and reports it", func(t *testing.T) {
+		server, calls := languageServer(t, english)
+		result, err := newService(t, server.URL, settings.LanguageCheckWarn, &recordingHistoryService{}).RunChain(context.Background(), req, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, english, result.FinalText)
+		assert.Len(t, *calls, 2)
+		require.Len(t, result.Warnings, 1)
+		assert.Equal(t, apperr.CodeLanguageMismatch, result.Warnings[0].Code)
+		assert.Equal(t, 0, result.Warnings[0].GroupIndex)
+		assert.Equal(t, "translate", result.Warnings[0].Family)
+	})
+
+	t.Run("fail mode ends the run", func(t *testing.T) {
+		server, _ := languageServer(t, english)
+		hist := &recordingHistoryService{}
+		result, err := newService(t, server.URL, settings.LanguageCheckFail, hist).RunChain(context.Background(), req, nil)
+
+		var ae *apperr.AppError
+		require.True(t, errors.As(err, &ae))
+		assert.Equal(t, apperr.CodeLanguageMismatch, ae.Code)
+		assert.Equal(t, "German", ae.Details["expected"])
+		assert.Equal(t, "English", ae.Details["detected"])
+		require.NotNil(t, result.FailedIndex)
+		assert.Equal(t, 0, *result.FailedIndex)
+		assert.Equal(t, english, result.FinalText, "the input is kept")
+		require.Len(t, hist.recorded, 1)
+		assert.Equal(t, string(apperr.CodeLanguageMismatch), hist.recorded[0].ErrorCode)
+	})
+
+	t.Run("off mode accepts the output without retrying", func(t *testing.T) {
+		server, calls := languageServer(t, english)
+		result, err := newService(t, server.URL, settings.LanguageCheckOff, &recordingHistoryService{}).RunChain(context.Background(), req, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, english, result.FinalText)
+		assert.Empty(t, result.Warnings)
+		assert.Len(t, *calls, 1)
+	})
+}
+
+func TestDetectMismatch(t *testing.T) {
+	t.Parallel()
+	const (
+		english  = "Thank you for your message. I will look into the problem this afternoon and get back to you."
+		serbian  = "Hvala vam na poruci. Pogledaću taj problem danas popodne i javiću vam se što pre."
+		croatian = "Hvala vam na poruci. Pogledat ću taj problem danas poslijepodne i javit ću vam se što prije."
+	)
+	tests := []struct {
+		name     string
+		out      string
+		expected string
+		want     string
+	}{
+		{name: "other language", out: english, expected: "German", want: "English"},
+		{name: "expected language", out: english, expected: "english"},
+		{name: "too short to tell", out: "OK thanks, see you soon.", expected: "German"},
+		{name: "Serbian for Croatian", out: serbian, expected: "Croatian"},
+		{name: "Croatian for Serbian", out: croatian, expected: "Serbian"},
+		{name: "Serbian for Bosnian without a profile", out: serbian, expected: "Bosnian"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectMismatch(tt.out, tt.expected))
+		})
+	}
+}
+
+func TestActionService_DetectLanguage(t *testing.T) {
+	t.Parallel()
+	cfg := testSettingsCfg("http://unused")
+	cfg.LanguageConfig.Languages = []string{"english", "Ukrainian"}
+	wlog, err := logging.New(logging.DefaultConfig(), false)
+	require.NoError(t, err)
+	settingsSvc := &orchestratorSettings{cfg: cfg}
+	llmSvc := llms.NewLLMApiService(wlog, llms.NewProviderFactory(resty.New()), settingsSvc)
+	svc := NewActionService(wlog, prompts.NewPromptService(wlog), llmSvc, settingsSvc, &noopTaskLog{}, &noopHistoryService{})
+
+	got, err := svc.DetectLanguage("Дякую за ваше повідомлення, я подивлюся сьогодні.")
+	require.NoError(t, err)
+	assert.Equal(t, "Ukrainian", got.Language)
+
+	got, err = svc.DetectLanguage("Thank you for your message.")
+	require.NoError(t, err)
+	assert.Equal(t, "english", got.Language, "returned as spelled in the language list")
+
+	got, err = svc.DetectLanguage("Merci beaucoup")
+	require.NoError(t, err)
+	assert.Equal(t, "english", got.Language, "only configured languages are candidates")
+
+	_, err = svc.DetectLanguage("   ")
+	var ae *apperr.AppError
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, apperr.CodeValidation, ae.Code)
+}
+
 // ── Handler-level tests ───────────────────────────────────────────────────────
 
 func TestActionHandler_ProcessPromptChain_Success(t *testing.T) {
@@ -797,3 +1415,218 @@ func TestActionHandler_RunAndTestInference_MutuallyExclusive(t *testing.T) {
 	require.NotNil(t, res.Error)
 	assert.Equal(t, string(apperr.CodeBusy), string(res.Error.Code))
 }
+
+// ── Fan-out ───────────────────────────────────────────────────────────────────
+
+// fanOutServer answers each completion with a label for the directive it saw —
+// SUMMARY, BULLETS, or PREFIX for anything else — and fails summary requests when
+// failSummary is set. It also records the peak number of concurrent requests.
+func fanOutServer(t *testing.T, failSummary bool, peak *int64) *httptest.Server {
+	t.Helper()
+	var inFlight int64
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			p := atomic.LoadInt64(peak)
+			if n <= p || atomic.CompareAndSwapInt64(peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		body, _ := io.ReadAll(r.Body)
+		label := "PREFIX"
+		switch {
+		case strings.Contains(string(body), "Write a concise summary"):
+			label = "SUMMARY"
+		case strings.Contains(string(body), "as a bullet list"):
+			label = "BULLETS"
+		}
+		if failSummary && label == "SUMMARY" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":{"message":"fail","type":"server_error"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"role": "assistant", "content": label}},
+			},
+		})
+	}))
+}
+
+func newFanOutService(t *testing.T, serverURL string, parallel int, hist *recordingHistoryService) ActionServiceAPI {
+	t.Helper()
+	wlog, err := logging.New(logging.DefaultConfig(), false)
+	require.NoError(t, err)
+	cfg := testSettingsCfg(serverURL)
+	cfg.AppBehaviorConfig.MaxParallelBranches = parallel
+	settingsSvc := &orchestratorSettings{cfg: cfg}
+	factory := llms.NewProviderFactory(resty.New().SetTimeout(10 * time.Second))
+	llmSvc := llms.NewLLMApiService(wlog, factory, settingsSvc)
+	return NewActionService(wlog, prompts.NewPromptService(wlog), llmSvc, settingsSvc, &noopTaskLog{}, hist)
+}
+
+func fanOutRequest(branches ...string) apperr.ChainRequest {
+	actions := map[string]string{"summary": "summarize.summary", "bullets": "structure.format.bullets"}
+	req := apperr.ChainRequest{
+		RunID:     "run-fan",
+		InputText: "meeting transcript",
+		Steps:     []apperr.ChainStep{{ActionID: "rewrite.proofread.basic"}},
+	}
+	for _, b := range branches {
+		req.Branches = append(req.Branches, apperr.ChainBranch{Name: b, Steps: []apperr.ChainStep{{ActionID: actions[b]}}})
+	}
+	return req
+}
+
+func TestRunChain_FanOut_NamedOutputsProgressAndHistory(t *testing.T) {
+	t.Parallel()
+	var peak int64
+	server := fanOutServer(t, false, &peak)
+	defer server.Close()
+	hist := &recordingHistoryService{}
+	svc := newFanOutService(t, server.URL, 2, hist)
+
+	var mu sync.Mutex
+	var events []apperr.StepProgress
+	emit := func(p apperr.StepProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, p)
+	}
+
+	result, err := svc.RunChain(context.Background(), fanOutRequest("summary", "bullets"), emit)
+
+	require.NoError(t, err)
+	require.Len(t, result.Outputs, 2)
+	assert.Equal(t, apperr.BranchOutput{Name: "summary", Text: "SUMMARY", Completed: 2}, result.Outputs[0])
+	assert.Equal(t, apperr.BranchOutput{Name: "bullets", Text: "BULLETS", Completed: 2}, result.Outputs[1])
+	assert.Equal(t, "SUMMARY", result.FinalText)
+	assert.Equal(t, 3, result.Completed, "prefix once plus one group per branch")
+
+	byBranch := map[string][]apperr.StepProgress{}
+	for _, e := range events {
+		byBranch[e.Branch] = append(byBranch[e.Branch], e)
+	}
+	require.Len(t, byBranch[""], 2)
+	assert.Equal(t, 1, byBranch[""][0].TotalGroups)
+	for _, name := range []string{"summary", "bullets"} {
+		require.Len(t, byBranch[name], 2, name)
+		assert.Equal(t, 1, byBranch[name][0].GroupIndex, "branch groups follow the prefix on the path")
+		assert.Equal(t, 2, byBranch[name][0].TotalGroups)
+	}
+
+	require.Len(t, hist.recorded, 2)
+	got := map[string]apperr.HistoryEntry{}
+	for _, e := range hist.recorded {
+		got[e.Branch] = e
+	}
+	for name, text := range map[string]string{"summary": "SUMMARY", "bullets": "BULLETS"} {
+		e := got[name]
+		assert.Equal(t, "run-fan", e.RunID, name)
+		assert.Empty(t, e.ID, "branch entries get generated IDs")
+		assert.Equal(t, text, e.OutputText)
+		assert.Equal(t, 2, e.Inferences, "entry covers the shared prefix and the branch")
+		assert.Equal(t, "success", e.Status)
+	}
+}
+
+func TestRunChain_FanOut_BranchFailureKeepsOtherBranches(t *testing.T) {
+	t.Parallel()
+	var peak int64
+	server := fanOutServer(t, true, &peak)
+	defer server.Close()
+	hist := &recordingHistoryService{}
+	svc := newFanOutService(t, server.URL, 2, hist)
+
+	result, err := svc.RunChain(context.Background(), fanOutRequest("summary", "bullets"), nil)
+
+	require.Error(t, err)
+	var ae *apperr.AppError
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, apperr.CodeStepFailed, ae.Code)
+	require.NotNil(t, result)
+	require.Len(t, result.Outputs, 2)
+	assert.Equal(t, "PREFIX", result.Outputs[0].Text, "failed branch keeps the last good text")
+	require.NotNil(t, result.Outputs[0].FailedIndex)
+	assert.Equal(t, 1, *result.Outputs[0].FailedIndex)
+	assert.NotEmpty(t, result.Outputs[0].Error)
+	assert.Equal(t, "BULLETS", result.Outputs[1].Text)
+	assert.Empty(t, result.Outputs[1].Error)
+
+	statuses := map[string]string{}
+	for _, e := range hist.recorded {
+		statuses[e.Branch] = e.Status
+	}
+	assert.Equal(t, map[string]string{"summary": "partial", "bullets": "success"}, statuses)
+}
+
+func TestRunChain_FanOut_PanickedBranchRecordsHistory(t *testing.T) {
+	t.Parallel()
+	var peak int64
+	server := fanOutServer(t, false, &peak)
+	defer server.Close()
+	hist := &recordingHistoryService{}
+	svc := newFanOutService(t, server.URL, 2, hist)
+
+	emit := func(p apperr.StepProgress) {
+		if p.Branch == "bullets" {
+			panic("boom")
+		}
+	}
+	result, err := svc.RunChain(context.Background(), fanOutRequest("summary", "bullets"), emit)
+
+	var ae *apperr.AppError
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, apperr.CodeInternal, ae.Code)
+	require.Len(t, result.Outputs, 2)
+	assert.Equal(t, "SUMMARY", result.Outputs[0].Text)
+	assert.NotEmpty(t, result.Outputs[1].Error)
+
+	require.Len(t, hist.recorded, 2)
+	got := map[string]apperr.HistoryEntry{}
+	for _, e := range hist.recorded {
+		got[e.Branch] = e
+	}
+	assert.Equal(t, "success", got["summary"].Status)
+	assert.Equal(t, "error", got["bullets"].Status)
+	assert.Equal(t, string(apperr.CodeInternal), got["bullets"].ErrorCode)
+	assert.Equal(t, "run-fan", got["bullets"].RunID)
+}
+
+func TestRunChain_FanOut_RespectsParallelLimit(t *testing.T) {
+	t.Parallel()
+	for _, parallel := range []int{1, 2} {
+		var peak int64
+		server := fanOutServer(t, false, &peak)
+		svc := newFanOutService(t, server.URL, parallel, &recordingHistoryService{})
+
+		req := fanOutRequest("summary", "bullets")
+		req.Branches = append(req.Branches, apperr.ChainBranch{
+			Name: "numbered", Steps: []apperr.ChainStep{{ActionID: "structure.format.numbered"}},
+		})
+		_, err := svc.RunChain(context.Background(), req, nil)
+		server.Close()
+
+		require.NoError(t, err)
+		assert.LessOrEqual(t, atomic.LoadInt64(&peak), int64(parallel), "parallel=%d", parallel)
+	}
+}
+
+func TestRunChain_FanOut_InvalidPlanRejectedBeforeAnyCall(t *testing.T) {
+	t.Parallel()
+	var peak int64
+	server := fanOutServer(t, false, &peak)
+	defer server.Close()
+	svc := newFanOutService(t, server.URL, 2, &recordingHistoryService{})
+
+	_, err := svc.RunChain(context.Background(), fanOutRequest("summary"), nil)
+
+	var ae *apperr.AppError
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, apperr.CodeValidation, ae.Code)
+	assert.Zero(t, atomic.LoadInt64(&peak))
+}
@@ -0,0 +1,92 @@
+package actions
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go_text/internal/apperr"
+	v3 "go_text/internal/prompts/v3"
+)
+
+// resolveParams returns the effective value of every parameter declared by meta for
+// step s: the step's own value when set, the spec default otherwise. Returns nil for
+// actions without a parameter schema so callers can omit the field on the wire.
+func resolveParams(meta apperr.ActionMeta, s apperr.ChainStep) map[string]string {
+	if len(meta.Params) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(meta.Params))
+	for _, p := range meta.Params {
+		v := strings.TrimSpace(s.Params[p.Name])
+		if v == "" {
+			v = p.Default
+		}
+		out[p.Name] = v
+	}
+	return out
+}
+
+// applyParams substitutes every {{name}} token declared by meta's schema into
+// directive with the resolved value for step s. Substitution is a single pass,
+// so a value that itself contains a {{token}} is inserted verbatim and never
+// expanded again.
+func applyParams(directive string, meta apperr.ActionMeta, s apperr.ChainStep) string {
+	pairs := paramReplacements(meta, s)
+	if len(pairs) == 0 {
+		return directive
+	}
+	return strings.NewReplacer(pairs...).Replace(directive)
+}
+
+// paramReplacements returns the strings.NewReplacer old/new pairs that
+// substitute step s's resolved parameters, in sorted name order.
+func paramReplacements(meta apperr.ActionMeta, s apperr.ChainStep) []string {
+	values := resolveParams(meta, s)
+	pairs := make([]string, 0, 2*len(values))
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		pairs = append(pairs, "{{"+name+"}}", values[name])
+	}
+	return pairs
+}
+
+// checkParamValue validates one resolved value against its spec and returns a
+// human-readable reason on failure, or "" when the value is acceptable.
+func checkParamValue(spec apperr.ParamSpec, value string) string {
+	if strings.Contains(value, "{{") {
+		// Would read as a template token in the directive.
+		return fmt.Sprintf("must not contain %q; got %q", "{{", value)
+	}
+	switch spec.Type {
+	case v3.ParamInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Sprintf("must be an integer; got %q", value)
+		}
+		if spec.Min != nil && n < *spec.Min {
+			return fmt.Sprintf("must be at least %d; got %d", *spec.Min, n)
+		}
+		if spec.Max != nil && n > *spec.Max {
+			return fmt.Sprintf("must be at most %d; got %d", *spec.Max, n)
+		}
+	case v3.ParamEnum:
+		if !slices.Contains(spec.Options, value) {
+			return fmt.Sprintf("must be one of [%s]; got %q", strings.Join(spec.Options, ", "), value)
+		}
+	case v3.ParamString:
+		n := utf8.RuneCountInString(value)
+		if spec.Min != nil && n < *spec.Min {
+			return fmt.Sprintf("must be at least %d characters; got %d", *spec.Min, n)
+		}
+		if spec.Max != nil && n > *spec.Max {
+			return fmt.Sprintf("must be at most %d characters; got %d", *spec.Max, n)
+		}
+	default:
+		// Catalog authoring bug — fail closed, mirroring unknown Requires tokens.
+		return fmt.Sprintf("declares unknown type %q", spec.Type)
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package actions
+
+import (
+	"testing"
+
+	"go_text/internal/apperr"
+	v3 "go_text/internal/prompts/v3"
+)
+
+func TestApplyParams_SinglePass(t *testing.T) {
+	meta := apperr.ActionMeta{
+		ID: "rewrite.tone.professional",
+		Params: []apperr.ParamSpec{
+			{Name: "audience", Type: v3.ParamString, Default: "a colleague"},
+			{Name: "tone", Type: v3.ParamString, Default: "warm"},
+		},
+	}
+	directive := "Write for {{audience}} in a {{tone}} tone."
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   string
+	}{
+		{
+			name: "defaults",
+			want: "Write for a colleague in a warm tone.",
+		},
+		{
+			name:   "value naming another token is not expanded",
+			params: map[string]string{"audience": "{{tone}}"},
+			want:   "Write for {{tone}} in a warm tone.",
+		},
+		{
+			name:   "value naming its own token is not expanded",
+			params: map[string]string{"tone": "{{audience}} {{tone}}"},
+			want:   "Write for a colleague in a {{audience}} {{tone}} tone.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Repeat to catch map-order dependence.
+			for range 20 {
+				got := applyParams(directive, meta, apperr.ChainStep{ActionID: meta.ID, Params: tt.params})
+				if got != tt.want {
+					t.Fatalf("applyParams = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -1,17 +1,17 @@
 package actions
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"go_text/internal/apperr"
 	v3 "go_text/internal/prompts/v3"
-)
-
-const (
-	maxSteps      = 5
-	maxInferences = 3
+	"go_text/internal/settings"
 )
 
 // Planner runs the four-stage chain-planning algorithm defined in spec §3.
@@ -28,8 +28,34 @@ func NewPlanner(catalog []apperr.ActionMeta) *Planner {
 	return &Planner{catalog: m}
 }
 
-// Plan runs all four stages and returns a ChainPlan or a typed *apperr.AppError.
+// PlanLimitsFromSettings extracts the user-configured plan caps.
+func PlanLimitsFromSettings(cfg settings.AppBehaviorConfig) PlanLimits {
+	return PlanLimits{MaxSteps: cfg.MaxPlanSteps, MaxInferences: cfg.MaxPlanInferences}
+}
+
+// withDefaults fills zero fields with the default caps.
+func (l PlanLimits) withDefaults() PlanLimits {
+	if l.MaxSteps <= 0 {
+		l.MaxSteps = settings.DefaultMaxPlanSteps
+	}
+	if l.MaxInferences <= 0 {
+		l.MaxInferences = settings.DefaultMaxPlanInferences
+	}
+	return l
+}
+
+// Plan runs all four stages under the default limits and returns a ChainPlan or a
+// typed *apperr.AppError.
 func (p *Planner) Plan(req apperr.ChainRequest) (ChainPlan, error) {
+	return p.PlanWithLimits(req, PlanLimits{})
+}
+
+// PlanWithLimits runs all four stages under the given limits. When req.StrictOrder
+// is set the canonical sort is skipped: steps keep their given order and only
+// adjacent compatible steps merge.
+func (p *Planner) PlanWithLimits(req apperr.ChainRequest, limits PlanLimits) (ChainPlan, error) {
+	limits = limits.withDefaults()
+
 	if len(req.Steps) == 0 {
 		return ChainPlan{}, apperr.Validation("steps", "at least one step", "0 steps provided")
 	}
@@ -44,56 +70,97 @@ func (p *Planner) Plan(req apperr.ChainRequest) (ChainPlan, error) {
 		return ChainPlan{}, err
 	}
 
-	ordered := p.sortCanonical(req.Steps)
+	if err := p.checkParams(req.Steps); err != nil {
+		return ChainPlan{}, err
+	}
+
+	if err := checkConditions(req.Steps); err != nil {
+		return ChainPlan{}, err
+	}
+
+	if err := p.checkOutputs(req.Steps); err != nil {
+		return ChainPlan{}, err
+	}
+
+	ordered := append([]apperr.ChainStep(nil), req.Steps...)
+	if !req.StrictOrder {
+		ordered = p.sortCanonical(req.Steps)
+	}
 
 	if err := p.checkExclusivity(ordered); err != nil {
 		return ChainPlan{}, err
 	}
 
-	if len(ordered) > maxSteps {
+	if len(ordered) > limits.MaxSteps {
 		return ChainPlan{}, apperr.InvalidPlan(
-			fmt.Sprintf("selected %d steps; maximum is %d", len(ordered), maxSteps),
+			fmt.Sprintf("selected %d steps; the configured maximum is %d", len(ordered), limits.MaxSteps),
 			len(ordered), 0)
 	}
 
 	groups := p.mergeGroups(ordered)
 
-	if len(groups) > maxInferences {
-		return ChainPlan{}, apperr.InvalidPlan(
-			fmt.Sprintf("stack produces %d inference groups; maximum is %d", len(groups), maxInferences),
-			len(ordered), len(groups))
+	inferences := countInferences(groups)
+	if inferences > limits.MaxInferences {
+		reason := fmt.Sprintf("stack produces %d inference groups; the configured maximum is %d",
+			inferences, limits.MaxInferences)
+		if req.StrictOrder {
+			reason = fmt.Sprintf("stack produces %d inference groups in strict order, where only adjacent "+
+				"mergeable steps of the same family share a group; the configured maximum is %d",
+				inferences, limits.MaxInferences)
+		}
+		return ChainPlan{}, apperr.InvalidPlan(reason, len(ordered), inferences)
 	}
 
-	return ChainPlan{Groups: groups, Inferences: len(groups)}, nil
+	return ChainPlan{Groups: groups, Inferences: inferences}, nil
 }
 
-// sortCanonical sorts steps: non-terminal before terminal, then by OrderRank ascending,
-// then by original insertion index (stable).
+// sortCanonical sorts steps by order class — regular, then terminal, then
+// finisher — then by OrderRank ascending, then by original insertion index (stable).
 func (p *Planner) sortCanonical(steps []apperr.ChainStep) []apperr.ChainStep {
-	type indexed struct {
-		step  apperr.ChainStep
-		meta  apperr.ActionMeta
-		index int
+	out := make([]apperr.ChainStep, len(steps))
+	for i, idx := range p.canonicalIndices(steps) {
+		out[i] = steps[idx]
 	}
-	is := make([]indexed, len(steps))
-	for i, s := range steps {
-		is[i] = indexed{step: s, meta: p.catalog[s.ActionID], index: i}
+	return out
+}
+
+// canonicalIndices returns the indices of steps in canonical order.
+func (p *Planner) canonicalIndices(steps []apperr.ChainStep) []int {
+	idx := make([]int, len(steps))
+	for i := range idx {
+		idx[i] = i
 	}
-	sort.SliceStable(is, func(i, j int) bool {
-		a, b := is[i], is[j]
-		if a.meta.Terminal != b.meta.Terminal {
-			return !a.meta.Terminal
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := p.catalog[steps[idx[i]].ActionID], p.catalog[steps[idx[j]].ActionID]
+		if ca, cb := orderClass(a), orderClass(b); ca != cb {
+			return ca < cb
 		}
-		if a.meta.OrderRank != b.meta.OrderRank {
-			return a.meta.OrderRank < b.meta.OrderRank
+		if a.OrderRank != b.OrderRank {
+			return a.OrderRank < b.OrderRank
 		}
-		return a.index < b.index
+		return idx[i] < idx[j]
 	})
-	out := make([]apperr.ChainStep, len(steps))
-	for i, s := range is {
-		out[i] = s.step
+	return idx
+}
+
+// Order classes, the first key of the canonical order. Finishers come after
+// terminal steps because they polish whatever text the chain ends with.
+const (
+	classRegular = iota
+	classTerminal
+	classFinisher
+)
+
+// orderClass returns the order class of meta; a terminal finisher is a finisher.
+func orderClass(meta apperr.ActionMeta) int {
+	switch {
+	case meta.Finisher:
+		return classFinisher
+	case meta.Terminal:
+		return classTerminal
+	default:
+		return classRegular
 	}
-	return out
 }
 
 // checkRequirements returns an InvalidPlan error if any step's action is missing a
@@ -101,35 +168,113 @@ func (p *Planner) sortCanonical(steps []apperr.ChainStep) []apperr.ChainStep {
 // p.catalog is already guaranteed by the caller's preceding loop.
 func (p *Planner) checkRequirements(req apperr.ChainRequest) error {
 	for _, s := range req.Steps {
-		meta := p.catalog[s.ActionID]
-		for _, r := range meta.Requires {
-			var missing bool
-			switch r {
-			case v3.ReqInputLang:
-				missing = strings.TrimSpace(req.InputLanguageID) == ""
-			case v3.ReqOutputLang:
-				missing = strings.TrimSpace(req.OutputLanguageID) == ""
-			case v3.ReqTargetModel:
-				missing = strings.TrimSpace(s.TargetModel) == ""
-			case v3.ReqGoal:
-				missing = strings.TrimSpace(s.Goal) == ""
-			default:
-				// Catalog authoring bug (unwired requirement token) — fail closed
-				// and say so distinctly, rather than silently letting it through.
-				return apperr.InvalidPlan(
-					fmt.Sprintf("action %q declares unknown requirement %q", s.ActionID, r),
-					len(req.Steps), 0)
-			}
-			if missing {
-				return apperr.InvalidPlan(
-					fmt.Sprintf("action %q is missing required parameter %q", s.ActionID, r),
-					len(req.Steps), 0)
+		if reason := p.requirementProblem(req, s); reason != "" {
+			return apperr.InvalidPlan(reason, len(req.Steps), 0)
+		}
+	}
+	return nil
+}
+
+// requirementProblem returns why step s fails its action's Requires list, or "" when
+// every requirement is satisfied.
+func (p *Planner) requirementProblem(req apperr.ChainRequest, s apperr.ChainStep) string {
+	meta := p.catalog[s.ActionID]
+	for _, r := range meta.Requires {
+		var missing bool
+		switch r {
+		case v3.ReqInputLang:
+			missing = strings.TrimSpace(req.InputLanguageID) == ""
+		case v3.ReqOutputLang:
+			missing = strings.TrimSpace(req.OutputLanguageID) == ""
+		case v3.ReqTargetModel:
+			missing = strings.TrimSpace(s.TargetModel) == ""
+		case v3.ReqGoal:
+			missing = strings.TrimSpace(s.Goal) == ""
+		default:
+			// Catalog authoring bug (unwired requirement token) — fail closed
+			// and say so distinctly, rather than silently letting it through.
+			return fmt.Sprintf("action %q declares unknown requirement %q", s.ActionID, r)
+		}
+		if missing {
+			return fmt.Sprintf("action %q is missing required parameter %q", s.ActionID, r)
+		}
+	}
+	return ""
+}
+
+// checkParams returns an InvalidPlan error if any step sets a parameter its action does
+// not declare, omits a required parameter, or supplies a value that fails the declared
+// type and bounds. Defaults are validated too, so a catalog authoring bug fails closed.
+func (p *Planner) checkParams(steps []apperr.ChainStep) error {
+	for _, s := range steps {
+		if reason := p.paramProblem(s); reason != "" {
+			return apperr.InvalidPlan(reason, len(steps), 0)
+		}
+	}
+	return nil
+}
+
+// paramProblem returns why step s's parameters fail its action's schema, or "".
+func (p *Planner) paramProblem(s apperr.ChainStep) string {
+	meta := p.catalog[s.ActionID]
+	for name := range s.Params {
+		if !slices.ContainsFunc(meta.Params, func(spec apperr.ParamSpec) bool { return spec.Name == name }) {
+			return fmt.Sprintf("action %q has no parameter %q", s.ActionID, name)
+		}
+	}
+	resolved := resolveParams(meta, s)
+	for _, spec := range meta.Params {
+		value := resolved[spec.Name]
+		if value == "" {
+			if spec.Required {
+				return fmt.Sprintf("action %q is missing required parameter %q", s.ActionID, spec.Name)
 			}
+			continue
+		}
+		if reason := checkParamValue(spec, value); reason != "" {
+			return fmt.Sprintf("parameter %q of action %q %s", spec.Name, s.ActionID, reason)
+		}
+	}
+	return ""
+}
+
+// checkConditions returns an InvalidPlan error if any step condition names an unknown
+// subject or check, or carries a value its check cannot use.
+func checkConditions(steps []apperr.ChainStep) error {
+	for _, s := range steps {
+		if reason := stepConditionProblem(s); reason != "" {
+			return apperr.InvalidPlan(reason, len(steps), 0)
+		}
+	}
+	return nil
+}
+
+// checkOutputs returns an InvalidPlan error if any step asks for an unknown output
+// mode, or for an edit list from an action that cannot produce one.
+func (p *Planner) checkOutputs(steps []apperr.ChainStep) error {
+	for _, s := range steps {
+		if reason := p.outputProblem(s); reason != "" {
+			return apperr.InvalidPlan(reason, len(steps), 0)
 		}
 	}
 	return nil
 }
 
+// outputProblem returns why step s's output mode is not allowed, or "".
+func (p *Planner) outputProblem(s apperr.ChainStep) string {
+	switch s.Output {
+	case "", v3.OutputText:
+		return ""
+	case v3.OutputEdits:
+		if !p.catalog[s.ActionID].EditList {
+			return fmt.Sprintf("action %q cannot return an edit list", s.ActionID)
+		}
+		return ""
+	default:
+		return fmt.Sprintf("output of action %q must be %q or %q; got %q", s.ActionID, v3.OutputText, v3.OutputEdits, s.Output)
+	}
+}
+
 // checkExclusivity returns an InvalidPlan error if any non-empty ExclusivityGroup appears twice.
 func (p *Planner) checkExclusivity(steps []apperr.ChainStep) error {
 	seen := make(map[string]string)
@@ -150,19 +295,30 @@ func (p *Planner) checkExclusivity(steps []apperr.ChainStep) error {
 	return nil
 }
 
+// Merge outcomes reported by mergeReason (and surfaced verbatim by Explain).
+const (
+	mergeMerged            = "merged"
+	mergeFirstStep         = "first-step"
+	mergeFamilyMismatch    = "family-mismatch"
+	mergeNotMergeable      = "non-mergeable"
+	mergeGroupNotMergeable = "group-non-mergeable"
+	mergeTerminal          = "terminal"
+	mergeConditional       = "conditional"
+	mergeEditList          = "edit-list"
+)
+
 // mergeGroups implements spec §3.4: extends the last group when family matches,
 // both the new step and the group's first step are Mergeable, and the step is not Terminal.
+// Steps with conditions always get a group of their own, so a skipped group never
+// takes an unconditional step with it, and so do edit-list steps, so an edit list
+// only ever holds the corrections its own action asked for.
 func (p *Planner) mergeGroups(steps []apperr.ChainStep) []Group {
 	var groups []Group
 	for _, s := range steps {
 		meta := p.catalog[s.ActionID]
 		if len(groups) > 0 {
 			last := &groups[len(groups)-1]
-			lastMeta := p.catalog[last.Steps[0].ActionID]
-			if last.Family == meta.Family &&
-				meta.Mergeable &&
-				lastMeta.Mergeable &&
-				!meta.Terminal {
+			if p.mergeReason(*last, s) == mergeMerged {
 				last.Steps = append(last.Steps, s)
 				continue
 			}
@@ -171,3 +327,121 @@ func (p *Planner) mergeGroups(steps []apperr.ChainStep) []Group {
 	}
 	return groups
 }
+
+// mergeReason reports whether step s may extend group last — mergeMerged — or the
+// first rule that keeps it out.
+func (p *Planner) mergeReason(last Group, s apperr.ChainStep) string {
+	meta := p.catalog[s.ActionID]
+	lastMeta := p.catalog[last.Steps[0].ActionID]
+	switch {
+	case last.Family != meta.Family:
+		return mergeFamilyMismatch
+	case len(s.When) > 0 || len(last.Steps[0].When) > 0:
+		return mergeConditional
+	case s.Output == v3.OutputEdits || last.Steps[0].Output == v3.OutputEdits:
+		return mergeEditList
+	case !meta.Mergeable:
+		return mergeNotMergeable
+	case !lastMeta.Mergeable:
+		return mergeGroupNotMergeable
+	case meta.Terminal:
+		return mergeTerminal
+	default:
+		return mergeMerged
+	}
+}
+
+// MinFanOutBranches and MaxFanOutBranches bound the number of branches in a
+// fan-out request.
+const (
+	MinFanOutBranches = 2
+	MaxFanOutBranches = 6
+)
+
+// PlanFanOut plans a fan-out request: req.Steps is the shared prefix and each of
+// req.Branches runs on its output. Every path (prefix plus one branch) must fit
+// the limits and pass exclusivity as a whole; the prefix may not contain terminal
+// actions, since a terminal step has to end the text it produces.
+func (p *Planner) PlanFanOut(req apperr.ChainRequest, limits PlanLimits) (FanOutPlan, error) {
+	limits = limits.withDefaults()
+
+	if n := len(req.Branches); n < MinFanOutBranches || n > MaxFanOutBranches {
+		return FanOutPlan{}, apperr.Validation("branches",
+			fmt.Sprintf("%d–%d branches", MinFanOutBranches, MaxFanOutBranches),
+			fmt.Sprintf("%d branches", n))
+	}
+	seen := make(map[string]bool, len(req.Branches))
+	for _, b := range req.Branches {
+		if strings.TrimSpace(b.Name) == "" {
+			return FanOutPlan{}, apperr.Validation("branches.name", "be non-empty", "empty string")
+		}
+		if seen[b.Name] {
+			return FanOutPlan{}, apperr.Validation("branches.name", "be unique", fmt.Sprintf("%q repeated", b.Name))
+		}
+		seen[b.Name] = true
+	}
+
+	// Parts are planned without caps; the caps apply to whole paths below.
+	unlimited := PlanLimits{MaxSteps: math.MaxInt, MaxInferences: math.MaxInt}
+
+	var out FanOutPlan
+	if len(req.Steps) > 0 {
+		prefixReq := req
+		prefixReq.Branches = nil
+		prefix, err := p.PlanWithLimits(prefixReq, unlimited)
+		if err != nil {
+			return FanOutPlan{}, err
+		}
+		for _, s := range req.Steps {
+			if p.catalog[s.ActionID].Terminal {
+				return FanOutPlan{}, apperr.InvalidPlan(
+					fmt.Sprintf("shared step %q is terminal; terminal actions can only end a branch", s.ActionID),
+					len(req.Steps), prefix.Inferences)
+			}
+		}
+		out.Prefix = prefix
+	}
+
+	for _, b := range req.Branches {
+		name := b.Name
+		branchReq := req
+		branchReq.Steps = b.Steps
+		branchReq.Branches = nil
+		plan, err := p.PlanWithLimits(branchReq, unlimited)
+		if err != nil {
+			return FanOutPlan{}, inBranch(name, err)
+		}
+
+		path := append(append([]apperr.ChainStep(nil), req.Steps...), b.Steps...)
+		if err := p.checkExclusivity(path); err != nil {
+			return FanOutPlan{}, inBranch(name, err)
+		}
+		inferences := out.Prefix.Inferences + plan.Inferences
+		if len(path) > limits.MaxSteps {
+			return FanOutPlan{}, apperr.InvalidPlan(
+				fmt.Sprintf("branch %q runs %d steps including the shared steps; the configured maximum is %d",
+					name, len(path), limits.MaxSteps),
+				len(path), inferences)
+		}
+		if inferences > limits.MaxInferences {
+			return FanOutPlan{}, apperr.InvalidPlan(
+				fmt.Sprintf("branch %q produces %d inference groups including the shared steps; the configured maximum is %d",
+					name, inferences, limits.MaxInferences),
+				len(path), inferences)
+		}
+		out.Branches = append(out.Branches, BranchPlan{Name: name, Plan: plan})
+	}
+	return out, nil
+}
+
+// inBranch prefixes an InvalidPlan reason with the branch it came from; other
+// errors pass through unchanged.
+func inBranch(name string, err error) error {
+	var ae *apperr.AppError
+	if !errors.As(err, &ae) || ae.Code != apperr.CodeInvalidPlan {
+		return err
+	}
+	steps, _ := strconv.Atoi(ae.Details["steps"])
+	inferences, _ := strconv.Atoi(ae.Details["inferences"])
+	return apperr.InvalidPlan(fmt.Sprintf("branch %q: %s", name, ae.Details["reason"]), steps, inferences)
+}
@@ -2,6 +2,9 @@ package actions
 
 import (
 	"errors"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	"go_text/internal/apperr"
@@ -11,8 +14,8 @@ import (
 func testCatalog() []apperr.ActionMeta {
 	return []apperr.ActionMeta{
 		// Rewrite — mergeable, non-terminal
-		{ID: "rewrite.proofread.basic", Family: v3.FamilyRewrite, OrderRank: 10, ExclusivityGroup: "proofread", Mergeable: true, Terminal: false},
-		{ID: "rewrite.proofread.enhanced", Family: v3.FamilyRewrite, OrderRank: 10, ExclusivityGroup: "proofread", Mergeable: true, Terminal: false},
+		{ID: "rewrite.proofread.basic", Family: v3.FamilyRewrite, OrderRank: 10, ExclusivityGroup: "proofread", Mergeable: true, Terminal: false, EditList: true},
+		{ID: "rewrite.proofread.enhanced", Family: v3.FamilyRewrite, OrderRank: 10, ExclusivityGroup: "proofread", Mergeable: true, Terminal: false, EditList: true},
 		{ID: "rewrite.tone.professional", Family: v3.FamilyRewrite, OrderRank: 30, ExclusivityGroup: "tone", Mergeable: true, Terminal: false},
 		{ID: "rewrite.tone.friendly", Family: v3.FamilyRewrite, OrderRank: 30, ExclusivityGroup: "tone", Mergeable: true, Terminal: false},
 		{ID: "rewrite.intent.concise", Family: v3.FamilyRewrite, OrderRank: 20, ExclusivityGroup: "rewrite-intent", Mergeable: true, Terminal: false},
@@ -28,6 +31,10 @@ func testCatalog() []apperr.ActionMeta {
 		{ID: "translate.text", Family: v3.FamilyTranslate, OrderRank: 90, ExclusivityGroup: "translate", Mergeable: false, Terminal: true},
 		// PromptEng — terminal
 		{ID: "prompteng.text.improve", Family: v3.FamilyPromptEng, OrderRank: 100, ExclusivityGroup: "prompteng", Mergeable: false, Terminal: true},
+		// Local — in-process, mergeable with each other
+		{ID: "local.whitespace.normalize", Family: v3.FamilyLocal, OrderRank: 5, Mergeable: true, Terminal: false},
+		{ID: "local.markdown.strip", Family: v3.FamilyLocal, OrderRank: 95, Mergeable: true, Terminal: false, Finisher: true},
+		{ID: "local.quotes.smart", Family: v3.FamilyLocal, OrderRank: 95, Mergeable: true, Terminal: false, Finisher: true},
 	}
 }
 
@@ -81,6 +88,36 @@ func TestPlanner_Plan_CanonicalOrdering(t *testing.T) {
 			wantOrder:  []string{"rewrite.proofread.basic", "structure.format.bullets", "summarize.summary"},
 			wantGroups: 3,
 		},
+		{
+			name:       "finisher after summarize",
+			input:      []apperr.ChainStep{step("summarize.summary"), step("local.markdown.strip")},
+			wantOrder:  []string{"summarize.summary", "local.markdown.strip"},
+			wantGroups: 1,
+		},
+		{
+			name:       "finisher moved after summarize",
+			input:      []apperr.ChainStep{step("local.markdown.strip"), step("summarize.summary")},
+			wantOrder:  []string{"summarize.summary", "local.markdown.strip"},
+			wantGroups: 1,
+		},
+		{
+			name:       "finisher after translate",
+			input:      []apperr.ChainStep{step("translate.text"), step("local.quotes.smart")},
+			wantOrder:  []string{"translate.text", "local.quotes.smart"},
+			wantGroups: 1,
+		},
+		{
+			name:       "finisher moved after translate",
+			input:      []apperr.ChainStep{step("local.quotes.smart"), step("translate.text")},
+			wantOrder:  []string{"translate.text", "local.quotes.smart"},
+			wantGroups: 1,
+		},
+		{
+			name:       "clean-up local step stays first, finishers last",
+			input:      []apperr.ChainStep{step("local.markdown.strip"), step("translate.text"), step("local.whitespace.normalize"), step("rewrite.proofread.basic")},
+			wantOrder:  []string{"local.whitespace.normalize", "rewrite.proofread.basic", "translate.text", "local.markdown.strip"},
+			wantGroups: 2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -183,6 +220,119 @@ func TestPlanner_Plan_Caps(t *testing.T) {
 	}
 }
 
+func TestPlanner_PlanWithLimits(t *testing.T) {
+	p := NewPlanner(testCatalog())
+
+	sixSteps := []apperr.ChainStep{
+		step("rewrite.proofread.basic"),
+		step("rewrite.intent.concise"),
+		step("rewrite.tone.professional"),
+		step("rewrite.style.formal"),
+		step("structure.format.bullets"),
+		step("structure.format.headings"),
+	}
+	fourGroups := []apperr.ChainStep{
+		step("rewrite.proofread.basic"),
+		step("structure.doc.faq"),
+		step("summarize.summary"),
+		step("translate.text"),
+	}
+
+	tests := []struct {
+		name    string
+		steps   []apperr.ChainStep
+		limits  PlanLimits
+		wantErr bool
+	}{
+		{name: "zero limits fall back to defaults", steps: sixSteps, limits: PlanLimits{}, wantErr: true},
+		{name: "raised step limit admits 6 steps", steps: sixSteps, limits: PlanLimits{MaxSteps: 6, MaxInferences: 3}, wantErr: false},
+		{name: "raised inference limit admits 4 groups", steps: fourGroups, limits: PlanLimits{MaxSteps: 5, MaxInferences: 4}, wantErr: false},
+		{name: "lowered inference limit rejects 2 groups", steps: []apperr.ChainStep{step("rewrite.proofread.basic"), step("summarize.summary")}, limits: PlanLimits{MaxSteps: 5, MaxInferences: 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := apperr.ChainRequest{Steps: tt.steps, InputLanguageID: "en", OutputLanguageID: "es"}
+			_, err := p.PlanWithLimits(req, tt.limits)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlanner_Plan_StrictOrder(t *testing.T) {
+	p := NewPlanner(testCatalog())
+
+	tests := []struct {
+		name       string
+		input      []apperr.ChainStep
+		wantGroups [][]string
+	}{
+		{
+			name:       "terminal step may come first",
+			input:      []apperr.ChainStep{step("translate.text"), step("rewrite.proofread.basic")},
+			wantGroups: [][]string{{"translate.text"}, {"rewrite.proofread.basic"}},
+		},
+		{
+			name:       "adjacent compatible steps merge in given order",
+			input:      []apperr.ChainStep{step("rewrite.tone.professional"), step("rewrite.proofread.basic")},
+			wantGroups: [][]string{{"rewrite.tone.professional", "rewrite.proofread.basic"}},
+		},
+		{
+			name:       "non-adjacent same-family steps do not merge",
+			input:      []apperr.ChainStep{step("rewrite.proofread.basic"), step("structure.format.bullets"), step("rewrite.tone.professional")},
+			wantGroups: [][]string{{"rewrite.proofread.basic"}, {"structure.format.bullets"}, {"rewrite.tone.professional"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := p.Plan(apperr.ChainRequest{Steps: tt.input, StrictOrder: true, InputLanguageID: "en", OutputLanguageID: "es"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(plan.Groups) != len(tt.wantGroups) {
+				t.Fatalf("groups = %d, want %d", len(plan.Groups), len(tt.wantGroups))
+			}
+			for i, g := range plan.Groups {
+				var ids []string
+				for _, s := range g.Steps {
+					ids = append(ids, s.ActionID)
+				}
+				if strings.Join(ids, ",") != strings.Join(tt.wantGroups[i], ",") {
+					t.Errorf("group[%d] = %v, want %v", i, ids, tt.wantGroups[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlanner_Plan_StrictOrder_InferenceCapExplainsMode(t *testing.T) {
+	p := NewPlanner(testCatalog())
+
+	// Canonical order merges both rewrites (2 groups); strict order keeps 4.
+	steps := []apperr.ChainStep{
+		step("rewrite.proofread.basic"),
+		step("structure.format.bullets"),
+		step("rewrite.tone.professional"),
+		step("structure.format.headings"),
+	}
+	if _, err := p.Plan(apperr.ChainRequest{Steps: steps}); err != nil {
+		t.Fatalf("canonical order should be valid, got %v", err)
+	}
+
+	_, err := p.Plan(apperr.ChainRequest{Steps: steps, StrictOrder: true})
+	var ae *apperr.AppError
+	if !errors.As(err, &ae) || ae.Code != apperr.CodeInvalidPlan {
+		t.Fatalf("expected CodeInvalidPlan, got %v", err)
+	}
+	if !strings.Contains(ae.Details["reason"], "strict order") {
+		t.Errorf("reason should explain strict-order grouping, got %q", ae.Details["reason"])
+	}
+	if ae.Details["inferences"] != "4" {
+		t.Errorf("inferences detail = %q, want 4", ae.Details["inferences"])
+	}
+}
+
 func TestPlanner_Plan_MergeGrouping(t *testing.T) {
 	p := NewPlanner(testCatalog())
 
@@ -248,6 +398,182 @@ func TestPlanner_Plan_MergeGrouping(t *testing.T) {
 	}
 }
 
+func TestPlanner_Plan_Conditions(t *testing.T) {
+	p := NewPlanner(testCatalog())
+	when := func(check, value string) []apperr.StepCondition {
+		return []apperr.StepCondition{{Subject: "input", Check: check, Value: value}}
+	}
+
+	tests := []struct {
+		name       string
+		steps      []apperr.ChainStep
+		wantGroups int
+		wantReason string
+	}{
+		{
+			name: "conditional step is not merged into its neighbour",
+			steps: []apperr.ChainStep{
+				step("rewrite.proofread.basic"),
+				{ActionID: "rewrite.tone.professional", When: when(condMinWords, "400")},
+			},
+			wantGroups: 2,
+		},
+		{
+			name: "unconditional step is not merged into a conditional group",
+			steps: []apperr.ChainStep{
+				{ActionID: "rewrite.proofread.basic", When: when(condNotLanguage, "English")},
+				step("rewrite.tone.professional"),
+			},
+			wantGroups: 2,
+		},
+		{
+			name:       "unknown check rejected",
+			steps:      []apperr.ChainStep{{ActionID: "summarize.summary", When: when("longerThan", "400")}},
+			wantReason: `condition 0 of action "summarize.summary" has unknown check "longerThan"`,
+		},
+		{
+			name:       "unknown subject rejected",
+			steps:      []apperr.ChainStep{{ActionID: "summarize.summary", When: []apperr.StepCondition{{Subject: "output", Check: condMinWords, Value: "1"}}}},
+			wantReason: `has unknown subject "output"`,
+		},
+		{
+			name:       "negative count rejected",
+			steps:      []apperr.ChainStep{{ActionID: "summarize.summary", When: when(condMaxTokens, "-1")}},
+			wantReason: `check "maxTokens" needs a non-negative count`,
+		},
+		{
+			name:       "invalid pattern rejected",
+			steps:      []apperr.ChainStep{{ActionID: "summarize.summary", When: when(condMatches, "(")}},
+			wantReason: `check "matches" has an invalid pattern`,
+		},
+		{
+			name:       "empty language rejected",
+			steps:      []apperr.ChainStep{{ActionID: "summarize.summary", When: when(condLanguage, " ")}},
+			wantReason: `check "language" needs a language`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := p.Plan(apperr.ChainRequest{Steps: tt.steps})
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if plan.Inferences != tt.wantGroups {
+					t.Errorf("groups: got %d, want %d", plan.Inferences, tt.wantGroups)
+				}
+				return
+			}
+			var ae *apperr.AppError
+			if !errors.As(err, &ae) || ae.Code != apperr.CodeInvalidPlan {
+				t.Fatalf("want InvalidPlan, got %v", err)
+			}
+			if !strings.Contains(ae.Details["reason"], tt.wantReason) {
+				t.Errorf("reason = %q, want it to contain %q", ae.Details["reason"], tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPlanner_Plan_LocalSteps(t *testing.T) {
+	p := NewPlanner(testCatalog())
+
+	tests := []struct {
+		name           string
+		steps          []apperr.ChainStep
+		wantFamilies   []string
+		wantInferences int
+	}{
+		{
+			name:           "local steps surround a model step without merging into it",
+			steps:          []apperr.ChainStep{step("local.markdown.strip"), step("rewrite.proofread.basic"), step("local.whitespace.normalize")},
+			wantFamilies:   []string{v3.FamilyLocal, v3.FamilyRewrite, v3.FamilyLocal},
+			wantInferences: 1,
+		},
+		{
+			name:           "adjacent local steps share a group",
+			steps:          []apperr.ChainStep{step("local.markdown.strip"), step("local.whitespace.normalize")},
+			wantFamilies:   []string{v3.FamilyLocal},
+			wantInferences: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One inference allowed: local groups must not count against the cap.
+			plan, err := p.PlanWithLimits(apperr.ChainRequest{Steps: tt.steps}, PlanLimits{MaxInferences: 1})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var families []string
+			for _, g := range plan.Groups {
+				families = append(families, g.Family)
+			}
+			if !reflect.DeepEqual(families, tt.wantFamilies) {
+				t.Errorf("group families = %v, want %v", families, tt.wantFamilies)
+			}
+			if plan.Inferences != tt.wantInferences {
+				t.Errorf("Inferences = %d, want %d", plan.Inferences, tt.wantInferences)
+			}
+		})
+	}
+}
+
+func TestPlanner_Plan_EditListOutput(t *testing.T) {
+	p := NewPlanner(testCatalog())
+	edits := func(id string) apperr.ChainStep { return apperr.ChainStep{ActionID: id, Output: v3.OutputEdits} }
+
+	tests := []struct {
+		name       string
+		steps      []apperr.ChainStep
+		wantGroups int
+		wantReason string
+	}{
+		{
+			name:       "edit-list step is not merged into its neighbour",
+			steps:      []apperr.ChainStep{edits("rewrite.proofread.basic"), step("rewrite.tone.professional")},
+			wantGroups: 2,
+		},
+		{
+			name:       "text output merges as usual",
+			steps:      []apperr.ChainStep{{ActionID: "rewrite.proofread.basic", Output: v3.OutputText}, step("rewrite.tone.professional")},
+			wantGroups: 1,
+		},
+		{
+			name:       "action without edit lists rejected",
+			steps:      []apperr.ChainStep{edits("rewrite.tone.professional")},
+			wantReason: `action "rewrite.tone.professional" cannot return an edit list`,
+		},
+		{
+			name:       "unknown output mode rejected",
+			steps:      []apperr.ChainStep{{ActionID: "rewrite.proofread.basic", Output: "diff"}},
+			wantReason: `output of action "rewrite.proofread.basic" must be "text" or "edits"; got "diff"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := p.Plan(apperr.ChainRequest{Steps: tt.steps})
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if plan.Inferences != tt.wantGroups {
+					t.Errorf("groups: got %d, want %d", plan.Inferences, tt.wantGroups)
+				}
+				return
+			}
+			var ae *apperr.AppError
+			if !errors.As(err, &ae) || ae.Code != apperr.CodeInvalidPlan {
+				t.Fatalf("want InvalidPlan, got %v", err)
+			}
+			if ae.Details["reason"] != tt.wantReason {
+				t.Errorf("reason = %q, want %q", ae.Details["reason"], tt.wantReason)
+			}
+		})
+	}
+}
+
 func TestPlanner_Plan_EmptySteps(t *testing.T) {
 	p := NewPlanner(testCatalog())
 	_, err := p.Plan(apperr.ChainRequest{Steps: nil})
@@ -308,3 +634,372 @@ func TestPlanner_Plan_Requirements(t *testing.T) {
 		})
 	}
 }
+
+func paramsCatalog() []apperr.ActionMeta {
+	lo, hi := 20, 1000
+	maxLen := 10
+	return []apperr.ActionMeta{
+		{ID: "summarize.summary", Family: v3.FamilySummarize, OrderRank: 80, ExclusivityGroup: "summarize", Terminal: true, Params: []apperr.ParamSpec{
+			{Name: "word_count", Type: v3.ParamInt, Default: "120", Min: &lo, Max: &hi},
+		}},
+		{ID: "structure.doc.email", Family: v3.FamilyStructure, OrderRank: 60, ExclusivityGroup: "doc-structure", Params: []apperr.ParamSpec{
+			{Name: "recipient", Type: v3.ParamString, Max: &maxLen, Required: true},
+			{Name: "length", Type: v3.ParamEnum, Default: "short", Options: []string{"short", "long"}},
+		}},
+		{ID: "rewrite.broken", Family: v3.FamilyRewrite, OrderRank: 10, Params: []apperr.ParamSpec{
+			{Name: "x", Type: "float", Default: "1.5"},
+		}},
+	}
+}
+
+func TestPlanner_Plan_Params(t *testing.T) {
+	p := NewPlanner(paramsCatalog())
+
+	withParams := func(id string, kv ...string) apperr.ChainStep {
+		s := apperr.ChainStep{ActionID: id, Params: map[string]string{}}
+		for i := 0; i+1 < len(kv); i += 2 {
+			s.Params[kv[i]] = kv[i+1]
+		}
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		step    apperr.ChainStep
+		wantErr bool
+	}{
+		{name: "default used when unset → ok", step: step("summarize.summary"), wantErr: false},
+		{name: "int within bounds → ok", step: withParams("summarize.summary", "word_count", "50"), wantErr: false},
+		{name: "int below min → error", step: withParams("summarize.summary", "word_count", "5"), wantErr: true},
+		{name: "int above max → error", step: withParams("summarize.summary", "word_count", "5000"), wantErr: true},
+		{name: "non-integer → error", step: withParams("summarize.summary", "word_count", "many"), wantErr: true},
+		{name: "unknown param → error", step: withParams("summarize.summary", "tone", "dry"), wantErr: true},
+		{name: "required string present → ok", step: withParams("structure.doc.email", "recipient", "Ana"), wantErr: false},
+		{name: "required string missing → error", step: step("structure.doc.email"), wantErr: true},
+		{name: "whitespace-only required string → error", step: withParams("structure.doc.email", "recipient", "   "), wantErr: true},
+		{name: "string too long → error", step: withParams("structure.doc.email", "recipient", "Maximilianus"), wantErr: true},
+		{name: "string with a token → error", step: withParams("structure.doc.email", "recipient", "{{goal}}"), wantErr: true},
+		{name: "enum valid → ok", step: withParams("structure.doc.email", "recipient", "Ana", "length", "long"), wantErr: false},
+		{name: "enum invalid → error", step: withParams("structure.doc.email", "recipient", "Ana", "length", "medium"), wantErr: true},
+		{name: "unknown param type fails closed → error", step: step("rewrite.broken"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Plan(apperr.ChainRequest{Steps: []apperr.ChainStep{tt.step}})
+			if tt.wantErr {
+				var ae *apperr.AppError
+				if !errors.As(err, &ae) {
+					t.Fatalf("expected *apperr.AppError, got %v (%T)", err, err)
+				}
+				if ae.Code != apperr.CodeInvalidPlan {
+					t.Fatalf("expected CodeInvalidPlan, got %v", ae.Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPlanner_Explain(t *testing.T) {
+	p := NewPlanner(testCatalog())
+
+	t.Run("reorder, merge and checks are traced", func(t *testing.T) {
+		ex := p.Explain(apperr.ChainRequest{Steps: []apperr.ChainStep{
+			step("summarize.summary"),
+			step("rewrite.tone.professional"),
+			step("rewrite.proofread.basic"),
+			step("structure.format.bullets"),
+			step("structure.doc.faq"),
+		}}, PlanLimits{MaxInferences: 4})
+
+		if !ex.Valid {
+			t.Fatalf("expected valid plan, checks: %+v", ex.Checks)
+		}
+		wantOrder := []string{"rewrite.proofread.basic", "rewrite.tone.professional", "structure.format.bullets", "structure.doc.faq", "summarize.summary"}
+		if !slices.Equal(ex.CanonicalOrder, wantOrder) {
+			t.Errorf("CanonicalOrder: got %v, want %v", ex.CanonicalOrder, wantOrder)
+		}
+		// Only the terminal step is credited with the terminal-last move; the
+		// structure steps shift up because of it but did not move themselves.
+		wantMoves := map[string]apperr.StepMove{
+			"summarize.summary":         {From: 0, To: 4, Rule: moveTerminalLast},
+			"rewrite.tone.professional": {From: 1, To: 1, Rule: moveOrderRank},
+			"rewrite.proofread.basic":   {From: 2, To: 0, Rule: moveOrderRank},
+			"structure.format.bullets":  {From: 3, To: 2, Rule: moveNone},
+			"structure.doc.faq":         {From: 4, To: 3, Rule: moveNone},
+		}
+		for _, m := range ex.Moves {
+			want := wantMoves[m.ActionID]
+			if m.From != want.From || m.To != want.To || m.Rule != want.Rule {
+				t.Errorf("move %s: got %d→%d %q, want %d→%d %q",
+					m.ActionID, m.From, m.To, m.Rule, want.From, want.To, want.Rule)
+			}
+		}
+		wantReasons := []string{mergeFirstStep, mergeMerged, mergeFamilyMismatch, mergeNotMergeable, mergeFamilyMismatch}
+		for i, d := range ex.MergeDecisions {
+			if d.Reason != wantReasons[i] {
+				t.Errorf("merge[%d] %s: got %q, want %q", i, d.ActionID, d.Reason, wantReasons[i])
+			}
+		}
+		if len(ex.Groups) != 4 || !slices.Equal(ex.Groups[0].ActionIDs, wantOrder[:2]) {
+			t.Errorf("Groups: got %+v", ex.Groups)
+		}
+		if ex.Inferences != 4 || ex.Steps != 5 || ex.MaxSteps != 5 || ex.MaxInferences != 4 {
+			t.Errorf("counts: got steps=%d inferences=%d caps=%d/%d", ex.Steps, ex.Inferences, ex.MaxSteps, ex.MaxInferences)
+		}
+	})
+
+	t.Run("finisher is credited with the finisher-last move", func(t *testing.T) {
+		ex := p.Explain(apperr.ChainRequest{Steps: []apperr.ChainStep{
+			step("local.quotes.smart"),
+			step("translate.text"),
+		}}, PlanLimits{})
+
+		wantMoves := map[string]apperr.StepMove{
+			"local.quotes.smart": {From: 0, To: 1, Rule: moveFinisherLast},
+			"translate.text":     {From: 1, To: 0, Rule: moveNone},
+		}
+		for _, m := range ex.Moves {
+			want := wantMoves[m.ActionID]
+			if m.From != want.From || m.To != want.To || m.Rule != want.Rule {
+				t.Errorf("move %s: got %d→%d %q, want %d→%d %q",
+					m.ActionID, m.From, m.To, m.Rule, want.From, want.To, want.Rule)
+			}
+		}
+	})
+
+	t.Run("failed checks do not stop the trace", func(t *testing.T) {
+		ex := p.Explain(apperr.ChainRequest{Steps: []apperr.ChainStep{
+			step("rewrite.proofread.basic"),
+			step("nope.unknown"),
+			step("rewrite.proofread.enhanced"),
+		}}, PlanLimits{})
+
+		if ex.Valid {
+			t.Fatal("expected invalid plan")
+		}
+		failed := map[string]string{}
+		for _, c := range ex.Checks {
+			if !c.Passed {
+				failed[c.Name] = c.Subject
+			}
+		}
+		want := map[string]string{checkKnownAction: "nope.unknown", checkExclusivity: "proofread"}
+		if !reflect.DeepEqual(failed, want) {
+			t.Errorf("failed checks: got %v, want %v", failed, want)
+		}
+		if len(ex.OriginalOrder) != 3 || len(ex.CanonicalOrder) != 2 || ex.Inferences != 1 {
+			t.Errorf("got original=%v canonical=%v inferences=%d", ex.OriginalOrder, ex.CanonicalOrder, ex.Inferences)
+		}
+	})
+
+	t.Run("strict order keeps positions and reports the cap", func(t *testing.T) {
+		ex := p.Explain(apperr.ChainRequest{StrictOrder: true, Steps: []apperr.ChainStep{
+			step("rewrite.proofread.basic"),
+			step("structure.format.bullets"),
+			step("rewrite.tone.professional"),
+		}}, PlanLimits{MaxInferences: 2})
+
+		if ex.Valid {
+			t.Fatal("expected invalid plan: 3 groups over a cap of 2")
+		}
+		for _, m := range ex.Moves {
+			if m.From != m.To || m.Rule != moveStrictOrder {
+				t.Errorf("move %s: got %d→%d %q", m.ActionID, m.From, m.To, m.Rule)
+			}
+		}
+		last := ex.Checks[len(ex.Checks)-1]
+		if last.Name != checkMaxInferences || last.Passed {
+			t.Errorf("last check: got %+v", last)
+		}
+	})
+
+	t.Run("requirement failure is attributed to its step", func(t *testing.T) {
+		rp := NewPlanner(requiresCatalog())
+		ex := rp.Explain(apperr.ChainRequest{Steps: []apperr.ChainStep{step("prompteng.video")}}, PlanLimits{})
+		var got *apperr.PlanCheck
+		for i := range ex.Checks {
+			if ex.Checks[i].Name == checkRequirement {
+				got = &ex.Checks[i]
+			}
+		}
+		if got == nil || got.Passed || got.Subject != "prompteng.video" || !strings.Contains(got.Detail, v3.ReqTargetModel) {
+			t.Errorf("requirement check: got %+v", got)
+		}
+	})
+
+	t.Run("empty request", func(t *testing.T) {
+		ex := p.Explain(apperr.ChainRequest{}, PlanLimits{})
+		if ex.Valid || len(ex.Checks) != 1 || ex.Checks[0].Name != checkNonEmpty {
+			t.Errorf("got %+v", ex)
+		}
+	})
+
+	t.Run("fan-out branches are traced per path", func(t *testing.T) {
+		req := apperr.ChainRequest{
+			Steps: []apperr.ChainStep{step("rewrite.proofread.basic")},
+			Branches: []apperr.ChainBranch{
+				{Name: "summary", Steps: []apperr.ChainStep{step("rewrite.tone.professional"), step("summarize.summary")}},
+				{Name: "strict", Steps: []apperr.ChainStep{step("rewrite.proofread.enhanced")}},
+			},
+		}
+		ex := p.Explain(req, PlanLimits{MaxSteps: 3})
+
+		if ex.Valid {
+			t.Fatal("expected invalid plan: branch \"strict\" repeats the proofread group")
+		}
+		if !slices.Equal(ex.CanonicalOrder, []string{"rewrite.proofread.basic"}) || ex.Steps != 1 {
+			t.Errorf("shared steps: got %v (%d)", ex.CanonicalOrder, ex.Steps)
+		}
+		if len(ex.Branches) != 2 {
+			t.Fatalf("Branches: got %d, want 2", len(ex.Branches))
+		}
+		summary, strict := ex.Branches[0], ex.Branches[1]
+		if summary.Name != "summary" || !summary.Explanation.Valid {
+			t.Errorf("branch summary: got %+v", summary)
+		}
+		if summary.Explanation.Steps != 3 || summary.Explanation.Inferences != 3 {
+			t.Errorf("branch summary counts: got steps=%d inferences=%d, want 3/3",
+				summary.Explanation.Steps, summary.Explanation.Inferences)
+		}
+		if _, err := p.PlanFanOut(req, PlanLimits{MaxSteps: 3}); err == nil {
+			t.Error("PlanFanOut accepted a plan Explain rejects")
+		}
+		var failed []string
+		for _, c := range strict.Explanation.Checks {
+			if !c.Passed {
+				failed = append(failed, c.Name+":"+c.Subject)
+			}
+		}
+		if strict.Explanation.Valid || !slices.Equal(failed, []string{checkExclusivity + ":proofread"}) {
+			t.Errorf("branch strict failed checks: got %v", failed)
+		}
+	})
+
+	t.Run("fan-out with a terminal shared step", func(t *testing.T) {
+		ex := p.Explain(apperr.ChainRequest{
+			Steps: []apperr.ChainStep{step("summarize.summary")},
+			Branches: []apperr.ChainBranch{
+				{Name: "a", Steps: []apperr.ChainStep{step("structure.format.bullets")}},
+				{Name: "a", Steps: []apperr.ChainStep{step("local.quotes.smart")}},
+			},
+		}, PlanLimits{})
+		failed := map[string]bool{}
+		for _, c := range ex.Checks {
+			if !c.Passed {
+				failed[c.Name] = true
+			}
+		}
+		if ex.Valid || !failed[checkSharedStep] || !failed[checkBranches] {
+			t.Errorf("failed checks: got %v", failed)
+		}
+	})
+}
+
+func TestPlanner_PlanFanOut(t *testing.T) {
+	p := NewPlanner(testCatalog())
+	branch := func(name string, ids ...string) apperr.ChainBranch {
+		b := apperr.ChainBranch{Name: name}
+		for _, id := range ids {
+			b.Steps = append(b.Steps, step(id))
+		}
+		return b
+	}
+
+	tests := []struct {
+		name         string
+		prefix       []apperr.ChainStep
+		branches     []apperr.ChainBranch
+		limits       PlanLimits
+		wantCode     apperr.ErrorCode
+		wantReason   string
+		wantBranches []int // inferences per branch
+	}{
+		{
+			name:         "shared prefix and two branches",
+			prefix:       []apperr.ChainStep{step("rewrite.proofread.basic")},
+			branches:     []apperr.ChainBranch{branch("summary", "summarize.summary"), branch("bullets", "structure.format.bullets")},
+			wantBranches: []int{1, 1},
+		},
+		{
+			name:         "empty prefix",
+			branches:     []apperr.ChainBranch{branch("a", "summarize.summary"), branch("b", "translate.text")},
+			wantBranches: []int{1, 1},
+		},
+		{
+			name:     "one branch is not a fan-out",
+			branches: []apperr.ChainBranch{branch("a", "summarize.summary")},
+			wantCode: apperr.CodeValidation,
+		},
+		{
+			name:     "duplicate branch names",
+			branches: []apperr.ChainBranch{branch("a", "summarize.summary"), branch("a", "translate.text")},
+			wantCode: apperr.CodeValidation,
+		},
+		{
+			name:       "terminal step in the prefix",
+			prefix:     []apperr.ChainStep{step("summarize.summary")},
+			branches:   []apperr.ChainBranch{branch("a", "rewrite.tone.friendly"), branch("b", "structure.format.bullets")},
+			wantCode:   apperr.CodeInvalidPlan,
+			wantReason: `shared step "summarize.summary" is terminal`,
+		},
+		{
+			name:       "exclusivity spans prefix and branch",
+			prefix:     []apperr.ChainStep{step("rewrite.proofread.basic")},
+			branches:   []apperr.ChainBranch{branch("a", "summarize.summary"), branch("b", "rewrite.proofread.enhanced")},
+			wantCode:   apperr.CodeInvalidPlan,
+			wantReason: `branch "b": exclusivity group "proofread"`,
+		},
+		{
+			name:       "step cap counts the prefix",
+			prefix:     []apperr.ChainStep{step("rewrite.proofread.basic"), step("rewrite.tone.friendly")},
+			branches:   []apperr.ChainBranch{branch("a", "summarize.summary"), branch("b", "structure.format.bullets", "structure.format.headings")},
+			limits:     PlanLimits{MaxSteps: 3},
+			wantCode:   apperr.CodeInvalidPlan,
+			wantReason: `branch "b" runs 4 steps`,
+		},
+		{
+			name:       "inference cap counts the prefix",
+			prefix:     []apperr.ChainStep{step("rewrite.proofread.basic")},
+			branches:   []apperr.ChainBranch{branch("a", "summarize.summary"), branch("b", "structure.doc.faq", "translate.text")},
+			wantCode:   apperr.CodeInvalidPlan,
+			wantReason: `branch "b" produces 3 inference groups`,
+			limits:     PlanLimits{MaxInferences: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := p.PlanFanOut(apperr.ChainRequest{Steps: tt.prefix, Branches: tt.branches}, tt.limits)
+			if tt.wantCode != "" {
+				var ae *apperr.AppError
+				if !errors.As(err, &ae) || ae.Code != tt.wantCode {
+					t.Fatalf("expected %s error, got %v", tt.wantCode, err)
+				}
+				if !strings.Contains(ae.Message, tt.wantReason) {
+					t.Errorf("message %q does not contain %q", ae.Message, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if plan.Prefix.Inferences != len(tt.prefix) {
+				t.Errorf("prefix inferences: got %d, want %d", plan.Prefix.Inferences, len(tt.prefix))
+			}
+			for i, want := range tt.wantBranches {
+				if plan.Branches[i].Name != tt.branches[i].Name || plan.Branches[i].Plan.Inferences != want {
+					t.Errorf("branch[%d]: got %s/%d, want %s/%d", i,
+						plan.Branches[i].Name, plan.Branches[i].Plan.Inferences, tt.branches[i].Name, want)
+				}
+				if got := len(plan.Path(i).Groups); got != plan.Prefix.Inferences+want {
+					t.Errorf("path[%d] groups: got %d", i, got)
+				}
+			}
+		})
+	}
+}
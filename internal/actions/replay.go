@@ -0,0 +1,140 @@
+package actions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go_text/internal/apperr"
+	"go_text/internal/settings"
+)
+
+// Values of HistoryEntry.Format.
+const (
+	formatPlain    = "plain"
+	formatMarkdown = "markdown"
+)
+
+// hashCatalog returns the hex SHA-256 of catalog as JSON, recorded in run
+// snapshots so a replay can tell whether the actions it runs have changed.
+func hashCatalog(catalog []apperr.ActionMeta) string {
+	b, err := json.Marshal(catalog)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// outputFormat returns the HistoryEntry.Format of a run with cfg.
+func outputFormat(cfg *settings.Settings) string {
+	if cfg != nil && cfg.InferenceBaseConfig.UseMarkdownForOutput {
+		return formatMarkdown
+	}
+	return formatPlain
+}
+
+// runSnapshot returns what req, run with cfg, can be replayed from, or nil
+// without settings.
+func (a *ActionService) runSnapshot(req apperr.ChainRequest, cfg *settings.Settings) *apperr.RunSnapshot {
+	if cfg == nil {
+		return nil
+	}
+	return &apperr.RunSnapshot{
+		Request:     req,
+		ProviderID:  cfg.CurrentProviderConfig.ID,
+		ModelConfig: apperr.ModelConfig(cfg.ModelConfig),
+		UseMarkdown: cfg.InferenceBaseConfig.UseMarkdownForOutput,
+		AppVersion:  settings.AppVersion,
+		CatalogHash: a.catalogHash,
+	}
+}
+
+// RerunEntry runs the history entry entryID again as the run runID; see
+// replayChain.
+func (a *ActionService) RerunEntry(
+	ctx context.Context,
+	entryID, runID string,
+	overrides apperr.RerunOverrides,
+	emitProgress func(apperr.StepProgress),
+) (*apperr.ChainResult, error) {
+	if strings.TrimSpace(entryID) == "" {
+		return nil, apperr.Validation("id", "be non-empty", "empty string")
+	}
+	entry, err := a.historyService.Get(entryID)
+	if err != nil {
+		return nil, err
+	}
+	return a.replayChain(ctx, *entry, runID, overrides, emitProgress)
+}
+
+// replayChain runs the request recorded in entry's snapshot again as the run
+// runID, with the provider, model settings and output format the entry ran
+// with rather than the current ones; the other settings are the current ones.
+// A non-empty overrides.ProviderID or overrides.Model replaces the snapshot's.
+// The new run's history entry is linked to entry through RerunOf. Progress
+// events, the result and its errors are those of RunChain, and the result
+// carries runID.
+// A snapshot taken against a different action catalog is replayed anyway, with
+// a warning logged, since the actions may no longer do what they did.
+func (a *ActionService) replayChain(
+	ctx context.Context,
+	entry apperr.HistoryEntry,
+	runID string,
+	overrides apperr.RerunOverrides,
+	emitProgress func(apperr.StepProgress),
+) (*apperr.ChainResult, error) {
+	const op = "ActionService.replayChain"
+	snap := entry.Snapshot
+	if snap == nil {
+		return nil, apperr.Validation("id", "name an entry recorded with a run snapshot", entry.ID)
+	}
+
+	current, err := a.settingsService.GetSettings()
+	if err != nil {
+		return nil, fmt.Errorf("%s: resolve settings: %w", op, err)
+	}
+	cfg := *current
+	providerID := snap.ProviderID
+	if overrides.ProviderID != "" {
+		providerID = overrides.ProviderID
+	}
+	provider, err := a.settingsService.GetProviderConfig(providerID)
+	if err != nil || provider == nil {
+		return nil, apperr.Validation("providerId", "existing provider id", providerID)
+	}
+	cfg.CurrentProviderConfig = *provider
+	cfg.ModelConfig = settings.ModelConfig(snap.ModelConfig)
+	if model := strings.TrimSpace(overrides.Model); model != "" {
+		cfg.ModelConfig.Name = model
+	}
+	cfg.InferenceBaseConfig.UseMarkdownForOutput = snap.UseMarkdown
+
+	req := snap.Request
+	req.RunID = runID
+	req.RerunOf = entry.ID
+
+	lg := a.logger.WithOp(op).With().
+		Str("component", "actions").
+		Str("run_id", req.RunID).
+		Str("rerun_of", entry.ID).
+		Logger()
+	if snap.CatalogHash != a.catalogHash {
+		lg.Warn().
+			Str("recorded_version", snap.AppVersion).
+			Msg("action catalog changed since the recorded run; replay may differ")
+	}
+
+	selection, err := selectionRange(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := a.runChainWith(ctx, lg, req, selection, &cfg, emitProgress)
+	if result != nil {
+		result.RunID = runID
+	}
+	return result, err
+}
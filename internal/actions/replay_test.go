@@ -0,0 +1,235 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/gate"
+	"go_text/internal/llms"
+	"go_text/internal/logging"
+	"go_text/internal/prompts"
+	"go_text/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"resty.dev/v3"
+)
+
+// modelRecordingServer answers every completion with "replayed" and records
+// the model each request asked for.
+func modelRecordingServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		models = append(models, body.Model)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"role": "assistant", "content": "replayed"}},
+			},
+		})
+	}))
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), models...)
+	}
+}
+
+// newReplayService wires a real ActionService to serverURL whose current
+// provider, "p1", is also the one GetProviderConfig returns.
+func newReplayService(t *testing.T, serverURL string, hist *recordingHistoryService) (*ActionService, *orchestratorSettings) {
+	t.Helper()
+	wlog, err := logging.New(logging.DefaultConfig(), false)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	cfg := testSettingsCfg(serverURL)
+	cfg.CurrentProviderConfig.ID = "p1"
+	provider := cfg.CurrentProviderConfig
+	settingsSvc := &orchestratorSettings{cfg: cfg}
+	settingsSvc.byIDProvider = &provider
+	factory := llms.NewProviderFactory(resty.New().SetTimeout(10 * time.Second))
+	llmSvc := llms.NewLLMApiService(wlog, factory, settingsSvc)
+	return NewActionService(wlog, prompts.NewPromptService(wlog), llmSvc, settingsSvc, &noopTaskLog{}, hist), settingsSvc
+}
+
+func TestRunChain_RecordsSnapshot(t *testing.T) {
+	t.Parallel()
+	srv, _ := modelRecordingServer(t)
+	defer srv.Close()
+
+	hist := &recordingHistoryService{}
+	svc, _ := newReplayService(t, srv.URL, hist)
+	step := apperr.ChainStep{ActionID: oneFamilyStep(t, svc)}
+	_, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:            "orig",
+		InputText:        "input",
+		Steps:            []apperr.ChainStep{step},
+		InputLanguageID:  "English",
+		OutputLanguageID: "English",
+	}, nil)
+	require.NoError(t, err)
+
+	require.Len(t, hist.recorded, 1)
+	entry := hist.recorded[0]
+	assert.Equal(t, "plain", entry.Format)
+	assert.Empty(t, entry.RerunOf)
+	require.NotNil(t, entry.Snapshot)
+	assert.Equal(t, "p1", entry.Snapshot.ProviderID)
+	assert.Equal(t, "test-model", entry.Snapshot.ModelConfig.Name)
+	assert.Equal(t, []apperr.ChainStep{step}, entry.Snapshot.Request.Steps)
+	assert.Equal(t, "input", entry.Snapshot.Request.InputText)
+	assert.Equal(t, settings.AppVersion, entry.Snapshot.AppVersion)
+	assert.Equal(t, svc.catalogHash, entry.Snapshot.CatalogHash)
+	assert.Len(t, entry.Snapshot.CatalogHash, 64)
+}
+
+func TestActionService_ReplayChain(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		overrides apperr.RerunOverrides
+		wantModel string
+	}{
+		{"exact", apperr.RerunOverrides{}, "test-model"},
+		{"different model", apperr.RerunOverrides{Model: "other-model"}, "other-model"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv, models := modelRecordingServer(t)
+			defer srv.Close()
+
+			hist := &recordingHistoryService{}
+			svc, settingsSvc := newReplayService(t, srv.URL, hist)
+			_, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+				RunID:     "orig",
+				InputText: "input",
+				Steps:     []apperr.ChainStep{{ActionID: oneFamilyStep(t, svc)}},
+			}, nil)
+			require.NoError(t, err)
+			require.Len(t, hist.recorded, 1)
+
+			// Settings changed since the run must not leak into an exact replay.
+			settingsSvc.cfg.ModelConfig.Name = "changed-model"
+			settingsSvc.cfg.InferenceBaseConfig.UseMarkdownForOutput = true
+
+			result, err := svc.replayChain(context.Background(), hist.recorded[0], "rerun-1", tt.overrides, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "replayed", result.FinalText)
+			assert.Equal(t, "rerun-1", result.RunID)
+
+			got := models()
+			require.Len(t, got, 2)
+			assert.Equal(t, tt.wantModel, got[1])
+
+			require.Len(t, hist.recorded, 2)
+			rerun := hist.recorded[1]
+			assert.Equal(t, "orig", rerun.RerunOf)
+			assert.Equal(t, "rerun-1", rerun.ID)
+			assert.Equal(t, rerun.ID, rerun.RunID)
+			assert.Equal(t, "plain", rerun.Format, "the recorded output format is replayed")
+			assert.Equal(t, tt.wantModel, rerun.Model)
+			require.NotNil(t, rerun.Snapshot)
+			assert.Equal(t, tt.wantModel, rerun.Snapshot.ModelConfig.Name)
+		})
+	}
+}
+
+func TestActionService_ReplayChain_Errors(t *testing.T) {
+	t.Parallel()
+	svc, settingsSvc := newReplayService(t, "http://unused", &recordingHistoryService{})
+	settingsSvc.byIDErr = errors.New("provider not found")
+	settingsSvc.byIDProvider = nil
+
+	tests := []struct {
+		name      string
+		entry     apperr.HistoryEntry
+		wantField string
+	}{
+		{"no snapshot", apperr.HistoryEntry{ID: "old"}, "id"},
+		{"provider removed", apperr.HistoryEntry{ID: "e1", Snapshot: &apperr.RunSnapshot{
+			Request:    apperr.ChainRequest{InputText: "input"},
+			ProviderID: "gone",
+		}}, "providerId"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.replayChain(context.Background(), tt.entry, "rerun-1", apperr.RerunOverrides{}, nil)
+			assert.Nil(t, result)
+			var ae *apperr.AppError
+			require.True(t, errors.As(err, &ae))
+			assert.Equal(t, apperr.CodeValidation, ae.Code)
+			assert.Equal(t, tt.wantField, ae.Details["field"])
+		})
+	}
+}
+
+func TestActionHandler_RerunHistoryEntry(t *testing.T) {
+	t.Parallel()
+	var h *ActionHandler
+	var cancelledRun atomic.Value
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The re-run's first call cancels it through CancelChain, as the
+		// frontend would with the run ID from its progress events.
+		if atomic.AddInt64(&calls, 1) == 3 {
+			h.mu.Lock()
+			for id := range h.runs {
+				cancelledRun.Store(id)
+			}
+			h.mu.Unlock()
+			h.CancelChain(cancelledRun.Load().(string))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"role": "assistant", "content": "replayed"}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	hist := &recordingHistoryService{}
+	svc, _ := newReplayService(t, srv.URL, hist)
+	id0, id1 := twoFamilySteps(t, svc)
+	_, err := svc.RunChain(context.Background(), apperr.ChainRequest{
+		RunID:     "orig",
+		InputText: "input",
+		Steps:     []apperr.ChainStep{{ActionID: id0}, {ActionID: id1}},
+	}, nil)
+	require.NoError(t, err)
+
+	h = NewActionHandler(nil, svc, &mockVerificationService{}, gate.New())
+	res := h.RerunHistoryEntry("orig", apperr.RerunOverrides{})
+
+	require.NotNil(t, res.Error)
+	assert.Equal(t, apperr.CodeCancelled, res.Error.Code)
+	require.NotNil(t, res.Data)
+	runID, _ := cancelledRun.Load().(string)
+	require.NotEmpty(t, runID, "the re-run must be registered for CancelChain")
+	assert.Equal(t, runID, res.Data.RunID)
+	assert.Empty(t, h.runs, "the run is unregistered once it ends")
+
+	res = h.RerunHistoryEntry("", apperr.RerunOverrides{})
+	require.NotNil(t, res.Error)
+	assert.Equal(t, apperr.CodeValidation, res.Error.Code)
+	res = h.RerunHistoryEntry("missing", apperr.RerunOverrides{})
+	assert.NotNil(t, res.Error)
+}
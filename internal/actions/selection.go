@@ -0,0 +1,162 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go_text/internal/apperr"
+)
+
+// Units of apperr.ChainSelection offsets.
+const (
+	SelectionUnitByte = "byte"
+	SelectionUnitRune = "rune"
+)
+
+// DefaultSelectionContext is how many runes of surrounding text on each side of
+// a selection the model sees when the request does not say.
+const DefaultSelectionContext = 1500
+
+// maxSelectionContext caps apperr.ChainSelection.ContextRunes so the context
+// cannot crowd the selection out of the model's context window.
+const maxSelectionContext = 20000
+
+// selectionContextFmt is appended to the user prompt of a selection-scoped run:
+// the text to transform is an excerpt, and the document around it is shown for
+// consistency only. The blocks are filled by selectionContextBlock.
+const selectionContextFmt = "\n\nThe text above is an excerpt of a longer document. The parts of the document " +
+	"around it are shown below for reference only: keep the result consistent with them (terminology, names, " +
+	"tense, tone), but transform only the excerpt, and do not repeat, rewrite or continue the surrounding text.%s"
+
+// selectionRange resolves req.Selection to a byte range of req.InputText. With
+// no selection the range is the whole text. A range that is out of bounds,
+// empty, blank, split inside a character, or in an unknown unit is a validation
+// error, as is a negative or oversized context.
+func selectionRange(req apperr.ChainRequest) (apperr.TextRange, error) {
+	sel := req.Selection
+	if sel == nil {
+		return apperr.TextRange{Start: 0, End: len(req.InputText)}, nil
+	}
+	if sel.ContextRunes != nil && (*sel.ContextRunes < 0 || *sel.ContextRunes > maxSelectionContext) {
+		return apperr.TextRange{}, apperr.Validation("selection.contextRunes",
+			fmt.Sprintf("between 0 and %d", maxSelectionContext), fmt.Sprint(*sel.ContextRunes))
+	}
+
+	start, end := sel.Start, sel.End
+	switch sel.Unit {
+	case "", SelectionUnitByte:
+		if start < 0 || end > len(req.InputText) || start >= end {
+			return apperr.TextRange{}, invalidSelection(req)
+		}
+		if !utf8.RuneStart(req.InputText[start]) || (end < len(req.InputText) && !utf8.RuneStart(req.InputText[end])) {
+			return apperr.TextRange{}, apperr.Validation("selection", "offsets on character boundaries",
+				fmt.Sprintf("%d–%d", start, end))
+		}
+	case SelectionUnitRune:
+		start, end = runeToByteOffset(req.InputText, sel.Start), runeToByteOffset(req.InputText, sel.End)
+		if sel.Start < 0 || start < 0 || end < 0 || start >= end {
+			return apperr.TextRange{}, invalidSelection(req)
+		}
+	default:
+		return apperr.TextRange{}, apperr.Validation("selection.unit",
+			fmt.Sprintf("%q or %q", SelectionUnitByte, SelectionUnitRune), sel.Unit)
+	}
+
+	if strings.TrimSpace(req.InputText[start:end]) == "" {
+		return apperr.TextRange{}, apperr.Validation("selection", "non-blank selected text", "whitespace only")
+	}
+	return apperr.TextRange{Start: start, End: end}, nil
+}
+
+// invalidSelection reports a selection range that does not fit the input text.
+func invalidSelection(req apperr.ChainRequest) error {
+	return apperr.Validation("selection", "a non-empty range within the input text",
+		fmt.Sprintf("%d–%d", req.Selection.Start, req.Selection.End))
+}
+
+// runeToByteOffset returns the byte offset of rune n of s, len(s) for n equal to
+// the rune count, and -1 when n is out of range.
+func runeToByteOffset(s string, n int) int {
+	if n < 0 {
+		return -1
+	}
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	if n == 0 {
+		return len(s)
+	}
+	return -1
+}
+
+// spliceSelection returns req.InputText with the range r replaced by out. For a
+// run without a selection r covers the whole text, so out is returned as is.
+func spliceSelection(req apperr.ChainRequest, r apperr.TextRange, out string) string {
+	return req.InputText[:r.Start] + out + req.InputText[r.End:]
+}
+
+// selectionContextBlock returns the read-only context block appended to the user
+// prompt of a selection-scoped run: up to the requested number of runes before
+// and after the selection, cut where the context window ends. It returns "" for
+// a run without a selection or when there is no context to send.
+func selectionContextBlock(req apperr.ChainRequest) string {
+	if req.Selection == nil {
+		return ""
+	}
+	r, err := selectionRange(req)
+	if err != nil {
+		return ""
+	}
+	size := DefaultSelectionContext
+	if req.Selection.ContextRunes != nil {
+		size = *req.Selection.ContextRunes
+	}
+
+	before := lastRunes(req.InputText[:r.Start], size)
+	after := firstRunes(req.InputText[r.End:], size)
+	var blocks strings.Builder
+	if strings.TrimSpace(before) != "" {
+		fmt.Fprintf(&blocks, "\n\n<<<Context Before Start>>>\n%s\n<<<Context Before End>>>", before)
+	}
+	if strings.TrimSpace(after) != "" {
+		fmt.Fprintf(&blocks, "\n\n<<<Context After Start>>>\n%s\n<<<Context After End>>>", after)
+	}
+	if blocks.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf(selectionContextFmt, blocks.String())
+}
+
+// lastRunes returns the last n runes of s, prefixed with "…" when s was cut.
+func lastRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	i := len(s)
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	if i > 0 {
+		return "…" + s[i:]
+	}
+	return s
+}
+
+// firstRunes returns the first n runes of s, followed by "…" when s was cut.
+func firstRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	for i := range s {
+		if n == 0 {
+			return s[:i] + "…"
+		}
+		n--
+	}
+	return s
+}
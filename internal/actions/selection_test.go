@@ -0,0 +1,84 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_text/internal/apperr"
+)
+
+func TestSelectionRange(t *testing.T) {
+	const text = "Héllo wörld. Bye."
+	tests := []struct {
+		name      string
+		selection *apperr.ChainSelection
+		want      apperr.TextRange
+		wantField string
+	}{
+		{name: "no selection covers the text", want: apperr.TextRange{Start: 0, End: len(text)}},
+		{name: "bytes", selection: &apperr.ChainSelection{Start: 0, End: 6}, want: apperr.TextRange{Start: 0, End: 6}},
+		{
+			name:      "runes",
+			selection: &apperr.ChainSelection{Start: 6, End: 12, Unit: SelectionUnitRune},
+			want:      apperr.TextRange{Start: 7, End: 14},
+		},
+		{
+			name:      "runes to the end",
+			selection: &apperr.ChainSelection{Start: 13, End: 17, Unit: SelectionUnitRune},
+			want:      apperr.TextRange{Start: 15, End: len(text)},
+		},
+		{name: "byte inside a character", selection: &apperr.ChainSelection{Start: 2, End: 6}, wantField: "selection"},
+		{name: "past the end", selection: &apperr.ChainSelection{Start: 0, End: 99}, wantField: "selection"},
+		{name: "rune past the end", selection: &apperr.ChainSelection{Start: 0, End: 18, Unit: SelectionUnitRune}, wantField: "selection"},
+		{name: "empty", selection: &apperr.ChainSelection{Start: 3, End: 3}, wantField: "selection"},
+		{name: "reversed", selection: &apperr.ChainSelection{Start: 5, End: 1}, wantField: "selection"},
+		{name: "blank", selection: &apperr.ChainSelection{Start: 6, End: 7}, wantField: "selection"},
+		{name: "unknown unit", selection: &apperr.ChainSelection{Start: 0, End: 1, Unit: "word"}, wantField: "selection.unit"},
+		{
+			name:      "negative context",
+			selection: &apperr.ChainSelection{Start: 0, End: 1, ContextRunes: intPtr(-1)},
+			wantField: "selection.contextRunes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectionRange(apperr.ChainRequest{InputText: text, Selection: tt.selection})
+			if tt.wantField != "" {
+				var ae *apperr.AppError
+				require.ErrorAs(t, err, &ae)
+				assert.Equal(t, apperr.CodeValidation, ae.Code)
+				assert.Equal(t, tt.wantField, ae.Details["field"])
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSelectionContextBlock(t *testing.T) {
+	const text = "First paragraph.\n\nSecond paragraph.\n\nThird paragraph."
+	second := strings.Index(text, "Second")
+	sel := func(contextRunes *int) apperr.ChainRequest {
+		return apperr.ChainRequest{InputText: text, Selection: &apperr.ChainSelection{
+			Start: second, End: second + len("Second paragraph."), ContextRunes: contextRunes,
+		}}
+	}
+
+	block := selectionContextBlock(sel(nil))
+	assert.Contains(t, block, "<<<Context Before Start>>>\nFirst paragraph.\n\n\n<<<Context Before End>>>")
+	assert.Contains(t, block, "<<<Context After Start>>>\n\n\nThird paragraph.\n<<<Context After End>>>")
+
+	block = selectionContextBlock(sel(intPtr(8)))
+	assert.Contains(t, block, "<<<Context Before Start>>>\n…graph.\n\n\n<<<Context Before End>>>")
+	assert.Contains(t, block, "<<<Context After Start>>>\n\n\nThird …\n<<<Context After End>>>")
+
+	assert.Empty(t, selectionContextBlock(sel(intPtr(0))), "no context requested")
+	assert.Empty(t, selectionContextBlock(apperr.ChainRequest{InputText: text}), "no selection")
+	whole := apperr.ChainRequest{InputText: text, Selection: &apperr.ChainSelection{Start: 0, End: len(text)}}
+	assert.Empty(t, selectionContextBlock(whole), "nothing around the selection")
+}
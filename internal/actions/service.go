@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go_text/internal/apperr"
 	"go_text/internal/history"
+	"go_text/internal/langdetect"
 	"go_text/internal/llms"
 	"go_text/internal/logging"
 	"go_text/internal/prompts"
@@ -76,6 +77,11 @@ type ActionServiceAPI interface {
 	GetActionCatalog() []apperr.ActionMeta
 	BuildPlanAndPrompts(req apperr.PromptPreviewRequest) (*apperr.PromptPreview, error)
 	RunChain(ctx context.Context, req apperr.ChainRequest, emitProgress func(apperr.StepProgress)) (*apperr.ChainResult, error)
+	ResumeChain(ctx context.Context, runID string, emitProgress func(apperr.StepProgress)) (*apperr.ChainResult, error)
+	RerunEntry(ctx context.Context, entryID, runID string, overrides apperr.RerunOverrides, emitProgress func(apperr.StepProgress)) (*apperr.ChainResult, error)
+	ExplainPlan(req apperr.ChainRequest) (*apperr.PlanExplanation, error)
+	DetectLanguage(text string) (*apperr.LanguageDetection, error)
+	ApplyEdits(text string, edits []apperr.TextEdit) (string, error)
 }
 
 type ActionService struct {
@@ -85,11 +91,15 @@ type ActionService struct {
 	settingsService settings.SettingsServiceAPI
 	taskLogService  tasklog.TaskLogServiceAPI
 	historyService  history.HistoryServiceAPI
-	catalog         []apperr.ActionMeta // cached at construction; avoids promptService call in BuildPlanAndPrompts
+	checkpoints     CheckpointRepositoryAPI // nil until Init wires SqliteCheckpointRepository
+	catalog         []apperr.ActionMeta     // cached at construction; avoids promptService call in BuildPlanAndPrompts
+	catalogHash     string                  // hashCatalog(catalog), recorded in run snapshots
 	planner         *Planner
 	composer        *Composer
 }
 
+// NewActionService constructs an ActionService. Panics on nil dependencies.
+// Returns *ActionService (concrete) so ApplicationContextHolder can call SetCheckpointRepository.
 func NewActionService(
 	logger *logging.Logger,
 	promptService prompts.PromptServiceAPI,
@@ -97,7 +107,7 @@ func NewActionService(
 	settingsService settings.SettingsServiceAPI,
 	taskLogService tasklog.TaskLogServiceAPI,
 	historyService history.HistoryServiceAPI,
-) ActionServiceAPI {
+) *ActionService {
 	const op = "ActionService.NewActionService"
 
 	if logger == nil {
@@ -129,6 +139,7 @@ func NewActionService(
 		taskLogService:  taskLogService,
 		historyService:  historyService,
 		catalog:         catalog,
+		catalogHash:     hashCatalog(catalog),
 		planner:         NewPlanner(catalog),
 		composer:        NewComposer(catalog),
 	}
@@ -190,9 +201,11 @@ func (a *ActionService) GetActionCatalog() []apperr.ActionMeta {
 }
 
 // runStep executes one LLM inference: builds the chat-completion request,
-// calls the provider, strips reasoning blocks, and writes one tasklog entry.
-// It is the shared primitive used by processAction and (via T13) ChainOrchestrator.
-func (a *ActionService) runStep(ctx context.Context, cfg *settings.Settings, req ChatStepRequest) (string, error) {
+// calls the provider, strips reasoning blocks and — when AppBehaviorConfig.CleanOutput
+// is set — model chatter (see cleanOutput), and writes one tasklog entry.
+// It is the shared primitive used by processAction and (via T13) ChainOrchestrator,
+// and also returns the tokens the provider reported for the call.
+func (a *ActionService) runStep(ctx context.Context, cfg *settings.Settings, req ChatStepRequest) (string, llms.TokenUsage, error) {
 	const op = "ActionService.runStep"
 	startTime := time.Now()
 
@@ -206,11 +219,12 @@ func (a *ActionService) runStep(ctx context.Context, cfg *settings.Settings, req
 	lg.Debug().Strs("actions", req.ActionIDs).Msg("starting LLM inference")
 
 	llmReq := newChatCompletionRequest(cfg, req.User, req.System)
-	rawResp, err := a.llmService.GetCompletionResponse(ctx, &llmReq)
+	resp, err := a.llmService.GetCompletionWithSettings(ctx, cfg, &llmReq)
 	if err != nil {
 		lg.Error().Err(err).Msg("LLM call failed")
-		return "", fmt.Errorf("%s: LLM call failed: %w", op, err)
+		return "", llms.TokenUsage{}, fmt.Errorf("%s: LLM call failed: %w", op, err)
 	}
+	rawResp := resp.Content
 
 	if strings.TrimSpace(rawResp) == "" {
 		lg.Warn().Msg("received empty response from LLM")
@@ -219,7 +233,19 @@ func (a *ActionService) runStep(ctx context.Context, cfg *settings.Settings, req
 	result, err := a.promptService.SanitizeReasoningBlock(rawResp)
 	if err != nil {
 		lg.Error().Err(err).Msg("sanitize failed")
-		return "", fmt.Errorf("%s: sanitize failed: %w", op, err)
+		return "", llms.TokenUsage{}, fmt.Errorf("%s: sanitize failed: %w", op, err)
+	}
+
+	var stripped []tasklog.StrippedText
+	if cfg.AppBehaviorConfig.CleanOutput {
+		result, stripped = cleanOutput(result, req.InputText, req.GroupFamily, req.ActionIDs)
+		if len(stripped) > 0 {
+			rules := make([]string, len(stripped))
+			for i, s := range stripped {
+				rules[i] = s.Rule
+			}
+			lg.Debug().Strs("stripped", rules).Msg("output cleaned")
+		}
 	}
 
 	actionID := strings.Join(req.ActionIDs, "+")
@@ -241,6 +267,7 @@ func (a *ActionService) runStep(ctx context.Context, cfg *settings.Settings, req
 		InputLanguage:  req.InputLang,
 		OutputLanguage: req.OutputLang,
 		RunID:          req.RunID,
+		Stripped:       stripped,
 	})
 
 	lg.Debug().
@@ -248,7 +275,7 @@ func (a *ActionService) runStep(ctx context.Context, cfg *settings.Settings, req
 		Int("result_len", len(result)).
 		Msg("step completed")
 
-	return result, nil
+	return result, resp.Usage, nil
 }
 
 // buildPreviewParams constructs PreviewParams from resolved settings and request context.
@@ -282,6 +309,49 @@ func buildPreviewParams(cfg *settings.Settings, req apperr.PromptPreviewRequest)
 	return p
 }
 
+// ExplainPlan traces how the planner treats req under the configured plan limits.
+// An invalid plan is not an error here: the explanation reports the failed checks.
+func (a *ActionService) ExplainPlan(req apperr.ChainRequest) (*apperr.PlanExplanation, error) {
+	const op = "ActionService.ExplainPlan"
+	var limits PlanLimits
+	if a.settingsService != nil {
+		cfg, err := a.settingsService.GetSettings()
+		if err != nil {
+			return nil, fmt.Errorf("%s: resolve settings: %w", op, err)
+		}
+		limits = PlanLimitsFromSettings(cfg.AppBehaviorConfig)
+	}
+	ex := a.planner.Explain(req, limits)
+	return &ex, nil
+}
+
+// DetectLanguage identifies which of the configured languages text is written in.
+// An unidentifiable text is not an error: the detection comes back with an empty
+// Language and zero confidence.
+func (a *ActionService) DetectLanguage(text string) (*apperr.LanguageDetection, error) {
+	const op = "ActionService.DetectLanguage"
+	if strings.TrimSpace(text) == "" {
+		return nil, apperr.Validation("text", "non-empty text", "empty string")
+	}
+	var candidates []string
+	if a.settingsService != nil {
+		cfg, err := a.settingsService.GetSettings()
+		if err != nil {
+			return nil, fmt.Errorf("%s: resolve settings: %w", op, err)
+		}
+		candidates = cfg.LanguageConfig.Languages
+	}
+	d := langdetect.Detect(text, candidates)
+	return &apperr.LanguageDetection{Language: d.Language, Confidence: d.Confidence}, nil
+}
+
+// ApplyEdits returns text with edits applied, typically the subset of an
+// apperr.EditList the user accepted. Each edit must still cover its Original in
+// text, and edits may not overlap; otherwise a validation error is returned.
+func (a *ActionService) ApplyEdits(text string, edits []apperr.TextEdit) (string, error) {
+	return applyEdits(text, edits)
+}
+
 // BuildPlanAndPrompts runs planning + composition without calling the LLM.
 // Used by PreviewPrompt (T15). Same Planner + Composer as RunChain — preview cannot drift from a real run.
 // Group 0 uses sampleInput (or a placeholder); groups 1+ show the previous-step placeholder.
@@ -298,31 +368,35 @@ func (a *ActionService) BuildPlanAndPrompts(req apperr.PromptPreviewRequest) (*a
 		InputLanguageID:  req.InputLanguageID,
 		OutputLanguageID: req.OutputLanguageID,
 		UseMarkdown:      req.UseMarkdown,
+		StrictOrder:      req.StrictOrder,
 	}
 	if req.ActionID != "" && len(req.Steps) == 0 {
 		chainReq.Steps = []apperr.ChainStep{{ActionID: req.ActionID}}
 	}
 
-	plan, err := a.planner.Plan(chainReq)
-	if err != nil {
-		return nil, fmt.Errorf("%s: planning failed: %w", op, err)
-	}
-
-	sampleInput := req.SampleInput
-	if sampleInput == "" {
-		sampleInput = defaultSampleInput
-	}
-
-	// Fill per-group parameters from current settings when the service is fully wired.
-	// In unit tests that construct ActionService directly without a settingsService, params
-	// are left as zero values — tests that verify Parameters must supply a mock settingsService.
+	// Fill per-group parameters and plan limits from current settings when the service
+	// is fully wired. In unit tests that construct ActionService directly without a
+	// settingsService, params are left as zero values and the default limits apply —
+	// tests that verify Parameters must supply a mock settingsService.
 	var params apperr.PreviewParams
+	var limits PlanLimits
 	if a.settingsService != nil {
 		cfg, err := a.settingsService.GetSettings()
 		if err != nil {
 			return nil, fmt.Errorf("%s: resolve settings: %w", op, err)
 		}
 		params = buildPreviewParams(cfg, req)
+		limits = PlanLimitsFromSettings(cfg.AppBehaviorConfig)
+	}
+
+	plan, err := a.planner.PlanWithLimits(chainReq, limits)
+	if err != nil {
+		return nil, fmt.Errorf("%s: planning failed: %w", op, err)
+	}
+
+	sampleInput := req.SampleInput
+	if sampleInput == "" {
+		sampleInput = defaultSampleInput
 	}
 
 	catalogMap := make(map[string]apperr.ActionMeta, len(a.catalog))
@@ -338,8 +412,13 @@ func (a *ActionService) BuildPlanAndPrompts(req apperr.PromptPreviewRequest) (*a
 		if i > 0 {
 			groupInput = prevStepPlaceholder
 		}
-		sys, user := a.composer.Compose(g, groupInput, chainReq, req.UseMarkdown)
-		estimatedTokens := prompts.EstimateTokenCount(sys) + prompts.EstimateTokenCount(user)
+		// Local groups run in-process: there is no prompt to show.
+		var sys, user string
+		var estimatedTokens int
+		if !g.isLocal() {
+			sys, user = a.composer.Compose(g, groupInput, chainReq, req.UseMarkdown)
+			estimatedTokens = prompts.EstimateTokenCount(sys) + prompts.EstimateTokenCount(user)
+		}
 
 		applied := make([]apperr.AppliedAction, len(g.Steps))
 		for j, s := range g.Steps {
@@ -348,6 +427,7 @@ func (a *ActionService) BuildPlanAndPrompts(req apperr.PromptPreviewRequest) (*a
 				ID:       meta.ID,
 				Name:     meta.Name,
 				Category: meta.Category,
+				Params:   resolveParams(meta, s),
 			}
 		}
 
@@ -359,6 +439,7 @@ func (a *ActionService) BuildPlanAndPrompts(req apperr.PromptPreviewRequest) (*a
 			UserPrompt:      user,
 			Parameters:      params,
 			EstimatedTokens: estimatedTokens,
+			Local:           g.isLocal(),
 		}
 	}
 
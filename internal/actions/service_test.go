@@ -128,6 +128,9 @@ func (s *stubLLMService) GetModelsList() ([]string, error) { return nil, nil }
 func (s *stubLLMService) GetCompletionResponse(_ context.Context, _ *llms.ChatCompletionRequest) (string, error) {
 	return "", nil
 }
+func (s *stubLLMService) GetCompletionWithSettings(_ context.Context, _ *settings.Settings, _ *llms.ChatCompletionRequest) (llms.ChatResponse, error) {
+	return llms.ChatResponse{}, nil
+}
 func (s *stubLLMService) GetModelsListForProvider(_ *settings.ProviderConfig) ([]string, error) {
 	return nil, nil
 }
@@ -0,0 +1,54 @@
+package analytics
+
+import (
+	"fmt"
+
+	"go_text/internal/apperr"
+	"go_text/internal/logging"
+
+	"github.com/rs/zerolog"
+)
+
+const panicMsgFmt = "panic: %v"
+
+// AnalyticsHandler is the Wails-bound handler for usage analytics.
+// All bound methods follow the envelope pattern: return apperr.*Result,
+// no error return, and include defer/recover for panic safety.
+type AnalyticsHandler struct {
+	appLogger *logging.Logger
+	service   AnalyticsServiceAPI
+}
+
+// NewAnalyticsHandler constructs an AnalyticsHandler.
+func NewAnalyticsHandler(appLogger *logging.Logger, service AnalyticsServiceAPI) *AnalyticsHandler {
+	return &AnalyticsHandler{appLogger: appLogger, service: service}
+}
+
+// liveZlog returns a live snapshot of the app logger's current writer, or a
+// no-op logger if appLogger has not been wired (e.g. bare struct-literal
+// tests exercising panic recovery).
+func (h *AnalyticsHandler) liveZlog() zerolog.Logger {
+	if h.appLogger != nil {
+		return h.appLogger.ZeroLogger()
+	}
+	return zerolog.Nop()
+}
+
+// GetUsageAnalytics returns the usage analytics of the runs recorded in the
+// date range of req: runs per day, action, stack and model, outcome rates by
+// error code, latency percentiles and inferences per run.
+func (h *AnalyticsHandler) GetUsageAnalytics(req apperr.AnalyticsRequest) (res apperr.UsageAnalyticsResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.UsageAnalyticsResult{Error: &wire}
+		}
+	}()
+	usage, err := h.service.Usage(req)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.UsageAnalyticsResult{Error: &wire}
+	}
+	return apperr.UsageAnalyticsResult{Data: usage}
+}
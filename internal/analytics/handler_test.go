@@ -0,0 +1,52 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+// mockAnalyticsService satisfies AnalyticsServiceAPI.
+type mockAnalyticsService struct {
+	req    apperr.AnalyticsRequest
+	usage  *apperr.UsageAnalytics
+	err    error
+	panics bool
+}
+
+func (m *mockAnalyticsService) Usage(req apperr.AnalyticsRequest) (*apperr.UsageAnalytics, error) {
+	if m.panics {
+		panic("boom")
+	}
+	m.req = req
+	return m.usage, m.err
+}
+
+func TestAnalyticsHandler_GetUsageAnalytics(t *testing.T) {
+	svc := &mockAnalyticsService{usage: &apperr.UsageAnalytics{Runs: 4}}
+	req := apperr.AnalyticsRequest{From: 100, To: 200}
+	res := NewAnalyticsHandler(nil, svc).GetUsageAnalytics(req)
+	if res.Error != nil || res.Data != svc.usage {
+		t.Errorf("GetUsageAnalytics = %+v", res)
+	}
+	if svc.req != req {
+		t.Errorf("service got %+v, want %+v", svc.req, req)
+	}
+
+	failing := NewAnalyticsHandler(nil, &mockAnalyticsService{err: apperr.Validation("to", "not be before from", "1")})
+	if res := failing.GetUsageAnalytics(req); res.Error == nil || res.Error.Code != apperr.CodeValidation {
+		t.Errorf("error = %+v, want validation", res.Error)
+	}
+	failing = NewAnalyticsHandler(nil, &mockAnalyticsService{err: errors.New("db fail")})
+	if res := failing.GetUsageAnalytics(req); res.Error == nil || res.Error.Code != apperr.CodeInternal {
+		t.Errorf("error = %+v, want internal", res.Error)
+	}
+}
+
+func TestAnalyticsHandler_RecoversPanics(t *testing.T) {
+	res := NewAnalyticsHandler(nil, &mockAnalyticsService{panics: true}).GetUsageAnalytics(apperr.AnalyticsRequest{})
+	if res.Error == nil || res.Error.Code != apperr.CodeInternal {
+		t.Errorf("GetUsageAnalytics = %+v, want internal error", res)
+	}
+}
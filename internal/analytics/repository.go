@@ -0,0 +1,12 @@
+package analytics
+
+import "go_text/internal/apperr"
+
+// AnalyticsRepositoryAPI is the contract for the SQLite analytics repository.
+// All methods use context.Background() internally — Wails bound callers supply no ctx.
+type AnalyticsRepositoryAPI interface {
+	// Usage aggregates the history entries created between from and to (Unix
+	// seconds, inclusive). Days are bucketed utcOffset seconds east of UTC and
+	// only days with runs are returned.
+	Usage(from, to, utcOffset int64) (*apperr.UsageAnalytics, error)
+}
@@ -0,0 +1,121 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go_text/internal/apperr"
+	"go_text/internal/db"
+	"go_text/internal/db/store"
+)
+
+// SqliteAnalyticsRepository is the SQLite-backed implementation of
+// AnalyticsRepositoryAPI. It only reads the history table.
+type SqliteAnalyticsRepository struct {
+	database *db.Database
+}
+
+// NewSqliteAnalyticsRepository constructs an analytics repository backed by database.
+func NewSqliteAnalyticsRepository(database *db.Database) *SqliteAnalyticsRepository {
+	if database == nil {
+		panic("SqliteAnalyticsRepository: database cannot be nil")
+	}
+	return &SqliteAnalyticsRepository{database: database}
+}
+
+func (r *SqliteAnalyticsRepository) bg() context.Context { return context.Background() }
+
+// Usage runs the usage aggregations over the entries created between from and
+// to, inclusive.
+func (r *SqliteAnalyticsRepository) Usage(from, to, utcOffset int64) (*apperr.UsageAnalytics, error) {
+	const op = "SqliteAnalyticsRepository.Usage"
+	ctx := r.bg()
+	q := r.database.Queries
+
+	totals, err := q.UsageTotals(ctx, store.UsageTotalsParams{CreatedFrom: from, CreatedTo: to})
+	if err != nil {
+		return nil, fmt.Errorf("%s: totals: %w", op, err)
+	}
+	usage := &apperr.UsageAnalytics{
+		From:          from,
+		To:            to,
+		Runs:          int(totals.Runs),
+		P50Ms:         totals.P50Ms,
+		P95Ms:         totals.P95Ms,
+		AvgInferences: totals.AvgInferences,
+		Days:          []apperr.AnalyticsDay{},
+		Actions:       []apperr.AnalyticsCount{},
+		Stacks:        []apperr.AnalyticsCount{},
+		Models:        []apperr.AnalyticsModel{},
+		Outcomes:      []apperr.AnalyticsOutcome{},
+		Inferences:    []apperr.AnalyticsCount{},
+	}
+	if totals.Runs > 0 {
+		usage.SuccessRate = float64(totals.Success) / float64(totals.Runs)
+	}
+
+	days, err := q.UsageByDay(ctx, store.UsageByDayParams{UtcOffset: utcOffset, CreatedFrom: from, CreatedTo: to})
+	if err != nil {
+		return nil, fmt.Errorf("%s: by day: %w", op, err)
+	}
+	for _, d := range days {
+		usage.Days = append(usage.Days, apperr.AnalyticsDay{
+			Day: d.Day, Runs: int(d.Runs), Success: int(d.Success), Partial: int(d.Partial), Error: int(d.Error),
+		})
+	}
+
+	actions, err := q.UsageByAction(ctx, store.UsageByActionParams{CreatedFrom: from, CreatedTo: to})
+	if err != nil {
+		return nil, fmt.Errorf("%s: by action: %w", op, err)
+	}
+	for _, a := range actions {
+		usage.Actions = append(usage.Actions, apperr.AnalyticsCount{Key: a.ActionID, Label: a.Name, Runs: int(a.Runs)})
+	}
+
+	stacks, err := q.UsageByStack(ctx, store.UsageByStackParams{CreatedFrom: from, CreatedTo: to})
+	if err != nil {
+		return nil, fmt.Errorf("%s: by stack: %w", op, err)
+	}
+	for _, st := range stacks {
+		usage.Stacks = append(usage.Stacks, apperr.AnalyticsCount{Key: st.Title, Runs: int(st.Runs)})
+	}
+
+	models, err := q.UsageByModel(ctx, store.UsageByModelParams{CreatedFrom: from, CreatedTo: to})
+	if err != nil {
+		return nil, fmt.Errorf("%s: by model: %w", op, err)
+	}
+	for _, m := range models {
+		usage.Models = append(usage.Models, apperr.AnalyticsModel{
+			ProviderName:  m.ProviderName,
+			Model:         m.Model,
+			Runs:          int(m.Runs),
+			Success:       int(m.Success),
+			Partial:       int(m.Partial),
+			Error:         int(m.Error),
+			SuccessRate:   float64(m.Success) / float64(m.Runs),
+			P50Ms:         m.P50Ms,
+			P95Ms:         m.P95Ms,
+			AvgInferences: m.AvgInferences,
+		})
+	}
+
+	outcomes, err := q.UsageByOutcome(ctx, store.UsageByOutcomeParams{CreatedFrom: from, CreatedTo: to})
+	if err != nil {
+		return nil, fmt.Errorf("%s: by outcome: %w", op, err)
+	}
+	for _, o := range outcomes {
+		usage.Outcomes = append(usage.Outcomes, apperr.AnalyticsOutcome{
+			Status: o.Status, ErrorCode: o.ErrorCode, Runs: int(o.Runs), Rate: float64(o.Runs) / float64(totals.Runs),
+		})
+	}
+
+	inferences, err := q.UsageByInferences(ctx, store.UsageByInferencesParams{CreatedFrom: from, CreatedTo: to})
+	if err != nil {
+		return nil, fmt.Errorf("%s: by inferences: %w", op, err)
+	}
+	for _, n := range inferences {
+		usage.Inferences = append(usage.Inferences, apperr.AnalyticsCount{Key: strconv.FormatInt(n.Inferences, 10), Runs: int(n.Runs)})
+	}
+	return usage, nil
+}
@@ -0,0 +1,140 @@
+package analytics
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go_text/internal/apperr"
+	"go_text/internal/db"
+	"go_text/internal/history"
+)
+
+func newAnalyticsRepo(t *testing.T) (*SqliteAnalyticsRepository, *history.SqliteHistoryRepository) {
+	t.Helper()
+	d, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+	return NewSqliteAnalyticsRepository(d), history.NewSqliteHistoryRepository(d)
+}
+
+// run is a history entry at createdAt seconds after the Unix epoch.
+func run(id string, createdAt int64, model, status, code string, durationMs int64, inferences int, actions ...string) apperr.HistoryEntry {
+	applied := make([]apperr.AppliedAction, len(actions))
+	for i, a := range actions {
+		applied[i] = apperr.AppliedAction{ID: a, Name: "Name of " + a}
+	}
+	kind := "single"
+	title := ""
+	if len(actions) > 1 {
+		kind = "stack"
+	}
+	for i, a := range actions {
+		if i > 0 {
+			title += " + "
+		}
+		title += a
+	}
+	return apperr.HistoryEntry{
+		ID: id, CreatedAt: createdAt, Kind: kind, Title: title, InputText: "in", OutputText: "out",
+		Applied: applied, ProviderName: "Ollama", Model: model, Status: status, ErrorCode: code,
+		FailedIndex: -1, DurationMs: durationMs, Inferences: inferences,
+	}
+}
+
+func TestSqliteAnalyticsRepository_Usage(t *testing.T) {
+	repo, hist := newAnalyticsRepo(t)
+	const day = 86400
+	entries := []apperr.HistoryEntry{
+		run("a", 1*day+10, "llama3", "success", "", 100, 1, "proofread"),
+		run("b", 1*day+20, "llama3", "success", "", 200, 1, "proofread"),
+		run("c", 1*day+30, "llama3", "success", "", 300, 2, "proofread", "translate"),
+		run("d", 1*day+40, "llama3", "success", "", 400, 2, "proofread", "translate"),
+		run("e", 3*day+10, "llama3", "error", "timeout", 9000, 1),
+		run("f", 3*day+20, "qwen", "partial", "rate_limited", 500, 2, "summarize"),
+		run("g", 3*day+30, "qwen", "success", "", 50, 1, "summarize"),
+		// Outside the range.
+		run("h", 9*day, "qwen", "success", "", 10, 1, "summarize"),
+	}
+	// A skipped action is not counted as used.
+	entries[6].Applied = append(entries[6].Applied, apperr.AppliedAction{ID: "tone", Name: "Tone", Skipped: true})
+	for _, e := range entries {
+		if err := hist.Add(e, 0); err != nil {
+			t.Fatalf("Add %s: %v", e.ID, err)
+		}
+	}
+
+	got, err := repo.Usage(0, 5*day, 0)
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if got.Runs != 7 || got.SuccessRate != 5.0/7 || got.AvgInferences != 10.0/7 {
+		t.Errorf("totals: runs=%d successRate=%v avgInferences=%v", got.Runs, got.SuccessRate, got.AvgInferences)
+	}
+	// Successful durations 50, 100, 200, 300, 400: nearest rank 3 and 5.
+	if got.P50Ms != 200 || got.P95Ms != 400 {
+		t.Errorf("latency p50=%d p95=%d, want 200 and 400", got.P50Ms, got.P95Ms)
+	}
+	wantDays := []apperr.AnalyticsDay{
+		{Day: "1970-01-02", Runs: 4, Success: 4},
+		{Day: "1970-01-04", Runs: 3, Success: 1, Partial: 1, Error: 1},
+	}
+	if !reflect.DeepEqual(got.Days, wantDays) {
+		t.Errorf("Days = %+v, want %+v", got.Days, wantDays)
+	}
+	wantActions := []apperr.AnalyticsCount{
+		{Key: "proofread", Label: "Name of proofread", Runs: 4},
+		{Key: "summarize", Label: "Name of summarize", Runs: 2},
+		{Key: "translate", Label: "Name of translate", Runs: 2},
+	}
+	if !reflect.DeepEqual(got.Actions, wantActions) {
+		t.Errorf("Actions = %+v, want %+v", got.Actions, wantActions)
+	}
+	if want := []apperr.AnalyticsCount{{Key: "proofread + translate", Runs: 2}}; !reflect.DeepEqual(got.Stacks, want) {
+		t.Errorf("Stacks = %+v, want %+v", got.Stacks, want)
+	}
+	wantModels := []apperr.AnalyticsModel{
+		{ProviderName: "Ollama", Model: "llama3", Runs: 5, Success: 4, Error: 1, SuccessRate: 0.8, P50Ms: 200, P95Ms: 400, AvgInferences: 1.4},
+		{ProviderName: "Ollama", Model: "qwen", Runs: 2, Success: 1, Partial: 1, SuccessRate: 0.5, P50Ms: 50, P95Ms: 50, AvgInferences: 1.5},
+	}
+	if !reflect.DeepEqual(got.Models, wantModels) {
+		t.Errorf("Models = %+v, want %+v", got.Models, wantModels)
+	}
+	wantOutcomes := []apperr.AnalyticsOutcome{
+		{Status: "success", Runs: 5, Rate: 5.0 / 7},
+		{Status: "error", ErrorCode: "timeout", Runs: 1, Rate: 1.0 / 7},
+		{Status: "partial", ErrorCode: "rate_limited", Runs: 1, Rate: 1.0 / 7},
+	}
+	if !reflect.DeepEqual(got.Outcomes, wantOutcomes) {
+		t.Errorf("Outcomes = %+v, want %+v", got.Outcomes, wantOutcomes)
+	}
+	if want := []apperr.AnalyticsCount{{Key: "1", Runs: 4}, {Key: "2", Runs: 3}}; !reflect.DeepEqual(got.Inferences, want) {
+		t.Errorf("Inferences = %+v, want %+v", got.Inferences, want)
+	}
+
+	// A positive offset moves late-evening runs to the next local day.
+	shifted, err := repo.Usage(0, 5*day, day-15)
+	if err != nil {
+		t.Fatalf("Usage shifted: %v", err)
+	}
+	var runs []int
+	for _, d := range shifted.Days {
+		runs = append(runs, d.Runs)
+	}
+	if want := []int{1, 3, 1, 2}; !reflect.DeepEqual(runs, want) || shifted.Days[3].Day != "1970-01-05" {
+		t.Errorf("shifted Days = %+v, want runs %v", shifted.Days, want)
+	}
+}
+
+func TestSqliteAnalyticsRepository_Usage_Empty(t *testing.T) {
+	repo, _ := newAnalyticsRepo(t)
+	got, err := repo.Usage(0, 100, 0)
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if got.Runs != 0 || got.SuccessRate != 0 || got.P50Ms != 0 || got.Days == nil || got.Models == nil || len(got.Outcomes) != 0 {
+		t.Errorf("empty usage = %+v", got)
+	}
+}
@@ -0,0 +1,115 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/logging"
+)
+
+// Date range defaults: a request without From covers the DefaultRangeDays
+// before To, and no range may span more than MaxRangeDays.
+const (
+	DefaultRangeDays = 30
+	MaxRangeDays     = 366
+)
+
+// dayLayout is the format of apperr.AnalyticsDay.Day.
+const dayLayout = "2006-01-02"
+
+// AnalyticsServiceAPI is the contract consumed by AnalyticsHandler.
+type AnalyticsServiceAPI interface {
+	Usage(req apperr.AnalyticsRequest) (*apperr.UsageAnalytics, error)
+}
+
+// AnalyticsService aggregates recorded runs into usage analytics. Everything is
+// computed from the local history table; nothing is sent anywhere.
+// repo is nil until Init wires it; Usage then returns an internal error.
+type AnalyticsService struct {
+	logger *logging.Logger
+	repo   AnalyticsRepositoryAPI
+	now    func() time.Time
+	loc    *time.Location
+}
+
+// NewAnalyticsService constructs an AnalyticsService. Panics on a nil logger.
+// Returns *AnalyticsService (concrete) so ApplicationContextHolder can call SetRepository.
+func NewAnalyticsService(appLogger *logging.Logger) *AnalyticsService {
+	const op = "AnalyticsService.NewAnalyticsService"
+	if appLogger == nil {
+		panic(fmt.Sprintf("%s: logger cannot be nil", op))
+	}
+	return &AnalyticsService{logger: appLogger, now: time.Now, loc: time.Local}
+}
+
+// SetRepository wires the SQLite-backed repository after the DB is open.
+// Called from ApplicationContextHolder.Init.
+func (s *AnalyticsService) SetRepository(repo AnalyticsRepositoryAPI) {
+	s.repo = repo
+}
+
+// Usage validates the date range of req, fills in its defaults and returns the
+// usage analytics of the runs in it, with a point for every local day.
+func (s *AnalyticsService) Usage(req apperr.AnalyticsRequest) (*apperr.UsageAnalytics, error) {
+	const op = "AnalyticsService.Usage"
+	if s.repo == nil {
+		return nil, apperr.Internal(errors.New("analytics repository not initialized"))
+	}
+	switch {
+	case req.From < 0:
+		return nil, apperr.Validation("from", "be zero or positive", fmt.Sprint(req.From))
+	case req.To < 0:
+		return nil, apperr.Validation("to", "be zero or positive", fmt.Sprint(req.To))
+	}
+	to := req.To
+	if to == 0 {
+		to = s.now().Unix()
+	}
+	from := req.From
+	if from == 0 {
+		from = to - DefaultRangeDays*86400
+	}
+	if from > to {
+		return nil, apperr.Validation("to", "not be before from", fmt.Sprint(to))
+	}
+	if to-from > MaxRangeDays*86400 {
+		return nil, apperr.Validation("to", fmt.Sprintf("at most %d days after from", MaxRangeDays), fmt.Sprintf("%d days", (to-from)/86400))
+	}
+
+	// Days are bucketed at the offset in force at the end of the range.
+	_, offset := time.Unix(to, 0).In(s.loc).Zone()
+	usage, err := s.repo.Usage(from, to, int64(offset))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	usage.Days = fillDays(usage.Days, from, to, time.FixedZone("", offset))
+	s.logger.Debug(fmt.Sprintf("[%s] %d runs over %d days", op, usage.Runs, len(usage.Days)))
+	return usage, nil
+}
+
+// fillDays returns one point per calendar day in loc from the day of from to
+// the day of to, taking the counts of days that have runs.
+func fillDays(days []apperr.AnalyticsDay, from, to int64, loc *time.Location) []apperr.AnalyticsDay {
+	byDay := make(map[string]apperr.AnalyticsDay, len(days))
+	for _, d := range days {
+		byDay[d.Day] = d
+	}
+	start := time.Unix(from, 0).In(loc)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	last := time.Unix(to, 0).In(loc).Format(dayLayout)
+	filled := make([]apperr.AnalyticsDay, 0, (to-from)/86400+2)
+	for {
+		label := day.Format(dayLayout)
+		point, ok := byDay[label]
+		if !ok {
+			point = apperr.AnalyticsDay{Day: label}
+		}
+		filled = append(filled, point)
+		if label >= last {
+			return filled
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,133 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/logging"
+)
+
+// mockRepo records the arguments of Usage and returns usage or err.
+type mockRepo struct {
+	from, to, offset int64
+	usage            *apperr.UsageAnalytics
+	err              error
+}
+
+func (m *mockRepo) Usage(from, to, utcOffset int64) (*apperr.UsageAnalytics, error) {
+	m.from, m.to, m.offset = from, to, utcOffset
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.usage != nil {
+		return m.usage, nil
+	}
+	return &apperr.UsageAnalytics{From: from, To: to}, nil
+}
+
+var testNow = time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+
+func newTestService(t *testing.T, repo AnalyticsRepositoryAPI) *AnalyticsService {
+	t.Helper()
+	wlog, err := logging.New(logging.DefaultConfig(), false)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	svc := NewAnalyticsService(wlog)
+	svc.now = func() time.Time { return testNow }
+	svc.loc = time.FixedZone("UTC+2", 2*3600)
+	if repo != nil {
+		svc.SetRepository(repo)
+	}
+	return svc
+}
+
+func TestAnalyticsService_Usage_Defaults(t *testing.T) {
+	repo := &mockRepo{}
+	svc := newTestService(t, repo)
+
+	got, err := svc.Usage(apperr.AnalyticsRequest{})
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if repo.to != testNow.Unix() || repo.from != testNow.AddDate(0, 0, -DefaultRangeDays).Unix() {
+		t.Errorf("range = %d..%d, want the %d days before now", repo.from, repo.to, DefaultRangeDays)
+	}
+	if repo.offset != 2*3600 {
+		t.Errorf("offset = %d, want %d", repo.offset, 2*3600)
+	}
+	// 2026-02-13 12:00 to 2026-03-15 12:00 local: 31 calendar days.
+	if len(got.Days) != 31 || got.Days[0].Day != "2026-02-13" || got.Days[30].Day != "2026-03-15" {
+		t.Errorf("Days = %d from %s to %s", len(got.Days), got.Days[0].Day, got.Days[len(got.Days)-1].Day)
+	}
+}
+
+func TestAnalyticsService_Usage_FillsDays(t *testing.T) {
+	repo := &mockRepo{usage: &apperr.UsageAnalytics{
+		Runs: 3,
+		Days: []apperr.AnalyticsDay{{Day: "2026-03-02", Runs: 2, Success: 2}, {Day: "2026-03-04", Runs: 1, Error: 1}},
+	}}
+	svc := newTestService(t, repo)
+	from := time.Date(2026, 3, 1, 23, 0, 0, 0, svc.loc).Unix()
+	to := time.Date(2026, 3, 4, 1, 0, 0, 0, svc.loc).Unix()
+
+	got, err := svc.Usage(apperr.AnalyticsRequest{From: from, To: to})
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	want := []apperr.AnalyticsDay{
+		{Day: "2026-03-01"},
+		{Day: "2026-03-02", Runs: 2, Success: 2},
+		{Day: "2026-03-03"},
+		{Day: "2026-03-04", Runs: 1, Error: 1},
+	}
+	if len(got.Days) != len(want) {
+		t.Fatalf("Days = %+v, want %+v", got.Days, want)
+	}
+	for i := range want {
+		if got.Days[i] != want[i] {
+			t.Errorf("Days[%d] = %+v, want %+v", i, got.Days[i], want[i])
+		}
+	}
+}
+
+func TestAnalyticsService_Usage_Validation(t *testing.T) {
+	now := testNow.Unix()
+	tests := []struct {
+		name  string
+		req   apperr.AnalyticsRequest
+		field string
+	}{
+		{"negative from", apperr.AnalyticsRequest{From: -1}, "from"},
+		{"negative to", apperr.AnalyticsRequest{To: -1}, "to"},
+		{"to before from", apperr.AnalyticsRequest{From: now, To: now - 1}, "to"},
+		{"from in the future", apperr.AnalyticsRequest{From: now + 60}, "to"},
+		{"range over a year", apperr.AnalyticsRequest{From: now - (MaxRangeDays+1)*86400}, "to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepo{}
+			_, err := newTestService(t, repo).Usage(tt.req)
+			var ae *apperr.AppError
+			if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation || ae.Details["field"] != tt.field {
+				t.Fatalf("err = %v, want validation error on %s", err, tt.field)
+			}
+			if repo.to != 0 {
+				t.Error("repository queried despite invalid range")
+			}
+		})
+	}
+}
+
+func TestAnalyticsService_Usage_Errors(t *testing.T) {
+	var ae *apperr.AppError
+	if _, err := newTestService(t, nil).Usage(apperr.AnalyticsRequest{}); !errors.As(err, &ae) || ae.Code != apperr.CodeInternal {
+		t.Errorf("without repository: err = %v, want internal error", err)
+	}
+	repoErr := errors.New("db fail")
+	if _, err := newTestService(t, &mockRepo{err: repoErr}).Usage(apperr.AnalyticsRequest{}); !errors.Is(err, repoErr) {
+		t.Errorf("err = %v, want wrapped repository error", err)
+	}
+}
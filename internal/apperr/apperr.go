@@ -25,6 +25,8 @@ const (
 	CodeContextWindow       ErrorCode = "context_window"
 	CodeStepFailed          ErrorCode = "step_failed"
 	CodeCancelled           ErrorCode = "cancelled"
+	CodeLanguageMismatch    ErrorCode = "language_mismatch"
+	CodeInvalidEdits        ErrorCode = "invalid_edits"
 	CodeInternal            ErrorCode = "internal"
 )
 
@@ -60,7 +62,7 @@ func InvalidPlan(reason string, steps, inferences int) *AppError {
 	return &AppError{
 		Code:    CodeInvalidPlan,
 		Title:   "Stack not allowed",
-		Message: fmt.Sprintf("%s (max 5 steps, 3 inferences).", reason),
+		Message: reason + ".",
 		Details: map[string]string{
 			"reason":     reason,
 			"steps":      strconv.Itoa(steps),
@@ -257,6 +259,39 @@ func StepFailed(index int, family string, inner *AppError) *AppError {
 	}
 }
 
+// LanguageMismatch signals that step index answered in detected instead of the
+// expected language, even after a retry with a stronger instruction. Retryable:
+// small models often comply on another attempt.
+func LanguageMismatch(index int, family, expected, detected string) *AppError {
+	return &AppError{
+		Code:    CodeLanguageMismatch,
+		Title:   "Wrong output language",
+		Message: fmt.Sprintf("Step %d (%s) answered in %s instead of %s, even after a retry.", index+1, family, detected, expected),
+		Details: map[string]string{
+			"stepIndex": strconv.Itoa(index),
+			"family":    family,
+			"expected":  expected,
+			"detected":  detected,
+		},
+		Retryable: true,
+	}
+}
+
+// InvalidEdits signals that a model asked for an edit list replied with
+// something that is not one. Retryable: the next attempt usually parses.
+func InvalidEdits(reason string, cause error) *AppError {
+	return &AppError{
+		Code:    CodeInvalidEdits,
+		Title:   "Unusable edit list",
+		Message: fmt.Sprintf("The model's list of edits could not be read: %s", reason),
+		Details: map[string]string{
+			"reason": reason,
+		},
+		Retryable: true,
+		cause:     cause,
+	}
+}
+
 // Cancelled signals a ctx-cancelled chain. stepIndex is the 0-based step that was
 // running when cancelled; message displays 1-based for readability.
 func Cancelled(stepIndex int) *AppError {
@@ -283,6 +283,42 @@ func TestStepFailed(t *testing.T) {
 	}
 }
 
+func TestLanguageMismatch(t *testing.T) {
+	e := apperr.LanguageMismatch(2, "translate", "German", "English")
+	if e.Code != apperr.CodeLanguageMismatch {
+		t.Errorf("Code: got %q", e.Code)
+	}
+	if !e.Retryable {
+		t.Error("LanguageMismatch should be retryable")
+	}
+	want := map[string]string{"stepIndex": "2", "family": "translate", "expected": "German", "detected": "English"}
+	for k, v := range want {
+		if e.Details[k] != v {
+			t.Errorf("Details[%s]: want %q, got %q", k, v, e.Details[k])
+		}
+	}
+	if !strings.Contains(e.Message, "Step 3") {
+		t.Errorf("Message should display the 1-based step: %q", e.Message)
+	}
+}
+
+func TestInvalidEdits(t *testing.T) {
+	cause := errors.New("unexpected end of JSON input")
+	e := apperr.InvalidEdits("the reply is not valid JSON", cause)
+	if e.Code != apperr.CodeInvalidEdits {
+		t.Errorf("Code: got %q", e.Code)
+	}
+	if !e.Retryable {
+		t.Error("InvalidEdits should be retryable")
+	}
+	if e.Details["reason"] != "the reply is not valid JSON" {
+		t.Errorf("Details[reason]: got %q", e.Details["reason"])
+	}
+	if !errors.Is(e, cause) {
+		t.Error("InvalidEdits should wrap its cause")
+	}
+}
+
 func TestStepFailed_NilInner(t *testing.T) {
 	// Nil inner must not panic; the guard clause returns CodeInternal instead.
 	e := apperr.StepFailed(1, "rewrite", nil)
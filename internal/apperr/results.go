@@ -15,36 +15,195 @@ type ModelInfo struct {
 	Caps  *ModelCaps `json:"caps,omitempty"`
 }
 
+// ActionMeta describes one catalog action. Finisher actions polish whatever text
+// a chain ends with, so the planner orders them after terminal actions.
 type ActionMeta struct {
-	ID               string   `json:"id"`
-	Name             string   `json:"name"`
-	Category         string   `json:"category"`
-	Family           string   `json:"family"`
-	Directive        string   `json:"directive"`
-	OrderRank        int      `json:"orderRank"`
-	ExclusivityGroup string   `json:"exclusivityGroup"`
-	Mergeable        bool     `json:"mergeable"`
-	Terminal         bool     `json:"terminal"`
-	Requires         []string `json:"requires"`
+	ID               string      `json:"id"`
+	Name             string      `json:"name"`
+	Category         string      `json:"category"`
+	Family           string      `json:"family"`
+	Directive        string      `json:"directive"`
+	OrderRank        int         `json:"orderRank"`
+	ExclusivityGroup string      `json:"exclusivityGroup"`
+	Mergeable        bool        `json:"mergeable"`
+	Terminal         bool        `json:"terminal"`
+	Finisher         bool        `json:"finisher,omitempty"`
+	Requires         []string    `json:"requires"`
+	Params           []ParamSpec `json:"params,omitempty"`
+	EditList         bool        `json:"editList,omitempty"`
 }
 
+// ParamSpec declares one typed, per-step parameter an action accepts. Type is
+// one of "string", "int" or "enum"; Min/Max bound int values (or string length
+// in runes) and Options lists the allowed enum values. Default is used when the
+// step leaves the parameter unset.
+type ParamSpec struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default,omitempty"`
+	Min         *int     `json:"min,omitempty"`
+	Max         *int     `json:"max,omitempty"`
+	Options     []string `json:"options,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Description string   `json:"description"`
+}
+
+// ChainStep is one requested action. When, if set, lists conditions that must
+// all hold for the step's group to run; otherwise the group is skipped. Output
+// is "text" (the default when empty) or, for actions with EditList set, "edits":
+// the step then runs alone and reports its changes as an EditList.
 type ChainStep struct {
-	ActionID    string `json:"actionId"`
-	TargetModel string `json:"targetModel,omitempty"`
-	Goal        string `json:"goal,omitempty"`
+	ActionID    string            `json:"actionId"`
+	TargetModel string            `json:"targetModel,omitempty"`
+	Goal        string            `json:"goal,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	When        []StepCondition   `json:"when,omitempty"`
+	Output      string            `json:"output,omitempty"`
+}
+
+// StepCondition gates a step on a property of a text. Subject picks the text:
+// "input" is the chain's original input, "previous" (the default) the output of
+// the groups run so far. Check is one of minWords, maxWords, minTokens,
+// maxTokens, language, notLanguage, matches or notMatches; Value is its operand —
+// a count, a language, or a regular expression.
+type StepCondition struct {
+	Subject string `json:"subject,omitempty"`
+	Check   string `json:"check"`
+	Value   string `json:"value"`
 }
 
+// ChainRequest describes one chain run. When Branches is set the run fans out:
+// Steps is the shared prefix (possibly empty) that runs once, and every branch
+// then runs on the prefix output.
 type ChainRequest struct {
-	RunID            string      `json:"runId"`
-	InputText        string      `json:"inputText"`
-	Steps            []ChainStep `json:"steps"`
-	InputLanguageID  string      `json:"inputLanguageId"`
-	OutputLanguageID string      `json:"outputLanguageId"`
-	UseMarkdown      bool        `json:"useMarkdown"`
+	RunID            string          `json:"runId"`
+	InputText        string          `json:"inputText"`
+	Steps            []ChainStep     `json:"steps"`
+	Branches         []ChainBranch   `json:"branches,omitempty"`
+	InputLanguageID  string          `json:"inputLanguageId"`
+	OutputLanguageID string          `json:"outputLanguageId"`
+	UseMarkdown      bool            `json:"useMarkdown"`
+	StrictOrder      bool            `json:"strictOrder,omitempty"`
+	Selection        *ChainSelection `json:"selection,omitempty"`
+
+	// SkipHistory keeps the run out of the history table and leaves no
+	// checkpoint to resume it from. Set by callers that record runs themselves,
+	// such as batch jobs; never sent by the frontend.
+	SkipHistory bool `json:"-"`
+
+	// RerunOf is the ID of the history entry this run replays; its entry is
+	// linked to that one. Set by ActionService.RerunEntry; never sent by the frontend.
+	RerunOf string `json:"-"`
+}
+
+// ChainSelection limits a chain run to the range [Start, End) of
+// ChainRequest.InputText, counted in bytes or, with Unit "rune", in Unicode code
+// points. ContextRunes is how many runes of the surrounding text on each side
+// the model sees as read-only context: nil uses the default, 0 sends none.
+type ChainSelection struct {
+	Start        int    `json:"start"`
+	End          int    `json:"end"`
+	Unit         string `json:"unit,omitempty"`
+	ContextRunes *int   `json:"contextRunes,omitempty"`
+}
+
+// TextRange is the byte range [Start, End) of a text.
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
+// ChainBranch is one named fan-out branch.
+type ChainBranch struct {
+	Name  string      `json:"name"`
+	Steps []ChainStep `json:"steps"`
+}
+
+// ChainResult is the outcome of a chain run. For a fan-out run Outputs holds one
+// entry per branch in request order, FinalText mirrors the first branch's text,
+// and Completed counts groups run across the prefix and all branches.
+// DetectedInputLanguage is set when the request asked for the input language to
+// be detected ("auto"). RunID is set for runs whose ID the backend chose, such
+// as re-runs of a history entry.
 type ChainResult struct {
-	FinalText   string `json:"finalText"`
+	RunID                 string             `json:"runId,omitempty"`
+	FinalText             string             `json:"finalText"`
+	Completed             int                `json:"completed"`
+	FailedIndex           *int               `json:"failedIndex,omitempty"`
+	Error                 string             `json:"error,omitempty"`
+	Outputs               []BranchOutput     `json:"outputs,omitempty"`
+	DetectedInputLanguage *LanguageDetection `json:"detectedInputLanguage,omitempty"`
+	Warnings              []ChainWarning     `json:"warnings,omitempty"`
+	EditLists             []EditList         `json:"editLists,omitempty"`
+}
+
+// TextEdit is one correction to a text: Original, found at byte offsets
+// [Start, End) of the text, is replaced by Replacement. Category names the kind
+// of correction (grammar, spelling, punctuation, capitalization, style, clarity,
+// consistency or other) and Explanation says why it was made.
+type TextEdit struct {
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	Category    string `json:"category"`
+	Explanation string `json:"explanation"`
+}
+
+// RejectedEdit is a correction the model proposed that could not be placed in
+// the text, with the reason it was dropped.
+type RejectedEdit struct {
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	Reason      string `json:"reason"`
+}
+
+// EditList is what an edit-list step proposed for the text it was given (Input):
+// the corrections that were found in Input, in text order and all applied to the
+// run's output, and the ones that were dropped. In a selection-scoped run Input
+// is the whole input text with the selection replaced by the step's input, and
+// edit offsets count from the start of that text. Branch is empty for linear
+// runs and for groups of a fan-out's shared prefix.
+type EditList struct {
+	GroupIndex int            `json:"groupIndex"`
+	ActionID   string         `json:"actionId"`
+	Branch     string         `json:"branch,omitempty"`
+	Input      string         `json:"input"`
+	Edits      []TextEdit     `json:"edits"`
+	Rejected   []RejectedEdit `json:"rejected,omitempty"`
+}
+
+// ApplyEditsRequest asks for Edits, typically the accepted subset of an
+// EditList, to be applied to Text.
+type ApplyEditsRequest struct {
+	Text  string     `json:"text"`
+	Edits []TextEdit `json:"edits"`
+}
+
+// ChainWarning is a problem a run accepted instead of failing on, such as an
+// output left in the wrong language under the "warn" language check. Branch is
+// empty for linear runs and for groups of a fan-out's shared prefix.
+type ChainWarning struct {
+	Code       ErrorCode `json:"code"`
+	Message    string    `json:"message"`
+	GroupIndex int       `json:"groupIndex"`
+	Family     string    `json:"family"`
+	Branch     string    `json:"branch,omitempty"`
+}
+
+// LanguageDetection is the language identified for a text. Language is empty
+// when none of the configured languages could be identified; Confidence is in
+// [0, 1].
+type LanguageDetection struct {
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+}
+
+// BranchOutput is one fan-out branch's result. Completed and FailedIndex count
+// groups along the branch's whole path, shared prefix included.
+type BranchOutput struct {
+	Name        string `json:"name"`
+	Text        string `json:"text"`
 	Completed   int    `json:"completed"`
 	FailedIndex *int   `json:"failedIndex,omitempty"`
 	Error       string `json:"error,omitempty"`
@@ -86,9 +245,20 @@ type ModelConfig struct {
 }
 
 type AppBehaviorConfig struct {
-	EnableTaskLogging bool `json:"enableTaskLogging"`
-	HistoryEnabled    bool `json:"historyEnabled"`
-	HistoryMaxEntries int  `json:"historyMaxEntries"`
+	EnableTaskLogging   bool   `json:"enableTaskLogging"`
+	HistoryEnabled      bool   `json:"historyEnabled"`
+	HistoryMaxEntries   int    `json:"historyMaxEntries"`
+	MaxPlanSteps        int    `json:"maxPlanSteps"`
+	MaxPlanInferences   int    `json:"maxPlanInferences"`
+	MaxParallelBranches int    `json:"maxParallelBranches"`
+	LanguageCheck       string `json:"languageCheck"`
+	CleanOutput         bool   `json:"cleanOutput"`
+	BatchConcurrency    int    `json:"batchConcurrency"`
+	ExportFileName      string `json:"exportFileName"`
+	HistoryMaxAgeDays   int    `json:"historyMaxAgeDays"`
+	HistoryMaxSizeMB    int    `json:"historyMaxSizeMB"`
+	TaskLogMaxAgeDays   int    `json:"taskLogMaxAgeDays"`
+	TaskLogMaxSizeMB    int    `json:"taskLogMaxSizeMB"`
 }
 
 type UIPreferencesConfig struct {
@@ -162,24 +332,97 @@ type VerifyOutcome struct {
 	Models []ModelInfo `json:"models,omitempty"`
 }
 
+// SavedStack is a user-defined stack. Steps holds the ordered action IDs;
+// StepParams is index-aligned with Steps and carries each step's parameters.
+// StepParams may be empty (or shorter than Steps) on input, meaning "no
+// parameters" for the uncovered steps; reads always return it fully aligned.
+// StrictOrder runs the steps exactly as saved instead of in canonical order.
+// A "fanout" stack treats Steps as the shared prefix and runs each of Branches
+// on its output; a "linear" stack (the default) has no branches.
 type SavedStack struct {
-	ID             string   `json:"id"`
-	Name           string   `json:"name"`
-	Icon           string   `json:"icon"`
-	Steps          []string `json:"steps"`
-	DefaultFormat  string   `json:"defaultFormat"`
-	DefaultInLang  string   `json:"defaultInLang"`
-	DefaultOutLang string   `json:"defaultOutLang"`
-	CreatedAt      int64    `json:"createdAt"`
-	UpdatedAt      int64    `json:"updatedAt"`
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	Icon           string        `json:"icon"`
+	Kind           string        `json:"kind"`
+	Steps          []string      `json:"steps"`
+	StepParams     []StepParams  `json:"stepParams"`
+	Branches       []StackBranch `json:"branches,omitempty"`
+	DefaultFormat  string        `json:"defaultFormat"`
+	DefaultInLang  string        `json:"defaultInLang"`
+	DefaultOutLang string        `json:"defaultOutLang"`
+	StrictOrder    bool          `json:"strictOrder"`
+	CreatedAt      int64         `json:"createdAt"`
+	UpdatedAt      int64         `json:"updatedAt"`
+}
+
+// Saved stack kinds.
+const (
+	StackKindLinear = "linear"
+	StackKindFanOut = "fanout"
+)
+
+// StackBranch is one named branch of a fan-out stack; StepParams is
+// index-aligned with Steps, as on SavedStack.
+type StackBranch struct {
+	Name       string       `json:"name"`
+	Steps      []string     `json:"steps"`
+	StepParams []StepParams `json:"stepParams"`
+}
+
+// StepParams is the per-step parameter set persisted with a saved stack step —
+// a ChainStep without its ActionID.
+type StepParams struct {
+	TargetModel string            `json:"targetModel,omitempty"`
+	Goal        string            `json:"goal,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	When        []StepCondition   `json:"when,omitempty"`
+}
+
+// ChainSteps zips Steps with StepParams into the ChainStep form the planner
+// and composer consume.
+func (s SavedStack) ChainSteps() []ChainStep {
+	return zipChainSteps(s.Steps, s.StepParams)
+}
+
+// ChainBranches converts Branches into the ChainBranch form of a ChainRequest.
+func (s SavedStack) ChainBranches() []ChainBranch {
+	if len(s.Branches) == 0 {
+		return nil
+	}
+	out := make([]ChainBranch, len(s.Branches))
+	for i, b := range s.Branches {
+		out[i] = ChainBranch{Name: b.Name, Steps: zipChainSteps(b.Steps, b.StepParams)}
+	}
+	return out
+}
+
+func zipChainSteps(ids []string, params []StepParams) []ChainStep {
+	out := make([]ChainStep, len(ids))
+	for i, id := range ids {
+		out[i] = ChainStep{ActionID: id}
+		if i < len(params) {
+			p := params[i]
+			out[i].TargetModel = p.TargetModel
+			out[i].Goal = p.Goal
+			out[i].Params = p.Params
+			out[i].When = p.When
+		}
+	}
+	return out
 }
 
+// AppliedAction is one action of a recorded run. Skipped marks an action whose
+// group was skipped because a step condition did not hold.
 type AppliedAction struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Category string `json:"category"`
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Category string            `json:"category"`
+	Params   map[string]string `json:"params,omitempty"`
+	Skipped  bool              `json:"skipped,omitempty"`
 }
 
+// HistoryEntry is one recorded run. A fan-out run records one entry per branch,
+// each covering the shared prefix plus that branch, all sharing RunID.
 type HistoryEntry struct {
 	ID           string          `json:"id"`
 	CreatedAt    int64           `json:"createdAt"`
@@ -198,6 +441,392 @@ type HistoryEntry struct {
 	Status       string          `json:"status"`
 	ErrorCode    string          `json:"errorCode"`
 	FailedIndex  int             `json:"failedIndex"`
+	RunID        string          `json:"runId"`
+	Branch       string          `json:"branch,omitempty"`
+	ChangeRatio  float64         `json:"changeRatio"`
+	Selection    *TextRange      `json:"selection,omitempty"`
+	RerunOf      string          `json:"rerunOf,omitempty"`
+
+	// Pinned and Favorite are set by the user; entries with either are never
+	// pruned. Tags are the entry's free-form tags, sorted.
+	Pinned   bool     `json:"pinned"`
+	Favorite bool     `json:"favorite"`
+	Tags     []string `json:"tags,omitempty"`
+
+	// Steps lists what each group of the run did, in order, and Snapshot is
+	// what the run can be replayed from. Both are filled in by GetHistoryEntry
+	// only; list results leave them empty. Snapshot is nil for entries
+	// recorded before snapshots were kept.
+	Steps    []HistoryStep `json:"steps,omitempty"`
+	Snapshot *RunSnapshot  `json:"snapshot,omitempty"`
+}
+
+// RunSnapshot is everything needed to replay a recorded run: the request as it
+// ran (its "auto" input language resolved), the provider and model settings in
+// effect, whether Markdown output was asked for, and the app version and hash
+// of the action catalog it ran against.
+type RunSnapshot struct {
+	Request     ChainRequest `json:"request"`
+	ProviderID  string       `json:"providerId"`
+	ModelConfig ModelConfig  `json:"modelConfig"`
+	UseMarkdown bool         `json:"useMarkdown"`
+	AppVersion  string       `json:"appVersion"`
+	CatalogHash string       `json:"catalogHash"`
+}
+
+// RerunOverrides changes what ActionHandler.RerunHistoryEntry replays: a non-empty
+// ProviderID or Model replaces the snapshot's. The zero value replays the run
+// exactly.
+type RerunOverrides struct {
+	ProviderID string `json:"providerId,omitempty"`
+	Model      string `json:"model,omitempty"`
+}
+
+// HistoryStep is one completed inference group of a recorded run: the actions
+// it ran, the text it was given and what it produced. Prompts are kept as
+// SHA-256 hex digests, enough to tell whether two runs sent the same prompt.
+// Local groups have no prompts and no tokens; groups whose conditions did not
+// hold are not listed. Tokens are as reported by the provider, 0 when it does
+// not report usage, and include a language-check retry.
+type HistoryStep struct {
+	GroupIndex       int      `json:"groupIndex"`
+	Family           string   `json:"family"`
+	ActionIDs        []string `json:"actionIds"`
+	InputText        string   `json:"inputText"`
+	OutputText       string   `json:"outputText"`
+	SystemHash       string   `json:"systemHash,omitempty"`
+	UserHash         string   `json:"userHash,omitempty"`
+	DurationMs       int64    `json:"durationMs"`
+	PromptTokens     int      `json:"promptTokens"`
+	CompletionTokens int      `json:"completionTokens"`
+}
+
+// HistorySearchRequest asks SearchHistory for the entries whose title, input
+// or output text match Query, in SQLite FTS5 syntax (words, "phrases",
+// prefix*, AND/OR/NOT, NEAR, column:word); an empty Query matches every entry.
+// Non-empty filters narrow the results to exact matches: Provider is the
+// provider name, ActionID an action that ran, Tag one of the entry's tags
+// (ignoring case); Pinned and Favorite keep only entries with that flag set.
+// From and To bound createdAt, in Unix seconds inclusive, 0 leaving that side
+// open. Limit 0 uses the default.
+type HistorySearchRequest struct {
+	Query    string `json:"query"`
+	Status   string `json:"status,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	ActionID string `json:"actionId,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	Pinned   bool   `json:"pinned,omitempty"`
+	Favorite bool   `json:"favorite,omitempty"`
+	From     int64  `json:"from,omitempty"`
+	To       int64  `json:"to,omitempty"`
+	Limit    int    `json:"limit"`
+	Offset   int    `json:"offset"`
+}
+
+// HistoryTag is a tag in use and the number of entries carrying it.
+type HistoryTag struct {
+	Tag     string `json:"tag"`
+	Entries int    `json:"entries"`
+}
+
+// HistorySearchHit is one entry found by SearchHistory, best match first, or
+// newest first without a query. Snippet is an excerpt of the entry's
+// best-matching text and Highlights the byte ranges of Snippet that matched
+// the query; both are empty without a query.
+type HistorySearchHit struct {
+	Entry      HistoryEntry `json:"entry"`
+	Snippet    string       `json:"snippet,omitempty"`
+	Highlights []TextRange  `json:"highlights,omitempty"`
+}
+
+// HistoryExportRequest asks for a history entry's changes, from its input to its
+// output, to be written as tracked changes into Directory. Format is
+// "criticmarkup" (Markdown) or "docx".
+type HistoryExportRequest struct {
+	ID        string `json:"id"`
+	Format    string `json:"format"`
+	Directory string `json:"directory"`
+}
+
+// HistoryArchiveRequest asks ExportHistory to write the entries matching its
+// filters, oldest first, into Directory as Format: "jsonl" (one HistoryEntry
+// per line with its steps, snapshot, flags and tags — what ImportHistory
+// reads), "csv" (one flat row per entry, for spreadsheets) or "markdown" (a
+// readable digest). Status keeps the entries with that status, Tags those
+// carrying any of the tags; From and To bound createdAt as in
+// HistorySearchRequest.
+type HistoryArchiveRequest struct {
+	Format    string   `json:"format"`
+	Directory string   `json:"directory"`
+	Status    string   `json:"status,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	From      int64    `json:"from,omitempty"`
+	To        int64    `json:"to,omitempty"`
+}
+
+// HistoryArchive is the file ExportHistory wrote and the number of entries in it.
+type HistoryArchive struct {
+	Path    string `json:"path"`
+	Entries int    `json:"entries"`
+}
+
+// HistoryImportSummary reports what ImportHistory did with the entries of a
+// file: Imported were added; Duplicates were skipped because their ID was
+// already recorded or came earlier in the file; Pruned is how many entries,
+// imported or not, HistoryMaxEntries then removed (never pinned or favorite).
+type HistoryImportSummary struct {
+	Read       int `json:"read"`
+	Imported   int `json:"imported"`
+	Duplicates int `json:"duplicates"`
+	Pruned     int `json:"pruned"`
+}
+
+// RetentionReport is what one retention pass deleted: history entries past the
+// age rule (HistoryByAge) then past the size rule (HistoryBySize), the chain
+// checkpoints and completed batch jobs past the same age rule, and the task
+// log files removed, by name, with their total size in bytes. RanAt is the Unix
+// time of the pass; Trigger is "startup", "periodic" or "manual".
+type RetentionReport struct {
+	RanAt            int64    `json:"ranAt"`
+	Trigger          string   `json:"trigger"`
+	HistoryByAge     int      `json:"historyByAge"`
+	HistoryBySize    int      `json:"historyBySize"`
+	CheckpointsByAge int      `json:"checkpointsByAge"`
+	BatchJobsByAge   int      `json:"batchJobsByAge"`
+	TaskLogFiles     []string `json:"taskLogFiles"`
+	TaskLogBytes     int64    `json:"taskLogBytes"`
+}
+
+// AnalyticsRequest asks GetUsageAnalytics for the runs recorded between From
+// and To, in Unix seconds inclusive. To 0 means now and From 0 thirty days
+// before To; the range may span at most a year.
+type AnalyticsRequest struct {
+	From int64 `json:"from,omitempty"`
+	To   int64 `json:"to,omitempty"`
+}
+
+// UsageAnalytics summarizes the history entries of a date range as series ready
+// to chart. Days has one point per local calendar day of the range, days
+// without runs included. Actions, Stacks and Models are ordered by runs, most
+// first, as are Outcomes; Inferences by inference count. Latencies are
+// nearest-rank percentiles of the duration of successful runs; rates are
+// fractions of Runs between 0 and 1.
+type UsageAnalytics struct {
+	From          int64              `json:"from"`
+	To            int64              `json:"to"`
+	Runs          int                `json:"runs"`
+	SuccessRate   float64            `json:"successRate"`
+	P50Ms         int64              `json:"p50Ms"`
+	P95Ms         int64              `json:"p95Ms"`
+	AvgInferences float64            `json:"avgInferences"`
+	Days          []AnalyticsDay     `json:"days"`
+	Actions       []AnalyticsCount   `json:"actions"`
+	Stacks        []AnalyticsCount   `json:"stacks"`
+	Models        []AnalyticsModel   `json:"models"`
+	Outcomes      []AnalyticsOutcome `json:"outcomes"`
+	Inferences    []AnalyticsCount   `json:"inferences"`
+}
+
+// AnalyticsDay counts the runs of one local calendar day (2006-01-02) by status.
+type AnalyticsDay struct {
+	Day     string `json:"day"`
+	Runs    int    `json:"runs"`
+	Success int    `json:"success"`
+	Partial int    `json:"partial"`
+	Error   int    `json:"error"`
+}
+
+// AnalyticsCount is how many runs share Key: an action ID (Label is the action
+// name), a stack's step list, or an inference count.
+type AnalyticsCount struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Runs  int    `json:"runs"`
+}
+
+// AnalyticsModel is the reliability and latency of one provider and model.
+type AnalyticsModel struct {
+	ProviderName  string  `json:"providerName"`
+	Model         string  `json:"model"`
+	Runs          int     `json:"runs"`
+	Success       int     `json:"success"`
+	Partial       int     `json:"partial"`
+	Error         int     `json:"error"`
+	SuccessRate   float64 `json:"successRate"`
+	P50Ms         int64   `json:"p50Ms"`
+	P95Ms         int64   `json:"p95Ms"`
+	AvgInferences float64 `json:"avgInferences"`
+}
+
+// AnalyticsOutcome is the share of runs that ended with Status and ErrorCode
+// ("" for successful runs).
+type AnalyticsOutcome struct {
+	Status    string  `json:"status"`
+	ErrorCode string  `json:"errorCode"`
+	Runs      int     `json:"runs"`
+	Rate      float64 `json:"rate"`
+}
+
+// OutputExportRequest asks for an output to be written into Directory as Format:
+// "md", "txt", "html" (a standalone page) or "docx". HistoryID names a history
+// entry whose output, title, actions and model are exported; without it Text is
+// exported, described by Name (the stack or action that produced it), Applied,
+// ProviderName and Model. FrontMatter starts the file with a block listing the
+// applied actions and the model.
+type OutputExportRequest struct {
+	HistoryID    string          `json:"historyId,omitempty"`
+	Text         string          `json:"text,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	Applied      []AppliedAction `json:"applied,omitempty"`
+	ProviderName string          `json:"providerName,omitempty"`
+	Model        string          `json:"model,omitempty"`
+	Format       string          `json:"format"`
+	Directory    string          `json:"directory"`
+	FrontMatter  bool            `json:"frontMatter"`
+}
+
+// BatchSource is where a batch job's items come from. Kind "files" reads each
+// file matching the glob Path as one item; "csv" reads column Column of every
+// row of the CSV file Path, whose first row is the header; "jsonl" reads the
+// string field Field of every record of the JSON Lines file Path.
+type BatchSource struct {
+	Kind   string `json:"kind"`
+	Path   string `json:"path"`
+	Column string `json:"column,omitempty"`
+	Field  string `json:"field,omitempty"`
+}
+
+// BatchDestination is where a batch job's outputs go. Kind "sibling" writes each
+// output next to its source file with Suffix (default ".out") before the
+// extension; "csv" writes the source rows with the output in column Column
+// (default "output"); "jsonl" writes the source records with the output in
+// field Field (default "output"), or one {"key", "output"} record per item for
+// other sources. Path is the CSV or JSONL file to write; empty means next to the
+// source, with ".out" before the extension.
+type BatchDestination struct {
+	Kind   string `json:"kind"`
+	Path   string `json:"path,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+	Column string `json:"column,omitempty"`
+	Field  string `json:"field,omitempty"`
+}
+
+// BatchJobRequest creates a batch job: every item of Source runs through the
+// chain of StackID (a linear stack) or Steps, and the outputs are written to
+// Destination. Concurrency overrides AppBehaviorConfig.BatchConcurrency when
+// non-zero.
+type BatchJobRequest struct {
+	Name             string           `json:"name"`
+	StackID          string           `json:"stackId,omitempty"`
+	Steps            []ChainStep      `json:"steps,omitempty"`
+	InputLanguageID  string           `json:"inputLanguageId"`
+	OutputLanguageID string           `json:"outputLanguageId"`
+	UseMarkdown      bool             `json:"useMarkdown"`
+	StrictOrder      bool             `json:"strictOrder,omitempty"`
+	Source           BatchSource      `json:"source"`
+	Destination      BatchDestination `json:"destination"`
+	Concurrency      int              `json:"concurrency,omitempty"`
+}
+
+// BatchCounts counts a batch job's items by status.
+type BatchCounts struct {
+	Total   int `json:"total"`
+	Pending int `json:"pending"`
+	Running int `json:"running"`
+	Done    int `json:"done"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+// BatchJob is a batch job and the state of its items. Status is "running",
+// "paused", "completed" (every item done, failed or skipped, and the outputs
+// written) or "failed" (the outputs could not be written; Error says why).
+// Request is the creating request with its stack resolved to Steps. OutputPath
+// is the file or, for sibling outputs, the directory written last.
+type BatchJob struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Status     string          `json:"status"`
+	Request    BatchJobRequest `json:"request"`
+	Counts     BatchCounts     `json:"counts"`
+	OutputPath string          `json:"outputPath,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  int64           `json:"createdAt"`
+	UpdatedAt  int64           `json:"updatedAt"`
+}
+
+// BatchItem is one input of a batch job. Key names it in the source: the file
+// path, "row N" or "line N". Status is "pending", "running", "done", "failed"
+// or "skipped" (nothing to run on; Error says why).
+type BatchItem struct {
+	JobID      string `json:"jobId"`
+	Index      int    `json:"index"`
+	Key        string `json:"key"`
+	InputText  string `json:"inputText"`
+	OutputText string `json:"outputText"`
+	Status     string `json:"status"`
+	ErrorCode  string `json:"errorCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Attempts   int    `json:"attempts"`
+	UpdatedAt  int64  `json:"updatedAt"`
+}
+
+// DiffRequest asks for the differences between two texts. Granularity is "word"
+// (the default when empty) or "sentence"; Markdown treats Markdown syntax as
+// formatting that is shown in hunks but not counted as changed text.
+type DiffRequest struct {
+	Before      string `json:"before"`
+	After       string `json:"after"`
+	Granularity string `json:"granularity,omitempty"`
+	Markdown    bool   `json:"markdown,omitempty"`
+}
+
+// DiffHunk is one run of text that is equal in both texts, or only deleted from
+// Before, or only inserted in After. Concatenating the equal and delete hunks
+// yields Before; the equal and insert hunks yield After.
+type DiffHunk struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffStats counts the compared tokens: words and punctuation at word
+// granularity, sentences at sentence granularity. EditDistance counts a replaced
+// token once; ChangeRatio is EditDistance over the longer text's token count, in
+// [0, 1].
+type DiffStats struct {
+	BeforeTokens int     `json:"beforeTokens"`
+	AfterTokens  int     `json:"afterTokens"`
+	Unchanged    int     `json:"unchanged"`
+	Inserted     int     `json:"inserted"`
+	Deleted      int     `json:"deleted"`
+	EditDistance int     `json:"editDistance"`
+	ChangeRatio  float64 `json:"changeRatio"`
+}
+
+// TextDiff is the outcome of comparing two texts.
+type TextDiff struct {
+	Granularity string     `json:"granularity"`
+	Hunks       []DiffHunk `json:"hunks"`
+	Stats       DiffStats  `json:"stats"`
+}
+
+// ImportedDocument is the text of a document file, as Markdown for formats with
+// structure (headings and lists of .docx, .odt and HTML). Format is "docx",
+// "odt", "html", "markdown" or "text"; Encoding is the character encoding the
+// file was decoded from ("utf-8", "utf-16le", "utf-16be" or "windows-1252"),
+// always "utf-8" for .docx and .odt. Title is the document's own title, if it
+// has one. Language is nil when the text has too few letters to tell.
+type ImportedDocument struct {
+	Path      string             `json:"path"`
+	Name      string             `json:"name"`
+	Format    string             `json:"format"`
+	Encoding  string             `json:"encoding"`
+	Title     string             `json:"title,omitempty"`
+	Text      string             `json:"text"`
+	WordCount int                `json:"wordCount"`
+	Language  *LanguageDetection `json:"language,omitempty"`
 }
 
 type PreviewParams struct {
@@ -219,6 +848,7 @@ type PreviewGroup struct {
 	UserPrompt      string          `json:"userPrompt"`
 	Parameters      PreviewParams   `json:"parameters"`
 	EstimatedTokens int             `json:"estimatedTokens"`
+	Local           bool            `json:"local,omitempty"` // runs in-process; no prompts
 }
 
 type PromptPreview struct {
@@ -236,6 +866,75 @@ type PromptPreviewRequest struct {
 	InputLanguageID  string      `json:"inputLanguageId"`
 	OutputLanguageID string      `json:"outputLanguageId"`
 	SampleInput      string      `json:"sampleInput,omitempty"`
+	StrictOrder      bool        `json:"strictOrder,omitempty"`
+}
+
+// PlanExplanation is the structured trace of how the planner treats a
+// ChainRequest: how steps were reordered, which merged into which group, and
+// which validation checks passed. Produced even for plans that would be rejected.
+// For a fan-out request the trace covers the shared steps, without the step and
+// inference caps, and Branches traces each branch.
+type PlanExplanation struct {
+	Valid          bool              `json:"valid"`
+	StrictOrder    bool              `json:"strictOrder"`
+	OriginalOrder  []string          `json:"originalOrder"`
+	CanonicalOrder []string          `json:"canonicalOrder"`
+	Moves          []StepMove        `json:"moves"`
+	Groups         []ExplainedGroup  `json:"groups"`
+	MergeDecisions []MergeDecision   `json:"mergeDecisions"`
+	Checks         []PlanCheck       `json:"checks"`
+	Steps          int               `json:"steps"`
+	Inferences     int               `json:"inferences"`
+	MaxSteps       int               `json:"maxSteps"`
+	MaxInferences  int               `json:"maxInferences"`
+	Branches       []ExplainedBranch `json:"branches,omitempty"`
+}
+
+// ExplainedBranch is the trace of one fan-out branch, planned on its own after
+// the shared steps. Its step and inference counts, exclusivity checks and caps
+// cover the whole path: the shared steps plus the branch.
+type ExplainedBranch struct {
+	Name        string          `json:"name"`
+	Explanation PlanExplanation `json:"explanation"`
+}
+
+// StepMove records where one step landed in the canonical order and the rule
+// responsible: "none" | "terminal-last" | "finisher-last" | "order-rank" |
+// "strict-order".
+type StepMove struct {
+	ActionID  string `json:"actionId"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Rule      string `json:"rule"`
+	OrderRank int    `json:"orderRank"`
+	Terminal  bool   `json:"terminal"`
+}
+
+type ExplainedGroup struct {
+	Index     int      `json:"index"`
+	Family    string   `json:"family"`
+	ActionIDs []string `json:"actionIds"`
+}
+
+// MergeDecision records whether the step at Position (in canonical order)
+// joined the preceding group. Reason is "first-step" | "merged" |
+// "family-mismatch" | "non-mergeable" | "group-non-mergeable" | "terminal".
+type MergeDecision struct {
+	ActionID   string `json:"actionId"`
+	Position   int    `json:"position"`
+	GroupIndex int    `json:"groupIndex"`
+	Merged     bool   `json:"merged"`
+	Reason     string `json:"reason"`
+}
+
+// PlanCheck is one validation check. Name is "non-empty" | "known-action" | "requirement" |
+// "param" | "exclusivity" | "max-steps" | "max-inferences"; Subject is the
+// action ID or exclusivity group checked, empty for plan-wide caps.
+type PlanCheck struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject,omitempty"`
+	Passed  bool   `json:"passed"`
+	Detail  string `json:"detail,omitempty"`
 }
 
 // SuggestedStack is one recommended stack recipe shown in the Info/About
@@ -309,7 +1008,9 @@ type StepProgress struct {
 	GroupIndex  int    `json:"groupIndex"`
 	TotalGroups int    `json:"totalGroups"`
 	Family      string `json:"family"`
-	Status      string `json:"status"` // "running" | "done" | "failed"
+	Status      string `json:"status"`               // "running" | "done" | "skipped" | "failed"
+	Branch      string `json:"branch,omitempty"`     // fan-out branch; empty for the shared prefix and linear runs
+	SkipReason  string `json:"skipReason,omitempty"` // the condition that did not hold; set with "skipped"
 }
 
 type StacksResult struct {
@@ -327,16 +1028,91 @@ type HistoryListResult struct {
 	Error *WireError     `json:"error,omitempty"`
 }
 
+type HistorySearchResult struct {
+	Data  []HistorySearchHit `json:"data"`
+	Error *WireError         `json:"error,omitempty"`
+}
+
+type HistoryTagListResult struct {
+	Data  []HistoryTag `json:"data"`
+	Error *WireError   `json:"error,omitempty"`
+}
+
+type HistoryArchiveResult struct {
+	Data  *HistoryArchive `json:"data,omitempty"`
+	Error *WireError      `json:"error,omitempty"`
+}
+
+type HistoryImportResult struct {
+	Data  *HistoryImportSummary `json:"data,omitempty"`
+	Error *WireError            `json:"error,omitempty"`
+}
+
+type RetentionReportResult struct {
+	Data  *RetentionReport `json:"data,omitempty"`
+	Error *WireError       `json:"error,omitempty"`
+}
+
+type UsageAnalyticsResult struct {
+	Data  *UsageAnalytics `json:"data,omitempty"`
+	Error *WireError      `json:"error,omitempty"`
+}
+
 type HistoryEntryResult struct {
 	Data  *HistoryEntry `json:"data,omitempty"`
 	Error *WireError    `json:"error,omitempty"`
 }
 
+type BatchJobResult struct {
+	Data  *BatchJob  `json:"data,omitempty"`
+	Error *WireError `json:"error,omitempty"`
+}
+
+type BatchJobsResult struct {
+	Data  []BatchJob `json:"data"`
+	Error *WireError `json:"error,omitempty"`
+}
+
+type BatchItemsResult struct {
+	Data  []BatchItem `json:"data"`
+	Error *WireError  `json:"error,omitempty"`
+}
+
+// BatchProgress is emitted as the "batch:progress" Wails event payload when a
+// batch job's item finishes and when the job changes status.
+type BatchProgress struct {
+	JobID      string      `json:"jobId"`
+	Status     string      `json:"status"`
+	Counts     BatchCounts `json:"counts"`
+	OutputPath string      `json:"outputPath,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
 type PromptPreviewResult struct {
 	Data  *PromptPreview `json:"data,omitempty"`
 	Error *WireError     `json:"error,omitempty"`
 }
 
+type PlanExplanationResult struct {
+	Data  *PlanExplanation `json:"data,omitempty"`
+	Error *WireError       `json:"error,omitempty"`
+}
+
+type LanguageDetectionResult struct {
+	Data  *LanguageDetection `json:"data,omitempty"`
+	Error *WireError         `json:"error,omitempty"`
+}
+
+type ImportedDocumentResult struct {
+	Data  *ImportedDocument `json:"data,omitempty"`
+	Error *WireError        `json:"error,omitempty"`
+}
+
+type TextDiffResult struct {
+	Data  *TextDiff  `json:"data,omitempty"`
+	Error *WireError `json:"error,omitempty"`
+}
+
 type ProviderResult struct {
 	Data  *ProviderConfig `json:"data,omitempty"`
 	Error *WireError      `json:"error,omitempty"`
@@ -9,15 +9,21 @@ import (
 	"strings"
 
 	"go_text/internal/actions"
+	"go_text/internal/analytics"
 	"go_text/internal/apperr"
+	"go_text/internal/batch"
 	"go_text/internal/bootstrap"
 	"go_text/internal/db"
+	"go_text/internal/diff"
+	"go_text/internal/document"
+	"go_text/internal/export"
 	"go_text/internal/file"
 	"go_text/internal/gate"
 	"go_text/internal/history"
 	"go_text/internal/llms"
 	"go_text/internal/logging"
 	"go_text/internal/prompts"
+	"go_text/internal/retention"
 	"go_text/internal/settings"
 	"go_text/internal/stacks"
 	"go_text/internal/tasklog"
@@ -32,18 +38,28 @@ const panicFmt = "panic: %v"
 
 // ApplicationContextHolder is the DI root. All exported fields are Wails-bound.
 type ApplicationContextHolder struct {
-	ctx             context.Context
-	SettingsHandler *settings.SettingsHandler
-	SettingsService *settings.SettingsService
-	ActionHandler   *actions.ActionHandler
-	StackHandler    *stacks.StackHandler
-	HistoryHandler  *history.HistoryHandler
-	RestyClient     *resty.Client
-	DB              *db.Database
-
-	fileService    file.FileUtilsServiceAPI
-	appLogger      *logging.Logger
-	historyService *history.HistoryService
+	ctx              context.Context
+	SettingsHandler  *settings.SettingsHandler
+	SettingsService  *settings.SettingsService
+	ActionHandler    *actions.ActionHandler
+	StackHandler     *stacks.StackHandler
+	HistoryHandler   *history.HistoryHandler
+	DiffHandler      *diff.DiffHandler
+	BatchHandler     *batch.BatchHandler
+	DocumentHandler  *document.DocumentHandler
+	ExportHandler    *export.ExportHandler
+	RetentionHandler *retention.RetentionHandler
+	AnalyticsHandler *analytics.AnalyticsHandler
+	RestyClient      *resty.Client
+	DB               *db.Database
+
+	fileService      file.FileUtilsServiceAPI
+	appLogger        *logging.Logger
+	historyService   *history.HistoryService
+	actionService    *actions.ActionService
+	batchService     *batch.BatchService
+	retentionService *retention.RetentionService
+	analyticsService *analytics.AnalyticsService
 }
 
 // NewApplicationContextHolder wires the DI graph.
@@ -55,7 +71,7 @@ func NewApplicationContextHolder(appLogger *logging.Logger, restyClient *resty.C
 	settingsHandler := settings.NewSettingsHandler(settingsService, providerPresets())
 
 	taskLogService := tasklog.NewTaskLogService(appLogger, settingsService, fileUtilsService)
-	historyService := history.NewHistoryService(appLogger, settingsService)
+	historyService := history.NewHistoryService(appLogger, settingsService, fileUtilsService)
 	promptService := prompts.NewPromptService(appLogger)
 	providerFactory := llms.NewProviderFactory(restyClient)
 	llmService := llms.NewLLMApiService(appLogger, providerFactory, settingsService)
@@ -68,17 +84,41 @@ func NewApplicationContextHolder(appLogger *logging.Logger, restyClient *resty.C
 	catalog := actionService.GetActionCatalog()
 	stackHandler := stacks.NewStackHandler(appLogger, nil, catalog, suggestedStackRecipes())
 	historyHandler := history.NewHistoryHandler(appLogger, historyService)
+	diffHandler := diff.NewDiffHandler(appLogger)
+	// batchRepo is nil until Init() opens the DB and wires SqliteBatchRepository.
+	batchService := batch.NewBatchService(appLogger, actionService, settingsService, inferenceGate)
+	batchHandler := batch.NewBatchHandler(appLogger, batchService)
+	documentService := document.NewDocumentService(appLogger, settingsService)
+	documentHandler := document.NewDocumentHandler(appLogger, documentService)
+	exportService := export.NewExportService(appLogger, historyService, settingsService, fileUtilsService)
+	exportHandler := export.NewExportHandler(appLogger, exportService)
+	// The history pruner is nil until Init() opens the DB; Init then starts the passes.
+	retentionService := retention.NewRetentionService(appLogger, settingsService, fileUtilsService)
+	retentionHandler := retention.NewRetentionHandler(appLogger, retentionService)
+	// analyticsRepo is nil until Init() opens the DB and wires SqliteAnalyticsRepository.
+	analyticsService := analytics.NewAnalyticsService(appLogger)
+	analyticsHandler := analytics.NewAnalyticsHandler(appLogger, analyticsService)
 
 	return &ApplicationContextHolder{
-		SettingsHandler: settingsHandler,
-		SettingsService: settingsService,
-		ActionHandler:   actionHandler,
-		StackHandler:    stackHandler,
-		HistoryHandler:  historyHandler,
-		RestyClient:     restyClient,
-		fileService:     fileUtilsService,
-		appLogger:       appLogger,
-		historyService:  historyService,
+		SettingsHandler:  settingsHandler,
+		SettingsService:  settingsService,
+		ActionHandler:    actionHandler,
+		StackHandler:     stackHandler,
+		HistoryHandler:   historyHandler,
+		DiffHandler:      diffHandler,
+		BatchHandler:     batchHandler,
+		DocumentHandler:  documentHandler,
+		ExportHandler:    exportHandler,
+		RetentionHandler: retentionHandler,
+		AnalyticsHandler: analyticsHandler,
+		RestyClient:      restyClient,
+		fileService:      fileUtilsService,
+		appLogger:        appLogger,
+		historyService:   historyService,
+		actionService:    actionService,
+		batchService:     batchService,
+		retentionService: retentionService,
+		analyticsService: analyticsService,
 	}
 }
 
@@ -121,6 +161,7 @@ func suggestedStackRecipes() []stacks.SuggestedStackRecipe {
 func (a *ApplicationContextHolder) SetContext(ctx context.Context) {
 	a.ctx = ctx
 	a.ActionHandler.SetContext(ctx)
+	a.BatchHandler.SetContext(ctx)
 }
 
 // liveZlog returns a live snapshot of the app logger's current writer, or a
@@ -158,11 +199,29 @@ func (a *ApplicationContextHolder) Init(ctx context.Context) error {
 
 	historyRepo := history.NewSqliteHistoryRepository(database)
 	a.historyService.SetRepository(historyRepo)
+	a.analyticsService.SetRepository(analytics.NewSqliteAnalyticsRepository(database))
+
+	checkpointRepo := actions.NewSqliteCheckpointRepository(database)
+	a.actionService.SetCheckpointRepository(checkpointRepo)
 
 	stackRepo := stacks.NewSqliteStackRepository(database)
 	a.StackHandler.SetRepository(stackRepo)
 	a.ActionHandler.SetStackLookup(a.StackHandler)
 	a.StackHandler.SetLastSelectionUpdater(a.SettingsService)
+	a.StackHandler.SetPlanLimitsSource(a.SettingsService)
+
+	// Jobs left running by a previous process come back paused, ready to resume.
+	batchRepo := batch.NewSqliteBatchRepository(database)
+	if err := a.batchService.SetRepository(batchRepo); err != nil {
+		a.appLogger.Warning(fmt.Sprintf("recover batch jobs: %v", err))
+	}
+	a.BatchHandler.SetStackLookup(a.StackHandler)
+
+	// Age and size retention runs now and then periodically in the background.
+	a.retentionService.SetHistoryPruner(historyRepo)
+	a.retentionService.SetCheckpointPruner(checkpointRepo)
+	a.retentionService.SetBatchPruner(batchRepo)
+	a.retentionService.Start(retention.DefaultInterval)
 
 	// Read logging config via service (now SQLite-backed).
 	logCfg, err := a.SettingsService.GetLoggingConfig()
@@ -211,9 +270,12 @@ func (a *ApplicationContextHolder) restoreWindowSize(ctx context.Context) error
 	return nil
 }
 
-// CancelAllRuns cancels every in-flight chain run (called on shutdown).
+// CancelAllRuns cancels every in-flight chain run, pauses every running
+// batch job and stops background retention (called on shutdown).
 func (a *ApplicationContextHolder) CancelAllRuns() {
 	a.ActionHandler.CancelAllRuns()
+	a.BatchHandler.PauseAllJobs()
+	a.retentionService.Stop()
 }
 
 // EnableLoggingForDev enables resty debug logging in dev builds.
@@ -285,6 +347,79 @@ func (a *ApplicationContextHolder) BrowserOpenURL(url string) (res apperr.VoidRe
 	return apperr.VoidResult{}
 }
 
+// ChooseDirectory shows the native folder picker with the given title and
+// returns the chosen directory, or "" if the user cancelled. Used to pick where
+// exports are written.
+func (a *ApplicationContextHolder) ChooseDirectory(title string) (res apperr.StringResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicFmt, r))
+			wire := apperr.ToWire(a.liveZlog(), ae)
+			res = apperr.StringResult{Error: &wire}
+		}
+	}()
+	dir, err := openDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                title,
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		ae := apperr.Internal(fmt.Errorf("choose directory: %w", err))
+		wire := apperr.ToWire(a.liveZlog(), ae)
+		return apperr.StringResult{Error: &wire}
+	}
+	return apperr.StringResult{Data: dir}
+}
+
+// ChooseDocument shows the native file picker, filtered to the document types
+// DocumentHandler.ImportDocument reads, and returns the chosen file, or "" if
+// the user cancelled.
+func (a *ApplicationContextHolder) ChooseDocument(title string) (res apperr.StringResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicFmt, r))
+			wire := apperr.ToWire(a.liveZlog(), ae)
+			res = apperr.StringResult{Error: &wire}
+		}
+	}()
+	path, err := openFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: title,
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Documents (*.docx, *.odt, *.html, *.md, *.txt)", Pattern: document.FilePattern()},
+		},
+	})
+	if err != nil {
+		ae := apperr.Internal(fmt.Errorf("choose document: %w", err))
+		wire := apperr.ToWire(a.liveZlog(), ae)
+		return apperr.StringResult{Error: &wire}
+	}
+	return apperr.StringResult{Data: path}
+}
+
+// ChooseHistoryBackup shows the native file picker, filtered to the JSONL
+// files HistoryHandler.ImportHistory reads, and returns the chosen file, or ""
+// if the user cancelled.
+func (a *ApplicationContextHolder) ChooseHistoryBackup(title string) (res apperr.StringResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicFmt, r))
+			wire := apperr.ToWire(a.liveZlog(), ae)
+			res = apperr.StringResult{Error: &wire}
+		}
+	}()
+	path, err := openFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: title,
+		Filters: []runtime.FileFilter{
+			{DisplayName: "History exports (*.jsonl)", Pattern: "*.jsonl"},
+		},
+	})
+	if err != nil {
+		ae := apperr.Internal(fmt.Errorf("choose history backup: %w", err))
+		wire := apperr.ToWire(a.liveZlog(), ae)
+		return apperr.StringResult{Error: &wire}
+	}
+	return apperr.StringResult{Data: path}
+}
+
 // SaveWindowSize persists the native window's current dimensions so they can
 // be restored on next launch. Called by the frontend (debounced) on resize.
 func (a *ApplicationContextHolder) SaveWindowSize(width, height int) (res apperr.VoidResult) {
@@ -309,17 +444,19 @@ var runOpenCommand = func(name string, args ...string) error {
 }
 
 // Wails-runtime execution seams. runtime.ClipboardGetText/ClipboardSetText/
-// BrowserOpenURL/WindowSetSize all call into Wails' getFrontend(ctx), which
+// BrowserOpenURL/WindowSetSize/OpenDirectoryDialog/OpenFileDialog all call into Wails' getFrontend(ctx), which
 // calls log.Fatalf (os.Exit) when ctx carries no real frontend — unrecoverable
 // via defer/recover and unfakeable from outside the wails module (its internal
 // Frontend interface references unexported-package types). Tests swap these
 // vars to exercise ClipboardGetText/ClipboardSetText/BrowserOpenURL/
-// restoreWindowSize without a live Wails runtime.
+// ChooseDirectory/ChooseDocument/restoreWindowSize without a live Wails runtime.
 var (
-	clipboardGetText = runtime.ClipboardGetText
-	clipboardSetText = runtime.ClipboardSetText
-	browserOpenURL   = runtime.BrowserOpenURL
-	windowSetSize    = runtime.WindowSetSize
+	clipboardGetText    = runtime.ClipboardGetText
+	clipboardSetText    = runtime.ClipboardSetText
+	browserOpenURL      = runtime.BrowserOpenURL
+	windowSetSize       = runtime.WindowSetSize
+	openDirectoryDialog = runtime.OpenDirectoryDialog
+	openFileDialog      = runtime.OpenFileDialog
 )
 
 // openPathArgs returns the OS file-manager command and arguments for goos.
@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"go_text/internal/apperr"
 	"go_text/internal/db"
 	"go_text/internal/logging"
+	"go_text/internal/retention"
 	"go_text/internal/settings"
 
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"resty.dev/v3"
 )
 
@@ -59,12 +62,15 @@ type fakeFileUtils struct {
 
 func (fakeFileUtils) GetAppSettingsFolderPath() (string, error) { return "", nil }
 func (f fakeFileUtils) GetAppDatabaseFilePath() (string, error) { return f.dbPath, f.dbPathErr }
-func (fakeFileUtils) ResolveAppLogsFolderPath(string) (string, error) {
-	return "", nil
+func (f fakeFileUtils) ResolveAppLogsFolderPath(string) (string, error) {
+	return f.logsDir, f.logsDirErr
 }
 func (f fakeFileUtils) EnsureAppLogsFolderExists(string) (string, error) {
 	return f.logsDir, f.logsDirErr
 }
+func (fakeFileUtils) WriteExportFile(string, string, []byte) (string, error) {
+	return "", nil
+}
 
 // The following swap* helpers replace the wails-runtime execution seams for
 // the duration of a test and restore them via t.Cleanup. Like
@@ -99,6 +105,20 @@ func swapWindowSetSize(t *testing.T, fn func(ctx context.Context, width, height
 	t.Cleanup(func() { windowSetSize = orig })
 }
 
+func swapOpenDirectoryDialog(t *testing.T, fn func(ctx context.Context, opts runtime.OpenDialogOptions) (string, error)) {
+	t.Helper()
+	orig := openDirectoryDialog
+	openDirectoryDialog = fn
+	t.Cleanup(func() { openDirectoryDialog = orig })
+}
+
+func swapOpenFileDialog(t *testing.T, fn func(ctx context.Context, opts runtime.OpenDialogOptions) (string, error)) {
+	t.Helper()
+	orig := openFileDialog
+	openFileDialog = fn
+	t.Cleanup(func() { openFileDialog = orig })
+}
+
 // ── SetContext ───────────────────────────────────────────────────────────
 
 func TestApplicationContextHolder_SetContext_StoresContext(t *testing.T) {
@@ -120,6 +140,8 @@ func TestApplicationContextHolder_Init_Success(t *testing.T) {
 		dbPath:  filepath.Join(t.TempDir(), "test.db"),
 		logsDir: t.TempDir(),
 	}
+	// Retention must not touch the real logs folder.
+	holder.retentionService = retention.NewRetentionService(holder.appLogger, holder.SettingsService, holder.fileService)
 	swapWindowSetSize(t, func(context.Context, int, int) {})
 
 	ctx := context.WithValue(context.Background(), "buildtype", "dev") //nolint:staticcheck // matches wails' own ctx key
@@ -131,6 +153,7 @@ func TestApplicationContextHolder_Init_Success(t *testing.T) {
 		t.Fatal("expected DB to be wired after Init")
 	}
 	t.Cleanup(func() { _ = holder.DB.Close() })
+	t.Cleanup(holder.CancelAllRuns)
 
 	// The settings service's repository was swapped from nil to a real
 	// SQLite-backed one; a call that would otherwise nil-pointer-panic must
@@ -320,6 +343,123 @@ func TestApplicationContextHolder_ClipboardSetText_Error(t *testing.T) {
 	}
 }
 
+// ── ChooseDirectory ──────────────────────────────────────────────────────
+
+func TestApplicationContextHolder_ChooseDirectory_Success(t *testing.T) {
+	var gotTitle string
+	swapOpenDirectoryDialog(t, func(_ context.Context, opts runtime.OpenDialogOptions) (string, error) {
+		gotTitle = opts.Title
+		return "/home/me/Documents", nil
+	})
+	holder := &ApplicationContextHolder{}
+
+	res := holder.ChooseDirectory("Export to")
+
+	if res.Error != nil {
+		t.Fatalf("unexpected error envelope: %+v", res.Error)
+	}
+	if res.Data != "/home/me/Documents" {
+		t.Errorf("Data: want %q, got %q", "/home/me/Documents", res.Data)
+	}
+	if gotTitle != "Export to" {
+		t.Errorf("dialog title: want %q, got %q", "Export to", gotTitle)
+	}
+}
+
+func TestApplicationContextHolder_ChooseDirectory_Error(t *testing.T) {
+	swapOpenDirectoryDialog(t, func(context.Context, runtime.OpenDialogOptions) (string, error) {
+		return "", errors.New("boom")
+	})
+	holder := &ApplicationContextHolder{}
+
+	res := holder.ChooseDirectory("Export to")
+
+	if res.Error == nil {
+		t.Fatal("expected an internal error envelope")
+	}
+	if res.Error.Code != apperr.CodeInternal {
+		t.Errorf("expected internal code, got %q", res.Error.Code)
+	}
+}
+
+// ── ChooseDocument ───────────────────────────────────────────────────────
+
+func TestApplicationContextHolder_ChooseDocument_Success(t *testing.T) {
+	var gotOpts runtime.OpenDialogOptions
+	swapOpenFileDialog(t, func(_ context.Context, opts runtime.OpenDialogOptions) (string, error) {
+		gotOpts = opts
+		return "/home/me/Documents/draft.docx", nil
+	})
+	holder := &ApplicationContextHolder{}
+
+	res := holder.ChooseDocument("Import document")
+
+	if res.Error != nil {
+		t.Fatalf("unexpected error envelope: %+v", res.Error)
+	}
+	if res.Data != "/home/me/Documents/draft.docx" {
+		t.Errorf("Data: want %q, got %q", "/home/me/Documents/draft.docx", res.Data)
+	}
+	if gotOpts.Title != "Import document" {
+		t.Errorf("dialog title: want %q, got %q", "Import document", gotOpts.Title)
+	}
+	if len(gotOpts.Filters) != 1 || !strings.Contains(gotOpts.Filters[0].Pattern, "*.docx") {
+		t.Errorf("dialog filters: want a document filter, got %+v", gotOpts.Filters)
+	}
+}
+
+func TestApplicationContextHolder_ChooseDocument_Error(t *testing.T) {
+	swapOpenFileDialog(t, func(context.Context, runtime.OpenDialogOptions) (string, error) {
+		return "", errors.New("boom")
+	})
+	holder := &ApplicationContextHolder{}
+
+	res := holder.ChooseDocument("Import document")
+
+	if res.Error == nil {
+		t.Fatal("expected an internal error envelope")
+	}
+	if res.Error.Code != apperr.CodeInternal {
+		t.Errorf("expected internal code, got %q", res.Error.Code)
+	}
+}
+
+// ── ChooseHistoryBackup ──────────────────────────────────────────────────
+
+func TestApplicationContextHolder_ChooseHistoryBackup_Success(t *testing.T) {
+	var gotOpts runtime.OpenDialogOptions
+	swapOpenFileDialog(t, func(_ context.Context, opts runtime.OpenDialogOptions) (string, error) {
+		gotOpts = opts
+		return "/home/me/history.jsonl", nil
+	})
+	holder := &ApplicationContextHolder{}
+
+	res := holder.ChooseHistoryBackup("Import history")
+
+	if res.Error != nil {
+		t.Fatalf("unexpected error envelope: %+v", res.Error)
+	}
+	if res.Data != "/home/me/history.jsonl" {
+		t.Errorf("Data: want %q, got %q", "/home/me/history.jsonl", res.Data)
+	}
+	if len(gotOpts.Filters) != 1 || gotOpts.Filters[0].Pattern != "*.jsonl" {
+		t.Errorf("dialog filters: want a JSONL filter, got %+v", gotOpts.Filters)
+	}
+}
+
+func TestApplicationContextHolder_ChooseHistoryBackup_Error(t *testing.T) {
+	swapOpenFileDialog(t, func(context.Context, runtime.OpenDialogOptions) (string, error) {
+		return "", errors.New("boom")
+	})
+	holder := &ApplicationContextHolder{}
+
+	res := holder.ChooseHistoryBackup("Import history")
+
+	if res.Error == nil || res.Error.Code != apperr.CodeInternal {
+		t.Fatalf("expected an internal error envelope, got %+v", res.Error)
+	}
+}
+
 // ── BrowserOpenURL ───────────────────────────────────────────────────────
 
 func TestApplicationContextHolder_BrowserOpenURL_Success(t *testing.T) {
@@ -0,0 +1,239 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go_text/internal/apperr"
+)
+
+// Destination kinds of apperr.BatchDestination.
+const (
+	DestinationSibling = "sibling"
+	DestinationCSV     = "csv"
+	DestinationJSONL   = "jsonl"
+)
+
+// Defaults of apperr.BatchDestination. defaultSuffix also names the CSV or JSONL
+// output written next to its source.
+const (
+	defaultSuffix = ".out"
+	defaultColumn = "output"
+	defaultField  = "output"
+)
+
+// siblingSuffix returns the suffix of dst's sibling files.
+func siblingSuffix(dst apperr.BatchDestination) string {
+	if dst.Suffix == "" {
+		return defaultSuffix
+	}
+	return dst.Suffix
+}
+
+// siblingPath returns the sibling output path of the source file path: suffix
+// inserted before the extension, so notes.md becomes notes.out.md.
+func siblingPath(path, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + suffix + ext
+}
+
+// resolveDestination checks that dst fits a job reading src and fills in its
+// defaults, including the absolute path of a CSV or JSONL output. Sibling files
+// need a "files" source and a CSV output a CSV source; a JSONL output fits any
+// source but needs an explicit path for a "files" one. An output may not
+// overwrite its source.
+func resolveDestination(src apperr.BatchSource, dst apperr.BatchDestination) (apperr.BatchDestination, error) {
+	mismatch := apperr.Validation("destination.kind", fmt.Sprintf("an output that fits a %s source", src.Kind), dst.Kind)
+	switch dst.Kind {
+	case DestinationSibling:
+		if src.Kind != SourceFiles {
+			return dst, mismatch
+		}
+		dst.Suffix = siblingSuffix(dst)
+		if strings.ContainsAny(dst.Suffix, `/\`) || strings.Trim(dst.Suffix, ".") == "" {
+			return dst, apperr.Validation("destination.suffix", "a file-name suffix without path separators", dst.Suffix)
+		}
+		dst.Path = ""
+		return dst, nil
+	case DestinationCSV:
+		if src.Kind != SourceCSV {
+			return dst, mismatch
+		}
+		if dst.Column == "" {
+			dst.Column = defaultColumn
+		}
+	case DestinationJSONL:
+		if dst.Field == "" {
+			dst.Field = defaultField
+		}
+	default:
+		return dst, apperr.Validation("destination.kind",
+			fmt.Sprintf("one of %s, %s, %s", DestinationSibling, DestinationCSV, DestinationJSONL), dst.Kind)
+	}
+
+	if dst.Path == "" {
+		if src.Kind == SourceFiles {
+			return dst, apperr.Validation("destination.path", "be non-empty for a files source", "empty string")
+		}
+		dst.Path = strings.TrimSuffix(src.Path, filepath.Ext(src.Path)) + defaultSuffix + "." + dst.Kind
+	}
+	path, err := filepath.Abs(dst.Path)
+	if err != nil {
+		return dst, fmt.Errorf("resolve %s: %w", dst.Path, err)
+	}
+	if source, err := filepath.Abs(src.Path); err == nil && source == path {
+		return dst, apperr.Validation("destination.path", "a file other than the source", dst.Path)
+	}
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+		return dst, apperr.Validation("destination.path", "a file in an existing directory", dst.Path)
+	}
+	dst.Path = path
+	return dst, nil
+}
+
+// writeOutputs writes the outputs of job's items to its destination and returns
+// the file written, or the directory of the sources for sibling files. Only done
+// items have an output: their sibling files are (over)written, their CSV cell or
+// JSONL field set. Other rows and records are written as read.
+func writeOutputs(job StoredJob, items []StoredItem) (string, error) {
+	dst := job.Request.Destination
+	var (
+		data []byte
+		err  error
+	)
+	switch dst.Kind {
+	case DestinationSibling:
+		for _, item := range items {
+			if item.Status != ItemDone {
+				continue
+			}
+			if err := writeFileAtomic(siblingPath(item.Key, dst.Suffix), []byte(item.OutputText)); err != nil {
+				return "", err
+			}
+		}
+		return filepath.Dir(job.Request.Source.Path), nil
+	case DestinationCSV:
+		data, err = csvOutput(job.Header, dst.Column, items)
+	case DestinationJSONL:
+		data, err = jsonlOutput(job.Request.Source.Kind, dst.Field, items)
+	default:
+		err = fmt.Errorf("unknown destination kind %q", dst.Kind)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(dst.Path, data); err != nil {
+		return "", err
+	}
+	return dst.Path, nil
+}
+
+// csvOutput renders the source rows under header with the output in column,
+// which is appended when the header does not have it.
+func csvOutput(header []string, column string, items []StoredItem) ([]byte, error) {
+	cols := slices.Clone(header)
+	at := slices.Index(cols, column)
+	if at < 0 {
+		cols = append(cols, column)
+		at = len(cols) - 1
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(cols); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+	for _, item := range items {
+		var row []string
+		if err := json.Unmarshal([]byte(item.Record), &row); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", item.Key, err)
+		}
+		for len(row) < len(cols) {
+			row = append(row, "")
+		}
+		if item.Status == ItemDone {
+			row[at] = item.OutputText
+		}
+		if err := w.Write(row); err != nil {
+			return nil, fmt.Errorf("write %s: %w", item.Key, err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("write csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonlRecord is a line of the JSONL output of a source other than JSONL.
+type jsonlRecord struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// jsonlOutput renders one line per item. For a JSONL source that is the source
+// record with the output in field (keys come out sorted); for other sources a
+// jsonlRecord.
+func jsonlOutput(sourceKind, field string, items []StoredItem) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, item := range items {
+		var (
+			line []byte
+			err  error
+		)
+		switch {
+		case sourceKind != SourceJSONL:
+			line, err = json.Marshal(jsonlRecord{Key: item.Key, Status: item.Status, Output: item.OutputText, Error: item.Error})
+		case item.Status != ItemDone:
+			line = []byte(item.Record)
+		default:
+			var record map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(item.Record), &record); err != nil {
+				return nil, fmt.Errorf("decode %s: %w", item.Key, err)
+			}
+			record[field], err = json.Marshal(item.OutputText)
+			if err == nil {
+				line, err = json.Marshal(record)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %w", item.Key, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFileAtomic writes data to path through a temporary file in the same
+// directory, so a reader never sees a half-written output.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+func TestResolveDestination(t *testing.T) {
+	dir := t.TempDir()
+	csvSrc := apperr.BatchSource{Kind: SourceCSV, Path: filepath.Join(dir, "in.csv"), Column: "text"}
+	filesSrc := apperr.BatchSource{Kind: SourceFiles, Path: filepath.Join(dir, "*.md")}
+
+	tests := []struct {
+		name      string
+		src       apperr.BatchSource
+		dst       apperr.BatchDestination
+		want      apperr.BatchDestination
+		wantField string
+	}{
+		{
+			name: "sibling defaults suffix",
+			src:  filesSrc,
+			dst:  apperr.BatchDestination{Kind: DestinationSibling, Path: "ignored"},
+			want: apperr.BatchDestination{Kind: DestinationSibling, Suffix: ".out"},
+		},
+		{
+			name: "csv defaults path and column",
+			src:  csvSrc,
+			dst:  apperr.BatchDestination{Kind: DestinationCSV},
+			want: apperr.BatchDestination{Kind: DestinationCSV, Path: filepath.Join(dir, "in.out.csv"), Column: "output"},
+		},
+		{
+			name: "jsonl defaults path and field",
+			src:  csvSrc,
+			dst:  apperr.BatchDestination{Kind: DestinationJSONL},
+			want: apperr.BatchDestination{Kind: DestinationJSONL, Path: filepath.Join(dir, "in.out.jsonl"), Field: "output"},
+		},
+		{name: "sibling needs files source", src: csvSrc, dst: apperr.BatchDestination{Kind: DestinationSibling}, wantField: "destination.kind"},
+		{name: "csv needs csv source", src: filesSrc, dst: apperr.BatchDestination{Kind: DestinationCSV}, wantField: "destination.kind"},
+		{name: "unknown kind", src: csvSrc, dst: apperr.BatchDestination{Kind: "xml"}, wantField: "destination.kind"},
+		{name: "suffix with separator", src: filesSrc, dst: apperr.BatchDestination{Kind: DestinationSibling, Suffix: "/x"}, wantField: "destination.suffix"},
+		{name: "files to jsonl needs path", src: filesSrc, dst: apperr.BatchDestination{Kind: DestinationJSONL}, wantField: "destination.path"},
+		{name: "overwrites source", src: csvSrc, dst: apperr.BatchDestination{Kind: DestinationCSV, Path: csvSrc.Path}, wantField: "destination.path"},
+		{
+			name:      "missing directory",
+			src:       csvSrc,
+			dst:       apperr.BatchDestination{Kind: DestinationCSV, Path: filepath.Join(dir, "nope", "out.csv")},
+			wantField: "destination.path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDestination(tt.src, tt.dst)
+			if tt.wantField != "" {
+				assertValidation(t, err, tt.wantField)
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveDestination: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteOutputs_Sibling(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.md"), filepath.Join(dir, "b.md")
+	job := StoredJob{BatchJob: apperr.BatchJob{Request: apperr.BatchJobRequest{
+		Source:      apperr.BatchSource{Kind: SourceFiles, Path: filepath.Join(dir, "*.md")},
+		Destination: apperr.BatchDestination{Kind: DestinationSibling, Suffix: ".fixed"},
+	}}}
+	items := []StoredItem{
+		{BatchItem: apperr.BatchItem{Key: a, Status: ItemDone, OutputText: "ALPHA"}},
+		{BatchItem: apperr.BatchItem{Key: b, Status: ItemFailed}},
+	}
+
+	path, err := writeOutputs(job, items)
+	if err != nil {
+		t.Fatalf("writeOutputs: %v", err)
+	}
+	if path != dir {
+		t.Errorf("path = %q, want %q", path, dir)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "a.fixed.md"))
+	if err != nil || string(data) != "ALPHA" {
+		t.Errorf("a.fixed.md = %q, %v; want ALPHA", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.fixed.md")); !os.IsNotExist(err) {
+		t.Errorf("b.fixed.md written for a failed item (err = %v)", err)
+	}
+}
+
+func TestWriteOutputs_CSV(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.csv")
+	job := StoredJob{
+		BatchJob: apperr.BatchJob{Request: apperr.BatchJobRequest{
+			Source:      apperr.BatchSource{Kind: SourceCSV},
+			Destination: apperr.BatchDestination{Kind: DestinationCSV, Path: out, Column: "rewritten"},
+		}},
+		Header: []string{"sku", "text"},
+	}
+	items := []StoredItem{
+		{BatchItem: apperr.BatchItem{Key: "row 1", Status: ItemDone, OutputText: "Soft, warm"}, Record: `["1","soft warm"]`},
+		{BatchItem: apperr.BatchItem{Key: "row 2", Status: ItemFailed}, Record: `["2"]`},
+	}
+
+	if _, err := writeOutputs(job, items); err != nil {
+		t.Fatalf("writeOutputs: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	want := "sku,text,rewritten\n1,soft warm,\"Soft, warm\"\n2,,\n"
+	if string(data) != want {
+		t.Errorf("output = %q, want %q", data, want)
+	}
+}
+
+func TestWriteOutputs_JSONL(t *testing.T) {
+	tests := []struct {
+		name       string
+		sourceKind string
+		items      []StoredItem
+		want       string
+	}{
+		{
+			name:       "jsonl source keeps records",
+			sourceKind: SourceJSONL,
+			items: []StoredItem{
+				{BatchItem: apperr.BatchItem{Key: "line 1", Status: ItemDone, OutputText: "HI"}, Record: `{"text":"hi","id":1}`},
+				{BatchItem: apperr.BatchItem{Key: "line 2", Status: ItemSkipped}, Record: `{"id":2}`},
+			},
+			want: "{\"id\":1,\"out\":\"HI\",\"text\":\"hi\"}\n{\"id\":2}\n",
+		},
+		{
+			name:       "other sources write key and status",
+			sourceKind: SourceFiles,
+			items: []StoredItem{
+				{BatchItem: apperr.BatchItem{Key: "/a.md", Status: ItemDone, OutputText: "A"}},
+				{BatchItem: apperr.BatchItem{Key: "/b.md", Status: ItemFailed, Error: "timeout"}},
+			},
+			want: "{\"key\":\"/a.md\",\"status\":\"done\",\"output\":\"A\"}\n{\"key\":\"/b.md\",\"status\":\"failed\",\"error\":\"timeout\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out.jsonl")
+			job := StoredJob{BatchJob: apperr.BatchJob{Request: apperr.BatchJobRequest{
+				Source:      apperr.BatchSource{Kind: tt.sourceKind},
+				Destination: apperr.BatchDestination{Kind: DestinationJSONL, Path: out, Field: "out"},
+			}}}
+			if _, err := writeOutputs(job, tt.items); err != nil {
+				t.Fatalf("writeOutputs: %v", err)
+			}
+			data, _ := os.ReadFile(out)
+			if string(data) != tt.want {
+				t.Errorf("output = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go_text/internal/apperr"
+	"go_text/internal/logging"
+
+	"github.com/rs/zerolog"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const panicMsgFmt = "panic: %v"
+
+// progressEvent is the Wails event carrying apperr.BatchProgress.
+const progressEvent = "batch:progress"
+
+// StackLookupAPI is the minimal contract BatchHandler needs to resolve a
+// StackID to its saved-stack definition.
+// Implemented by *stacks.StackHandler — defined here to avoid an import cycle.
+type StackLookupAPI interface {
+	GetStack(id string) apperr.StackResult
+}
+
+// BatchHandler is the Wails-bound handler for batch jobs.
+// All bound methods follow the envelope pattern: return apperr.*Result,
+// no error return, and include defer/recover for panic safety.
+type BatchHandler struct {
+	appLogger   *logging.Logger
+	service     BatchServiceAPI
+	stackLookup StackLookupAPI
+	appCtx      context.Context
+}
+
+// NewBatchHandler constructs a BatchHandler.
+func NewBatchHandler(appLogger *logging.Logger, service BatchServiceAPI) *BatchHandler {
+	return &BatchHandler{appLogger: appLogger, service: service}
+}
+
+// SetContext stores the Wails runtime context used to emit progress events.
+func (h *BatchHandler) SetContext(ctx context.Context) {
+	h.appCtx = ctx
+}
+
+// SetStackLookup wires the stack accessor CreateBatchJob resolves StackID with.
+func (h *BatchHandler) SetStackLookup(lookup StackLookupAPI) {
+	h.stackLookup = lookup
+}
+
+// liveZlog returns a live snapshot of the app logger's current writer, or a
+// no-op logger if appLogger has not been wired (e.g. bare struct-literal
+// tests exercising panic recovery).
+func (h *BatchHandler) liveZlog() zerolog.Logger {
+	if h.appLogger != nil {
+		return h.appLogger.ZeroLogger()
+	}
+	return zerolog.Nop()
+}
+
+// emitProgress dispatches a "batch:progress" event. No-op if the runtime
+// context is not yet set (e.g. during unit tests).
+func (h *BatchHandler) emitProgress(p apperr.BatchProgress) {
+	if h.appCtx == nil {
+		return
+	}
+	runtime.EventsEmit(h.appCtx, progressEvent, p)
+}
+
+// resolveStack replaces req.StackID with the saved stack's steps, returning the
+// wire error to report if it cannot. A fan-out stack is rejected: a batch item
+// has a single output.
+func (h *BatchHandler) resolveStack(req *apperr.BatchJobRequest) *apperr.WireError {
+	if h.stackLookup == nil {
+		ae := apperr.Internal(errors.New("stack lookup not configured"))
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return &wire
+	}
+	stackResult := h.stackLookup.GetStack(req.StackID)
+	if stackResult.Error != nil {
+		return stackResult.Error
+	}
+	if stackResult.Data == nil {
+		ae := apperr.Validation("stackId", "a known stack ID", req.StackID)
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return &wire
+	}
+	if stackResult.Data.Kind == apperr.StackKindFanOut {
+		ae := apperr.Validation("stackId", "a linear stack", "fan-out stack")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return &wire
+	}
+	req.Steps = stackResult.Data.ChainSteps()
+	req.StrictOrder = stackResult.Data.StrictOrder
+	return nil
+}
+
+// CreateBatchJob reads the job's source, stores the job and starts it. Steps
+// come from req.StackID when set. If another job or a chain run holds the
+// inference gate, the job is returned paused alongside the busy error, ready
+// to be resumed later.
+func (h *BatchHandler) CreateBatchJob(req apperr.BatchJobRequest) (res apperr.BatchJobResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.BatchJobResult{Error: &wire}
+		}
+	}()
+	if req.StackID != "" {
+		if wire := h.resolveStack(&req); wire != nil {
+			return apperr.BatchJobResult{Error: wire}
+		}
+	}
+	job, err := h.service.Create(req)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.BatchJobResult{Error: &wire}
+	}
+	started, err := h.service.Start(job.ID, h.emitProgress)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.BatchJobResult{Data: job, Error: &wire}
+	}
+	return apperr.BatchJobResult{Data: started}
+}
+
+// ListBatchJobs returns every batch job, newest first, with its item counts.
+func (h *BatchHandler) ListBatchJobs() (res apperr.BatchJobsResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.BatchJobsResult{Error: &wire}
+		}
+	}()
+	data, err := h.service.List()
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.BatchJobsResult{Error: &wire}
+	}
+	if data == nil {
+		data = []apperr.BatchJob{}
+	}
+	return apperr.BatchJobsResult{Data: data}
+}
+
+// GetBatchJob returns a single batch job by ID.
+func (h *BatchHandler) GetBatchJob(id string) (res apperr.BatchJobResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.BatchJobResult{Error: &wire}
+		}
+	}()
+	if id == "" {
+		ae := apperr.Validation("id", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.BatchJobResult{Error: &wire}
+	}
+	job, err := h.service.Get(id)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.BatchJobResult{Error: &wire}
+	}
+	return apperr.BatchJobResult{Data: job}
+}
+
+// ListBatchItems returns a job's items paginated by limit/offset, in source order.
+func (h *BatchHandler) ListBatchItems(jobID string, limit, offset int) (res apperr.BatchItemsResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.BatchItemsResult{Error: &wire}
+		}
+	}()
+	if jobID == "" {
+		ae := apperr.Validation("jobId", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.BatchItemsResult{Error: &wire}
+	}
+	data, err := h.service.Items(jobID, int64(limit), int64(offset))
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.BatchItemsResult{Error: &wire}
+	}
+	if data == nil {
+		data = []apperr.BatchItem{}
+	}
+	return apperr.BatchItemsResult{Data: data}
+}
+
+// PauseBatchJob stops a running job; items in flight go back to pending.
+func (h *BatchHandler) PauseBatchJob(id string) (res apperr.BatchJobResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.BatchJobResult{Error: &wire}
+		}
+	}()
+	if id == "" {
+		ae := apperr.Validation("id", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.BatchJobResult{Error: &wire}
+	}
+	job, err := h.service.Pause(id)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.BatchJobResult{Error: &wire}
+	}
+	return apperr.BatchJobResult{Data: job}
+}
+
+// ResumeBatchJob starts a paused job — including one interrupted by a restart —
+// from its pending items.
+func (h *BatchHandler) ResumeBatchJob(id string) (res apperr.BatchJobResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.BatchJobResult{Error: &wire}
+		}
+	}()
+	if id == "" {
+		ae := apperr.Validation("id", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.BatchJobResult{Error: &wire}
+	}
+	job, err := h.service.Start(id, h.emitProgress)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.BatchJobResult{Error: &wire}
+	}
+	return apperr.BatchJobResult{Data: job}
+}
+
+// RetryFailedBatchItems puts a job's failed items back to pending and starts it.
+func (h *BatchHandler) RetryFailedBatchItems(id string) (res apperr.BatchJobResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.BatchJobResult{Error: &wire}
+		}
+	}()
+	if id == "" {
+		ae := apperr.Validation("id", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.BatchJobResult{Error: &wire}
+	}
+	job, err := h.service.RetryFailed(id, h.emitProgress)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.BatchJobResult{Error: &wire}
+	}
+	return apperr.BatchJobResult{Data: job}
+}
+
+// DeleteBatchJob removes a job that is not running, with its items. Output
+// files already written are kept.
+func (h *BatchHandler) DeleteBatchJob(id string) (res apperr.VoidResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.VoidResult{Error: &wire}
+		}
+	}()
+	if id == "" {
+		ae := apperr.Validation("id", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.VoidResult{Error: &wire}
+	}
+	if err := h.service.Delete(id); err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.VoidResult{Error: &wire}
+	}
+	return apperr.VoidResult{}
+}
+
+// PauseAllJobs pauses every running job and waits for them to stop. Called
+// from ApplicationContextHolder.CancelAllRuns on shutdown; not Wails-facing
+// in practice.
+func (h *BatchHandler) PauseAllJobs() {
+	h.service.PauseAll()
+}
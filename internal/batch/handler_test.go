@@ -0,0 +1,150 @@
+package batch
+
+import (
+	"errors"
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+// mockBatchService satisfies BatchServiceAPI.
+type mockBatchService struct {
+	created  apperr.BatchJobRequest
+	job      *apperr.BatchJob
+	jobs     []apperr.BatchJob
+	items    []apperr.BatchItem
+	err      error
+	startErr error
+	paused   bool
+}
+
+func (m *mockBatchService) Create(req apperr.BatchJobRequest) (*apperr.BatchJob, error) {
+	m.created = req
+	return m.job, m.err
+}
+func (m *mockBatchService) List() ([]apperr.BatchJob, error)        { return m.jobs, m.err }
+func (m *mockBatchService) Get(id string) (*apperr.BatchJob, error) { return m.job, m.err }
+func (m *mockBatchService) Items(jobID string, limit, offset int64) ([]apperr.BatchItem, error) {
+	return m.items, m.err
+}
+func (m *mockBatchService) Start(id string, emit func(apperr.BatchProgress)) (*apperr.BatchJob, error) {
+	if m.startErr != nil {
+		return nil, m.startErr
+	}
+	running := *m.job
+	running.Status = JobRunning
+	return &running, nil
+}
+func (m *mockBatchService) Pause(id string) (*apperr.BatchJob, error) { return m.job, m.err }
+func (m *mockBatchService) RetryFailed(id string, emit func(apperr.BatchProgress)) (*apperr.BatchJob, error) {
+	return m.job, m.err
+}
+func (m *mockBatchService) Delete(id string) error { return m.err }
+func (m *mockBatchService) PauseAll()              { m.paused = true }
+
+// mockStackLookup satisfies StackLookupAPI.
+type mockStackLookup struct {
+	stack *apperr.SavedStack
+}
+
+func (m *mockStackLookup) GetStack(id string) apperr.StackResult {
+	return apperr.StackResult{Data: m.stack}
+}
+
+func TestBatchHandler_CreateBatchJob_ResolvesStack(t *testing.T) {
+	svc := &mockBatchService{job: &apperr.BatchJob{ID: "job-1", Status: JobPaused}}
+	h := NewBatchHandler(nil, svc)
+	h.SetStackLookup(&mockStackLookup{stack: &apperr.SavedStack{
+		ID: "s1", Kind: "linear", Steps: []string{"proofread", "formal"}, StrictOrder: true,
+	}})
+
+	res := h.CreateBatchJob(apperr.BatchJobRequest{StackID: "s1"})
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %+v", res.Error)
+	}
+	if res.Data.Status != JobRunning {
+		t.Errorf("status = %q, want running", res.Data.Status)
+	}
+	if len(svc.created.Steps) != 2 || svc.created.Steps[1].ActionID != "formal" || !svc.created.StrictOrder {
+		t.Errorf("service got %+v, want the stack's steps", svc.created)
+	}
+}
+
+func TestBatchHandler_CreateBatchJob_StackErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookup StackLookupAPI
+		want   apperr.ErrorCode
+	}{
+		{"no lookup", nil, apperr.CodeInternal},
+		{"unknown stack", &mockStackLookup{}, apperr.CodeValidation},
+		{"fan-out stack", &mockStackLookup{stack: &apperr.SavedStack{Kind: apperr.StackKindFanOut}}, apperr.CodeValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewBatchHandler(nil, &mockBatchService{})
+			if tt.lookup != nil {
+				h.SetStackLookup(tt.lookup)
+			}
+			res := h.CreateBatchJob(apperr.BatchJobRequest{StackID: "s1"})
+			if res.Error == nil || res.Error.Code != tt.want {
+				t.Errorf("error = %+v, want %s", res.Error, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchHandler_CreateBatchJob_BusyReturnsPausedJob(t *testing.T) {
+	svc := &mockBatchService{job: &apperr.BatchJob{ID: "job-1", Status: JobPaused}, startErr: apperr.Busy()}
+	res := NewBatchHandler(nil, svc).CreateBatchJob(apperr.BatchJobRequest{})
+	if res.Error == nil || res.Error.Code != apperr.CodeBusy {
+		t.Fatalf("error = %+v, want busy", res.Error)
+	}
+	if res.Data == nil || res.Data.ID != "job-1" || res.Data.Status != JobPaused {
+		t.Errorf("data = %+v, want the paused job", res.Data)
+	}
+}
+
+func TestBatchHandler_ListBatchJobs(t *testing.T) {
+	res := NewBatchHandler(nil, &mockBatchService{}).ListBatchJobs()
+	if res.Error != nil || res.Data == nil || len(res.Data) != 0 {
+		t.Errorf("res = %+v, want an empty non-nil list", res)
+	}
+	res = NewBatchHandler(nil, &mockBatchService{err: errors.New("db fail")}).ListBatchJobs()
+	if res.Error == nil {
+		t.Error("expected error in result")
+	}
+}
+
+func TestBatchHandler_EmptyID(t *testing.T) {
+	h := NewBatchHandler(nil, &mockBatchService{})
+	results := map[string]*apperr.WireError{
+		"GetBatchJob":           h.GetBatchJob("").Error,
+		"ListBatchItems":        h.ListBatchItems("", 10, 0).Error,
+		"PauseBatchJob":         h.PauseBatchJob("").Error,
+		"ResumeBatchJob":        h.ResumeBatchJob("").Error,
+		"RetryFailedBatchItems": h.RetryFailedBatchItems("").Error,
+		"DeleteBatchJob":        h.DeleteBatchJob("").Error,
+	}
+	for name, wire := range results {
+		if wire == nil || wire.Code != apperr.CodeValidation {
+			t.Errorf("%s(\"\") error = %+v, want validation", name, wire)
+		}
+	}
+}
+
+func TestBatchHandler_PauseAllJobs(t *testing.T) {
+	svc := &mockBatchService{}
+	NewBatchHandler(nil, svc).PauseAllJobs()
+	if !svc.paused {
+		t.Error("PauseAll not called")
+	}
+}
+
+func TestBatchHandler_PanicRecovery_ListBatchJobs(t *testing.T) {
+	h := &BatchHandler{service: nil}
+	res := h.ListBatchJobs()
+	if res.Error == nil {
+		t.Fatal("expected internal error after nil-service panic")
+	}
+}
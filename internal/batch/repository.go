@@ -0,0 +1,42 @@
+package batch
+
+import "go_text/internal/apperr"
+
+// StoredJob is a batch job with the header of its CSV source (nil for other
+// sources), which its CSV output is written under.
+type StoredJob struct {
+	apperr.BatchJob
+	Header []string
+}
+
+// StoredItem is a batch item with the source record its output is written back
+// into: the CSV row as a JSON array of cells, the JSONL line, or "" for a file.
+type StoredItem struct {
+	apperr.BatchItem
+	Record string
+}
+
+// BatchRepositoryAPI is the contract for the SQLite batch repository.
+// All methods use context.Background() internally — Wails bound callers supply no ctx.
+type BatchRepositoryAPI interface {
+	// Create inserts job and its items in one transaction. job.ID and the
+	// timestamps are generated; the job is returned with its item counts.
+	Create(job StoredJob, items []StoredItem) (*StoredJob, error)
+	List() ([]apperr.BatchJob, error)
+	Get(id string) (*StoredJob, error)
+	SetStatus(id, status, outputPath, errMsg string) error
+	Delete(id string) error
+	// Items returns up to limit items of a job from offset, in source order; a
+	// negative limit returns all of them.
+	Items(jobID string, limit, offset int64) ([]StoredItem, error)
+	ItemsByStatus(jobID, status string) ([]StoredItem, error)
+	// StartItem marks an item running and counts the attempt.
+	StartItem(jobID string, index int) error
+	// FinishItem stores item's status, output and error.
+	FinishItem(item apperr.BatchItem) error
+	// ResetItems puts a job's items with the given status back to pending.
+	ResetItems(jobID, status string) error
+	// RecoverInterrupted pauses every job left running by a previous process and
+	// puts its running items back to pending.
+	RecoverInterrupted() error
+}
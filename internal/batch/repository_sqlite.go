@@ -0,0 +1,339 @@
+package batch
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go_text/internal/apperr"
+	"go_text/internal/db"
+	"go_text/internal/db/store"
+)
+
+// SqliteBatchRepository is the SQLite-backed implementation of BatchRepositoryAPI.
+type SqliteBatchRepository struct {
+	database *db.Database
+}
+
+// NewSqliteBatchRepository constructs a batch repository backed by database.
+func NewSqliteBatchRepository(database *db.Database) *SqliteBatchRepository {
+	if database == nil {
+		panic("SqliteBatchRepository: database cannot be nil")
+	}
+	return &SqliteBatchRepository{database: database}
+}
+
+func (r *SqliteBatchRepository) bg() context.Context { return context.Background() }
+
+func rowToBatchItem(row store.BatchItem) StoredItem {
+	return StoredItem{
+		BatchItem: apperr.BatchItem{
+			JobID:      row.JobID,
+			Index:      int(row.Position),
+			Key:        row.ItemKey,
+			InputText:  row.InputText,
+			OutputText: row.OutputText,
+			Status:     row.Status,
+			ErrorCode:  row.ErrorCode,
+			Error:      row.Error,
+			Attempts:   int(row.Attempts),
+			UpdatedAt:  row.UpdatedAt,
+		},
+		Record: row.Record,
+	}
+}
+
+func rowsToBatchItems(rows []store.BatchItem) []StoredItem {
+	items := make([]StoredItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, rowToBatchItem(row))
+	}
+	return items
+}
+
+// loadJob decodes row's spec and header and counts its items.
+func (r *SqliteBatchRepository) loadJob(ctx context.Context, q *store.Queries, row store.BatchJob) (StoredJob, error) {
+	var spec apperr.BatchJobRequest
+	if err := json.Unmarshal([]byte(row.Spec), &spec); err != nil {
+		return StoredJob{}, fmt.Errorf("decode spec of job %s: %w", row.ID, err)
+	}
+	var header []string
+	if row.Header != "" {
+		if err := json.Unmarshal([]byte(row.Header), &header); err != nil {
+			return StoredJob{}, fmt.Errorf("decode header of job %s: %w", row.ID, err)
+		}
+	}
+	counts, err := q.CountBatchItemsByStatus(ctx, row.ID)
+	if err != nil {
+		return StoredJob{}, fmt.Errorf("count items of job %s: %w", row.ID, err)
+	}
+	job := StoredJob{
+		BatchJob: apperr.BatchJob{
+			ID:         row.ID,
+			Name:       row.Name,
+			Status:     row.Status,
+			Request:    spec,
+			OutputPath: row.OutputPath,
+			Error:      row.Error,
+			CreatedAt:  row.CreatedAt,
+			UpdatedAt:  row.UpdatedAt,
+		},
+		Header: header,
+	}
+	for _, c := range counts {
+		n := int(c.N)
+		job.Counts.Total += n
+		switch c.Status {
+		case ItemPending:
+			job.Counts.Pending = n
+		case ItemRunning:
+			job.Counts.Running = n
+		case ItemDone:
+			job.Counts.Done = n
+		case ItemFailed:
+			job.Counts.Failed = n
+		case ItemSkipped:
+			job.Counts.Skipped = n
+		}
+	}
+	return job, nil
+}
+
+// Create inserts job and its items in one transaction.
+func (r *SqliteBatchRepository) Create(job StoredJob, items []StoredItem) (*StoredJob, error) {
+	const op = "SqliteBatchRepository.Create"
+	ctx := r.bg()
+	now := time.Now().Unix()
+	id := uuid.NewString()
+
+	spec, err := json.Marshal(job.Request)
+	if err != nil {
+		return nil, fmt.Errorf("%s: encode spec: %w", op, err)
+	}
+	header := ""
+	if job.Header != nil {
+		raw, err := json.Marshal(job.Header)
+		if err != nil {
+			return nil, fmt.Errorf("%s: encode header: %w", op, err)
+		}
+		header = string(raw)
+	}
+
+	tx, err := r.database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	q := r.database.Queries.WithTx(tx)
+	if err := q.InsertBatchJob(ctx, store.InsertBatchJobParams{
+		ID:        id,
+		Name:      job.Name,
+		Status:    job.Status,
+		Spec:      string(spec),
+		Header:    header,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		return nil, fmt.Errorf("%s: insert job: %w", op, err)
+	}
+	for i, item := range items {
+		if err := q.InsertBatchItem(ctx, store.InsertBatchItemParams{
+			JobID:     id,
+			Position:  int64(i),
+			ItemKey:   item.Key,
+			InputText: item.InputText,
+			Record:    item.Record,
+			Status:    item.Status,
+			Error:     item.Error,
+			UpdatedAt: now,
+		}); err != nil {
+			return nil, fmt.Errorf("%s: insert item[%d]: %w", op, i, err)
+		}
+	}
+
+	row, err := q.GetBatchJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: reload: %w", op, err)
+	}
+	created, err := r.loadJob(ctx, q, row)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return &created, nil
+}
+
+// List returns every batch job, newest first, with its item counts.
+func (r *SqliteBatchRepository) List() ([]apperr.BatchJob, error) {
+	const op = "SqliteBatchRepository.List"
+	ctx := r.bg()
+	rows, err := r.database.Queries.ListBatchJobs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	jobs := make([]apperr.BatchJob, 0, len(rows))
+	for _, row := range rows {
+		job, err := r.loadJob(ctx, r.database.Queries, row)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		jobs = append(jobs, job.BatchJob)
+	}
+	return jobs, nil
+}
+
+// Get returns the batch job with the given id. An unknown id is a validation error.
+func (r *SqliteBatchRepository) Get(id string) (*StoredJob, error) {
+	const op = "SqliteBatchRepository.Get"
+	ctx := r.bg()
+	row, err := r.database.Queries.GetBatchJob(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Validation("id", "an existing batch job ID", id)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	job, err := r.loadJob(ctx, r.database.Queries, row)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &job, nil
+}
+
+// SetStatus stores a job's status, output path and error message.
+func (r *SqliteBatchRepository) SetStatus(id, status, outputPath, errMsg string) error {
+	const op = "SqliteBatchRepository.SetStatus"
+	if err := r.database.Queries.UpdateBatchJobStatus(r.bg(), store.UpdateBatchJobStatusParams{
+		Status:     status,
+		OutputPath: outputPath,
+		Error:      errMsg,
+		UpdatedAt:  time.Now().Unix(),
+		ID:         id,
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Delete removes the job and its items (ON DELETE CASCADE).
+func (r *SqliteBatchRepository) Delete(id string) error {
+	const op = "SqliteBatchRepository.Delete"
+	if err := r.database.Queries.DeleteBatchJob(r.bg(), id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// PruneCompletedOlderThan deletes completed jobs (and their items) last
+// updated before cutoff (Unix seconds) and returns how many were removed.
+// Paused, failed and running jobs are kept so they can still be resumed.
+func (r *SqliteBatchRepository) PruneCompletedOlderThan(cutoff int64) (int64, error) {
+	const op = "SqliteBatchRepository.PruneCompletedOlderThan"
+	n, err := r.database.Queries.PruneCompletedBatchJobsOlderThan(r.bg(), cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+// Items returns up to limit items of a job from offset, in source order.
+func (r *SqliteBatchRepository) Items(jobID string, limit, offset int64) ([]StoredItem, error) {
+	const op = "SqliteBatchRepository.Items"
+	rows, err := r.database.Queries.ListBatchItems(r.bg(), store.ListBatchItemsParams{
+		JobID:  jobID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rowsToBatchItems(rows), nil
+}
+
+// ItemsByStatus returns a job's items with the given status, in source order.
+func (r *SqliteBatchRepository) ItemsByStatus(jobID, status string) ([]StoredItem, error) {
+	const op = "SqliteBatchRepository.ItemsByStatus"
+	rows, err := r.database.Queries.ListBatchItemsByStatus(r.bg(), store.ListBatchItemsByStatusParams{
+		JobID:  jobID,
+		Status: status,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rowsToBatchItems(rows), nil
+}
+
+// StartItem marks an item running and counts the attempt.
+func (r *SqliteBatchRepository) StartItem(jobID string, index int) error {
+	const op = "SqliteBatchRepository.StartItem"
+	if err := r.database.Queries.StartBatchItem(r.bg(), store.StartBatchItemParams{
+		UpdatedAt: time.Now().Unix(),
+		JobID:     jobID,
+		Position:  int64(index),
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// FinishItem stores item's status, output and error.
+func (r *SqliteBatchRepository) FinishItem(item apperr.BatchItem) error {
+	const op = "SqliteBatchRepository.FinishItem"
+	if err := r.database.Queries.FinishBatchItem(r.bg(), store.FinishBatchItemParams{
+		Status:     item.Status,
+		OutputText: item.OutputText,
+		ErrorCode:  item.ErrorCode,
+		Error:      item.Error,
+		UpdatedAt:  time.Now().Unix(),
+		JobID:      item.JobID,
+		Position:   int64(item.Index),
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ResetItems puts a job's items with the given status back to pending.
+func (r *SqliteBatchRepository) ResetItems(jobID, status string) error {
+	const op = "SqliteBatchRepository.ResetItems"
+	if err := r.database.Queries.ResetBatchItems(r.bg(), store.ResetBatchItemsParams{
+		UpdatedAt: time.Now().Unix(),
+		JobID:     jobID,
+		Status:    status,
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RecoverInterrupted pauses jobs left running and resets their running items,
+// in one transaction.
+func (r *SqliteBatchRepository) RecoverInterrupted() error {
+	const op = "SqliteBatchRepository.RecoverInterrupted"
+	ctx := r.bg()
+	now := time.Now().Unix()
+
+	tx, err := r.database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	q := r.database.Queries.WithTx(tx)
+	if err := q.PauseInterruptedBatchJobs(ctx, now); err != nil {
+		return fmt.Errorf("%s: pause jobs: %w", op, err)
+	}
+	if err := q.ResetInterruptedBatchItems(ctx, now); err != nil {
+		return fmt.Errorf("%s: reset items: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return nil
+}
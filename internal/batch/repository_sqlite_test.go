@@ -0,0 +1,184 @@
+package batch
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go_text/internal/apperr"
+	"go_text/internal/db"
+)
+
+func openTestDB(t *testing.T) *db.Database {
+	t.Helper()
+	d, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = d.Close() })
+	return d
+}
+
+func newBatchRepo(t *testing.T) *SqliteBatchRepository {
+	t.Helper()
+	return NewSqliteBatchRepository(openTestDB(t))
+}
+
+func createTestJob(t *testing.T, repo *SqliteBatchRepository, statuses ...string) *StoredJob {
+	t.Helper()
+	items := make([]StoredItem, len(statuses))
+	for i, status := range statuses {
+		items[i] = StoredItem{
+			BatchItem: apperr.BatchItem{Key: filepath.Join("/in", string(rune('a'+i))+".md"), InputText: "text", Status: status},
+		}
+	}
+	job, err := repo.Create(StoredJob{
+		BatchJob: apperr.BatchJob{Name: "job", Status: JobPaused, Request: apperr.BatchJobRequest{
+			Steps:       []apperr.ChainStep{{ActionID: "proofread"}},
+			Source:      apperr.BatchSource{Kind: SourceCSV, Path: "/in.csv", Column: "text"},
+			Destination: apperr.BatchDestination{Kind: DestinationCSV, Path: "/in.out.csv", Column: "output"},
+		}},
+		Header: []string{"text"},
+	}, items)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return job
+}
+
+func TestSqliteBatchRepository_CreateAndGet(t *testing.T) {
+	repo := newBatchRepo(t)
+	created := createTestJob(t, repo, ItemPending, ItemPending, ItemSkipped)
+
+	got, err := repo.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "job" || got.Status != JobPaused {
+		t.Errorf("job = %+v, want name job, status paused", got.BatchJob)
+	}
+	if got.Request.Source.Column != "text" || len(got.Request.Steps) != 1 {
+		t.Errorf("request not round-tripped: %+v", got.Request)
+	}
+	if len(got.Header) != 1 || got.Header[0] != "text" {
+		t.Errorf("header = %q, want [text]", got.Header)
+	}
+	want := apperr.BatchCounts{Total: 3, Pending: 2, Skipped: 1}
+	if got.Counts != want {
+		t.Errorf("counts = %+v, want %+v", got.Counts, want)
+	}
+
+	items, err := repo.Items(created.ID, -1, 0)
+	if err != nil {
+		t.Fatalf("Items: %v", err)
+	}
+	if len(items) != 3 || items[2].Index != 2 || items[2].Status != ItemSkipped {
+		t.Errorf("items = %+v", items)
+	}
+}
+
+func TestSqliteBatchRepository_Get_Unknown(t *testing.T) {
+	_, err := newBatchRepo(t).Get("missing")
+	assertValidation(t, err, "id")
+}
+
+func TestSqliteBatchRepository_ItemLifecycle(t *testing.T) {
+	repo := newBatchRepo(t)
+	job := createTestJob(t, repo, ItemPending, ItemPending)
+
+	if err := repo.StartItem(job.ID, 0); err != nil {
+		t.Fatalf("StartItem: %v", err)
+	}
+	if err := repo.FinishItem(apperr.BatchItem{JobID: job.ID, Index: 0, Status: ItemFailed, ErrorCode: "timeout", Error: "slow"}); err != nil {
+		t.Fatalf("FinishItem: %v", err)
+	}
+	failed, err := repo.ItemsByStatus(job.ID, ItemFailed)
+	if err != nil {
+		t.Fatalf("ItemsByStatus: %v", err)
+	}
+	if len(failed) != 1 || failed[0].Attempts != 1 || failed[0].ErrorCode != "timeout" {
+		t.Fatalf("failed items = %+v", failed)
+	}
+
+	if err := repo.ResetItems(job.ID, ItemFailed); err != nil {
+		t.Fatalf("ResetItems: %v", err)
+	}
+	pending, _ := repo.ItemsByStatus(job.ID, ItemPending)
+	if len(pending) != 2 || pending[0].ErrorCode != "" || pending[0].Error != "" {
+		t.Errorf("pending items after reset = %+v", pending)
+	}
+}
+
+func TestSqliteBatchRepository_RecoverInterrupted(t *testing.T) {
+	repo := newBatchRepo(t)
+	job := createTestJob(t, repo, ItemPending, ItemPending)
+	if err := repo.SetStatus(job.ID, JobRunning, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.StartItem(job.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.RecoverInterrupted(); err != nil {
+		t.Fatalf("RecoverInterrupted: %v", err)
+	}
+	got, _ := repo.Get(job.ID)
+	if got.Status != JobPaused || got.Counts.Running != 0 || got.Counts.Pending != 2 {
+		t.Errorf("job after recovery = %+v", got.BatchJob)
+	}
+}
+
+func TestSqliteBatchRepository_Delete(t *testing.T) {
+	repo := newBatchRepo(t)
+	job := createTestJob(t, repo, ItemPending)
+
+	if err := repo.Delete(job.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	jobs, _ := repo.List()
+	if len(jobs) != 0 {
+		t.Errorf("List = %+v, want none", jobs)
+	}
+	items, _ := repo.Items(job.ID, -1, 0)
+	if len(items) != 0 {
+		t.Errorf("items survived delete: %+v", items)
+	}
+}
+
+func TestSqliteBatchRepository_PruneCompletedOlderThan(t *testing.T) {
+	repo := newBatchRepo(t)
+	old := createTestJob(t, repo, ItemDone)
+	paused := createTestJob(t, repo, ItemPending)
+	recent := createTestJob(t, repo, ItemDone)
+	for _, job := range []*StoredJob{old, recent} {
+		if err := repo.SetStatus(job.ID, JobCompleted, "/in.out.csv", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Status updates stamp the current second; age the old and paused jobs explicitly.
+	for _, id := range []string{old.ID, paused.ID} {
+		if _, err := repo.database.DB.Exec(`UPDATE batch_jobs SET updated_at = 100 WHERE id = ?`, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := repo.PruneCompletedOlderThan(200)
+	if err != nil {
+		t.Fatalf("PruneCompletedOlderThan: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("pruned %d jobs, want 1", n)
+	}
+	jobs, _ := repo.List()
+	if len(jobs) != 2 {
+		t.Fatalf("List = %+v, want the paused and recent jobs", jobs)
+	}
+	for _, job := range jobs {
+		if job.ID == old.ID {
+			t.Errorf("old completed job survived the prune")
+		}
+	}
+	items, _ := repo.Items(old.ID, -1, 0)
+	if len(items) != 0 {
+		t.Errorf("items survived prune: %+v", items)
+	}
+}
@@ -0,0 +1,462 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go_text/internal/apperr"
+	"go_text/internal/gate"
+	"go_text/internal/logging"
+	"go_text/internal/settings"
+)
+
+// Job statuses (apperr.BatchJob.Status).
+const (
+	JobRunning   = "running"
+	JobPaused    = "paused"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// Item statuses (apperr.BatchItem.Status).
+const (
+	ItemPending = "pending"
+	ItemRunning = "running"
+	ItemDone    = "done"
+	ItemFailed  = "failed"
+	ItemSkipped = "skipped"
+)
+
+// chainRunnerAPI is the minimal contract BatchService needs from the action service.
+type chainRunnerAPI interface {
+	RunChain(ctx context.Context, req apperr.ChainRequest, emitProgress func(apperr.StepProgress)) (*apperr.ChainResult, error)
+}
+
+// batchSettingsAPI is the minimal contract BatchService needs from the settings service.
+type batchSettingsAPI interface {
+	GetAppBehaviorConfig() (*settings.AppBehaviorConfig, error)
+}
+
+// BatchServiceAPI is the contract consumed by BatchHandler.
+type BatchServiceAPI interface {
+	// Create reads the source of req and stores the job, paused, with its items.
+	// req.Steps must already be resolved from any stack.
+	Create(req apperr.BatchJobRequest) (*apperr.BatchJob, error)
+	List() ([]apperr.BatchJob, error)
+	Get(id string) (*apperr.BatchJob, error)
+	Items(jobID string, limit, offset int64) ([]apperr.BatchItem, error)
+	// Start runs a job's pending items in the background, then writes its
+	// outputs. emit receives the job's progress; it may be nil.
+	Start(id string, emit func(apperr.BatchProgress)) (*apperr.BatchJob, error)
+	Pause(id string) (*apperr.BatchJob, error)
+	// RetryFailed puts a job's failed items back to pending and starts it.
+	RetryFailed(id string, emit func(apperr.BatchProgress)) (*apperr.BatchJob, error)
+	Delete(id string) error
+	// PauseAll pauses every running job and waits for them to stop.
+	PauseAll()
+}
+
+// BatchService implements BatchServiceAPI. A running job holds the inference
+// gate, so only one job runs at a time and no chain runs beside it.
+// repo is nil until Init wires it; every method then returns an internal error.
+type BatchService struct {
+	logger   *logging.Logger
+	repo     BatchRepositoryAPI
+	runner   chainRunnerAPI
+	settings batchSettingsAPI
+	gate     *gate.InferenceGate
+
+	mu     sync.Mutex
+	active map[string]context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBatchService constructs a BatchService. Panics on nil dependencies.
+// Returns *BatchService (concrete) so ApplicationContextHolder can call SetRepository.
+func NewBatchService(appLogger *logging.Logger, runner chainRunnerAPI, settingsService batchSettingsAPI, g *gate.InferenceGate) *BatchService {
+	const op = "BatchService.NewBatchService"
+	if appLogger == nil {
+		panic(fmt.Sprintf("%s: logger cannot be nil", op))
+	}
+	if runner == nil {
+		panic(fmt.Sprintf("%s: chain runner cannot be nil", op))
+	}
+	if settingsService == nil {
+		panic(fmt.Sprintf("%s: settings service cannot be nil", op))
+	}
+	if g == nil {
+		panic(fmt.Sprintf("%s: inference gate cannot be nil", op))
+	}
+	return &BatchService{
+		logger:   appLogger,
+		runner:   runner,
+		settings: settingsService,
+		gate:     g,
+		active:   make(map[string]context.CancelFunc),
+	}
+}
+
+// SetRepository wires the SQLite-backed repository after the DB is open and
+// pauses the jobs a previous process left running, so they can be resumed.
+// Called from ApplicationContextHolder.Init.
+func (s *BatchService) SetRepository(repo BatchRepositoryAPI) error {
+	s.repo = repo
+	return repo.RecoverInterrupted()
+}
+
+func errNoRepository() error {
+	return apperr.Internal(errors.New("batch repository not initialized"))
+}
+
+// Create validates req, reads its source and stores the job, paused.
+func (s *BatchService) Create(req apperr.BatchJobRequest) (*apperr.BatchJob, error) {
+	const op = "BatchService.Create"
+	if s.repo == nil {
+		return nil, errNoRepository()
+	}
+	if len(req.Steps) == 0 {
+		return nil, apperr.Validation("steps", "at least one step", "none")
+	}
+	if req.Concurrency < 0 || req.Concurrency > settings.BatchConcurrencyUpperBound {
+		return nil, apperr.Validation("concurrency", fmt.Sprintf("0–%d", settings.BatchConcurrencyUpperBound), fmt.Sprint(req.Concurrency))
+	}
+	source, err := filepath.Abs(req.Source.Path)
+	if err != nil || strings.TrimSpace(req.Source.Path) == "" {
+		return nil, apperr.Validation("source.path", "be non-empty", "empty string")
+	}
+	req.Source.Path = source
+	if req.Destination, err = resolveDestination(req.Source, req.Destination); err != nil {
+		return nil, err
+	}
+	read, header, err := readSource(req.Source, req.Destination)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		req.Name = filepath.Base(req.Source.Path)
+	}
+
+	items := make([]StoredItem, len(read))
+	for i, r := range read {
+		items[i] = StoredItem{
+			BatchItem: apperr.BatchItem{Key: r.key, InputText: r.input, Status: ItemPending, Error: r.skip},
+			Record:    r.record,
+		}
+		if r.skip != "" {
+			items[i].Status = ItemSkipped
+		}
+	}
+	job, err := s.repo.Create(StoredJob{
+		BatchJob: apperr.BatchJob{Name: req.Name, Status: JobPaused, Request: req},
+		Header:   header,
+	}, items)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	s.logger.Info(fmt.Sprintf("[%s] created job %s with %d items from %s", op, job.ID, len(items), req.Source.Path))
+	return &job.BatchJob, nil
+}
+
+func (s *BatchService) List() ([]apperr.BatchJob, error) {
+	if s.repo == nil {
+		return nil, errNoRepository()
+	}
+	return s.repo.List()
+}
+
+func (s *BatchService) Get(id string) (*apperr.BatchJob, error) {
+	if s.repo == nil {
+		return nil, errNoRepository()
+	}
+	job, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return &job.BatchJob, nil
+}
+
+func (s *BatchService) Items(jobID string, limit, offset int64) ([]apperr.BatchItem, error) {
+	if s.repo == nil {
+		return nil, errNoRepository()
+	}
+	stored, err := s.repo.Items(jobID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]apperr.BatchItem, len(stored))
+	for i, item := range stored {
+		items[i] = item.BatchItem
+	}
+	return items, nil
+}
+
+// Start takes the inference gate and runs the job's pending items in the
+// background, AppBehaviorConfig.BatchConcurrency (or the job's own
+// concurrency) at a time. A job that is already running is a validation error;
+// a held gate is apperr.Busy.
+func (s *BatchService) Start(id string, emit func(apperr.BatchProgress)) (*apperr.BatchJob, error) {
+	const op = "BatchService.Start"
+	if s.repo == nil {
+		return nil, errNoRepository()
+	}
+	job, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.isActive(id) {
+		return nil, apperr.Validation("id", "a job that is not running", id)
+	}
+	concurrency := job.Request.Concurrency
+	if concurrency == 0 {
+		cfg, err := s.settings.GetAppBehaviorConfig()
+		if err != nil {
+			return nil, fmt.Errorf("%s: get config: %w", op, err)
+		}
+		concurrency = cfg.BatchConcurrency
+	}
+	if concurrency <= 0 {
+		concurrency = settings.DefaultBatchConcurrency
+	}
+
+	if !s.gate.TryAcquire() {
+		return nil, apperr.Busy()
+	}
+	if err := s.repo.SetStatus(id, JobRunning, job.OutputPath, ""); err != nil {
+		s.gate.Release()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.active[id] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	logging.SafeGo(s.logger, op, func() {
+		defer s.wg.Done()
+		defer s.gate.Release()
+		defer func() {
+			s.mu.Lock()
+			delete(s.active, id)
+			s.mu.Unlock()
+			cancel()
+		}()
+		s.run(ctx, *job, concurrency, emit)
+	})
+	s.logger.Info(fmt.Sprintf("[%s] started job %s, %d items at a time", op, id, concurrency))
+	return s.Get(id)
+}
+
+// run processes the job's pending items and, unless it was paused, writes the
+// outputs and completes the job.
+func (s *BatchService) run(ctx context.Context, job StoredJob, concurrency int, emit func(apperr.BatchProgress)) {
+	const op = "BatchService.run"
+	s.emitProgress(job.ID, emit)
+
+	pending, err := s.repo.ItemsByStatus(job.ID, ItemPending)
+	if err != nil {
+		s.fail(job, fmt.Errorf("%s: %w", op, err), emit)
+		return
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+loop:
+	for _, item := range pending {
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.runItem(ctx, job, item.BatchItem)
+			s.emitProgress(job.ID, emit)
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		if err := s.repo.SetStatus(job.ID, JobPaused, job.OutputPath, ""); err != nil {
+			s.logger.Warning(fmt.Sprintf("[%s] pause job %s: %v", op, job.ID, err))
+		}
+		s.emitProgress(job.ID, emit)
+		return
+	}
+
+	items, err := s.repo.Items(job.ID, -1, 0)
+	if err != nil {
+		s.fail(job, fmt.Errorf("%s: %w", op, err), emit)
+		return
+	}
+	path, err := writeOutputs(job, items)
+	if err != nil {
+		s.fail(job, err, emit)
+		return
+	}
+	if err := s.repo.SetStatus(job.ID, JobCompleted, path, ""); err != nil {
+		s.logger.Warning(fmt.Sprintf("[%s] complete job %s: %v", op, job.ID, err))
+	}
+	s.logger.Info(fmt.Sprintf("[%s] completed job %s, outputs in %s", op, job.ID, path))
+	s.emitProgress(job.ID, emit)
+}
+
+// runItem runs the job's chain on one item and stores the outcome. An item
+// interrupted by a pause goes back to pending.
+func (s *BatchService) runItem(ctx context.Context, job StoredJob, item apperr.BatchItem) {
+	const op = "BatchService.runItem"
+	defer func() {
+		if r := recover(); r != nil {
+			item.Status, item.ErrorCode, item.Error = ItemFailed, string(apperr.CodeInternal), fmt.Sprintf("panic: %v", r)
+			if err := s.repo.FinishItem(item); err != nil {
+				s.logger.Warning(fmt.Sprintf("[%s] store item %d of job %s: %v", op, item.Index, job.ID, err))
+			}
+		}
+	}()
+	if err := s.repo.StartItem(job.ID, item.Index); err != nil {
+		s.logger.Warning(fmt.Sprintf("[%s] start item %d of job %s: %v", op, item.Index, job.ID, err))
+		return
+	}
+
+	req := job.Request
+	result, err := s.runner.RunChain(ctx, apperr.ChainRequest{
+		RunID:            fmt.Sprintf("%s#%d", job.ID, item.Index),
+		InputText:        item.InputText,
+		Steps:            req.Steps,
+		InputLanguageID:  req.InputLanguageID,
+		OutputLanguageID: req.OutputLanguageID,
+		UseMarkdown:      req.UseMarkdown,
+		StrictOrder:      req.StrictOrder,
+		SkipHistory:      true,
+	}, nil)
+
+	item.OutputText, item.ErrorCode, item.Error = "", "", ""
+	switch {
+	case err == nil:
+		item.Status = ItemDone
+		item.OutputText = result.FinalText
+	case ctx.Err() != nil:
+		item.Status = ItemPending
+	default:
+		item.Status = ItemFailed
+		var ae *apperr.AppError
+		if errors.As(err, &ae) {
+			item.ErrorCode, item.Error = string(ae.Code), ae.Message
+		} else {
+			item.ErrorCode, item.Error = string(apperr.CodeInternal), err.Error()
+		}
+	}
+	if err := s.repo.FinishItem(item); err != nil {
+		s.logger.Warning(fmt.Sprintf("[%s] store item %d of job %s: %v", op, item.Index, job.ID, err))
+	}
+}
+
+// fail marks the job failed with err.
+func (s *BatchService) fail(job StoredJob, err error, emit func(apperr.BatchProgress)) {
+	const op = "BatchService.fail"
+	s.logger.Error(fmt.Sprintf("[%s] job %s: %v", op, job.ID, err))
+	if setErr := s.repo.SetStatus(job.ID, JobFailed, job.OutputPath, err.Error()); setErr != nil {
+		s.logger.Warning(fmt.Sprintf("[%s] store status of job %s: %v", op, job.ID, setErr))
+	}
+	s.emitProgress(job.ID, emit)
+}
+
+// emitProgress sends the job's current status and counts to emit.
+func (s *BatchService) emitProgress(id string, emit func(apperr.BatchProgress)) {
+	if emit == nil {
+		return
+	}
+	job, err := s.repo.Get(id)
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("[BatchService.emitProgress] get job %s: %v", id, err))
+		return
+	}
+	emit(apperr.BatchProgress{
+		JobID:      job.ID,
+		Status:     job.Status,
+		Counts:     job.Counts,
+		OutputPath: job.OutputPath,
+		Error:      job.Error,
+	})
+}
+
+func (s *BatchService) isActive(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.active[id]
+	return ok
+}
+
+// Pause stops a running job: items in flight are cancelled and go back to
+// pending. The job is paused at once; its background run winds down after.
+func (s *BatchService) Pause(id string) (*apperr.BatchJob, error) {
+	const op = "BatchService.Pause"
+	if s.repo == nil {
+		return nil, errNoRepository()
+	}
+	s.mu.Lock()
+	cancel, ok := s.active[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, apperr.Validation("id", "a running job", id)
+	}
+	cancel()
+	job, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetStatus(id, JobPaused, job.OutputPath, ""); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s.Get(id)
+}
+
+// RetryFailed puts the job's failed items back to pending and starts the job.
+func (s *BatchService) RetryFailed(id string, emit func(apperr.BatchProgress)) (*apperr.BatchJob, error) {
+	const op = "BatchService.RetryFailed"
+	if s.repo == nil {
+		return nil, errNoRepository()
+	}
+	job, err := s.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.isActive(id) {
+		return nil, apperr.Validation("id", "a job that is not running", id)
+	}
+	if job.Counts.Failed == 0 {
+		return nil, apperr.Validation("id", "a job with failed items", id)
+	}
+	if err := s.repo.ResetItems(id, ItemFailed); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s.Start(id, emit)
+}
+
+// Delete removes a job that is not running, with its items. Outputs already
+// written stay.
+func (s *BatchService) Delete(id string) error {
+	if s.repo == nil {
+		return errNoRepository()
+	}
+	if s.isActive(id) {
+		return apperr.Validation("id", "a job that is not running", id)
+	}
+	return s.repo.Delete(id)
+}
+
+// PauseAll cancels every running job and waits for their runs to stop, leaving
+// them paused. Called on shutdown, before the database closes.
+func (s *BatchService) PauseAll() {
+	s.mu.Lock()
+	for _, cancel := range s.active {
+		cancel()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
@@ -0,0 +1,291 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/gate"
+	"go_text/internal/logging"
+	"go_text/internal/settings"
+)
+
+// fakeRunner upper-cases its input, fails inputs containing "fail", and blocks
+// on inputs containing "block" until its context is cancelled.
+type fakeRunner struct {
+	mu   sync.Mutex
+	reqs []apperr.ChainRequest
+}
+
+func (f *fakeRunner) RunChain(ctx context.Context, req apperr.ChainRequest, _ func(apperr.StepProgress)) (*apperr.ChainResult, error) {
+	f.mu.Lock()
+	f.reqs = append(f.reqs, req)
+	f.mu.Unlock()
+	switch {
+	case strings.Contains(req.InputText, "block"):
+		<-ctx.Done()
+		return nil, apperr.Cancelled(0)
+	case strings.Contains(req.InputText, "fail"):
+		return nil, apperr.Timeout("fake", 1, nil)
+	}
+	return &apperr.ChainResult{FinalText: strings.ToUpper(req.InputText)}, nil
+}
+
+type fakeSettings struct{}
+
+func (fakeSettings) GetAppBehaviorConfig() (*settings.AppBehaviorConfig, error) {
+	return &settings.AppBehaviorConfig{BatchConcurrency: 2}, nil
+}
+
+func newTestService(t *testing.T) (*BatchService, *fakeRunner, *gate.InferenceGate) {
+	t.Helper()
+	wlog, err := logging.New(logging.DefaultConfig(), false)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	runner := &fakeRunner{}
+	g := gate.New()
+	svc := NewBatchService(wlog, runner, fakeSettings{}, g)
+	if err := svc.SetRepository(newBatchRepo(t)); err != nil {
+		t.Fatalf("SetRepository: %v", err)
+	}
+	t.Cleanup(svc.PauseAll)
+	return svc, runner, g
+}
+
+func filesRequest(dir string) apperr.BatchJobRequest {
+	return apperr.BatchJobRequest{
+		Steps:       []apperr.ChainStep{{ActionID: "proofread"}},
+		Source:      apperr.BatchSource{Kind: SourceFiles, Path: filepath.Join(dir, "*.md")},
+		Destination: apperr.BatchDestination{Kind: DestinationSibling},
+	}
+}
+
+// progressRecorder collects progress events and signals the first one with
+// a final status.
+type progressRecorder struct {
+	mu     sync.Mutex
+	events []apperr.BatchProgress
+	done   chan apperr.BatchProgress
+}
+
+func newProgressRecorder() *progressRecorder {
+	return &progressRecorder{done: make(chan apperr.BatchProgress, 1)}
+}
+
+func (p *progressRecorder) emit(e apperr.BatchProgress) {
+	p.mu.Lock()
+	p.events = append(p.events, e)
+	p.mu.Unlock()
+	if e.Status == JobCompleted || e.Status == JobFailed {
+		select {
+		case p.done <- e:
+		default:
+		}
+	}
+}
+
+func (p *progressRecorder) wait(t *testing.T) apperr.BatchProgress {
+	t.Helper()
+	select {
+	case e := <-p.done:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not finish")
+		return apperr.BatchProgress{}
+	}
+}
+
+// waitForCounts polls the job until its counts satisfy ok.
+func waitForCounts(t *testing.T, svc *BatchService, id string, ok func(apperr.BatchCounts) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := svc.Get(id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if ok(job.Counts) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("job counts never reached the expected state")
+}
+
+func TestBatchService_RunToCompletion(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "alpha")
+	writeFile(t, filepath.Join(dir, "b.md"), "fail me")
+	writeFile(t, filepath.Join(dir, "c.md"), " ")
+	svc, runner, g := newTestService(t)
+
+	job, err := svc.Create(filesRequest(dir))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if job.Status != JobPaused || job.Name != "*.md" {
+		t.Errorf("created job = %+v, want paused *.md", job)
+	}
+	rec := newProgressRecorder()
+	if _, err := svc.Start(job.ID, rec.emit); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	final := rec.wait(t)
+
+	if final.Status != JobCompleted || final.OutputPath != dir {
+		t.Errorf("final progress = %+v, want completed in %s", final, dir)
+	}
+	want := apperr.BatchCounts{Total: 3, Done: 1, Failed: 1, Skipped: 1}
+	if final.Counts != want {
+		t.Errorf("counts = %+v, want %+v", final.Counts, want)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "a.out.md"))
+	if err != nil || string(data) != "ALPHA" {
+		t.Errorf("a.out.md = %q, %v; want ALPHA", data, err)
+	}
+	items, _ := svc.Items(job.ID, -1, 0)
+	if items[1].Status != ItemFailed || items[1].ErrorCode != string(apperr.CodeTimeout) {
+		t.Errorf("failed item = %+v, want timeout", items[1])
+	}
+	for _, req := range runner.reqs {
+		if !req.SkipHistory || !strings.HasPrefix(req.RunID, job.ID+"#") {
+			t.Errorf("chain request = %+v, want SkipHistory and a job run ID", req)
+		}
+	}
+
+	svc.PauseAll()
+	if !g.TryAcquire() {
+		t.Error("gate still held after the job finished")
+	}
+}
+
+func TestBatchService_Start_Busy(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "alpha")
+	svc, _, g := newTestService(t)
+	job, err := svc.Create(filesRequest(dir))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	g.TryAcquire()
+	_, err = svc.Start(job.ID, nil)
+	var ae *apperr.AppError
+	if !errors.As(err, &ae) || ae.Code != apperr.CodeBusy {
+		t.Fatalf("Start with held gate: err = %v, want busy", err)
+	}
+	got, _ := svc.Get(job.ID)
+	if got.Status != JobPaused {
+		t.Errorf("status = %q, want paused", got.Status)
+	}
+}
+
+func TestBatchService_PauseResumeRetry(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "block")
+	writeFile(t, filepath.Join(dir, "b.md"), "fail")
+	svc, runner, _ := newTestService(t)
+	job, err := svc.Create(filesRequest(dir))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := svc.Start(job.ID, nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := svc.Start(job.ID, nil); err == nil {
+		t.Error("second Start of a running job succeeded")
+	}
+	if err := svc.Delete(job.ID); err == nil {
+		t.Error("Delete of a running job succeeded")
+	}
+	waitForCounts(t, svc, job.ID, func(c apperr.BatchCounts) bool { return c.Failed == 1 && c.Running == 1 })
+	paused, err := svc.Pause(job.ID)
+	if err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if paused.Status != JobPaused {
+		t.Errorf("status after Pause = %q, want paused", paused.Status)
+	}
+	svc.PauseAll()
+
+	got, _ := svc.Get(job.ID)
+	if got.Status != JobPaused || got.Counts.Pending != 1 || got.Counts.Failed != 1 {
+		t.Fatalf("job after pause = %+v, want the blocked item pending again", got)
+	}
+
+	writeFile(t, filepath.Join(dir, "a.md"), "changed on disk")
+	runner.mu.Lock()
+	runner.reqs = nil
+	runner.mu.Unlock()
+	if _, err := svc.RetryFailed(job.ID, nil); err != nil {
+		t.Fatalf("RetryFailed: %v", err)
+	}
+	waitForCounts(t, svc, job.ID, func(c apperr.BatchCounts) bool { return c.Failed == 1 && c.Running == 1 })
+	if _, err := svc.Pause(job.ID); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	svc.PauseAll()
+
+	// Both items ran again, from the snapshot taken at Create rather than the
+	// file as it is now.
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+	var inputs []string
+	for _, req := range runner.reqs {
+		inputs = append(inputs, req.InputText)
+	}
+	slices.Sort(inputs)
+	if !slices.Equal(inputs, []string{"block", "fail"}) {
+		t.Errorf("rerun inputs = %q, want [block fail]", inputs)
+	}
+}
+
+func TestBatchService_Create_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "alpha")
+	svc, _, _ := newTestService(t)
+
+	noSteps := filesRequest(dir)
+	noSteps.Steps = nil
+	tooWide := filesRequest(dir)
+	tooWide.Concurrency = settings.BatchConcurrencyUpperBound + 1
+
+	tests := []struct {
+		name      string
+		req       apperr.BatchJobRequest
+		wantField string
+	}{
+		{"no steps", noSteps, "steps"},
+		{"concurrency above bound", tooWide, "concurrency"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Create(tt.req)
+			assertValidation(t, err, tt.wantField)
+		})
+	}
+}
+
+func TestBatchService_NoRepository(t *testing.T) {
+	wlog, err := logging.New(logging.DefaultConfig(), false)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	svc := NewBatchService(wlog, &fakeRunner{}, fakeSettings{}, gate.New())
+
+	if _, err := svc.List(); err == nil {
+		t.Error("List without a repository succeeded")
+	}
+	if _, err := svc.Create(apperr.BatchJobRequest{}); err == nil {
+		t.Error("Create without a repository succeeded")
+	}
+}
@@ -0,0 +1,230 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go_text/internal/apperr"
+)
+
+// Source kinds of apperr.BatchSource.
+const (
+	SourceFiles = "files"
+	SourceCSV   = "csv"
+	SourceJSONL = "jsonl"
+)
+
+// Limits on what a job reads from its source, so a mistyped glob cannot load a
+// whole disk into the database.
+const (
+	maxItems       = 10000
+	maxFileBytes   = 1 << 20
+	maxSourceBytes = 64 << 20
+)
+
+// sourceItem is one item read from a source; see StoredItem for record. skip
+// says why the item has nothing to run on, and is "" when it has.
+type sourceItem struct {
+	key    string
+	input  string
+	record string
+	skip   string
+}
+
+// readSource reads every item of src, plus the header of a CSV source. Items
+// with blank input are kept, marked skipped, so outputs stay aligned with the
+// source. For a "files" source with sibling outputs, files that are themselves
+// sibling outputs of an earlier run are left out.
+func readSource(src apperr.BatchSource, dst apperr.BatchDestination) ([]sourceItem, []string, error) {
+	if strings.TrimSpace(src.Path) == "" {
+		return nil, nil, apperr.Validation("source.path", "be non-empty", "empty string")
+	}
+	var (
+		items  []sourceItem
+		header []string
+		err    error
+	)
+	switch src.Kind {
+	case SourceFiles:
+		items, err = readFiles(src, dst)
+	case SourceCSV:
+		items, header, err = readCSV(src)
+	case SourceJSONL:
+		items, err = readJSONL(src)
+	default:
+		return nil, nil, apperr.Validation("source.kind",
+			fmt.Sprintf("one of %s, %s, %s", SourceFiles, SourceCSV, SourceJSONL), src.Kind)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, nil, apperr.Validation("source", "at least one item", "none")
+	}
+	if len(items) > maxItems {
+		return nil, nil, apperr.Validation("source", fmt.Sprintf("at most %d items", maxItems), fmt.Sprint(len(items)))
+	}
+	total := 0
+	for i := range items {
+		total += len(items[i].input) + len(items[i].record)
+		if items[i].skip == "" && strings.TrimSpace(items[i].input) == "" {
+			items[i].skip = "the input is empty"
+		}
+	}
+	if total > maxSourceBytes {
+		return nil, nil, apperr.Validation("source", fmt.Sprintf("at most %d MiB of text", maxSourceBytes>>20),
+			fmt.Sprintf("%d MiB", total>>20))
+	}
+	return items, header, nil
+}
+
+// readFiles reads every regular file matching the glob src.Path, in name order.
+// Each item is keyed by the file's absolute path.
+func readFiles(src apperr.BatchSource, dst apperr.BatchDestination) ([]sourceItem, error) {
+	matches, err := filepath.Glob(src.Path)
+	if err != nil {
+		return nil, apperr.Validation("source.path", "a valid glob pattern", src.Path)
+	}
+	suffix := ""
+	if dst.Kind == DestinationSibling {
+		suffix = siblingSuffix(dst)
+	}
+
+	var items []sourceItem
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if suffix != "" && strings.HasSuffix(strings.TrimSuffix(match, filepath.Ext(match)), suffix) {
+			continue
+		}
+		if info.Size() > maxFileBytes {
+			return nil, apperr.Validation("source.path", fmt.Sprintf("files of at most %d KiB", maxFileBytes>>10), match)
+		}
+		path, err := filepath.Abs(match)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", match, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		item := sourceItem{key: path, input: string(data)}
+		if !utf8.Valid(data) {
+			item.input = ""
+			item.skip = "the file is not UTF-8 text"
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// readCSV reads column src.Column of every row of the CSV file src.Path after
+// the header row. Items are keyed "row N", counting data rows from 1.
+func readCSV(src apperr.BatchSource) ([]sourceItem, []string, error) {
+	if src.Column == "" {
+		return nil, nil, apperr.Validation("source.column", "be non-empty", "empty string")
+	}
+	data, err := readSourceFile(src.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, apperr.Validation("source.path", "a valid CSV file", err.Error())
+	}
+	if len(rows) == 0 {
+		return nil, nil, apperr.Validation("source.path", "a CSV file with a header row", "an empty file")
+	}
+	header := rows[0]
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	col := slices.Index(header, src.Column)
+	if col < 0 {
+		return nil, nil, apperr.Validation("source.column", "a column of the CSV header", src.Column)
+	}
+
+	items := make([]sourceItem, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		record, err := json.Marshal(row)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encode row %d: %w", i+1, err)
+		}
+		item := sourceItem{key: fmt.Sprintf("row %d", i+1), record: string(record)}
+		if col < len(row) {
+			item.input = row[col]
+		}
+		items = append(items, item)
+	}
+	return items, header, nil
+}
+
+// readJSONL reads the string field src.Field of every record of the JSON Lines
+// file src.Path. Blank lines are ignored; items are keyed "line N". A record
+// without the field, or with a field that is not a string, is skipped.
+func readJSONL(src apperr.BatchSource) ([]sourceItem, error) {
+	if src.Field == "" {
+		return nil, apperr.Validation("source.field", "be non-empty", "empty string")
+	}
+	data, err := readSourceFile(src.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []sourceItem
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(line), &record); err != nil || record == nil {
+			return nil, apperr.Validation("source.path", "one JSON object per line", fmt.Sprintf("line %d", n+1))
+		}
+		item := sourceItem{key: fmt.Sprintf("line %d", n+1), record: line}
+		raw, ok := record[src.Field]
+		switch {
+		case !ok:
+			item.skip = fmt.Sprintf("the record has no %q field", src.Field)
+		case json.Unmarshal(raw, &item.input) != nil:
+			item.skip = fmt.Sprintf("the %q field is not a string", src.Field)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// readSourceFile reads a CSV or JSONL source file of at most maxSourceBytes; a
+// missing or larger file is a validation error.
+func readSourceFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, apperr.Validation("source.path", "an existing file", path)
+		}
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxSourceBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if len(data) > maxSourceBytes {
+		return nil, apperr.Validation("source.path", fmt.Sprintf("a file of at most %d MiB", maxSourceBytes>>20), path)
+	}
+	return data, nil
+}
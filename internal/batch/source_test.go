@@ -0,0 +1,141 @@
+package batch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func assertValidation(t *testing.T, err error, field string) {
+	t.Helper()
+	var ae *apperr.AppError
+	if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+		t.Fatalf("err = %v, want a validation error", err)
+	}
+	if ae.Details["field"] != field {
+		t.Errorf("field = %q, want %q", ae.Details["field"], field)
+	}
+}
+
+func TestReadSource_Files(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "alpha")
+	writeFile(t, filepath.Join(dir, "b.md"), "  \n")
+	writeFile(t, filepath.Join(dir, "c.md"), "\xff\xfe")
+	writeFile(t, filepath.Join(dir, "a.out.md"), "earlier output")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "not matched")
+	if err := os.Mkdir(filepath.Join(dir, "d.md"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	items, header, err := readSource(
+		apperr.BatchSource{Kind: SourceFiles, Path: filepath.Join(dir, "*.md")},
+		apperr.BatchDestination{Kind: DestinationSibling},
+	)
+	if err != nil {
+		t.Fatalf("readSource: %v", err)
+	}
+	if header != nil {
+		t.Errorf("header = %v, want nil", header)
+	}
+	want := []sourceItem{
+		{key: filepath.Join(dir, "a.md"), input: "alpha"},
+		{key: filepath.Join(dir, "b.md"), input: "  \n", skip: "the input is empty"},
+		{key: filepath.Join(dir, "c.md"), skip: "the file is not UTF-8 text"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("items = %+v, want %+v", items, want)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("items[%d] = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+}
+
+func TestReadSource_CSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.csv")
+	writeFile(t, path, "\ufeffsku,description\n1,\"Soft, warm\"\n2,\n3\n")
+
+	items, header, err := readSource(apperr.BatchSource{Kind: SourceCSV, Path: path, Column: "description"}, apperr.BatchDestination{})
+	if err != nil {
+		t.Fatalf("readSource: %v", err)
+	}
+	if len(header) != 2 || header[0] != "sku" || header[1] != "description" {
+		t.Errorf("header = %q, want [sku description]", header)
+	}
+	want := []sourceItem{
+		{key: "row 1", input: "Soft, warm", record: `["1","Soft, warm"]`},
+		{key: "row 2", record: `["2",""]`, skip: "the input is empty"},
+		{key: "row 3", record: `["3"]`, skip: "the input is empty"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("items = %+v, want %+v", items, want)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("items[%d] = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+}
+
+func TestReadSource_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	writeFile(t, path, "{\"id\":1,\"text\":\"hello\"}\n\n{\"id\":2}\n{\"text\":3}\n")
+
+	items, _, err := readSource(apperr.BatchSource{Kind: SourceJSONL, Path: path, Field: "text"}, apperr.BatchDestination{})
+	if err != nil {
+		t.Fatalf("readSource: %v", err)
+	}
+	want := []sourceItem{
+		{key: "line 1", input: "hello", record: `{"id":1,"text":"hello"}`},
+		{key: "line 3", record: `{"id":2}`, skip: `the record has no "text" field`},
+		{key: "line 4", record: `{"text":3}`, skip: `the "text" field is not a string`},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("items = %+v, want %+v", items, want)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("items[%d] = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+}
+
+func TestReadSource_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "in.csv")
+	writeFile(t, csvPath, "a,b\n1,2\n")
+	jsonlPath := filepath.Join(dir, "in.jsonl")
+	writeFile(t, jsonlPath, "[1,2]\n")
+
+	tests := []struct {
+		name      string
+		src       apperr.BatchSource
+		wantField string
+	}{
+		{"empty path", apperr.BatchSource{Kind: SourceFiles}, "source.path"},
+		{"unknown kind", apperr.BatchSource{Kind: "xml", Path: csvPath}, "source.kind"},
+		{"no matches", apperr.BatchSource{Kind: SourceFiles, Path: filepath.Join(dir, "*.md")}, "source"},
+		{"missing csv column", apperr.BatchSource{Kind: SourceCSV, Path: csvPath}, "source.column"},
+		{"unknown csv column", apperr.BatchSource{Kind: SourceCSV, Path: csvPath, Column: "c"}, "source.column"},
+		{"missing file", apperr.BatchSource{Kind: SourceCSV, Path: filepath.Join(dir, "nope.csv"), Column: "a"}, "source.path"},
+		{"jsonl line not an object", apperr.BatchSource{Kind: SourceJSONL, Path: jsonlPath, Field: "text"}, "source.path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := readSource(tt.src, apperr.BatchDestination{})
+			assertValidation(t, err, tt.wantField)
+		})
+	}
+}
@@ -218,11 +218,12 @@ func seedDefaults(ctx context.Context, q *store.Queries) error {
 	return nil
 }
 
-// wipeAllTables deletes all rows from entity and settings tables.
+// wipeAllTables deletes all rows from entity and settings tables; batch items
+// and checkpoint groups go with their parents through ON DELETE CASCADE.
 // Table names are hardcoded (not user-supplied) so no injection risk.
 func wipeAllTables(ctx context.Context, tx *sql.Tx) error {
 	tables := []string{
-		"history", "stack_steps", "stacks",
+		"history", "batch_jobs", "chain_checkpoints", "stack_steps", "stacks",
 		"app_state", "providers", "languages", "settings",
 	}
 	for _, t := range tables {
@@ -355,7 +356,7 @@ func seedLanguages(ctx context.Context, q *store.Queries) error {
 	return nil
 }
 
-// seedSettings inserts all 28 default KV rows from the §A.6 catalog.
+// seedSettings inserts the default KV rows from the §A.6 catalog.
 func seedSettings(ctx context.Context, q *store.Queries) error {
 	rows := []store.UpsertSettingParams{
 		{Key: "inference.timeout", Value: "60", Type: "int"},
@@ -386,6 +387,13 @@ func seedSettings(ctx context.Context, q *store.Queries) error {
 		{Key: "log.compress", Value: "false", Type: "bool"},
 		{Key: "history.enabled", Value: "true", Type: "bool"},
 		{Key: "history.maxEntries", Value: "100", Type: "int"},
+		{Key: "plan.maxSteps", Value: "5", Type: "int"},
+		{Key: "plan.maxInferences", Value: "3", Type: "int"},
+		{Key: "chain.maxParallelBranches", Value: "3", Type: "int"},
+		{Key: "chain.languageCheck", Value: "warn", Type: "string"},
+		{Key: "chain.cleanOutput", Value: "false", Type: "bool"},
+		{Key: "batch.concurrency", Value: "2", Type: "int"},
+		{Key: "export.fileNameTemplate", Value: "{name} {date}", Type: "string"},
 	}
 	for _, r := range rows {
 		if err := q.UpsertSetting(ctx, r); err != nil {
@@ -40,10 +40,10 @@ func TestOpen_FreshDB_MigratesAndSeeds(t *testing.T) {
 	assert.Contains(t, langs, "English")
 	assert.Contains(t, langs, "Ukrainian")
 
-	// Settings: 28 defaults seeded
+	// Settings: 35 defaults seeded
 	settings, err := database.Queries.ListSettings(ctx)
 	require.NoError(t, err)
-	assert.Len(t, settings, 28)
+	assert.Len(t, settings, 35)
 
 	// app_state: current provider is set, and it is the Ollama provider.
 	provID, err := database.Queries.GetCurrentProviderID(ctx)
@@ -180,7 +180,7 @@ func TestSeed_FactoryReset_RepopulatesDefaults(t *testing.T) {
 
 	settings, err := database.Queries.ListSettings(ctx)
 	require.NoError(t, err)
-	assert.Len(t, settings, 28)
+	assert.Len(t, settings, 35)
 
 	langs, err := database.Queries.ListLanguages(ctx)
 	require.NoError(t, err)
@@ -195,6 +195,38 @@ func TestSeed_FactoryReset_RepopulatesDefaults(t *testing.T) {
 	assert.Equal(t, "ollama", current.Kind, "Ollama must be the default current provider after reset")
 }
 
+func TestSeed_FactoryReset_WipesUserContent(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "reset-content.db")
+
+	database, err := Open(dbPath)
+	require.NoError(t, err)
+	defer database.Close()
+
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		`INSERT INTO batch_jobs (id, name, status, spec, created_at, updated_at)
+		 VALUES ('job-1', 'job', 'completed', '{}', 1, 1)`,
+		`INSERT INTO batch_items (job_id, position, item_key, input_text, status, updated_at)
+		 VALUES ('job-1', 0, 'a', 'private text', 'done', 1)`,
+		`INSERT INTO chain_checkpoints (run_id, request, plan, created_at, updated_at)
+		 VALUES ('run-1', '{}', '{}', 1, 1)`,
+		`INSERT INTO chain_checkpoint_groups (run_id, group_index, output_text)
+		 VALUES ('run-1', 0, 'private text')`,
+	} {
+		_, err = database.DB.ExecContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, database.Seed(ctx))
+
+	for _, table := range []string{"batch_jobs", "batch_items", "chain_checkpoints", "chain_checkpoint_groups"} {
+		var n int
+		require.NoError(t, database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n))
+		assert.Zero(t, n, "%s must be empty after a factory reset", table)
+	}
+}
+
 func TestSeed_Idempotent_WhenCalledTwice(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "idempotent.db")
 
@@ -236,3 +268,34 @@ func TestOpen_LockReleased_AfterClose_AllowsReopen(t *testing.T) {
 	require.NoError(t, err)
 	defer db2.Close()
 }
+
+// TestMigration_StackStepParamsBackfillsExistingRows proves migration 0005 gives
+// steps saved before per-step params existed the empty JSON object.
+func TestMigration_StackStepParamsBackfillsExistingRows(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "step_params.db")
+
+	database, err := Open(dbPath)
+	require.NoError(t, err)
+	defer database.Close()
+
+	ctx := context.Background()
+
+	_, err = database.provider.DownTo(ctx, 4)
+	require.NoError(t, err)
+
+	_, err = database.DB.ExecContext(ctx,
+		"INSERT INTO stacks (id, name, icon, created_at, updated_at) VALUES ('s1', 'Legacy', 'star', 0, 0)")
+	require.NoError(t, err)
+	_, err = database.DB.ExecContext(ctx,
+		"INSERT INTO stack_steps (stack_id, position, action_id) VALUES ('s1', 0, 'prompteng.image')")
+	require.NoError(t, err)
+
+	_, err = database.provider.Up(ctx)
+	require.NoError(t, err)
+
+	steps, err := database.Queries.GetStackSteps(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "prompteng.image", steps[0].ActionID)
+	assert.Equal(t, "{}", steps[0].Params)
+}
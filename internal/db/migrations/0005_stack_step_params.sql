@@ -0,0 +1,12 @@
+-- +goose Up
+-- Per-step parameters for saved stacks (target model, goal, and the typed
+-- ActionMeta.Params values) stored as a JSON object. Existing rows are migrated
+-- to the empty object, which is exactly what they ran with before.
+-- +goose StatementBegin
+ALTER TABLE stack_steps ADD COLUMN params TEXT NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stack_steps DROP COLUMN params;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Per-stack "strict order" flag: when set, the planner keeps the saved step order
+-- instead of sorting canonically, and merges only adjacent compatible steps.
+-- Plan limits (plan.maxSteps / plan.maxInferences) become user settings; the
+-- defaults match the previously hard-coded 5 steps / 3 inferences.
+-- +goose StatementBegin
+ALTER TABLE stacks ADD COLUMN strict_order INTEGER NOT NULL DEFAULT 0;
+INSERT OR IGNORE INTO settings (key, value, type) VALUES ('plan.maxSteps', '5', 'int');
+INSERT OR IGNORE INTO settings (key, value, type) VALUES ('plan.maxInferences', '3', 'int');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM settings WHERE key IN ('plan.maxSteps', 'plan.maxInferences');
+ALTER TABLE stacks DROP COLUMN strict_order;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Fan-out stacks: a shared prefix (steps with branch = '') runs once, then each
+-- named branch runs on its output. Branch order is the order of first position.
+-- History gains the run ID that links the per-branch entries of one fan-out run;
+-- existing rows were one entry per run, keyed by that run's ID.
+-- +goose StatementBegin
+ALTER TABLE stacks ADD COLUMN kind TEXT NOT NULL DEFAULT 'linear' CHECK (kind IN ('linear','fanout'));
+ALTER TABLE stack_steps ADD COLUMN branch TEXT NOT NULL DEFAULT '';
+ALTER TABLE history ADD COLUMN run_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE history ADD COLUMN branch TEXT NOT NULL DEFAULT '';
+UPDATE history SET run_id = id;
+CREATE INDEX idx_history_run ON history(run_id);
+INSERT OR IGNORE INTO settings (key, value, type) VALUES ('chain.maxParallelBranches', '3', 'int');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM settings WHERE key = 'chain.maxParallelBranches';
+DROP INDEX idx_history_run;
+ALTER TABLE history DROP COLUMN branch;
+ALTER TABLE history DROP COLUMN run_id;
+ALTER TABLE stack_steps DROP COLUMN branch;
+ALTER TABLE stacks DROP COLUMN kind;
+-- +goose StatementEnd
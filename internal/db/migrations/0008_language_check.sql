@@ -0,0 +1,10 @@
+-- +goose Up
+-- Output-language verification after each chain group: off, warn or fail.
+-- +goose StatementBegin
+INSERT OR IGNORE INTO settings (key, value, type) VALUES ('chain.languageCheck', 'warn', 'string');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM settings WHERE key = 'chain.languageCheck';
+-- +goose StatementEnd
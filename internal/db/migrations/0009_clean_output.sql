@@ -0,0 +1,12 @@
+-- +goose Up
+-- Deterministic cleanup of model chatter (preambles, postambles, wrapping
+-- fences and quotes) after each inference; off by default, since its rules can
+-- remove closings and intro lines that belong to the result.
+-- +goose StatementBegin
+INSERT OR IGNORE INTO settings (key, value, type) VALUES ('chain.cleanOutput', 'false', 'bool');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM settings WHERE key = 'chain.cleanOutput';
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Share of the input's words a run changed (word-level diff of input and output,
+-- Markdown formatting ignored). Rows recorded before this column existed read 0.
+-- +goose StatementBegin
+ALTER TABLE history ADD COLUMN change_ratio REAL NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE history DROP COLUMN change_ratio;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Byte range of input_text a selection-scoped run transformed; output_text is the
+-- whole document with only that range replaced. -1 when the run covered the
+-- whole input, including rows recorded before these columns existed.
+-- +goose StatementBegin
+ALTER TABLE history ADD COLUMN selection_start INTEGER NOT NULL DEFAULT -1;
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE history ADD COLUMN selection_end INTEGER NOT NULL DEFAULT -1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE history DROP COLUMN selection_end;
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE history DROP COLUMN selection_start;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- Batch jobs run one chain over many inputs. spec is the job's request as JSON,
+-- with its stack resolved to steps so later stack edits do not change the job.
+-- header holds the source CSV header (JSON array) for CSV jobs, '' otherwise.
+-- Items are read from the source once, when the job is created: input_text is
+-- what the chain runs on, record the source row (CSV: JSON array of cells;
+-- JSONL: the raw line) the output is written back into.
+-- +goose StatementBegin
+CREATE TABLE batch_jobs (
+  id          TEXT PRIMARY KEY,
+  name        TEXT NOT NULL,
+  status      TEXT NOT NULL CHECK (status IN ('running','paused','completed','failed')),
+  spec        TEXT NOT NULL,
+  header      TEXT NOT NULL DEFAULT '',
+  output_path TEXT NOT NULL DEFAULT '',
+  error       TEXT NOT NULL DEFAULT '',
+  created_at  INTEGER NOT NULL,
+  updated_at  INTEGER NOT NULL
+);
+
+CREATE TABLE batch_items (
+  job_id      TEXT NOT NULL REFERENCES batch_jobs(id) ON DELETE CASCADE,
+  position    INTEGER NOT NULL,
+  item_key    TEXT NOT NULL,
+  input_text  TEXT NOT NULL,
+  record      TEXT NOT NULL DEFAULT '',
+  output_text TEXT NOT NULL DEFAULT '',
+  status      TEXT NOT NULL CHECK (status IN ('pending','running','done','failed','skipped')),
+  error_code  TEXT NOT NULL DEFAULT '',
+  error       TEXT NOT NULL DEFAULT '',
+  attempts    INTEGER NOT NULL DEFAULT 0,
+  updated_at  INTEGER NOT NULL,
+  PRIMARY KEY (job_id, position)
+);
+
+CREATE INDEX idx_batch_items_status ON batch_items(job_id, status);
+INSERT OR IGNORE INTO settings (key, value, type) VALUES ('batch.concurrency', '2', 'int');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM settings WHERE key = 'batch.concurrency';
+DROP INDEX idx_batch_items_status;
+DROP TABLE batch_items;
+DROP TABLE batch_jobs;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- File name template of exported outputs; {name}, {date} and {time} are
+-- replaced with the stack or action name and the run's date and time.
+-- +goose StatementBegin
+INSERT OR IGNORE INTO settings (key, value, type) VALUES ('export.fileNameTemplate', '{name} {date}', 'string');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM settings WHERE key = 'export.fileNameTemplate';
+-- +goose StatementEnd
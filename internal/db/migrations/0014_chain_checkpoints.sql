@@ -0,0 +1,38 @@
+-- +goose Up
+-- Checkpoints let a failed or cancelled chain run resume from its last completed
+-- group. request is the run's ChainRequest as JSON, its "auto" input language
+-- already resolved; plan the action IDs of each planned group (JSON array of
+-- arrays), compared against a fresh plan before resuming. duration_ms adds up
+-- the time of every pass over the run. Each completed group stores the text it
+-- produced and the language that text is in, whether its conditions skipped it,
+-- the LLM calls it made, and the warning (JSON, '' for none) and edit list
+-- (JSON, '' for none) it produced. A run that finishes successfully drops its
+-- checkpoint.
+-- +goose StatementBegin
+CREATE TABLE chain_checkpoints (
+  run_id      TEXT PRIMARY KEY,
+  request     TEXT NOT NULL,
+  plan        TEXT NOT NULL,
+  duration_ms INTEGER NOT NULL DEFAULT 0,
+  created_at  INTEGER NOT NULL,
+  updated_at  INTEGER NOT NULL
+);
+
+CREATE TABLE chain_checkpoint_groups (
+  run_id      TEXT NOT NULL REFERENCES chain_checkpoints(run_id) ON DELETE CASCADE,
+  group_index INTEGER NOT NULL,
+  output_text TEXT NOT NULL,
+  output_lang TEXT NOT NULL DEFAULT '',
+  skipped     INTEGER NOT NULL DEFAULT 0,
+  inferences  INTEGER NOT NULL DEFAULT 0,
+  warning     TEXT NOT NULL DEFAULT '',
+  edit_list   TEXT NOT NULL DEFAULT '',
+  PRIMARY KEY (run_id, group_index)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chain_checkpoint_groups;
+DROP TABLE chain_checkpoints;
+-- +goose StatementEnd
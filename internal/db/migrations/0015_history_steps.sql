@@ -0,0 +1,26 @@
+-- +goose Up
+-- One row per completed inference group of a history entry, in group order.
+-- action_ids is a JSON array; system_hash and user_hash are SHA-256 hex digests
+-- of the prompts sent ('' for local groups). Rows go with their entry when it
+-- is deleted, cleared or pruned.
+-- +goose StatementBegin
+CREATE TABLE history_steps (
+  history_id        TEXT NOT NULL REFERENCES history(id) ON DELETE CASCADE,
+  group_index       INTEGER NOT NULL,
+  family            TEXT NOT NULL,
+  action_ids        TEXT NOT NULL DEFAULT '[]',
+  input_text        TEXT NOT NULL,
+  output_text       TEXT NOT NULL,
+  system_hash       TEXT NOT NULL DEFAULT '',
+  user_hash         TEXT NOT NULL DEFAULT '',
+  duration_ms       INTEGER NOT NULL DEFAULT 0,
+  prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+  completion_tokens INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (history_id, group_index)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE history_steps;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- snapshot is the JSON apperr.RunSnapshot a run can be replayed from ('' for
+-- rows recorded before this column existed); rerun_of is the ID of the entry a
+-- re-run replayed, '' for runs started by the user.
+-- +goose StatementBegin
+ALTER TABLE history ADD COLUMN snapshot TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE history ADD COLUMN rerun_of TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE history DROP COLUMN rerun_of;
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE history DROP COLUMN snapshot;
+-- +goose StatementEnd
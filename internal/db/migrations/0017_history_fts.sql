@@ -0,0 +1,48 @@
+-- +goose Up
+-- Full-text index over the title, input and output text of history entries,
+-- backed by the history table itself (external content) and kept in sync by
+-- the triggers below. Diacritics are folded so "cafe" finds "café".
+-- +goose StatementBegin
+CREATE VIRTUAL TABLE history_fts USING fts5(
+  title, input_text, output_text,
+  content = 'history', content_rowid = 'rowid',
+  tokenize = 'unicode61 remove_diacritics 2'
+);
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE TRIGGER history_fts_insert AFTER INSERT ON history BEGIN
+  INSERT INTO history_fts (rowid, title, input_text, output_text)
+  VALUES (new.rowid, new.title, new.input_text, new.output_text);
+END;
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE TRIGGER history_fts_delete AFTER DELETE ON history BEGIN
+  INSERT INTO history_fts (history_fts, rowid, title, input_text, output_text)
+  VALUES ('delete', old.rowid, old.title, old.input_text, old.output_text);
+END;
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE TRIGGER history_fts_update AFTER UPDATE OF title, input_text, output_text ON history BEGIN
+  INSERT INTO history_fts (history_fts, rowid, title, input_text, output_text)
+  VALUES ('delete', old.rowid, old.title, old.input_text, old.output_text);
+  INSERT INTO history_fts (rowid, title, input_text, output_text)
+  VALUES (new.rowid, new.title, new.input_text, new.output_text);
+END;
+-- +goose StatementEnd
+-- +goose StatementBegin
+INSERT INTO history_fts (history_fts) VALUES ('rebuild');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER history_fts_update;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TRIGGER history_fts_delete;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TRIGGER history_fts_insert;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE history_fts;
+-- +goose StatementEnd
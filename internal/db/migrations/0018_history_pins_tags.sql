@@ -0,0 +1,31 @@
+-- +goose Up
+-- pinned and favorite are user flags (0/1); entries with either set are never
+-- pruned. history_tags holds the free-form tags of each entry, compared without
+-- regard to ASCII case, and goes with its entry when it is deleted.
+-- +goose StatementBegin
+ALTER TABLE history ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE history ADD COLUMN favorite INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE TABLE history_tags (
+  history_id TEXT NOT NULL REFERENCES history(id) ON DELETE CASCADE,
+  tag        TEXT NOT NULL COLLATE NOCASE,
+  PRIMARY KEY (history_id, tag)
+);
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE INDEX history_tags_tag ON history_tags (tag);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE history_tags;
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE history DROP COLUMN favorite;
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE history DROP COLUMN pinned;
+-- +goose StatementEnd
@@ -0,0 +1,80 @@
+-- name: UsageTotals :one
+WITH runs AS (
+  SELECT status, duration_ms, inferences,
+    row_number() OVER (PARTITION BY status = 'success' ORDER BY duration_ms) AS rn,
+    count(*) OVER (PARTITION BY status = 'success') AS n
+  FROM history
+  WHERE created_at >= sqlc.arg(created_from) AND created_at <= sqlc.arg(created_to)
+)
+SELECT
+  CAST(count(*) AS INTEGER) AS runs,
+  CAST(coalesce(sum(status = 'success'), 0) AS INTEGER) AS success,
+  CAST(coalesce(avg(inferences), 0) AS REAL) AS avg_inferences,
+  CAST(coalesce(min(CASE WHEN status = 'success' AND rn * 100 >= n * 50 THEN duration_ms END), 0) AS INTEGER) AS p50_ms,
+  CAST(coalesce(min(CASE WHEN status = 'success' AND rn * 100 >= n * 95 THEN duration_ms END), 0) AS INTEGER) AS p95_ms
+FROM runs;
+
+-- name: UsageByDay :many
+SELECT
+  CAST(date(created_at + sqlc.arg(utc_offset), 'unixepoch') AS TEXT) AS day,
+  CAST(count(*) AS INTEGER) AS runs,
+  CAST(sum(status = 'success') AS INTEGER) AS success,
+  CAST(sum(status = 'partial') AS INTEGER) AS partial,
+  CAST(sum(status = 'error') AS INTEGER) AS error
+FROM history
+WHERE created_at >= sqlc.arg(created_from) AND created_at <= sqlc.arg(created_to)
+GROUP BY day
+ORDER BY day;
+
+-- name: UsageByAction :many
+SELECT
+  CAST(json_extract(a.value, '$.id') AS TEXT) AS action_id,
+  CAST(coalesce(max(json_extract(a.value, '$.name')), '') AS TEXT) AS name,
+  CAST(count(DISTINCT h.id) AS INTEGER) AS runs
+FROM history h, json_each(h.applied) a
+WHERE h.created_at >= sqlc.arg(created_from) AND h.created_at <= sqlc.arg(created_to)
+  AND coalesce(json_extract(a.value, '$.skipped'), 0) = 0
+GROUP BY action_id
+ORDER BY runs DESC, action_id;
+
+-- name: UsageByStack :many
+SELECT title, CAST(count(*) AS INTEGER) AS runs
+FROM history
+WHERE kind = 'stack' AND created_at >= sqlc.arg(created_from) AND created_at <= sqlc.arg(created_to)
+GROUP BY title
+ORDER BY runs DESC, title;
+
+-- name: UsageByModel :many
+WITH runs AS (
+  SELECT provider_name, model, status, duration_ms, inferences,
+    row_number() OVER (PARTITION BY provider_name, model, status = 'success' ORDER BY duration_ms) AS rn,
+    count(*) OVER (PARTITION BY provider_name, model, status = 'success') AS n
+  FROM history
+  WHERE created_at >= sqlc.arg(created_from) AND created_at <= sqlc.arg(created_to)
+)
+SELECT
+  provider_name, model,
+  CAST(count(*) AS INTEGER) AS runs,
+  CAST(sum(status = 'success') AS INTEGER) AS success,
+  CAST(sum(status = 'partial') AS INTEGER) AS partial,
+  CAST(sum(status = 'error') AS INTEGER) AS error,
+  CAST(avg(inferences) AS REAL) AS avg_inferences,
+  CAST(coalesce(min(CASE WHEN status = 'success' AND rn * 100 >= n * 50 THEN duration_ms END), 0) AS INTEGER) AS p50_ms,
+  CAST(coalesce(min(CASE WHEN status = 'success' AND rn * 100 >= n * 95 THEN duration_ms END), 0) AS INTEGER) AS p95_ms
+FROM runs
+GROUP BY provider_name, model
+ORDER BY runs DESC, provider_name, model;
+
+-- name: UsageByOutcome :many
+SELECT status, error_code, CAST(count(*) AS INTEGER) AS runs
+FROM history
+WHERE created_at >= sqlc.arg(created_from) AND created_at <= sqlc.arg(created_to)
+GROUP BY status, error_code
+ORDER BY runs DESC, status, error_code;
+
+-- name: UsageByInferences :many
+SELECT inferences, CAST(count(*) AS INTEGER) AS runs
+FROM history
+WHERE created_at >= sqlc.arg(created_from) AND created_at <= sqlc.arg(created_to)
+GROUP BY inferences
+ORDER BY inferences;
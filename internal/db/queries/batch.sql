@@ -0,0 +1,49 @@
+-- name: InsertBatchJob :exec
+INSERT INTO batch_jobs (id, name, status, spec, header, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: InsertBatchItem :exec
+INSERT INTO batch_items (job_id, position, item_key, input_text, record, status, error, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListBatchJobs :many
+SELECT * FROM batch_jobs ORDER BY created_at DESC, id;
+
+-- name: GetBatchJob :one
+SELECT * FROM batch_jobs WHERE id = ?;
+
+-- name: UpdateBatchJobStatus :exec
+UPDATE batch_jobs SET status = ?, output_path = ?, error = ?, updated_at = ? WHERE id = ?;
+
+-- name: DeleteBatchJob :exec
+DELETE FROM batch_jobs WHERE id = ?;
+
+-- name: PruneCompletedBatchJobsOlderThan :execrows
+DELETE FROM batch_jobs WHERE status = 'completed' AND updated_at < ?;
+
+-- name: ListBatchItems :many
+SELECT * FROM batch_items WHERE job_id = ? ORDER BY position LIMIT ? OFFSET ?;
+
+-- name: ListBatchItemsByStatus :many
+SELECT * FROM batch_items WHERE job_id = ? AND status = ? ORDER BY position;
+
+-- name: CountBatchItemsByStatus :many
+SELECT status, count(*) AS n FROM batch_items WHERE job_id = ? GROUP BY status;
+
+-- name: StartBatchItem :exec
+UPDATE batch_items SET status = 'running', attempts = attempts + 1, updated_at = ?
+WHERE job_id = ? AND position = ?;
+
+-- name: FinishBatchItem :exec
+UPDATE batch_items SET status = ?, output_text = ?, error_code = ?, error = ?, updated_at = ?
+WHERE job_id = ? AND position = ?;
+
+-- name: ResetBatchItems :exec
+UPDATE batch_items SET status = 'pending', error_code = '', error = '', updated_at = ?
+WHERE job_id = ? AND status = ?;
+
+-- name: PauseInterruptedBatchJobs :exec
+UPDATE batch_jobs SET status = 'paused', updated_at = ? WHERE status = 'running';
+
+-- name: ResetInterruptedBatchItems :exec
+UPDATE batch_items SET status = 'pending', updated_at = ? WHERE status = 'running';
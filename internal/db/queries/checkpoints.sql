@@ -0,0 +1,36 @@
+-- name: UpsertChainCheckpoint :exec
+INSERT INTO chain_checkpoints (run_id, request, plan, duration_ms, created_at, updated_at)
+VALUES (?, ?, ?, 0, ?, ?)
+ON CONFLICT (run_id) DO UPDATE SET
+  request = excluded.request,
+  plan = excluded.plan,
+  duration_ms = 0,
+  updated_at = excluded.updated_at;
+
+-- name: DeleteChainCheckpointGroups :exec
+DELETE FROM chain_checkpoint_groups WHERE run_id = ?;
+
+-- name: PruneChainCheckpoints :exec
+DELETE FROM chain_checkpoints WHERE run_id NOT IN (
+  SELECT run_id FROM chain_checkpoints ORDER BY updated_at DESC, created_at DESC LIMIT ?
+);
+
+-- name: PruneChainCheckpointsOlderThan :execrows
+DELETE FROM chain_checkpoints WHERE updated_at < ?;
+
+-- name: UpsertChainCheckpointGroup :exec
+INSERT OR REPLACE INTO chain_checkpoint_groups (
+  run_id, group_index, output_text, output_lang, skipped, inferences, warning, edit_list
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: FinishChainCheckpoint :exec
+UPDATE chain_checkpoints SET duration_ms = duration_ms + ?, updated_at = ? WHERE run_id = ?;
+
+-- name: GetChainCheckpoint :one
+SELECT * FROM chain_checkpoints WHERE run_id = ?;
+
+-- name: ListChainCheckpointGroups :many
+SELECT * FROM chain_checkpoint_groups WHERE run_id = ? ORDER BY group_index;
+
+-- name: DeleteChainCheckpoint :exec
+DELETE FROM chain_checkpoints WHERE run_id = ?;
@@ -2,17 +2,48 @@
 INSERT INTO history (
   id, created_at, kind, title, input_text, output_text, applied,
   provider_name, model, input_lang, output_lang, format,
-  duration_ms, inferences, status, error_code, failed_index
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+  duration_ms, inferences, status, error_code, failed_index, run_id, branch, change_ratio,
+  selection_start, selection_end, snapshot, rerun_of
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+  kind = excluded.kind, title = excluded.title,
+  input_text = excluded.input_text, output_text = excluded.output_text, applied = excluded.applied,
+  provider_name = excluded.provider_name, model = excluded.model,
+  input_lang = excluded.input_lang, output_lang = excluded.output_lang, format = excluded.format,
+  duration_ms = excluded.duration_ms, inferences = excluded.inferences, status = excluded.status,
+  error_code = excluded.error_code, failed_index = excluded.failed_index,
+  run_id = excluded.run_id, branch = excluded.branch, change_ratio = excluded.change_ratio,
+  selection_start = excluded.selection_start, selection_end = excluded.selection_end,
+  snapshot = excluded.snapshot,
+  rerun_of = CASE WHEN excluded.rerun_of = '' THEN history.rerun_of ELSE excluded.rerun_of END;
 
 -- name: PruneHistory :exec
-DELETE FROM history WHERE id NOT IN (
-  SELECT id FROM history ORDER BY created_at DESC LIMIT ?
+DELETE FROM history WHERE pinned = 0 AND favorite = 0 AND id NOT IN (
+  SELECT id FROM history WHERE pinned = 0 AND favorite = 0 ORDER BY created_at DESC LIMIT ?
+);
+
+-- name: PruneHistoryOlderThan :execrows
+DELETE FROM history WHERE pinned = 0 AND favorite = 0 AND created_at < ?;
+
+-- name: PruneHistoryToSize :execrows
+DELETE FROM history WHERE id IN (
+  SELECT id FROM (
+    SELECT h.id, SUM(
+      length(CAST(h.input_text AS BLOB)) + length(CAST(h.output_text AS BLOB)) +
+      length(CAST(h.applied AS BLOB)) + length(CAST(h.snapshot AS BLOB)) +
+      COALESCE((SELECT SUM(length(CAST(s.input_text AS BLOB)) + length(CAST(s.output_text AS BLOB)))
+        FROM history_steps s WHERE s.history_id = h.id), 0)
+    ) OVER (ORDER BY h.created_at DESC, h.id DESC) AS running
+    FROM history h WHERE h.pinned = 0 AND h.favorite = 0
+  ) WHERE running > sqlc.arg(max_bytes)
 );
 
 -- name: ListHistory :many
 SELECT * FROM history ORDER BY created_at DESC LIMIT ? OFFSET ?;
 
+-- name: ListHistoryByRun :many
+SELECT * FROM history WHERE run_id = ? ORDER BY created_at, branch;
+
 -- name: GetHistory :one
 SELECT * FROM history WHERE id = ?;
 
@@ -24,3 +55,94 @@ DELETE FROM history;
 
 -- name: CountHistory :one
 SELECT count(*) FROM history;
+
+-- name: CountHistoryByID :one
+SELECT count(*) FROM history WHERE id = ?;
+
+-- name: ExportHistory :many
+SELECT * FROM history
+WHERE (sqlc.arg(status) = '' OR status = sqlc.arg(status))
+  AND (sqlc.arg(created_from) = 0 OR created_at >= sqlc.arg(created_from))
+  AND (sqlc.arg(created_to) = 0 OR created_at <= sqlc.arg(created_to))
+  AND (sqlc.arg(tags) = '[]' OR EXISTS (
+    SELECT 1 FROM history_tags t
+    WHERE t.history_id = history.id AND t.tag IN (SELECT value FROM json_each(sqlc.arg(tags)))
+  ))
+ORDER BY created_at, id;
+
+-- name: AddHistoryStep :exec
+INSERT OR REPLACE INTO history_steps (
+  history_id, group_index, family, action_ids, input_text, output_text,
+  system_hash, user_hash, duration_ms, prompt_tokens, completion_tokens
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListHistorySteps :many
+SELECT * FROM history_steps WHERE history_id = ? ORDER BY group_index;
+
+-- name: SearchHistory :many
+SELECT h.id, h.created_at, h.kind, h.title, h.input_text, h.output_text, h.applied,
+  h.provider_name, h.model, h.input_lang, h.output_lang, h.format,
+  h.duration_ms, h.inferences, h.status, h.error_code, h.failed_index, h.run_id, h.branch,
+  h.change_ratio, h.selection_start, h.selection_end, h.snapshot, h.rerun_of, h.pinned, h.favorite,
+  CAST(snippet(history_fts, -1, char(2), char(3), '…', 16) AS TEXT) AS snippet
+FROM history_fts
+JOIN history h ON h.rowid = history_fts.rowid
+WHERE history_fts MATCH sqlc.arg(query)
+  AND (sqlc.arg(status) = '' OR h.status = sqlc.arg(status))
+  AND (sqlc.arg(kind) = '' OR h.kind = sqlc.arg(kind))
+  AND (sqlc.arg(model) = '' OR h.model = sqlc.arg(model))
+  AND (sqlc.arg(provider_name) = '' OR h.provider_name = sqlc.arg(provider_name))
+  AND (sqlc.arg(action_id) = '' OR EXISTS (
+    SELECT 1 FROM json_each(h.applied) WHERE json_extract(json_each.value, '$.id') = sqlc.arg(action_id)
+  ))
+  AND (sqlc.arg(created_from) = 0 OR h.created_at >= sqlc.arg(created_from))
+  AND (sqlc.arg(created_to) = 0 OR h.created_at <= sqlc.arg(created_to))
+  AND (sqlc.arg(tag) = '' OR EXISTS (
+    SELECT 1 FROM history_tags t WHERE t.history_id = h.id AND t.tag = sqlc.arg(tag)
+  ))
+  AND (sqlc.arg(pinned_only) = 0 OR h.pinned = 1)
+  AND (sqlc.arg(favorite_only) = 0 OR h.favorite = 1)
+ORDER BY bm25(history_fts, 4.0, 1.0, 1.0), h.created_at DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: FilterHistory :many
+SELECT * FROM history
+WHERE (sqlc.arg(status) = '' OR status = sqlc.arg(status))
+  AND (sqlc.arg(kind) = '' OR kind = sqlc.arg(kind))
+  AND (sqlc.arg(model) = '' OR model = sqlc.arg(model))
+  AND (sqlc.arg(provider_name) = '' OR provider_name = sqlc.arg(provider_name))
+  AND (sqlc.arg(action_id) = '' OR EXISTS (
+    SELECT 1 FROM json_each(applied) WHERE json_extract(json_each.value, '$.id') = sqlc.arg(action_id)
+  ))
+  AND (sqlc.arg(created_from) = 0 OR created_at >= sqlc.arg(created_from))
+  AND (sqlc.arg(created_to) = 0 OR created_at <= sqlc.arg(created_to))
+  AND (sqlc.arg(tag) = '' OR EXISTS (
+    SELECT 1 FROM history_tags t WHERE t.history_id = history.id AND t.tag = sqlc.arg(tag)
+  ))
+  AND (sqlc.arg(pinned_only) = 0 OR pinned = 1)
+  AND (sqlc.arg(favorite_only) = 0 OR favorite = 1)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: SetHistoryPinned :execrows
+UPDATE history SET pinned = ? WHERE id = ?;
+
+-- name: SetHistoryFavorite :execrows
+UPDATE history SET favorite = ? WHERE id = ?;
+
+-- name: AddHistoryTag :exec
+INSERT OR IGNORE INTO history_tags (history_id, tag) VALUES (?, ?);
+
+-- name: RemoveHistoryTag :exec
+DELETE FROM history_tags WHERE history_id = ? AND tag = ?;
+
+-- name: DeleteHistoryTag :exec
+DELETE FROM history_tags WHERE tag = ?;
+
+-- name: ListHistoryTags :many
+SELECT tag, count(*) AS entries FROM history_tags GROUP BY tag ORDER BY tag;
+
+-- name: ListTagsForHistory :many
+SELECT * FROM history_tags
+WHERE history_id IN (SELECT value FROM json_each(sqlc.arg(ids)))
+ORDER BY history_id, tag;
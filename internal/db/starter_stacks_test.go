@@ -56,7 +56,7 @@ func TestMigration_RemapsStaleCamelCaseActionID(t *testing.T) {
 
 	// Roll back to before the remap migration (0003) so we can simulate a
 	// pre-remap DB. DownTo(2), not Down() — Down() only undoes the single most
-	// recent migration, which is 0004 (T62) now that 0003 is no longer latest.
+	// recent migration, which is no longer 0003 now that later migrations exist.
 	_, err = database.provider.DownTo(ctx, 2)
 	require.NoError(t, err)
 
@@ -134,7 +134,7 @@ func TestMigration_RemapsEveryStaleActionID(t *testing.T) {
 
 	// Roll back to before the remap migration to simulate a pre-remap DB.
 	// DownTo(2), not Down() — Down() only undoes the single most recent
-	// migration, which is 0004 (T62) now that 0003 is no longer latest.
+	// migration, which is no longer 0003 now that later migrations exist.
 	_, err = database.provider.DownTo(ctx, 2)
 	require.NoError(t, err)
 
@@ -192,7 +192,7 @@ func TestMigration_RemapDownReversesMapping(t *testing.T) {
 
 	// Roll back to before the remap migration: dotted -> camelCase.
 	// DownTo(2), not Down() — Down() only undoes the single most recent
-	// migration, which is 0004 (T62) now that 0003 is no longer latest.
+	// migration, which is no longer 0003 now that later migrations exist.
 	_, err = database.provider.DownTo(ctx, 2)
 	require.NoError(t, err)
 
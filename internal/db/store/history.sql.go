@@ -13,28 +13,47 @@ const addHistory = `-- name: AddHistory :exec
 INSERT INTO history (
   id, created_at, kind, title, input_text, output_text, applied,
   provider_name, model, input_lang, output_lang, format,
-  duration_ms, inferences, status, error_code, failed_index
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  duration_ms, inferences, status, error_code, failed_index, run_id, branch, change_ratio,
+  selection_start, selection_end, snapshot, rerun_of
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+  kind = excluded.kind, title = excluded.title,
+  input_text = excluded.input_text, output_text = excluded.output_text, applied = excluded.applied,
+  provider_name = excluded.provider_name, model = excluded.model,
+  input_lang = excluded.input_lang, output_lang = excluded.output_lang, format = excluded.format,
+  duration_ms = excluded.duration_ms, inferences = excluded.inferences, status = excluded.status,
+  error_code = excluded.error_code, failed_index = excluded.failed_index,
+  run_id = excluded.run_id, branch = excluded.branch, change_ratio = excluded.change_ratio,
+  selection_start = excluded.selection_start, selection_end = excluded.selection_end,
+  snapshot = excluded.snapshot,
+  rerun_of = CASE WHEN excluded.rerun_of = '' THEN history.rerun_of ELSE excluded.rerun_of END
 `
 
 type AddHistoryParams struct {
-	ID           string
-	CreatedAt    int64
-	Kind         string
-	Title        string
-	InputText    string
-	OutputText   string
-	Applied      string
-	ProviderName string
-	Model        string
-	InputLang    string
-	OutputLang   string
-	Format       string
-	DurationMs   int64
-	Inferences   int64
-	Status       string
-	ErrorCode    string
-	FailedIndex  int64
+	ID             string
+	CreatedAt      int64
+	Kind           string
+	Title          string
+	InputText      string
+	OutputText     string
+	Applied        string
+	ProviderName   string
+	Model          string
+	InputLang      string
+	OutputLang     string
+	Format         string
+	DurationMs     int64
+	Inferences     int64
+	Status         string
+	ErrorCode      string
+	FailedIndex    int64
+	RunID          string
+	Branch         string
+	ChangeRatio    float64
+	SelectionStart int64
+	SelectionEnd   int64
+	Snapshot       string
+	RerunOf        string
 }
 
 func (q *Queries) AddHistory(ctx context.Context, arg AddHistoryParams) error {
@@ -56,10 +75,69 @@ func (q *Queries) AddHistory(ctx context.Context, arg AddHistoryParams) error {
 		arg.Status,
 		arg.ErrorCode,
 		arg.FailedIndex,
+		arg.RunID,
+		arg.Branch,
+		arg.ChangeRatio,
+		arg.SelectionStart,
+		arg.SelectionEnd,
+		arg.Snapshot,
+		arg.RerunOf,
 	)
 	return err
 }
 
+const addHistoryStep = `-- name: AddHistoryStep :exec
+INSERT OR REPLACE INTO history_steps (
+  history_id, group_index, family, action_ids, input_text, output_text,
+  system_hash, user_hash, duration_ms, prompt_tokens, completion_tokens
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type AddHistoryStepParams struct {
+	HistoryID        string
+	GroupIndex       int64
+	Family           string
+	ActionIds        string
+	InputText        string
+	OutputText       string
+	SystemHash       string
+	UserHash         string
+	DurationMs       int64
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+func (q *Queries) AddHistoryStep(ctx context.Context, arg AddHistoryStepParams) error {
+	_, err := q.db.ExecContext(ctx, addHistoryStep,
+		arg.HistoryID,
+		arg.GroupIndex,
+		arg.Family,
+		arg.ActionIds,
+		arg.InputText,
+		arg.OutputText,
+		arg.SystemHash,
+		arg.UserHash,
+		arg.DurationMs,
+		arg.PromptTokens,
+		arg.CompletionTokens,
+	)
+	return err
+}
+
+const addHistoryTag = `-- name: AddHistoryTag :exec
+INSERT OR IGNORE INTO history_tags (history_id, tag) VALUES (?, ?)
+`
+
+type AddHistoryTagParams struct {
+	HistoryID string
+	Tag       string
+}
+
+func (q *Queries) AddHistoryTag(ctx context.Context, arg AddHistoryTagParams) error {
+	_, err := q.db.ExecContext(ctx, addHistoryTag, arg.HistoryID, arg.Tag)
+	return err
+}
+
 const clearHistory = `-- name: ClearHistory :exec
 DELETE FROM history
 `
@@ -80,6 +158,17 @@ func (q *Queries) CountHistory(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const countHistoryByID = `-- name: CountHistoryByID :one
+SELECT count(*) FROM history WHERE id = ?
+`
+
+func (q *Queries) CountHistoryByID(ctx context.Context, id string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countHistoryByID, id)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const deleteHistory = `-- name: DeleteHistory :exec
 DELETE FROM history WHERE id = ?
 `
@@ -89,8 +178,189 @@ func (q *Queries) DeleteHistory(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteHistoryTag = `-- name: DeleteHistoryTag :exec
+DELETE FROM history_tags WHERE tag = ?
+`
+
+func (q *Queries) DeleteHistoryTag(ctx context.Context, tag string) error {
+	_, err := q.db.ExecContext(ctx, deleteHistoryTag, tag)
+	return err
+}
+
+const exportHistory = `-- name: ExportHistory :many
+SELECT id, created_at, kind, title, input_text, output_text, applied, provider_name, model, input_lang, output_lang, format, duration_ms, inferences, status, error_code, failed_index, run_id, branch, change_ratio, selection_start, selection_end, snapshot, rerun_of, pinned, favorite FROM history
+WHERE (?1 = '' OR status = ?1)
+  AND (?2 = 0 OR created_at >= ?2)
+  AND (?3 = 0 OR created_at <= ?3)
+  AND (?4 = '[]' OR EXISTS (
+    SELECT 1 FROM history_tags t
+    WHERE t.history_id = history.id AND t.tag IN (SELECT value FROM json_each(?4))
+  ))
+ORDER BY created_at, id
+`
+
+type ExportHistoryParams struct {
+	Status      string
+	CreatedFrom int64
+	CreatedTo   int64
+	Tags        string
+}
+
+func (q *Queries) ExportHistory(ctx context.Context, arg ExportHistoryParams) ([]History, error) {
+	rows, err := q.db.QueryContext(ctx, exportHistory,
+		arg.Status,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Tags,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []History
+	for rows.Next() {
+		var i History
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Kind,
+			&i.Title,
+			&i.InputText,
+			&i.OutputText,
+			&i.Applied,
+			&i.ProviderName,
+			&i.Model,
+			&i.InputLang,
+			&i.OutputLang,
+			&i.Format,
+			&i.DurationMs,
+			&i.Inferences,
+			&i.Status,
+			&i.ErrorCode,
+			&i.FailedIndex,
+			&i.RunID,
+			&i.Branch,
+			&i.ChangeRatio,
+			&i.SelectionStart,
+			&i.SelectionEnd,
+			&i.Snapshot,
+			&i.RerunOf,
+			&i.Pinned,
+			&i.Favorite,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const filterHistory = `-- name: FilterHistory :many
+SELECT id, created_at, kind, title, input_text, output_text, applied, provider_name, model, input_lang, output_lang, format, duration_ms, inferences, status, error_code, failed_index, run_id, branch, change_ratio, selection_start, selection_end, snapshot, rerun_of, pinned, favorite FROM history
+WHERE (?1 = '' OR status = ?1)
+  AND (?2 = '' OR kind = ?2)
+  AND (?3 = '' OR model = ?3)
+  AND (?4 = '' OR provider_name = ?4)
+  AND (?5 = '' OR EXISTS (
+    SELECT 1 FROM json_each(applied) WHERE json_extract(json_each.value, '$.id') = ?5
+  ))
+  AND (?6 = 0 OR created_at >= ?6)
+  AND (?7 = 0 OR created_at <= ?7)
+  AND (?8 = '' OR EXISTS (
+    SELECT 1 FROM history_tags t WHERE t.history_id = history.id AND t.tag = ?8
+  ))
+  AND (?9 = 0 OR pinned = 1)
+  AND (?10 = 0 OR favorite = 1)
+ORDER BY created_at DESC
+LIMIT ?11 OFFSET ?12
+`
+
+type FilterHistoryParams struct {
+	Status       string
+	Kind         string
+	Model        string
+	ProviderName string
+	ActionID     string
+	CreatedFrom  int64
+	CreatedTo    int64
+	Tag          string
+	PinnedOnly   int64
+	FavoriteOnly int64
+	Limit        int64
+	Offset       int64
+}
+
+func (q *Queries) FilterHistory(ctx context.Context, arg FilterHistoryParams) ([]History, error) {
+	rows, err := q.db.QueryContext(ctx, filterHistory,
+		arg.Status,
+		arg.Kind,
+		arg.Model,
+		arg.ProviderName,
+		arg.ActionID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Tag,
+		arg.PinnedOnly,
+		arg.FavoriteOnly,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []History
+	for rows.Next() {
+		var i History
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Kind,
+			&i.Title,
+			&i.InputText,
+			&i.OutputText,
+			&i.Applied,
+			&i.ProviderName,
+			&i.Model,
+			&i.InputLang,
+			&i.OutputLang,
+			&i.Format,
+			&i.DurationMs,
+			&i.Inferences,
+			&i.Status,
+			&i.ErrorCode,
+			&i.FailedIndex,
+			&i.RunID,
+			&i.Branch,
+			&i.ChangeRatio,
+			&i.SelectionStart,
+			&i.SelectionEnd,
+			&i.Snapshot,
+			&i.RerunOf,
+			&i.Pinned,
+			&i.Favorite,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getHistory = `-- name: GetHistory :one
-SELECT id, created_at, kind, title, input_text, output_text, applied, provider_name, model, input_lang, output_lang, format, duration_ms, inferences, status, error_code, failed_index FROM history WHERE id = ?
+SELECT id, created_at, kind, title, input_text, output_text, applied, provider_name, model, input_lang, output_lang, format, duration_ms, inferences, status, error_code, failed_index, run_id, branch, change_ratio, selection_start, selection_end, snapshot, rerun_of, pinned, favorite FROM history WHERE id = ?
 `
 
 func (q *Queries) GetHistory(ctx context.Context, id string) (History, error) {
@@ -114,12 +384,21 @@ func (q *Queries) GetHistory(ctx context.Context, id string) (History, error) {
 		&i.Status,
 		&i.ErrorCode,
 		&i.FailedIndex,
+		&i.RunID,
+		&i.Branch,
+		&i.ChangeRatio,
+		&i.SelectionStart,
+		&i.SelectionEnd,
+		&i.Snapshot,
+		&i.RerunOf,
+		&i.Pinned,
+		&i.Favorite,
 	)
 	return i, err
 }
 
 const listHistory = `-- name: ListHistory :many
-SELECT id, created_at, kind, title, input_text, output_text, applied, provider_name, model, input_lang, output_lang, format, duration_ms, inferences, status, error_code, failed_index FROM history ORDER BY created_at DESC LIMIT ? OFFSET ?
+SELECT id, created_at, kind, title, input_text, output_text, applied, provider_name, model, input_lang, output_lang, format, duration_ms, inferences, status, error_code, failed_index, run_id, branch, change_ratio, selection_start, selection_end, snapshot, rerun_of, pinned, favorite FROM history ORDER BY created_at DESC LIMIT ? OFFSET ?
 `
 
 type ListHistoryParams struct {
@@ -154,6 +433,15 @@ func (q *Queries) ListHistory(ctx context.Context, arg ListHistoryParams) ([]His
 			&i.Status,
 			&i.ErrorCode,
 			&i.FailedIndex,
+			&i.RunID,
+			&i.Branch,
+			&i.ChangeRatio,
+			&i.SelectionStart,
+			&i.SelectionEnd,
+			&i.Snapshot,
+			&i.RerunOf,
+			&i.Pinned,
+			&i.Favorite,
 		); err != nil {
 			return nil, err
 		}
@@ -168,9 +456,163 @@ func (q *Queries) ListHistory(ctx context.Context, arg ListHistoryParams) ([]His
 	return items, nil
 }
 
+const listHistoryByRun = `-- name: ListHistoryByRun :many
+SELECT id, created_at, kind, title, input_text, output_text, applied, provider_name, model, input_lang, output_lang, format, duration_ms, inferences, status, error_code, failed_index, run_id, branch, change_ratio, selection_start, selection_end, snapshot, rerun_of, pinned, favorite FROM history WHERE run_id = ? ORDER BY created_at, branch
+`
+
+func (q *Queries) ListHistoryByRun(ctx context.Context, runID string) ([]History, error) {
+	rows, err := q.db.QueryContext(ctx, listHistoryByRun, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []History
+	for rows.Next() {
+		var i History
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Kind,
+			&i.Title,
+			&i.InputText,
+			&i.OutputText,
+			&i.Applied,
+			&i.ProviderName,
+			&i.Model,
+			&i.InputLang,
+			&i.OutputLang,
+			&i.Format,
+			&i.DurationMs,
+			&i.Inferences,
+			&i.Status,
+			&i.ErrorCode,
+			&i.FailedIndex,
+			&i.RunID,
+			&i.Branch,
+			&i.ChangeRatio,
+			&i.SelectionStart,
+			&i.SelectionEnd,
+			&i.Snapshot,
+			&i.RerunOf,
+			&i.Pinned,
+			&i.Favorite,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHistorySteps = `-- name: ListHistorySteps :many
+SELECT history_id, group_index, family, action_ids, input_text, output_text, system_hash, user_hash, duration_ms, prompt_tokens, completion_tokens FROM history_steps WHERE history_id = ? ORDER BY group_index
+`
+
+func (q *Queries) ListHistorySteps(ctx context.Context, historyID string) ([]HistoryStep, error) {
+	rows, err := q.db.QueryContext(ctx, listHistorySteps, historyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HistoryStep
+	for rows.Next() {
+		var i HistoryStep
+		if err := rows.Scan(
+			&i.HistoryID,
+			&i.GroupIndex,
+			&i.Family,
+			&i.ActionIds,
+			&i.InputText,
+			&i.OutputText,
+			&i.SystemHash,
+			&i.UserHash,
+			&i.DurationMs,
+			&i.PromptTokens,
+			&i.CompletionTokens,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHistoryTags = `-- name: ListHistoryTags :many
+SELECT tag, count(*) AS entries FROM history_tags GROUP BY tag ORDER BY tag
+`
+
+type ListHistoryTagsRow struct {
+	Tag     string
+	Entries int64
+}
+
+func (q *Queries) ListHistoryTags(ctx context.Context) ([]ListHistoryTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listHistoryTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListHistoryTagsRow
+	for rows.Next() {
+		var i ListHistoryTagsRow
+		if err := rows.Scan(&i.Tag, &i.Entries); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagsForHistory = `-- name: ListTagsForHistory :many
+SELECT history_id, tag FROM history_tags
+WHERE history_id IN (SELECT value FROM json_each(?1))
+ORDER BY history_id, tag
+`
+
+func (q *Queries) ListTagsForHistory(ctx context.Context, ids string) ([]HistoryTag, error) {
+	rows, err := q.db.QueryContext(ctx, listTagsForHistory, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HistoryTag
+	for rows.Next() {
+		var i HistoryTag
+		if err := rows.Scan(&i.HistoryID, &i.Tag); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const pruneHistory = `-- name: PruneHistory :exec
-DELETE FROM history WHERE id NOT IN (
-  SELECT id FROM history ORDER BY created_at DESC LIMIT ?
+DELETE FROM history WHERE pinned = 0 AND favorite = 0 AND id NOT IN (
+  SELECT id FROM history WHERE pinned = 0 AND favorite = 0 ORDER BY created_at DESC LIMIT ?
 )
 `
 
@@ -178,3 +620,223 @@ func (q *Queries) PruneHistory(ctx context.Context, limit int64) error {
 	_, err := q.db.ExecContext(ctx, pruneHistory, limit)
 	return err
 }
+
+const pruneHistoryOlderThan = `-- name: PruneHistoryOlderThan :execrows
+DELETE FROM history WHERE pinned = 0 AND favorite = 0 AND created_at < ?
+`
+
+func (q *Queries) PruneHistoryOlderThan(ctx context.Context, createdAt int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pruneHistoryOlderThan, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const pruneHistoryToSize = `-- name: PruneHistoryToSize :execrows
+DELETE FROM history WHERE id IN (
+  SELECT id FROM (
+    SELECT h.id, SUM(
+      length(CAST(h.input_text AS BLOB)) + length(CAST(h.output_text AS BLOB)) +
+      length(CAST(h.applied AS BLOB)) + length(CAST(h.snapshot AS BLOB)) +
+      COALESCE((SELECT SUM(length(CAST(s.input_text AS BLOB)) + length(CAST(s.output_text AS BLOB)))
+        FROM history_steps s WHERE s.history_id = h.id), 0)
+    ) OVER (ORDER BY h.created_at DESC, h.id DESC) AS running
+    FROM history h WHERE h.pinned = 0 AND h.favorite = 0
+  ) WHERE running > ?1
+)
+`
+
+func (q *Queries) PruneHistoryToSize(ctx context.Context, maxBytes int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pruneHistoryToSize, maxBytes)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeHistoryTag = `-- name: RemoveHistoryTag :exec
+DELETE FROM history_tags WHERE history_id = ? AND tag = ?
+`
+
+type RemoveHistoryTagParams struct {
+	HistoryID string
+	Tag       string
+}
+
+func (q *Queries) RemoveHistoryTag(ctx context.Context, arg RemoveHistoryTagParams) error {
+	_, err := q.db.ExecContext(ctx, removeHistoryTag, arg.HistoryID, arg.Tag)
+	return err
+}
+
+const searchHistory = `-- name: SearchHistory :many
+SELECT h.id, h.created_at, h.kind, h.title, h.input_text, h.output_text, h.applied,
+  h.provider_name, h.model, h.input_lang, h.output_lang, h.format,
+  h.duration_ms, h.inferences, h.status, h.error_code, h.failed_index, h.run_id, h.branch,
+  h.change_ratio, h.selection_start, h.selection_end, h.snapshot, h.rerun_of, h.pinned, h.favorite,
+  CAST(snippet(history_fts, -1, char(2), char(3), '…', 16) AS TEXT) AS snippet
+FROM history_fts
+JOIN history h ON h.rowid = history_fts.rowid
+WHERE history_fts MATCH ?1
+  AND (?2 = '' OR h.status = ?2)
+  AND (?3 = '' OR h.kind = ?3)
+  AND (?4 = '' OR h.model = ?4)
+  AND (?5 = '' OR h.provider_name = ?5)
+  AND (?6 = '' OR EXISTS (
+    SELECT 1 FROM json_each(h.applied) WHERE json_extract(json_each.value, '$.id') = ?6
+  ))
+  AND (?7 = 0 OR h.created_at >= ?7)
+  AND (?8 = 0 OR h.created_at <= ?8)
+  AND (?9 = '' OR EXISTS (
+    SELECT 1 FROM history_tags t WHERE t.history_id = h.id AND t.tag = ?9
+  ))
+  AND (?10 = 0 OR h.pinned = 1)
+  AND (?11 = 0 OR h.favorite = 1)
+ORDER BY bm25(history_fts, 4.0, 1.0, 1.0), h.created_at DESC
+LIMIT ?12 OFFSET ?13
+`
+
+type SearchHistoryParams struct {
+	Query        string
+	Status       string
+	Kind         string
+	Model        string
+	ProviderName string
+	ActionID     string
+	CreatedFrom  int64
+	CreatedTo    int64
+	Tag          string
+	PinnedOnly   int64
+	FavoriteOnly int64
+	Limit        int64
+	Offset       int64
+}
+
+type SearchHistoryRow struct {
+	ID             string
+	CreatedAt      int64
+	Kind           string
+	Title          string
+	InputText      string
+	OutputText     string
+	Applied        string
+	ProviderName   string
+	Model          string
+	InputLang      string
+	OutputLang     string
+	Format         string
+	DurationMs     int64
+	Inferences     int64
+	Status         string
+	ErrorCode      string
+	FailedIndex    int64
+	RunID          string
+	Branch         string
+	ChangeRatio    float64
+	SelectionStart int64
+	SelectionEnd   int64
+	Snapshot       string
+	RerunOf        string
+	Pinned         int64
+	Favorite       int64
+	Snippet        string
+}
+
+func (q *Queries) SearchHistory(ctx context.Context, arg SearchHistoryParams) ([]SearchHistoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchHistory,
+		arg.Query,
+		arg.Status,
+		arg.Kind,
+		arg.Model,
+		arg.ProviderName,
+		arg.ActionID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Tag,
+		arg.PinnedOnly,
+		arg.FavoriteOnly,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchHistoryRow
+	for rows.Next() {
+		var i SearchHistoryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Kind,
+			&i.Title,
+			&i.InputText,
+			&i.OutputText,
+			&i.Applied,
+			&i.ProviderName,
+			&i.Model,
+			&i.InputLang,
+			&i.OutputLang,
+			&i.Format,
+			&i.DurationMs,
+			&i.Inferences,
+			&i.Status,
+			&i.ErrorCode,
+			&i.FailedIndex,
+			&i.RunID,
+			&i.Branch,
+			&i.ChangeRatio,
+			&i.SelectionStart,
+			&i.SelectionEnd,
+			&i.Snapshot,
+			&i.RerunOf,
+			&i.Pinned,
+			&i.Favorite,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setHistoryFavorite = `-- name: SetHistoryFavorite :execrows
+UPDATE history SET favorite = ? WHERE id = ?
+`
+
+type SetHistoryFavoriteParams struct {
+	Favorite int64
+	ID       string
+}
+
+func (q *Queries) SetHistoryFavorite(ctx context.Context, arg SetHistoryFavoriteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setHistoryFavorite, arg.Favorite, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setHistoryPinned = `-- name: SetHistoryPinned :execrows
+UPDATE history SET pinned = ? WHERE id = ?
+`
+
+type SetHistoryPinnedParams struct {
+	Pinned int64
+	ID     string
+}
+
+func (q *Queries) SetHistoryPinned(ctx context.Context, arg SetHistoryPinnedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setHistoryPinned, arg.Pinned, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -13,24 +13,98 @@ type AppState struct {
 	CurrentProviderID sql.NullString
 }
 
+type BatchItem struct {
+	JobID      string
+	Position   int64
+	ItemKey    string
+	InputText  string
+	Record     string
+	OutputText string
+	Status     string
+	ErrorCode  string
+	Error      string
+	Attempts   int64
+	UpdatedAt  int64
+}
+
+type BatchJob struct {
+	ID         string
+	Name       string
+	Status     string
+	Spec       string
+	Header     string
+	OutputPath string
+	Error      string
+	CreatedAt  int64
+	UpdatedAt  int64
+}
+
+type ChainCheckpoint struct {
+	RunID      string
+	Request    string
+	Plan       string
+	DurationMs int64
+	CreatedAt  int64
+	UpdatedAt  int64
+}
+
+type ChainCheckpointGroup struct {
+	RunID      string
+	GroupIndex int64
+	OutputText string
+	OutputLang string
+	Skipped    int64
+	Inferences int64
+	Warning    string
+	EditList   string
+}
+
 type History struct {
-	ID           string
-	CreatedAt    int64
-	Kind         string
-	Title        string
-	InputText    string
-	OutputText   string
-	Applied      string
-	ProviderName string
-	Model        string
-	InputLang    string
-	OutputLang   string
-	Format       string
-	DurationMs   int64
-	Inferences   int64
-	Status       string
-	ErrorCode    string
-	FailedIndex  int64
+	ID             string
+	CreatedAt      int64
+	Kind           string
+	Title          string
+	InputText      string
+	OutputText     string
+	Applied        string
+	ProviderName   string
+	Model          string
+	InputLang      string
+	OutputLang     string
+	Format         string
+	DurationMs     int64
+	Inferences     int64
+	Status         string
+	ErrorCode      string
+	FailedIndex    int64
+	RunID          string
+	Branch         string
+	ChangeRatio    float64
+	SelectionStart int64
+	SelectionEnd   int64
+	Snapshot       string
+	RerunOf        string
+	Pinned         int64
+	Favorite       int64
+}
+
+type HistoryStep struct {
+	HistoryID        string
+	GroupIndex       int64
+	Family           string
+	ActionIds        string
+	InputText        string
+	OutputText       string
+	SystemHash       string
+	UserHash         string
+	DurationMs       int64
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+type HistoryTag struct {
+	HistoryID string
+	Tag       string
 }
 
 type Language struct {
@@ -71,10 +145,14 @@ type Stack struct {
 	DefaultOutLang string
 	CreatedAt      int64
 	UpdatedAt      int64
+	StrictOrder    int64
+	Kind           string
 }
 
 type StackStep struct {
 	StackID  string
 	Position int64
 	ActionID string
+	Params   string
+	Branch   string
 }
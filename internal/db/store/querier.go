@@ -11,34 +11,81 @@ import (
 
 type Querier interface {
 	AddHistory(ctx context.Context, arg AddHistoryParams) error
+	AddHistoryStep(ctx context.Context, arg AddHistoryStepParams) error
+	AddHistoryTag(ctx context.Context, arg AddHistoryTagParams) error
 	AddLanguage(ctx context.Context, arg AddLanguageParams) error
 	ClearHistory(ctx context.Context) error
+	CountBatchItemsByStatus(ctx context.Context, jobID string) ([]CountBatchItemsByStatusRow, error)
 	CountHistory(ctx context.Context) (int64, error)
+	CountHistoryByID(ctx context.Context, id string) (int64, error)
 	CountProviders(ctx context.Context) (int64, error)
 	CreateProvider(ctx context.Context, arg CreateProviderParams) error
 	DeleteAllStackSteps(ctx context.Context, stackID string) error
+	DeleteBatchJob(ctx context.Context, id string) error
+	DeleteChainCheckpoint(ctx context.Context, runID string) error
+	DeleteChainCheckpointGroups(ctx context.Context, runID string) error
 	DeleteHistory(ctx context.Context, id string) error
+	DeleteHistoryTag(ctx context.Context, tag string) error
 	DeleteProvider(ctx context.Context, id string) error
 	DeleteStack(ctx context.Context, id string) error
+	ExportHistory(ctx context.Context, arg ExportHistoryParams) ([]History, error)
+	FinishBatchItem(ctx context.Context, arg FinishBatchItemParams) error
+	FilterHistory(ctx context.Context, arg FilterHistoryParams) ([]History, error)
+	FinishChainCheckpoint(ctx context.Context, arg FinishChainCheckpointParams) error
+	GetBatchJob(ctx context.Context, id string) (BatchJob, error)
+	GetChainCheckpoint(ctx context.Context, runID string) (ChainCheckpoint, error)
 	GetCurrentProviderID(ctx context.Context) (sql.NullString, error)
 	GetHistory(ctx context.Context, id string) (History, error)
 	GetProvider(ctx context.Context, id string) (Provider, error)
 	GetSetting(ctx context.Context, key string) (GetSettingRow, error)
 	GetStack(ctx context.Context, id string) (Stack, error)
-	GetStackSteps(ctx context.Context, stackID string) ([]string, error)
+	GetStackSteps(ctx context.Context, stackID string) ([]GetStackStepsRow, error)
+	InsertBatchItem(ctx context.Context, arg InsertBatchItemParams) error
+	InsertBatchJob(ctx context.Context, arg InsertBatchJobParams) error
 	InsertStack(ctx context.Context, arg InsertStackParams) error
 	InsertStackStep(ctx context.Context, arg InsertStackStepParams) error
+	ListBatchItems(ctx context.Context, arg ListBatchItemsParams) ([]BatchItem, error)
+	ListBatchItemsByStatus(ctx context.Context, arg ListBatchItemsByStatusParams) ([]BatchItem, error)
+	ListBatchJobs(ctx context.Context) ([]BatchJob, error)
+	ListChainCheckpointGroups(ctx context.Context, runID string) ([]ChainCheckpointGroup, error)
 	ListHistory(ctx context.Context, arg ListHistoryParams) ([]History, error)
+	ListHistoryByRun(ctx context.Context, runID string) ([]History, error)
+	ListHistorySteps(ctx context.Context, historyID string) ([]HistoryStep, error)
+	ListHistoryTags(ctx context.Context) ([]ListHistoryTagsRow, error)
 	ListLanguages(ctx context.Context) ([]string, error)
 	ListProviders(ctx context.Context) ([]Provider, error)
 	ListSettings(ctx context.Context) ([]Setting, error)
 	ListStacks(ctx context.Context) ([]Stack, error)
+	ListTagsForHistory(ctx context.Context, ids string) ([]HistoryTag, error)
+	PauseInterruptedBatchJobs(ctx context.Context, updatedAt int64) error
+	PruneChainCheckpoints(ctx context.Context, limit int64) error
+	PruneChainCheckpointsOlderThan(ctx context.Context, updatedAt int64) (int64, error)
+	PruneCompletedBatchJobsOlderThan(ctx context.Context, updatedAt int64) (int64, error)
 	PruneHistory(ctx context.Context, limit int64) error
+	PruneHistoryOlderThan(ctx context.Context, createdAt int64) (int64, error)
+	PruneHistoryToSize(ctx context.Context, maxBytes int64) (int64, error)
+	RemoveHistoryTag(ctx context.Context, arg RemoveHistoryTagParams) error
 	RemoveLanguage(ctx context.Context, name string) error
+	ResetBatchItems(ctx context.Context, arg ResetBatchItemsParams) error
+	ResetInterruptedBatchItems(ctx context.Context, updatedAt int64) error
+	SearchHistory(ctx context.Context, arg SearchHistoryParams) ([]SearchHistoryRow, error)
 	SetCurrentProviderID(ctx context.Context, currentProviderID sql.NullString) error
+	SetHistoryFavorite(ctx context.Context, arg SetHistoryFavoriteParams) (int64, error)
+	SetHistoryPinned(ctx context.Context, arg SetHistoryPinnedParams) (int64, error)
+	StartBatchItem(ctx context.Context, arg StartBatchItemParams) error
+	UpdateBatchJobStatus(ctx context.Context, arg UpdateBatchJobStatusParams) error
 	UpdateProvider(ctx context.Context, arg UpdateProviderParams) error
 	UpdateStack(ctx context.Context, arg UpdateStackParams) error
+	UpsertChainCheckpoint(ctx context.Context, arg UpsertChainCheckpointParams) error
+	UpsertChainCheckpointGroup(ctx context.Context, arg UpsertChainCheckpointGroupParams) error
 	UpsertSetting(ctx context.Context, arg UpsertSettingParams) error
+	UsageByAction(ctx context.Context, arg UsageByActionParams) ([]UsageByActionRow, error)
+	UsageByDay(ctx context.Context, arg UsageByDayParams) ([]UsageByDayRow, error)
+	UsageByInferences(ctx context.Context, arg UsageByInferencesParams) ([]UsageByInferencesRow, error)
+	UsageByModel(ctx context.Context, arg UsageByModelParams) ([]UsageByModelRow, error)
+	UsageByOutcome(ctx context.Context, arg UsageByOutcomeParams) ([]UsageByOutcomeRow, error)
+	UsageByStack(ctx context.Context, arg UsageByStackParams) ([]UsageByStackRow, error)
+	UsageTotals(ctx context.Context, arg UsageTotalsParams) (UsageTotalsRow, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -28,7 +28,7 @@ func (q *Queries) DeleteStack(ctx context.Context, id string) error {
 }
 
 const getStack = `-- name: GetStack :one
-SELECT id, name, icon, default_format, default_in_lang, default_out_lang, created_at, updated_at FROM stacks WHERE id = ?
+SELECT id, name, icon, default_format, default_in_lang, default_out_lang, created_at, updated_at, strict_order, kind FROM stacks WHERE id = ?
 `
 
 func (q *Queries) GetStack(ctx context.Context, id string) (Stack, error) {
@@ -43,27 +43,35 @@ func (q *Queries) GetStack(ctx context.Context, id string) (Stack, error) {
 		&i.DefaultOutLang,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.StrictOrder,
+		&i.Kind,
 	)
 	return i, err
 }
 
 const getStackSteps = `-- name: GetStackSteps :many
-SELECT action_id FROM stack_steps WHERE stack_id = ? ORDER BY position
+SELECT action_id, params, branch FROM stack_steps WHERE stack_id = ? ORDER BY position
 `
 
-func (q *Queries) GetStackSteps(ctx context.Context, stackID string) ([]string, error) {
+type GetStackStepsRow struct {
+	ActionID string
+	Params   string
+	Branch   string
+}
+
+func (q *Queries) GetStackSteps(ctx context.Context, stackID string) ([]GetStackStepsRow, error) {
 	rows, err := q.db.QueryContext(ctx, getStackSteps, stackID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []GetStackStepsRow
 	for rows.Next() {
-		var action_id string
-		if err := rows.Scan(&action_id); err != nil {
+		var i GetStackStepsRow
+		if err := rows.Scan(&i.ActionID, &i.Params, &i.Branch); err != nil {
 			return nil, err
 		}
-		items = append(items, action_id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -75,8 +83,8 @@ func (q *Queries) GetStackSteps(ctx context.Context, stackID string) ([]string,
 }
 
 const insertStack = `-- name: InsertStack :exec
-INSERT INTO stacks (id, name, icon, default_format, default_in_lang, default_out_lang, created_at, updated_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO stacks (id, name, icon, default_format, default_in_lang, default_out_lang, strict_order, kind, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type InsertStackParams struct {
@@ -86,6 +94,8 @@ type InsertStackParams struct {
 	DefaultFormat  string
 	DefaultInLang  string
 	DefaultOutLang string
+	StrictOrder    int64
+	Kind           string
 	CreatedAt      int64
 	UpdatedAt      int64
 }
@@ -98,6 +108,8 @@ func (q *Queries) InsertStack(ctx context.Context, arg InsertStackParams) error
 		arg.DefaultFormat,
 		arg.DefaultInLang,
 		arg.DefaultOutLang,
+		arg.StrictOrder,
+		arg.Kind,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
@@ -105,22 +117,30 @@ func (q *Queries) InsertStack(ctx context.Context, arg InsertStackParams) error
 }
 
 const insertStackStep = `-- name: InsertStackStep :exec
-INSERT INTO stack_steps (stack_id, position, action_id) VALUES (?, ?, ?)
+INSERT INTO stack_steps (stack_id, position, action_id, params, branch) VALUES (?, ?, ?, ?, ?)
 `
 
 type InsertStackStepParams struct {
 	StackID  string
 	Position int64
 	ActionID string
+	Params   string
+	Branch   string
 }
 
 func (q *Queries) InsertStackStep(ctx context.Context, arg InsertStackStepParams) error {
-	_, err := q.db.ExecContext(ctx, insertStackStep, arg.StackID, arg.Position, arg.ActionID)
+	_, err := q.db.ExecContext(ctx, insertStackStep,
+		arg.StackID,
+		arg.Position,
+		arg.ActionID,
+		arg.Params,
+		arg.Branch,
+	)
 	return err
 }
 
 const listStacks = `-- name: ListStacks :many
-SELECT id, name, icon, default_format, default_in_lang, default_out_lang, created_at, updated_at FROM stacks ORDER BY name
+SELECT id, name, icon, default_format, default_in_lang, default_out_lang, created_at, updated_at, strict_order, kind FROM stacks ORDER BY name
 `
 
 func (q *Queries) ListStacks(ctx context.Context) ([]Stack, error) {
@@ -141,6 +161,8 @@ func (q *Queries) ListStacks(ctx context.Context) ([]Stack, error) {
 			&i.DefaultOutLang,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.StrictOrder,
+			&i.Kind,
 		); err != nil {
 			return nil, err
 		}
@@ -157,7 +179,7 @@ func (q *Queries) ListStacks(ctx context.Context) ([]Stack, error) {
 
 const updateStack = `-- name: UpdateStack :exec
 UPDATE stacks SET
-  name = ?, icon = ?, default_format = ?, default_in_lang = ?, default_out_lang = ?, updated_at = ?
+  name = ?, icon = ?, default_format = ?, default_in_lang = ?, default_out_lang = ?, strict_order = ?, kind = ?, updated_at = ?
 WHERE id = ?
 `
 
@@ -167,6 +189,8 @@ type UpdateStackParams struct {
 	DefaultFormat  string
 	DefaultInLang  string
 	DefaultOutLang string
+	StrictOrder    int64
+	Kind           string
 	UpdatedAt      int64
 	ID             string
 }
@@ -178,6 +202,8 @@ func (q *Queries) UpdateStack(ctx context.Context, arg UpdateStackParams) error
 		arg.DefaultFormat,
 		arg.DefaultInLang,
 		arg.DefaultOutLang,
+		arg.StrictOrder,
+		arg.Kind,
 		arg.UpdatedAt,
 		arg.ID,
 	)
@@ -0,0 +1,297 @@
+// Package diff compares two texts word by word or sentence by sentence and
+// reports the changes as hunks plus change statistics. Tokens are found with
+// Unicode character classes, so any script compares sensibly; Markdown syntax
+// can be told apart from text so reformatting does not count as rewriting.
+package diff
+
+import (
+	"slices"
+	"strings"
+
+	"go_text/internal/apperr"
+)
+
+// Granularities accepted by Compare.
+const (
+	GranularityWord     = "word"
+	GranularitySentence = "sentence"
+)
+
+// Hunk operations.
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxEditCost bounds the edit script search. Texts that differ by more tokens
+// than this (after their common start and end) are reported as replaced
+// wholesale, which keeps time and memory bounded for long, unrelated texts.
+const maxEditCost = 2000
+
+// Compare diffs req.Before against req.After. It returns a validation error for
+// an unknown granularity.
+func Compare(req apperr.DiffRequest) (*apperr.TextDiff, error) {
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = GranularityWord
+	}
+	var tokenize func(string, bool) []token
+	switch granularity {
+	case GranularityWord:
+		tokenize = tokenizeWords
+	case GranularitySentence:
+		tokenize = tokenizeSentences
+	default:
+		return nil, apperr.Validation("granularity", `"word" or "sentence"`, req.Granularity)
+	}
+
+	before, after := tokenize(req.Before, req.Markdown), tokenize(req.After, req.Markdown)
+	edits := shortestEdit(intern(before, after))
+	return &apperr.TextDiff{
+		Granularity: granularity,
+		Hunks:       hunks(edits, before, after),
+		Stats:       stats(edits, before, after),
+	}, nil
+}
+
+// ChangeRatio returns the share of words and punctuation that differ between
+// before and after, in [0, 1], ignoring Markdown formatting.
+func ChangeRatio(before, after string) float64 {
+	d, _ := Compare(apperr.DiffRequest{Before: before, After: after, Markdown: true})
+	return d.Stats.ChangeRatio
+}
+
+// CountWords returns the number of words in text, ignoring Markdown
+// formatting; see tokenizeWords for what a word is.
+func CountWords(text string) int {
+	n := 0
+	for _, t := range tokenizeWords(text, true) {
+		if t.kind == kindWord {
+			n++
+		}
+	}
+	return n
+}
+
+// edit is one step of an edit script: keep before[a] (equal to after[b]), delete
+// before[a], or insert after[b].
+type edit struct {
+	op   string
+	a, b int
+}
+
+// intern maps every distinct token of before and after to an int so the edit
+// search compares integers.
+func intern(before, after []token) ([]int, []int) {
+	ids := make(map[token]int)
+	conv := func(toks []token) []int {
+		out := make([]int, len(toks))
+		for i, t := range toks {
+			id, ok := ids[t]
+			if !ok {
+				id = len(ids)
+				ids[t] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	return conv(before), conv(after)
+}
+
+// shortestEdit returns a shortest edit script turning a into b. The common
+// start and end are matched directly; the middle is searched with Myers'
+// algorithm.
+func shortestEdit(a, b []int) []edit {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	for i := 0; i < pre; i++ {
+		edits = append(edits, edit{OpEqual, i, i})
+	}
+	for _, e := range myers(a[pre:len(a)-suf], b[pre:len(b)-suf]) {
+		edits = append(edits, edit{e.op, e.a + pre, e.b + pre})
+	}
+	for i := suf; i > 0; i-- {
+		edits = append(edits, edit{OpEqual, len(a) - i, len(b) - i})
+	}
+	return edits
+}
+
+// myers is the greedy O((N+M)D) shortest edit search of Myers (1986). trace[d]
+// keeps the furthest-reaching x of every diagonal k in [-(d-1), d-1] before
+// round d, which is what backtracking needs.
+func myers(a, b []int) []edit {
+	n, m := len(a), len(b)
+	if n+m == 0 {
+		return nil
+	}
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	trace := make([][]int, 0, 16)
+	for d := 0; d <= n+m; d++ {
+		if d > maxEditCost {
+			return replaceAll(n, m)
+		}
+		if d == 0 {
+			trace = append(trace, nil)
+		} else {
+			trace = append(trace, slices.Clone(v[offset-d+1:offset+d]))
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	return replaceAll(n, m)
+}
+
+// backtrack walks trace from (n, m) back to the origin and returns the edit
+// script in order.
+func backtrack(trace [][]int, n, m int) []edit {
+	x, y := n, m
+	var rev []edit
+	for d := len(trace) - 1; d > 0; d-- {
+		vd := trace[d]
+		at := func(k int) int { return vd[k+d-1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, edit{OpEqual, x, y})
+		}
+		if x == prevX {
+			y--
+			rev = append(rev, edit{OpInsert, x, y})
+		} else {
+			x--
+			rev = append(rev, edit{OpDelete, x, y})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		rev = append(rev, edit{OpEqual, x, y})
+	}
+	slices.Reverse(rev)
+	return rev
+}
+
+// replaceAll is the edit script that deletes all n tokens and inserts all m.
+func replaceAll(n, m int) []edit {
+	edits := make([]edit, 0, n+m)
+	for i := 0; i < n; i++ {
+		edits = append(edits, edit{OpDelete, i, 0})
+	}
+	for j := 0; j < m; j++ {
+		edits = append(edits, edit{OpInsert, n, j})
+	}
+	return edits
+}
+
+// hunks merges edits into hunks. Within a change, every deletion is reported
+// before every insertion, and a whitespace token between two changed tokens is
+// folded into both, so "a b" → "x y" reads as one replacement rather than two.
+func hunks(edits []edit, before, after []token) []apperr.DiffHunk {
+	out := make([]apperr.DiffHunk, 0)
+	var equal, del, ins strings.Builder
+	flush := func(op string, b *strings.Builder) {
+		if b.Len() > 0 {
+			out = append(out, apperr.DiffHunk{Op: op, Text: b.String()})
+			b.Reset()
+		}
+	}
+	inChange := func() bool { return del.Len() > 0 || ins.Len() > 0 }
+
+	for i, e := range edits {
+		switch e.op {
+		case OpEqual:
+			t := before[e.a]
+			if t.kind == kindSpace && inChange() && i+1 < len(edits) && edits[i+1].op != OpEqual {
+				del.WriteString(t.text)
+				ins.WriteString(t.text)
+				continue
+			}
+			flush(OpDelete, &del)
+			flush(OpInsert, &ins)
+			equal.WriteString(t.text)
+		case OpDelete:
+			flush(OpEqual, &equal)
+			del.WriteString(before[e.a].text)
+		case OpInsert:
+			flush(OpEqual, &equal)
+			ins.WriteString(after[e.b].text)
+		}
+	}
+	flush(OpEqual, &equal)
+	flush(OpDelete, &del)
+	flush(OpInsert, &ins)
+	return out
+}
+
+// stats counts the text tokens of edits. Each change — the edits between two
+// unchanged text tokens — adds the larger of its deleted and inserted counts to
+// the edit distance, so a replaced word costs one edit rather than two.
+func stats(edits []edit, before, after []token) apperr.DiffStats {
+	var s apperr.DiffStats
+	del, ins := 0, 0
+	endChange := func() {
+		s.EditDistance += max(del, ins)
+		del, ins = 0, 0
+	}
+	for _, e := range edits {
+		switch e.op {
+		case OpEqual:
+			if before[e.a].counted() {
+				s.Unchanged++
+				endChange()
+			}
+		case OpDelete:
+			if before[e.a].counted() {
+				s.Deleted++
+				del++
+			}
+		case OpInsert:
+			if after[e.b].counted() {
+				s.Inserted++
+				ins++
+			}
+		}
+	}
+	endChange()
+
+	s.BeforeTokens = s.Unchanged + s.Deleted
+	s.AfterTokens = s.Unchanged + s.Inserted
+	if longest := max(s.BeforeTokens, s.AfterTokens); longest > 0 {
+		s.ChangeRatio = float64(s.EditDistance) / float64(longest)
+	}
+	return s
+}
@@ -0,0 +1,270 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_text/internal/apperr"
+)
+
+// rebuild joins the hunks of d that make up the before (or after) text.
+func rebuild(d *apperr.TextDiff, after bool) string {
+	var b strings.Builder
+	for _, h := range d.Hunks {
+		if h.Op == OpEqual || (h.Op == OpInsert) == after {
+			b.WriteString(h.Text)
+		}
+	}
+	return b.String()
+}
+
+func TestCompare_Hunks(t *testing.T) {
+	tests := []struct {
+		name string
+		req  apperr.DiffRequest
+		want []apperr.DiffHunk
+	}{
+		{
+			name: "identical",
+			req:  apperr.DiffRequest{Before: "same text", After: "same text"},
+			want: []apperr.DiffHunk{{Op: OpEqual, Text: "same text"}},
+		},
+		{
+			name: "both empty",
+			req:  apperr.DiffRequest{},
+			want: []apperr.DiffHunk{},
+		},
+		{
+			name: "one word replaced",
+			req:  apperr.DiffRequest{Before: "the quick brown fox", After: "the slow brown fox"},
+			want: []apperr.DiffHunk{
+				{Op: OpEqual, Text: "the "},
+				{Op: OpDelete, Text: "quick"},
+				{Op: OpInsert, Text: "slow"},
+				{Op: OpEqual, Text: " brown fox"},
+			},
+		},
+		{
+			name: "adjacent words read as one replacement",
+			req:  apperr.DiffRequest{Before: "a b c", After: "x y c"},
+			want: []apperr.DiffHunk{
+				{Op: OpDelete, Text: "a b"},
+				{Op: OpInsert, Text: "x y"},
+				{Op: OpEqual, Text: " c"},
+			},
+		},
+		{
+			name: "punctuation is its own token",
+			req:  apperr.DiffRequest{Before: "Hello world", After: "Hello, world!"},
+			want: []apperr.DiffHunk{
+				{Op: OpEqual, Text: "Hello"},
+				{Op: OpInsert, Text: ","},
+				{Op: OpEqual, Text: " world"},
+				{Op: OpInsert, Text: "!"},
+			},
+		},
+		{
+			name: "contractions and compounds stay whole",
+			req:  apperr.DiffRequest{Before: "don't over-think it", After: "do not over-think it"},
+			want: []apperr.DiffHunk{
+				{Op: OpDelete, Text: "don't"},
+				{Op: OpInsert, Text: "do not"},
+				{Op: OpEqual, Text: " over-think it"},
+			},
+		},
+		{
+			name: "cyrillic words",
+			req:  apperr.DiffRequest{Before: "Привіт світ", After: "Привіт, світе"},
+			want: []apperr.DiffHunk{
+				{Op: OpEqual, Text: "Привіт"},
+				{Op: OpDelete, Text: " світ"},
+				{Op: OpInsert, Text: ", світе"},
+			},
+		},
+		{
+			name: "han characters compare one by one",
+			req:  apperr.DiffRequest{Before: "我喜欢猫", After: "我喜欢狗"},
+			want: []apperr.DiffHunk{
+				{Op: OpEqual, Text: "我喜欢"},
+				{Op: OpDelete, Text: "猫"},
+				{Op: OpInsert, Text: "狗"},
+			},
+		},
+		{
+			name: "sentences",
+			req: apperr.DiffRequest{
+				Before:      "First one. Second one! Third?",
+				After:       "First one. The second one! Third?",
+				Granularity: GranularitySentence,
+			},
+			want: []apperr.DiffHunk{
+				{Op: OpEqual, Text: "First one. "},
+				{Op: OpDelete, Text: "Second one!"},
+				{Op: OpInsert, Text: "The second one!"},
+				{Op: OpEqual, Text: " Third?"},
+			},
+		},
+		{
+			name: "markdown emphasis is a token of its own",
+			req:  apperr.DiffRequest{Before: "a bold move", After: "a **bold** move", Markdown: true},
+			want: []apperr.DiffHunk{
+				{Op: OpEqual, Text: "a "},
+				{Op: OpInsert, Text: "**"},
+				{Op: OpEqual, Text: "bold"},
+				{Op: OpInsert, Text: "**"},
+				{Op: OpEqual, Text: " move"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compare(tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Hunks)
+			assert.Equal(t, tt.req.Before, rebuild(got, false))
+			assert.Equal(t, tt.req.After, rebuild(got, true))
+		})
+	}
+}
+
+func TestCompare_Stats(t *testing.T) {
+	tests := []struct {
+		name string
+		req  apperr.DiffRequest
+		want apperr.DiffStats
+	}{
+		{
+			name: "identical",
+			req:  apperr.DiffRequest{Before: "one two", After: "one two"},
+			want: apperr.DiffStats{BeforeTokens: 2, AfterTokens: 2, Unchanged: 2},
+		},
+		{
+			name: "replaced word counts once",
+			req:  apperr.DiffRequest{Before: "one two three four", After: "one 2 three four"},
+			want: apperr.DiffStats{BeforeTokens: 4, AfterTokens: 4, Unchanged: 3, Inserted: 1, Deleted: 1, EditDistance: 1, ChangeRatio: 0.25},
+		},
+		{
+			name: "insertion",
+			req:  apperr.DiffRequest{Before: "one two", After: "one and two"},
+			want: apperr.DiffStats{BeforeTokens: 2, AfterTokens: 3, Unchanged: 2, Inserted: 1, EditDistance: 1, ChangeRatio: 1.0 / 3},
+		},
+		{
+			name: "fully rewritten",
+			req:  apperr.DiffRequest{Before: "alpha beta", After: "gamma"},
+			want: apperr.DiffStats{BeforeTokens: 2, AfterTokens: 1, Inserted: 1, Deleted: 2, EditDistance: 2, ChangeRatio: 1},
+		},
+		{
+			name: "whitespace is not counted",
+			req:  apperr.DiffRequest{Before: "one  two", After: "one two\n"},
+			want: apperr.DiffStats{BeforeTokens: 2, AfterTokens: 2, Unchanged: 2},
+		},
+		{
+			name: "markdown formatting is not counted",
+			req: apperr.DiffRequest{
+				Before:   "Title\n\nfirst item\nsecond item",
+				After:    "# Title\n\n- first item\n- second item",
+				Markdown: true,
+			},
+			want: apperr.DiffStats{BeforeTokens: 5, AfterTokens: 5, Unchanged: 5},
+		},
+		{
+			name: "without markdown the same markers count",
+			req: apperr.DiffRequest{
+				Before: "Title",
+				After:  "# Title",
+			},
+			want: apperr.DiffStats{BeforeTokens: 1, AfterTokens: 2, Unchanged: 1, Inserted: 1, EditDistance: 1, ChangeRatio: 0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compare(tt.req)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want.ChangeRatio, got.Stats.ChangeRatio, 1e-9)
+			got.Stats.ChangeRatio = tt.want.ChangeRatio
+			assert.Equal(t, tt.want, got.Stats)
+		})
+	}
+}
+
+func TestCompare_Granularity(t *testing.T) {
+	got, err := Compare(apperr.DiffRequest{Before: "a", After: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, GranularityWord, got.Granularity)
+
+	_, err = Compare(apperr.DiffRequest{Before: "a", After: "b", Granularity: "char"})
+	var ae *apperr.AppError
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, apperr.CodeValidation, ae.Code)
+}
+
+// TestCompare_RoundTrip checks that hunks rebuild both texts for edits spread
+// through a longer text, including past the edit search bound.
+func TestCompare_RoundTrip(t *testing.T) {
+	var before, after strings.Builder
+	for i := 0; i < 3000; i++ {
+		word := []string{"alpha", "beta", "gamma", "delta"}[i%4]
+		before.WriteString(word + " ")
+		if i%2 == 0 {
+			after.WriteString("x" + word + " ")
+		} else {
+			after.WriteString(word + " ")
+		}
+	}
+
+	for _, size := range []int{300, 3000} {
+		b, a := before.String(), after.String()
+		cutB, cutA := nthSpace(b, size), nthSpace(a, size)
+		req := apperr.DiffRequest{Before: b[:cutB], After: a[:cutA]}
+		got, err := Compare(req)
+		require.NoError(t, err)
+		assert.Equal(t, req.Before, rebuild(got, false))
+		assert.Equal(t, req.After, rebuild(got, true))
+		assert.Greater(t, got.Stats.ChangeRatio, 0.0)
+		assert.LessOrEqual(t, got.Stats.ChangeRatio, 1.0)
+	}
+}
+
+func nthSpace(s string, n int) int {
+	for i, r := range s {
+		if r == ' ' {
+			n--
+			if n == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+func TestChangeRatio(t *testing.T) {
+	assert.Equal(t, 0.0, ChangeRatio("same", "same"))
+	assert.Equal(t, 0.0, ChangeRatio("", ""))
+	assert.Equal(t, 0.0, ChangeRatio("plain words", "**plain** words"))
+	assert.Equal(t, 0.5, ChangeRatio("two words", "two birds"))
+	assert.Equal(t, 1.0, ChangeRatio("", "new"))
+}
+
+func TestCountWords(t *testing.T) {
+	assert.Equal(t, 0, CountWords(""))
+	assert.Equal(t, 3, CountWords("## Don't stop now!"))
+	assert.Equal(t, 3, CountWords("- **well-known** [link](http://x.y) 42"))
+	assert.Equal(t, 2, CountWords("你好"))
+}
+
+func TestTokenizeSentences(t *testing.T) {
+	var got []string
+	for _, tok := range tokenizeSentences("He said \"Stop.\" Then left… Done!\n- Item 3.5 works\n你好。再见。", true) {
+		got = append(got, tok.text)
+	}
+	assert.Equal(t, []string{
+		`He said "Stop."`, " ", "Then left…", " ", "Done!", "\n",
+		"-", " ", "Item 3.5 works", "\n",
+		"你好。", "再见。",
+	}, got)
+}
@@ -0,0 +1,158 @@
+package diff
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_text/internal/apperr"
+)
+
+// CriticMarkup renders d as the after text with every change marked up:
+// insertions as {++text++} and deletions as {--text--}. Deletions come before
+// the insertions that replace them, as in the hunks.
+func CriticMarkup(d *apperr.TextDiff) string {
+	var b strings.Builder
+	for _, h := range d.Hunks {
+		switch h.Op {
+		case OpInsert:
+			b.WriteString("{++" + h.Text + "++}")
+		case OpDelete:
+			b.WriteString("{--" + h.Text + "--}")
+		default:
+			b.WriteString(h.Text)
+		}
+	}
+	return b.String()
+}
+
+// Parts of the .docx package written by TrackedChangesDocx.
+const (
+	docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`</Types>`
+	docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+		`</Relationships>`
+	docxDocumentStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`
+	docxDocumentEnd = `<w:sectPr/></w:body></w:document>`
+)
+
+// TrackedChangesDocx renders d as a Word document whose text is the before text
+// with every change recorded as a tracked revision (w:ins / w:del) by author at
+// the given time, so Word shows the after text with the changes to accept or
+// reject. Each line of the text is a paragraph; a line break that was inserted
+// or deleted is a revision of its paragraph mark.
+func TrackedChangesDocx(d *apperr.TextDiff, author string, at time.Time) ([]byte, error) {
+	w := &docxWriter{
+		attrs: fmt.Sprintf(` w:author="%s" w:date="%s"`, escapeXML(author), at.UTC().Format(time.RFC3339)),
+	}
+	w.body.WriteString(docxDocumentStart)
+	for _, h := range d.Hunks {
+		lines := strings.Split(strings.ReplaceAll(h.Text, "\r", ""), "\n")
+		for i, line := range lines {
+			if line != "" {
+				w.run(h.Op, line)
+			}
+			if i < len(lines)-1 {
+				w.endParagraph(h.Op)
+			}
+		}
+	}
+	w.endParagraph(OpEqual)
+	w.body.WriteString(docxDocumentEnd)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"word/document.xml", w.body.String()},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("create %s: %w", part.name, err)
+		}
+		if _, err := f.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("write %s: %w", part.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("close docx: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// docxWriter accumulates document.xml. Runs are buffered per paragraph because
+// a paragraph's mark revision goes in its properties, ahead of its runs.
+type docxWriter struct {
+	body  strings.Builder
+	para  strings.Builder
+	attrs string // author and date shared by every revision
+	id    int
+}
+
+// revision returns the attributes of the next revision: a unique id plus attrs.
+func (w *docxWriter) revision() string {
+	w.id++
+	return fmt.Sprintf(` w:id="%d"%s`, w.id, w.attrs)
+}
+
+// run appends text to the current paragraph as an unchanged, inserted or
+// deleted run. Tabs become w:tab elements.
+func (w *docxWriter) run(op, text string) {
+	tag := "w:t"
+	if op == OpDelete {
+		tag = "w:delText"
+	}
+	var r strings.Builder
+	r.WriteString("<w:r>")
+	for i, part := range strings.Split(text, "\t") {
+		if i > 0 {
+			r.WriteString("<w:tab/>")
+		}
+		if part != "" {
+			fmt.Fprintf(&r, `<%s xml:space="preserve">%s</%s>`, tag, escapeXML(part), tag)
+		}
+	}
+	r.WriteString("</w:r>")
+
+	switch op {
+	case OpInsert:
+		fmt.Fprintf(&w.para, "<w:ins%s>%s</w:ins>", w.revision(), r.String())
+	case OpDelete:
+		fmt.Fprintf(&w.para, "<w:del%s>%s</w:del>", w.revision(), r.String())
+	default:
+		w.para.WriteString(r.String())
+	}
+}
+
+// endParagraph closes the current paragraph; op says whether its mark, the line
+// break, was unchanged, inserted or deleted.
+func (w *docxWriter) endParagraph(op string) {
+	w.body.WriteString("<w:p>")
+	switch op {
+	case OpInsert:
+		fmt.Fprintf(&w.body, "<w:pPr><w:rPr><w:ins%s/></w:rPr></w:pPr>", w.revision())
+	case OpDelete:
+		fmt.Fprintf(&w.body, "<w:pPr><w:rPr><w:del%s/></w:rPr></w:pPr>", w.revision())
+	}
+	w.body.WriteString(w.para.String())
+	w.body.WriteString("</w:p>")
+	w.para.Reset()
+}
+
+// escapeXML escapes s for use in XML text and attribute values.
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
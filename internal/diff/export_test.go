@@ -0,0 +1,108 @@
+package diff
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_text/internal/apperr"
+)
+
+func TestCriticMarkup(t *testing.T) {
+	tests := []struct {
+		name string
+		req  apperr.DiffRequest
+		want string
+	}{
+		{name: "unchanged", req: apperr.DiffRequest{Before: "same", After: "same"}, want: "same"},
+		{
+			name: "replacement",
+			req:  apperr.DiffRequest{Before: "the quick fox", After: "the slow fox"},
+			want: "the {--quick--}{++slow++} fox",
+		},
+		{
+			name: "insertion and deletion",
+			req:  apperr.DiffRequest{Before: "Hello world again", After: "Hello, world"},
+			want: "Hello{++,++} world{-- again--}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Compare(tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, CriticMarkup(d))
+		})
+	}
+}
+
+// docxDocument returns word/document.xml from a .docx package.
+func docxDocument(t *testing.T, data []byte) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	names := make([]string, 0, len(zr.File))
+	var doc []byte
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name == "word/document.xml" {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			doc, err = io.ReadAll(rc)
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+		}
+	}
+	assert.ElementsMatch(t, []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml"}, names)
+	return string(doc)
+}
+
+func TestTrackedChangesDocx(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	rev := func(id string) string {
+		return ` w:id="` + id + `" w:author="M &amp; Co" w:date="2026-05-01T12:00:00Z"`
+	}
+	tests := []struct {
+		name string
+		req  apperr.DiffRequest
+		want string
+	}{
+		{
+			name: "replacement",
+			req:  apperr.DiffRequest{Before: "a <b> c", After: "a x c"},
+			want: `<w:p><w:r><w:t xml:space="preserve">a </w:t></w:r>` +
+				`<w:del` + rev("1") + `><w:r><w:delText xml:space="preserve">&lt;b&gt;</w:delText></w:r></w:del>` +
+				`<w:ins` + rev("2") + `><w:r><w:t xml:space="preserve">x</w:t></w:r></w:ins>` +
+				`<w:r><w:t xml:space="preserve"> c</w:t></w:r></w:p>`,
+		},
+		{
+			name: "inserted paragraph break",
+			req:  apperr.DiffRequest{Before: "one two", After: "one\ntwo"},
+			want: `<w:p><w:pPr><w:rPr><w:ins` + rev("2") + `/></w:rPr></w:pPr>` +
+				`<w:r><w:t xml:space="preserve">one</w:t></w:r>` +
+				`<w:del` + rev("1") + `><w:r><w:delText xml:space="preserve"> </w:delText></w:r></w:del></w:p>` +
+				`<w:p><w:r><w:t xml:space="preserve">two</w:t></w:r></w:p>`,
+		},
+		{
+			name: "tab",
+			req:  apperr.DiffRequest{Before: "a\tb", After: "a\tb"},
+			want: `<w:p><w:r><w:t xml:space="preserve">a</w:t><w:tab/><w:t xml:space="preserve">b</w:t></w:r></w:p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Compare(tt.req)
+			require.NoError(t, err)
+			data, err := TrackedChangesDocx(d, "M & Co", at)
+			require.NoError(t, err)
+			doc := docxDocument(t, data)
+			assert.Equal(t, docxDocumentStart+tt.want+docxDocumentEnd, doc)
+			assert.NoError(t, xml.Unmarshal([]byte(doc), new(struct{})), "document.xml must be well-formed")
+		})
+	}
+}
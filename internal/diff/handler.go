@@ -0,0 +1,52 @@
+package diff
+
+import (
+	"fmt"
+
+	"go_text/internal/apperr"
+	"go_text/internal/logging"
+
+	"github.com/rs/zerolog"
+)
+
+const panicMsgFmt = "panic: %v"
+
+// DiffHandler is the Wails-bound handler for comparing texts.
+// All bound methods follow the envelope pattern: return apperr.*Result,
+// no error return, and include defer/recover for panic safety.
+type DiffHandler struct {
+	appLogger *logging.Logger
+}
+
+// NewDiffHandler constructs a DiffHandler.
+func NewDiffHandler(appLogger *logging.Logger) *DiffHandler {
+	return &DiffHandler{appLogger: appLogger}
+}
+
+// liveZlog returns a live snapshot of the app logger's current writer, or a
+// no-op logger if appLogger has not been wired (e.g. bare struct-literal
+// tests exercising panic recovery).
+func (h *DiffHandler) liveZlog() zerolog.Logger {
+	if h.appLogger != nil {
+		return h.appLogger.ZeroLogger()
+	}
+	return zerolog.Nop()
+}
+
+// DiffTexts compares req.Before with req.After and returns the hunks and change
+// statistics.
+func (h *DiffHandler) DiffTexts(req apperr.DiffRequest) (res apperr.TextDiffResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.TextDiffResult{Error: &wire}
+		}
+	}()
+	data, err := Compare(req)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.TextDiffResult{Error: &wire}
+	}
+	return apperr.TextDiffResult{Data: data}
+}
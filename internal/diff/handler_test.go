@@ -0,0 +1,32 @@
+package diff
+
+import (
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+func TestDiffHandler_DiffTexts_Success(t *testing.T) {
+	h := NewDiffHandler(nil)
+	res := h.DiffTexts(apperr.DiffRequest{Before: "a cat", After: "a dog"})
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %+v", res.Error)
+	}
+	if res.Data == nil || len(res.Data.Hunks) != 3 {
+		t.Fatalf("unexpected data: %+v", res.Data)
+	}
+	if res.Data.Stats.ChangeRatio != 0.5 {
+		t.Errorf("ChangeRatio = %v, want 0.5", res.Data.Stats.ChangeRatio)
+	}
+}
+
+func TestDiffHandler_DiffTexts_InvalidGranularity(t *testing.T) {
+	h := NewDiffHandler(nil)
+	res := h.DiffTexts(apperr.DiffRequest{Before: "a", After: "b", Granularity: "paragraph"})
+	if res.Error == nil {
+		t.Fatal("expected error in result")
+	}
+	if res.Error.Code != apperr.CodeValidation {
+		t.Errorf("Code = %q, want %q", res.Error.Code, apperr.CodeValidation)
+	}
+}
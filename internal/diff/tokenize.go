@@ -0,0 +1,279 @@
+package diff
+
+import (
+	"unicode"
+)
+
+// tokenKind classifies a token; only words and punctuation count as text.
+type tokenKind int
+
+const (
+	kindWord tokenKind = iota
+	kindPunct
+	kindSpace
+	kindMarkup
+)
+
+// token is the unit the diff compares: a word, a punctuation mark, a run of
+// whitespace, a piece of Markdown syntax, or a whole sentence.
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// counted reports whether t is text rather than spacing or formatting.
+func (t token) counted() bool { return t.kind == kindWord || t.kind == kindPunct }
+
+// tokenizeWords splits text into words, single punctuation marks and whitespace
+// runs. Words are runs of letters, marks and digits, joined across an inner
+// apostrophe, hyphen or underscore ("don't", "well-known", "snake_case"); Han,
+// Hiragana and Katakana characters are a word each, as those scripts do not
+// separate words with spaces. With markdown, heading, quote, list and rule
+// markers at the start of a line, emphasis and code markers and link syntax are
+// tokens of their own.
+func tokenizeWords(text string, markdown bool) []token {
+	runes := []rune(text)
+	lastLink := lastIndexOf(runes, ']', '(')
+	var toks []token
+	lineStart := true
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if unicode.IsSpace(r) {
+			j := spaceEnd(runes, i)
+			toks = append(toks, token{kindSpace, string(runes[i:j])})
+			lineStart = lineStart || containsNewline(runes[i:j])
+			i = j
+			continue
+		}
+		if markdown {
+			n := 0
+			if lineStart {
+				n = blockMarkerLen(runes, i)
+			}
+			if n == 0 {
+				n = inlineMarkupLen(runes, i, i < lastLink)
+			}
+			if n > 0 {
+				toks = append(toks, token{kindMarkup, string(runes[i : i+n])})
+				lineStart = false
+				i += n
+				continue
+			}
+		}
+		lineStart = false
+		switch {
+		case isIdeograph(r):
+			toks = append(toks, token{kindWord, string(r)})
+			i++
+		case isWordRune(r):
+			j := i + 1
+			for j < len(runes) {
+				if isWordRune(runes[j]) && !isIdeograph(runes[j]) {
+					j++
+					continue
+				}
+				if j+1 < len(runes) && isJoiner(runes[j]) && isWordRune(runes[j+1]) && !isIdeograph(runes[j+1]) {
+					j += 2
+					continue
+				}
+				break
+			}
+			toks = append(toks, token{kindWord, string(runes[i:j])})
+			i = j
+		default:
+			toks = append(toks, token{kindPunct, string(r)})
+			i++
+		}
+	}
+	return toks
+}
+
+// tokenizeSentences splits text into sentences and the whitespace between them.
+// A sentence ends at a line break, or after terminal punctuation (with any
+// closing quotes or brackets) that is followed by whitespace; the CJK full stop,
+// exclamation and question marks end a sentence on their own. With markdown,
+// block markers at the start of a line are tokens of their own.
+func tokenizeSentences(text string, markdown bool) []token {
+	runes := []rune(text)
+	var toks []token
+	lineStart := true
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			j := spaceEnd(runes, i)
+			toks = append(toks, token{kindSpace, string(runes[i:j])})
+			lineStart = lineStart || containsNewline(runes[i:j])
+			i = j
+			continue
+		}
+		if markdown && lineStart {
+			if n := blockMarkerLen(runes, i); n > 0 {
+				toks = append(toks, token{kindMarkup, string(runes[i : i+n])})
+				lineStart = false
+				i += n
+				continue
+			}
+		}
+		lineStart = false
+
+		j := sentenceEnd(runes, i)
+		for j > i && unicode.IsSpace(runes[j-1]) {
+			j--
+		}
+		toks = append(toks, token{kindWord, string(runes[i:j])})
+		i = j
+	}
+	return toks
+}
+
+// sentenceEnd returns the index just past the sentence starting at i.
+func sentenceEnd(runes []rune, i int) int {
+	j := i
+	for j < len(runes) && runes[j] != '\n' {
+		if !isTerminator(runes[j]) {
+			j++
+			continue
+		}
+		k := j + 1
+		for k < len(runes) && (isTerminator(runes[k]) || isCloser(runes[k])) {
+			k++
+		}
+		if k == len(runes) || unicode.IsSpace(runes[k]) || isFullwidthTerminator(runes[j]) {
+			return k
+		}
+		j = k
+	}
+	return j
+}
+
+// blockMarkerLen returns the length of the Markdown block marker at i, the first
+// non-space position of a line: a heading, quote, bullet or numbered-list marker,
+// or a rule of three or more dashes. It returns 0 when there is none.
+func blockMarkerLen(runes []rune, i int) int {
+	spaceAt := func(j int) bool { return j < len(runes) && (runes[j] == ' ' || runes[j] == '\t') }
+	switch r := runes[i]; {
+	case r == '#':
+		j := i
+		for j < len(runes) && runes[j] == '#' {
+			j++
+		}
+		if j-i <= 6 && spaceAt(j) {
+			return j - i
+		}
+	case r == '>':
+		return 1
+	case r == '-' || r == '*' || r == '+':
+		if spaceAt(i + 1) {
+			return 1
+		}
+		j := i
+		for j < len(runes) && runes[j] == r {
+			j++
+		}
+		if j-i >= 3 && (j == len(runes) || runes[j] == '\n') {
+			return j - i
+		}
+	case r >= '0' && r <= '9':
+		j := i
+		for j < len(runes) && runes[j] >= '0' && runes[j] <= '9' {
+			j++
+		}
+		if j < len(runes) && (runes[j] == '.' || runes[j] == ')') && spaceAt(j+1) {
+			return j + 1 - i
+		}
+	}
+	return 0
+}
+
+// inlineMarkupLen returns the length of the inline Markdown syntax at i: a run
+// of emphasis or code markers, a strikethrough "~~", the opening "[" or "![" of
+// a link or image when one can still close (linkAhead), or a link's "](target)".
+// It returns 0 when there is none.
+func inlineMarkupLen(runes []rune, i int, linkAhead bool) int {
+	run := func(r rune) int {
+		j := i
+		for j < len(runes) && runes[j] == r {
+			j++
+		}
+		return j - i
+	}
+	switch r := runes[i]; r {
+	case '*', '_', '`':
+		return run(r)
+	case '~':
+		if n := run(r); n >= 2 {
+			return n
+		}
+	case '!':
+		if linkAhead && i+1 < len(runes) && runes[i+1] == '[' {
+			return 2
+		}
+	case '[':
+		if linkAhead {
+			return 1
+		}
+	case ']':
+		if i+1 < len(runes) && runes[i+1] == '(' {
+			for j := i + 2; j < len(runes) && !unicode.IsSpace(runes[j]); j++ {
+				if runes[j] == ')' {
+					return j + 1 - i
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// lastIndexOf returns the index of the last occurrence of the pair a, b in
+// runes, or -1.
+func lastIndexOf(runes []rune, a, b rune) int {
+	for i := len(runes) - 2; i >= 0; i-- {
+		if runes[i] == a && runes[i+1] == b {
+			return i
+		}
+	}
+	return -1
+}
+
+func spaceEnd(runes []rune, i int) int {
+	for i < len(runes) && unicode.IsSpace(runes[i]) {
+		i++
+	}
+	return i
+}
+
+func containsNewline(runes []rune) bool {
+	for _, r := range runes {
+		if r == '\n' {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+func isJoiner(r rune) bool {
+	return r == '\'' || r == '’' || r == '-' || r == '_'
+}
+
+func isTerminator(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…' || isFullwidthTerminator(r)
+}
+
+func isFullwidthTerminator(r rune) bool {
+	return r == '。' || r == '！' || r == '？'
+}
+
+func isCloser(r rune) bool {
+	switch r {
+	case '"', '\'', '”', '’', '»', '“', ')', ']', '」', '』':
+		return true
+	}
+	return false
+}
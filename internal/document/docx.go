@@ -0,0 +1,291 @@
+package document
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxEntryBytes caps how much of one zip entry a .docx or .odt import reads,
+// so a zip bomb cannot exhaust memory.
+const maxEntryBytes = 64 << 20
+
+// errNoEntry is returned by openEntry for an entry the archive does not have.
+var errNoEntry = errors.New("entry not found")
+
+// openEntry returns a reader of the zip entry name, capped at maxEntryBytes.
+func openEntry(zr *zip.Reader, name string) (io.ReadCloser, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		if f.UncompressedSize64 > maxEntryBytes {
+			return nil, fmt.Errorf("%s is larger than %d MiB", name, maxEntryBytes>>20)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(rc, maxEntryBytes), rc}, nil
+	}
+	return nil, fmt.Errorf("%s: %w", name, errNoEntry)
+}
+
+// walkEntry streams the XML tokens of the zip entry name to fn. A missing entry
+// is not an error when optional is set.
+func walkEntry(zr *zip.Reader, name string, optional bool, fn func(*xml.Decoder, xml.Token) error) error {
+	rc, err := openEntry(zr, name)
+	if err != nil {
+		if optional && errors.Is(err, errNoEntry) {
+			return nil
+		}
+		return err
+	}
+	defer rc.Close()
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", name, err)
+		}
+		if err := fn(dec, tok); err != nil {
+			return err
+		}
+	}
+}
+
+// attr returns the value of the attribute with the given local name.
+func attr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// coreTitle returns dc:title from the document properties entry name
+// (docProps/core.xml in a .docx, meta.xml in an .odt).
+func coreTitle(zr *zip.Reader, name string) string {
+	var title strings.Builder
+	in := false
+	_ = walkEntry(zr, name, true, func(_ *xml.Decoder, tok xml.Token) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			in = t.Name.Local == "title"
+		case xml.EndElement:
+			in = false
+		case xml.CharData:
+			if in {
+				title.Write(t)
+			}
+		}
+		return nil
+	})
+	return strings.TrimSpace(title.String())
+}
+
+// docxNumbering maps a numbering ID and list level to whether the list is
+// numbered rather than bulleted, from word/numbering.xml.
+type docxNumbering map[string]map[int]bool
+
+// readDocxNumbering reads word/numbering.xml; a document without lists has none.
+func readDocxNumbering(zr *zip.Reader) (docxNumbering, error) {
+	abstract := map[string]map[int]bool{}
+	numToAbstract := map[string]string{}
+	var curAbstract, curNum string
+	level := 0
+	err := walkEntry(zr, "word/numbering.xml", true, func(_ *xml.Decoder, tok xml.Token) error {
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			return nil
+		}
+		switch el.Name.Local {
+		case "abstractNum":
+			curAbstract, curNum = attr(el, "abstractNumId"), ""
+			abstract[curAbstract] = map[int]bool{}
+		case "num":
+			curNum, curAbstract = attr(el, "numId"), ""
+		case "abstractNumId":
+			if curNum != "" {
+				numToAbstract[curNum] = attr(el, "val")
+			}
+		case "lvl":
+			level, _ = strconv.Atoi(attr(el, "ilvl"))
+		case "numFmt":
+			if curAbstract != "" {
+				numFmt := attr(el, "val")
+				abstract[curAbstract][level] = numFmt != "bullet" && numFmt != "none"
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	numbering := docxNumbering{}
+	for num, abs := range numToAbstract {
+		numbering[num] = abstract[abs]
+	}
+	return numbering, nil
+}
+
+// readDocxHeadingStyles maps paragraph style IDs to heading levels, from
+// word/styles.xml: styles named "heading N" or "Title", or with an outline
+// level. Style IDs are localized ("Überschrift1"), the names are not.
+func readDocxHeadingStyles(zr *zip.Reader) (map[string]int, error) {
+	headings := map[string]int{}
+	cur := ""
+	err := walkEntry(zr, "word/styles.xml", true, func(_ *xml.Decoder, tok xml.Token) error {
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			return nil
+		}
+		switch el.Name.Local {
+		case "style":
+			cur = ""
+			if attr(el, "type") == "paragraph" {
+				cur = attr(el, "styleId")
+			}
+		case "name":
+			if cur == "" {
+				return nil
+			}
+			name := strings.ToLower(attr(el, "val"))
+			if name == "title" {
+				headings[cur] = 1
+			} else if n, ok := strings.CutPrefix(name, "heading "); ok {
+				if level, err := strconv.Atoi(n); err == nil {
+					headings[cur] = level
+				}
+			}
+		case "outlineLvl":
+			if level, err := strconv.Atoi(attr(el, "val")); err == nil && cur != "" && level < 9 {
+				headings[cur] = level + 1
+			}
+		}
+		return nil
+	})
+	return headings, err
+}
+
+// docxParagraph is the state of the w:p being read.
+type docxParagraph struct {
+	text     strings.Builder
+	style    string
+	outline  int // w:outlineLvl + 1; 0 when unset
+	numID    string
+	numLevel int
+	listed   bool
+}
+
+// extractDocx reads the body of word/document.xml as blocks: paragraphs with a
+// heading style or outline level become headings and numbered paragraphs list
+// items. Tables come out one paragraph per cell paragraph and a text box's
+// paragraphs before the paragraph anchoring it; deleted revisions, field codes,
+// footnotes and comments are left out.
+func extractDocx(zr *zip.Reader) ([]block, string, error) {
+	numbering, err := readDocxNumbering(zr)
+	if err != nil {
+		return nil, "", err
+	}
+	headings, err := readDocxHeadingStyles(zr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var (
+		blocks []block
+		open   []*docxParagraph
+		inText bool
+	)
+	err = walkEntry(zr, "word/document.xml", false, func(dec *xml.Decoder, tok xml.Token) error {
+		var p *docxParagraph
+		if len(open) > 0 {
+			p = open[len(open)-1]
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "p" {
+				open = append(open, &docxParagraph{})
+				return nil
+			}
+			if p == nil {
+				return nil
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				// w:tab in w:pPr/w:tabs defines a tab stop; only a run's w:tab is text.
+				if attr(t, "val") == "" {
+					p.text.WriteString("\t")
+				}
+			case "br", "cr":
+				if attr(t, "type") == "" || attr(t, "type") == "textWrapping" {
+					p.text.WriteString("\n")
+				}
+			case "noBreakHyphen":
+				p.text.WriteString("-")
+			case "pStyle":
+				p.style = attr(t, "val")
+			case "outlineLvl":
+				if level, err := strconv.Atoi(attr(t, "val")); err == nil && level < 9 {
+					p.outline = level + 1
+				}
+			case "numPr":
+				p.listed = true
+			case "ilvl":
+				p.numLevel, _ = strconv.Atoi(attr(t, "val"))
+			case "numId":
+				p.numID = attr(t, "val")
+			case "del", "instrText", "footnoteReference", "endnoteReference", "commentReference":
+				return dec.Skip()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if p != nil {
+					blocks = append(blocks, p.block(numbering, headings))
+					open = open[:len(open)-1]
+				}
+			}
+		case xml.CharData:
+			if inText && p != nil {
+				p.text.Write(t)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return blocks, coreTitle(zr, "docProps/core.xml"), nil
+}
+
+// block classifies the finished paragraph. numId 0 turns numbering off.
+func (p *docxParagraph) block(numbering docxNumbering, headings map[string]int) block {
+	text := p.text.String()
+	if level := headings[p.style]; level > 0 {
+		return block{kind: blockHeading, level: level, text: text}
+	}
+	if p.outline > 0 {
+		return block{kind: blockHeading, level: p.outline, text: text}
+	}
+	if p.listed && p.numID != "" && p.numID != "0" {
+		return block{kind: blockListItem, level: p.numLevel, ordered: numbering[p.numID][p.numLevel], text: text}
+	}
+	return block{kind: blockParagraph, text: text}
+}
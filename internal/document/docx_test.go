@@ -0,0 +1,131 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+// zipBytes returns a zip archive holding files, keyed by entry name.
+func zipBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zipReader(t *testing.T, files map[string]string) *zip.Reader {
+	t.Helper()
+	data := zipBytes(t, files)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	return zr
+}
+
+const wordNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+
+const docxDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<w:document ` + wordNS + `><w:body>
+<w:p><w:pPr><w:pStyle w:val="Berschrift1"/></w:pPr><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>
+<w:p><w:r><w:t>Sales rose</w:t></w:r><w:del><w:r><w:delText>fell</w:delText></w:r></w:del><w:r><w:t xml:space="preserve"> by</w:t><w:tab/><w:t>5%</w:t></w:r><w:r><w:footnoteReference w:id="1"/></w:r></w:p>
+<w:p><w:pPr><w:tabs><w:tab w:val="left" w:pos="720"/></w:tabs><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>First step</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Detail</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Second step</w:t></w:r></w:p>
+<w:p><w:pPr><w:outlineLvl w:val="1"/></w:pPr><w:r><w:t>Next</w:t></w:r><w:r><w:br/><w:t>line</w:t><w:br w:type="page"/></w:r></w:p>
+<w:p><w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText>PAGE</w:instrText></w:r><w:r><w:t>well</w:t><w:noBreakHyphen/><w:t>known</w:t></w:r></w:p>
+</w:body></w:document>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<w:styles ` + wordNS + `>
+<w:style w:type="paragraph" w:styleId="Berschrift1"><w:name w:val="heading 1"/></w:style>
+<w:style w:type="character" w:styleId="Strong"><w:name w:val="Strong"/></w:style>
+</w:styles>`
+
+const docxNumberingXML = `<?xml version="1.0" encoding="UTF-8"?>
+<w:numbering ` + wordNS + `>
+<w:abstractNum w:abstractNumId="7">
+<w:lvl w:ilvl="0"><w:numFmt w:val="decimal"/></w:lvl>
+<w:lvl w:ilvl="1"><w:numFmt w:val="bullet"/></w:lvl>
+</w:abstractNum>
+<w:num w:numId="1"><w:abstractNumId w:val="7"/></w:num>
+</w:numbering>`
+
+const docxCore = `<?xml version="1.0" encoding="UTF-8"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title> Q3 Report </dc:title><dc:creator>Someone</dc:creator>
+</cp:coreProperties>`
+
+func TestExtractDocx(t *testing.T) {
+	zr := zipReader(t, map[string]string{
+		"word/document.xml":  docxDocument,
+		"word/styles.xml":    docxStyles,
+		"word/numbering.xml": docxNumberingXML,
+		"docProps/core.xml":  docxCore,
+	})
+
+	blocks, title, err := extractDocx(zr)
+	if err != nil {
+		t.Fatalf("extractDocx: %v", err)
+	}
+	if title != "Q3 Report" {
+		t.Errorf("title = %q, want %q", title, "Q3 Report")
+	}
+	want := "# Quarterly report\n\n" +
+		"Sales rose by\t5%\n\n" +
+		"1. First step\n" +
+		"  - Detail\n" +
+		"2. Second step\n\n" +
+		"## Next line\n\n" +
+		"well-known"
+	if got := renderMarkdown(blocks); got != want {
+		t.Errorf("markdown =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestExtractDocx_MinimalPackage(t *testing.T) {
+	zr := zipReader(t, map[string]string{
+		"word/document.xml": `<w:document ` + wordNS + `><w:body><w:p><w:r><w:t>Just text</w:t></w:r></w:p></w:body></w:document>`,
+	})
+
+	blocks, title, err := extractDocx(zr)
+	if err != nil {
+		t.Fatalf("extractDocx: %v", err)
+	}
+	if title != "" {
+		t.Errorf("title = %q, want empty", title)
+	}
+	if got := renderMarkdown(blocks); got != "Just text" {
+		t.Errorf("markdown = %q, want %q", got, "Just text")
+	}
+}
+
+func TestExtractDocx_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"missing document.xml", map[string]string{"word/styles.xml": docxStyles}},
+		{"malformed xml", map[string]string{"word/document.xml": `<w:document ` + wordNS + `><w:body><w:p>`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := extractDocx(zipReader(t, tt.files)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+
+	"go_text/internal/apperr"
+	"go_text/internal/logging"
+
+	"github.com/rs/zerolog"
+)
+
+const panicMsgFmt = "panic: %v"
+
+// DocumentHandler is the Wails-bound handler for document import.
+// All bound methods follow the envelope pattern: return apperr.*Result,
+// no error return, and include defer/recover for panic safety.
+type DocumentHandler struct {
+	appLogger *logging.Logger
+	service   DocumentServiceAPI
+}
+
+// NewDocumentHandler constructs a DocumentHandler.
+func NewDocumentHandler(appLogger *logging.Logger, service DocumentServiceAPI) *DocumentHandler {
+	return &DocumentHandler{appLogger: appLogger, service: service}
+}
+
+// liveZlog returns a live snapshot of the app logger's current writer, or a
+// no-op logger if appLogger has not been wired (e.g. bare struct-literal
+// tests exercising panic recovery).
+func (h *DocumentHandler) liveZlog() zerolog.Logger {
+	if h.appLogger != nil {
+		return h.appLogger.ZeroLogger()
+	}
+	return zerolog.Nop()
+}
+
+// ImportDocument reads a .docx, .odt, .html, .md or .txt file, typically chosen
+// with ChooseDocument, and returns its text as Markdown with its word count and
+// detected language.
+func (h *DocumentHandler) ImportDocument(path string) (res apperr.ImportedDocumentResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.ImportedDocumentResult{Error: &wire}
+		}
+	}()
+	if strings.TrimSpace(path) == "" {
+		ae := apperr.Validation("path", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.ImportedDocumentResult{Error: &wire}
+	}
+	doc, err := h.service.Import(path)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.ImportedDocumentResult{Error: &wire}
+	}
+	return apperr.ImportedDocumentResult{Data: doc}
+}
@@ -0,0 +1,68 @@
+package document
+
+import (
+	"errors"
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+type stubDocumentService struct {
+	doc   *apperr.ImportedDocument
+	err   error
+	panic bool
+	got   string
+}
+
+func (s *stubDocumentService) Import(path string) (*apperr.ImportedDocument, error) {
+	if s.panic {
+		panic("boom")
+	}
+	s.got = path
+	return s.doc, s.err
+}
+
+func TestDocumentHandler_ImportDocument_Success(t *testing.T) {
+	svc := &stubDocumentService{doc: &apperr.ImportedDocument{Name: "a.md", Text: "hi", WordCount: 1}}
+	h := NewDocumentHandler(nil, svc)
+
+	res := h.ImportDocument("/tmp/a.md")
+
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %+v", res.Error)
+	}
+	if res.Data == nil || res.Data.Name != "a.md" {
+		t.Errorf("unexpected data: %+v", res.Data)
+	}
+	if svc.got != "/tmp/a.md" {
+		t.Errorf("service got path %q, want %q", svc.got, "/tmp/a.md")
+	}
+}
+
+func TestDocumentHandler_ImportDocument_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		svc      *stubDocumentService
+		wantCode apperr.ErrorCode
+	}{
+		{"empty path", "  ", &stubDocumentService{}, apperr.CodeValidation},
+		{"service validation", "/tmp/a.pptx", &stubDocumentService{err: apperr.Validation("path", "a document", "a.pptx")}, apperr.CodeValidation},
+		{"service failure", "/tmp/a.md", &stubDocumentService{err: errors.New("disk error")}, apperr.CodeInternal},
+		{"panic", "/tmp/a.md", &stubDocumentService{panic: true}, apperr.CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &DocumentHandler{service: tt.svc}
+
+			res := h.ImportDocument(tt.path)
+
+			if res.Error == nil {
+				t.Fatal("expected error in result")
+			}
+			if res.Error.Code != tt.wantCode {
+				t.Errorf("Code = %q, want %q", res.Error.Code, tt.wantCode)
+			}
+		})
+	}
+}
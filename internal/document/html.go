@@ -0,0 +1,192 @@
+package document
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements hold no readable text of the page: scripts and styles,
+// navigation and page chrome, forms and embedded media.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Aside: true, atom.Footer: true, atom.Form: true,
+	atom.Button: true, atom.Select: true, atom.Textarea: true, atom.Svg: true,
+	atom.Iframe: true, atom.Canvas: true, atom.Object: true, atom.Video: true,
+	atom.Audio: true, atom.Head: true,
+}
+
+// blockElements start a new paragraph.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Figure: true, atom.Figcaption: true, atom.Table: true,
+	atom.Tr: true, atom.Td: true, atom.Th: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Address: true, atom.Details: true, atom.Summary: true, atom.Hr: true,
+}
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// htmlExtractor collects the blocks of a page as it walks the tree.
+type htmlExtractor struct {
+	blocks []block
+	text   strings.Builder
+	kind   blockKind // kind of the block text belongs to
+	level  int
+	order  bool
+	lists  []bool // open lists, true when ordered
+	quote  int    // depth of open blockquotes
+}
+
+// extractHTML returns the readable text of an HTML page as blocks, with the
+// page's <title>. Only the first <article>, else <main>, else <body> is read;
+// scripts, styles, navigation, asides, footers, forms, hidden elements and a
+// <header> outside the article are dropped.
+func extractHTML(data []byte) ([]block, string, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	title := ""
+	if n := findElement(doc, atom.Title); n != nil {
+		title = oneLine(textContent(n))
+	}
+	root := findElement(doc, atom.Article)
+	if root == nil {
+		root = findElement(doc, atom.Main)
+	}
+	if root == nil {
+		root = findElement(doc, atom.Body)
+	}
+	if root == nil {
+		root = doc
+	}
+
+	e := &htmlExtractor{}
+	e.walkChildren(root, root.DataAtom == atom.Body || root == doc)
+	e.flush()
+	return e.blocks, title, nil
+}
+
+// findElement returns the first element a in n's subtree, depth first.
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// textContent returns the text of n's subtree.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// hidden reports whether an element is not shown to readers.
+func hidden(n *html.Node) bool {
+	for _, a := range n.Attr {
+		switch {
+		case a.Key == "hidden",
+			a.Key == "aria-hidden" && a.Val == "true",
+			a.Key == "role" && (a.Val == "navigation" || a.Val == "banner" || a.Val == "contentinfo"):
+			return true
+		}
+	}
+	return false
+}
+
+func (e *htmlExtractor) walkChildren(n *html.Node, page bool) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		e.walk(c, page)
+	}
+}
+
+// walk adds n's text to the blocks. page is set while outside any article or
+// main element, where a <header> is the site's banner rather than content.
+func (e *htmlExtractor) walk(n *html.Node, page bool) {
+	switch n.Type {
+	case html.TextNode:
+		e.text.WriteString(collapseSpace(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		e.walkChildren(n, page)
+		return
+	}
+	if skippedElements[n.DataAtom] || hidden(n) || (page && n.DataAtom == atom.Header) {
+		return
+	}
+
+	switch a := n.DataAtom; {
+	case headingLevels[a] > 0:
+		e.flush()
+		e.blocks = append(e.blocks, block{kind: blockHeading, level: headingLevels[a], text: textContent(n)})
+	case a == atom.Br:
+		e.text.WriteString("\n")
+	case a == atom.Pre:
+		e.flush()
+		e.blocks = append(e.blocks, block{kind: blockCode, text: strings.TrimPrefix(textContent(n), "\n")})
+	case a == atom.Ul || a == atom.Ol:
+		e.flush()
+		e.lists = append(e.lists, a == atom.Ol)
+		e.walkChildren(n, page)
+		e.flush()
+		e.lists = e.lists[:len(e.lists)-1]
+	case a == atom.Li:
+		e.flush()
+		if len(e.lists) > 0 {
+			e.kind, e.level, e.order = blockListItem, len(e.lists)-1, e.lists[len(e.lists)-1]
+		}
+		e.walkChildren(n, page)
+		e.flush()
+		e.kind, e.level, e.order = blockParagraph, 0, false
+	case a == atom.Blockquote:
+		e.flush()
+		e.quote++
+		e.walkChildren(n, page)
+		e.flush()
+		e.quote--
+	case blockElements[a]:
+		e.flush()
+		e.walkChildren(n, page && a != atom.Article && a != atom.Main)
+		e.flush()
+	default:
+		e.walkChildren(n, page && a != atom.Article && a != atom.Main)
+	}
+}
+
+// flush ends the current block: its lines are trimmed and the block is kept if
+// it has text. Text after a list item's first block (past a nested list, say)
+// is a paragraph of its own; an empty block leaves the item waiting for text,
+// as in <li><p>text</p></li>.
+func (e *htmlExtractor) flush() {
+	lines := strings.Split(e.text.String(), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	e.text.Reset()
+	if text == "" {
+		return
+	}
+	b := block{kind: e.kind, level: e.level, ordered: e.order, text: text}
+	if b.kind == blockParagraph && e.quote > 0 {
+		b.kind = blockQuote
+	}
+	e.blocks = append(e.blocks, b)
+	e.kind, e.level, e.order = blockParagraph, 0, false
+}
@@ -0,0 +1,61 @@
+package document
+
+import "testing"
+
+func TestExtractHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      string
+		wantTitle string
+		want      string
+	}{
+		{
+			name: "article page",
+			page: `<!DOCTYPE html><html><head><title> A  post </title><style>p{}</style><script>var x=1</script></head>
+<body><header><a href="/">Site</a></header><nav><ul><li>Home</li></ul></nav>
+<article><header><h1>The   post</h1></header>
+<p>Some <b>bold</b>
+text.<br>Next line.</p>
+<ol><li>one</li><li><p>two</p><ul><li>inner</li></ul></li></ol>
+<blockquote><p>Quoted.</p></blockquote>
+<pre>
+code()
+</pre>
+<div hidden>secret</div><span aria-hidden="true">icon</span>
+</article><aside>Related</aside><footer>© 2026</footer></body></html>`,
+			wantTitle: "A post",
+			want: "# The post\n\n" +
+				"Some bold text.\nNext line.\n\n" +
+				"1. one\n" +
+				"2. two\n" +
+				"  - inner\n\n" +
+				"> Quoted.\n\n" +
+				"```\ncode()\n```",
+		},
+		{
+			name: "body without article",
+			page: `<html><body><header>Banner</header><div role="navigation">Menu</div>
+<h2>Section</h2>loose text<div>in a div</div><form><input value="x">Search</form></body></html>`,
+			want: "## Section\n\nloose text\n\nin a div",
+		},
+		{
+			name: "fragment",
+			page: `plain <i>fragment</i>`,
+			want: "plain fragment",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, title, err := extractHTML([]byte(tt.page))
+			if err != nil {
+				t.Fatalf("extractHTML: %v", err)
+			}
+			if title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", title, tt.wantTitle)
+			}
+			if got := renderMarkdown(blocks); got != tt.want {
+				t.Errorf("markdown =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
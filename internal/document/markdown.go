@@ -0,0 +1,94 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+)
+
+// blockKind is the kind of a block of extracted text.
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockListItem
+	blockQuote
+	blockCode
+)
+
+// block is one paragraph-level piece of a document. level is the heading level
+// (1–6) of a heading and the nesting depth (0 for a top-level item) of a list
+// item; ordered says a list item is numbered.
+type block struct {
+	kind    blockKind
+	level   int
+	ordered bool
+	text    string
+}
+
+// renderMarkdown joins blocks into Markdown: headings as "#" lines, list items
+// as "-" or numbered lines indented by depth, quotes as ">" lines and code as
+// fenced blocks, separated by blank lines except between items of one list.
+// Blocks with no text are dropped.
+func renderMarkdown(blocks []block) string {
+	var b strings.Builder
+	var counters []int
+	prev := blockKind(-1)
+	for _, bl := range blocks {
+		text := bl.text
+		if bl.kind != blockCode {
+			text = strings.TrimSpace(text)
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+			if bl.kind != blockListItem || prev != blockListItem {
+				b.WriteString("\n")
+			}
+		}
+		if bl.kind != blockListItem {
+			counters = counters[:0]
+		}
+		switch bl.kind {
+		case blockHeading:
+			b.WriteString(strings.Repeat("#", min(max(bl.level, 1), 6)) + " " + oneLine(text))
+		case blockListItem:
+			for len(counters) <= bl.level {
+				counters = append(counters, 0)
+			}
+			counters = counters[:bl.level+1]
+			marker := "- "
+			if bl.ordered {
+				counters[bl.level]++
+				marker = strconv.Itoa(counters[bl.level]) + ". "
+			}
+			indent := strings.Repeat("  ", bl.level)
+			b.WriteString(indent + marker + strings.ReplaceAll(text, "\n", "\n"+indent+strings.Repeat(" ", len(marker))))
+		case blockQuote:
+			b.WriteString("> " + strings.ReplaceAll(text, "\n", "\n> "))
+		case blockCode:
+			b.WriteString("```\n" + strings.TrimRight(text, "\n") + "\n```")
+		default:
+			b.WriteString(text)
+		}
+		prev = bl.kind
+	}
+	return b.String()
+}
+
+// oneLine joins the lines of text with spaces, as a heading must fit on one.
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// firstHeading returns the text of the first heading among blocks.
+func firstHeading(blocks []block) string {
+	for _, bl := range blocks {
+		if bl.kind == blockHeading && strings.TrimSpace(bl.text) != "" {
+			return oneLine(bl.text)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package document
+
+import "testing"
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []block
+		want   string
+	}{
+		{
+			name: "headings and paragraphs",
+			blocks: []block{
+				{kind: blockHeading, level: 1, text: "Report\nTitle"},
+				{kind: blockParagraph, text: " First paragraph. "},
+				{kind: blockParagraph, text: "  "},
+				{kind: blockHeading, level: 9, text: "Deep"},
+			},
+			want: "# Report Title\n\nFirst paragraph.\n\n###### Deep",
+		},
+		{
+			name: "nested lists",
+			blocks: []block{
+				{kind: blockListItem, ordered: true, text: "one"},
+				{kind: blockListItem, level: 1, text: "bullet"},
+				{kind: blockListItem, level: 1, text: "second\nline"},
+				{kind: blockListItem, ordered: true, text: "two"},
+				{kind: blockParagraph, text: "after"},
+				{kind: blockListItem, ordered: true, text: "restarted"},
+			},
+			want: "1. one\n  - bullet\n  - second\n    line\n2. two\n\nafter\n\n1. restarted",
+		},
+		{
+			name: "quote and code",
+			blocks: []block{
+				{kind: blockQuote, text: "quoted\nlines"},
+				{kind: blockCode, text: "  indented()\n"},
+			},
+			want: "> quoted\n> lines\n\n```\n  indented()\n```",
+		},
+		{name: "empty", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMarkdown(tt.blocks); got != tt.want {
+				t.Errorf("renderMarkdown =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstHeading(t *testing.T) {
+	blocks := []block{
+		{kind: blockParagraph, text: "intro"},
+		{kind: blockHeading, level: 2, text: " "},
+		{kind: blockHeading, level: 2, text: "Scope  of\nwork"},
+	}
+	if got := firstHeading(blocks); got != "Scope of work" {
+		t.Errorf("firstHeading = %q, want %q", got, "Scope of work")
+	}
+}
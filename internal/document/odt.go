@@ -0,0 +1,140 @@
+package document
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// odtListStyles maps a list style name and level (1-based) to whether the
+// level is numbered rather than bulleted.
+type odtListStyles map[string]map[int]bool
+
+// readODTListStyles adds the text:list-style definitions of the entry name to
+// styles.
+func readODTListStyles(zr *zip.Reader, name string, styles odtListStyles) error {
+	cur := ""
+	return walkEntry(zr, name, true, func(_ *xml.Decoder, tok xml.Token) error {
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			return nil
+		}
+		switch el.Name.Local {
+		case "list-style":
+			cur = attr(el, "name")
+			if styles[cur] == nil {
+				styles[cur] = map[int]bool{}
+			}
+		case "list-level-style-number", "list-level-style-bullet":
+			if level, err := strconv.Atoi(attr(el, "level")); err == nil && cur != "" {
+				styles[cur][level] = el.Name.Local == "list-level-style-number"
+			}
+		}
+		return nil
+	})
+}
+
+// odtList is an open text:list: its style (inherited from the enclosing list
+// when it names none) and whether an item's first paragraph was written.
+type odtList struct {
+	style    string
+	itemText bool
+}
+
+// extractODT reads the office:text of content.xml as blocks: text:h becomes a
+// heading at its outline level and the first paragraph of a text:list-item a
+// list item, numbered when its list style says so. Notes and annotations are
+// left out. As in ODF itself, runs of whitespace in the XML are one space;
+// text:s, text:tab and text:line-break are kept.
+func extractODT(zr *zip.Reader) ([]block, string, error) {
+	styles := odtListStyles{}
+	if err := readODTListStyles(zr, "styles.xml", styles); err != nil {
+		return nil, "", err
+	}
+	if err := readODTListStyles(zr, "content.xml", styles); err != nil {
+		return nil, "", err
+	}
+
+	var (
+		blocks []block
+		lists  []*odtList
+		cur    *block
+		text   strings.Builder
+		depth  int // nesting of text:p / text:h, as frames can hold paragraphs
+	)
+	err := walkEntry(zr, "content.xml", false, func(dec *xml.Decoder, tok xml.Token) error {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "list":
+				style := attr(t, "style-name")
+				if style == "" && len(lists) > 0 {
+					style = lists[len(lists)-1].style
+				}
+				lists = append(lists, &odtList{style: style})
+			case "list-item", "list-header":
+				if len(lists) > 0 {
+					lists[len(lists)-1].itemText = false
+				}
+			case "h", "p":
+				depth++
+				if depth > 1 {
+					return nil
+				}
+				b := block{kind: blockParagraph}
+				if t.Name.Local == "h" {
+					b.kind = blockHeading
+					b.level, _ = strconv.Atoi(attr(t, "outline-level"))
+				} else if n := len(lists); n > 0 && !lists[n-1].itemText {
+					lists[n-1].itemText = true
+					b.kind, b.level = blockListItem, n-1
+					b.ordered = styles[lists[n-1].style][n]
+				}
+				cur = &b
+				text.Reset()
+			case "s":
+				if cur != nil {
+					n, err := strconv.Atoi(attr(t, "c"))
+					if err != nil || n < 1 {
+						n = 1
+					}
+					text.WriteString(strings.Repeat(" ", n))
+				}
+			case "tab":
+				if cur != nil {
+					text.WriteString("\t")
+				}
+			case "line-break":
+				if cur != nil {
+					text.WriteString("\n")
+				}
+			case "note", "annotation", "tracked-changes":
+				return dec.Skip()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "list":
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+			case "h", "p":
+				depth--
+				if depth == 0 && cur != nil {
+					cur.text = text.String()
+					blocks = append(blocks, *cur)
+					cur = nil
+				}
+			}
+		case xml.CharData:
+			if cur != nil {
+				text.WriteString(collapseSpace(string(t)))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return blocks, coreTitle(zr, "meta.xml"), nil
+}
@@ -0,0 +1,74 @@
+package document
+
+import "testing"
+
+const odtNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"`
+
+const odtContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content ` + odtNS + `>
+<office:automatic-styles>
+<text:list-style style:name="L2"><text:list-level-style-bullet text:level="1"/></text:list-style>
+</office:automatic-styles>
+<office:body><office:text>
+<office:annotation><text:p>a reviewer's comment</text:p></office:annotation>
+<text:h text:outline-level="1">Field   notes</text:h>
+<text:p>Two<text:s text:c="2"/>spaces,<text:tab/>a tab<text:line-break/>and a break.<text:note><text:note-body><text:p>footnote</text:p></text:note-body></text:note></text:p>
+<text:list text:style-name="L1">
+<text:list-item><text:p>Numbered</text:p>
+<text:list><text:list-item><text:p>Nested</text:p></text:list-item></text:list>
+</text:list-item>
+<text:list-item><text:p>Again</text:p><text:p>Continued paragraph</text:p></text:list-item>
+</text:list>
+<text:list text:style-name="L2"><text:list-item><text:p>Bullet</text:p></text:list-item></text:list>
+</office:text></office:body>
+</office:document-content>`
+
+const odtStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles ` + odtNS + `>
+<office:styles>
+<text:list-style style:name="L1">
+<text:list-level-style-number text:level="1"/>
+<text:list-level-style-number text:level="2"/>
+</text:list-style>
+</office:styles>
+</office:document-styles>`
+
+const odtMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta ` + odtNS + ` xmlns:dc="http://purl.org/dc/elements/1.1/">
+<office:meta><dc:title>Notes</dc:title></office:meta>
+</office:document-meta>`
+
+func TestExtractODT(t *testing.T) {
+	zr := zipReader(t, map[string]string{
+		"content.xml": odtContent,
+		"styles.xml":  odtStyles,
+		"meta.xml":    odtMeta,
+	})
+
+	blocks, title, err := extractODT(zr)
+	if err != nil {
+		t.Fatalf("extractODT: %v", err)
+	}
+	if title != "Notes" {
+		t.Errorf("title = %q, want %q", title, "Notes")
+	}
+	want := "# Field notes\n\n" +
+		"Two  spaces,\ta tab\nand a break.\n\n" +
+		"1. Numbered\n" +
+		"  1. Nested\n" +
+		"2. Again\n\n" +
+		"Continued paragraph\n\n" +
+		"- Bullet"
+	if got := renderMarkdown(blocks); got != want {
+		t.Errorf("markdown =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestExtractODT_MissingContent(t *testing.T) {
+	zr := zipReader(t, map[string]string{"meta.xml": odtMeta})
+	if _, _, err := extractODT(zr); err == nil {
+		t.Error("expected an error for a package without content.xml")
+	}
+}
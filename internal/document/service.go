@@ -0,0 +1,172 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go_text/internal/apperr"
+	"go_text/internal/diff"
+	"go_text/internal/langdetect"
+	"go_text/internal/settings"
+
+	"github.com/wailsapp/wails/v2/pkg/logger"
+)
+
+// Formats reported in apperr.ImportedDocument.Format.
+const (
+	FormatDocx     = "docx"
+	FormatODT      = "odt"
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+	FormatText     = "text"
+)
+
+// formats maps the file extensions Import reads to their format.
+var formats = map[string]string{
+	".docx":     FormatDocx,
+	".odt":      FormatODT,
+	".html":     FormatHTML,
+	".htm":      FormatHTML,
+	".md":       FormatMarkdown,
+	".markdown": FormatMarkdown,
+	".txt":      FormatText,
+}
+
+// maxDocumentBytes caps the size of an imported file.
+const maxDocumentBytes = 32 << 20
+
+// FilePattern returns the open-dialog pattern of the files Import reads,
+// such as "*.docx;*.htm;…".
+func FilePattern() string {
+	patterns := make([]string, 0, len(formats))
+	for ext := range formats {
+		patterns = append(patterns, "*"+ext)
+	}
+	slices.Sort(patterns)
+	return strings.Join(patterns, ";")
+}
+
+// documentSettingsAPI is the minimal contract DocumentService needs from the settings service.
+type documentSettingsAPI interface {
+	GetLanguageConfig() (*settings.LanguageConfig, error)
+}
+
+// DocumentServiceAPI is the contract consumed by DocumentHandler.
+type DocumentServiceAPI interface {
+	// Import reads the document at path and returns its text with metadata.
+	Import(path string) (*apperr.ImportedDocument, error)
+}
+
+// DocumentService implements DocumentServiceAPI.
+type DocumentService struct {
+	logger   logger.Logger
+	settings documentSettingsAPI
+}
+
+// NewDocumentService constructs a DocumentService. Panics on nil dependencies.
+func NewDocumentService(wailsLogger logger.Logger, settingsService documentSettingsAPI) *DocumentService {
+	const op = "DocumentService.NewDocumentService"
+	if wailsLogger == nil {
+		panic(fmt.Sprintf("%s: logger cannot be nil", op))
+	}
+	if settingsService == nil {
+		panic(fmt.Sprintf("%s: settings service cannot be nil", op))
+	}
+	return &DocumentService{logger: wailsLogger, settings: settingsService}
+}
+
+// Import reads the document at path, chosen by its extension: .docx and .odt
+// are unzipped and their XML read, HTML reduced to its readable text, and
+// Markdown and plain text decoded from their detected encoding. Headings and
+// lists of .docx, .odt and HTML come out as Markdown. The language is detected
+// among the configured languages. A missing, oversized, unsupported or
+// unreadable file is a validation error.
+func (s *DocumentService) Import(path string) (*apperr.ImportedDocument, error) {
+	const op = "DocumentService.Import"
+	ext := strings.ToLower(filepath.Ext(path))
+	format, ok := formats[ext]
+	if !ok {
+		return nil, apperr.Validation("path", fmt.Sprintf("a document of type %s", FilePattern()), filepath.Base(path))
+	}
+	data, err := readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &apperr.ImportedDocument{Path: path, Name: filepath.Base(path), Format: format, Encoding: EncodingUTF8}
+	var blocks []block
+	switch format {
+	case FormatDocx, FormatODT:
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, apperr.Validation("path", "a readable "+ext+" document", "a damaged file")
+		}
+		if format == FormatDocx {
+			blocks, doc.Title, err = extractDocx(zr)
+		} else {
+			blocks, doc.Title, err = extractODT(zr)
+		}
+		if err != nil {
+			s.logger.Warning(fmt.Sprintf("[%s] read %s: %v", op, path, err))
+			return nil, apperr.Validation("path", "a readable "+ext+" document", "a damaged file")
+		}
+		doc.Text = renderMarkdown(blocks)
+	case FormatHTML:
+		var text string
+		text, doc.Encoding = decodeText(data)
+		if blocks, doc.Title, err = extractHTML([]byte(text)); err != nil {
+			return nil, fmt.Errorf("%s: parse %s: %w", op, path, err)
+		}
+		doc.Text = renderMarkdown(blocks)
+	default:
+		doc.Text, doc.Encoding = decodeText(data)
+		if strings.ContainsRune(doc.Text, 0) {
+			return nil, apperr.Validation("path", "a text document", "binary data")
+		}
+		if format == FormatMarkdown {
+			doc.Title = markdownTitle(doc.Text)
+		}
+	}
+	if doc.Title == "" {
+		doc.Title = firstHeading(blocks)
+	}
+	doc.WordCount = diff.CountWords(doc.Text)
+
+	cfg, err := s.settings.GetLanguageConfig()
+	if err != nil {
+		return nil, fmt.Errorf("%s: get language config: %w", op, err)
+	}
+	if d := langdetect.Detect(doc.Text, cfg.Languages); d.Language != "" {
+		doc.Language = &apperr.LanguageDetection{Language: d.Language, Confidence: d.Confidence}
+	}
+	s.logger.Info(fmt.Sprintf("[%s] imported %s (%s, %s, %d words)", op, path, format, doc.Encoding, doc.WordCount))
+	return doc, nil
+}
+
+// readDocument reads the file at path of at most maxDocumentBytes.
+func readDocument(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, apperr.Validation("path", "point to an existing file", "not found")
+		}
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxDocumentBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if len(data) > maxDocumentBytes {
+		return nil, apperr.Validation("path", fmt.Sprintf("a file of at most %d MiB", maxDocumentBytes>>20), filepath.Base(path))
+	}
+	return data, nil
+}
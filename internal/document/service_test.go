@@ -0,0 +1,215 @@
+package document
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go_text/internal/apperr"
+	"go_text/internal/settings"
+)
+
+// --- fakes ---
+
+type fakeLogger struct{ warnings []string }
+
+func (f *fakeLogger) Print(msg string)   {}
+func (f *fakeLogger) Trace(msg string)   {}
+func (f *fakeLogger) Debug(msg string)   {}
+func (f *fakeLogger) Info(msg string)    {}
+func (f *fakeLogger) Warning(msg string) { f.warnings = append(f.warnings, msg) }
+func (f *fakeLogger) Error(msg string)   {}
+func (f *fakeLogger) Fatal(msg string)   {}
+
+type mockSettingsSvc struct {
+	cfg *settings.LanguageConfig
+	err error
+}
+
+func (m *mockSettingsSvc) GetLanguageConfig() (*settings.LanguageConfig, error) {
+	return m.cfg, m.err
+}
+
+func newTestService(languages ...string) *DocumentService {
+	return NewDocumentService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.LanguageConfig{Languages: languages}})
+}
+
+// writeFile writes data to name in a fresh temp dir and returns its path.
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	return path
+}
+
+func assertValidation(t *testing.T, err error, field string) {
+	t.Helper()
+	var ae *apperr.AppError
+	if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if ae.Details["field"] != field {
+		t.Errorf("field = %v, want %q", ae.Details["field"], field)
+	}
+}
+
+func TestNewDocumentService_PanicsOnNil(t *testing.T) {
+	tests := []struct {
+		name     string
+		logger   *fakeLogger
+		settings documentSettingsAPI
+	}{
+		{"nil logger", nil, &mockSettingsSvc{}},
+		{"nil settings", &fakeLogger{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			if tt.logger == nil {
+				NewDocumentService(nil, tt.settings)
+			} else {
+				NewDocumentService(tt.logger, tt.settings)
+			}
+		})
+	}
+}
+
+func TestDocumentService_Import(t *testing.T) {
+	docx := zipBytes(t, map[string]string{
+		"word/document.xml": docxDocument,
+		"word/styles.xml":   docxStyles,
+	})
+	odt := zipBytes(t, map[string]string{"content.xml": odtContent, "styles.xml": odtStyles})
+
+	tests := []struct {
+		name         string
+		file         string
+		data         []byte
+		wantFormat   string
+		wantEncoding string
+		wantTitle    string
+		wantPrefix   string
+		wantWords    int
+	}{
+		{
+			name: "docx", file: "report.DOCX", data: docx,
+			wantFormat: FormatDocx, wantEncoding: EncodingUTF8, wantTitle: "Quarterly report",
+			wantPrefix: "# Quarterly report\n\nSales rose by", wantWords: 14,
+		},
+		{
+			name: "odt", file: "notes.odt", data: odt,
+			wantFormat: FormatODT, wantEncoding: EncodingUTF8, wantTitle: "Field notes",
+			wantPrefix: "# Field notes\n\n", wantWords: 15,
+		},
+		{
+			name: "html", file: "page.htm", data: []byte("<title>Page</title><p>The weather is lovely today.</p>"),
+			wantFormat: FormatHTML, wantEncoding: EncodingUTF8, wantTitle: "Page",
+			wantPrefix: "The weather is lovely today.", wantWords: 5,
+		},
+		{
+			name: "markdown", file: "readme.md", data: []byte("# Read me\r\n\r\nThe weather is lovely today.\r\n"),
+			wantFormat: FormatMarkdown, wantEncoding: EncodingUTF8, wantTitle: "Read me",
+			wantPrefix: "# Read me\n\nThe weather", wantWords: 7,
+		},
+		{
+			name: "windows-1252 text", file: "legacy.txt", data: []byte("The caf\xe9 is lovely today."),
+			wantFormat: FormatText, wantEncoding: EncodingWindows1252,
+			wantPrefix: "The café is", wantWords: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.file, tt.data)
+
+			doc, err := newTestService("English", "German").Import(path)
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+			if doc.Path != path || doc.Name != tt.file {
+				t.Errorf("Path, Name = %q, %q; want %q, %q", doc.Path, doc.Name, path, tt.file)
+			}
+			if doc.Format != tt.wantFormat {
+				t.Errorf("Format = %q, want %q", doc.Format, tt.wantFormat)
+			}
+			if doc.Encoding != tt.wantEncoding {
+				t.Errorf("Encoding = %q, want %q", doc.Encoding, tt.wantEncoding)
+			}
+			if doc.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", doc.Title, tt.wantTitle)
+			}
+			if !strings.HasPrefix(doc.Text, tt.wantPrefix) {
+				t.Errorf("Text = %q, want prefix %q", doc.Text, tt.wantPrefix)
+			}
+			if doc.WordCount != tt.wantWords {
+				t.Errorf("WordCount = %d, want %d", doc.WordCount, tt.wantWords)
+			}
+			if doc.Language == nil || doc.Language.Language != "English" {
+				t.Errorf("Language = %+v, want English", doc.Language)
+			}
+		})
+	}
+}
+
+func TestDocumentService_Import_NoLanguage(t *testing.T) {
+	path := writeFile(t, "numbers.txt", []byte("12345 67890"))
+
+	doc, err := newTestService("English").Import(path)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if doc.Language != nil {
+		t.Errorf("Language = %+v, want nil", doc.Language)
+	}
+}
+
+func TestDocumentService_Import_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data []byte
+	}{
+		{"unsupported extension", "slides.pptx", []byte("x")},
+		{"damaged docx", "broken.docx", []byte("not a zip")},
+		{"zip without document.xml", "empty.docx", nil},
+		{"binary text", "image.txt", []byte("\x89PNG\x00\x00\x01\xff\xfe")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			if data == nil {
+				data = zipBytes(t, map[string]string{"other.xml": "<x/>"})
+			}
+			_, err := newTestService().Import(writeFile(t, tt.file, data))
+			assertValidation(t, err, "path")
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := newTestService().Import(filepath.Join(t.TempDir(), "gone.txt"))
+		assertValidation(t, err, "path")
+	})
+}
+
+func TestDocumentService_Import_SettingsError(t *testing.T) {
+	svc := NewDocumentService(&fakeLogger{}, &mockSettingsSvc{err: errors.New("db closed")})
+
+	_, err := svc.Import(writeFile(t, "a.txt", []byte("hello")))
+	if err == nil || !strings.Contains(err.Error(), "db closed") {
+		t.Fatalf("expected the settings error, got %v", err)
+	}
+}
+
+func TestFilePattern(t *testing.T) {
+	want := "*.docx;*.htm;*.html;*.markdown;*.md;*.odt;*.txt"
+	if got := FilePattern(); got != want {
+		t.Errorf("FilePattern = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package document
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings reported in apperr.ImportedDocument.Encoding.
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// sniffBytes is how much of a file decodeText inspects to spot BOM-less UTF-16.
+const sniffBytes = 4096
+
+// decodeText decodes a text file and reports the encoding it was read as: the
+// encoding of its byte order mark, else UTF-16 when every other byte of the
+// start is zero, else UTF-8 when the bytes are valid UTF-8, else Windows-1252
+// (a superset of Latin-1 and what legacy Windows editors save). Line endings
+// are normalized to "\n".
+func decodeText(data []byte) (string, string) {
+	var text, enc string
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		text, enc = string(data[3:]), EncodingUTF8
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		text, enc = decodeUTF16(data[2:], false), EncodingUTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		text, enc = decodeUTF16(data[2:], true), EncodingUTF16BE
+	default:
+		switch zeroBytes(data) {
+		case 1:
+			text, enc = decodeUTF16(data, false), EncodingUTF16LE
+		case 0:
+			text, enc = decodeUTF16(data, true), EncodingUTF16BE
+		default:
+			if utf8.Valid(data) {
+				text, enc = string(data), EncodingUTF8
+			} else {
+				text, enc = decodeWindows1252(data), EncodingWindows1252
+			}
+		}
+	}
+	return normalizeNewlines(text), enc
+}
+
+// zeroBytes reports which byte of each pair at the start of data is mostly
+// zero, as ASCII text encoded in BOM-less UTF-16 has: 1 for the odd bytes
+// (little-endian), 0 for the even ones (big-endian), -1 for neither.
+func zeroBytes(data []byte) int {
+	data = data[:min(len(data), sniffBytes)]
+	pairs := len(data) / 2
+	if pairs == 0 {
+		return -1
+	}
+	var even, odd int
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 {
+			even++
+		}
+		if data[i+1] == 0 {
+			odd++
+		}
+	}
+	switch {
+	case odd*10 >= pairs*3 && even*10 < pairs:
+		return 1
+	case even*10 >= pairs*3 && odd*10 < pairs:
+		return 0
+	}
+	return -1
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// windows1252 maps bytes 0x80–0x9F, where Windows-1252 differs from Latin-1.
+// Bytes it leaves undefined decode to U+FFFD.
+var windows1252 = [32]rune{
+	'€', '\uFFFD', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\uFFFD', 'Ž', '\uFFFD',
+	'\uFFFD', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\uFFFD', 'ž', 'Ÿ',
+}
+
+func decodeWindows1252(data []byte) string {
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		if c >= 0x80 && c < 0xA0 {
+			b.WriteRune(windows1252[c-0x80])
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+func normalizeNewlines(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+// collapseSpace replaces every run of whitespace in s with one space.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	return b.String()
+}
+
+// markdownTitle returns the text of the first level-1 ATX heading of text.
+func markdownTitle(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimLeft(line, " "), "# "); ok {
+			return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#"))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package document
+
+import (
+	"testing"
+	"unicode/utf16"
+)
+
+func utf16Bytes(s string, bigEndian bool) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	return out
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		wantText string
+		wantEnc  string
+	}{
+		{"utf-8", []byte("Grüße\r\nzurück"), "Grüße\nzurück", EncodingUTF8},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, "héllo"...), "héllo", EncodingUTF8},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, utf16Bytes("Привет\r\n", false)...), "Привет\n", EncodingUTF16LE},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, utf16Bytes("naïve", true)...), "naïve", EncodingUTF16BE},
+		{"utf-16le without bom", utf16Bytes("plain old text", false), "plain old text", EncodingUTF16LE},
+		{"utf-16be without bom", utf16Bytes("plain old text", true), "plain old text", EncodingUTF16BE},
+		{"windows-1252", []byte("caf\xe9 \x93quoted\x94 \x80 5\rnext"), "café “quoted” € 5\nnext", EncodingWindows1252},
+		{"empty", nil, "", EncodingUTF8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, enc := decodeText(tt.data)
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if enc != tt.wantEnc {
+				t.Errorf("encoding = %q, want %q", enc, tt.wantEnc)
+			}
+		})
+	}
+}
+
+func TestCollapseSpace(t *testing.T) {
+	if got := collapseSpace("  a\n\t b  c "); got != " a b c " {
+		t.Errorf("collapseSpace = %q, want %q", got, " a b c ")
+	}
+}
+
+func TestMarkdownTitle(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"intro\n\n# The Title #\n\n# Another", "The Title"},
+		{"## Only a subheading", ""},
+		{"#hashtag", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := markdownTitle(tt.text); got != tt.want {
+			t.Errorf("markdownTitle(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
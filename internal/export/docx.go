@@ -0,0 +1,334 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Parts of the .docx package written by renderDocx that do not depend on the
+// document.
+const (
+	docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+		`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>` +
+		`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+		`</Types>`
+	docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+		`</Relationships>`
+	docxRelsStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`
+	docxDocumentStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><w:body>`
+	docxDocumentEnd = `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/>` +
+		`<w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>` +
+		`</w:body></w:document>`
+	docxCore = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" ` +
+		`xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<dc:title>%s</dc:title><dcterms:created xsi:type="dcterms:W3CDTF">%s</dcterms:created>` +
+		`</cp:coreProperties>`
+)
+
+// docxStyles defines the paragraph, character and table styles the document
+// uses, under the built-in style IDs so Word's navigation pane and outline see
+// the headings.
+var docxStyles = func() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+		`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/>` +
+		`<w:sz w:val="22"/><w:szCs w:val="22"/></w:rPr></w:rPrDefault>` +
+		`<w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+		`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>`)
+	sizes := []int{32, 28, 26, 24, 22, 22}
+	for i, size := range sizes {
+		fmt.Fprintf(&b, `<w:style w:type="paragraph" w:styleId="Heading%[1]d"><w:name w:val="heading %[1]d"/>`+
+			`<w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>`+
+			`<w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="%[2]d"/></w:pPr>`+
+			`<w:rPr><w:b/><w:sz w:val="%[3]d"/><w:szCs w:val="%[3]d"/></w:rPr></w:style>`, i+1, i, size)
+	}
+	b.WriteString(`<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/>` +
+		`<w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="60"/><w:contextualSpacing/></w:pPr></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:qFormat/>` +
+		`<w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="D1D9E0"/></w:pBdr><w:ind w:left="360"/></w:pPr>` +
+		`<w:rPr><w:i/><w:color w:val="59636E"/></w:rPr></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/>` +
+		`<w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr>` +
+		`<w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="FrontMatter"><w:name w:val="Front Matter"/><w:basedOn w:val="Normal"/>` +
+		`<w:pPr><w:spacing w:after="0"/></w:pPr><w:rPr><w:color w:val="59636E"/><w:sz w:val="18"/></w:rPr></w:style>` +
+		`<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/>` +
+		`<w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/></w:rPr></w:style>` +
+		`<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/>` +
+		`<w:rPr><w:color w:val="0969DA"/><w:u w:val="single"/></w:rPr></w:style>` +
+		`<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders>` +
+		`<w:top w:val="single" w:sz="4" w:space="0" w:color="D1D9E0"/><w:left w:val="single" w:sz="4" w:space="0" w:color="D1D9E0"/>` +
+		`<w:bottom w:val="single" w:sz="4" w:space="0" w:color="D1D9E0"/><w:right w:val="single" w:sz="4" w:space="0" w:color="D1D9E0"/>` +
+		`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="D1D9E0"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="D1D9E0"/>` +
+		`</w:tblBorders><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr>` +
+		`<w:pPr><w:spacing w:after="0"/></w:pPr></w:style>` +
+		`</w:styles>`)
+	return b.String()
+}()
+
+// Abstract numbering definitions of numbering.xml: bullets and decimals, nine
+// levels each, indented 720 twips per level.
+const (
+	docxBulletAbstract  = 0
+	docxDecimalAbstract = 1
+	docxBulletNum       = 1 // the w:num every bullet list shares
+)
+
+func docxAbstractNum(id int, ordered bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, id)
+	bullets := []string{"•", "◦", "▪"}
+	for lvl := 0; lvl < 9; lvl++ {
+		format, text := "bullet", bullets[lvl%len(bullets)]
+		if ordered {
+			format, text = "decimal", fmt.Sprintf("%%%d.", lvl+1)
+		}
+		fmt.Fprintf(&b, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/>`+
+			`<w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`, lvl, format, text, 720*(lvl+1))
+	}
+	b.WriteString(`</w:abstractNum>`)
+	return b.String()
+}
+
+// docxWriter accumulates the document body, its hyperlink relationships and
+// the numbering instances of its ordered lists.
+type docxWriter struct {
+	body     strings.Builder
+	rels     strings.Builder
+	nextRel  int
+	links    map[string]string // target → relationship ID
+	list     int               // list of the last list item
+	levelNum []int             // w:numId per level of that list, 0 for bullets
+	nextNum  int
+	numDefs  strings.Builder
+}
+
+// renderDocx renders text, Markdown, as a Word document titled title:
+// headings with the Heading styles, bullet and numbered lists with real list
+// numbering, tables, code blocks, quotes, bold, italic, strikethrough, inline
+// code and hyperlinks. meta, when set, comes first as a block of labelled lines.
+func renderDocx(title, text string, meta *frontMatter, at time.Time) ([]byte, error) {
+	w := &docxWriter{nextRel: 3, links: map[string]string{}, nextNum: docxBulletNum + 1}
+	w.body.WriteString(docxDocumentStart)
+	if meta != nil {
+		for _, f := range meta.fields() {
+			w.body.WriteString(`<w:p><w:pPr><w:pStyle w:val="FrontMatter"/></w:pPr>`)
+			w.runs([]span{{text: f.label + ": ", bold: true}, {text: f.value}})
+			w.body.WriteString(`</w:p>`)
+		}
+		w.body.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="4" w:space="1" w:color="D1D9E0"/></w:pBdr></w:pPr></w:p>`)
+	}
+	for _, bl := range parseMarkdown(text) {
+		w.block(bl)
+	}
+	w.body.WriteString(docxDocumentEnd)
+
+	numbering := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+		docxAbstractNum(docxBulletAbstract, false) + docxAbstractNum(docxDecimalAbstract, true) +
+		fmt.Sprintf(`<w:num w:numId="%d"><w:abstractNumId w:val="%d"/></w:num>`, docxBulletNum, docxBulletAbstract) +
+		w.numDefs.String() + `</w:numbering>`
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"docProps/core.xml", fmt.Sprintf(docxCore, escapeXML(title), at.UTC().Format(time.RFC3339))},
+		{"word/document.xml", w.body.String()},
+		{"word/styles.xml", docxStyles},
+		{"word/numbering.xml", numbering},
+		{"word/_rels/document.xml.rels", docxRelsStart + w.rels.String() + `</Relationships>`},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("create %s: %w", part.name, err)
+		}
+		if _, err := f.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("write %s: %w", part.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("close docx: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *docxWriter) block(bl block) {
+	switch bl.kind {
+	case blockHeading:
+		w.paragraph(fmt.Sprintf(`<w:pStyle w:val="Heading%d"/>`, bl.level), parseInline(bl.text))
+	case blockListItem:
+		w.paragraph(fmt.Sprintf(`<w:pStyle w:val="ListParagraph"/><w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`,
+			min(bl.level, 8), w.numID(bl)), parseInline(bl.text))
+	case blockQuote:
+		for _, para := range strings.Split(bl.text, "\n\n") {
+			w.paragraph(`<w:pStyle w:val="Quote"/>`, parseInline(para))
+		}
+	case blockCode:
+		for _, line := range strings.Split(bl.text, "\n") {
+			w.paragraph(`<w:pStyle w:val="Code"/>`, []span{{text: line}})
+		}
+		w.body.WriteString(`<w:p/>`)
+	case blockTable:
+		w.table(bl)
+	case blockRule:
+		w.body.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="D1D9E0"/></w:pBdr></w:pPr></w:p>`)
+	default:
+		w.paragraph("", parseInline(bl.text))
+	}
+}
+
+// numID returns the numbering instance of a list item. Bullets share one;
+// each run of numbered items at a level gets its own instance restarting at 1,
+// so a nested numbered list restarts under every parent item.
+func (w *docxWriter) numID(bl block) int {
+	if bl.list != w.list {
+		w.list, w.levelNum = bl.list, nil
+	}
+	level := min(bl.level, 8)
+	if len(w.levelNum) > level+1 {
+		w.levelNum = w.levelNum[:level+1]
+	}
+	for len(w.levelNum) <= level {
+		w.levelNum = append(w.levelNum, 0)
+	}
+	if !bl.ordered {
+		w.levelNum[level] = 0
+		return docxBulletNum
+	}
+	if w.levelNum[level] == 0 {
+		w.levelNum[level] = w.nextNum
+		w.nextNum++
+		fmt.Fprintf(&w.numDefs, `<w:num w:numId="%d"><w:abstractNumId w:val="%d"/>`+
+			`<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="1"/></w:lvlOverride></w:num>`, w.levelNum[level], docxDecimalAbstract, level)
+	}
+	return w.levelNum[level]
+}
+
+func (w *docxWriter) paragraph(props string, spans []span) {
+	w.body.WriteString(`<w:p>`)
+	if props != "" {
+		w.body.WriteString(`<w:pPr>` + props + `</w:pPr>`)
+	}
+	w.runs(spans)
+	w.body.WriteString(`</w:p>`)
+}
+
+func (w *docxWriter) table(bl block) {
+	w.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/></w:tblPr><w:tblGrid>`)
+	for range bl.align {
+		w.body.WriteString(`<w:gridCol/>`)
+	}
+	w.body.WriteString(`</w:tblGrid>`)
+	for r, row := range bl.rows {
+		w.body.WriteString(`<w:tr>`)
+		if r == 0 {
+			w.body.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+		}
+		for c, align := range bl.align {
+			cell := ""
+			if c < len(row) {
+				cell = row[c]
+			}
+			spans := parseInline(cell)
+			if r == 0 {
+				for i := range spans {
+					spans[i].bold = true
+				}
+			}
+			props := ""
+			switch align {
+			case "center":
+				props = `<w:jc w:val="center"/>`
+			case "right":
+				props = `<w:jc w:val="right"/>`
+			}
+			w.body.WriteString(`<w:tc>`)
+			w.paragraph(props, spans)
+			w.body.WriteString(`</w:tc>`)
+		}
+		w.body.WriteString(`</w:tr>`)
+	}
+	w.body.WriteString(`</w:tbl><w:p/>`)
+}
+
+// runs writes spans as runs, wrapping each link in a w:hyperlink.
+func (w *docxWriter) runs(spans []span) {
+	for i, sp := range spans {
+		if sp.link != "" && (i == 0 || spans[i-1].link != sp.link) {
+			w.body.WriteString(`<w:hyperlink r:id="` + w.linkRel(sp.link) + `">`)
+		}
+		var props strings.Builder
+		if sp.code {
+			props.WriteString(`<w:rStyle w:val="CodeChar"/>`)
+		} else if sp.link != "" {
+			props.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+		}
+		if sp.bold {
+			props.WriteString(`<w:b/>`)
+		}
+		if sp.italic {
+			props.WriteString(`<w:i/>`)
+		}
+		if sp.strike {
+			props.WriteString(`<w:strike/>`)
+		}
+		w.body.WriteString(`<w:r>`)
+		if props.Len() > 0 {
+			w.body.WriteString(`<w:rPr>` + props.String() + `</w:rPr>`)
+		}
+		for j, part := range strings.Split(sp.text, "\t") {
+			if j > 0 {
+				w.body.WriteString(`<w:tab/>`)
+			}
+			if part != "" {
+				w.body.WriteString(`<w:t xml:space="preserve">` + escapeXML(part) + `</w:t>`)
+			}
+		}
+		w.body.WriteString(`</w:r>`)
+		if sp.link != "" && (i+1 == len(spans) || spans[i+1].link != sp.link) {
+			w.body.WriteString(`</w:hyperlink>`)
+		}
+	}
+}
+
+// linkRel returns the relationship ID of an external hyperlink target.
+func (w *docxWriter) linkRel(target string) string {
+	if id, ok := w.links[target]; ok {
+		return id
+	}
+	id := fmt.Sprintf("rId%d", w.nextRel)
+	w.nextRel++
+	w.links[target] = id
+	w.rels.WriteString(`<Relationship Id="` + id + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" ` +
+		`Target="` + escapeXML(target) + `" TargetMode="External"/>`)
+	return id
+}
+
+// escapeXML escapes s for use in XML text and attribute values.
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
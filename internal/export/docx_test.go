@@ -0,0 +1,93 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// docxParts unzips a .docx into its parts, checking each XML part is well formed.
+func docxParts(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		b, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		dec := xml.NewDecoder(bytes.NewReader(b))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", f.Name, err)
+			}
+		}
+		parts[f.Name] = string(b)
+	}
+	return parts
+}
+
+func TestRenderDocx(t *testing.T) {
+	text := "## Findings\n\nText with **bold**, *italic*, ~~old~~, `code` and [a link](https://x.y?a&b).\n\n" +
+		"1. first\n   1. nested\n2. second\n   1. nested again\n\n- bullet\n\n| H1 | H2 |\n|:--:|----|\n| a\tb | c |\n\n> quoted\n\n```\nline 1\nline 2\n```"
+	meta := &frontMatter{title: "Report", date: time.Date(2026, 5, 1, 8, 30, 0, 0, time.UTC), actions: []string{"Summarize"}, model: "llama3"}
+
+	data, err := renderDocx("Report <final>", text, meta, meta.date)
+	if err != nil {
+		t.Fatalf("renderDocx: %v", err)
+	}
+	parts := docxParts(t, data)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "docProps/core.xml", "word/document.xml",
+		"word/styles.xml", "word/numbering.xml", "word/_rels/document.xml.rels"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+
+	doc := parts["word/document.xml"]
+	for _, want := range []string{
+		`<w:pStyle w:val="FrontMatter"/></w:pPr><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">Actions: </w:t></w:r><w:r><w:t xml:space="preserve">Summarize</w:t></w:r>`,
+		`<w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t xml:space="preserve">Findings</w:t></w:r>`,
+		`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">bold</w:t></w:r>`,
+		`<w:r><w:rPr><w:i/></w:rPr><w:t xml:space="preserve">italic</w:t></w:r>`,
+		`<w:r><w:rPr><w:strike/></w:rPr><w:t xml:space="preserve">old</w:t></w:r>`,
+		`<w:r><w:rPr><w:rStyle w:val="CodeChar"/></w:rPr><w:t xml:space="preserve">code</w:t></w:r>`,
+		`<w:hyperlink r:id="rId3"><w:r><w:rPr><w:rStyle w:val="Hyperlink"/></w:rPr><w:t xml:space="preserve">a link</w:t></w:r></w:hyperlink>`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">first</w:t>`,
+		`<w:numPr><w:ilvl w:val="1"/><w:numId w:val="3"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">nested</w:t>`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">second</w:t>`,
+		`<w:numPr><w:ilvl w:val="1"/><w:numId w:val="4"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">nested again</w:t>`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t xml:space="preserve">bullet</w:t>`,
+		`<w:tr><w:trPr><w:tblHeader/></w:trPr><w:tc><w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">H1</w:t>`,
+		`<w:t xml:space="preserve">a</w:t><w:tab/><w:t xml:space="preserve">b</w:t>`,
+		`<w:pStyle w:val="Quote"/>`,
+		`<w:pStyle w:val="Code"/></w:pPr><w:r><w:t xml:space="preserve">line 2</w:t>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document.xml lacks %s", want)
+		}
+	}
+	if !strings.Contains(parts["word/_rels/document.xml.rels"], `Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://x.y?a&amp;b" TargetMode="External"`) {
+		t.Errorf("hyperlink relationship missing: %s", parts["word/_rels/document.xml.rels"])
+	}
+	numbering := parts["word/numbering.xml"]
+	for _, id := range []string{`w:numId="2"`, `w:numId="3"`, `w:numId="4"`} {
+		if !strings.Contains(numbering, `<w:num `+id+`><w:abstractNumId w:val="1"/>`) {
+			t.Errorf("numbering.xml lacks restarting instance %s", id)
+		}
+	}
+	if !strings.Contains(parts["docProps/core.xml"], "<dc:title>Report &lt;final&gt;</dc:title>") {
+		t.Errorf("core.xml title: %s", parts["docProps/core.xml"])
+	}
+}
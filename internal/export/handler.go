@@ -0,0 +1,59 @@
+package export
+
+import (
+	"fmt"
+
+	"go_text/internal/apperr"
+	"go_text/internal/logging"
+
+	"github.com/rs/zerolog"
+)
+
+const panicMsgFmt = "panic: %v"
+
+// ExportHandler is the Wails-bound handler for exporting outputs to files.
+// All bound methods follow the envelope pattern: return apperr.*Result,
+// no error return, and include defer/recover for panic safety.
+type ExportHandler struct {
+	appLogger *logging.Logger
+	service   ExportServiceAPI
+}
+
+// NewExportHandler constructs an ExportHandler.
+func NewExportHandler(appLogger *logging.Logger, service ExportServiceAPI) *ExportHandler {
+	return &ExportHandler{appLogger: appLogger, service: service}
+}
+
+// liveZlog returns a live snapshot of the app logger's current writer, or a
+// no-op logger if appLogger has not been wired (e.g. bare struct-literal
+// tests exercising panic recovery).
+func (h *ExportHandler) liveZlog() zerolog.Logger {
+	if h.appLogger != nil {
+		return h.appLogger.ZeroLogger()
+	}
+	return zerolog.Nop()
+}
+
+// ExportOutput writes a run's output, or a history entry's, into the chosen
+// directory as .md, .txt, .html or .docx and returns the path of the file
+// written.
+func (h *ExportHandler) ExportOutput(req apperr.OutputExportRequest) (res apperr.StringResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.StringResult{Error: &wire}
+		}
+	}()
+	if req.Directory == "" {
+		ae := apperr.Validation("directory", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.StringResult{Error: &wire}
+	}
+	path, err := h.service.Export(req)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.StringResult{Error: &wire}
+	}
+	return apperr.StringResult{Data: path}
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+type stubExportService struct {
+	path  string
+	err   error
+	panic bool
+	got   apperr.OutputExportRequest
+}
+
+func (s *stubExportService) Export(req apperr.OutputExportRequest) (string, error) {
+	if s.panic {
+		panic("boom")
+	}
+	s.got = req
+	return s.path, s.err
+}
+
+func TestExportHandler_ExportOutput_Success(t *testing.T) {
+	svc := &stubExportService{path: "/docs/Summary 2026-01-02.md"}
+	h := NewExportHandler(nil, svc)
+
+	res := h.ExportOutput(apperr.OutputExportRequest{Text: "x", Format: FormatMarkdown, Directory: "/docs"})
+
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %+v", res.Error)
+	}
+	if res.Data != svc.path {
+		t.Errorf("Data = %q, want %q", res.Data, svc.path)
+	}
+	if svc.got.Directory != "/docs" {
+		t.Errorf("service got %+v", svc.got)
+	}
+}
+
+func TestExportHandler_ExportOutput_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      apperr.OutputExportRequest
+		svc      *stubExportService
+		wantCode apperr.ErrorCode
+	}{
+		{"empty directory", apperr.OutputExportRequest{Text: "x", Format: FormatMarkdown}, &stubExportService{}, apperr.CodeValidation},
+		{"service error", apperr.OutputExportRequest{Format: "pdf", Directory: "/d"}, &stubExportService{err: apperr.Validation("format", "md", "pdf")}, apperr.CodeValidation},
+		{"panic", apperr.OutputExportRequest{Directory: "/d"}, &stubExportService{panic: true}, apperr.CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &ExportHandler{service: tt.svc}
+
+			res := h.ExportOutput(tt.req)
+
+			if res.Error == nil {
+				t.Fatal("expected error in result")
+			}
+			if res.Error.Code != tt.wantCode {
+				t.Errorf("Code = %q, want %q", res.Error.Code, tt.wantCode)
+			}
+		})
+	}
+}
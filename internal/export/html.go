@@ -0,0 +1,176 @@
+package export
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// htmlStyle is the stylesheet of an HTML export: a readable single column that
+// follows the reader's light or dark preference and prints cleanly.
+const htmlStyle = `:root{color-scheme:light dark;--fg:#1f2328;--muted:#59636e;--line:#d1d9e0;--code:#f6f8fa;--link:#0969da}
+@media (prefers-color-scheme:dark){:root{--fg:#e6edf3;--muted:#9198a1;--line:#3d444d;--code:#151b23;--link:#4493f8}}
+body{margin:0 auto;max-width:46rem;padding:3rem 1.5rem;font:1rem/1.65 system-ui,-apple-system,"Segoe UI",Roboto,sans-serif;color:var(--fg)}
+h1,h2,h3,h4,h5,h6{line-height:1.25;margin:2rem 0 .75rem}
+h1{font-size:2rem}h2{font-size:1.5rem;border-bottom:1px solid var(--line);padding-bottom:.3rem}h3{font-size:1.25rem}
+p,ul,ol,blockquote,pre,table{margin:0 0 1rem}
+a{color:var(--link)}
+blockquote{margin-left:0;padding:0 1rem;color:var(--muted);border-left:.25rem solid var(--line)}
+code{font:.875em ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;background:var(--code);padding:.15em .35em;border-radius:4px}
+pre{background:var(--code);padding:1rem;overflow:auto;border-radius:6px}pre code{padding:0;background:none}
+table{border-collapse:collapse;display:block;overflow:auto}th,td{border:1px solid var(--line);padding:.4rem .8rem}th{background:var(--code)}
+hr{border:0;border-top:1px solid var(--line);margin:2rem 0}
+.front-matter{display:grid;grid-template-columns:max-content 1fr;gap:.25rem 1rem;margin:0 0 2rem;padding-bottom:1rem;border-bottom:1px solid var(--line);color:var(--muted);font-size:.875rem}
+.front-matter dt{font-weight:600}.front-matter dd{margin:0}
+@media print{body{max-width:none;padding:0}a{color:inherit}}
+`
+
+// renderHTML renders text, Markdown, as a standalone HTML page titled title,
+// starting with meta as a definition list when it is set.
+func renderHTML(title, text string, meta *frontMatter) []byte {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	b.WriteString("<style>\n" + htmlStyle + "</style>\n</head>\n<body>\n")
+	if meta != nil {
+		b.WriteString("<dl class=\"front-matter\">\n")
+		for _, f := range meta.fields() {
+			b.WriteString("<dt>" + html.EscapeString(f.label) + "</dt><dd>" + html.EscapeString(f.value) + "</dd>\n")
+		}
+		b.WriteString("</dl>\n")
+	}
+	writeHTMLBlocks(&b, parseMarkdown(text))
+	b.WriteString("</body>\n</html>\n")
+	return []byte(b.String())
+}
+
+// htmlList is an open <ul> or <ol>.
+type htmlList struct {
+	ordered bool
+	list    int
+}
+
+func writeHTMLBlocks(b *strings.Builder, blocks []block) {
+	var open []htmlList
+	closeLists := func(depth int) {
+		for len(open) > depth {
+			tag := "ul"
+			if open[len(open)-1].ordered {
+				tag = "ol"
+			}
+			b.WriteString("</li>\n</" + tag + ">\n")
+			open = open[:len(open)-1]
+		}
+	}
+	for _, bl := range blocks {
+		if bl.kind != blockListItem {
+			closeLists(0)
+		}
+		switch bl.kind {
+		case blockHeading:
+			level := strconv.Itoa(bl.level)
+			b.WriteString("<h" + level + ">" + inlineHTML(bl.text) + "</h" + level + ">\n")
+		case blockListItem:
+			closeLists(bl.level + 1)
+			if n := len(open); n == bl.level+1 && (open[n-1].ordered != bl.ordered || open[n-1].list != bl.list) {
+				closeLists(bl.level)
+			}
+			if len(open) == bl.level+1 {
+				b.WriteString("</li>\n")
+			}
+			for len(open) <= bl.level {
+				l := htmlList{ordered: bl.ordered, list: bl.list}
+				if l.ordered {
+					b.WriteString("<ol>\n")
+				} else {
+					b.WriteString("<ul>\n")
+				}
+				open = append(open, l)
+				if len(open) <= bl.level {
+					b.WriteString("<li>")
+				}
+			}
+			b.WriteString("<li>" + inlineHTML(bl.text))
+		case blockQuote:
+			b.WriteString("<blockquote>\n")
+			for _, para := range strings.Split(bl.text, "\n\n") {
+				b.WriteString("<p>" + inlineHTML(para) + "</p>\n")
+			}
+			b.WriteString("</blockquote>\n")
+		case blockCode:
+			b.WriteString("<pre><code>" + html.EscapeString(bl.text) + "</code></pre>\n")
+		case blockTable:
+			writeHTMLTable(b, bl)
+		case blockRule:
+			b.WriteString("<hr>\n")
+		default:
+			b.WriteString("<p>" + inlineHTML(bl.text) + "</p>\n")
+		}
+	}
+	closeLists(0)
+}
+
+func writeHTMLTable(b *strings.Builder, bl block) {
+	b.WriteString("<table>\n")
+	for r, row := range bl.rows {
+		if r == 0 {
+			b.WriteString("<thead>\n")
+		} else if r == 1 {
+			b.WriteString("<tbody>\n")
+		}
+		b.WriteString("<tr>")
+		tag := "td"
+		if r == 0 {
+			tag = "th"
+		}
+		for c := range bl.align {
+			cell := ""
+			if c < len(row) {
+				cell = row[c]
+			}
+			attrs := ""
+			if bl.align[c] != "" {
+				attrs = ` style="text-align:` + bl.align[c] + `"`
+			}
+			b.WriteString("<" + tag + attrs + ">" + inlineHTML(cell) + "</" + tag + ">")
+		}
+		b.WriteString("</tr>\n")
+		if r == 0 {
+			b.WriteString("</thead>\n")
+		}
+	}
+	if len(bl.rows) > 1 {
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+// inlineHTML renders inline Markdown as escaped HTML.
+func inlineHTML(text string) string {
+	var b strings.Builder
+	spans := parseInline(text)
+	for i, sp := range spans {
+		if sp.link != "" && (i == 0 || spans[i-1].link != sp.link) {
+			b.WriteString(`<a href="` + html.EscapeString(sp.link) + `">`)
+		}
+		s := html.EscapeString(sp.text)
+		if sp.code {
+			s = "<code>" + s + "</code>"
+		}
+		if sp.strike {
+			s = "<del>" + s + "</del>"
+		}
+		if sp.italic {
+			s = "<em>" + s + "</em>"
+		}
+		if sp.bold {
+			s = "<strong>" + s + "</strong>"
+		}
+		b.WriteString(s)
+		if sp.link != "" && (i+1 == len(spans) || spans[i+1].link != sp.link) {
+			b.WriteString("</a>")
+		}
+	}
+	return b.String()
+}
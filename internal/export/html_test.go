@@ -0,0 +1,45 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderHTML(t *testing.T) {
+	text := "# Plan <v2>\n\nSome **bold** & [link](https://x.y?a=1&b=2).\n\n" +
+		"1. one\n   - sub\n2. two\n\n- bullet\n\n> quote\n\n```\n<tag>\n```\n\n| A | B |\n|---|--:|\n| 1 | 2 |\n\n***"
+	meta := &frontMatter{title: "Plan", date: time.Date(2026, 5, 1, 8, 30, 0, 0, time.UTC), actions: []string{"Proofread", "Formal"}, model: "gpt-4o"}
+
+	got := string(renderHTML("Plan & more", text, meta))
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>Plan &amp; more</title>",
+		"<style>",
+		`<dl class="front-matter">`,
+		"<dt>Actions</dt><dd>Proofread → Formal</dd>",
+		"<dt>Model</dt><dd>gpt-4o</dd>",
+		"<h1>Plan &lt;v2&gt;</h1>",
+		`<p>Some <strong>bold</strong> &amp; <a href="https://x.y?a=1&amp;b=2">link</a>.</p>`,
+		"<ol>\n<li>one<ul>\n<li>sub</li>\n</ul>\n</li>\n<li>two</li>\n</ol>\n<ul>\n<li>bullet</li>\n</ul>",
+		"<blockquote>\n<p>quote</p>\n</blockquote>",
+		"<pre><code>&lt;tag&gt;</code></pre>",
+		"<thead>\n<tr><th>A</th><th style=\"text-align:right\">B</th></tr>\n</thead>\n<tbody>\n<tr><td>1</td><td style=\"text-align:right\">2</td></tr>\n</tbody>",
+		"<hr>\n</body>\n</html>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderHTML_NoFrontMatter(t *testing.T) {
+	got := string(renderHTML("T", "text", nil))
+	if strings.Contains(got, "<dl") {
+		t.Errorf("unexpected front matter:\n%s", got)
+	}
+	if !strings.Contains(got, "<p>text</p>") {
+		t.Errorf("missing paragraph:\n%s", got)
+	}
+}
@@ -0,0 +1,462 @@
+package export
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// blockKind is the kind of a block of a parsed Markdown document.
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockListItem
+	blockQuote
+	blockCode
+	blockTable
+	blockRule
+)
+
+// block is one block of a Markdown document. level is the heading level (1–6)
+// of a heading and the nesting depth (0 for top level) of a list item; list
+// numbers the list an item belongs to, so consecutive lists restart their
+// numbering. text holds inline Markdown, except for code, whose text is
+// verbatim. A table's rows are its cells, the first row being the header, and
+// align holds "left", "center", "right" or "" per column.
+type block struct {
+	kind    blockKind
+	level   int
+	ordered bool
+	list    int
+	text    string
+	rows    [][]string
+	align   []string
+}
+
+var (
+	headingRe   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	ruleRe      = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	listItemRe  = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])(?:[ \t]+(.*))?$`)
+	fenceRe     = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	quoteRe     = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	delimiterRe = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+)
+
+// parseMarkdown splits a Markdown document into blocks: ATX headings, thematic
+// breaks, fenced code, block quotes, nested bullet and numbered lists, pipe
+// tables and paragraphs. Lines of a paragraph, quote or list item are joined
+// with "\n" (a soft break). Lists and tables may interrupt a paragraph.
+// Indented code is read as paragraphs and a setext underline as a thematic
+// break.
+func parseMarkdown(text string) []block {
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n"), "\n")
+	var (
+		blocks  []block
+		para    []string
+		indents []int // indents of the open lists, outermost first
+		lists   int
+		inList  bool // the previous block is a list item open for continuation lines
+	)
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, block{kind: blockParagraph, text: strings.Join(para, "\n")})
+			para = nil
+		}
+	}
+	endList := func() {
+		indents, inList = nil, false
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			flush()
+			inList = false
+			continue
+		}
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			flush()
+			endList()
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimLeft(lines[i], " "), m[1]) && strings.Trim(strings.TrimSpace(lines[i]), m[1][:1]) == "" {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, block{kind: blockCode, text: strings.Join(code, "\n")})
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			flush()
+			endList()
+			blocks = append(blocks, block{kind: blockHeading, level: len(m[1]), text: m[2]})
+			continue
+		}
+		if ruleRe.MatchString(line) {
+			flush()
+			endList()
+			blocks = append(blocks, block{kind: blockRule})
+			continue
+		}
+		if m := listItemRe.FindStringSubmatch(line); m != nil {
+			flush()
+			indent := columns(m[1])
+			if len(indents) == 0 {
+				lists++
+			}
+			for len(indents) > 0 && indent < indents[len(indents)-1] {
+				indents = indents[:len(indents)-1]
+			}
+			if len(indents) == 0 || indent > indents[len(indents)-1]+1 {
+				indents = append(indents, indent)
+			}
+			ordered := m[2][0] >= '0' && m[2][0] <= '9'
+			blocks = append(blocks, block{kind: blockListItem, level: len(indents) - 1, ordered: ordered, list: lists, text: m[3]})
+			inList = true
+			continue
+		}
+		if inList && len(para) == 0 {
+			last := &blocks[len(blocks)-1]
+			last.text += "\n" + strings.TrimSpace(line)
+			continue
+		}
+		if m := quoteRe.FindStringSubmatch(line); m != nil {
+			flush()
+			endList()
+			quote := []string{m[1]}
+			for i+1 < len(lines) {
+				next := quoteRe.FindStringSubmatch(lines[i+1])
+				if next == nil {
+					break
+				}
+				quote = append(quote, next[1])
+				i++
+			}
+			blocks = append(blocks, block{kind: blockQuote, text: strings.Join(quote, "\n")})
+			continue
+		}
+		if strings.Contains(line, "|") && i+1 < len(lines) && delimiterRe.MatchString(lines[i+1]) {
+			flush()
+			header := splitRow(line)
+			align := tableAlign(splitRow(lines[i+1]), len(header))
+			rows := [][]string{header}
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				rows = append(rows, splitRow(lines[i]))
+			}
+			i--
+			endList()
+			blocks = append(blocks, block{kind: blockTable, rows: rows, align: align})
+			continue
+		}
+		if len(indents) > 0 && len(para) == 0 {
+			endList()
+		}
+		para = append(para, strings.TrimSpace(line))
+	}
+	flush()
+	return blocks
+}
+
+// columns returns the width of an indent, a tab counting as four columns.
+func columns(indent string) int {
+	n := 0
+	for _, r := range indent {
+		if r == '\t' {
+			n += 4
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// splitRow returns the trimmed cells of a table row, without the outer pipes.
+// A backslash-escaped pipe is part of its cell.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// tableAlign reads the column alignments of a table's delimiter row.
+func tableAlign(delims []string, n int) []string {
+	align := make([]string, n)
+	for i := range align {
+		if i >= len(delims) {
+			break
+		}
+		d := delims[i]
+		switch left, right := strings.HasPrefix(d, ":"), strings.HasSuffix(d, ":"); {
+		case left && right:
+			align[i] = "center"
+		case right:
+			align[i] = "right"
+		case left:
+			align[i] = "left"
+		}
+	}
+	return align
+}
+
+// span is a run of inline text with its formatting. link is the target of a
+// link the run is part of.
+type span struct {
+	text   string
+	bold   bool
+	italic bool
+	strike bool
+	code   bool
+	link   string
+}
+
+// parseInline splits inline Markdown into formatted spans: **strong** and
+// __strong__, *emphasis* and _emphasis_, ~~strikethrough~~, `code`, [links](url)
+// and <autolinks>. Backslash escapes are resolved; unmatched delimiters are
+// text. Soft breaks become spaces.
+func parseInline(text string) []span {
+	var spans []span
+	parseInlineInto(&spans, strings.ReplaceAll(text, "\n", " "), span{})
+	return mergeSpans(spans)
+}
+
+func parseInlineInto(out *[]span, s string, style span) {
+	var text strings.Builder
+	emit := func() {
+		if text.Len() > 0 {
+			st := style
+			st.text = text.String()
+			*out = append(*out, st)
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+		case c == '`':
+			n := runLength(s[i:], '`')
+			fence := s[i : i+n]
+			if end := strings.Index(s[i+n:], fence); end >= 0 {
+				emit()
+				code := style
+				code.code, code.text = true, strings.TrimSpace(s[i+n:i+n+end])
+				*out = append(*out, code)
+				i += n + end + n
+				continue
+			}
+			text.WriteString(fence)
+			i += n
+			continue
+		case c == '[':
+			if label, target, n, ok := linkAt(s[i:]); ok {
+				emit()
+				linked := style
+				linked.link = target
+				parseInlineInto(out, label, linked)
+				i += n
+				continue
+			}
+		case c == '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				target := s[i+1 : i+end]
+				if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") || strings.HasPrefix(target, "mailto:") {
+					emit()
+					linked := style
+					linked.link, linked.text = target, strings.TrimPrefix(target, "mailto:")
+					*out = append(*out, linked)
+					i += end + 1
+					continue
+				}
+			}
+		case c == '*' || c == '_' || c == '~':
+			n := runLength(s[i:], c)
+			delim := s[i : i+min(n, 2)]
+			if c == '~' && n < 2 {
+				break
+			}
+			if c == '_' && i > 0 && isWordByte(s[i-1]) {
+				break
+			}
+			if end := closingDelimiter(s, i+len(delim), delim); end > 0 {
+				emit()
+				inner := style
+				switch {
+				case c == '~':
+					inner.strike = true
+				case len(delim) == 2:
+					inner.bold = true
+				default:
+					inner.italic = true
+				}
+				parseInlineInto(out, s[i+len(delim):end], inner)
+				i = end + len(delim)
+				continue
+			}
+			text.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		text.WriteByte(c)
+		i++
+	}
+	emit()
+}
+
+// closingDelimiter returns the index in s, from start, of the delim closing an
+// opening one: a run of delim's character not preceded by whitespace, not
+// followed by a word character for "_", and as long as delim — or, closing
+// "**", the last two of a longer run. Other runs, such as "**" within "*…*",
+// are skipped whole.
+func closingDelimiter(s string, start int, delim string) int {
+	if start >= len(s) || s[start] == ' ' {
+		return -1
+	}
+	for i := start + 1; i+len(delim) <= len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '`' {
+			n := runLength(s[i:], '`')
+			if end := strings.Index(s[i+n:], s[i:i+n]); end >= 0 {
+				i += n + end + n - 1
+			}
+			continue
+		}
+		if s[i] != delim[0] {
+			continue
+		}
+		run := runLength(s[i:], delim[0])
+		after := i + run
+		switch {
+		case s[i-1] == ' ', run < len(delim):
+		case delim[0] == '_' && after < len(s) && isWordByte(s[after]):
+		case run == len(delim):
+			return i
+		case len(delim) == 2:
+			return after - 2
+		}
+		i = after - 1
+	}
+	return -1
+}
+
+// linkAt parses an inline link "[label](target)" at the start of s and returns
+// its label, target and length.
+func linkAt(s string) (label, target string, n int, ok bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if i+1 >= len(s) || s[i+1] != '(' {
+				return "", "", 0, false
+			}
+			end := strings.IndexByte(s[i+2:], ')')
+			if end < 0 {
+				return "", "", 0, false
+			}
+			target = strings.TrimSpace(s[i+2 : i+2+end])
+			if sp := strings.IndexAny(target, " \t"); sp >= 0 {
+				target = target[:sp] // drop a "title"
+			}
+			return s[1:i], strings.Trim(target, "<>"), i + 3 + end, true
+		}
+	}
+	return "", "", 0, false
+}
+
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func isWordByte(c byte) bool {
+	return c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// asciiPunct are the characters a backslash escapes.
+const asciiPunct = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte(asciiPunct, c) >= 0
+}
+
+// mergeSpans joins adjacent spans of the same formatting.
+func mergeSpans(spans []span) []span {
+	var out []span
+	for _, sp := range spans {
+		if n := len(out); n > 0 {
+			last := out[n-1]
+			last.text = sp.text
+			if last == sp {
+				out[n-1].text += sp.text
+				continue
+			}
+		}
+		out = append(out, sp)
+	}
+	return out
+}
+
+// plainText returns the text of inline Markdown without its markup. A link
+// whose label differs from its target is followed by the target in brackets.
+func plainText(text string) string {
+	var b strings.Builder
+	spans := parseInline(text)
+	for i, sp := range spans {
+		b.WriteString(sp.text)
+		if sp.link != "" && (i+1 == len(spans) || spans[i+1].link != sp.link) {
+			if label := linkLabel(spans, i); label != sp.link && label != strings.TrimPrefix(sp.link, "mailto:") {
+				b.WriteString(" (" + sp.link + ")")
+			}
+		}
+	}
+	return b.String()
+}
+
+// linkLabel returns the text of the link ending with spans[end].
+func linkLabel(spans []span, end int) string {
+	start := end
+	for start > 0 && spans[start-1].link == spans[end].link {
+		start--
+	}
+	var b strings.Builder
+	for _, sp := range spans[start : end+1] {
+		b.WriteString(sp.text)
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []block
+	}{
+		{
+			name: "headings, paragraphs and rule",
+			text: "# Title #\r\n\r\nFirst line\nsecond line\n\n---\n###### Deep\n#nope",
+			want: []block{
+				{kind: blockHeading, level: 1, text: "Title"},
+				{kind: blockParagraph, text: "First line\nsecond line"},
+				{kind: blockRule},
+				{kind: blockHeading, level: 6, text: "Deep"},
+				{kind: blockParagraph, text: "#nope"},
+			},
+		},
+		{
+			name: "nested lists",
+			text: "Intro:\n- one\n  continued\n  1. sub\n  2. sub two\n- two\n\n3) third\n\nAfter",
+			want: []block{
+				{kind: blockParagraph, text: "Intro:"},
+				{kind: blockListItem, list: 1, text: "one\ncontinued"},
+				{kind: blockListItem, level: 1, ordered: true, list: 1, text: "sub"},
+				{kind: blockListItem, level: 1, ordered: true, list: 1, text: "sub two"},
+				{kind: blockListItem, list: 1, text: "two"},
+				{kind: blockListItem, ordered: true, list: 1, text: "third"},
+				{kind: blockParagraph, text: "After"},
+			},
+		},
+		{
+			name: "separate lists",
+			text: "* a\n\nText\n\n* b",
+			want: []block{
+				{kind: blockListItem, list: 1, text: "a"},
+				{kind: blockParagraph, text: "Text"},
+				{kind: blockListItem, list: 2, text: "b"},
+			},
+		},
+		{
+			name: "code and quote",
+			text: "```go\nfunc f() {\n\n\t# not a heading\n}\n```\n> quoted\n> more\n>\n> next",
+			want: []block{
+				{kind: blockCode, text: "func f() {\n\n\t# not a heading\n}"},
+				{kind: blockQuote, text: "quoted\nmore\n\nnext"},
+			},
+		},
+		{
+			name: "unclosed fence runs to the end",
+			text: "~~~\ncode",
+			want: []block{{kind: blockCode, text: "code"}},
+		},
+		{
+			name: "table",
+			text: "Totals:\n| Item | Qty | Note |\n|:-----|----:|:----:|\n| a \\| b | 1 |\n| c | 2 | x |\nafter",
+			want: []block{
+				{kind: blockParagraph, text: "Totals:"},
+				{kind: blockTable, rows: [][]string{{"Item", "Qty", "Note"}, {"a | b", "1"}, {"c", "2", "x"}}, align: []string{"left", "right", "center"}},
+				{kind: blockParagraph, text: "after"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseMarkdown(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMarkdown =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		text string
+		want []span
+	}{
+		{"plain", []span{{text: "plain"}}},
+		{"a **bold** and *it* and __b__ and _i_", []span{
+			{text: "a "}, {text: "bold", bold: true}, {text: " and "}, {text: "it", italic: true},
+			{text: " and "}, {text: "b", bold: true}, {text: " and "}, {text: "i", italic: true},
+		}},
+		{"***both***", []span{{text: "both", bold: true, italic: true}}},
+		{"*a **b** c*", []span{{text: "a ", italic: true}, {text: "b", bold: true, italic: true}, {text: " c", italic: true}}},
+		{"~~gone~~ `x*y*` ``a`b``", []span{{text: "gone", strike: true}, {text: " "}, {text: "x*y*", code: true}, {text: " "}, {text: "a`b", code: true}}},
+		{"[the **site**](https://x.y \"T\") <https://a.b>", []span{
+			{text: "the ", link: "https://x.y"}, {text: "site", bold: true, link: "https://x.y"},
+			{text: " "}, {text: "https://a.b", link: "https://a.b"},
+		}},
+		{`snake_case_name 2 * 3 * 4 \*lit\* a ** b`, []span{{text: "snake_case_name 2 * 3 * 4 *lit* a ** b"}}},
+		{"line\nbreak", []span{{text: "line break"}}},
+		{"[not a link] (x)", []span{{text: "[not a link] (x)"}}},
+	}
+	for _, tt := range tests {
+		if got := parseInline(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseInline(%q) =\n%+v\nwant\n%+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"**Note:** see [the docs](https://d.io) or <https://d.io>", "Note: see the docs (https://d.io) or https://d.io"},
+		{"[https://d.io](https://d.io)", "https://d.io"},
+		{"`code` and ~~old~~", "code and old"},
+	}
+	for _, tt := range tests {
+		if got := plainText(tt.text); got != tt.want {
+			t.Errorf("plainText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
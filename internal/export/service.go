@@ -0,0 +1,156 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/settings"
+
+	"github.com/wailsapp/wails/v2/pkg/logger"
+)
+
+// Export formats accepted in apperr.OutputExportRequest.Format; each is also
+// the file extension.
+const (
+	FormatMarkdown = "md"
+	FormatText     = "txt"
+	FormatHTML     = "html"
+	FormatDocx     = "docx"
+)
+
+// defaultTitle titles an export whose stack or action name is unknown.
+const defaultTitle = "Export"
+
+// exportHistoryAPI is the minimal contract ExportService needs from the history service.
+type exportHistoryAPI interface {
+	Get(id string) (*apperr.HistoryEntry, error)
+}
+
+// exportSettingsAPI is the minimal contract ExportService needs from the settings service.
+type exportSettingsAPI interface {
+	GetAppBehaviorConfig() (*settings.AppBehaviorConfig, error)
+}
+
+// exportFileAPI is the minimal contract ExportService needs from the file utils service.
+type exportFileAPI interface {
+	WriteExportFile(dir, name string, data []byte) (string, error)
+}
+
+// ExportServiceAPI is the contract consumed by ExportHandler.
+type ExportServiceAPI interface {
+	// Export writes an output to a file and returns its path.
+	Export(req apperr.OutputExportRequest) (string, error)
+}
+
+// ExportService implements ExportServiceAPI.
+type ExportService struct {
+	logger   logger.Logger
+	history  exportHistoryAPI
+	settings exportSettingsAPI
+	files    exportFileAPI
+}
+
+// NewExportService constructs an ExportService. Panics on nil dependencies.
+func NewExportService(wailsLogger logger.Logger, historyService exportHistoryAPI, settingsService exportSettingsAPI, fileService exportFileAPI) *ExportService {
+	const op = "ExportService.NewExportService"
+	if wailsLogger == nil {
+		panic(fmt.Sprintf("%s: logger cannot be nil", op))
+	}
+	if historyService == nil {
+		panic(fmt.Sprintf("%s: history service cannot be nil", op))
+	}
+	if settingsService == nil {
+		panic(fmt.Sprintf("%s: settings service cannot be nil", op))
+	}
+	if fileService == nil {
+		panic(fmt.Sprintf("%s: file service cannot be nil", op))
+	}
+	return &ExportService{logger: wailsLogger, history: historyService, settings: settingsService, files: fileService}
+}
+
+// Export writes the output of req.HistoryID, or req.Text, into req.Directory
+// as Markdown, plain text with the markup removed, a standalone HTML page or a
+// Word document, and returns the path written. The file is named by
+// AppBehaviorConfig.ExportFileName from the stack or action name and the run's
+// date; an existing file is never overwritten.
+func (s *ExportService) Export(req apperr.OutputExportRequest) (string, error) {
+	const op = "ExportService.Export"
+	switch req.Format {
+	case FormatMarkdown, FormatText, FormatHTML, FormatDocx:
+	default:
+		return "", apperr.Validation("format",
+			fmt.Sprintf("one of %s, %s, %s, %s", FormatMarkdown, FormatText, FormatHTML, FormatDocx), req.Format)
+	}
+
+	meta := &frontMatter{title: req.Name, provider: req.ProviderName, model: req.Model, date: time.Now()}
+	text, applied := req.Text, req.Applied
+	if req.HistoryID != "" {
+		entry, err := s.history.Get(req.HistoryID)
+		if err != nil {
+			return "", err
+		}
+		if entry.OutputText == "" {
+			return "", apperr.Validation("historyId", "name an entry with output text", req.HistoryID)
+		}
+		text, applied = entry.OutputText, entry.Applied
+		meta = &frontMatter{title: entry.Title, provider: entry.ProviderName, model: entry.Model, date: time.Unix(entry.CreatedAt, 0)}
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", apperr.Validation("text", "be non-empty", "empty string")
+	}
+	for _, a := range applied {
+		if !a.Skipped {
+			meta.actions = append(meta.actions, a.Name)
+		}
+	}
+	if meta.title == "" {
+		meta.title = strings.Join(meta.actions, " → ")
+	}
+	title := meta.title
+	if title == "" {
+		title = defaultTitle
+	}
+	shown := meta
+	if !req.FrontMatter {
+		shown = nil
+	}
+
+	var data []byte
+	switch req.Format {
+	case FormatMarkdown:
+		if shown != nil {
+			text = shown.yaml() + text
+		}
+		data = []byte(strings.TrimRight(text, "\n") + "\n")
+	case FormatText:
+		if shown != nil {
+			data = []byte(shown.header() + renderText(text))
+		} else {
+			data = []byte(renderText(text))
+		}
+	case FormatHTML:
+		data = renderHTML(title, text, shown)
+	case FormatDocx:
+		var err error
+		if data, err = renderDocx(title, text, shown, meta.date); err != nil {
+			return "", apperr.Internal(fmt.Errorf("%s: %w", op, err))
+		}
+	}
+
+	cfg, err := s.settings.GetAppBehaviorConfig()
+	if err != nil {
+		return "", fmt.Errorf("%s: get app behavior config: %w", op, err)
+	}
+	template := cfg.ExportFileName
+	if template == "" {
+		template = settings.DefaultExportFileName
+	}
+	path, err := s.files.WriteExportFile(req.Directory, FileName(template, meta.title, meta.date)+"."+req.Format, data)
+	if err != nil {
+		return "", err
+	}
+	s.logger.Info(fmt.Sprintf("[%s] exported %s output to %s", op, req.Format, path))
+	return path, nil
+}
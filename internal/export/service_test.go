@@ -0,0 +1,197 @@
+package export
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/settings"
+)
+
+// --- fakes ---
+
+type fakeLogger struct{}
+
+func (fakeLogger) Print(string)   {}
+func (fakeLogger) Trace(string)   {}
+func (fakeLogger) Debug(string)   {}
+func (fakeLogger) Info(string)    {}
+func (fakeLogger) Warning(string) {}
+func (fakeLogger) Error(string)   {}
+func (fakeLogger) Fatal(string)   {}
+
+type mockHistory struct {
+	entry *apperr.HistoryEntry
+	err   error
+}
+
+func (m *mockHistory) Get(string) (*apperr.HistoryEntry, error) { return m.entry, m.err }
+
+type mockSettingsSvc struct {
+	cfg *settings.AppBehaviorConfig
+	err error
+}
+
+func (m *mockSettingsSvc) GetAppBehaviorConfig() (*settings.AppBehaviorConfig, error) {
+	return m.cfg, m.err
+}
+
+type mockFiles struct {
+	dir, name string
+	data      []byte
+	err       error
+}
+
+func (m *mockFiles) WriteExportFile(dir, name string, data []byte) (string, error) {
+	m.dir, m.name, m.data = dir, name, data
+	return filepath.Join(dir, name), m.err
+}
+
+func newTestService(history *mockHistory, template string, files *mockFiles) *ExportService {
+	return NewExportService(fakeLogger{}, history, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{ExportFileName: template}}, files)
+}
+
+func historyEntry() *apperr.HistoryEntry {
+	return &apperr.HistoryEntry{
+		ID:           "e1",
+		CreatedAt:    time.Date(2026, 3, 14, 9, 26, 53, 0, time.Local).Unix(),
+		Title:        "Proofread → Professional tone",
+		OutputText:   "# Report\n\nThe report is **finished**.",
+		Applied:      []apperr.AppliedAction{{Name: "Proofread"}, {Name: "Translate", Skipped: true}, {Name: "Professional tone"}},
+		ProviderName: "Ollama",
+		Model:        "llama3.1:8b",
+	}
+}
+
+func TestNewExportService_PanicsOnNil(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	NewExportService(fakeLogger{}, nil, &mockSettingsSvc{}, &mockFiles{})
+}
+
+func TestExportService_Export_HistoryEntry(t *testing.T) {
+	tests := []struct {
+		format      string
+		frontMatter bool
+		wantName    string
+		wantContent []string
+		notContent  []string
+	}{
+		{
+			format: FormatMarkdown, frontMatter: true, wantName: "Proofread Professional tone 2026-03-14.md",
+			wantContent: []string{"---\ntitle: \"Proofread → Professional tone\"\n", "actions:\n  - \"Proofread\"\n  - \"Professional tone\"\nmodel: \"llama3.1:8b\"\nprovider: \"Ollama\"\n---\n\n# Report\n\nThe report is **finished**.\n"},
+			notContent:  []string{"Translate"},
+		},
+		{
+			format: FormatMarkdown, wantName: "Proofread Professional tone 2026-03-14.md",
+			wantContent: []string{"# Report\n\nThe report is **finished**.\n"},
+			notContent:  []string{"---", "llama3.1:8b"},
+		},
+		{
+			format: FormatText, frontMatter: true, wantName: "Proofread Professional tone 2026-03-14.txt",
+			wantContent: []string{"Title: Proofread → Professional tone\nDate: 2026-03-14 09:26\nActions: Proofread → Professional tone\nModel: llama3.1:8b\nProvider: Ollama\n\nReport\n\nThe report is finished.\n"},
+		},
+		{
+			format: FormatHTML, frontMatter: true, wantName: "Proofread Professional tone 2026-03-14.html",
+			wantContent: []string{"<title>Proofread → Professional tone</title>", "<dt>Model</dt><dd>llama3.1:8b</dd>", "<strong>finished</strong>"},
+		},
+		{
+			format: FormatDocx, wantName: "Proofread Professional tone 2026-03-14.docx",
+			wantContent: []string{"PK"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			files := &mockFiles{}
+			svc := newTestService(&mockHistory{entry: historyEntry()}, "", files)
+
+			path, err := svc.Export(apperr.OutputExportRequest{HistoryID: "e1", Format: tt.format, Directory: "/docs", FrontMatter: tt.frontMatter})
+			if err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			if files.name != tt.wantName || path != filepath.Join("/docs", tt.wantName) {
+				t.Errorf("name = %q, path = %q; want %q", files.name, path, tt.wantName)
+			}
+			for _, want := range tt.wantContent {
+				if !strings.Contains(string(files.data), want) {
+					t.Errorf("content lacks %q:\n%s", want, files.data)
+				}
+			}
+			for _, not := range tt.notContent {
+				if strings.Contains(string(files.data), not) {
+					t.Errorf("content has %q:\n%s", not, files.data)
+				}
+			}
+		})
+	}
+}
+
+func TestExportService_Export_Text(t *testing.T) {
+	files := &mockFiles{}
+	svc := newTestService(&mockHistory{}, "{name}", files)
+
+	_, err := svc.Export(apperr.OutputExportRequest{
+		Text:        "Hello",
+		Applied:     []apperr.AppliedAction{{Name: "Shorten"}},
+		Model:       "gpt-4o",
+		Format:      FormatDocx,
+		Directory:   "/out",
+		FrontMatter: true,
+	})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if files.name != "Shorten.docx" {
+		t.Errorf("name = %q, want the actions as name", files.name)
+	}
+	doc := docxParts(t, files.data)["word/document.xml"]
+	if !strings.Contains(doc, "gpt-4o") || !strings.Contains(doc, "Hello") {
+		t.Errorf("document.xml lacks model or text: %s", doc)
+	}
+}
+
+func TestExportService_Export_Errors(t *testing.T) {
+	noOutput := historyEntry()
+	noOutput.OutputText = ""
+	tests := []struct {
+		name      string
+		req       apperr.OutputExportRequest
+		history   *mockHistory
+		files     *mockFiles
+		wantField string
+		wantCode  apperr.ErrorCode
+	}{
+		{name: "unknown format", req: apperr.OutputExportRequest{Text: "x", Format: "pdf"}, wantField: "format"},
+		{name: "empty text", req: apperr.OutputExportRequest{Text: " \n", Format: FormatMarkdown}, wantField: "text"},
+		{name: "entry without output", req: apperr.OutputExportRequest{HistoryID: "e1", Format: FormatMarkdown}, history: &mockHistory{entry: noOutput}, wantField: "historyId"},
+		{name: "history error", req: apperr.OutputExportRequest{HistoryID: "e1", Format: FormatMarkdown}, history: &mockHistory{err: apperr.Internal(errors.New("db closed"))}, wantCode: apperr.CodeInternal},
+		{name: "write error", req: apperr.OutputExportRequest{Text: "x", Format: FormatMarkdown}, files: &mockFiles{err: apperr.Validation("directory", "point to an existing directory", "/nope")}, wantField: "directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.history == nil {
+				tt.history = &mockHistory{}
+			}
+			if tt.files == nil {
+				tt.files = &mockFiles{}
+			}
+			_, err := newTestService(tt.history, "", tt.files).Export(tt.req)
+			var ae *apperr.AppError
+			if !errors.As(err, &ae) {
+				t.Fatalf("expected an AppError, got %v", err)
+			}
+			if tt.wantField != "" && (ae.Code != apperr.CodeValidation || ae.Details["field"] != tt.wantField) {
+				t.Errorf("got %s on %v, want validation of %q", ae.Code, ae.Details["field"], tt.wantField)
+			}
+			if tt.wantCode != "" && ae.Code != tt.wantCode {
+				t.Errorf("Code = %q, want %q", ae.Code, tt.wantCode)
+			}
+		})
+	}
+}
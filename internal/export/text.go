@@ -0,0 +1,184 @@
+package export
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go_text/internal/settings"
+)
+
+// maxNameRunes caps the length of a file name built by FileName.
+const maxNameRunes = 60
+
+// CleanName reduces s to characters safe in a file name on every file system:
+// letters, digits, "-", "_" and single spaces, at most maxNameRunes of them.
+func CleanName(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n == maxNameRunes {
+			break
+		}
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+		n++
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// FileName returns the file name, without extension, that template gives an
+// export of name at the given time: {name} is replaced with name, {date} with
+// the date and {time} with the time of day, and the result made safe with
+// CleanName. A template that leaves nothing falls back to "export" and the date.
+func FileName(template, name string, at time.Time) string {
+	file := strings.NewReplacer(
+		settings.ExportNamePlaceholder, name,
+		settings.ExportDatePlaceholder, at.Format("2006-01-02"),
+		settings.ExportTimePlaceholder, at.Format("150405"),
+	).Replace(template)
+	if file = CleanName(file); file == "" {
+		file = "export " + at.Format("2006-01-02")
+	}
+	return file
+}
+
+// frontMatter describes an exported output: the stack or action that produced
+// it, when, the actions applied and the model.
+type frontMatter struct {
+	title    string
+	date     time.Time
+	actions  []string
+	provider string
+	model    string
+}
+
+type frontMatterField struct {
+	label string
+	value string
+}
+
+// fields returns the set fields of m, labelled for display.
+func (m *frontMatter) fields() []frontMatterField {
+	var fields []frontMatterField
+	add := func(label, value string) {
+		if value != "" {
+			fields = append(fields, frontMatterField{label, value})
+		}
+	}
+	add("Title", m.title)
+	add("Date", m.date.Format("2006-01-02 15:04"))
+	add("Actions", strings.Join(m.actions, " → "))
+	add("Model", m.model)
+	add("Provider", m.provider)
+	return fields
+}
+
+// yaml renders m as a YAML front-matter block, as read by static site
+// generators and Markdown editors.
+func (m *frontMatter) yaml() string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	if m.title != "" {
+		b.WriteString("title: " + strconv.Quote(m.title) + "\n")
+	}
+	b.WriteString("date: " + m.date.Format(time.RFC3339) + "\n")
+	if len(m.actions) > 0 {
+		b.WriteString("actions:\n")
+		for _, a := range m.actions {
+			b.WriteString("  - " + strconv.Quote(a) + "\n")
+		}
+	}
+	if m.model != "" {
+		b.WriteString("model: " + strconv.Quote(m.model) + "\n")
+	}
+	if m.provider != "" {
+		b.WriteString("provider: " + strconv.Quote(m.provider) + "\n")
+	}
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+// header renders m as "Label: value" lines for a plain-text export.
+func (m *frontMatter) header() string {
+	var b strings.Builder
+	for _, f := range m.fields() {
+		b.WriteString(f.label + ": " + f.value + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderText renders Markdown as plain text: markup is dropped, list items keep
+// a "-" or their number, quotes their ">" and table cells are separated by
+// tabs. Line breaks within a paragraph are kept.
+func renderText(text string) string {
+	var b strings.Builder
+	var counters []int
+	prev := blockKind(-1)
+	list := 0
+	for _, bl := range parseMarkdown(text) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+			if bl.kind != blockListItem || prev != blockListItem {
+				b.WriteString("\n")
+			}
+		}
+		if bl.kind != blockListItem || bl.list != list {
+			counters, list = counters[:0], bl.list
+		}
+		switch bl.kind {
+		case blockListItem:
+			for len(counters) <= bl.level {
+				counters = append(counters, 0)
+			}
+			counters = counters[:bl.level+1]
+			marker := "- "
+			if bl.ordered {
+				counters[bl.level]++
+				marker = strconv.Itoa(counters[bl.level]) + ". "
+			}
+			indent := strings.Repeat("  ", bl.level)
+			b.WriteString(indent + marker + plainLines(bl.text, "\n"+indent+strings.Repeat(" ", len(marker))))
+		case blockQuote:
+			b.WriteString("> " + plainLines(bl.text, "\n> "))
+		case blockCode:
+			b.WriteString(bl.text)
+		case blockTable:
+			for r, row := range bl.rows {
+				if r > 0 {
+					b.WriteString("\n")
+				}
+				cells := make([]string, len(row))
+				for c, cell := range row {
+					cells[c] = plainText(cell)
+				}
+				b.WriteString(strings.Join(cells, "\t"))
+			}
+		case blockRule:
+			b.WriteString("----")
+		default:
+			b.WriteString(plainLines(bl.text, "\n"))
+		}
+		prev = bl.kind
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// plainLines returns the plain text of each line of inline Markdown, joined
+// with sep.
+func plainLines(text, sep string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = plainText(line)
+	}
+	return strings.Join(lines, sep)
+}
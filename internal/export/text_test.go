@@ -0,0 +1,53 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileName(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	tests := []struct {
+		template string
+		name     string
+		want     string
+	}{
+		{"{name} {date}", "Proofread → Formal", "Proofread Formal 2026-01-02"},
+		{"{date}_{time}-{name}", "Summary", "2026-01-02_030405-Summary"},
+		{"{name}", "", "export 2026-01-02"},
+		{"{name}", `a/b\c: "d"?`, "a b c d"},
+		{"{name}", strings.Repeat("x", 100), strings.Repeat("x", 60)},
+	}
+	for _, tt := range tests {
+		if got := FileName(tt.template, tt.name, at); got != tt.want {
+			t.Errorf("FileName(%q, %q) = %q, want %q", tt.template, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFrontMatter(t *testing.T) {
+	m := &frontMatter{
+		title:    `Say "hi"`,
+		date:     time.Date(2026, 5, 1, 8, 30, 0, 0, time.UTC),
+		actions:  []string{"Proofread", "Formal"},
+		provider: "Ollama",
+		model:    "llama3",
+	}
+	wantYAML := "---\ntitle: \"Say \\\"hi\\\"\"\ndate: 2026-05-01T08:30:00Z\nactions:\n  - \"Proofread\"\n  - \"Formal\"\nmodel: \"llama3\"\nprovider: \"Ollama\"\n---\n\n"
+	if got := m.yaml(); got != wantYAML {
+		t.Errorf("yaml =\n%q\nwant\n%q", got, wantYAML)
+	}
+	wantHeader := "Title: Say \"hi\"\nDate: 2026-05-01 08:30\nActions: Proofread → Formal\nModel: llama3\nProvider: Ollama\n\n"
+	if got := m.header(); got != wantHeader {
+		t.Errorf("header =\n%q\nwant\n%q", got, wantHeader)
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	text := "# Title\n\nSome **bold** text\nand a [link](https://x.y).\n\n1. one\n   - sub\n2. two\n\n> quoted\n\n```\n**kept**\n```\n\n| A | B |\n|---|---|\n| `1` | 2 |\n\n---"
+	want := "Title\n\nSome bold text\nand a link (https://x.y).\n\n1. one\n  - sub\n2. two\n\n> quoted\n\n**kept**\n\nA\tB\n1\t2\n\n----\n"
+	if got := renderText(text); got != want {
+		t.Errorf("renderText =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -1,11 +1,15 @@
 package file
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"go_text/internal/apperr"
 	"go_text/internal/logging"
 
 	"github.com/rs/zerolog"
@@ -18,6 +22,7 @@ type FileUtilsServiceAPI interface {
 	GetAppDatabaseFilePath() (string, error)
 	ResolveAppLogsFolderPath(customDir string) (string, error)
 	EnsureAppLogsFolderExists(customDir string) (string, error)
+	WriteExportFile(dir, name string, data []byte) (string, error)
 }
 
 type FileUtilsService struct {
@@ -160,3 +165,56 @@ func (s *FileUtilsService) EnsureAppLogsFolderExists(customDir string) (string,
 	lg.Debug().Int64("duration_ms", duration.Milliseconds()).Msg("successfully ensured logs folder exists")
 	return logsPath, nil
 }
+
+// maxExportNameTries bounds the " (n)" suffixes WriteExportFile tries before
+// giving up on finding a free file name.
+const maxExportNameTries = 100
+
+// WriteExportFile writes data to a new file called name in the user-chosen
+// directory dir and returns its path. An existing file is never overwritten:
+// "report.docx" becomes "report (2).docx", and so on. dir must be an existing
+// directory and name a plain file name; either failing is a validation error.
+func (s *FileUtilsService) WriteExportFile(dir, name string, data []byte) (string, error) {
+	const op = "FileUtilsService.WriteExportFile"
+	startTime := time.Now()
+	lg := s.log(op)
+	lg.Debug().Str("dir", dir).Str("name", name).Int("bytes", len(data)).Msg("writing export file")
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", apperr.Validation("directory", "point to an existing directory", dir)
+	}
+	if name == "" || name == "." || name == ".." || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) {
+		return "", apperr.Validation("name", "be a plain file name", name)
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 1; n <= maxExportNameTries; n++ {
+		candidate := name
+		if n > 1 {
+			candidate = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+		}
+		path := filepath.Join(dir, candidate)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			lg.Error().Err(err).Str("path", path).Msg("failed to create export file")
+			return "", fmt.Errorf("%s: create %s: %w", op, path, err)
+		}
+		if _, err := f.Write(data); err != nil {
+			_ = f.Close()
+			_ = os.Remove(path)
+			lg.Error().Err(err).Str("path", path).Msg("failed to write export file")
+			return "", fmt.Errorf("%s: write %s: %w", op, path, err)
+		}
+		if err := f.Close(); err != nil {
+			_ = os.Remove(path)
+			return "", fmt.Errorf("%s: close %s: %w", op, path, err)
+		}
+		lg.Info().Str("path", path).Int64("duration_ms", time.Since(startTime).Milliseconds()).Msg("export file written")
+		return path, nil
+	}
+	return "", fmt.Errorf("%s: no free file name for %q in %s", op, name, dir)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"go_text/internal/apperr"
 	"go_text/internal/logging"
 
 	"github.com/stretchr/testify/assert"
@@ -594,3 +595,46 @@ func TestFileUtilsService_DevIsolation(t *testing.T) {
 		})
 	}
 }
+
+func TestFileUtilsService_WriteExportFile(t *testing.T) {
+	t.Run("writes a new file and never overwrites", func(t *testing.T) {
+		dir := t.TempDir()
+		svc := NewFileUtilsService(newTestLogger(t), false)
+
+		first, err := svc.WriteExportFile(dir, "report.md", []byte("one"))
+		require.NoError(t, err)
+		second, err := svc.WriteExportFile(dir, "report.md", []byte("two"))
+		require.NoError(t, err)
+
+		assert.Equal(t, filepath.Join(dir, "report.md"), first)
+		assert.Equal(t, filepath.Join(dir, "report (2).md"), second)
+		got, err := os.ReadFile(first)
+		require.NoError(t, err)
+		assert.Equal(t, "one", string(got))
+		got, err = os.ReadFile(second)
+		require.NoError(t, err)
+		assert.Equal(t, "two", string(got))
+	})
+
+	t.Run("rejects a missing directory and unsafe names", func(t *testing.T) {
+		dir := t.TempDir()
+		svc := NewFileUtilsService(newTestLogger(t), false)
+
+		tests := []struct{ dir, name string }{
+			{filepath.Join(dir, "missing"), "report.md"},
+			{dir, ""},
+			{dir, ".."},
+			{dir, "../report.md"},
+			{dir, `sub\report.md`},
+		}
+		for _, tt := range tests {
+			_, err := svc.WriteExportFile(tt.dir, tt.name, []byte("x"))
+			var ae *apperr.AppError
+			require.ErrorAs(t, err, &ae, "dir=%q name=%q", tt.dir, tt.name)
+			assert.Equal(t, apperr.CodeValidation, ae.Code)
+		}
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
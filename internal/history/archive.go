@@ -0,0 +1,338 @@
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_text/internal/apperr"
+)
+
+// History archive formats accepted by ExportHistory; ImportHistory reads
+// ArchiveJSONL.
+const (
+	ArchiveJSONL    = "jsonl"
+	ArchiveCSV      = "csv"
+	ArchiveMarkdown = "markdown"
+)
+
+// archiveExtensions maps each archive format to its file extension.
+var archiveExtensions = map[string]string{
+	ArchiveJSONL:    ".jsonl",
+	ArchiveCSV:      ".csv",
+	ArchiveMarkdown: ".md",
+}
+
+// maxImportBytes caps the size of a file ImportHistory reads.
+const maxImportBytes = 256 << 20
+
+// ExportHistory writes the entries matching req's filters into req.Directory
+// in req.Format and returns the file written with its entry count. No entry
+// matching the filters is a validation error.
+func (s *HistoryService) ExportHistory(req apperr.HistoryArchiveRequest) (*apperr.HistoryArchive, error) {
+	const op = "HistoryService.ExportHistory"
+	if s.repo == nil {
+		return nil, apperr.Internal(errors.New("history repository not initialized"))
+	}
+	ext, ok := archiveExtensions[req.Format]
+	if !ok {
+		return nil, apperr.Validation("format", fmt.Sprintf("%q, %q or %q", ArchiveJSONL, ArchiveCSV, ArchiveMarkdown), req.Format)
+	}
+	if err := validateSearch(apperr.HistorySearchRequest{Status: req.Status, From: req.From, To: req.To}); err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(req.Tags))
+	for _, tag := range req.Tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	req.Tags = tags
+
+	entries, err := s.repo.Export(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, apperr.Validation("filters", "match at least one entry", "no entries")
+	}
+
+	now := time.Now()
+	var data []byte
+	switch req.Format {
+	case ArchiveJSONL:
+		data, err = encodeJSONL(entries)
+	case ArchiveCSV:
+		data, err = encodeCSV(entries)
+	case ArchiveMarkdown:
+		data = encodeDigest(entries, now)
+	}
+	if err != nil {
+		return nil, apperr.Internal(fmt.Errorf("%s: %w", op, err))
+	}
+
+	path, err := s.files.WriteExportFile(req.Directory, "history "+now.Format("2006-01-02 150405")+ext, data)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info(fmt.Sprintf("[%s] exported %d entries as %s to %s", op, len(entries), req.Format, path))
+	return &apperr.HistoryArchive{Path: path, Entries: len(entries)}, nil
+}
+
+// ImportHistory adds the entries of the JSONL file at path, as written by
+// ExportHistory, keeping their IDs, dates, steps, flags and tags. Entries whose
+// ID is already recorded are skipped, and history is then pruned to
+// HistoryMaxEntries, sparing pinned and favorite entries. A missing, oversized
+// or malformed file is a validation error and imports nothing.
+func (s *HistoryService) ImportHistory(path string) (*apperr.HistoryImportSummary, error) {
+	const op = "HistoryService.ImportHistory"
+	if s.repo == nil {
+		return nil, apperr.Internal(errors.New("history repository not initialized"))
+	}
+	if path == "" {
+		return nil, apperr.Validation("path", "be non-empty", "empty string")
+	}
+	data, err := readArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := decodeJSONL(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.settings.GetAppBehaviorConfig()
+	if err != nil {
+		return nil, err
+	}
+	var maxEntries int64
+	if cfg != nil {
+		maxEntries = int64(cfg.HistoryMaxEntries)
+	}
+
+	summary, err := s.repo.Import(entries, maxEntries)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info(fmt.Sprintf("[%s] imported %d of %d entries from %s (%d duplicates, %d pruned)",
+		op, summary.Imported, summary.Read, path, summary.Duplicates, summary.Pruned))
+	return &summary, nil
+}
+
+// readArchive reads a file of at most maxImportBytes; a missing or larger file
+// is a validation error.
+func readArchive(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, apperr.Validation("path", "an existing file", path)
+		}
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxImportBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if len(data) > maxImportBytes {
+		return nil, apperr.Validation("path", fmt.Sprintf("a file of at most %d MB", maxImportBytes>>20), "a larger file")
+	}
+	return data, nil
+}
+
+// encodeJSONL writes one entry per line.
+func encodeJSONL(entries []apperr.HistoryEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return nil, fmt.Errorf("encode entry %s: %w", e.ID, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeJSONL parses one entry per non-blank line and checks each is one
+// ExportHistory could have written, with normalized tags.
+func decodeJSONL(data []byte) ([]apperr.HistoryEntry, error) {
+	var entries []apperr.HistoryEntry
+	for n, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		bad := func(reason string) error {
+			return apperr.Validation("path", "a history export in JSONL", fmt.Sprintf("line %d: %s", n+1, reason))
+		}
+		var e apperr.HistoryEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, bad("not a JSON history entry")
+		}
+		switch {
+		case e.ID == "":
+			return nil, bad("no id")
+		case e.CreatedAt <= 0:
+			return nil, bad("no createdAt")
+		case e.Kind != "single" && e.Kind != "stack":
+			return nil, bad(fmt.Sprintf("kind %q", e.Kind))
+		case e.Status != "success" && e.Status != "partial" && e.Status != "error":
+			return nil, bad(fmt.Sprintf("status %q", e.Status))
+		}
+		tags := make([]string, 0, len(e.Tags))
+		for _, tag := range e.Tags {
+			normalized, err := normalizeTag(tag)
+			if err != nil {
+				return nil, bad(fmt.Sprintf("tag %q", tag))
+			}
+			tags = append(tags, normalized)
+		}
+		e.Tags = tags
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		return nil, apperr.Validation("path", "a history export in JSONL", "no entries")
+	}
+	return entries, nil
+}
+
+// csvHeader names the columns encodeCSV writes.
+var csvHeader = []string{
+	"id", "created_at", "kind", "title", "status", "error_code", "failed_index",
+	"provider", "model", "input_lang", "output_lang", "format",
+	"duration_ms", "inferences", "change_ratio", "actions", "tags", "pinned", "favorite",
+	"run_id", "branch", "rerun_of", "input_text", "output_text",
+}
+
+// encodeCSV writes a header then one flat row per entry: actions are the
+// applied action IDs and tags joined by "; ", dates RFC 3339 in local time.
+func encodeCSV(entries []apperr.HistoryEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		actions := make([]string, len(e.Applied))
+		for i, a := range e.Applied {
+			actions[i] = a.ID
+		}
+		if err := w.Write([]string{
+			e.ID,
+			time.Unix(e.CreatedAt, 0).Format(time.RFC3339),
+			e.Kind,
+			csvText(e.Title),
+			e.Status,
+			e.ErrorCode,
+			strconv.Itoa(e.FailedIndex),
+			csvText(e.ProviderName),
+			csvText(e.Model),
+			e.InputLang,
+			e.OutputLang,
+			e.Format,
+			strconv.FormatInt(e.DurationMs, 10),
+			strconv.Itoa(e.Inferences),
+			strconv.FormatFloat(e.ChangeRatio, 'f', -1, 64),
+			strings.Join(actions, "; "),
+			csvText(strings.Join(e.Tags, "; ")),
+			strconv.FormatBool(e.Pinned),
+			strconv.FormatBool(e.Favorite),
+			e.RunID,
+			e.Branch,
+			e.RerunOf,
+			csvText(e.InputText),
+			csvText(e.OutputText),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// csvText keeps a spreadsheet from reading s as a formula by prefixing an
+// apostrophe when s starts with a character that begins one.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// encodeDigest writes entries as a Markdown document: a heading per entry with
+// a line of run details, its actions and tags, then its input and output
+// quoted.
+func encodeDigest(entries []apperr.HistoryEntry, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# History digest\n\nExported %s · %d entries\n", now.Format("2006-01-02 15:04"), len(entries))
+	for _, e := range entries {
+		title := strings.TrimSpace(e.Title)
+		if title == "" {
+			title = "Untitled"
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+
+		details := []string{time.Unix(e.CreatedAt, 0).Format("2006-01-02 15:04"), e.Kind, e.Status}
+		if e.ErrorCode != "" {
+			details = append(details, e.ErrorCode)
+		}
+		if model := strings.Trim(e.ProviderName+" / "+e.Model, " /"); model != "" {
+			details = append(details, model)
+		}
+		details = append(details,
+			fmt.Sprintf("%.1f s", float64(e.DurationMs)/1000),
+			fmt.Sprintf("%d inferences", e.Inferences))
+		if e.Pinned {
+			details = append(details, "pinned")
+		}
+		if e.Favorite {
+			details = append(details, "favorite")
+		}
+		fmt.Fprintf(&b, "*%s*\n", strings.Join(details, " · "))
+
+		if len(e.Applied) > 0 {
+			names := make([]string, len(e.Applied))
+			for i, a := range e.Applied {
+				names[i] = a.Name
+				if names[i] == "" {
+					names[i] = a.ID
+				}
+			}
+			fmt.Fprintf(&b, "\nActions: %s\n", strings.Join(names, " → "))
+		}
+		if len(e.Tags) > 0 {
+			fmt.Fprintf(&b, "\nTags: %s\n", strings.Join(e.Tags, ", "))
+		}
+		b.WriteString("\n**Input**\n\n")
+		b.WriteString(quote(e.InputText))
+		b.WriteString("\n**Output**\n\n")
+		b.WriteString(quote(e.OutputText))
+	}
+	return []byte(b.String())
+}
+
+// quote returns text as a Markdown block quote ending in a newline.
+func quote(text string) string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			b.WriteString(">\n")
+			continue
+		}
+		b.WriteString("> " + line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,376 @@
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/settings"
+)
+
+// archiveSvc returns a HistoryService over repo that keeps at most maxEntries
+// and writes exports into files.
+func archiveSvc(repo HistoryRepositoryAPI, maxEntries int, files *mockFiles) *HistoryService {
+	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{
+		HistoryEnabled:    true,
+		HistoryMaxEntries: maxEntries,
+	}}, files)
+	svc.SetRepository(repo)
+	return svc
+}
+
+// seedArchiveRepo returns a repository holding three entries: "full" with
+// steps, a snapshot, both flags and two tags; "failed" with status error and
+// the tag "draft"; and an untagged "plain".
+func seedArchiveRepo(t *testing.T) *SqliteHistoryRepository {
+	t.Helper()
+	repo := newHistoryRepo(t)
+
+	full := makeEntry("full", "stack", "Quarterly email", 1000)
+	full.Steps = []apperr.HistoryStep{
+		{GroupIndex: 0, Family: "rewrite", ActionIDs: []string{"act1"}, InputText: "a", OutputText: "b", SystemHash: "s", UserHash: "u", DurationMs: 10, PromptTokens: 5, CompletionTokens: 6},
+		{GroupIndex: 1, Family: "local", ActionIDs: []string{"local.trim"}, InputText: "b", OutputText: "c"},
+	}
+	full.Snapshot = &apperr.RunSnapshot{
+		Request:     apperr.ChainRequest{RunID: "r1", InputText: "a", Steps: []apperr.ChainStep{{ActionID: "act1"}}},
+		ProviderID:  "p1",
+		ModelConfig: apperr.ModelConfig{Name: "llama3"},
+		AppVersion:  "1.0.0",
+	}
+	full.Selection = &apperr.TextRange{Start: 2, End: 5}
+	failed := makeEntry("failed", "single", "=SUM(A1:A2)", 2000)
+	failed.Status = "error"
+	failed.ErrorCode = "timeout"
+	plain := makeEntry("plain", "single", "Plain", 3000)
+
+	for _, e := range []apperr.HistoryEntry{full, failed, plain} {
+		if err := repo.Add(e, 0); err != nil {
+			t.Fatalf("Add %s: %v", e.ID, err)
+		}
+	}
+	for _, err := range []error{
+		repo.SetPinned("full", true),
+		repo.SetFavorite("full", true),
+		repo.AddTag("full", "work"),
+		repo.AddTag("full", "q3"),
+		repo.AddTag("failed", "draft"),
+	} {
+		if err != nil {
+			t.Fatalf("seed flags: %v", err)
+		}
+	}
+	return repo
+}
+
+func TestSqliteHistoryRepository_Export(t *testing.T) {
+	repo := seedArchiveRepo(t)
+
+	tests := []struct {
+		name string
+		req  apperr.HistoryArchiveRequest
+		want []string
+	}{
+		{"all oldest first", apperr.HistoryArchiveRequest{}, []string{"full", "failed", "plain"}},
+		{"status", apperr.HistoryArchiveRequest{Status: "error"}, []string{"failed"}},
+		{"any tag", apperr.HistoryArchiveRequest{Tags: []string{"Q3", "draft"}}, []string{"full", "failed"}},
+		{"date range", apperr.HistoryArchiveRequest{From: 1500, To: 3000}, []string{"failed", "plain"}},
+		{"no match", apperr.HistoryArchiveRequest{Tags: []string{"none"}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := repo.Export(tt.req)
+			if err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			ids := make([]string, len(entries))
+			for i, e := range entries {
+				ids[i] = e.ID
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	entries, err := repo.Export(apperr.HistoryArchiveRequest{})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	full := entries[0]
+	if len(full.Steps) != 2 || full.Snapshot == nil || !full.Pinned || !full.Favorite ||
+		!reflect.DeepEqual(full.Tags, []string{"q3", "work"}) {
+		t.Errorf("full entry not exported whole: %+v", full)
+	}
+}
+
+func TestHistoryService_ExportImport_RoundTrip(t *testing.T) {
+	src := seedArchiveRepo(t)
+	files := &mockFiles{}
+	dir := t.TempDir()
+	archive, err := archiveSvc(src, 100, files).ExportHistory(apperr.HistoryArchiveRequest{Format: ArchiveJSONL, Directory: dir})
+	if err != nil {
+		t.Fatalf("ExportHistory: %v", err)
+	}
+	if archive.Entries != 3 || files.dir != dir || !strings.HasSuffix(files.name, ".jsonl") {
+		t.Errorf("archive = %+v, file %s/%s", archive, files.dir, files.name)
+	}
+	path := filepath.Join(dir, files.name)
+	if err := os.WriteFile(path, files.data, 0o600); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+
+	dst := newHistoryRepo(t)
+	summary, err := archiveSvc(dst, 100, &mockFiles{}).ImportHistory(path)
+	if err != nil {
+		t.Fatalf("ImportHistory: %v", err)
+	}
+	if want := (apperr.HistoryImportSummary{Read: 3, Imported: 3}); *summary != want {
+		t.Errorf("summary = %+v, want %+v", *summary, want)
+	}
+	for _, id := range []string{"full", "failed", "plain"} {
+		want, err := src.Get(id)
+		if err != nil {
+			t.Fatalf("Get source %s: %v", id, err)
+		}
+		got, err := dst.Get(id)
+		if err != nil {
+			t.Fatalf("Get imported %s: %v", id, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("imported %s =\n%+v\nwant\n%+v", id, got, want)
+		}
+	}
+
+	// Importing again adds nothing.
+	summary, err = archiveSvc(dst, 100, &mockFiles{}).ImportHistory(path)
+	if err != nil {
+		t.Fatalf("ImportHistory again: %v", err)
+	}
+	if want := (apperr.HistoryImportSummary{Read: 3, Duplicates: 3}); *summary != want {
+		t.Errorf("second summary = %+v, want %+v", *summary, want)
+	}
+}
+
+func TestSqliteHistoryRepository_Import_PrunesUnpinned(t *testing.T) {
+	repo := newHistoryRepo(t)
+	if err := repo.Add(makeEntry("existing", "single", "Existing", 5000), 0); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	pinned := makeEntry("pinned", "single", "Pinned", 100)
+	pinned.Pinned = true
+	entries := []apperr.HistoryEntry{
+		pinned,
+		makeEntry("old", "single", "Old", 200),
+		makeEntry("new", "single", "New", 300),
+		makeEntry("old", "single", "Repeated", 400),
+	}
+
+	summary, err := repo.Import(entries, 2)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if want := (apperr.HistoryImportSummary{Read: 4, Imported: 3, Duplicates: 1, Pruned: 1}); summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	for id, kept := range map[string]bool{"existing": true, "new": true, "pinned": true, "old": false} {
+		_, err := repo.Get(id)
+		if (err == nil) != kept {
+			t.Errorf("%s kept = %t, want %t", id, err == nil, kept)
+		}
+	}
+}
+
+func TestHistoryService_ImportHistory_PassesMaxEntries(t *testing.T) {
+	repo := &mockRepo{importSummary: apperr.HistoryImportSummary{Read: 1, Imported: 1}}
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	line := `{"id":"a","createdAt":1,"kind":"single","status":"success","tags":[" work "]}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := archiveSvc(repo, 250, &mockFiles{}).ImportHistory(path); err != nil {
+		t.Fatalf("ImportHistory: %v", err)
+	}
+	if repo.importMax != 250 {
+		t.Errorf("maxEntries = %d, want 250", repo.importMax)
+	}
+	if len(repo.imported) != 1 || !reflect.DeepEqual(repo.imported[0].Tags, []string{"work"}) {
+		t.Errorf("imported = %+v, want one entry tagged work", repo.imported)
+	}
+}
+
+func TestHistoryService_ImportHistory_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", "\n\n"},
+		{"not json", "hello\n"},
+		{"no id", `{"createdAt":1,"kind":"single","status":"success"}`},
+		{"no date", `{"id":"a","kind":"single","status":"success"}`},
+		{"bad kind", `{"id":"a","createdAt":1,"kind":"batch","status":"success"}`},
+		{"bad status", `{"id":"a","createdAt":1,"kind":"single","status":"done"}`},
+		{"bad tag", `{"id":"a","createdAt":1,"kind":"single","status":"success","tags":[""]}`},
+		{"second line bad", `{"id":"a","createdAt":1,"kind":"single","status":"success"}` + "\n{"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "_")+".jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			repo := &mockRepo{}
+			_, err := archiveSvc(repo, 100, &mockFiles{}).ImportHistory(path)
+			var ae *apperr.AppError
+			if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+				t.Errorf("err = %v, want validation error", err)
+			}
+			if repo.imported != nil {
+				t.Error("repository called for an invalid file")
+			}
+		})
+	}
+
+	for _, path := range []string{"", filepath.Join(dir, "missing.jsonl")} {
+		_, err := archiveSvc(&mockRepo{}, 100, &mockFiles{}).ImportHistory(path)
+		var ae *apperr.AppError
+		if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+			t.Errorf("ImportHistory(%q) err = %v, want validation error", path, err)
+		}
+	}
+}
+
+func TestHistoryService_ExportHistory_CSV(t *testing.T) {
+	files := &mockFiles{}
+	if _, err := archiveSvc(seedArchiveRepo(t), 100, files).ExportHistory(apperr.HistoryArchiveRequest{Format: ArchiveCSV}); err != nil {
+		t.Fatalf("ExportHistory: %v", err)
+	}
+	if !strings.HasSuffix(files.name, ".csv") {
+		t.Errorf("file name = %q, want .csv", files.name)
+	}
+	records, err := csv.NewReader(bytes.NewReader(files.data)).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	if len(records) != 4 || !reflect.DeepEqual(records[0], csvHeader) {
+		t.Fatalf("records = %q", records)
+	}
+	col := func(row []string, name string) string {
+		for i, h := range csvHeader {
+			if h == name {
+				return row[i]
+			}
+		}
+		t.Fatalf("no column %s", name)
+		return ""
+	}
+	full, failed := records[1], records[2]
+	if got := col(full, "tags"); got != "q3; work" {
+		t.Errorf("tags = %q", got)
+	}
+	if got := col(full, "pinned"); got != "true" {
+		t.Errorf("pinned = %q", got)
+	}
+	if got := col(full, "created_at"); got != time.Unix(1000, 0).Format(time.RFC3339) {
+		t.Errorf("created_at = %q", got)
+	}
+	if got := col(failed, "title"); got != "'=SUM(A1:A2)" {
+		t.Errorf("formula title = %q, want it neutralized", got)
+	}
+}
+
+func TestHistoryService_ExportHistory_Markdown(t *testing.T) {
+	files := &mockFiles{}
+	archive, err := archiveSvc(seedArchiveRepo(t), 100, files).ExportHistory(apperr.HistoryArchiveRequest{
+		Format: ArchiveMarkdown,
+		Tags:   []string{" work "},
+	})
+	if err != nil {
+		t.Fatalf("ExportHistory: %v", err)
+	}
+	if archive.Entries != 1 || !strings.HasSuffix(files.name, ".md") {
+		t.Errorf("archive = %+v, name %q", archive, files.name)
+	}
+	md := string(files.data)
+	for _, want := range []string{
+		"# History digest",
+		"1 entries",
+		"## Quarterly email",
+		"stack · success · Ollama / llama3 · 1.5 s · 1 inferences · pinned · favorite",
+		"Actions: Proofread",
+		"Tags: q3, work",
+		"**Input**\n\n> sample input\n",
+		"**Output**\n\n> sample output\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("digest missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestHistoryService_ExportHistory_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  apperr.HistoryArchiveRequest
+	}{
+		{"unknown format", apperr.HistoryArchiveRequest{Format: "xml"}},
+		{"unknown status", apperr.HistoryArchiveRequest{Format: ArchiveJSONL, Status: "done"}},
+		{"to before from", apperr.HistoryArchiveRequest{Format: ArchiveJSONL, From: 10, To: 5}},
+		{"blank tag", apperr.HistoryArchiveRequest{Format: ArchiveJSONL, Tags: []string{" "}}},
+		{"no entries", apperr.HistoryArchiveRequest{Format: ArchiveJSONL}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := &mockFiles{}
+			_, err := archiveSvc(&mockRepo{}, 100, files).ExportHistory(tt.req)
+			var ae *apperr.AppError
+			if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+				t.Errorf("err = %v, want validation error", err)
+			}
+			if files.data != nil {
+				t.Error("file written")
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got, want := quote("one\r\n\ntwo\n"), "> one\n>\n> two\n"; got != want {
+		t.Errorf("quote = %q, want %q", got, want)
+	}
+}
+
+func TestHistoryHandler_ExportImportHistory(t *testing.T) {
+	svc := &mockHistoryService{
+		archiveRet: &apperr.HistoryArchive{Path: "/tmp/history.jsonl", Entries: 2},
+		importRet:  &apperr.HistoryImportSummary{Read: 2, Imported: 2},
+	}
+	h := newTestHandler(svc)
+
+	res := h.ExportHistory(apperr.HistoryArchiveRequest{Format: ArchiveJSONL, Directory: "/tmp"})
+	if res.Error != nil || res.Data == nil || res.Data.Entries != 2 {
+		t.Errorf("ExportHistory = %+v", res)
+	}
+	if svc.archiveReq.Format != ArchiveJSONL || svc.archiveReq.Directory != "/tmp" {
+		t.Errorf("request not passed through: %+v", svc.archiveReq)
+	}
+	imp := h.ImportHistory("/tmp/history.jsonl")
+	if imp.Error != nil || imp.Data == nil || imp.Data.Imported != 2 || svc.importPath != "/tmp/history.jsonl" {
+		t.Errorf("ImportHistory = %+v (path %q)", imp, svc.importPath)
+	}
+
+	failing := newTestHandler(&mockHistoryService{archiveErr: apperr.Validation("format", "jsonl", "xml")})
+	if res := failing.ExportHistory(apperr.HistoryArchiveRequest{}); res.Error == nil || res.Error.Code != apperr.CodeValidation {
+		t.Errorf("ExportHistory error = %+v, want validation", res.Error)
+	}
+	if res := failing.ImportHistory(""); res.Error == nil {
+		t.Error("ImportHistory: expected error")
+	}
+}
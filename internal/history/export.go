@@ -0,0 +1,79 @@
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/diff"
+	"go_text/internal/export"
+)
+
+// Tracked-changes export formats accepted by ExportTrackedChanges.
+const (
+	ExportCriticMarkup = "criticmarkup"
+	ExportDocx         = "docx"
+)
+
+// defaultRevisionAuthor is the revision author of a .docx export when the entry
+// did not record a model name.
+const defaultRevisionAuthor = "Model"
+
+// ExportTrackedChanges writes the changes from entry id's input to its output
+// into dir, as Markdown with CriticMarkup or as a .docx file whose revisions are
+// authored by the model, and returns the path written.
+func (s *HistoryService) ExportTrackedChanges(id, format, dir string) (string, error) {
+	const op = "HistoryService.ExportTrackedChanges"
+	if format != ExportCriticMarkup && format != ExportDocx {
+		return "", apperr.Validation("format", fmt.Sprintf("%q or %q", ExportCriticMarkup, ExportDocx), format)
+	}
+	entry, err := s.Get(id)
+	if err != nil {
+		return "", err
+	}
+	if entry.OutputText == "" {
+		return "", apperr.Validation("id", "name an entry with output text", id)
+	}
+
+	var data []byte
+	ext := ".md"
+	switch format {
+	case ExportCriticMarkup:
+		d, err := diff.Compare(apperr.DiffRequest{Before: entry.InputText, After: entry.OutputText, Markdown: true})
+		if err != nil {
+			return "", err
+		}
+		data = []byte(diff.CriticMarkup(d))
+	case ExportDocx:
+		d, err := diff.Compare(apperr.DiffRequest{Before: entry.InputText, After: entry.OutputText})
+		if err != nil {
+			return "", err
+		}
+		author := entry.Model
+		if author == "" {
+			author = defaultRevisionAuthor
+		}
+		data, err = diff.TrackedChangesDocx(d, author, time.Unix(entry.CreatedAt, 0))
+		if err != nil {
+			return "", apperr.Internal(fmt.Errorf("%s: %w", op, err))
+		}
+		ext = ".docx"
+	}
+
+	path, err := s.files.WriteExportFile(dir, exportFileName(entry)+ext, data)
+	if err != nil {
+		return "", err
+	}
+	s.logger.Info(fmt.Sprintf("[%s] exported entry %s as %s to %s", op, id, format, path))
+	return path, nil
+}
+
+// exportFileName returns a file name, without extension, for an export of entry:
+// its title made safe for every file system, then its date.
+func exportFileName(entry *apperr.HistoryEntry) string {
+	title := export.CleanName(entry.Title)
+	if title == "" {
+		title = "history"
+	}
+	return title + " " + time.Unix(entry.CreatedAt, 0).Format("2006-01-02 150405")
+}
@@ -0,0 +1,125 @@
+package history
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/settings"
+)
+
+func exportSvc(entry *apperr.HistoryEntry, files *mockFiles) *HistoryService {
+	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}}, files)
+	svc.SetRepository(&mockRepo{getRet: entry})
+	return svc
+}
+
+func exportEntry() *apperr.HistoryEntry {
+	created := time.Date(2026, 3, 14, 9, 26, 53, 0, time.Local).Unix()
+	return &apperr.HistoryEntry{
+		ID:         "e1",
+		CreatedAt:  created,
+		Title:      "Proofread → Professional tone",
+		InputText:  "Teh report is done.",
+		OutputText: "The report is finished.",
+		Model:      "llama3.1:8b",
+	}
+}
+
+func TestHistoryService_ExportTrackedChanges_CriticMarkup(t *testing.T) {
+	files := &mockFiles{}
+	path, err := exportSvc(exportEntry(), files).ExportTrackedChanges("e1", ExportCriticMarkup, "/docs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/docs/Proofread Professional tone 2026-03-14 092653.md" {
+		t.Errorf("path = %q", path)
+	}
+	want := "{--Teh--}{++The++} report is {--done--}{++finished++}."
+	if string(files.data) != want {
+		t.Errorf("content = %q, want %q", files.data, want)
+	}
+}
+
+func TestHistoryService_ExportTrackedChanges_Docx(t *testing.T) {
+	files := &mockFiles{}
+	path, err := exportSvc(exportEntry(), files).ExportTrackedChanges("e1", ExportDocx, "/docs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(path, ".docx") {
+		t.Errorf("path = %q", path)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(files.data), int64(len(files.data)))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	var doc string
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			rc, _ := f.Open()
+			b, _ := io.ReadAll(rc)
+			_ = rc.Close()
+			doc = string(b)
+		}
+	}
+	if !strings.Contains(doc, `<w:ins w:id="2" w:author="llama3.1:8b"`) {
+		t.Errorf("revisions not authored as the model: %s", doc)
+	}
+}
+
+func TestHistoryService_ExportTrackedChanges_Errors(t *testing.T) {
+	noOutput := exportEntry()
+	noOutput.OutputText = ""
+	tests := []struct {
+		name     string
+		entry    *apperr.HistoryEntry
+		format   string
+		files    *mockFiles
+		wantCode apperr.ErrorCode
+	}{
+		{name: "unknown format", entry: exportEntry(), format: "pdf", files: &mockFiles{}, wantCode: apperr.CodeValidation},
+		{name: "no output", entry: noOutput, format: ExportDocx, files: &mockFiles{}, wantCode: apperr.CodeValidation},
+		{
+			name:     "write fails",
+			entry:    exportEntry(),
+			format:   ExportCriticMarkup,
+			files:    &mockFiles{err: apperr.Validation("directory", "point to an existing directory", "/nope")},
+			wantCode: apperr.CodeValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := exportSvc(tt.entry, tt.files).ExportTrackedChanges("e1", tt.format, "/docs")
+			var ae *apperr.AppError
+			if !errors.As(err, &ae) || ae.Code != tt.wantCode {
+				t.Fatalf("want %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestExportFileName(t *testing.T) {
+	entry := &apperr.HistoryEntry{CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local).Unix()}
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "Proofread", want: "Proofread 2026-01-02 030405"},
+		{title: `a/b\c: "d"?`, want: "a b c d 2026-01-02 030405"},
+		{title: "Переклад → English", want: "Переклад English 2026-01-02 030405"},
+		{title: "", want: "history 2026-01-02 030405"},
+		{title: strings.Repeat("x", 100), want: strings.Repeat("x", 60) + " 2026-01-02 030405"},
+	}
+	for _, tt := range tests {
+		entry.Title = tt.title
+		if got := exportFileName(entry); got != tt.want {
+			t.Errorf("exportFileName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
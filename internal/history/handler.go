@@ -57,6 +57,55 @@ func (h *HistoryHandler) ListHistory(limit, offset int) (res apperr.HistoryListR
 	return apperr.HistoryListResult{Data: data}
 }
 
+// ListRunHistory returns the entries recorded by one chain run, oldest first —
+// one per branch for a fan-out run.
+func (h *HistoryHandler) ListRunHistory(runID string) (res apperr.HistoryListResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.HistoryListResult{Error: &wire}
+		}
+	}()
+	if runID == "" {
+		ae := apperr.Validation("runId", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.HistoryListResult{Error: &wire}
+	}
+	data, err := h.service.ListByRun(runID)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.HistoryListResult{Error: &wire}
+	}
+	if data == nil {
+		data = []apperr.HistoryEntry{}
+	}
+	return apperr.HistoryListResult{Data: data}
+}
+
+// SearchHistory returns the page of entries matching req: a full-text query
+// over title, input and output text, best match first with a highlighted
+// snippet, narrowed by the status, kind, model, provider, action and date
+// filters; without a query, the filtered entries newest first.
+func (h *HistoryHandler) SearchHistory(req apperr.HistorySearchRequest) (res apperr.HistorySearchResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.HistorySearchResult{Error: &wire}
+		}
+	}()
+	data, err := h.service.Search(req)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.HistorySearchResult{Error: &wire}
+	}
+	if data == nil {
+		data = []apperr.HistorySearchHit{}
+	}
+	return apperr.HistorySearchResult{Data: data}
+}
+
 // GetHistoryEntry returns a single history entry by ID.
 func (h *HistoryHandler) GetHistoryEntry(id string) (res apperr.HistoryEntryResult) {
 	defer func() {
@@ -105,3 +154,172 @@ func (h *HistoryHandler) ClearHistory() (res apperr.VoidResult) {
 	}
 	return apperr.VoidResult{}
 }
+
+// SetHistoryPinned pins or unpins an entry; pinned entries are never pruned.
+func (h *HistoryHandler) SetHistoryPinned(id string, pinned bool) (res apperr.VoidResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.VoidResult{Error: &wire}
+		}
+	}()
+	if err := h.service.SetPinned(id, pinned); err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.VoidResult{Error: &wire}
+	}
+	return apperr.VoidResult{}
+}
+
+// SetHistoryFavorite marks or unmarks an entry as a favorite; favorites are
+// never pruned.
+func (h *HistoryHandler) SetHistoryFavorite(id string, favorite bool) (res apperr.VoidResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.VoidResult{Error: &wire}
+		}
+	}()
+	if err := h.service.SetFavorite(id, favorite); err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.VoidResult{Error: &wire}
+	}
+	return apperr.VoidResult{}
+}
+
+// AddHistoryTag tags an entry. Tags are free-form, trimmed, at most 50
+// characters, and compared without regard to case.
+func (h *HistoryHandler) AddHistoryTag(id, tag string) (res apperr.VoidResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.VoidResult{Error: &wire}
+		}
+	}()
+	if err := h.service.AddTag(id, tag); err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.VoidResult{Error: &wire}
+	}
+	return apperr.VoidResult{}
+}
+
+// RemoveHistoryTag removes a tag from an entry.
+func (h *HistoryHandler) RemoveHistoryTag(id, tag string) (res apperr.VoidResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.VoidResult{Error: &wire}
+		}
+	}()
+	if err := h.service.RemoveTag(id, tag); err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.VoidResult{Error: &wire}
+	}
+	return apperr.VoidResult{}
+}
+
+// ListHistoryTags returns every tag in use with the number of entries carrying
+// it, sorted by tag.
+func (h *HistoryHandler) ListHistoryTags() (res apperr.HistoryTagListResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.HistoryTagListResult{Error: &wire}
+		}
+	}()
+	data, err := h.service.ListTags()
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.HistoryTagListResult{Error: &wire}
+	}
+	if data == nil {
+		data = []apperr.HistoryTag{}
+	}
+	return apperr.HistoryTagListResult{Data: data}
+}
+
+// DeleteHistoryTag removes a tag from every entry carrying it.
+func (h *HistoryHandler) DeleteHistoryTag(tag string) (res apperr.VoidResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.VoidResult{Error: &wire}
+		}
+	}()
+	if err := h.service.DeleteTag(tag); err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.VoidResult{Error: &wire}
+	}
+	return apperr.VoidResult{}
+}
+
+// ExportHistory writes the history entries matching req's filters into
+// req.Directory as JSONL (a lossless backup ImportHistory reads), CSV or a
+// Markdown digest, and returns the file written with its entry count.
+func (h *HistoryHandler) ExportHistory(req apperr.HistoryArchiveRequest) (res apperr.HistoryArchiveResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.HistoryArchiveResult{Error: &wire}
+		}
+	}()
+	archive, err := h.service.ExportHistory(req)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.HistoryArchiveResult{Error: &wire}
+	}
+	return apperr.HistoryArchiveResult{Data: archive}
+}
+
+// ImportHistory adds the entries of a JSONL file written by ExportHistory,
+// skipping those already recorded, and reports what it imported and pruned.
+func (h *HistoryHandler) ImportHistory(path string) (res apperr.HistoryImportResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.HistoryImportResult{Error: &wire}
+		}
+	}()
+	summary, err := h.service.ImportHistory(path)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.HistoryImportResult{Error: &wire}
+	}
+	return apperr.HistoryImportResult{Data: summary}
+}
+
+// ExportHistoryEntry writes an entry's changes as tracked changes (CriticMarkup
+// Markdown or a .docx with revisions) into the chosen directory and returns the
+// path of the file written.
+func (h *HistoryHandler) ExportHistoryEntry(req apperr.HistoryExportRequest) (res apperr.StringResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.StringResult{Error: &wire}
+		}
+	}()
+	if req.ID == "" {
+		ae := apperr.Validation("id", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.StringResult{Error: &wire}
+	}
+	if req.Directory == "" {
+		ae := apperr.Validation("directory", "be non-empty", "empty string")
+		wire := apperr.ToWire(h.liveZlog(), ae)
+		return apperr.StringResult{Error: &wire}
+	}
+	path, err := h.service.ExportTrackedChanges(req.ID, req.Format, req.Directory)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.StringResult{Error: &wire}
+	}
+	return apperr.StringResult{Data: path}
+}
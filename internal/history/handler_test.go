@@ -2,6 +2,8 @@ package history
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"go_text/internal/apperr"
@@ -11,20 +13,81 @@ import (
 type mockHistoryService struct {
 	listRet []apperr.HistoryEntry
 	listErr error
+	runRet  []apperr.HistoryEntry
+	runID   string
 	getRet  *apperr.HistoryEntry
 	getErr  error
 	delErr  error
 	clrErr  error
+
+	exportRet  string
+	exportErr  error
+	exportArgs []string
+
+	searchRet []apperr.HistorySearchHit
+	searchErr error
+	searchReq apperr.HistorySearchRequest
+
+	tagCalls []string
+	tagsRet  []apperr.HistoryTag
+	tagErr   error
+
+	archiveReq apperr.HistoryArchiveRequest
+	archiveRet *apperr.HistoryArchive
+	importPath string
+	importRet  *apperr.HistoryImportSummary
+	archiveErr error
 }
 
 func (m *mockHistoryService) Record(_ apperr.HistoryEntry) {}
 func (m *mockHistoryService) List(l, o int64) ([]apperr.HistoryEntry, error) {
 	return m.listRet, m.listErr
 }
+func (m *mockHistoryService) ListByRun(runID string) ([]apperr.HistoryEntry, error) {
+	m.runID = runID
+	return m.runRet, nil
+}
 func (m *mockHistoryService) Get(id string) (*apperr.HistoryEntry, error) { return m.getRet, m.getErr }
 func (m *mockHistoryService) Delete(id string) error                      { return m.delErr }
 func (m *mockHistoryService) Clear() error                                { return m.clrErr }
 func (m *mockHistoryService) Count() (int64, error)                       { return 0, nil }
+func (m *mockHistoryService) Search(req apperr.HistorySearchRequest) ([]apperr.HistorySearchHit, error) {
+	m.searchReq = req
+	return m.searchRet, m.searchErr
+}
+func (m *mockHistoryService) SetPinned(id string, pinned bool) error {
+	m.tagCalls = append(m.tagCalls, fmt.Sprintf("pin %s %t", id, pinned))
+	return m.tagErr
+}
+func (m *mockHistoryService) SetFavorite(id string, favorite bool) error {
+	m.tagCalls = append(m.tagCalls, fmt.Sprintf("favorite %s %t", id, favorite))
+	return m.tagErr
+}
+func (m *mockHistoryService) AddTag(id, tag string) error {
+	m.tagCalls = append(m.tagCalls, "add "+id+" "+tag)
+	return m.tagErr
+}
+func (m *mockHistoryService) RemoveTag(id, tag string) error {
+	m.tagCalls = append(m.tagCalls, "remove "+id+" "+tag)
+	return m.tagErr
+}
+func (m *mockHistoryService) ListTags() ([]apperr.HistoryTag, error) { return m.tagsRet, m.tagErr }
+func (m *mockHistoryService) DeleteTag(tag string) error {
+	m.tagCalls = append(m.tagCalls, "delete "+tag)
+	return m.tagErr
+}
+func (m *mockHistoryService) ExportHistory(req apperr.HistoryArchiveRequest) (*apperr.HistoryArchive, error) {
+	m.archiveReq = req
+	return m.archiveRet, m.archiveErr
+}
+func (m *mockHistoryService) ImportHistory(path string) (*apperr.HistoryImportSummary, error) {
+	m.importPath = path
+	return m.importRet, m.archiveErr
+}
+func (m *mockHistoryService) ExportTrackedChanges(id, format, dir string) (string, error) {
+	m.exportArgs = []string{id, format, dir}
+	return m.exportRet, m.exportErr
+}
 
 func newTestHandler(svc HistoryServiceAPI) *HistoryHandler {
 	return NewHistoryHandler(nil, svc)
@@ -50,6 +113,35 @@ func TestHistoryHandler_ListHistory_Error(t *testing.T) {
 	}
 }
 
+func TestHistoryHandler_ListRunHistory_Success(t *testing.T) {
+	entries := []apperr.HistoryEntry{
+		{ID: "e1", RunID: "run-1", Branch: "summary"},
+		{ID: "e2", RunID: "run-1", Branch: "bullets"},
+	}
+	svc := &mockHistoryService{runRet: entries}
+	res := newTestHandler(svc).ListRunHistory("run-1")
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %+v", res.Error)
+	}
+	if svc.runID != "run-1" {
+		t.Errorf("service got runID %q, want run-1", svc.runID)
+	}
+	if len(res.Data) != 2 {
+		t.Errorf("unexpected data: %+v", res.Data)
+	}
+}
+
+func TestHistoryHandler_ListRunHistory_EmptyID(t *testing.T) {
+	svc := &mockHistoryService{}
+	res := newTestHandler(svc).ListRunHistory("")
+	if res.Error == nil || res.Error.Code != apperr.CodeValidation {
+		t.Fatalf("expected validation error, got %+v", res.Error)
+	}
+	if svc.runID != "" {
+		t.Errorf("service should not be called, got runID %q", svc.runID)
+	}
+}
+
 func TestHistoryHandler_GetHistoryEntry_Success(t *testing.T) {
 	entry := &apperr.HistoryEntry{ID: "e2", Status: "error", Kind: "stack"}
 	h := newTestHandler(&mockHistoryService{getRet: entry})
@@ -109,3 +201,41 @@ func TestHistoryHandler_PanicRecovery_ListHistory(t *testing.T) {
 		t.Fatal("expected internal error after nil-service panic")
 	}
 }
+
+func TestHistoryHandler_ExportHistoryEntry_Success(t *testing.T) {
+	svc := &mockHistoryService{exportRet: "/docs/Proofread.docx"}
+	res := newTestHandler(svc).ExportHistoryEntry(apperr.HistoryExportRequest{ID: "e1", Format: ExportDocx, Directory: "/docs"})
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %+v", res.Error)
+	}
+	if res.Data != "/docs/Proofread.docx" {
+		t.Errorf("Data = %q", res.Data)
+	}
+	if got := strings.Join(svc.exportArgs, ","); got != "e1,docx,/docs" {
+		t.Errorf("service got %q", got)
+	}
+}
+
+func TestHistoryHandler_ExportHistoryEntry_Validation(t *testing.T) {
+	for _, req := range []apperr.HistoryExportRequest{
+		{Format: ExportDocx, Directory: "/docs"},
+		{ID: "e1", Format: ExportDocx},
+	} {
+		svc := &mockHistoryService{}
+		res := newTestHandler(svc).ExportHistoryEntry(req)
+		if res.Error == nil || res.Error.Code != apperr.CodeValidation {
+			t.Fatalf("%+v: expected validation error, got %+v", req, res.Error)
+		}
+		if svc.exportArgs != nil {
+			t.Errorf("%+v: service should not be called", req)
+		}
+	}
+}
+
+func TestHistoryHandler_ExportHistoryEntry_Error(t *testing.T) {
+	h := newTestHandler(&mockHistoryService{exportErr: errors.New("disk full")})
+	res := h.ExportHistoryEntry(apperr.HistoryExportRequest{ID: "e1", Format: ExportCriticMarkup, Directory: "/docs"})
+	if res.Error == nil {
+		t.Fatal("expected error in result")
+	}
+}
@@ -5,12 +5,33 @@ import "go_text/internal/apperr"
 // HistoryRepositoryAPI is the contract for the SQLite history repository.
 // All methods use context.Background() internally — Wails bound callers supply no ctx.
 type HistoryRepositoryAPI interface {
-	// Add inserts entry then prunes to maxEntries newest rows in one transaction.
+	// Add inserts entry then prunes to the maxEntries newest rows that are
+	// neither pinned nor favorite, in one transaction.
 	// entry.ID and entry.CreatedAt are used as-is when non-zero; generated otherwise.
+	// An entry whose ID is already recorded replaces it, keeping its CreatedAt.
 	Add(entry apperr.HistoryEntry, maxEntries int64) error
 	List(limit, offset int64) ([]apperr.HistoryEntry, error)
+	ListByRun(runID string) ([]apperr.HistoryEntry, error)
 	Get(id string) (*apperr.HistoryEntry, error)
 	Delete(id string) error
 	Clear() error
 	Count() (int64, error)
+	// Search returns the entries matching req, already validated, one page at a
+	// time; a query the full-text index cannot parse is a validation error.
+	Search(req apperr.HistorySearchRequest) ([]apperr.HistorySearchHit, error)
+	// SetPinned and SetFavorite fail for an unknown id; tags compare without
+	// regard to ASCII case.
+	SetPinned(id string, pinned bool) error
+	SetFavorite(id string, favorite bool) error
+	AddTag(id, tag string) error
+	RemoveTag(id, tag string) error
+	ListTags() ([]apperr.HistoryTag, error)
+	DeleteTag(tag string) error
+	// Export returns the entries matching req's filters, already validated,
+	// oldest first, each with its steps, snapshot and tags.
+	Export(req apperr.HistoryArchiveRequest) ([]apperr.HistoryEntry, error)
+	// Import adds entries with their steps, flags and tags, skipping IDs
+	// already recorded, then prunes to maxEntries as Add does, in one
+	// transaction.
+	Import(entries []apperr.HistoryEntry, maxEntries int64) (apperr.HistoryImportSummary, error)
 }
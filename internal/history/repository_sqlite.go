@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -55,6 +56,10 @@ func rowToHistoryEntry(row store.History) (apperr.HistoryEntry, error) {
 	if err != nil {
 		return apperr.HistoryEntry{}, err
 	}
+	var selection *apperr.TextRange
+	if row.SelectionStart >= 0 {
+		selection = &apperr.TextRange{Start: int(row.SelectionStart), End: int(row.SelectionEnd)}
+	}
 	return apperr.HistoryEntry{
 		ID:           row.ID,
 		CreatedAt:    row.CreatedAt,
@@ -73,19 +78,100 @@ func rowToHistoryEntry(row store.History) (apperr.HistoryEntry, error) {
 		Status:       row.Status,
 		ErrorCode:    row.ErrorCode,
 		FailedIndex:  int(row.FailedIndex),
+		RunID:        row.RunID,
+		Branch:       row.Branch,
+		ChangeRatio:  row.ChangeRatio,
+		Selection:    selection,
+		RerunOf:      row.RerunOf,
+		Pinned:       row.Pinned != 0,
+		Favorite:     row.Favorite != 0,
 	}, nil
 }
 
-// Add inserts entry then prunes history to maxEntries newest rows in one transaction.
-func (r *SqliteHistoryRepository) Add(entry apperr.HistoryEntry, maxEntries int64) error {
-	const op = "SqliteHistoryRepository.Add"
-	ctx := r.bg()
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
 
-	applied, err := marshalApplied(entry.Applied)
+// attachTags fills in the Tags of entries with one query.
+func (r *SqliteHistoryRepository) attachTags(ctx context.Context, entries []apperr.HistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ids := make([]string, len(entries))
+	index := make(map[string]int, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+		index[e.ID] = i
+	}
+	b, err := json.Marshal(ids)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("marshal entry IDs: %w", err)
+	}
+	rows, err := r.database.Queries.ListTagsForHistory(ctx, string(b))
+	if err != nil {
+		return fmt.Errorf("list tags: %w", err)
+	}
+	for _, row := range rows {
+		if i, ok := index[row.HistoryID]; ok {
+			entries[i].Tags = append(entries[i].Tags, row.Tag)
+		}
 	}
+	return nil
+}
+
+func addHistoryStep(ctx context.Context, q *store.Queries, historyID string, step apperr.HistoryStep) error {
+	actionIDs := step.ActionIDs
+	if actionIDs == nil {
+		actionIDs = []string{}
+	}
+	ids, err := json.Marshal(actionIDs)
+	if err != nil {
+		return fmt.Errorf("marshal action IDs: %w", err)
+	}
+	return q.AddHistoryStep(ctx, store.AddHistoryStepParams{
+		HistoryID:        historyID,
+		GroupIndex:       int64(step.GroupIndex),
+		Family:           step.Family,
+		ActionIds:        string(ids),
+		InputText:        step.InputText,
+		OutputText:       step.OutputText,
+		SystemHash:       step.SystemHash,
+		UserHash:         step.UserHash,
+		DurationMs:       step.DurationMs,
+		PromptTokens:     int64(step.PromptTokens),
+		CompletionTokens: int64(step.CompletionTokens),
+	})
+}
 
+func rowToHistoryStep(row store.HistoryStep) (apperr.HistoryStep, error) {
+	var ids []string
+	if err := json.Unmarshal([]byte(row.ActionIds), &ids); err != nil {
+		return apperr.HistoryStep{}, fmt.Errorf("unmarshal action IDs of step %d: %w", row.GroupIndex, err)
+	}
+	return apperr.HistoryStep{
+		GroupIndex:       int(row.GroupIndex),
+		Family:           row.Family,
+		ActionIDs:        ids,
+		InputText:        row.InputText,
+		OutputText:       row.OutputText,
+		SystemHash:       row.SystemHash,
+		UserHash:         row.UserHash,
+		DurationMs:       row.DurationMs,
+		PromptTokens:     int(row.PromptTokens),
+		CompletionTokens: int(row.CompletionTokens),
+	}, nil
+}
+
+// historyParams returns the row of entry, with a new ID and the current time
+// when entry has none.
+func historyParams(entry apperr.HistoryEntry) (store.AddHistoryParams, error) {
+	applied, err := marshalApplied(entry.Applied)
+	if err != nil {
+		return store.AddHistoryParams{}, err
+	}
 	id := entry.ID
 	if id == "" {
 		id = uuid.NewString()
@@ -94,6 +180,60 @@ func (r *SqliteHistoryRepository) Add(entry apperr.HistoryEntry, maxEntries int6
 	if createdAt == 0 {
 		createdAt = time.Now().Unix()
 	}
+	selectionStart, selectionEnd := int64(-1), int64(-1)
+	if entry.Selection != nil {
+		selectionStart, selectionEnd = int64(entry.Selection.Start), int64(entry.Selection.End)
+	}
+	snapshot := ""
+	if entry.Snapshot != nil {
+		b, err := json.Marshal(entry.Snapshot)
+		if err != nil {
+			return store.AddHistoryParams{}, fmt.Errorf("marshal snapshot: %w", err)
+		}
+		snapshot = string(b)
+	}
+	return store.AddHistoryParams{
+		ID:             id,
+		CreatedAt:      createdAt,
+		Kind:           entry.Kind,
+		Title:          entry.Title,
+		InputText:      entry.InputText,
+		OutputText:     entry.OutputText,
+		Applied:        applied,
+		ProviderName:   entry.ProviderName,
+		Model:          entry.Model,
+		InputLang:      entry.InputLang,
+		OutputLang:     entry.OutputLang,
+		Format:         entry.Format,
+		DurationMs:     entry.DurationMs,
+		Inferences:     int64(entry.Inferences),
+		Status:         entry.Status,
+		ErrorCode:      entry.ErrorCode,
+		FailedIndex:    int64(entry.FailedIndex),
+		RunID:          entry.RunID,
+		Branch:         entry.Branch,
+		ChangeRatio:    entry.ChangeRatio,
+		SelectionStart: selectionStart,
+		SelectionEnd:   selectionEnd,
+		Snapshot:       snapshot,
+		RerunOf:        entry.RerunOf,
+	}, nil
+}
+
+// Add inserts entry and its steps then prunes history to the maxEntries newest
+// rows that are neither pinned nor favorite, in one transaction. An entry with the ID of a recorded one, such as a resumed
+// run, replaces it in place; its steps replace those at the same group index
+// and keep the others, so a resumed run's entry lists the groups of every pass.
+// A replacing entry without RerunOf keeps the recorded one, since a resumed
+// re-run's request comes back from its checkpoint without it.
+func (r *SqliteHistoryRepository) Add(entry apperr.HistoryEntry, maxEntries int64) error {
+	const op = "SqliteHistoryRepository.Add"
+	ctx := r.bg()
+
+	params, err := historyParams(entry)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
 	tx, err := r.database.DB.BeginTx(ctx, nil)
 	if err != nil {
@@ -102,27 +242,14 @@ func (r *SqliteHistoryRepository) Add(entry apperr.HistoryEntry, maxEntries int6
 	defer func() { _ = tx.Rollback() }()
 
 	q := r.database.Queries.WithTx(tx)
-	if err := q.AddHistory(ctx, store.AddHistoryParams{
-		ID:           id,
-		CreatedAt:    createdAt,
-		Kind:         entry.Kind,
-		Title:        entry.Title,
-		InputText:    entry.InputText,
-		OutputText:   entry.OutputText,
-		Applied:      applied,
-		ProviderName: entry.ProviderName,
-		Model:        entry.Model,
-		InputLang:    entry.InputLang,
-		OutputLang:   entry.OutputLang,
-		Format:       entry.Format,
-		DurationMs:   entry.DurationMs,
-		Inferences:   int64(entry.Inferences),
-		Status:       entry.Status,
-		ErrorCode:    entry.ErrorCode,
-		FailedIndex:  int64(entry.FailedIndex),
-	}); err != nil {
+	if err := q.AddHistory(ctx, params); err != nil {
 		return fmt.Errorf("%s: insert: %w", op, err)
 	}
+	for _, step := range entry.Steps {
+		if err := addHistoryStep(ctx, q, params.ID, step); err != nil {
+			return fmt.Errorf("%s: insert step %d: %w", op, step.GroupIndex, err)
+		}
+	}
 
 	if maxEntries > 0 {
 		if err := q.PruneHistory(ctx, maxEntries); err != nil {
@@ -154,10 +281,35 @@ func (r *SqliteHistoryRepository) List(limit, offset int64) ([]apperr.HistoryEnt
 		}
 		entries = append(entries, e)
 	}
+	if err := r.attachTags(r.bg(), entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
+
+// ListByRun returns every entry recorded by the chain run runID, oldest first —
+// one per branch for a fan-out run.
+func (r *SqliteHistoryRepository) ListByRun(runID string) ([]apperr.HistoryEntry, error) {
+	const op = "SqliteHistoryRepository.ListByRun"
+	rows, err := r.database.Queries.ListHistoryByRun(r.bg(), runID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	entries := make([]apperr.HistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		e, err := rowToHistoryEntry(row)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := r.attachTags(r.bg(), entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 	return entries, nil
 }
 
-// Get returns the history entry with the given id.
+// Get returns the history entry with the given id, with its steps and snapshot.
 func (r *SqliteHistoryRepository) Get(id string) (*apperr.HistoryEntry, error) {
 	const op = "SqliteHistoryRepository.Get"
 	row, err := r.database.Queries.GetHistory(r.bg(), id)
@@ -167,11 +319,41 @@ func (r *SqliteHistoryRepository) Get(id string) (*apperr.HistoryEntry, error) {
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	e, err := rowToHistoryEntry(row)
+	e, err := r.fullEntry(r.bg(), row)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	return &e, nil
+	one := []apperr.HistoryEntry{e}
+	if err := r.attachTags(r.bg(), one); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &one[0], nil
+}
+
+// fullEntry returns the entry of row with its snapshot and steps.
+func (r *SqliteHistoryRepository) fullEntry(ctx context.Context, row store.History) (apperr.HistoryEntry, error) {
+	e, err := rowToHistoryEntry(row)
+	if err != nil {
+		return apperr.HistoryEntry{}, err
+	}
+	if row.Snapshot != "" {
+		e.Snapshot = &apperr.RunSnapshot{}
+		if err := json.Unmarshal([]byte(row.Snapshot), e.Snapshot); err != nil {
+			return apperr.HistoryEntry{}, fmt.Errorf("unmarshal snapshot of %s: %w", row.ID, err)
+		}
+	}
+	stepRows, err := r.database.Queries.ListHistorySteps(ctx, row.ID)
+	if err != nil {
+		return apperr.HistoryEntry{}, fmt.Errorf("list steps of %s: %w", row.ID, err)
+	}
+	for _, sr := range stepRows {
+		step, err := rowToHistoryStep(sr)
+		if err != nil {
+			return apperr.HistoryEntry{}, err
+		}
+		e.Steps = append(e.Steps, step)
+	}
+	return e, nil
 }
 
 // Delete removes the history entry with the given id.
@@ -192,6 +374,30 @@ func (r *SqliteHistoryRepository) Clear() error {
 	return nil
 }
 
+// PruneOlderThan removes the entries recorded before cutoff (Unix seconds),
+// sparing pinned and favorite entries, and returns how many were removed.
+func (r *SqliteHistoryRepository) PruneOlderThan(cutoff int64) (int64, error) {
+	const op = "SqliteHistoryRepository.PruneOlderThan"
+	n, err := r.database.Queries.PruneHistoryOlderThan(r.bg(), cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
+// PruneToSize removes the oldest entries until the text of the rest — input,
+// output, actions, snapshot and step texts — fits in maxBytes, and returns how
+// many were removed. Pinned and favorite entries are neither counted nor
+// removed.
+func (r *SqliteHistoryRepository) PruneToSize(maxBytes int64) (int64, error) {
+	const op = "SqliteHistoryRepository.PruneToSize"
+	n, err := r.database.Queries.PruneHistoryToSize(r.bg(), maxBytes)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return n, nil
+}
+
 // Count returns the total number of history entries.
 func (r *SqliteHistoryRepository) Count() (int64, error) {
 	const op = "SqliteHistoryRepository.Count"
@@ -201,3 +407,363 @@ func (r *SqliteHistoryRepository) Count() (int64, error) {
 	}
 	return n, nil
 }
+
+// Snippet markers around matched terms; control characters that never occur
+// in recorded text, so parseSnippet can turn them into byte ranges.
+const (
+	snippetOpen  = '\x02'
+	snippetClose = '\x03'
+)
+
+// parseSnippet strips the match markers from snippet and returns the text with
+// the byte ranges they enclosed.
+func parseSnippet(snippet string) (string, []apperr.TextRange) {
+	var b strings.Builder
+	var highlights []apperr.TextRange
+	start := -1
+	for i := 0; i < len(snippet); i++ {
+		switch snippet[i] {
+		case snippetOpen:
+			start = b.Len()
+		case snippetClose:
+			if start >= 0 && b.Len() > start {
+				highlights = append(highlights, apperr.TextRange{Start: start, End: b.Len()})
+			}
+			start = -1
+		default:
+			b.WriteByte(snippet[i])
+		}
+	}
+	return b.String(), highlights
+}
+
+// isQuerySyntaxError reports whether err is SQLite rejecting an FTS5 query.
+func isQuerySyntaxError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "fts5:") ||
+		strings.Contains(msg, "no such column") ||
+		strings.Contains(msg, "unterminated string")
+}
+
+// Search returns the entries matching req through the history_fts index, best
+// match first, or through the filters alone, newest first, when req.Query is
+// empty.
+func (r *SqliteHistoryRepository) Search(req apperr.HistorySearchRequest) ([]apperr.HistorySearchHit, error) {
+	const op = "SqliteHistoryRepository.Search"
+	ctx := r.bg()
+	if strings.TrimSpace(req.Query) == "" {
+		rows, err := r.database.Queries.FilterHistory(ctx, store.FilterHistoryParams{
+			Status:       req.Status,
+			Kind:         req.Kind,
+			Model:        req.Model,
+			ProviderName: req.Provider,
+			ActionID:     req.ActionID,
+			CreatedFrom:  req.From,
+			CreatedTo:    req.To,
+			Tag:          strings.TrimSpace(req.Tag),
+			PinnedOnly:   boolToInt(req.Pinned),
+			FavoriteOnly: boolToInt(req.Favorite),
+			Limit:        int64(req.Limit),
+			Offset:       int64(req.Offset),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		hits := make([]apperr.HistorySearchHit, 0, len(rows))
+		for _, row := range rows {
+			e, err := rowToHistoryEntry(row)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			hits = append(hits, apperr.HistorySearchHit{Entry: e})
+		}
+		if err := r.attachHitTags(ctx, hits); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return hits, nil
+	}
+
+	rows, err := r.database.Queries.SearchHistory(ctx, store.SearchHistoryParams{
+		Query:        req.Query,
+		Status:       req.Status,
+		Kind:         req.Kind,
+		Model:        req.Model,
+		ProviderName: req.Provider,
+		ActionID:     req.ActionID,
+		CreatedFrom:  req.From,
+		CreatedTo:    req.To,
+		Tag:          strings.TrimSpace(req.Tag),
+		PinnedOnly:   boolToInt(req.Pinned),
+		FavoriteOnly: boolToInt(req.Favorite),
+		Limit:        int64(req.Limit),
+		Offset:       int64(req.Offset),
+	})
+	if err != nil {
+		if isQuerySyntaxError(err) {
+			return nil, apperr.Validation("query", "valid search syntax", req.Query)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	hits := make([]apperr.HistorySearchHit, 0, len(rows))
+	for _, row := range rows {
+		e, err := rowToHistoryEntry(store.History{
+			ID:             row.ID,
+			CreatedAt:      row.CreatedAt,
+			Kind:           row.Kind,
+			Title:          row.Title,
+			InputText:      row.InputText,
+			OutputText:     row.OutputText,
+			Applied:        row.Applied,
+			ProviderName:   row.ProviderName,
+			Model:          row.Model,
+			InputLang:      row.InputLang,
+			OutputLang:     row.OutputLang,
+			Format:         row.Format,
+			DurationMs:     row.DurationMs,
+			Inferences:     row.Inferences,
+			Status:         row.Status,
+			ErrorCode:      row.ErrorCode,
+			FailedIndex:    row.FailedIndex,
+			RunID:          row.RunID,
+			Branch:         row.Branch,
+			ChangeRatio:    row.ChangeRatio,
+			SelectionStart: row.SelectionStart,
+			SelectionEnd:   row.SelectionEnd,
+			Snapshot:       row.Snapshot,
+			RerunOf:        row.RerunOf,
+			Pinned:         row.Pinned,
+			Favorite:       row.Favorite,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		snippet, highlights := parseSnippet(row.Snippet)
+		hits = append(hits, apperr.HistorySearchHit{Entry: e, Snippet: snippet, Highlights: highlights})
+	}
+	if err := r.attachHitTags(ctx, hits); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return hits, nil
+}
+
+// attachHitTags fills in the Tags of the entries of hits.
+func (r *SqliteHistoryRepository) attachHitTags(ctx context.Context, hits []apperr.HistorySearchHit) error {
+	entries := make([]apperr.HistoryEntry, len(hits))
+	for i, h := range hits {
+		entries[i] = h.Entry
+	}
+	if err := r.attachTags(ctx, entries); err != nil {
+		return err
+	}
+	for i := range hits {
+		hits[i].Entry.Tags = entries[i].Tags
+	}
+	return nil
+}
+
+// SetPinned sets or clears the pinned flag of entry id.
+func (r *SqliteHistoryRepository) SetPinned(id string, pinned bool) error {
+	const op = "SqliteHistoryRepository.SetPinned"
+	n, err := r.database.Queries.SetHistoryPinned(r.bg(), store.SetHistoryPinnedParams{
+		Pinned: boolToInt(pinned),
+		ID:     id,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: entry %q not found", op, id)
+	}
+	return nil
+}
+
+// SetFavorite sets or clears the favorite flag of entry id.
+func (r *SqliteHistoryRepository) SetFavorite(id string, favorite bool) error {
+	const op = "SqliteHistoryRepository.SetFavorite"
+	n, err := r.database.Queries.SetHistoryFavorite(r.bg(), store.SetHistoryFavoriteParams{
+		Favorite: boolToInt(favorite),
+		ID:       id,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: entry %q not found", op, id)
+	}
+	return nil
+}
+
+// AddTag tags entry id with tag; tagging an entry twice with the same tag, in
+// any case, keeps one.
+func (r *SqliteHistoryRepository) AddTag(id, tag string) error {
+	const op = "SqliteHistoryRepository.AddTag"
+	ctx := r.bg()
+	if _, err := r.database.Queries.GetHistory(ctx, id); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%s: entry %q not found", op, id)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := r.database.Queries.AddHistoryTag(ctx, store.AddHistoryTagParams{HistoryID: id, Tag: tag}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RemoveTag removes tag from entry id; removing a tag it lacks is a no-op.
+func (r *SqliteHistoryRepository) RemoveTag(id, tag string) error {
+	const op = "SqliteHistoryRepository.RemoveTag"
+	if err := r.database.Queries.RemoveHistoryTag(r.bg(), store.RemoveHistoryTagParams{HistoryID: id, Tag: tag}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListTags returns every tag in use with its entry count, sorted by tag.
+func (r *SqliteHistoryRepository) ListTags() ([]apperr.HistoryTag, error) {
+	const op = "SqliteHistoryRepository.ListTags"
+	rows, err := r.database.Queries.ListHistoryTags(r.bg())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	tags := make([]apperr.HistoryTag, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, apperr.HistoryTag{Tag: row.Tag, Entries: int(row.Entries)})
+	}
+	return tags, nil
+}
+
+// DeleteTag removes tag from every entry carrying it.
+func (r *SqliteHistoryRepository) DeleteTag(tag string) error {
+	const op = "SqliteHistoryRepository.DeleteTag"
+	if err := r.database.Queries.DeleteHistoryTag(r.bg(), tag); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Export returns the entries matching req's status, tag and date filters,
+// oldest first, with their snapshots, steps and tags.
+func (r *SqliteHistoryRepository) Export(req apperr.HistoryArchiveRequest) ([]apperr.HistoryEntry, error) {
+	const op = "SqliteHistoryRepository.Export"
+	ctx := r.bg()
+	tags := req.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	b, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("%s: marshal tags: %w", op, err)
+	}
+	rows, err := r.database.Queries.ExportHistory(ctx, store.ExportHistoryParams{
+		Status:      req.Status,
+		CreatedFrom: req.From,
+		CreatedTo:   req.To,
+		Tags:        string(b),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	entries := make([]apperr.HistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		e, err := r.fullEntry(ctx, row)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := r.attachTags(ctx, entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return entries, nil
+}
+
+// Import adds entries as they are, keeping their IDs, dates, steps, flags and
+// tags, skips those whose ID is already recorded or came earlier in entries,
+// then prunes to the maxEntries newest rows that are neither pinned nor
+// favorite, all in one transaction.
+func (r *SqliteHistoryRepository) Import(entries []apperr.HistoryEntry, maxEntries int64) (apperr.HistoryImportSummary, error) {
+	const op = "SqliteHistoryRepository.Import"
+	ctx := r.bg()
+	summary := apperr.HistoryImportSummary{Read: len(entries)}
+
+	tx, err := r.database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return summary, fmt.Errorf("%s: begin tx: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	q := r.database.Queries.WithTx(tx)
+	before, err := q.CountHistory(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("%s: count: %w", op, err)
+	}
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if seen[entry.ID] {
+			summary.Duplicates++
+			continue
+		}
+		seen[entry.ID] = true
+		n, err := q.CountHistoryByID(ctx, entry.ID)
+		if err != nil {
+			return summary, fmt.Errorf("%s: look up %s: %w", op, entry.ID, err)
+		}
+		if n > 0 {
+			summary.Duplicates++
+			continue
+		}
+		if err := importEntry(ctx, q, entry); err != nil {
+			return summary, fmt.Errorf("%s: import %s: %w", op, entry.ID, err)
+		}
+		summary.Imported++
+	}
+
+	if maxEntries > 0 {
+		if err := q.PruneHistory(ctx, maxEntries); err != nil {
+			return summary, fmt.Errorf("%s: prune: %w", op, err)
+		}
+	}
+	after, err := q.CountHistory(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("%s: count: %w", op, err)
+	}
+	summary.Pruned = int(before) + summary.Imported - int(after)
+
+	if err := tx.Commit(); err != nil {
+		return summary, fmt.Errorf("%s: commit: %w", op, err)
+	}
+	return summary, nil
+}
+
+// importEntry inserts entry with its steps, flags and tags.
+func importEntry(ctx context.Context, q *store.Queries, entry apperr.HistoryEntry) error {
+	params, err := historyParams(entry)
+	if err != nil {
+		return err
+	}
+	if err := q.AddHistory(ctx, params); err != nil {
+		return fmt.Errorf("insert: %w", err)
+	}
+	for _, step := range entry.Steps {
+		if err := addHistoryStep(ctx, q, entry.ID, step); err != nil {
+			return fmt.Errorf("insert step %d: %w", step.GroupIndex, err)
+		}
+	}
+	if entry.Pinned {
+		if _, err := q.SetHistoryPinned(ctx, store.SetHistoryPinnedParams{Pinned: 1, ID: entry.ID}); err != nil {
+			return fmt.Errorf("pin: %w", err)
+		}
+	}
+	if entry.Favorite {
+		if _, err := q.SetHistoryFavorite(ctx, store.SetHistoryFavoriteParams{Favorite: 1, ID: entry.ID}); err != nil {
+			return fmt.Errorf("mark favorite: %w", err)
+		}
+	}
+	for _, tag := range entry.Tags {
+		if err := q.AddHistoryTag(ctx, store.AddHistoryTagParams{HistoryID: entry.ID, Tag: tag}); err != nil {
+			return fmt.Errorf("tag %q: %w", tag, err)
+		}
+	}
+	return nil
+}
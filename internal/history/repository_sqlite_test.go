@@ -3,6 +3,8 @@ package history
 import (
 	"fmt"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -44,6 +46,7 @@ func makeEntry(id, kind, title string, createdAt int64) apperr.HistoryEntry {
 		Status:       "success",
 		ErrorCode:    "",
 		FailedIndex:  -1,
+		ChangeRatio:  0.25,
 	}
 }
 
@@ -71,9 +74,94 @@ func TestSqliteHistoryRepository_AddAndGet(t *testing.T) {
 	if got.FailedIndex != -1 {
 		t.Errorf("Get: FailedIndex = %d, want -1", got.FailedIndex)
 	}
+	if got.ChangeRatio != 0.25 {
+		t.Errorf("Get: ChangeRatio = %v, want 0.25", got.ChangeRatio)
+	}
 	if len(got.Applied) != 1 || got.Applied[0].ID != "act1" {
 		t.Errorf("Get: Applied = %+v", got.Applied)
 	}
+	if got.Selection != nil {
+		t.Errorf("Get: Selection = %+v, want nil", got.Selection)
+	}
+}
+
+func TestSqliteHistoryRepository_Add_ReplacesSameID(t *testing.T) {
+	repo := newHistoryRepo(t)
+
+	first := makeEntry("resumed-run", "stack", "Run", 1000)
+	first.Status, first.ErrorCode, first.FailedIndex = "partial", "rate_limited", 1
+	if err := repo.Add(first, 100); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	second := makeEntry("resumed-run", "stack", "Run", 2000)
+	second.OutputText = "finished output"
+	second.Inferences = 2
+	if err := repo.Add(second, 100); err != nil {
+		t.Fatalf("Add again: %v", err)
+	}
+
+	n, err := repo.Count()
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("Count = %d, want 1", n)
+	}
+	got, err := repo.Get("resumed-run")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != "success" || got.ErrorCode != "" || got.FailedIndex != -1 {
+		t.Errorf("outcome = %s/%q/%d, want success/\"\"/-1", got.Status, got.ErrorCode, got.FailedIndex)
+	}
+	if got.OutputText != "finished output" || got.Inferences != 2 {
+		t.Errorf("output = %q, inferences = %d", got.OutputText, got.Inferences)
+	}
+	if got.CreatedAt != 1000 {
+		t.Errorf("CreatedAt = %d, want the original 1000", got.CreatedAt)
+	}
+}
+
+func TestSqliteHistoryRepository_Add_ResumedRerunKeepsRerunOf(t *testing.T) {
+	repo := newHistoryRepo(t)
+
+	// A re-run fails, then is resumed: the resumed pass's request comes back
+	// from its checkpoint without RerunOf.
+	failed := makeEntry("rerun-run", "stack", "Run", 1000)
+	failed.Status, failed.RerunOf = "partial", "orig"
+	if err := repo.Add(failed, 100); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	resumed := makeEntry("rerun-run", "stack", "Run", 2000)
+	if err := repo.Add(resumed, 100); err != nil {
+		t.Fatalf("Add resumed: %v", err)
+	}
+
+	got, err := repo.Get("rerun-run")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.RerunOf != "orig" || got.Status != "success" {
+		t.Errorf("RerunOf = %q, Status = %s; want orig, success", got.RerunOf, got.Status)
+	}
+}
+
+func TestSqliteHistoryRepository_AddAndGet_Selection(t *testing.T) {
+	repo := newHistoryRepo(t)
+
+	entry := makeEntry("test-hist-sel", "single", "Selection", time.Now().Unix())
+	entry.Selection = &apperr.TextRange{Start: 4, End: 9}
+	if err := repo.Add(entry, 100); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	got, err := repo.Get("test-hist-sel")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Selection == nil || *got.Selection != (apperr.TextRange{Start: 4, End: 9}) {
+		t.Errorf("Get: Selection = %+v, want 4–9", got.Selection)
+	}
 }
 
 func TestSqliteHistoryRepository_ListNewestFirst(t *testing.T) {
@@ -230,3 +318,283 @@ func TestSqliteHistoryRepository_Count(t *testing.T) {
 		t.Errorf("Count (4): got %d, want 4", n4)
 	}
 }
+
+func TestSqliteHistoryRepository_ListByRun(t *testing.T) {
+	repo := newHistoryRepo(t)
+	base := time.Now().Unix()
+
+	for i, branch := range []string{"summary", "bullets"} {
+		e := makeEntry(fmt.Sprintf("fan-%d", i), "stack", "Fan-out", base)
+		e.RunID = "run-1"
+		e.Branch = branch
+		if err := repo.Add(e, 100); err != nil {
+			t.Fatalf("Add %q: %v", branch, err)
+		}
+	}
+	other := makeEntry("other", "single", "Other run", base)
+	other.RunID = "run-2"
+	if err := repo.Add(other, 100); err != nil {
+		t.Fatalf("Add other: %v", err)
+	}
+
+	list, err := repo.ListByRun("run-1")
+	if err != nil {
+		t.Fatalf("ListByRun: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("ListByRun: got %d entries, want 2", len(list))
+	}
+	// Same timestamp, so entries come back ordered by branch name.
+	if list[0].Branch != "bullets" || list[1].Branch != "summary" {
+		t.Errorf("ListByRun branches = [%q %q], want [bullets summary]", list[0].Branch, list[1].Branch)
+	}
+	for _, e := range list {
+		if e.RunID != "run-1" {
+			t.Errorf("entry %q: RunID = %q, want run-1", e.ID, e.RunID)
+		}
+	}
+
+	empty, err := repo.ListByRun("missing")
+	if err != nil {
+		t.Fatalf("ListByRun missing: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("ListByRun missing: got %d entries, want 0", len(empty))
+	}
+}
+
+func countSteps(t *testing.T, repo *SqliteHistoryRepository) int {
+	t.Helper()
+	var n int
+	if err := repo.database.DB.QueryRow(`SELECT count(*) FROM history_steps`).Scan(&n); err != nil {
+		t.Fatalf("count steps: %v", err)
+	}
+	return n
+}
+
+func makeSteps(texts ...string) []apperr.HistoryStep {
+	steps := make([]apperr.HistoryStep, len(texts)-1)
+	for i := range steps {
+		steps[i] = apperr.HistoryStep{
+			GroupIndex:       i,
+			Family:           "rewrite",
+			ActionIDs:        []string{fmt.Sprintf("act%d", i)},
+			InputText:        texts[i],
+			OutputText:       texts[i+1],
+			SystemHash:       "sys",
+			UserHash:         fmt.Sprintf("user%d", i),
+			DurationMs:       int64(100 * (i + 1)),
+			PromptTokens:     10,
+			CompletionTokens: 5,
+		}
+	}
+	return steps
+}
+
+func TestSqliteHistoryRepository_Steps(t *testing.T) {
+	repo := newHistoryRepo(t)
+
+	entry := makeEntry("with-steps", "stack", "Stack", time.Now().Unix())
+	entry.Steps = makeSteps("a", "b", "c")
+	if err := repo.Add(entry, 100); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	got, err := repo.Get("with-steps")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !reflect.DeepEqual(got.Steps, entry.Steps) {
+		t.Errorf("Get: Steps = %+v, want %+v", got.Steps, entry.Steps)
+	}
+
+	list, err := repo.List(10, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 1 || list[0].Steps != nil {
+		t.Errorf("List: want one entry without steps, got %+v", list)
+	}
+}
+
+func TestSqliteHistoryRepository_Steps_ResumedRunKeepsEarlierGroups(t *testing.T) {
+	repo := newHistoryRepo(t)
+
+	first := makeEntry("resumed", "stack", "Stack", 1000)
+	first.Status = "partial"
+	first.Steps = makeSteps("a", "b")
+	if err := repo.Add(first, 100); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	second := makeEntry("resumed", "stack", "Stack", 1000)
+	second.Steps = makeSteps("a", "b", "c")[1:]
+	if err := repo.Add(second, 100); err != nil {
+		t.Fatalf("Add resumed: %v", err)
+	}
+
+	got, err := repo.Get("resumed")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got.Steps) != 2 || got.Steps[0].OutputText != "b" || got.Steps[1].OutputText != "c" {
+		t.Errorf("Steps = %+v, want groups 0 and 1 ending in b, c", got.Steps)
+	}
+}
+
+func TestSqliteHistoryRepository_Steps_RemovedWithEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(repo *SqliteHistoryRepository) error
+	}{
+		{"delete", func(repo *SqliteHistoryRepository) error { return repo.Delete("old") }},
+		{"clear", func(repo *SqliteHistoryRepository) error { return repo.Clear() }},
+		{"prune", func(repo *SqliteHistoryRepository) error {
+			return repo.Add(makeEntry("new", "single", "New", 2000), 1)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newHistoryRepo(t)
+			old := makeEntry("old", "stack", "Old", 1000)
+			old.Steps = makeSteps("a", "b", "c")
+			if err := repo.Add(old, 100); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			if n := countSteps(t, repo); n != 2 {
+				t.Fatalf("steps before = %d, want 2", n)
+			}
+			if err := tt.remove(repo); err != nil {
+				t.Fatalf("remove: %v", err)
+			}
+			if n := countSteps(t, repo); n != 0 {
+				t.Errorf("steps after = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestSqliteHistoryRepository_Snapshot(t *testing.T) {
+	repo := newHistoryRepo(t)
+
+	snap := &apperr.RunSnapshot{
+		Request: apperr.ChainRequest{
+			RunID:            "orig",
+			InputText:        "input",
+			Steps:            []apperr.ChainStep{{ActionID: "translate.basic", Params: map[string]string{"tone": "formal"}}},
+			InputLanguageID:  "English",
+			OutputLanguageID: "German",
+		},
+		ProviderID:  "p1",
+		ModelConfig: apperr.ModelConfig{Name: "m", UseTemperature: true, Temperature: 0.2, UseContextWindow: true, ContextWindow: 8192},
+		UseMarkdown: true,
+		AppVersion:  "1.2.3",
+		CatalogHash: "abc",
+	}
+	orig := makeEntry("orig", "single", "Orig", 1000)
+	orig.Snapshot = snap
+	rerun := makeEntry("rerun", "single", "Orig", 2000)
+	rerun.RerunOf = "orig"
+	for _, e := range []apperr.HistoryEntry{orig, rerun} {
+		if err := repo.Add(e, 100); err != nil {
+			t.Fatalf("Add %s: %v", e.ID, err)
+		}
+	}
+
+	got, err := repo.Get("orig")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !reflect.DeepEqual(got.Snapshot, snap) {
+		t.Errorf("Snapshot = %+v, want %+v", got.Snapshot, snap)
+	}
+
+	list, err := repo.List(10, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].RerunOf != "orig" || list[0].Snapshot != nil || list[1].Snapshot != nil {
+		t.Errorf("List: want the re-run linked to orig and no snapshots, got %+v", list)
+	}
+
+	got, err = repo.Get("rerun")
+	if err != nil {
+		t.Fatalf("Get rerun: %v", err)
+	}
+	if got.Snapshot != nil {
+		t.Errorf("an entry recorded without a snapshot has none, got %+v", got.Snapshot)
+	}
+}
+
+func TestSqliteHistoryRepository_PruneOlderThan(t *testing.T) {
+	repo := newHistoryRepo(t)
+	for i, id := range []string{"old", "pinned-old", "new"} {
+		if err := repo.Add(makeEntry(id, "single", id, int64(100+i*100)), 0); err != nil {
+			t.Fatalf("Add %s: %v", id, err)
+		}
+	}
+	if err := repo.SetPinned("pinned-old", true); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+
+	n, err := repo.PruneOlderThan(300)
+	if err != nil {
+		t.Fatalf("PruneOlderThan: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("removed %d, want 1", n)
+	}
+	if _, err := repo.Get("old"); err == nil {
+		t.Error("old entry survived")
+	}
+	for _, id := range []string{"pinned-old", "new"} {
+		if _, err := repo.Get(id); err != nil {
+			t.Errorf("%s removed: %v", id, err)
+		}
+	}
+}
+
+func TestSqliteHistoryRepository_PruneToSize(t *testing.T) {
+	repo := newHistoryRepo(t)
+	big := func(id string, createdAt int64) apperr.HistoryEntry {
+		e := makeEntry(id, "single", id, createdAt)
+		e.InputText = strings.Repeat("x", 1000)
+		e.OutputText = ""
+		return e
+	}
+	// "stepped" is small itself but carries 2000 bytes of step text.
+	stepped := makeEntry("stepped", "stack", "stepped", 400)
+	stepped.Steps = []apperr.HistoryStep{{GroupIndex: 0, Family: "rewrite", InputText: strings.Repeat("s", 1000), OutputText: strings.Repeat("t", 1000)}}
+	for _, e := range []apperr.HistoryEntry{big("oldest", 100), big("favorite", 150), big("older", 200), big("newer", 300), stepped} {
+		if err := repo.Add(e, 0); err != nil {
+			t.Fatalf("Add %s: %v", e.ID, err)
+		}
+	}
+	if err := repo.SetFavorite("favorite", true); err != nil {
+		t.Fatalf("SetFavorite: %v", err)
+	}
+
+	// About 2 KB for "stepped" and 1 KB for "newer" fit; the favorite is not counted.
+	n, err := repo.PruneToSize(3500)
+	if err != nil {
+		t.Fatalf("PruneToSize: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("removed %d, want 2", n)
+	}
+	entries, err := repo.List(10, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	if want := []string{"stepped", "newer", "favorite"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+
+	// A limit smaller than the newest entry removes every unflagged entry.
+	if n, err = repo.PruneToSize(1); err != nil || n != 2 {
+		t.Errorf("PruneToSize(1) = %d, %v; want 2 removed", n, err)
+	}
+}
@@ -0,0 +1,48 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go_text/internal/apperr"
+)
+
+// defaultSearchLimit is the page size of a search that does not set one.
+const defaultSearchLimit = 50
+
+// Search returns the page of entries matching req; see HistorySearchRequest.
+func (s *HistoryService) Search(req apperr.HistorySearchRequest) ([]apperr.HistorySearchHit, error) {
+	if s.repo == nil {
+		return nil, apperr.Internal(errors.New("history repository not initialized"))
+	}
+	req.Query = strings.TrimSpace(req.Query)
+	if err := validateSearch(req); err != nil {
+		return nil, err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSearchLimit
+	}
+	return s.repo.Search(req)
+}
+
+// validateSearch rejects paging and filter values no entry can have.
+func validateSearch(req apperr.HistorySearchRequest) error {
+	switch {
+	case req.Limit < 0:
+		return apperr.Validation("limit", "be zero or positive", fmt.Sprint(req.Limit))
+	case req.Offset < 0:
+		return apperr.Validation("offset", "be zero or positive", fmt.Sprint(req.Offset))
+	case req.Status != "" && req.Status != "success" && req.Status != "partial" && req.Status != "error":
+		return apperr.Validation("status", "one of success, partial, error", req.Status)
+	case req.Kind != "" && req.Kind != "single" && req.Kind != "stack":
+		return apperr.Validation("kind", "one of single, stack", req.Kind)
+	case req.From < 0:
+		return apperr.Validation("from", "be zero or positive", fmt.Sprint(req.From))
+	case req.To < 0:
+		return apperr.Validation("to", "be zero or positive", fmt.Sprint(req.To))
+	case req.From > 0 && req.To > 0 && req.From > req.To:
+		return apperr.Validation("to", "not be before from", fmt.Sprint(req.To))
+	}
+	return nil
+}
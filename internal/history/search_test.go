@@ -0,0 +1,289 @@
+package history
+
+import (
+	"errors"
+	"testing"
+
+	"go_text/internal/apperr"
+	"go_text/internal/settings"
+)
+
+// seedSearchRepo returns a repository holding three entries with distinct
+// text, status, kind, model, provider, actions and dates.
+func seedSearchRepo(t *testing.T) *SqliteHistoryRepository {
+	t.Helper()
+	repo := newHistoryRepo(t)
+
+	budget := makeEntry("budget", "single", "Email to finance", 1000)
+	budget.InputText = "hi team, the Q3 budget review moved to friday"
+	budget.OutputText = "Hello team, the Q3 budget review has moved to Friday."
+	budget.Applied = []apperr.AppliedAction{{ID: "rewrite.proofread.basic"}}
+
+	cafe := makeEntry("cafe", "stack", "Café menu", 2000)
+	cafe.InputText = "Le café est fermé le lundi."
+	cafe.OutputText = "The café is closed on Mondays."
+	cafe.Applied = []apperr.AppliedAction{{ID: "translate.english"}, {ID: "rewrite.proofread.basic"}}
+	cafe.Status = "partial"
+	cafe.Model = "qwen2.5"
+	cafe.ProviderName = "LM Studio"
+
+	report := makeEntry("report", "single", "Budget report", 3000)
+	report.InputText = "annual report draft"
+	report.OutputText = "Annual report, final draft."
+	report.Applied = []apperr.AppliedAction{{ID: "summarize.short"}}
+	report.Status = "error"
+
+	for _, e := range []apperr.HistoryEntry{budget, cafe, report} {
+		if err := repo.Add(e, 100); err != nil {
+			t.Fatalf("Add %s: %v", e.ID, err)
+		}
+	}
+	return repo
+}
+
+func hitIDs(hits []apperr.HistorySearchHit) []string {
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.Entry.ID
+	}
+	return ids
+}
+
+func TestSqliteHistoryRepository_Search(t *testing.T) {
+	repo := seedSearchRepo(t)
+
+	tests := []struct {
+		name string
+		req  apperr.HistorySearchRequest
+		want []string
+	}{
+		{"word", apperr.HistorySearchRequest{Query: "friday"}, []string{"budget"}},
+		{"title match ranks first", apperr.HistorySearchRequest{Query: "budget"}, []string{"report", "budget"}},
+		{"phrase", apperr.HistorySearchRequest{Query: `"budget review"`}, []string{"budget"}},
+		{"prefix", apperr.HistorySearchRequest{Query: "mond*"}, []string{"cafe"}},
+		{"diacritics folded", apperr.HistorySearchRequest{Query: "cafe"}, []string{"cafe"}},
+		{"column filter", apperr.HistorySearchRequest{Query: "title:budget"}, []string{"report"}},
+		{"boolean", apperr.HistorySearchRequest{Query: "budget NOT annual"}, []string{"budget"}},
+		{"status", apperr.HistorySearchRequest{Query: "budget", Status: "success"}, []string{"budget"}},
+		{"kind", apperr.HistorySearchRequest{Kind: "stack"}, []string{"cafe"}},
+		{"model", apperr.HistorySearchRequest{Model: "qwen2.5"}, []string{"cafe"}},
+		{"provider", apperr.HistorySearchRequest{Provider: "LM Studio"}, []string{"cafe"}},
+		{"action", apperr.HistorySearchRequest{ActionID: "rewrite.proofread.basic"}, []string{"cafe", "budget"}},
+		{"action with query", apperr.HistorySearchRequest{Query: "closed", ActionID: "summarize.short"}, []string{}},
+		{"date range", apperr.HistorySearchRequest{From: 1500, To: 3000}, []string{"report", "cafe"}},
+		{"from only", apperr.HistorySearchRequest{Query: "budget", From: 2000}, []string{"report"}},
+		{"no filters newest first", apperr.HistorySearchRequest{}, []string{"report", "cafe", "budget"}},
+		{"page", apperr.HistorySearchRequest{Limit: 1, Offset: 1}, []string{"cafe"}},
+		{"no match", apperr.HistorySearchRequest{Query: "invoice"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.req.Limit == 0 {
+				tt.req.Limit = 10
+			}
+			hits, err := repo.Search(tt.req)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			got := hitIDs(hits)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ids = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("ids = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSqliteHistoryRepository_Search_Snippet(t *testing.T) {
+	repo := seedSearchRepo(t)
+
+	hits, err := repo.Search(apperr.HistorySearchRequest{Query: "friday", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("got %d hits, want 1", len(hits))
+	}
+	hit := hits[0]
+	if len(hit.Highlights) == 0 {
+		t.Fatalf("no highlights in snippet %q", hit.Snippet)
+	}
+	for _, r := range hit.Highlights {
+		if got := hit.Snippet[r.Start:r.End]; got != "friday" && got != "Friday" {
+			t.Errorf("highlight %d-%d = %q, want friday", r.Start, r.End, got)
+		}
+	}
+
+	hits, err = repo.Search(apperr.HistorySearchRequest{Status: "error", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search without query: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Snippet != "" || hits[0].Highlights != nil {
+		t.Errorf("filter-only hits = %+v, want one hit without snippet", hits)
+	}
+}
+
+func TestSqliteHistoryRepository_Search_FollowsChanges(t *testing.T) {
+	repo := seedSearchRepo(t)
+	search := func(q string) []string {
+		t.Helper()
+		hits, err := repo.Search(apperr.HistorySearchRequest{Query: q, Limit: 10})
+		if err != nil {
+			t.Fatalf("Search %q: %v", q, err)
+		}
+		return hitIDs(hits)
+	}
+
+	// Re-recording an entry updates its indexed text.
+	updated := makeEntry("budget", "single", "Email to finance", 1000)
+	updated.OutputText = "The forecast meeting is on Thursday."
+	if err := repo.Add(updated, 100); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if got := search("friday"); len(got) != 0 {
+		t.Errorf("after update, friday = %v, want none", got)
+	}
+	if got := search("thursday"); len(got) != 1 || got[0] != "budget" {
+		t.Errorf("after update, thursday = %v, want [budget]", got)
+	}
+
+	if err := repo.Delete("cafe"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := search("cafe"); len(got) != 0 {
+		t.Errorf("after delete, cafe = %v, want none", got)
+	}
+
+	// Pruning to one entry keeps only the newest.
+	if err := repo.Add(makeEntry("newest", "single", "Latest", 4000), 1); err != nil {
+		t.Fatalf("Add with prune: %v", err)
+	}
+	if got := search("report"); len(got) != 0 {
+		t.Errorf("after prune, report = %v, want none", got)
+	}
+	if got := search("latest"); len(got) != 1 || got[0] != "newest" {
+		t.Errorf("after prune, latest = %v, want [newest]", got)
+	}
+}
+
+func TestSqliteHistoryRepository_Search_InvalidQuery(t *testing.T) {
+	repo := seedSearchRepo(t)
+	for _, q := range []string{`"unterminated`, "AND", "(budget", "nosuchcolumn:budget"} {
+		_, err := repo.Search(apperr.HistorySearchRequest{Query: q, Limit: 10})
+		var ae *apperr.AppError
+		if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+			t.Errorf("Search(%q) err = %v, want validation error", q, err)
+		}
+	}
+}
+
+func TestParseSnippet(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		text string
+		want []apperr.TextRange
+	}{
+		{"plain", "no matches", "no matches", nil},
+		{"one", "the \x02Q3\x03 budget", "the Q3 budget", []apperr.TextRange{{Start: 4, End: 6}}},
+		{"two", "\x02a\x03 and \x02b\x03", "a and b", []apperr.TextRange{{Start: 0, End: 1}, {Start: 6, End: 7}}},
+		{"multibyte", "…\x02café\x03", "…café", []apperr.TextRange{{Start: 3, End: 8}}},
+		{"unbalanced", "x\x03y\x02", "xy", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, got := parseSnippet(tt.in)
+			if text != tt.text {
+				t.Errorf("text = %q, want %q", text, tt.text)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("highlights = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("highlights = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHistoryService_Search(t *testing.T) {
+	svc, repo, _ := enabledSvc(t, 100)
+	repo.searchRet = []apperr.HistorySearchHit{{Entry: sampleEntry("success")}}
+
+	got, err := svc.Search(apperr.HistorySearchRequest{Query: "  budget  ", Status: "success"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d hits, want 1", len(got))
+	}
+	if repo.searchReq.Query != "budget" {
+		t.Errorf("query = %q, want trimmed", repo.searchReq.Query)
+	}
+	if repo.searchReq.Limit != defaultSearchLimit {
+		t.Errorf("limit = %d, want default %d", repo.searchReq.Limit, defaultSearchLimit)
+	}
+}
+
+func TestHistoryService_Search_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  apperr.HistorySearchRequest
+	}{
+		{"negative limit", apperr.HistorySearchRequest{Limit: -1}},
+		{"negative offset", apperr.HistorySearchRequest{Offset: -1}},
+		{"unknown status", apperr.HistorySearchRequest{Status: "done"}},
+		{"unknown kind", apperr.HistorySearchRequest{Kind: "batch"}},
+		{"negative from", apperr.HistorySearchRequest{From: -5}},
+		{"to before from", apperr.HistorySearchRequest{From: 200, To: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := enabledSvc(t, 100)
+			_, err := svc.Search(tt.req)
+			var ae *apperr.AppError
+			if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+				t.Errorf("err = %v, want validation error", err)
+			}
+		})
+	}
+}
+
+func TestHistoryService_Search_NoRepo(t *testing.T) {
+	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}}, &mockFiles{})
+	if _, err := svc.Search(apperr.HistorySearchRequest{}); err == nil {
+		t.Error("expected error when repo is nil")
+	}
+}
+
+func TestHistoryHandler_SearchHistory(t *testing.T) {
+	hits := []apperr.HistorySearchHit{{Entry: apperr.HistoryEntry{ID: "e1"}, Snippet: "x"}}
+	svc := &mockHistoryService{searchRet: hits}
+	res := newTestHandler(svc).SearchHistory(apperr.HistorySearchRequest{Query: "x", Kind: "single"})
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %+v", res.Error)
+	}
+	if len(res.Data) != 1 || res.Data[0].Entry.ID != "e1" {
+		t.Errorf("unexpected data: %+v", res.Data)
+	}
+	if svc.searchReq.Query != "x" || svc.searchReq.Kind != "single" {
+		t.Errorf("request not passed through: %+v", svc.searchReq)
+	}
+
+	res = newTestHandler(&mockHistoryService{}).SearchHistory(apperr.HistorySearchRequest{})
+	if res.Error != nil || res.Data == nil {
+		t.Errorf("empty result = %+v, want non-nil empty data", res)
+	}
+
+	res = newTestHandler(&mockHistoryService{searchErr: apperr.Validation("query", "valid search syntax", "AND")}).
+		SearchHistory(apperr.HistorySearchRequest{Query: "AND"})
+	if res.Error == nil || res.Error.Code != apperr.CodeValidation {
+		t.Errorf("error = %+v, want validation", res.Error)
+	}
+}
@@ -15,16 +15,33 @@ type historySettingsAPI interface {
 	GetAppBehaviorConfig() (*settings.AppBehaviorConfig, error)
 }
 
+// historyFileAPI is the minimal contract HistoryService needs from the file service.
+type historyFileAPI interface {
+	WriteExportFile(dir, name string, data []byte) (string, error)
+}
+
 // HistoryServiceAPI is the contract consumed by ActionService and HistoryHandler.
 type HistoryServiceAPI interface {
 	// Record writes one history entry if history is enabled.
 	// All errors are logged and swallowed — recording must never break a run.
 	Record(entry apperr.HistoryEntry)
 	List(limit, offset int64) ([]apperr.HistoryEntry, error)
+	ListByRun(runID string) ([]apperr.HistoryEntry, error)
 	Get(id string) (*apperr.HistoryEntry, error)
 	Delete(id string) error
 	Clear() error
 	Count() (int64, error)
+	// Search returns the page of entries matching req; see HistoryService.Search.
+	Search(req apperr.HistorySearchRequest) ([]apperr.HistorySearchHit, error)
+	SetPinned(id string, pinned bool) error
+	SetFavorite(id string, favorite bool) error
+	AddTag(id, tag string) error
+	RemoveTag(id, tag string) error
+	ListTags() ([]apperr.HistoryTag, error)
+	DeleteTag(tag string) error
+	ExportHistory(req apperr.HistoryArchiveRequest) (*apperr.HistoryArchive, error)
+	ImportHistory(path string) (*apperr.HistoryImportSummary, error)
+	ExportTrackedChanges(id, format, dir string) (string, error)
 }
 
 // HistoryService implements HistoryServiceAPI.
@@ -33,11 +50,12 @@ type HistoryService struct {
 	logger   logger.Logger
 	repo     HistoryRepositoryAPI
 	settings historySettingsAPI
+	files    historyFileAPI
 }
 
 // NewHistoryService constructs a HistoryService. Panics on nil dependencies.
 // Returns *HistoryService (concrete) so ApplicationContextHolder can call SetRepository.
-func NewHistoryService(wailsLogger logger.Logger, settingsService historySettingsAPI) *HistoryService {
+func NewHistoryService(wailsLogger logger.Logger, settingsService historySettingsAPI, fileService historyFileAPI) *HistoryService {
 	const op = "HistoryService.NewHistoryService"
 	if wailsLogger == nil {
 		panic(fmt.Sprintf("%s: logger cannot be nil", op))
@@ -45,8 +63,11 @@ func NewHistoryService(wailsLogger logger.Logger, settingsService historySetting
 	if settingsService == nil {
 		panic(fmt.Sprintf("%s: settings service cannot be nil", op))
 	}
+	if fileService == nil {
+		panic(fmt.Sprintf("%s: file service cannot be nil", op))
+	}
 	wailsLogger.Info(fmt.Sprintf("[%s] Initializing history service", op))
-	return &HistoryService{logger: wailsLogger, settings: settingsService}
+	return &HistoryService{logger: wailsLogger, settings: settingsService, files: fileService}
 }
 
 // SetRepository wires the SQLite-backed repository after the DB is open.
@@ -83,6 +104,13 @@ func (s *HistoryService) List(limit, offset int64) ([]apperr.HistoryEntry, error
 	return s.repo.List(limit, offset)
 }
 
+func (s *HistoryService) ListByRun(runID string) ([]apperr.HistoryEntry, error) {
+	if s.repo == nil {
+		return nil, apperr.Internal(errors.New("history repository not initialized"))
+	}
+	return s.repo.ListByRun(runID)
+}
+
 func (s *HistoryService) Get(id string) (*apperr.HistoryEntry, error) {
 	if s.repo == nil {
 		return nil, apperr.Internal(errors.New("history repository not initialized"))
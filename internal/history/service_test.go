@@ -2,6 +2,7 @@ package history
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"go_text/internal/apperr"
@@ -26,8 +27,20 @@ type mockRepo struct {
 	addErr  error
 	listRet []apperr.HistoryEntry
 	listErr error
+	getRet  *apperr.HistoryEntry
 	getErr  error
 	delErr  error
+
+	searchRet []apperr.HistorySearchHit
+	searchReq apperr.HistorySearchRequest
+
+	tagCalls []string
+
+	exportReq     apperr.HistoryArchiveRequest
+	exportRet     []apperr.HistoryEntry
+	imported      []apperr.HistoryEntry
+	importMax     int64
+	importSummary apperr.HistoryImportSummary
 }
 
 func (r *mockRepo) Add(entry apperr.HistoryEntry, maxEntries int64) error {
@@ -37,15 +50,70 @@ func (r *mockRepo) Add(entry apperr.HistoryEntry, maxEntries int64) error {
 func (r *mockRepo) List(limit, offset int64) ([]apperr.HistoryEntry, error) {
 	return r.listRet, r.listErr
 }
+func (r *mockRepo) ListByRun(runID string) ([]apperr.HistoryEntry, error) {
+	return r.listRet, r.listErr
+}
 func (r *mockRepo) Get(id string) (*apperr.HistoryEntry, error) {
 	if r.getErr != nil {
 		return nil, r.getErr
 	}
-	return nil, nil
+	return r.getRet, nil
 }
 func (r *mockRepo) Delete(id string) error { return r.delErr }
 func (r *mockRepo) Clear() error           { return nil }
 func (r *mockRepo) Count() (int64, error)  { return 0, nil }
+func (r *mockRepo) Search(req apperr.HistorySearchRequest) ([]apperr.HistorySearchHit, error) {
+	r.searchReq = req
+	return r.searchRet, nil
+}
+
+func (r *mockRepo) SetPinned(id string, pinned bool) error {
+	r.tagCalls = append(r.tagCalls, fmt.Sprintf("pin %s %t", id, pinned))
+	return nil
+}
+func (r *mockRepo) SetFavorite(id string, favorite bool) error {
+	r.tagCalls = append(r.tagCalls, fmt.Sprintf("favorite %s %t", id, favorite))
+	return nil
+}
+func (r *mockRepo) AddTag(id, tag string) error {
+	r.tagCalls = append(r.tagCalls, "add "+id+" "+tag)
+	return nil
+}
+func (r *mockRepo) RemoveTag(id, tag string) error {
+	r.tagCalls = append(r.tagCalls, "remove "+id+" "+tag)
+	return nil
+}
+func (r *mockRepo) ListTags() ([]apperr.HistoryTag, error) { return nil, nil }
+func (r *mockRepo) DeleteTag(tag string) error {
+	r.tagCalls = append(r.tagCalls, "delete "+tag)
+	return nil
+}
+
+func (r *mockRepo) Export(req apperr.HistoryArchiveRequest) ([]apperr.HistoryEntry, error) {
+	r.exportReq = req
+	return r.exportRet, nil
+}
+func (r *mockRepo) Import(entries []apperr.HistoryEntry, maxEntries int64) (apperr.HistoryImportSummary, error) {
+	r.imported, r.importMax = entries, maxEntries
+	return r.importSummary, nil
+}
+
+// --- mock file service ---
+
+type mockFiles struct {
+	dir  string
+	name string
+	data []byte
+	err  error
+}
+
+func (f *mockFiles) WriteExportFile(dir, name string, data []byte) (string, error) {
+	f.dir, f.name, f.data = dir, name, data
+	if f.err != nil {
+		return "", f.err
+	}
+	return dir + "/" + name, nil
+}
 
 // --- fakeLogger ---
 
@@ -65,7 +133,7 @@ func disabledSvc(t *testing.T) (*HistoryService, *mockRepo) {
 	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{
 		HistoryEnabled:    false,
 		HistoryMaxEntries: 100,
-	}})
+	}}, &mockFiles{})
 	svc.SetRepository(repo)
 	return svc, repo
 }
@@ -77,7 +145,7 @@ func enabledSvc(t *testing.T, maxEntries int) (*HistoryService, *mockRepo, *fake
 	svc := NewHistoryService(log, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{
 		HistoryEnabled:    true,
 		HistoryMaxEntries: maxEntries,
-	}})
+	}}, &mockFiles{})
 	svc.SetRepository(repo)
 	return svc, repo, log
 }
@@ -97,7 +165,7 @@ func TestHistoryService_Record_DisabledNoWrite(t *testing.T) {
 func TestHistoryService_Record_NilRepoNoPanic(t *testing.T) {
 	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{
 		HistoryEnabled: true, HistoryMaxEntries: 100,
-	}})
+	}}, &mockFiles{})
 	svc.Record(sampleEntry("success")) // must not panic
 }
 
@@ -140,7 +208,7 @@ func TestHistoryService_Record_PassesMaxEntriesToRepo(t *testing.T) {
 func TestHistoryService_Record_SettingsErrorSwallowed(t *testing.T) {
 	repo := &mockRepo{}
 	log := &fakeLogger{}
-	svc := NewHistoryService(log, &mockSettingsSvc{err: errors.New("settings fail")})
+	svc := NewHistoryService(log, &mockSettingsSvc{err: errors.New("settings fail")}, &mockFiles{})
 	svc.SetRepository(repo)
 	svc.Record(sampleEntry("success")) // must not propagate
 	if len(repo.added) != 0 {
@@ -152,7 +220,7 @@ func TestHistoryService_Record_SettingsErrorSwallowed(t *testing.T) {
 }
 
 func TestHistoryService_List_NoRepo(t *testing.T) {
-	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}})
+	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}}, &mockFiles{})
 	_, err := svc.List(10, 0)
 	if err == nil {
 		t.Error("expected error when repo is nil")
@@ -172,7 +240,7 @@ func TestHistoryService_List_DelegatesToRepo(t *testing.T) {
 }
 
 func TestHistoryService_Get_NoRepo(t *testing.T) {
-	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}})
+	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}}, &mockFiles{})
 	_, err := svc.Get("x")
 	if err == nil {
 		t.Error("expected error when repo is nil")
@@ -180,7 +248,7 @@ func TestHistoryService_Get_NoRepo(t *testing.T) {
 }
 
 func TestHistoryService_Delete_NoRepo(t *testing.T) {
-	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}})
+	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}}, &mockFiles{})
 	err := svc.Delete("x")
 	if err == nil {
 		t.Error("expected error when repo is nil")
@@ -188,7 +256,7 @@ func TestHistoryService_Delete_NoRepo(t *testing.T) {
 }
 
 func TestHistoryService_Clear_NoRepo(t *testing.T) {
-	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}})
+	svc := NewHistoryService(&fakeLogger{}, &mockSettingsSvc{cfg: &settings.AppBehaviorConfig{}}, &mockFiles{})
 	err := svc.Clear()
 	if err == nil {
 		t.Error("expected error when repo is nil")
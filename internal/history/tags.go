@@ -0,0 +1,109 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go_text/internal/apperr"
+)
+
+// maxTagLength is the longest tag accepted, in runes.
+const maxTagLength = 50
+
+// normalizeTag trims tag and rejects empty, overlong or multi-line tags.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", apperr.Validation("tag", "be non-empty", "empty string")
+	}
+	if n := utf8.RuneCountInString(tag); n > maxTagLength {
+		return "", apperr.Validation("tag", fmt.Sprintf("at most %d characters", maxTagLength), fmt.Sprint(n))
+	}
+	if strings.IndexFunc(tag, unicode.IsControl) >= 0 {
+		return "", apperr.Validation("tag", "no control characters", tag)
+	}
+	return tag, nil
+}
+
+func requireID(id string) error {
+	if id == "" {
+		return apperr.Validation("id", "be non-empty", "empty string")
+	}
+	return nil
+}
+
+// SetPinned pins or unpins entry id. Pinned entries are never pruned.
+func (s *HistoryService) SetPinned(id string, pinned bool) error {
+	if s.repo == nil {
+		return apperr.Internal(errors.New("history repository not initialized"))
+	}
+	if err := requireID(id); err != nil {
+		return err
+	}
+	return s.repo.SetPinned(id, pinned)
+}
+
+// SetFavorite marks or unmarks entry id as a favorite. Favorites are never
+// pruned.
+func (s *HistoryService) SetFavorite(id string, favorite bool) error {
+	if s.repo == nil {
+		return apperr.Internal(errors.New("history repository not initialized"))
+	}
+	if err := requireID(id); err != nil {
+		return err
+	}
+	return s.repo.SetFavorite(id, favorite)
+}
+
+// AddTag tags entry id with tag, trimmed.
+func (s *HistoryService) AddTag(id, tag string) error {
+	if s.repo == nil {
+		return apperr.Internal(errors.New("history repository not initialized"))
+	}
+	if err := requireID(id); err != nil {
+		return err
+	}
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	return s.repo.AddTag(id, tag)
+}
+
+// RemoveTag removes tag from entry id.
+func (s *HistoryService) RemoveTag(id, tag string) error {
+	if s.repo == nil {
+		return apperr.Internal(errors.New("history repository not initialized"))
+	}
+	if err := requireID(id); err != nil {
+		return err
+	}
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemoveTag(id, tag)
+}
+
+// ListTags returns every tag in use with the number of entries carrying it.
+func (s *HistoryService) ListTags() ([]apperr.HistoryTag, error) {
+	if s.repo == nil {
+		return nil, apperr.Internal(errors.New("history repository not initialized"))
+	}
+	return s.repo.ListTags()
+}
+
+// DeleteTag removes tag from every entry.
+func (s *HistoryService) DeleteTag(tag string) error {
+	if s.repo == nil {
+		return apperr.Internal(errors.New("history repository not initialized"))
+	}
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteTag(tag)
+}
@@ -0,0 +1,286 @@
+package history
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+func TestSqliteHistoryRepository_PinnedAndFavoriteSurvivePrune(t *testing.T) {
+	repo := newHistoryRepo(t)
+	for i, id := range []string{"oldest", "old", "mid"} {
+		if err := repo.Add(makeEntry(id, "single", id, int64(100+i)), 0); err != nil {
+			t.Fatalf("Add %s: %v", id, err)
+		}
+	}
+	if err := repo.SetPinned("oldest", true); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+	if err := repo.SetFavorite("old", true); err != nil {
+		t.Fatalf("SetFavorite: %v", err)
+	}
+
+	// Two entries beyond a limit of one: only the unflagged "mid" is pruned.
+	if err := repo.Add(makeEntry("new", "single", "new", 200), 1); err != nil {
+		t.Fatalf("Add new: %v", err)
+	}
+	entries, err := repo.List(10, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	if want := []string{"new", "old", "oldest"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("ids = %v, want %v", ids, want)
+	}
+	if !entries[2].Pinned || entries[2].Favorite {
+		t.Errorf("oldest: pinned=%t favorite=%t, want pinned only", entries[2].Pinned, entries[2].Favorite)
+	}
+	if entries[1].Pinned || !entries[1].Favorite {
+		t.Errorf("old: pinned=%t favorite=%t, want favorite only", entries[1].Pinned, entries[1].Favorite)
+	}
+
+	// Unpinned, the entry is pruned by the next recording.
+	if err := repo.SetPinned("oldest", false); err != nil {
+		t.Fatalf("unpin: %v", err)
+	}
+	if err := repo.Add(makeEntry("newer", "single", "newer", 300), 1); err != nil {
+		t.Fatalf("Add newer: %v", err)
+	}
+	if _, err := repo.Get("oldest"); err == nil {
+		t.Error("unpinned entry survived prune")
+	}
+	if _, err := repo.Get("old"); err != nil {
+		t.Errorf("favorite entry pruned: %v", err)
+	}
+}
+
+func TestSqliteHistoryRepository_FlagsKeptWhenReplaced(t *testing.T) {
+	repo := newHistoryRepo(t)
+	entry := makeEntry("h1", "single", "First", 100)
+	if err := repo.Add(entry, 0); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := repo.SetPinned("h1", true); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+	if err := repo.AddTag("h1", "keep"); err != nil {
+		t.Fatalf("AddTag: %v", err)
+	}
+	entry.OutputText = "resumed output"
+	if err := repo.Add(entry, 0); err != nil {
+		t.Fatalf("Add again: %v", err)
+	}
+	got, err := repo.Get("h1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !got.Pinned || !reflect.DeepEqual(got.Tags, []string{"keep"}) {
+		t.Errorf("after replace: pinned=%t tags=%v, want pinned with [keep]", got.Pinned, got.Tags)
+	}
+}
+
+func TestSqliteHistoryRepository_FlagsUnknownID(t *testing.T) {
+	repo := newHistoryRepo(t)
+	if err := repo.SetPinned("missing", true); err == nil {
+		t.Error("SetPinned: expected error for unknown id")
+	}
+	if err := repo.SetFavorite("missing", true); err == nil {
+		t.Error("SetFavorite: expected error for unknown id")
+	}
+	if err := repo.AddTag("missing", "x"); err == nil {
+		t.Error("AddTag: expected error for unknown id")
+	}
+}
+
+func TestSqliteHistoryRepository_Tags(t *testing.T) {
+	repo := newHistoryRepo(t)
+	for i, id := range []string{"a", "b"} {
+		if err := repo.Add(makeEntry(id, "single", id, int64(100+i)), 0); err != nil {
+			t.Fatalf("Add %s: %v", id, err)
+		}
+	}
+	for _, tc := range [][2]string{{"a", "work"}, {"a", "Work"}, {"a", "q3"}, {"b", "work"}} {
+		if err := repo.AddTag(tc[0], tc[1]); err != nil {
+			t.Fatalf("AddTag %v: %v", tc, err)
+		}
+	}
+
+	got, err := repo.Get("a")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if want := []string{"q3", "work"}; !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("Get tags = %v, want %v", got.Tags, want)
+	}
+	tags, err := repo.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	if want := []apperr.HistoryTag{{Tag: "q3", Entries: 1}, {Tag: "work", Entries: 2}}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("ListTags = %+v, want %+v", tags, want)
+	}
+
+	hits, err := repo.Search(apperr.HistorySearchRequest{Tag: "WORK", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search by tag: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Entry.ID != "b" || !reflect.DeepEqual(hits[1].Entry.Tags, []string{"q3", "work"}) {
+		t.Errorf("Search by tag = %+v", hits)
+	}
+	hits, err = repo.Search(apperr.HistorySearchRequest{Query: "sample", Tag: "q3", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search text by tag: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Entry.ID != "a" {
+		t.Errorf("Search text by tag = %+v, want [a]", hits)
+	}
+
+	if err := repo.RemoveTag("a", "WORK"); err != nil {
+		t.Fatalf("RemoveTag: %v", err)
+	}
+	if err := repo.DeleteTag("q3"); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+	list, err := repo.List(10, 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if list[0].ID != "b" || !reflect.DeepEqual(list[0].Tags, []string{"work"}) || list[1].Tags != nil {
+		t.Errorf("List after removal = %+v", list)
+	}
+
+	// Tags go with their entry.
+	if err := repo.Delete("b"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	tags, err = repo.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("ListTags after delete = %+v, want none", tags)
+	}
+}
+
+func TestSqliteHistoryRepository_Search_FlagFilters(t *testing.T) {
+	repo := newHistoryRepo(t)
+	for i, id := range []string{"plain", "pinned", "favorite"} {
+		if err := repo.Add(makeEntry(id, "single", id, int64(100+i)), 0); err != nil {
+			t.Fatalf("Add %s: %v", id, err)
+		}
+	}
+	if err := repo.SetPinned("pinned", true); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+	if err := repo.SetFavorite("favorite", true); err != nil {
+		t.Fatalf("SetFavorite: %v", err)
+	}
+	for _, tt := range []struct {
+		req  apperr.HistorySearchRequest
+		want string
+	}{
+		{apperr.HistorySearchRequest{Pinned: true, Limit: 10}, "pinned"},
+		{apperr.HistorySearchRequest{Favorite: true, Limit: 10}, "favorite"},
+		{apperr.HistorySearchRequest{Query: "sample", Pinned: true, Limit: 10}, "pinned"},
+	} {
+		hits, err := repo.Search(tt.req)
+		if err != nil {
+			t.Fatalf("Search %+v: %v", tt.req, err)
+		}
+		if len(hits) != 1 || hits[0].Entry.ID != tt.want {
+			t.Errorf("Search %+v = %v, want [%s]", tt.req, hitIDs(hits), tt.want)
+		}
+	}
+}
+
+func TestHistoryService_Tags(t *testing.T) {
+	svc, repo, _ := enabledSvc(t, 100)
+	if err := svc.SetPinned("h1", true); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+	if err := svc.SetFavorite("h1", false); err != nil {
+		t.Fatalf("SetFavorite: %v", err)
+	}
+	if err := svc.AddTag("h1", "  Q3 budget "); err != nil {
+		t.Fatalf("AddTag: %v", err)
+	}
+	if err := svc.RemoveTag("h1", "draft"); err != nil {
+		t.Fatalf("RemoveTag: %v", err)
+	}
+	if err := svc.DeleteTag(" draft"); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+	want := []string{"pin h1 true", "favorite h1 false", "add h1 Q3 budget", "remove h1 draft", "delete draft"}
+	if !reflect.DeepEqual(repo.tagCalls, want) {
+		t.Errorf("repo calls = %q, want %q", repo.tagCalls, want)
+	}
+}
+
+func TestHistoryService_Tags_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(*HistoryService) error
+	}{
+		{"pin without id", func(s *HistoryService) error { return s.SetPinned("", true) }},
+		{"favorite without id", func(s *HistoryService) error { return s.SetFavorite("", true) }},
+		{"tag without id", func(s *HistoryService) error { return s.AddTag("", "x") }},
+		{"blank tag", func(s *HistoryService) error { return s.AddTag("h1", "   ") }},
+		{"long tag", func(s *HistoryService) error { return s.AddTag("h1", strings.Repeat("é", maxTagLength+1)) }},
+		{"multi-line tag", func(s *HistoryService) error { return s.AddTag("h1", "a\nb") }},
+		{"remove blank tag", func(s *HistoryService) error { return s.RemoveTag("h1", "") }},
+		{"delete blank tag", func(s *HistoryService) error { return s.DeleteTag("") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, _ := enabledSvc(t, 100)
+			err := tt.call(svc)
+			var ae *apperr.AppError
+			if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+				t.Errorf("err = %v, want validation error", err)
+			}
+			if len(repo.tagCalls) != 0 {
+				t.Errorf("repo called: %q", repo.tagCalls)
+			}
+		})
+	}
+}
+
+func TestHistoryHandler_Tags(t *testing.T) {
+	svc := &mockHistoryService{tagsRet: []apperr.HistoryTag{{Tag: "work", Entries: 2}}}
+	h := newTestHandler(svc)
+	for name, res := range map[string]apperr.VoidResult{
+		"pin":      h.SetHistoryPinned("h1", true),
+		"favorite": h.SetHistoryFavorite("h1", true),
+		"add":      h.AddHistoryTag("h1", "work"),
+		"remove":   h.RemoveHistoryTag("h1", "work"),
+		"delete":   h.DeleteHistoryTag("work"),
+	} {
+		if res.Error != nil {
+			t.Errorf("%s: unexpected error %+v", name, res.Error)
+		}
+	}
+	if len(svc.tagCalls) != 5 {
+		t.Errorf("service calls = %q, want 5", svc.tagCalls)
+	}
+	list := h.ListHistoryTags()
+	if list.Error != nil || len(list.Data) != 1 || list.Data[0].Tag != "work" {
+		t.Errorf("ListHistoryTags = %+v", list)
+	}
+
+	if list := newTestHandler(&mockHistoryService{}).ListHistoryTags(); list.Data == nil {
+		t.Error("ListHistoryTags: want non-nil empty data")
+	}
+	failing := newTestHandler(&mockHistoryService{tagErr: errors.New("db fail")})
+	if res := failing.SetHistoryPinned("h1", true); res.Error == nil {
+		t.Error("SetHistoryPinned: expected error")
+	}
+	if res := failing.ListHistoryTags(); res.Error == nil {
+		t.Error("ListHistoryTags: expected error")
+	}
+}
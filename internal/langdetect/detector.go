@@ -0,0 +1,276 @@
+// Package langdetect identifies the language of a text offline. Each supported
+// language has a character trigram profile per script it is written in, built
+// from an embedded sample; a text is matched against the profiles written in its
+// dominant script by the out-of-place rank distance of Cavnar & Trenkle.
+package langdetect
+
+import (
+	"embed"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed samples/*.txt
+var samples embed.FS
+
+const (
+	// profileSize is the number of top-ranked trigrams kept per profile.
+	profileSize = 300
+	// minLetters is the shortest text, in letters, Detect attempts to identify.
+	minLetters = 3
+	// fullConfidenceLetters is the shortest text, in letters, whose detection
+	// can be certain; confidence in shorter texts is scaled down in proportion.
+	fullConfidenceLetters = 20
+)
+
+// Detection is the outcome of Detect. Confidence is in [0, 1]: the share of the
+// text's letters written in the detected language's script, scaled by how
+// clearly the best profile beat the runner-up in that script and, below
+// fullConfidenceLetters, by the length of the text.
+type Detection struct {
+	Language   string
+	Confidence float64
+}
+
+// profile is the ranked trigram list of one language.
+type profile struct {
+	language string
+	script   string
+	ranks    map[string]int
+}
+
+var (
+	profilesOnce sync.Once
+	profiles     []profile
+)
+
+// loadProfiles builds the profiles from the embedded samples once. Samples are
+// named Language.txt, or Language.Script.txt for a further script of a language
+// (Serbian.Latin.txt). A sample that cannot be read is skipped; its language is
+// then simply not detectable in that script.
+func loadProfiles() []profile {
+	profilesOnce.Do(func() {
+		entries, _ := samples.ReadDir("samples")
+		for _, e := range entries {
+			raw, err := samples.ReadFile(path.Join("samples", e.Name()))
+			if err != nil {
+				continue
+			}
+			text := string(raw)
+			script, _, _ := dominantScript(text)
+			language, _, _ := strings.Cut(strings.TrimSuffix(e.Name(), ".txt"), ".")
+			profiles = append(profiles, profile{
+				language: language,
+				script:   script,
+				ranks:    rankTrigrams(text),
+			})
+		}
+	})
+	return profiles
+}
+
+// Languages returns the languages Detect can identify, sorted by name.
+func Languages() []string {
+	ps := loadProfiles()
+	out := make([]string, len(ps))
+	for i, p := range ps {
+		out[i] = p.language
+	}
+	sort.Strings(out)
+	return slices.Compact(out)
+}
+
+// relatedLanguages are groups of languages close enough that a text in one is
+// routinely matched as another; see Related.
+var relatedLanguages = [][]string{
+	{"Bosnian", "Croatian", "Montenegrin", "Serbian"},
+}
+
+// Related reports whether languages a and b, matched case-insensitively, are
+// the same or belong to one group of closely related languages, such as
+// Serbian and Croatian.
+func Related(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if strings.EqualFold(a, b) {
+		return true
+	}
+	for _, group := range relatedLanguages {
+		inA := slices.ContainsFunc(group, func(l string) bool { return strings.EqualFold(l, a) })
+		inB := slices.ContainsFunc(group, func(l string) bool { return strings.EqualFold(l, b) })
+		if inA && inB {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports reports whether language, matched case-insensitively, has a profile.
+func Supports(language string) bool {
+	language = strings.TrimSpace(language)
+	for _, p := range loadProfiles() {
+		if strings.EqualFold(p.language, language) {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect returns the most likely language of text among candidates, matched
+// case-insensitively and returned as spelled in candidates. Candidates without
+// a profile are ignored; nil candidates means every supported language. The
+// zero Detection is returned when text has too few letters or no candidate is
+// written in the text's script.
+func Detect(text string, candidates []string) Detection {
+	script, share, letters := dominantScript(text)
+	if script == "" {
+		return Detection{}
+	}
+	share *= min(1, float64(letters)/fullConfidenceLetters)
+
+	type match struct {
+		name     string
+		distance int
+	}
+	doc := rankTrigrams(text)
+	var matches []match
+	for _, p := range loadProfiles() {
+		if p.script != script {
+			continue
+		}
+		name := p.language
+		if candidates != nil {
+			name = ""
+			for _, c := range candidates {
+				if strings.EqualFold(strings.TrimSpace(c), p.language) {
+					name = c
+					break
+				}
+			}
+			if name == "" {
+				continue
+			}
+		}
+		matches = append(matches, match{name: name, distance: distance(doc, p.ranks)})
+	}
+	if len(matches) == 0 {
+		return Detection{}
+	}
+	if len(matches) == 1 {
+		return Detection{Language: matches[0].name, Confidence: share}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+	best, next := matches[0].distance, matches[1].distance
+	margin := 1.0
+	if next > 0 {
+		margin = min(1, float64(next-best)/float64(next)/marginScale)
+	}
+	return Detection{Language: matches[0].name, Confidence: share * margin}
+}
+
+// marginScale is the relative distance gap between the best and second-best
+// profile that counts as a certain match; smaller gaps scale confidence down.
+const marginScale = 0.2
+
+// rankTrigrams returns the profileSize most frequent trigrams of text, each
+// mapped to its rank. Words are lower-cased and padded with a space on both
+// sides, so word starts and ends form trigrams of their own. Combining marks
+// stay inside words, as Devanagari vowel signs must.
+func rankTrigrams(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r)
+	}) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+	grams := make([]string, 0, len(counts))
+	for g := range counts {
+		grams = append(grams, g)
+	}
+	sort.Slice(grams, func(i, j int) bool {
+		if counts[grams[i]] != counts[grams[j]] {
+			return counts[grams[i]] > counts[grams[j]]
+		}
+		return grams[i] < grams[j]
+	})
+	if len(grams) > profileSize {
+		grams = grams[:profileSize]
+	}
+	ranks := make(map[string]int, len(grams))
+	for i, g := range grams {
+		ranks[g] = i
+	}
+	return ranks
+}
+
+// distance is the out-of-place measure between a document and a language
+// profile: the sum of rank differences, with profileSize for every document
+// trigram the profile lacks.
+func distance(doc, lang map[string]int) int {
+	d := 0
+	for g, r := range doc {
+		lr, ok := lang[g]
+		if !ok {
+			d += profileSize
+			continue
+		}
+		if lr > r {
+			d += lr - r
+		} else {
+			d += r - lr
+		}
+	}
+	return d
+}
+
+// scripts are the writing systems the profiles are partitioned by.
+var scripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Han", unicode.Han},
+	{"Hangul", unicode.Hangul},
+	{"Devanagari", unicode.Devanagari},
+}
+
+// dominantScript returns the script most of text's letters are written in, the
+// share of letters in it and the number of letters. It returns "" when text has
+// fewer than minLetters letters or none of them is in a known script.
+func dominantScript(text string) (string, float64, int) {
+	counts := make([]int, len(scripts))
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for i, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[i]++
+				break
+			}
+		}
+	}
+	if letters < minLetters {
+		return "", 0, letters
+	}
+	best := 0
+	for i := range counts {
+		if counts[i] > counts[best] {
+			best = i
+		}
+	}
+	if counts[best] == 0 {
+		return "", 0, letters
+	}
+	return scripts[best].name, float64(counts[best]) / float64(letters), letters
+}
@@ -0,0 +1,138 @@
+package langdetect
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello, how are you doing today? I hope everything is fine.", "English"},
+		{"Bonjour, comment allez-vous aujourd'hui ?", "French"},
+		{"Ich habe heute keine Zeit, vielleicht morgen.", "German"},
+		{"¿Dónde está la biblioteca? Necesito estudiar.", "Spanish"},
+		{"Ciao, come stai? Io sto bene, grazie.", "Italian"},
+		{"Eu gosto muito de café pela manhã.", "Portuguese"},
+		{"Dzień dobry, jak się masz?", "Polish"},
+		{"Dobrý den, jak se máte?", "Czech"},
+		{"Možete li mi poslati izvješće do petka? Hvala unaprijed.", "Croatian"},
+		{"Dobar dan, hvala vam na poruci. Pogledaću problem danas popodne.", "Serbian"},
+		{"Molim vas da mi pošaljete izveštaj do petka.", "Serbian"},
+		{"Привет, как дела? Я сегодня очень занят.", "Russian"},
+		{"Привіт, як справи? Я сьогодні дуже зайнятий.", "Ukrainian"},
+		{"Здраво, како сте? Хвала, добро сам.", "Serbian"},
+		{"你好，你今天怎么样？", "Chinese"},
+		{"안녕하세요, 오늘 어떻게 지내세요?", "Korean"},
+		{"नमस्ते, आप कैसे हैं?", "Hindi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := Detect(tt.text, nil)
+			if got.Language != tt.want {
+				t.Fatalf("Detect(%q) = %q, want %q", tt.text, got.Language, tt.want)
+			}
+			if got.Confidence <= 0 || got.Confidence > 1 {
+				t.Errorf("Confidence = %v, want in (0, 1]", got.Confidence)
+			}
+		})
+	}
+}
+
+func TestDetect_Candidates(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		candidates     []string
+		want           string
+		wantConfidence float64
+	}{
+		{
+			name:       "returned as spelled in candidates",
+			text:       "Je suis très content de vous voir.",
+			candidates: []string{"english", "french"},
+			want:       "french",
+		},
+		{
+			name:           "only candidate in the script is certain",
+			text:           "Привет, как дела? Я сегодня очень занят.",
+			candidates:     []string{"English", "Russian"},
+			want:           "Russian",
+			wantConfidence: 1,
+		},
+		{
+			name:       "no candidate in the text's script",
+			text:       "Привет, как дела?",
+			candidates: []string{"English", "German"},
+		},
+		{
+			name:       "candidates without a profile are ignored",
+			text:       "The weather is lovely today.",
+			candidates: []string{"Klingon"},
+		},
+		{
+			name: "too few letters",
+			text: "ok 12345 !!!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.text, tt.candidates)
+			if got.Language != tt.want {
+				t.Fatalf("Language = %q, want %q", got.Language, tt.want)
+			}
+			if tt.want == "" && got.Confidence != 0 {
+				t.Errorf("Confidence = %v, want 0 without a detection", got.Confidence)
+			}
+			if tt.wantConfidence != 0 && got.Confidence != tt.wantConfidence {
+				t.Errorf("Confidence = %v, want %v", got.Confidence, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestDetect_ShortTextConfidence(t *testing.T) {
+	short := Detect("OK thanks", nil)
+	if short.Language != "English" {
+		t.Fatalf("Language = %q, want English", short.Language)
+	}
+	if short.Confidence >= 0.5 {
+		t.Errorf("Confidence = %v for a two-word text, want < 0.5", short.Confidence)
+	}
+	long := Detect("Thanks, that works for me and I will send it tomorrow.", nil)
+	if long.Confidence <= short.Confidence {
+		t.Errorf("Confidence = %v for a full sentence, want above %v", long.Confidence, short.Confidence)
+	}
+}
+
+func TestRelated(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Serbian", "serbian", true},
+		{"Serbian", "Croatian", true},
+		{" bosnian", "Croatian", true},
+		{"Russian", "Ukrainian", false},
+		{"Serbian", "Russian", false},
+	}
+	for _, tt := range tests {
+		if got := Related(tt.a, tt.b); got != tt.want {
+			t.Errorf("Related(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	got := Languages()
+	if len(got) != 15 {
+		t.Fatalf("Languages() returned %d languages, want 15: %v", len(got), got)
+	}
+	if !slices.IsSorted(got) {
+		t.Errorf("Languages() not sorted: %v", got)
+	}
+}
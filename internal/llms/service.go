@@ -20,6 +20,7 @@ var errNilProvider = errors.New("provider configuration cannot be nil")
 type LLMServiceAPI interface {
 	GetModelsList() ([]string, error)
 	GetCompletionResponse(ctx context.Context, request *ChatCompletionRequest) (string, error)
+	GetCompletionWithSettings(ctx context.Context, cfg *settings.Settings, request *ChatCompletionRequest) (ChatResponse, error)
 	GetModelsListForProvider(provider *settings.ProviderConfig) ([]string, error)
 	GetModelsInfoForProvider(provider *settings.ProviderConfig) ([]apperr.ModelInfo, error)
 	GetCompletionResponseForProvider(ctx context.Context, provider *settings.ProviderConfig, request *ChatCompletionRequest) (string, error)
@@ -171,36 +172,63 @@ func (l *LLMService) GetModelsInfoForProvider(provider *settings.ProviderConfig)
 }
 
 func (l *LLMService) GetCompletionResponseForProvider(ctx context.Context, provider *settings.ProviderConfig, request *ChatCompletionRequest) (string, error) {
-	const op = "LLMService.GetCompletionResponseForProvider"
-	if provider == nil {
-		return "", fmt.Errorf("%s: %s", op, errNilProvider)
-	}
-	if request == nil {
-		return "", fmt.Errorf("%s: completion request cannot be nil", op)
-	}
-
-	resolved, err := l.resolveConfig(provider)
+	resp, err := l.completeForProvider(ctx, provider, request)
 	if err != nil {
 		return "", err
 	}
+	return resp.Content, nil
+}
 
-	p, err := l.factory.Build(resolved)
-	if err != nil {
-		return "", err
+// completeForProvider runs request against provider with the current
+// inference and model settings.
+func (l *LLMService) completeForProvider(ctx context.Context, provider *settings.ProviderConfig, request *ChatCompletionRequest) (ChatResponse, error) {
+	const op = "LLMService.completeForProvider"
+	if provider == nil {
+		return ChatResponse{}, fmt.Errorf("%s: %s", op, errNilProvider)
 	}
 
 	baseConfig, err := l.settingsService.GetInferenceBaseConfig()
 	if err != nil {
-		return "", fmt.Errorf("%s: get inference config: %w", op, err)
+		return ChatResponse{}, fmt.Errorf("%s: get inference config: %w", op, err)
 	}
 	modelConfig, err := l.settingsService.GetModelConfig()
 	if err != nil {
-		return "", fmt.Errorf("%s: get model config: %w", op, err)
+		return ChatResponse{}, fmt.Errorf("%s: get model config: %w", op, err)
 	}
 
-	timeout := ValidateTimeout(baseConfig.Timeout)
-	maxRetries := l.validateMaxRetries(baseConfig.MaxRetries)
-	attempt := chatAttempt{provider: p, request: chatRequestFrom(request, modelConfig), timeout: timeout}
+	cfg := &settings.Settings{CurrentProviderConfig: *provider, InferenceBaseConfig: *baseConfig}
+	if modelConfig != nil {
+		cfg.ModelConfig = *modelConfig
+	}
+	return l.GetCompletionWithSettings(ctx, cfg, request)
+}
+
+// GetCompletionWithSettings runs request against cfg's current provider with
+// cfg's timeout, retries and model settings instead of reading the current
+// ones, and returns the response of the attempt that succeeded with its token
+// usage. Chain runs use it so settings stay fixed for the whole run.
+func (l *LLMService) GetCompletionWithSettings(ctx context.Context, cfg *settings.Settings, request *ChatCompletionRequest) (ChatResponse, error) {
+	const op = "LLMService.GetCompletionWithSettings"
+	if cfg == nil {
+		return ChatResponse{}, fmt.Errorf("%s: settings cannot be nil", op)
+	}
+	if request == nil {
+		return ChatResponse{}, fmt.Errorf("%s: completion request cannot be nil", op)
+	}
+
+	resolved, err := l.resolveConfig(&cfg.CurrentProviderConfig)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	p, err := l.factory.Build(resolved)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	timeout := ValidateTimeout(cfg.InferenceBaseConfig.Timeout)
+	maxRetries := l.validateMaxRetries(cfg.InferenceBaseConfig.MaxRetries)
+	attempt := chatAttempt{provider: p, request: chatRequestFrom(request, &cfg.ModelConfig), timeout: timeout}
 
 	return l.chatWithRetry(ctx, attempt, maxRetries)
 }
@@ -221,42 +249,42 @@ const (
 // chatWithRetry runs up to maxRetries+1 attempts against a.provider, retrying only on
 // apperr.AppError.Retryable errors. Each attempt gets a fresh timeout-second budget
 // derived from the caller's ctx, so a slow first attempt cannot starve later retries.
-func (l *LLMService) chatWithRetry(ctx context.Context, a chatAttempt, maxRetries int) (string, error) {
+func (l *LLMService) chatWithRetry(ctx context.Context, a chatAttempt, maxRetries int) (ChatResponse, error) {
 	const op = "LLMService.chatWithRetry"
 	var lastErr error
 	for attemptNum := 0; attemptNum <= maxRetries; attemptNum++ {
-		content, err := l.chatOnce(ctx, a)
+		resp, err := l.chatOnce(ctx, a)
 		if err == nil {
-			return content, nil
+			return resp, nil
 		}
 		lastErr = err
 
 		ae, retryable := asRetryableAppError(err)
 		if !retryable || attemptNum == maxRetries {
-			return "", err
+			return ChatResponse{}, err
 		}
 
 		l.logger.Warning(fmt.Sprintf("[%s] Attempt %d/%d failed for provider %s, retrying: %v",
 			op, attemptNum+1, maxRetries+1, a.provider.Kind(), err))
 		if waitErr := l.waitBeforeRetry(ctx, attemptNum, ae); waitErr != nil {
-			return "", waitErr
+			return ChatResponse{}, waitErr
 		}
 	}
-	return "", lastErr
+	return ChatResponse{}, lastErr
 }
 
 // chatOnce performs a single HTTP attempt bounded by its own timeout-second budget
 // derived from ctx. Scoping the context to this function (rather than the caller's loop)
 // ensures cancel() runs on every path, satisfying go vet's lostcancel check.
-func (l *LLMService) chatOnce(ctx context.Context, a chatAttempt) (string, error) {
+func (l *LLMService) chatOnce(ctx context.Context, a chatAttempt) (ChatResponse, error) {
 	reqCtx, cancel := context.WithTimeout(ctx, time.Duration(a.timeout)*time.Second)
 	defer cancel()
 
 	resp, err := a.provider.Chat(reqCtx, a.request)
 	if err != nil {
-		return "", apperr.RewriteTimeoutSeconds(err, a.timeout)
+		return ChatResponse{}, apperr.RewriteTimeoutSeconds(err, a.timeout)
 	}
-	return resp.Content, nil
+	return resp, nil
 }
 
 // waitBeforeRetry blocks for the backoff delay, aborting immediately if ctx is cancelled
@@ -367,6 +367,22 @@ func TestLLMServiceAPI_GetCompletionResponse(t *testing.T) {
 		assert.Contains(t, response, "test completion response", "Response should contain expected content")
 	})
 
+	t.Run("GetCompletionWithSettingsReportsUsage", func(t *testing.T) {
+		request := &ChatCompletionRequest{
+			Model:    "model-1",
+			Messages: []CompletionRequestMessage{{Role: "user", Content: "Hello"}},
+		}
+		cfg := &settings.Settings{
+			CurrentProviderConfig: *settingsService.providerConfig,
+			InferenceBaseConfig:   settings.InferenceBaseConfig{Timeout: 10},
+		}
+
+		response, err := llmService.GetCompletionWithSettings(context.Background(), cfg, request)
+		require.NoError(t, err, "GetCompletionWithSettings should succeed")
+		assert.Equal(t, "This is a test completion response.", response.Content)
+		assert.Equal(t, TokenUsage{PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30}, response.Usage)
+	})
+
 	// Test with nil request
 	t.Run("NilRequest", func(t *testing.T) {
 		_, err := llmService.GetCompletionResponse(context.Background(), nil)
@@ -16,9 +16,38 @@ func Catalog() []apperr.ActionMeta {
 	return result
 }
 
+// intPtr returns a pointer to v, for ParamSpec bounds.
+func intPtr(v int) *int { return &v }
+
 func buildCatalog() []apperr.ActionMeta {
 	return []apperr.ActionMeta{
 
+		// ── LOCAL — clean-up before model steps (orderRank 5) ────────────────
+		{
+			ID:               "local.whitespace.normalize",
+			Name:             "Normalize whitespace",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Unify line endings, turn non-breaking spaces into spaces, drop zero-width characters and trailing spaces, collapse repeated spaces and blank lines.",
+			OrderRank:        5,
+			ExclusivityGroup: "",
+			Mergeable:        true,
+			Terminal:         false,
+			Requires:         nil,
+		},
+		{
+			ID:               "local.quotes.straight",
+			Name:             "Straight quotes",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Replace typographic quotes and apostrophes with straight ASCII quotes.",
+			OrderRank:        5,
+			ExclusivityGroup: ExclQuotes,
+			Mergeable:        true,
+			Terminal:         false,
+			Requires:         nil,
+		},
+
 		// ── REWRITE — proofread (orderRank 10) ───────────────────────────────
 		// Source: original v3 prompt draft — directives-rewrite.md §proofread
 		{
@@ -32,6 +61,7 @@ func buildCatalog() []apperr.ActionMeta {
 			Mergeable:        true,
 			Terminal:         false,
 			Requires:         nil,
+			EditList:         true,
 		},
 		{
 			ID:               "rewrite.proofread.enhanced",
@@ -44,6 +74,7 @@ func buildCatalog() []apperr.ActionMeta {
 			Mergeable:        true,
 			Terminal:         false,
 			Requires:         nil,
+			EditList:         true,
 		},
 		{
 			ID:               "rewrite.proofread.consistency",
@@ -56,6 +87,7 @@ func buildCatalog() []apperr.ActionMeta {
 			Mergeable:        true,
 			Terminal:         false,
 			Requires:         nil,
+			EditList:         true,
 		},
 		{
 			ID:               "rewrite.proofread.readability",
@@ -68,6 +100,7 @@ func buildCatalog() []apperr.ActionMeta {
 			Mergeable:        true,
 			Terminal:         false,
 			Requires:         nil,
+			EditList:         true,
 		},
 		{
 			ID:               "rewrite.proofread.clarification",
@@ -80,6 +113,7 @@ func buildCatalog() []apperr.ActionMeta {
 			Mergeable:        true,
 			Terminal:         false,
 			Requires:         nil,
+			EditList:         true,
 		},
 
 		// ── REWRITE — rewrite-intent (orderRank 20) ──────────────────────────
@@ -792,6 +826,7 @@ func buildCatalog() []apperr.ActionMeta {
 			Family:   FamilyStructure,
 			Directive: "Task: Format the text below as a professional email.\n" +
 				"- Organize into subject line (if derivable), greeting, body paragraphs, and closing.\n" +
+				"- Address the greeting to {{recipient}}.\n" +
 				"- Preserve the message, wording, intent, and the original language. Add no new content, signature details, or claims.\n\n" +
 				"<<<UserText Start>>>\n{{user_text}}\n<<<UserText End>>>\n\n" +
 				"Format: {{user_format}}",
@@ -800,6 +835,9 @@ func buildCatalog() []apperr.ActionMeta {
 			Mergeable:        false,
 			Terminal:         false,
 			Requires:         nil,
+			Params: []apperr.ParamSpec{
+				{Name: "recipient", Type: ParamString, Default: "the recipient named in the text, or a neutral greeting if none is named", Max: intPtr(120), Description: "Name of the person the email is addressed to"},
+			},
 		},
 		{
 			ID:       "structure.doc.blog",
@@ -1047,7 +1085,7 @@ func buildCatalog() []apperr.ActionMeta {
 			Category: CatDocStructure,
 			Family:   FamilyStructure,
 			Directive: "Task: Format the text below as an X (Twitter) post.\n" +
-				"- Keep it concise within roughly 280 characters; if the content cannot fit, format it as a numbered thread.\n" +
+				"- Keep it concise within roughly {{char_limit}} characters; if the content cannot fit, format it as a numbered thread.\n" +
 				"- Preserve the core message and the original language. Add no hashtags or emojis unless already present.\n\n" +
 				"<<<UserText Start>>>\n{{user_text}}\n<<<UserText End>>>\n\n" +
 				"Format: {{user_format}}",
@@ -1056,6 +1094,9 @@ func buildCatalog() []apperr.ActionMeta {
 			Mergeable:        false,
 			Terminal:         false,
 			Requires:         nil,
+			Params: []apperr.ParamSpec{
+				{Name: "char_limit", Type: ParamInt, Default: "280", Min: intPtr(50), Max: intPtr(25000), Description: "Maximum characters per post"},
+			},
 		},
 		{
 			ID:       "structure.doc.instagram",
@@ -1083,6 +1124,7 @@ func buildCatalog() []apperr.ActionMeta {
 			Family:   FamilySummarize,
 			Directive: "Task: Write a concise summary of the text below.\n" +
 				"- Capture the essential ideas faithfully in a short narrative, in your own concise wording.\n" +
+				"- Aim for about {{word_count}} words.\n" +
 				"- Add no facts, opinions, or outside context. Preserve emphasis and the original language.\n\n" +
 				"<<<UserText Start>>>\n{{user_text}}\n<<<UserText End>>>\n\n" +
 				"Format: {{user_format}}",
@@ -1091,6 +1133,9 @@ func buildCatalog() []apperr.ActionMeta {
 			Mergeable:        false,
 			Terminal:         true,
 			Requires:         nil,
+			Params: []apperr.ParamSpec{
+				{Name: "word_count", Type: ParamInt, Default: "120", Min: intPtr(20), Max: intPtr(1000), Description: "Target length of the summary in words"},
+			},
 		},
 		{
 			ID:       "summarize.keypoints",
@@ -1247,6 +1292,118 @@ func buildCatalog() []apperr.ActionMeta {
 			Requires:         []string{ReqOutputLang},
 		},
 
+		// ── LOCAL — finishers, after model steps (orderRank 95–99) ──────────
+		{
+			ID:               "local.quotes.smart",
+			Name:             "Smart quotes",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Replace straight quotes and apostrophes with typographic ones.",
+			OrderRank:        95,
+			ExclusivityGroup: ExclQuotes,
+			Mergeable:        true,
+			Terminal:         false,
+			Finisher:         true,
+			Requires:         nil,
+		},
+		{
+			ID:               "local.case.sentence",
+			Name:             "Sentence case",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Lower-case the text and capitalize the first letter of every sentence and line.",
+			OrderRank:        95,
+			ExclusivityGroup: ExclCase,
+			Mergeable:        true,
+			Terminal:         false,
+			Finisher:         true,
+			Requires:         nil,
+		},
+		{
+			ID:               "local.case.title",
+			Name:             "Title case",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Capitalize every word except short articles, conjunctions and prepositions inside a line.",
+			OrderRank:        95,
+			ExclusivityGroup: ExclCase,
+			Mergeable:        true,
+			Terminal:         false,
+			Finisher:         true,
+			Requires:         nil,
+		},
+		{
+			ID:               "local.markdown.strip",
+			Name:             "Strip Markdown",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Remove Markdown syntax — headings, emphasis, links, images, code marks, quotes, bullets and rules — keeping the plain text.",
+			OrderRank:        95,
+			ExclusivityGroup: "",
+			Mergeable:        true,
+			Terminal:         false,
+			Finisher:         true,
+			Requires:         nil,
+		},
+		{
+			ID:               "local.lines.dedupe",
+			Name:             "Remove duplicate lines",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Remove repeated lines, keeping the first occurrence of each; blank lines are kept.",
+			OrderRank:        96,
+			ExclusivityGroup: "",
+			Mergeable:        true,
+			Terminal:         false,
+			Finisher:         true,
+			Requires:         nil,
+		},
+		{
+			ID:               "local.lines.sort",
+			Name:             "Sort lines",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Sort the non-blank lines alphabetically, ignoring case, in {{order}} order; blank lines are dropped.",
+			OrderRank:        97,
+			ExclusivityGroup: "",
+			Mergeable:        true,
+			Terminal:         false,
+			Finisher:         true,
+			Requires:         nil,
+			Params: []apperr.ParamSpec{
+				{Name: "order", Type: ParamEnum, Default: "ascending", Options: []string{"ascending", "descending"}, Description: "Sort direction"},
+			},
+		},
+		{
+			ID:               "local.wrap",
+			Name:             "Wrap lines",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Wrap every line longer than {{width}} characters at word boundaries, keeping its indentation.",
+			OrderRank:        98,
+			ExclusivityGroup: "",
+			Mergeable:        true,
+			Terminal:         false,
+			Finisher:         true,
+			Requires:         nil,
+			Params: []apperr.ParamSpec{
+				{Name: "width", Type: ParamInt, Default: "80", Min: intPtr(20), Max: intPtr(200), Description: "Maximum line length in characters"},
+			},
+		},
+		{
+			ID:               "local.stats",
+			Name:             "Word and character count",
+			Category:         CatTextTools,
+			Family:           FamilyLocal,
+			Directive:        "Replace the text with its word, character, line and paragraph counts.",
+			OrderRank:        99,
+			ExclusivityGroup: "",
+			Mergeable:        false,
+			Terminal:         true,
+			Finisher:         true,
+			Requires:         nil,
+		},
+
 		// ── PROMPT ENGINEERING (orderRank 100, terminal, standalone) ─────────
 		// Source: original v3 prompt draft — templates-prompt-engineering.md
 
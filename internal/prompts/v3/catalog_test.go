@@ -1,6 +1,7 @@
 package v3_test
 
 import (
+	"strconv"
 	"strings"
 	"testing"
 
@@ -9,7 +10,7 @@ import (
 )
 
 func TestCatalog_ExactCount(t *testing.T) {
-	const wantCount = 91
+	const wantCount = 101
 	got := v3.Catalog()
 	if len(got) != wantCount {
 		t.Errorf("Catalog() len = %d, want %d", len(got), wantCount)
@@ -83,6 +84,37 @@ func TestCatalog_RequiresTokensAreKnown(t *testing.T) {
 	}
 }
 
+// TestCatalog_ParamSpecsAreWellFormed guards the composer's generic {{param}}
+// substitution: every declared parameter must have a known type, appear as a token
+// in its directive, and (when it has one) a default that passes its own bounds.
+func TestCatalog_ParamSpecsAreWellFormed(t *testing.T) {
+	for _, a := range v3.Catalog() {
+		for _, p := range a.Params {
+			switch p.Type {
+			case v3.ParamString, v3.ParamInt, v3.ParamEnum:
+			default:
+				t.Errorf("action %q param %q has unknown type %q", a.ID, p.Name, p.Type)
+			}
+			if !strings.Contains(a.Directive, "{{"+p.Name+"}}") {
+				t.Errorf("action %q param %q has no {{%s}} token in its directive", a.ID, p.Name, p.Name)
+			}
+			if strings.TrimSpace(p.Description) == "" {
+				t.Errorf("action %q param %q has empty Description", a.ID, p.Name)
+			}
+			if p.Type == v3.ParamInt && p.Default != "" {
+				n, err := strconv.Atoi(p.Default)
+				if err != nil {
+					t.Errorf("action %q param %q default %q is not an integer", a.ID, p.Name, p.Default)
+					continue
+				}
+				if (p.Min != nil && n < *p.Min) || (p.Max != nil && n > *p.Max) {
+					t.Errorf("action %q param %q default %d is out of bounds", a.ID, p.Name, n)
+				}
+			}
+		}
+	}
+}
+
 func TestCatalog_DefensiveCopy(t *testing.T) {
 	c1 := v3.Catalog()
 	c2 := v3.Catalog()
@@ -101,6 +133,7 @@ type wantMeta struct {
 	exclusivity string
 	mergeable   bool
 	terminal    bool
+	finisher    bool
 	orderRank   int
 	requires    []string
 }
@@ -128,6 +161,14 @@ func TestCatalog_MetadataCorrectness(t *testing.T) {
 	pe := func(req []string) wantMeta {
 		return wantMeta{family: "prompteng", category: "Prompt Engineering", exclusivity: "prompteng", mergeable: false, terminal: true, orderRank: 100, requires: req}
 	}
+	loc := func(excl string, rank int) wantMeta {
+		return wantMeta{family: "local", category: "Text Tools", exclusivity: excl, mergeable: true, terminal: false, orderRank: rank}
+	}
+	fin := func(excl string, rank int) wantMeta {
+		w := loc(excl, rank)
+		w.finisher = true
+		return w
+	}
 
 	tests := []struct {
 		id   string
@@ -233,6 +274,17 @@ func TestCatalog_MetadataCorrectness(t *testing.T) {
 		{"prompteng.text.expand", pe(nil)},
 		{"prompteng.image", pe([]string{"target_model", "goal"})},
 		{"prompteng.video", pe([]string{"target_model"})},
+		// local — in-process, mergeable with each other; clean-up first, finishing last
+		{"local.whitespace.normalize", loc("", 5)},
+		{"local.quotes.straight", loc("quotes", 5)},
+		{"local.quotes.smart", fin("quotes", 95)},
+		{"local.case.sentence", fin("case", 95)},
+		{"local.case.title", fin("case", 95)},
+		{"local.markdown.strip", fin("", 95)},
+		{"local.lines.dedupe", fin("", 96)},
+		{"local.lines.sort", fin("", 97)},
+		{"local.wrap", fin("", 98)},
+		{"local.stats", wantMeta{family: "local", category: "Text Tools", mergeable: false, terminal: true, finisher: true, orderRank: 99}},
 	}
 
 	for _, tt := range tests {
@@ -260,6 +312,9 @@ func assertActionFields(t *testing.T, a apperr.ActionMeta, w wantMeta) {
 	if a.Terminal != w.terminal {
 		t.Errorf("Terminal = %v, want %v", a.Terminal, w.terminal)
 	}
+	if a.Finisher != w.finisher {
+		t.Errorf("Finisher = %v, want %v", a.Finisher, w.finisher)
+	}
 	if a.ExclusivityGroup != w.exclusivity {
 		t.Errorf("ExclusivityGroup = %q, want %q", a.ExclusivityGroup, w.exclusivity)
 	}
@@ -7,6 +7,9 @@ const (
 	FamilySummarize = "summarize"
 	FamilyTranslate = "translate"
 	FamilyPromptEng = "prompteng"
+	// FamilyLocal actions run in-process without a model; their Directive only
+	// describes the transformation.
+	FamilyLocal = "local"
 )
 
 // Category constants used in ActionMeta.Category for UI grouping.
@@ -20,6 +23,7 @@ const (
 	CatSummarize    = "Summarization"
 	CatTranslate    = "Translation"
 	CatPromptEng    = "Prompt Engineering"
+	CatTextTools    = "Text Tools"
 )
 
 // Exclusivity group constants — actions sharing a group are mutually exclusive (one per stack).
@@ -33,6 +37,8 @@ const (
 	ExclSummarize     = "summarize"
 	ExclTranslate     = "translate"
 	ExclPromptEng     = "prompteng"
+	ExclQuotes        = "quotes"
+	ExclCase          = "case"
 	// structure.format actions use ExclusivityGroup = "" (composable, per templates-structure.md:9).
 )
 
@@ -43,3 +49,18 @@ const (
 	ReqTargetModel = "target_model"
 	ReqGoal        = "goal"
 )
+
+// Step output modes used in apperr.ChainStep.Output. OutputEdits is accepted
+// only by actions with ActionMeta.EditList set.
+const (
+	OutputText  = "text"
+	OutputEdits = "edits"
+)
+
+// Param type constants used in apperr.ParamSpec.Type. A parameter named "x" is
+// substituted into the directive wherever the {{x}} token appears.
+const (
+	ParamString = "string"
+	ParamInt    = "int"
+	ParamEnum   = "enum"
+)
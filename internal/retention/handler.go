@@ -0,0 +1,65 @@
+package retention
+
+import (
+	"fmt"
+
+	"go_text/internal/apperr"
+	"go_text/internal/logging"
+
+	"github.com/rs/zerolog"
+)
+
+const panicMsgFmt = "panic: %v"
+
+// RetentionHandler is the Wails-bound handler for history and task log retention.
+// All bound methods follow the envelope pattern: return apperr.*Result,
+// no error return, and include defer/recover for panic safety.
+type RetentionHandler struct {
+	appLogger *logging.Logger
+	service   RetentionServiceAPI
+}
+
+// NewRetentionHandler constructs a RetentionHandler.
+func NewRetentionHandler(appLogger *logging.Logger, service RetentionServiceAPI) *RetentionHandler {
+	return &RetentionHandler{appLogger: appLogger, service: service}
+}
+
+// liveZlog returns a live snapshot of the app logger's current writer, or a
+// no-op logger if appLogger has not been wired (e.g. bare struct-literal
+// tests exercising panic recovery).
+func (h *RetentionHandler) liveZlog() zerolog.Logger {
+	if h.appLogger != nil {
+		return h.appLogger.ZeroLogger()
+	}
+	return zerolog.Nop()
+}
+
+// PurgeNow applies the retention rules immediately and reports what was deleted.
+func (h *RetentionHandler) PurgeNow() (res apperr.RetentionReportResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.RetentionReportResult{Error: &wire}
+		}
+	}()
+	report, err := h.service.Purge(TriggerManual)
+	if err != nil {
+		wire := apperr.ToWire(h.liveZlog(), err)
+		return apperr.RetentionReportResult{Error: &wire}
+	}
+	return apperr.RetentionReportResult{Data: report}
+}
+
+// GetLastRetentionReport returns the report of the latest retention pass —
+// startup, periodic or manual — with no data before the first one finishes.
+func (h *RetentionHandler) GetLastRetentionReport() (res apperr.RetentionReportResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			ae := apperr.Internal(fmt.Errorf(panicMsgFmt, r))
+			wire := apperr.ToWire(h.liveZlog(), ae)
+			res = apperr.RetentionReportResult{Error: &wire}
+		}
+	}()
+	return apperr.RetentionReportResult{Data: h.service.LastReport()}
+}
@@ -0,0 +1,67 @@
+package retention
+
+import (
+	"errors"
+	"testing"
+
+	"go_text/internal/apperr"
+)
+
+// mockRetentionService satisfies RetentionServiceAPI.
+type mockRetentionService struct {
+	report  *apperr.RetentionReport
+	err     error
+	trigger string
+	panics  bool
+}
+
+func (m *mockRetentionService) Purge(trigger string) (*apperr.RetentionReport, error) {
+	if m.panics {
+		panic("boom")
+	}
+	m.trigger = trigger
+	return m.report, m.err
+}
+
+func (m *mockRetentionService) LastReport() *apperr.RetentionReport {
+	if m.panics {
+		panic("boom")
+	}
+	return m.report
+}
+
+func TestRetentionHandler_PurgeNow(t *testing.T) {
+	svc := &mockRetentionService{report: &apperr.RetentionReport{HistoryByAge: 2, TaskLogFiles: []string{"tasks-2026-01-01.jsonl"}}}
+	res := NewRetentionHandler(nil, svc).PurgeNow()
+	if res.Error != nil || res.Data != svc.report {
+		t.Errorf("PurgeNow = %+v", res)
+	}
+	if svc.trigger != TriggerManual {
+		t.Errorf("trigger = %q, want %q", svc.trigger, TriggerManual)
+	}
+
+	res = NewRetentionHandler(nil, &mockRetentionService{err: errors.New("db fail")}).PurgeNow()
+	if res.Error == nil || res.Error.Code != apperr.CodeInternal {
+		t.Errorf("PurgeNow error = %+v, want internal", res.Error)
+	}
+}
+
+func TestRetentionHandler_GetLastRetentionReport(t *testing.T) {
+	if res := NewRetentionHandler(nil, &mockRetentionService{}).GetLastRetentionReport(); res.Error != nil || res.Data != nil {
+		t.Errorf("before any pass = %+v, want no data", res)
+	}
+	report := &apperr.RetentionReport{Trigger: TriggerStartup}
+	if res := NewRetentionHandler(nil, &mockRetentionService{report: report}).GetLastRetentionReport(); res.Data != report {
+		t.Errorf("GetLastRetentionReport = %+v", res)
+	}
+}
+
+func TestRetentionHandler_RecoversPanics(t *testing.T) {
+	h := NewRetentionHandler(nil, &mockRetentionService{panics: true})
+	if res := h.PurgeNow(); res.Error == nil || res.Error.Code != apperr.CodeInternal {
+		t.Errorf("PurgeNow = %+v, want internal error", res)
+	}
+	if res := h.GetLastRetentionReport(); res.Error == nil {
+		t.Error("GetLastRetentionReport: expected error")
+	}
+}
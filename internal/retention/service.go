@@ -0,0 +1,353 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go_text/internal/apperr"
+	"go_text/internal/logging"
+	"go_text/internal/settings"
+)
+
+// Retention pass triggers (apperr.RetentionReport.Trigger).
+const (
+	TriggerStartup  = "startup"
+	TriggerPeriodic = "periodic"
+	TriggerManual   = "manual"
+)
+
+// DefaultInterval is how often Start applies the retention rules after its
+// startup pass.
+const DefaultInterval = 6 * time.Hour
+
+// Task log files are named tasks-YYYY-MM-DD.jsonl after their UTC day, as
+// written by tasklog.TaskLogService.
+const (
+	taskLogPrefix     = "tasks-"
+	taskLogSuffix     = ".jsonl"
+	taskLogDateLayout = "2006-01-02"
+)
+
+// retentionSettingsAPI is the minimal contract RetentionService needs from the settings service.
+type retentionSettingsAPI interface {
+	GetAppBehaviorConfig() (*settings.AppBehaviorConfig, error)
+	GetLoggingConfig() (*settings.LoggingConfig, error)
+}
+
+// logsFolderAPI resolves the folder task logs are written to.
+// Implemented by *file.FileUtilsService.
+type logsFolderAPI interface {
+	ResolveAppLogsFolderPath(customDir string) (string, error)
+}
+
+// HistoryPrunerAPI is the minimal contract RetentionService needs from the
+// history store. Both spare pinned and favorite entries.
+// Implemented by *history.SqliteHistoryRepository.
+type HistoryPrunerAPI interface {
+	PruneOlderThan(cutoff int64) (int64, error)
+	PruneToSize(maxBytes int64) (int64, error)
+}
+
+// CheckpointPrunerAPI deletes chain checkpoints by age.
+// Implemented by *actions.SqliteCheckpointRepository.
+type CheckpointPrunerAPI interface {
+	PruneOlderThan(cutoff int64) (int64, error)
+}
+
+// BatchPrunerAPI deletes completed batch jobs by age; unfinished jobs are kept.
+// Implemented by *batch.SqliteBatchRepository.
+type BatchPrunerAPI interface {
+	PruneCompletedOlderThan(cutoff int64) (int64, error)
+}
+
+// RetentionServiceAPI is the contract consumed by RetentionHandler.
+type RetentionServiceAPI interface {
+	// Purge applies the retention rules now and reports what it deleted.
+	Purge(trigger string) (*apperr.RetentionReport, error)
+	// LastReport returns the report of the latest pass, nil before the first.
+	LastReport() *apperr.RetentionReport
+}
+
+// RetentionService applies the age and size rules of AppBehaviorConfig to
+// history entries, chain checkpoints, completed batch jobs and task log files.
+// Passes never overlap. The SQLite pruners are nil until Init wires them; their
+// rules are skipped until then.
+type RetentionService struct {
+	logger      *logging.Logger
+	settings    retentionSettingsAPI
+	files       logsFolderAPI
+	history     HistoryPrunerAPI
+	checkpoints CheckpointPrunerAPI
+	batches     BatchPrunerAPI
+	now         func() time.Time
+
+	mu     sync.Mutex
+	last   *apperr.RetentionReport
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRetentionService constructs a RetentionService. Panics on nil dependencies.
+// Returns *RetentionService (concrete) so ApplicationContextHolder can call
+// the Set*Pruner setters, Start and Stop.
+func NewRetentionService(appLogger *logging.Logger, settingsService retentionSettingsAPI, files logsFolderAPI) *RetentionService {
+	const op = "RetentionService.NewRetentionService"
+	if appLogger == nil {
+		panic(fmt.Sprintf("%s: logger cannot be nil", op))
+	}
+	if settingsService == nil {
+		panic(fmt.Sprintf("%s: settings service cannot be nil", op))
+	}
+	if files == nil {
+		panic(fmt.Sprintf("%s: file utils service cannot be nil", op))
+	}
+	return &RetentionService{
+		logger:   appLogger,
+		settings: settingsService,
+		files:    files,
+		now:      time.Now,
+	}
+}
+
+// SetHistoryPruner wires the SQLite-backed history store after the DB is open.
+// Called from ApplicationContextHolder.Init.
+func (s *RetentionService) SetHistoryPruner(history HistoryPrunerAPI) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = history
+}
+
+// SetCheckpointPruner wires the SQLite-backed checkpoint store.
+// Called from ApplicationContextHolder.Init.
+func (s *RetentionService) SetCheckpointPruner(checkpoints CheckpointPrunerAPI) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints = checkpoints
+}
+
+// SetBatchPruner wires the SQLite-backed batch job store.
+// Called from ApplicationContextHolder.Init.
+func (s *RetentionService) SetBatchPruner(batches BatchPrunerAPI) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = batches
+}
+
+// Start runs a retention pass in the background, then one every interval until
+// Stop. Calling Start again while running does nothing.
+func (s *RetentionService) Start(interval time.Duration) {
+	const op = "RetentionService.Start"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	logging.SafeGo(s.logger, op, func() {
+		defer s.wg.Done()
+		s.runPass(TriggerStartup)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runPass(TriggerPeriodic)
+			}
+		}
+	})
+	s.logger.Info(fmt.Sprintf("[%s] retention runs every %s", op, interval))
+}
+
+// Stop ends the background passes and waits for a pass in progress to finish.
+func (s *RetentionService) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+// runPass runs a background pass; a failure is logged, never surfaced.
+func (s *RetentionService) runPass(trigger string) {
+	const op = "RetentionService.runPass"
+	if _, err := s.Purge(trigger); err != nil {
+		s.logger.Warning(fmt.Sprintf("[%s] %s retention pass failed: %v", op, trigger, err))
+	}
+}
+
+// LastReport returns the report of the latest pass, nil before the first.
+func (s *RetentionService) LastReport() *apperr.RetentionReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Purge deletes the history entries, chain checkpoints and completed batch jobs
+// older than HistoryMaxAgeDays, then the oldest history entries beyond
+// HistoryMaxSizeMB, then the task log files past
+// TaskLogMaxAgeDays or, oldest first, beyond TaskLogMaxSizeMB. Today's task
+// log is never deleted. A rule set to 0 is not applied.
+func (s *RetentionService) Purge(trigger string) (*apperr.RetentionReport, error) {
+	const op = "RetentionService.Purge"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, err := s.settings.GetAppBehaviorConfig()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	now := s.now()
+	report := &apperr.RetentionReport{RanAt: now.Unix(), Trigger: trigger, TaskLogFiles: []string{}}
+
+	if cfg.HistoryMaxAgeDays > 0 {
+		if err := s.pruneByAge(now.AddDate(0, 0, -cfg.HistoryMaxAgeDays).Unix(), report); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if s.history != nil {
+		if cfg.HistoryMaxSizeMB > 0 {
+			n, err := s.history.PruneToSize(int64(cfg.HistoryMaxSizeMB) << 20)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			report.HistoryBySize = int(n)
+		}
+	}
+	if cfg.TaskLogMaxAgeDays > 0 || cfg.TaskLogMaxSizeMB > 0 {
+		if err := s.purgeTaskLogs(cfg, now, report); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	s.last = report
+	s.logger.Info(fmt.Sprintf("[%s] %s pass deleted %d history entries by age, %d by size, %d checkpoints, %d batch jobs, %d task logs (%d bytes)",
+		op, trigger, report.HistoryByAge, report.HistoryBySize, report.CheckpointsByAge, report.BatchJobsByAge,
+		len(report.TaskLogFiles), report.TaskLogBytes))
+	return report, nil
+}
+
+// pruneByAge applies the history age rule, cutoff in Unix seconds, to every
+// wired SQLite store and records the counts on report.
+func (s *RetentionService) pruneByAge(cutoff int64, report *apperr.RetentionReport) error {
+	if s.history != nil {
+		n, err := s.history.PruneOlderThan(cutoff)
+		if err != nil {
+			return err
+		}
+		report.HistoryByAge = int(n)
+	}
+	if s.checkpoints != nil {
+		n, err := s.checkpoints.PruneOlderThan(cutoff)
+		if err != nil {
+			return err
+		}
+		report.CheckpointsByAge = int(n)
+	}
+	if s.batches != nil {
+		n, err := s.batches.PruneCompletedOlderThan(cutoff)
+		if err != nil {
+			return err
+		}
+		report.BatchJobsByAge = int(n)
+	}
+	return nil
+}
+
+// taskLog is one tasks-YYYY-MM-DD.jsonl file of the logs folder.
+type taskLog struct {
+	name string
+	day  time.Time
+	size int64
+}
+
+// purgeTaskLogs deletes task log files and records them on report. A file is
+// past the age rule once its whole day is; the size rule keeps the newest files
+// whose sizes add up to at most TaskLogMaxSizeMB. A file that cannot be removed
+// is logged and skipped.
+func (s *RetentionService) purgeTaskLogs(cfg *settings.AppBehaviorConfig, now time.Time, report *apperr.RetentionReport) error {
+	const op = "RetentionService.purgeTaskLogs"
+	logCfg, err := s.settings.GetLoggingConfig()
+	if err != nil {
+		return err
+	}
+	var customDir string
+	if logCfg != nil {
+		customDir = logCfg.LogDirectory
+	}
+	dir, err := s.files.ResolveAppLogsFolderPath(customDir)
+	if err != nil {
+		return err
+	}
+	logs, err := listTaskLogs(dir)
+	if err != nil {
+		return err
+	}
+
+	today := taskLogPrefix + now.UTC().Format(taskLogDateLayout) + taskLogSuffix
+	cutoff := now.UTC().AddDate(0, 0, -cfg.TaskLogMaxAgeDays)
+	maxBytes := int64(cfg.TaskLogMaxSizeMB) << 20
+	var kept int64
+	for _, l := range logs {
+		kept += l.size
+		expired := cfg.TaskLogMaxAgeDays > 0 && l.day.AddDate(0, 0, 1).Before(cutoff)
+		oversize := maxBytes > 0 && kept > maxBytes
+		if l.name == today || (!expired && !oversize) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, l.name)); err != nil {
+			s.logger.Warning(fmt.Sprintf("[%s] failed to remove %s: %v", op, l.name, err))
+			continue
+		}
+		kept -= l.size
+		report.TaskLogFiles = append(report.TaskLogFiles, l.name)
+		report.TaskLogBytes += l.size
+	}
+	sort.Strings(report.TaskLogFiles)
+	return nil
+}
+
+// listTaskLogs returns the task log files of dir, newest first. A missing dir
+// has none; other files are ignored.
+func listTaskLogs(dir string) ([]taskLog, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", dir, err)
+	}
+	var logs []taskLog
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, taskLogPrefix) || !strings.HasSuffix(name, taskLogSuffix) {
+			continue
+		}
+		day, err := time.Parse(taskLogDateLayout, strings.TrimSuffix(strings.TrimPrefix(name, taskLogPrefix), taskLogSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		logs = append(logs, taskLog{name: name, day: day, size: info.Size()})
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].day.After(logs[j].day) })
+	return logs, nil
+}
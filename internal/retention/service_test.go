@@ -0,0 +1,267 @@
+package retention
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go_text/internal/logging"
+	"go_text/internal/settings"
+)
+
+type fakeSettings struct {
+	cfg    settings.AppBehaviorConfig
+	logDir string
+	err    error
+}
+
+func (f *fakeSettings) GetAppBehaviorConfig() (*settings.AppBehaviorConfig, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	cfg := f.cfg
+	return &cfg, nil
+}
+
+func (f *fakeSettings) GetLoggingConfig() (*settings.LoggingConfig, error) {
+	return &settings.LoggingConfig{LogDirectory: f.logDir}, nil
+}
+
+// fakeFiles resolves the logs folder to the custom directory it is given.
+type fakeFiles struct{}
+
+func (fakeFiles) ResolveAppLogsFolderPath(customDir string) (string, error) { return customDir, nil }
+
+// fakePruner records the arguments it was called with.
+type fakePruner struct {
+	cutoff   int64
+	maxBytes int64
+	n        int64
+	err      error
+}
+
+func (f *fakePruner) PruneOlderThan(cutoff int64) (int64, error) {
+	f.cutoff = cutoff
+	return f.n, f.err
+}
+
+func (f *fakePruner) PruneToSize(maxBytes int64) (int64, error) {
+	f.maxBytes = maxBytes
+	return f.n, f.err
+}
+
+// fakeBatchPruner records the cutoff it was called with.
+type fakeBatchPruner struct {
+	cutoff int64
+	n      int64
+}
+
+func (f *fakeBatchPruner) PruneCompletedOlderThan(cutoff int64) (int64, error) {
+	f.cutoff = cutoff
+	return f.n, nil
+}
+
+var testNow = time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+
+func newTestService(t *testing.T, cfg settings.AppBehaviorConfig) (*RetentionService, *fakeSettings) {
+	t.Helper()
+	wlog, err := logging.New(logging.DefaultConfig(), false)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	st := &fakeSettings{cfg: cfg, logDir: t.TempDir()}
+	svc := NewRetentionService(wlog, st, fakeFiles{})
+	svc.now = func() time.Time { return testNow }
+	t.Cleanup(svc.Stop)
+	return svc, st
+}
+
+// writeTaskLogs creates a task log of size bytes for each day.
+func writeTaskLogs(t *testing.T, dir string, size int, days ...string) {
+	t.Helper()
+	for _, day := range days {
+		path := filepath.Join(dir, "tasks-"+day+".jsonl")
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+}
+
+func remainingFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestRetentionService_Purge_History(t *testing.T) {
+	svc, _ := newTestService(t, settings.AppBehaviorConfig{HistoryMaxAgeDays: 10, HistoryMaxSizeMB: 2})
+	pruner := &fakePruner{n: 3}
+	checkpoints := &fakePruner{n: 2}
+	batches := &fakeBatchPruner{n: 1}
+	svc.SetHistoryPruner(pruner)
+	svc.SetCheckpointPruner(checkpoints)
+	svc.SetBatchPruner(batches)
+
+	report, err := svc.Purge(TriggerManual)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	want := testNow.AddDate(0, 0, -10).Unix()
+	if pruner.cutoff != want || checkpoints.cutoff != want || batches.cutoff != want {
+		t.Errorf("cutoffs = %d/%d/%d, want %d", pruner.cutoff, checkpoints.cutoff, batches.cutoff, want)
+	}
+	if pruner.maxBytes != 2<<20 {
+		t.Errorf("maxBytes = %d, want %d", pruner.maxBytes, 2<<20)
+	}
+	if report.HistoryByAge != 3 || report.HistoryBySize != 3 || report.CheckpointsByAge != 2 || report.BatchJobsByAge != 1 || report.Trigger != TriggerManual || report.RanAt != testNow.Unix() {
+		t.Errorf("report = %+v", report)
+	}
+	if svc.LastReport() != report {
+		t.Error("LastReport is not the latest report")
+	}
+}
+
+func TestRetentionService_Purge_NoLimits(t *testing.T) {
+	svc, st := newTestService(t, settings.AppBehaviorConfig{})
+	pruner := &fakePruner{}
+	checkpoints := &fakePruner{}
+	batches := &fakeBatchPruner{}
+	svc.SetHistoryPruner(pruner)
+	svc.SetCheckpointPruner(checkpoints)
+	svc.SetBatchPruner(batches)
+	writeTaskLogs(t, st.logDir, 10, "2020-01-01")
+
+	report, err := svc.Purge(TriggerManual)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if pruner.cutoff != 0 || pruner.maxBytes != 0 {
+		t.Errorf("history pruned without a rule: %+v", pruner)
+	}
+	if checkpoints.cutoff != 0 || batches.cutoff != 0 {
+		t.Errorf("checkpoints or batch jobs pruned without a rule: %+v %+v", checkpoints, batches)
+	}
+	if len(report.TaskLogFiles) != 0 || len(remainingFiles(t, st.logDir)) != 1 {
+		t.Errorf("task logs deleted without a rule: %+v", report)
+	}
+}
+
+func TestRetentionService_Purge_TaskLogs(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       settings.AppBehaviorConfig
+		wantGone  []string
+		wantBytes int64
+	}{
+		{
+			name:      "age keeps files whose day is within the rule",
+			cfg:       settings.AppBehaviorConfig{TaskLogMaxAgeDays: 3},
+			wantGone:  []string{"tasks-2026-03-01.jsonl", "tasks-2026-03-10.jsonl", "tasks-2026-03-11.jsonl"},
+			wantBytes: 3 << 19,
+		},
+		{
+			name:      "size keeps the newest files that fit",
+			cfg:       settings.AppBehaviorConfig{TaskLogMaxSizeMB: 1},
+			wantGone:  []string{"tasks-2026-03-01.jsonl", "tasks-2026-03-10.jsonl", "tasks-2026-03-11.jsonl", "tasks-2026-03-12.jsonl"},
+			wantBytes: 4 << 19,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, st := newTestService(t, tt.cfg)
+			// Half a megabyte each; "notes.jsonl" and a malformed name are not task logs.
+			writeTaskLogs(t, st.logDir, 1<<19, "2026-03-01", "2026-03-10", "2026-03-11", "2026-03-12", "2026-03-14", "2026-03-15")
+			writeTaskLogs(t, st.logDir, 1<<19, "someday")
+			if err := os.WriteFile(filepath.Join(st.logDir, "notes.jsonl"), []byte("x"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := svc.Purge(TriggerManual)
+			if err != nil {
+				t.Fatalf("Purge: %v", err)
+			}
+			if !reflect.DeepEqual(report.TaskLogFiles, tt.wantGone) {
+				t.Errorf("TaskLogFiles = %v, want %v", report.TaskLogFiles, tt.wantGone)
+			}
+			if report.TaskLogBytes != tt.wantBytes {
+				t.Errorf("TaskLogBytes = %d, want %d", report.TaskLogBytes, tt.wantBytes)
+			}
+			remaining := remainingFiles(t, st.logDir)
+			for _, name := range tt.wantGone {
+				for _, r := range remaining {
+					if r == name {
+						t.Errorf("%s still on disk", name)
+					}
+				}
+			}
+			if len(remaining) != 8-len(tt.wantGone) {
+				t.Errorf("remaining = %v", remaining)
+			}
+		})
+	}
+}
+
+func TestRetentionService_Purge_TodayOverSize(t *testing.T) {
+	svc, st := newTestService(t, settings.AppBehaviorConfig{TaskLogMaxSizeMB: 1})
+	writeTaskLogs(t, st.logDir, 2<<20, "2026-03-15")
+
+	report, err := svc.Purge(TriggerManual)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if len(report.TaskLogFiles) != 0 {
+		t.Errorf("deleted %v, want today's file kept", report.TaskLogFiles)
+	}
+}
+
+func TestRetentionService_Purge_MissingLogsFolder(t *testing.T) {
+	svc, st := newTestService(t, settings.AppBehaviorConfig{TaskLogMaxAgeDays: 1})
+	st.logDir = filepath.Join(st.logDir, "missing")
+	if _, err := svc.Purge(TriggerManual); err != nil {
+		t.Errorf("Purge: %v", err)
+	}
+}
+
+func TestRetentionService_Purge_Errors(t *testing.T) {
+	svc, st := newTestService(t, settings.AppBehaviorConfig{HistoryMaxAgeDays: 1})
+	svc.SetHistoryPruner(&fakePruner{err: errors.New("db fail")})
+	if _, err := svc.Purge(TriggerManual); err == nil {
+		t.Error("Purge: expected history error")
+	}
+	st.err = errors.New("settings fail")
+	if _, err := svc.Purge(TriggerManual); err == nil {
+		t.Error("Purge: expected settings error")
+	}
+	if svc.LastReport() != nil {
+		t.Error("failed passes must not replace the last report")
+	}
+}
+
+func TestRetentionService_StartStop(t *testing.T) {
+	svc, st := newTestService(t, settings.AppBehaviorConfig{TaskLogMaxAgeDays: 1})
+	writeTaskLogs(t, st.logDir, 10, "2026-01-01")
+
+	svc.Start(time.Hour)
+	svc.Start(time.Hour) // no second loop
+	deadline := time.Now().Add(5 * time.Second)
+	for svc.LastReport() == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	svc.Stop()
+	report := svc.LastReport()
+	if report == nil || report.Trigger != TriggerStartup || len(report.TaskLogFiles) != 1 {
+		t.Fatalf("startup report = %+v", report)
+	}
+	svc.Stop() // idempotent
+}
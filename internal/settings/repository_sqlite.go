@@ -362,9 +362,20 @@ func (r *SqliteSettingsRepository) UpdateModelConfig(cfg *ModelConfig) error {
 
 func (r *SqliteSettingsRepository) GetAppBehaviorConfig() (*AppBehaviorConfig, error) {
 	return &AppBehaviorConfig{
-		EnableTaskLogging: r.getBool("app.enableTaskLogging", false),
-		HistoryEnabled:    r.getBool("history.enabled", true),
-		HistoryMaxEntries: r.getInt("history.maxEntries", 100),
+		EnableTaskLogging:   r.getBool("app.enableTaskLogging", false),
+		HistoryEnabled:      r.getBool("history.enabled", true),
+		HistoryMaxEntries:   r.getInt("history.maxEntries", 100),
+		MaxPlanSteps:        r.getInt("plan.maxSteps", DefaultMaxPlanSteps),
+		MaxPlanInferences:   r.getInt("plan.maxInferences", DefaultMaxPlanInferences),
+		MaxParallelBranches: r.getInt("chain.maxParallelBranches", DefaultMaxParallelBranches),
+		LanguageCheck:       r.getString("chain.languageCheck", DefaultLanguageCheck),
+		CleanOutput:         r.getBool("chain.cleanOutput", false),
+		BatchConcurrency:    r.getInt("batch.concurrency", DefaultBatchConcurrency),
+		ExportFileName:      r.getString("export.fileNameTemplate", DefaultExportFileName),
+		HistoryMaxAgeDays:   r.getInt("history.maxAgeDays", 0),
+		HistoryMaxSizeMB:    r.getInt("history.maxSizeMB", 0),
+		TaskLogMaxAgeDays:   r.getInt("tasklog.maxAgeDays", DefaultTaskLogMaxAgeDays),
+		TaskLogMaxSizeMB:    r.getInt("tasklog.maxSizeMB", 0),
 	}, nil
 }
 
@@ -373,6 +384,17 @@ func (r *SqliteSettingsRepository) UpdateAppBehaviorConfig(cfg *AppBehaviorConfi
 		{Key: "app.enableTaskLogging", Value: strconv.FormatBool(cfg.EnableTaskLogging), Type: "bool"},
 		{Key: "history.enabled", Value: strconv.FormatBool(cfg.HistoryEnabled), Type: "bool"},
 		{Key: "history.maxEntries", Value: strconv.Itoa(cfg.HistoryMaxEntries), Type: "int"},
+		{Key: "plan.maxSteps", Value: strconv.Itoa(cfg.MaxPlanSteps), Type: "int"},
+		{Key: "plan.maxInferences", Value: strconv.Itoa(cfg.MaxPlanInferences), Type: "int"},
+		{Key: "chain.maxParallelBranches", Value: strconv.Itoa(cfg.MaxParallelBranches), Type: "int"},
+		{Key: "chain.languageCheck", Value: cfg.LanguageCheck, Type: "string"},
+		{Key: "chain.cleanOutput", Value: strconv.FormatBool(cfg.CleanOutput), Type: "bool"},
+		{Key: "batch.concurrency", Value: strconv.Itoa(cfg.BatchConcurrency), Type: "int"},
+		{Key: "export.fileNameTemplate", Value: cfg.ExportFileName, Type: "string"},
+		{Key: "history.maxAgeDays", Value: strconv.Itoa(cfg.HistoryMaxAgeDays), Type: "int"},
+		{Key: "history.maxSizeMB", Value: strconv.Itoa(cfg.HistoryMaxSizeMB), Type: "int"},
+		{Key: "tasklog.maxAgeDays", Value: strconv.Itoa(cfg.TaskLogMaxAgeDays), Type: "int"},
+		{Key: "tasklog.maxSizeMB", Value: strconv.Itoa(cfg.TaskLogMaxSizeMB), Type: "int"},
 	}
 	for _, row := range rows {
 		if err := r.database.Queries.UpsertSetting(bg(), row); err != nil {
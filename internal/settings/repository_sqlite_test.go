@@ -227,7 +227,7 @@ func TestSqliteSettingsRepository_LoggingConfig_RoundTrip(t *testing.T) {
 func TestSqliteSettingsRepository_AppBehaviorConfig_RoundTrip(t *testing.T) {
 	repo := newRepo(t)
 
-	want := &settings.AppBehaviorConfig{EnableTaskLogging: true, HistoryEnabled: false, HistoryMaxEntries: 50}
+	want := &settings.AppBehaviorConfig{EnableTaskLogging: true, HistoryEnabled: false, HistoryMaxEntries: 50, LanguageCheck: settings.LanguageCheckFail, CleanOutput: true, ExportFileName: "{date} {name}", HistoryMaxAgeDays: 90, TaskLogMaxSizeMB: 64}
 	if err := repo.UpdateAppBehaviorConfig(want); err != nil {
 		t.Fatalf("UpdateAppBehaviorConfig: %v", err)
 	}
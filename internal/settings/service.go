@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"unicode/utf8"
 
 	"go_text/internal/apperr"
 	"go_text/internal/file"
@@ -420,8 +421,8 @@ func (s *SettingsService) SetDefaultInputLanguage(language string) error {
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	if !containsIgnoreCase(langCfg.Languages, language) {
-		return apperr.Validation("language", "one of the configured supported languages", language)
+	if !strings.EqualFold(language, AutoInputLanguage) && !containsIgnoreCase(langCfg.Languages, language) {
+		return apperr.Validation("language", "one of the configured supported languages or \"auto\"", language)
 	}
 	return s.settingsRepo.SetDefaultInputLanguage(language)
 }
@@ -503,12 +504,87 @@ func (s *SettingsService) UpdateAppBehaviorConfig(cfg *AppBehaviorConfig) (*AppB
 	if cfg.HistoryMaxEntries < 10 || cfg.HistoryMaxEntries > 1000 {
 		return nil, apperr.Validation("historyMaxEntries", "10–1000", fmt.Sprintf("%d", cfg.HistoryMaxEntries))
 	}
+	// Zero means "not sent" (clients predating plan limits) — keep the defaults.
+	if cfg.MaxPlanSteps == 0 {
+		cfg.MaxPlanSteps = DefaultMaxPlanSteps
+	}
+	if cfg.MaxPlanInferences == 0 {
+		cfg.MaxPlanInferences = DefaultMaxPlanInferences
+	}
+	if cfg.MaxParallelBranches == 0 {
+		cfg.MaxParallelBranches = DefaultMaxParallelBranches
+	}
+	if cfg.LanguageCheck == "" {
+		cfg.LanguageCheck = DefaultLanguageCheck
+	}
+	if cfg.BatchConcurrency == 0 {
+		cfg.BatchConcurrency = DefaultBatchConcurrency
+	}
+	cfg.ExportFileName = strings.TrimSpace(cfg.ExportFileName)
+	if cfg.ExportFileName == "" {
+		cfg.ExportFileName = DefaultExportFileName
+	}
+	if cfg.MaxPlanSteps < 1 || cfg.MaxPlanSteps > PlanStepsUpperBound {
+		return nil, apperr.Validation("maxPlanSteps", fmt.Sprintf("1–%d", PlanStepsUpperBound), fmt.Sprintf("%d", cfg.MaxPlanSteps))
+	}
+	if cfg.MaxPlanInferences < 1 || cfg.MaxPlanInferences > PlanInferencesUpperBound {
+		return nil, apperr.Validation("maxPlanInferences", fmt.Sprintf("1–%d", PlanInferencesUpperBound), fmt.Sprintf("%d", cfg.MaxPlanInferences))
+	}
+	if cfg.MaxPlanInferences > cfg.MaxPlanSteps {
+		return nil, apperr.Validation("maxPlanInferences", "at most maxPlanSteps", fmt.Sprintf("%d > %d", cfg.MaxPlanInferences, cfg.MaxPlanSteps))
+	}
+	if cfg.MaxParallelBranches < 1 || cfg.MaxParallelBranches > ParallelBranchesUpperBound {
+		return nil, apperr.Validation("maxParallelBranches", fmt.Sprintf("1–%d", ParallelBranchesUpperBound), fmt.Sprintf("%d", cfg.MaxParallelBranches))
+	}
+	if cfg.BatchConcurrency < 1 || cfg.BatchConcurrency > BatchConcurrencyUpperBound {
+		return nil, apperr.Validation("batchConcurrency", fmt.Sprintf("1–%d", BatchConcurrencyUpperBound), fmt.Sprintf("%d", cfg.BatchConcurrency))
+	}
+	if err := validateExportFileName(cfg.ExportFileName); err != nil {
+		return nil, err
+	}
+	// Retention rules: zero is a valid value (no limit), so it is not defaulted.
+	for _, rule := range []struct {
+		field string
+		value int
+		upper int
+	}{
+		{"historyMaxAgeDays", cfg.HistoryMaxAgeDays, RetentionDaysUpperBound},
+		{"historyMaxSizeMB", cfg.HistoryMaxSizeMB, RetentionMBUpperBound},
+		{"taskLogMaxAgeDays", cfg.TaskLogMaxAgeDays, RetentionDaysUpperBound},
+		{"taskLogMaxSizeMB", cfg.TaskLogMaxSizeMB, RetentionMBUpperBound},
+	} {
+		if rule.value < 0 || rule.value > rule.upper {
+			return nil, apperr.Validation(rule.field, fmt.Sprintf("0–%d", rule.upper), fmt.Sprintf("%d", rule.value))
+		}
+	}
+	switch cfg.LanguageCheck {
+	case LanguageCheckOff, LanguageCheckWarn, LanguageCheckFail:
+	default:
+		return nil, apperr.Validation("languageCheck",
+			fmt.Sprintf("one of %s, %s, %s", LanguageCheckOff, LanguageCheckWarn, LanguageCheckFail), cfg.LanguageCheck)
+	}
 	if err := s.settingsRepo.UpdateAppBehaviorConfig(cfg); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return cfg, nil
 }
 
+// validateExportFileName checks an export file name template: at most
+// ExportFileNameMaxLen characters, no path separators, and only the {name},
+// {date} and {time} placeholders.
+func validateExportFileName(template string) error {
+	expected := fmt.Sprintf("a file name of at most %d characters using %s, %s and %s",
+		ExportFileNameMaxLen, ExportNamePlaceholder, ExportDatePlaceholder, ExportTimePlaceholder)
+	if utf8.RuneCountInString(template) > ExportFileNameMaxLen || strings.ContainsAny(template, `/\:*?"<>|`) {
+		return apperr.Validation("exportFileName", expected, template)
+	}
+	rest := strings.NewReplacer(ExportNamePlaceholder, "", ExportDatePlaceholder, "", ExportTimePlaceholder, "").Replace(template)
+	if strings.ContainsAny(rest, "{}") {
+		return apperr.Validation("exportFileName", expected, template)
+	}
+	return nil
+}
+
 func (s *SettingsService) GetUIPreferencesConfig() (*UIPreferencesConfig, error) {
 	return s.settingsRepo.GetUIPreferencesConfig()
 }
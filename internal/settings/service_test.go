@@ -34,6 +34,9 @@ func (stubFileUtils) GetAppSettingsFolderPath() (string, error)        { return
 func (stubFileUtils) GetAppDatabaseFilePath() (string, error)          { return "", nil }
 func (stubFileUtils) ResolveAppLogsFolderPath(string) (string, error)  { return "", nil }
 func (stubFileUtils) EnsureAppLogsFolderExists(string) (string, error) { return "", nil }
+func (stubFileUtils) WriteExportFile(string, string, []byte) (string, error) {
+	return "", nil
+}
 
 // Regression: switching the current provider must sync the active model to the
 // newly-current provider's selected model, so a chain run never inherits a
@@ -251,6 +254,9 @@ func (f ensuringFileUtils) ResolveAppLogsFolderPath(string) (string, error) {
 func (f ensuringFileUtils) EnsureAppLogsFolderExists(string) (string, error) {
 	return f.ensuredDir, nil
 }
+func (ensuringFileUtils) WriteExportFile(string, string, []byte) (string, error) {
+	return "", nil
+}
 
 // Regression: GetAppSettingsMetadata must return a logs folder that exists on
 // disk. Previously it called ResolveAppLogsFolderPath (which never creates the
@@ -542,6 +548,308 @@ func TestSettingsService_UpdateAppBehaviorConfig_HistoryMaxEntriesBoundaries(t *
 	}
 }
 
+func TestSettingsService_UpdateAppBehaviorConfig_PlanLimits(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxSteps       int
+		maxInferences  int
+		wantErr        bool
+		wantSteps      int
+		wantInferences int
+	}{
+		{name: "zero keeps defaults (older clients)", wantSteps: settings.DefaultMaxPlanSteps, wantInferences: settings.DefaultMaxPlanInferences},
+		{name: "raised within bounds", maxSteps: 8, maxInferences: 5, wantSteps: 8, wantInferences: 5},
+		{name: "upper bounds accepted", maxSteps: settings.PlanStepsUpperBound, maxInferences: settings.PlanInferencesUpperBound, wantSteps: settings.PlanStepsUpperBound, wantInferences: settings.PlanInferencesUpperBound},
+		{name: "steps above upper bound rejected", maxSteps: settings.PlanStepsUpperBound + 1, maxInferences: 3, wantErr: true},
+		{name: "inferences above upper bound rejected", maxSteps: 10, maxInferences: settings.PlanInferencesUpperBound + 1, wantErr: true},
+		{name: "negative steps rejected", maxSteps: -1, maxInferences: 1, wantErr: true},
+		{name: "inferences above steps rejected", maxSteps: 2, maxInferences: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			svc := settings.NewSettingsService(newTestLogger(t), repo, stubFileUtils{})
+
+			got, err := svc.UpdateAppBehaviorConfig(&settings.AppBehaviorConfig{
+				HistoryEnabled:    true,
+				HistoryMaxEntries: 100,
+				MaxPlanSteps:      tt.maxSteps,
+				MaxPlanInferences: tt.maxInferences,
+			})
+
+			if tt.wantErr {
+				var ae *apperr.AppError
+				if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+					t.Fatalf("want validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateAppBehaviorConfig() error = %v", err)
+			}
+			if got.MaxPlanSteps != tt.wantSteps || got.MaxPlanInferences != tt.wantInferences {
+				t.Errorf("limits = %d/%d, want %d/%d", got.MaxPlanSteps, got.MaxPlanInferences, tt.wantSteps, tt.wantInferences)
+			}
+			stored, err := svc.GetAppBehaviorConfig()
+			if err != nil {
+				t.Fatalf("GetAppBehaviorConfig() error = %v", err)
+			}
+			if stored.MaxPlanSteps != tt.wantSteps || stored.MaxPlanInferences != tt.wantInferences {
+				t.Errorf("stored limits = %d/%d, want %d/%d", stored.MaxPlanSteps, stored.MaxPlanInferences, tt.wantSteps, tt.wantInferences)
+			}
+		})
+	}
+}
+
+func TestSettingsService_UpdateAppBehaviorConfig_MaxParallelBranches(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   int
+		wantErr bool
+		want    int
+	}{
+		{name: "zero keeps default (older clients)", value: 0, want: settings.DefaultMaxParallelBranches},
+		{name: "one runs branches sequentially", value: 1, want: 1},
+		{name: "upper bound accepted", value: settings.ParallelBranchesUpperBound, want: settings.ParallelBranchesUpperBound},
+		{name: "above upper bound rejected", value: settings.ParallelBranchesUpperBound + 1, wantErr: true},
+		{name: "negative rejected", value: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			svc := settings.NewSettingsService(newTestLogger(t), repo, stubFileUtils{})
+
+			got, err := svc.UpdateAppBehaviorConfig(&settings.AppBehaviorConfig{
+				HistoryEnabled:      true,
+				HistoryMaxEntries:   100,
+				MaxParallelBranches: tt.value,
+			})
+
+			if tt.wantErr {
+				var ae *apperr.AppError
+				if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+					t.Fatalf("want validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateAppBehaviorConfig() error = %v", err)
+			}
+			if got.MaxParallelBranches != tt.want {
+				t.Errorf("MaxParallelBranches = %d, want %d", got.MaxParallelBranches, tt.want)
+			}
+			stored, err := svc.GetAppBehaviorConfig()
+			if err != nil {
+				t.Fatalf("GetAppBehaviorConfig() error = %v", err)
+			}
+			if stored.MaxParallelBranches != tt.want {
+				t.Errorf("stored MaxParallelBranches = %d, want %d", stored.MaxParallelBranches, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettingsService_UpdateAppBehaviorConfig_BatchConcurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   int
+		wantErr bool
+		want    int
+	}{
+		{name: "zero keeps default (older clients)", value: 0, want: settings.DefaultBatchConcurrency},
+		{name: "one runs items sequentially", value: 1, want: 1},
+		{name: "upper bound accepted", value: settings.BatchConcurrencyUpperBound, want: settings.BatchConcurrencyUpperBound},
+		{name: "above upper bound rejected", value: settings.BatchConcurrencyUpperBound + 1, wantErr: true},
+		{name: "negative rejected", value: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			svc := settings.NewSettingsService(newTestLogger(t), repo, stubFileUtils{})
+
+			got, err := svc.UpdateAppBehaviorConfig(&settings.AppBehaviorConfig{
+				HistoryEnabled:    true,
+				HistoryMaxEntries: 100,
+				BatchConcurrency:  tt.value,
+			})
+
+			if tt.wantErr {
+				var ae *apperr.AppError
+				if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+					t.Fatalf("want validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateAppBehaviorConfig() error = %v", err)
+			}
+			if got.BatchConcurrency != tt.want {
+				t.Errorf("BatchConcurrency = %d, want %d", got.BatchConcurrency, tt.want)
+			}
+			stored, err := svc.GetAppBehaviorConfig()
+			if err != nil {
+				t.Fatalf("GetAppBehaviorConfig() error = %v", err)
+			}
+			if stored.BatchConcurrency != tt.want {
+				t.Errorf("stored BatchConcurrency = %d, want %d", stored.BatchConcurrency, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettingsService_UpdateAppBehaviorConfig_Retention(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     settings.AppBehaviorConfig
+		wantErr string
+	}{
+		{name: "zero means no limit", cfg: settings.AppBehaviorConfig{}},
+		{name: "upper bounds accepted", cfg: settings.AppBehaviorConfig{
+			HistoryMaxAgeDays: settings.RetentionDaysUpperBound, HistoryMaxSizeMB: settings.RetentionMBUpperBound,
+			TaskLogMaxAgeDays: settings.RetentionDaysUpperBound, TaskLogMaxSizeMB: settings.RetentionMBUpperBound,
+		}},
+		{name: "negative history age rejected", cfg: settings.AppBehaviorConfig{HistoryMaxAgeDays: -1}, wantErr: "historyMaxAgeDays"},
+		{name: "history size above bound rejected", cfg: settings.AppBehaviorConfig{HistoryMaxSizeMB: settings.RetentionMBUpperBound + 1}, wantErr: "historyMaxSizeMB"},
+		{name: "task log age above bound rejected", cfg: settings.AppBehaviorConfig{TaskLogMaxAgeDays: settings.RetentionDaysUpperBound + 1}, wantErr: "taskLogMaxAgeDays"},
+		{name: "negative task log size rejected", cfg: settings.AppBehaviorConfig{TaskLogMaxSizeMB: -5}, wantErr: "taskLogMaxSizeMB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			svc := settings.NewSettingsService(newTestLogger(t), repo, stubFileUtils{})
+
+			cfg := tt.cfg
+			cfg.HistoryEnabled = true
+			cfg.HistoryMaxEntries = 100
+			got, err := svc.UpdateAppBehaviorConfig(&cfg)
+
+			if tt.wantErr != "" {
+				var ae *apperr.AppError
+				if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+					t.Fatalf("want validation error, got %v", err)
+				}
+				if ae.Details["field"] != tt.wantErr {
+					t.Errorf("field = %v, want %s", ae.Details["field"], tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateAppBehaviorConfig() error = %v", err)
+			}
+			stored, err := svc.GetAppBehaviorConfig()
+			if err != nil {
+				t.Fatalf("GetAppBehaviorConfig() error = %v", err)
+			}
+			if stored.HistoryMaxAgeDays != got.HistoryMaxAgeDays || stored.HistoryMaxSizeMB != got.HistoryMaxSizeMB ||
+				stored.TaskLogMaxAgeDays != got.TaskLogMaxAgeDays || stored.TaskLogMaxSizeMB != got.TaskLogMaxSizeMB {
+				t.Errorf("stored %+v, want retention of %+v", stored, got)
+			}
+		})
+	}
+}
+
+func TestSettingsService_UpdateAppBehaviorConfig_ExportFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+		want    string
+	}{
+		{name: "empty keeps default (older clients)", value: "", want: settings.DefaultExportFileName},
+		{name: "placeholders and text", value: " {date}_{time} {name} export ", want: "{date}_{time} {name} export"},
+		{name: "no placeholders", value: "notes", want: "notes"},
+		{name: "unknown placeholder rejected", value: "{name} {model}", wantErr: true},
+		{name: "path separator rejected", value: "out/{name}", wantErr: true},
+		{name: "too long rejected", value: strings.Repeat("x", settings.ExportFileNameMaxLen+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			svc := settings.NewSettingsService(newTestLogger(t), repo, stubFileUtils{})
+
+			got, err := svc.UpdateAppBehaviorConfig(&settings.AppBehaviorConfig{
+				HistoryEnabled:    true,
+				HistoryMaxEntries: 100,
+				ExportFileName:    tt.value,
+			})
+
+			if tt.wantErr {
+				var ae *apperr.AppError
+				if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+					t.Fatalf("want validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateAppBehaviorConfig() error = %v", err)
+			}
+			if got.ExportFileName != tt.want {
+				t.Errorf("ExportFileName = %q, want %q", got.ExportFileName, tt.want)
+			}
+			stored, err := svc.GetAppBehaviorConfig()
+			if err != nil {
+				t.Fatalf("GetAppBehaviorConfig() error = %v", err)
+			}
+			if stored.ExportFileName != tt.want {
+				t.Errorf("stored ExportFileName = %q, want %q", stored.ExportFileName, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettingsService_UpdateAppBehaviorConfig_LanguageCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+		want    string
+	}{
+		{name: "empty keeps default (older clients)", value: "", want: settings.DefaultLanguageCheck},
+		{name: "off accepted", value: settings.LanguageCheckOff, want: settings.LanguageCheckOff},
+		{name: "fail accepted", value: settings.LanguageCheckFail, want: settings.LanguageCheckFail},
+		{name: "unknown mode rejected", value: "strict", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			svc := settings.NewSettingsService(newTestLogger(t), repo, stubFileUtils{})
+
+			got, err := svc.UpdateAppBehaviorConfig(&settings.AppBehaviorConfig{
+				HistoryEnabled:    true,
+				HistoryMaxEntries: 100,
+				LanguageCheck:     tt.value,
+			})
+
+			if tt.wantErr {
+				var ae *apperr.AppError
+				if !errors.As(err, &ae) || ae.Code != apperr.CodeValidation {
+					t.Fatalf("want validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateAppBehaviorConfig() error = %v", err)
+			}
+			if got.LanguageCheck != tt.want {
+				t.Errorf("LanguageCheck = %q, want %q", got.LanguageCheck, tt.want)
+			}
+			stored, err := svc.GetAppBehaviorConfig()
+			if err != nil {
+				t.Fatalf("GetAppBehaviorConfig() error = %v", err)
+			}
+			if stored.LanguageCheck != tt.want {
+				t.Errorf("stored LanguageCheck = %q, want %q", stored.LanguageCheck, tt.want)
+			}
+		})
+	}
+}
+
 // T84 regression: an empty (or whitespace-only, after TrimSpace) language must
 // surface as apperr.CodeValidation.
 func TestSettingsService_SetDefaultInputLanguage_RejectsEmptyLanguage(t *testing.T) {
@@ -584,6 +892,7 @@ func TestSettingsService_SetDefaultInputLanguage_RejectsUnsupportedLanguage(t *t
 	}{
 		{name: "unsupported language is rejected", language: "Klingon", wantErr: true},
 		{name: "seeded default language is accepted", language: "English", wantErr: false},
+		{name: "auto detection is accepted", language: settings.AutoInputLanguage, wantErr: false},
 	}
 
 	for _, tt := range tests {
@@ -652,6 +961,7 @@ func TestSettingsService_SetDefaultOutputLanguage_RejectsUnsupportedLanguage(t *
 	}{
 		{name: "unsupported language is rejected", language: "Klingon", wantErr: true},
 		{name: "seeded default language is accepted", language: "Ukrainian", wantErr: false},
+		{name: "auto is not an output language", language: settings.AutoInputLanguage, wantErr: true},
 	}
 
 	for _, tt := range tests {
@@ -38,13 +38,91 @@ type ModelConfig struct {
 }
 
 // AppBehaviorConfig — v3 adds HistoryEnabled/HistoryMaxEntries;
-// LogDirectory removed (moved to LoggingConfig).
+// LogDirectory removed (moved to LoggingConfig). MaxPlanSteps/MaxPlanInferences
+// cap the chain planner (see PlanStepsUpperBound/PlanInferencesUpperBound).
+// MaxParallelBranches caps how many fan-out branches run at once.
+// LanguageCheck is one of the LanguageCheck* modes. CleanOutput, off by
+// default, strips model chatter (preambles, postambles, wrapping fences and
+// quotes) from every output.
+// BatchConcurrency caps how many items of a batch job run at once.
+// ExportFileName is the file name template of exported outputs.
+// HistoryMaxAgeDays/HistoryMaxSizeMB and TaskLogMaxAgeDays/TaskLogMaxSizeMB are
+// the retention rules for history rows and task log files; 0 means no limit.
+// HistoryMaxAgeDays also covers chain checkpoints and completed batch jobs.
+// Only TaskLogMaxAgeDays has a default: the history rules are 0, so history
+// rows, checkpoints and batch jobs are kept until the user sets them.
 type AppBehaviorConfig struct {
-	EnableTaskLogging bool `json:"enableTaskLogging"`
-	HistoryEnabled    bool `json:"historyEnabled"`
-	HistoryMaxEntries int  `json:"historyMaxEntries"`
+	EnableTaskLogging   bool   `json:"enableTaskLogging"`
+	HistoryEnabled      bool   `json:"historyEnabled"`
+	HistoryMaxEntries   int    `json:"historyMaxEntries"`
+	MaxPlanSteps        int    `json:"maxPlanSteps"`
+	MaxPlanInferences   int    `json:"maxPlanInferences"`
+	MaxParallelBranches int    `json:"maxParallelBranches"`
+	LanguageCheck       string `json:"languageCheck"`
+	CleanOutput         bool   `json:"cleanOutput"`
+	BatchConcurrency    int    `json:"batchConcurrency"`
+	ExportFileName      string `json:"exportFileName"`
+	HistoryMaxAgeDays   int    `json:"historyMaxAgeDays"`
+	HistoryMaxSizeMB    int    `json:"historyMaxSizeMB"`
+	TaskLogMaxAgeDays   int    `json:"taskLogMaxAgeDays"`
+	TaskLogMaxSizeMB    int    `json:"taskLogMaxSizeMB"`
 }
 
+// Plan-limit defaults (the historical fixed caps) and the upper bounds a user may
+// raise them to. Longer pipelines cost proportionally more latency and tokens.
+const (
+	DefaultMaxPlanSteps      = 5
+	DefaultMaxPlanInferences = 3
+	PlanStepsUpperBound      = 10
+	PlanInferencesUpperBound = 6
+)
+
+// Fan-out concurrency default and the upper bound a user may raise it to. Each
+// concurrent branch holds its own in-flight request against the provider.
+const (
+	DefaultMaxParallelBranches = 3
+	ParallelBranchesUpperBound = 8
+)
+
+// Batch concurrency default and upper bound. A job's items share one provider,
+// so running many at once mostly helps hosted providers.
+const (
+	DefaultBatchConcurrency    = 2
+	BatchConcurrencyUpperBound = 8
+)
+
+// Retention defaults and upper bounds. Task logs hold full prompts, so they are
+// kept for a month by default; history is limited by HistoryMaxEntries alone,
+// and checkpoints and batch jobs are not limited by age, unless a history rule
+// is set.
+const (
+	DefaultTaskLogMaxAgeDays = 30
+	RetentionDaysUpperBound  = 3650
+	RetentionMBUpperBound    = 10240
+)
+
+// Export file name template default and its placeholders: the stack or action
+// name, the run's date (2006-01-02) and its time (150405). The extension is
+// added by the export format.
+const (
+	DefaultExportFileName = "{name} {date}"
+	ExportNamePlaceholder = "{name}"
+	ExportDatePlaceholder = "{date}"
+	ExportTimePlaceholder = "{time}"
+	ExportFileNameMaxLen  = 100
+)
+
+// Output-language verification modes. After each chain group the output language
+// is checked locally; a mismatch is retried once with a stronger instruction, and
+// a persisting mismatch is ignored (off), reported on the result (warn) or ends
+// the run with apperr.CodeLanguageMismatch (fail).
+const (
+	LanguageCheckOff     = "off"
+	LanguageCheckWarn    = "warn"
+	LanguageCheckFail    = "fail"
+	DefaultLanguageCheck = LanguageCheckWarn
+)
+
 // UIPreferencesConfig holds persisted UI preferences that must survive restart.
 // Theme is "auto" | "light" | "dark". Layout is "side" | "stacked".
 // ViewMode is "preview" | "source" | "diff".
@@ -95,6 +173,11 @@ type LoggingConfig struct {
 	LogCompress    bool   `json:"logCompress"`
 }
 
+// AutoInputLanguage is the input language value that asks for the language to be
+// detected from the input text. It is accepted as the default input language
+// without being one of Languages.
+const AutoInputLanguage = "auto"
+
 type LanguageConfig struct {
 	Languages             []string `json:"languages"`
 	DefaultInputLanguage  string   `json:"defaultInputLanguage"`
@@ -9,6 +9,7 @@ import (
 	"go_text/internal/actions"
 	"go_text/internal/apperr"
 	"go_text/internal/logging"
+	"go_text/internal/settings"
 )
 
 const panicMsgFmt = "panic: %v"
@@ -31,6 +32,12 @@ type LastSelectionUpdater interface {
 	ClearLastSelectionIfStack(stackID string) error
 }
 
+// PlanLimitsSource supplies the user-configured plan caps stacks are validated
+// against. Implemented by *settings.SettingsService.
+type PlanLimitsSource interface {
+	GetAppBehaviorConfig() (*settings.AppBehaviorConfig, error)
+}
+
 type StackHandler struct {
 	appLogger     *logging.Logger
 	repo          StackRepositoryAPI
@@ -39,6 +46,7 @@ type StackHandler struct {
 	catalogNames  map[string]string
 	recipes       []SuggestedStackRecipe
 	lastSelection LastSelectionUpdater
+	planLimits    PlanLimitsSource
 }
 
 // NewStackHandler constructs a StackHandler.
@@ -79,6 +87,13 @@ func (h *StackHandler) SetLastSelectionUpdater(u LastSelectionUpdater) {
 	h.lastSelection = u
 }
 
+// SetPlanLimitsSource wires the settings service whose plan caps CreateStack and
+// UpdateStack enforce. Unset (e.g. in tests), the default caps apply.
+// Called from ApplicationContextHolder.Init.
+func (h *StackHandler) SetPlanLimitsSource(src PlanLimitsSource) {
+	h.planLimits = src
+}
+
 // liveZlog returns a live snapshot of the app logger's current writer, or a
 // no-op logger if appLogger has not been wired (e.g. bare struct-literal
 // tests exercising panic recovery).
@@ -89,33 +104,91 @@ func (h *StackHandler) liveZlog() zerolog.Logger {
 	return zerolog.Nop()
 }
 
-// filterUnknownSteps removes action IDs not present in the catalog,
-// logging a warning for each removal. Called on every read (List/Get).
+// filterUnknownSteps removes action IDs not present in the catalog, together
+// with their index-aligned StepParams entry, from the shared steps and every
+// branch, logging a warning for each removal. Called on every read (List/Get).
 func (h *StackHandler) filterUnknownSteps(stack *apperr.SavedStack) {
-	out := make([]string, 0, len(stack.Steps))
-	for _, id := range stack.Steps {
+	stack.Steps, stack.StepParams = h.knownSteps(stack.ID, stack.Steps, stack.StepParams)
+	for i := range stack.Branches {
+		b := &stack.Branches[i]
+		b.Steps, b.StepParams = h.knownSteps(stack.ID, b.Steps, b.StepParams)
+	}
+}
+
+func (h *StackHandler) knownSteps(stackID string, steps []string, stepParams []apperr.StepParams) ([]string, []apperr.StepParams) {
+	out := make([]string, 0, len(steps))
+	params := make([]apperr.StepParams, 0, len(steps))
+	for i, id := range steps {
 		if h.catalogIDs[id] {
 			out = append(out, id)
+			var p apperr.StepParams
+			if i < len(stepParams) {
+				p = stepParams[i]
+			}
+			params = append(params, p)
 		} else {
 			zl := h.liveZlog()
 			zl.Warn().
-				Str("stackId", stack.ID).
+				Str("stackId", stackID).
 				Str("actionId", id).
 				Msg("dropping unknown action ID from saved stack")
 		}
 	}
-	stack.Steps = out
+	return out, params
 }
 
-// validatePlan converts action IDs to a ChainRequest and runs the planner.
+// validatePlan converts the stack's steps and their parameters to a ChainRequest
+// (with the stack's default languages and order mode) and runs the planner under
+// the configured plan limits — PlanFanOut for a fan-out stack.
 // Returns a typed *AppError (validation or invalid_plan) on failure.
-func (h *StackHandler) validatePlan(steps []string) error {
-	chainSteps := make([]apperr.ChainStep, len(steps))
-	for i, id := range steps {
-		chainSteps[i] = apperr.ChainStep{ActionID: id}
+func (h *StackHandler) validatePlan(stack apperr.SavedStack) error {
+	if len(stack.StepParams) > len(stack.Steps) {
+		return apperr.Validation("stepParams", "at most one entry per step",
+			fmt.Sprintf("%d entries for %d steps", len(stack.StepParams), len(stack.Steps)))
+	}
+	req := apperr.ChainRequest{
+		Steps:            stack.ChainSteps(),
+		InputLanguageID:  stack.DefaultInLang,
+		OutputLanguageID: stack.DefaultOutLang,
+		StrictOrder:      stack.StrictOrder,
+	}
+	switch stack.Kind {
+	case "", apperr.StackKindLinear:
+		if len(stack.Branches) > 0 {
+			return apperr.Validation("branches", "none for a linear stack",
+				fmt.Sprintf("%d branches", len(stack.Branches)))
+		}
+		_, err := h.planner.PlanWithLimits(req, h.currentPlanLimits())
+		return err
+	case apperr.StackKindFanOut:
+		for _, b := range stack.Branches {
+			if len(b.StepParams) > len(b.Steps) {
+				return apperr.Validation("branches.stepParams", "at most one entry per step",
+					fmt.Sprintf("%d entries for %d steps in branch %q", len(b.StepParams), len(b.Steps), b.Name))
+			}
+		}
+		req.Branches = stack.ChainBranches()
+		_, err := h.planner.PlanFanOut(req, h.currentPlanLimits())
+		return err
+	default:
+		return apperr.Validation("kind",
+			fmt.Sprintf("%q or %q", apperr.StackKindLinear, apperr.StackKindFanOut), stack.Kind)
+	}
+}
+
+// currentPlanLimits reads the configured plan caps, falling back to the defaults
+// when no source is wired or it cannot be read.
+func (h *StackHandler) currentPlanLimits() actions.PlanLimits {
+	if h.planLimits == nil {
+		return actions.PlanLimits{}
+	}
+	cfg, err := h.planLimits.GetAppBehaviorConfig()
+	if err != nil || cfg == nil {
+		zl := h.liveZlog()
+		zl.Warn().Str("component", "stacks").Err(err).Msg("failed to read plan limits; using defaults")
+		return actions.PlanLimits{}
 	}
-	_, err := h.planner.Plan(apperr.ChainRequest{Steps: chainSteps})
-	return err
+	return actions.PlanLimitsFromSettings(*cfg)
 }
 
 // mapRepoError converts repository string-based errors to typed AppErrors.
@@ -233,7 +306,7 @@ func (h *StackHandler) CreateStack(s apperr.SavedStack) (res apperr.StackResult)
 		wire := apperr.ToWire(h.liveZlog(), ae)
 		return apperr.StackResult{Error: &wire}
 	}
-	if err := h.validatePlan(s.Steps); err != nil {
+	if err := h.validatePlan(s); err != nil {
 		wire := apperr.ToWire(h.liveZlog(), err)
 		return apperr.StackResult{Error: &wire}
 	}
@@ -261,7 +334,7 @@ func (h *StackHandler) UpdateStack(s apperr.SavedStack) (res apperr.StackResult)
 		wire := apperr.ToWire(h.liveZlog(), ae)
 		return apperr.StackResult{Error: &wire}
 	}
-	if err := h.validatePlan(s.Steps); err != nil {
+	if err := h.validatePlan(s); err != nil {
 		wire := apperr.ToWire(h.liveZlog(), err)
 		return apperr.StackResult{Error: &wire}
 	}
@@ -322,10 +395,14 @@ func (h *StackHandler) DuplicateStack(id string, newName string) (res apperr.Sta
 	dupe := apperr.SavedStack{
 		Name:           newName,
 		Icon:           original.Icon,
+		Kind:           original.Kind,
 		Steps:          original.Steps,
+		StepParams:     original.StepParams,
+		Branches:       original.Branches,
 		DefaultFormat:  original.DefaultFormat,
 		DefaultInLang:  original.DefaultInLang,
 		DefaultOutLang: original.DefaultOutLang,
+		StrictOrder:    original.StrictOrder,
 	}
 	created, err := h.repo.Create(dupe)
 	if err != nil {
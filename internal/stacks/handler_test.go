@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"go_text/internal/apperr"
+	"go_text/internal/settings"
 )
 
 // ─── Minimal catalog (enough to exercise planner rules) ─────────────────────
@@ -17,8 +18,14 @@ var testCatalog = []apperr.ActionMeta{
 	{ID: "professional", Family: "Rewrite", ExclusivityGroup: "tone", OrderRank: 210, Mergeable: true},
 	{ID: "keyPoints", Family: "Summarize", ExclusivityGroup: "summarize-mode", OrderRank: 300, Mergeable: false, Terminal: true},
 	{ID: "documentStructuring", Family: "Structure", ExclusivityGroup: "structure-mode", OrderRank: 400, Mergeable: true},
+	{ID: "imagePrompt", Family: "PromptEng", ExclusivityGroup: "prompteng-mode", OrderRank: 500, Terminal: true, Requires: []string{"target_model", "goal"}},
+	{ID: "tweet", Family: "Structure", ExclusivityGroup: "doc-mode", OrderRank: 450, Params: []apperr.ParamSpec{
+		{Name: "char_limit", Type: "int", Default: "280", Min: intPtr(50), Max: intPtr(1000)},
+	}},
 }
 
+func intPtr(v int) *int { return &v }
+
 // ─── Mock repository ─────────────────────────────────────────────────────────
 
 type mockRepo struct {
@@ -288,6 +295,30 @@ func TestStackHandler_GetStack_FiltersUnknown(t *testing.T) {
 	}
 }
 
+func TestStackHandler_GetStack_FiltersUnknownKeepsParamsAligned(t *testing.T) {
+	t.Parallel()
+	stack := &apperr.SavedStack{
+		ID:    "x",
+		Name:  "Test",
+		Steps: []string{"unknownAction", "imagePrompt"},
+		StepParams: []apperr.StepParams{
+			{Goal: "dropped"},
+			{TargetModel: "SDXL", Goal: "restore"},
+		},
+	}
+	h := newTestHandler(&mockRepo{getData: stack})
+
+	res := h.GetStack("x")
+
+	if res.Error != nil {
+		t.Fatalf("unexpected error: %v", res.Error)
+	}
+	want := []apperr.StepParams{{TargetModel: "SDXL", Goal: "restore"}}
+	if !reflect.DeepEqual(res.Data.StepParams, want) {
+		t.Errorf("StepParams = %+v, want %+v", res.Data.StepParams, want)
+	}
+}
+
 func TestStackHandler_GetStack_NotFound(t *testing.T) {
 	t.Parallel()
 	h := newTestHandler(&mockRepo{getErr: fmt.Errorf("stack %q not found", "x")})
@@ -401,6 +432,213 @@ func TestStackHandler_CreateStack_ValidFourStepStack(t *testing.T) {
 	}
 }
 
+func TestStackHandler_CreateStack_StepParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		steps    []string
+		params   []apperr.StepParams
+		wantCode apperr.ErrorCode
+	}{
+		{
+			name:   "requirements satisfied by saved params",
+			steps:  []string{"conciseRewrite", "imagePrompt"},
+			params: []apperr.StepParams{{}, {TargetModel: "SDXL", Goal: "restore"}},
+		},
+		{
+			name:     "missing required goal",
+			steps:    []string{"imagePrompt"},
+			params:   []apperr.StepParams{{TargetModel: "SDXL"}},
+			wantCode: apperr.CodeInvalidPlan,
+		},
+		{
+			name:     "no params for action that requires them",
+			steps:    []string{"imagePrompt"},
+			wantCode: apperr.CodeInvalidPlan,
+		},
+		{
+			name:   "typed param within bounds",
+			steps:  []string{"tweet"},
+			params: []apperr.StepParams{{Params: map[string]string{"char_limit": "140"}}},
+		},
+		{
+			name:     "typed param out of bounds",
+			steps:    []string{"tweet"},
+			params:   []apperr.StepParams{{Params: map[string]string{"char_limit": "5"}}},
+			wantCode: apperr.CodeInvalidPlan,
+		},
+		{
+			name:   "step condition saved with params",
+			steps:  []string{"conciseRewrite", "keyPoints"},
+			params: []apperr.StepParams{{}, {When: []apperr.StepCondition{{Subject: "input", Check: "minWords", Value: "400"}}}},
+		},
+		{
+			name:     "malformed step condition",
+			steps:    []string{"keyPoints"},
+			params:   []apperr.StepParams{{When: []apperr.StepCondition{{Check: "matches", Value: "("}}}},
+			wantCode: apperr.CodeInvalidPlan,
+		},
+		{
+			name:     "more params than steps",
+			steps:    []string{"formal"},
+			params:   []apperr.StepParams{{}, {}},
+			wantCode: apperr.CodeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := newTestHandler(&mockRepo{})
+
+			res := h.CreateStack(apperr.SavedStack{Name: "Params", Steps: tt.steps, StepParams: tt.params})
+
+			if tt.wantCode == "" {
+				if res.Error != nil {
+					t.Fatalf("unexpected error: %v", res.Error)
+				}
+				return
+			}
+			if res.Error == nil || res.Error.Code != tt.wantCode {
+				t.Errorf("expected %s, got %v", tt.wantCode, res.Error)
+			}
+		})
+	}
+}
+
+type stubPlanLimits struct {
+	cfg *settings.AppBehaviorConfig
+	err error
+}
+
+func (s stubPlanLimits) GetAppBehaviorConfig() (*settings.AppBehaviorConfig, error) {
+	return s.cfg, s.err
+}
+
+func TestStackHandler_CreateStack_FanOut(t *testing.T) {
+	t.Parallel()
+
+	twoBranches := []apperr.StackBranch{
+		{Name: "points", Steps: []string{"keyPoints"}},
+		{Name: "structured", Steps: []string{"documentStructuring"}},
+	}
+	tests := []struct {
+		name     string
+		stack    apperr.SavedStack
+		wantCode apperr.ErrorCode
+	}{
+		{
+			name:  "valid fan-out",
+			stack: apperr.SavedStack{Name: "F", Kind: apperr.StackKindFanOut, Steps: []string{"conciseRewrite"}, Branches: twoBranches},
+		},
+		{
+			name:     "linear stack with branches",
+			stack:    apperr.SavedStack{Name: "F", Steps: []string{"conciseRewrite"}, Branches: twoBranches},
+			wantCode: apperr.CodeValidation,
+		},
+		{
+			name:     "unknown kind",
+			stack:    apperr.SavedStack{Name: "F", Kind: "tree", Steps: []string{"conciseRewrite"}},
+			wantCode: apperr.CodeValidation,
+		},
+		{
+			name: "branch params out of range",
+			stack: apperr.SavedStack{Name: "F", Kind: apperr.StackKindFanOut, Branches: []apperr.StackBranch{
+				twoBranches[0],
+				{Name: "post", Steps: []string{"tweet"}, StepParams: []apperr.StepParams{{Params: map[string]string{"char_limit": "5"}}}},
+			}},
+			wantCode: apperr.CodeInvalidPlan,
+		},
+		{
+			name: "too many branch step params",
+			stack: apperr.SavedStack{Name: "F", Kind: apperr.StackKindFanOut, Branches: []apperr.StackBranch{
+				twoBranches[0],
+				{Name: "post", Steps: []string{"tweet"}, StepParams: []apperr.StepParams{{}, {}}},
+			}},
+			wantCode: apperr.CodeValidation,
+		},
+		{
+			name:     "terminal shared step",
+			stack:    apperr.SavedStack{Name: "F", Kind: apperr.StackKindFanOut, Steps: []string{"keyPoints"}, Branches: []apperr.StackBranch{{Name: "a", Steps: []string{"formal"}}, {Name: "b", Steps: []string{"documentStructuring"}}}},
+			wantCode: apperr.CodeInvalidPlan,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			res := newTestHandler(&mockRepo{}).CreateStack(tt.stack)
+
+			if tt.wantCode == "" {
+				if res.Error != nil {
+					t.Fatalf("unexpected error: %v", res.Error)
+				}
+				return
+			}
+			if res.Error == nil || res.Error.Code != tt.wantCode {
+				t.Errorf("expected %s, got %v", tt.wantCode, res.Error)
+			}
+		})
+	}
+}
+
+func TestStackHandler_CreateStack_PlanLimitsAndStrictOrder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		stack    apperr.SavedStack
+		limits   PlanLimitsSource
+		wantCode apperr.ErrorCode
+	}{
+		{
+			name:  "strict order keeps terminal step first",
+			stack: apperr.SavedStack{Name: "S", Steps: []string{"keyPoints", "conciseRewrite"}, StrictOrder: true},
+		},
+		{
+			name:     "configured inference cap is enforced",
+			stack:    apperr.SavedStack{Name: "S", Steps: []string{"conciseRewrite", "keyPoints"}},
+			limits:   stubPlanLimits{cfg: &settings.AppBehaviorConfig{MaxPlanSteps: 5, MaxPlanInferences: 1}},
+			wantCode: apperr.CodeInvalidPlan,
+		},
+		{
+			name:   "unreadable limits fall back to defaults",
+			stack:  apperr.SavedStack{Name: "S", Steps: []string{"conciseRewrite", "keyPoints"}},
+			limits: stubPlanLimits{err: errors.New("db closed")},
+		},
+		{
+			name: "raised step cap admits a longer stack",
+			stack: apperr.SavedStack{Name: "S", StrictOrder: true, Steps: []string{
+				"conciseRewrite", "formal", "documentStructuring", "tweet", "keyPoints", "imagePrompt",
+			}, StepParams: []apperr.StepParams{{}, {}, {}, {}, {}, {TargetModel: "m", Goal: "g"}}},
+			limits: stubPlanLimits{cfg: &settings.AppBehaviorConfig{MaxPlanSteps: 6, MaxPlanInferences: 6}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := newTestHandler(&mockRepo{})
+			if tt.limits != nil {
+				h.SetPlanLimitsSource(tt.limits)
+			}
+
+			res := h.CreateStack(tt.stack)
+
+			if tt.wantCode == "" {
+				if res.Error != nil {
+					t.Fatalf("unexpected error: %v", res.Error)
+				}
+				return
+			}
+			if res.Error == nil || res.Error.Code != tt.wantCode {
+				t.Errorf("expected %s, got %v", tt.wantCode, res.Error)
+			}
+		})
+	}
+}
+
 // ─── UpdateStack ─────────────────────────────────────────────────────────────
 
 func TestStackHandler_UpdateStack_Success(t *testing.T) {
@@ -3,6 +3,7 @@ package stacks
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -28,43 +29,139 @@ func NewSqliteStackRepository(database *db.Database) *SqliteStackRepository {
 
 func (r *SqliteStackRepository) bg() context.Context { return context.Background() }
 
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func isUniqueViolation(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
 }
 
-func rowToSavedStack(row store.Stack, steps []string) apperr.SavedStack {
+// stackKind returns the stack's kind, defaulting an unset kind to linear.
+func stackKind(stack apperr.SavedStack) string {
+	if stack.Kind == "" {
+		return apperr.StackKindLinear
+	}
+	return stack.Kind
+}
+
+func rowToSavedStack(row store.Stack, steps []string, params []apperr.StepParams, branches []apperr.StackBranch) apperr.SavedStack {
 	if steps == nil {
 		steps = []string{}
 	}
+	if params == nil {
+		params = []apperr.StepParams{}
+	}
 	return apperr.SavedStack{
 		ID:             row.ID,
 		Name:           row.Name,
 		Icon:           row.Icon,
+		Kind:           row.Kind,
 		Steps:          steps,
+		StepParams:     params,
+		Branches:       branches,
 		DefaultFormat:  row.DefaultFormat,
 		DefaultInLang:  row.DefaultInLang,
 		DefaultOutLang: row.DefaultOutLang,
+		StrictOrder:    row.StrictOrder != 0,
 		CreatedAt:      row.CreatedAt,
 		UpdatedAt:      row.UpdatedAt,
 	}
 }
 
+// alignedStepParams returns params padded (or truncated) to exactly len(steps)
+// entries, so callers always get an index-aligned slice back.
+func alignedStepParams(steps []string, params []apperr.StepParams) []apperr.StepParams {
+	out := make([]apperr.StepParams, len(steps))
+	copy(out, params)
+	return out
+}
+
+// alignedBranches returns stack.Branches with each branch's StepParams aligned,
+// or nil for a stack without branches.
+func alignedBranches(stack apperr.SavedStack) []apperr.StackBranch {
+	if len(stack.Branches) == 0 {
+		return nil
+	}
+	out := make([]apperr.StackBranch, len(stack.Branches))
+	for i, b := range stack.Branches {
+		steps := b.Steps
+		if steps == nil {
+			steps = []string{}
+		}
+		out[i] = apperr.StackBranch{Name: b.Name, Steps: steps, StepParams: alignedStepParams(b.Steps, b.StepParams)}
+	}
+	return out
+}
+
 func (r *SqliteStackRepository) loadWithSteps(ctx context.Context, q *store.Queries, row store.Stack) (apperr.SavedStack, error) {
-	steps, err := q.GetStackSteps(ctx, row.ID)
+	rows, err := q.GetStackSteps(ctx, row.ID)
 	if err != nil {
 		return apperr.SavedStack{}, fmt.Errorf("get steps for stack %s: %w", row.ID, err)
 	}
-	return rowToSavedStack(row, steps), nil
+	// Shared (branch '') steps form Steps; the rest are grouped into branches in
+	// order of their first position.
+	var (
+		steps    = []string{}
+		params   = []apperr.StepParams{}
+		branches []apperr.StackBranch
+		branchAt = map[string]int{}
+	)
+	for i, sr := range rows {
+		var p apperr.StepParams
+		if err := json.Unmarshal([]byte(sr.Params), &p); err != nil {
+			return apperr.SavedStack{}, fmt.Errorf("decode params for stack %s step[%d]: %w", row.ID, i, err)
+		}
+		if sr.Branch == "" {
+			steps = append(steps, sr.ActionID)
+			params = append(params, p)
+			continue
+		}
+		idx, ok := branchAt[sr.Branch]
+		if !ok {
+			idx = len(branches)
+			branchAt[sr.Branch] = idx
+			branches = append(branches, apperr.StackBranch{Name: sr.Branch, Steps: []string{}, StepParams: []apperr.StepParams{}})
+		}
+		branches[idx].Steps = append(branches[idx].Steps, sr.ActionID)
+		branches[idx].StepParams = append(branches[idx].StepParams, p)
+	}
+	return rowToSavedStack(row, steps, params, branches), nil
 }
 
-func (r *SqliteStackRepository) insertSteps(ctx context.Context, q *store.Queries, stackID string, steps []string) error {
-	for pos, actionID := range steps {
-		if err := q.InsertStackStep(ctx, store.InsertStackStepParams{
-			StackID:  stackID,
-			Position: int64(pos),
-			ActionID: actionID,
-		}); err != nil {
-			return fmt.Errorf("insert step[%d]: %w", pos, err)
+// insertSteps writes the shared steps followed by each branch's steps, numbering
+// positions consecutively across all of them.
+func (r *SqliteStackRepository) insertSteps(ctx context.Context, q *store.Queries, stackID string, stack apperr.SavedStack) error {
+	pos := 0
+	insert := func(branch string, steps []string, stepParams []apperr.StepParams) error {
+		params := alignedStepParams(steps, stepParams)
+		for i, actionID := range steps {
+			raw, err := json.Marshal(params[i])
+			if err != nil {
+				return fmt.Errorf("encode params for step[%d]: %w", pos, err)
+			}
+			if err := q.InsertStackStep(ctx, store.InsertStackStepParams{
+				StackID:  stackID,
+				Position: int64(pos),
+				ActionID: actionID,
+				Params:   string(raw),
+				Branch:   branch,
+			}); err != nil {
+				return fmt.Errorf("insert step[%d]: %w", pos, err)
+			}
+			pos++
+		}
+		return nil
+	}
+	if err := insert("", stack.Steps, stack.StepParams); err != nil {
+		return err
+	}
+	for _, b := range stack.Branches {
+		if err := insert(b.Name, b.Steps, b.StepParams); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -129,6 +226,8 @@ func (r *SqliteStackRepository) Create(stack apperr.SavedStack) (*apperr.SavedSt
 		DefaultFormat:  stack.DefaultFormat,
 		DefaultInLang:  stack.DefaultInLang,
 		DefaultOutLang: stack.DefaultOutLang,
+		StrictOrder:    boolToInt(stack.StrictOrder),
+		Kind:           stackKind(stack),
 		CreatedAt:      now,
 		UpdatedAt:      now,
 	}); err != nil {
@@ -138,7 +237,7 @@ func (r *SqliteStackRepository) Create(stack apperr.SavedStack) (*apperr.SavedSt
 		return nil, fmt.Errorf("%s: insert stack: %w", op, err)
 	}
 
-	if err := r.insertSteps(ctx, q, id, stack.Steps); err != nil {
+	if err := r.insertSteps(ctx, q, id, stack); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -154,10 +253,14 @@ func (r *SqliteStackRepository) Create(stack apperr.SavedStack) (*apperr.SavedSt
 		ID:             id,
 		Name:           stack.Name,
 		Icon:           stack.Icon,
+		Kind:           stackKind(stack),
 		Steps:          steps,
+		StepParams:     alignedStepParams(stack.Steps, stack.StepParams),
+		Branches:       alignedBranches(stack),
 		DefaultFormat:  stack.DefaultFormat,
 		DefaultInLang:  stack.DefaultInLang,
 		DefaultOutLang: stack.DefaultOutLang,
+		StrictOrder:    stack.StrictOrder,
 		CreatedAt:      now,
 		UpdatedAt:      now,
 	}, nil
@@ -184,6 +287,8 @@ func (r *SqliteStackRepository) Update(stack apperr.SavedStack) (*apperr.SavedSt
 		DefaultFormat:  stack.DefaultFormat,
 		DefaultInLang:  stack.DefaultInLang,
 		DefaultOutLang: stack.DefaultOutLang,
+		StrictOrder:    boolToInt(stack.StrictOrder),
+		Kind:           stackKind(stack),
 		UpdatedAt:      now,
 	}); err != nil {
 		if isUniqueViolation(err) {
@@ -196,7 +301,7 @@ func (r *SqliteStackRepository) Update(stack apperr.SavedStack) (*apperr.SavedSt
 		return nil, fmt.Errorf("%s: delete steps: %w", op, err)
 	}
 
-	if err := r.insertSteps(ctx, q, stack.ID, stack.Steps); err != nil {
+	if err := r.insertSteps(ctx, q, stack.ID, stack); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -212,10 +317,14 @@ func (r *SqliteStackRepository) Update(stack apperr.SavedStack) (*apperr.SavedSt
 		ID:             stack.ID,
 		Name:           stack.Name,
 		Icon:           stack.Icon,
+		Kind:           stackKind(stack),
 		Steps:          steps,
+		StepParams:     alignedStepParams(stack.Steps, stack.StepParams),
+		Branches:       alignedBranches(stack),
 		DefaultFormat:  stack.DefaultFormat,
 		DefaultInLang:  stack.DefaultInLang,
 		DefaultOutLang: stack.DefaultOutLang,
+		StrictOrder:    stack.StrictOrder,
 		CreatedAt:      stack.CreatedAt,
 		UpdatedAt:      now,
 	}, nil
@@ -241,10 +350,14 @@ func (r *SqliteStackRepository) Duplicate(id string) (*apperr.SavedStack, error)
 	result, err := r.Create(apperr.SavedStack{
 		Name:           original.Name + " (copy)",
 		Icon:           original.Icon,
+		Kind:           original.Kind,
 		Steps:          original.Steps,
+		StepParams:     original.StepParams,
+		Branches:       original.Branches,
 		DefaultFormat:  original.DefaultFormat,
 		DefaultInLang:  original.DefaultInLang,
 		DefaultOutLang: original.DefaultOutLang,
+		StrictOrder:    original.StrictOrder,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
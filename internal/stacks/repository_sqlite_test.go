@@ -3,6 +3,7 @@ package stacks
 import (
 	"context"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"go_text/internal/apperr"
@@ -68,6 +69,112 @@ func TestSqliteStackRepository_CreateAndGet(t *testing.T) {
 	}
 }
 
+func TestSqliteStackRepository_StepParamsAndStrictOrderRoundTrip(t *testing.T) {
+	repo := newStackRepo(t)
+
+	in := apperr.SavedStack{
+		Name:        "Params Stack",
+		Icon:        "image",
+		Steps:       []string{"rewrite.proofread.basic", "prompteng.image", "summarize.summary"},
+		StrictOrder: true,
+		// Shorter than Steps: the uncovered trailing step stores an empty object.
+		StepParams: []apperr.StepParams{
+			{When: []apperr.StepCondition{{Subject: "input", Check: "minWords", Value: "400"}}},
+			{TargetModel: "SDXL", Goal: "restore", Params: map[string]string{"style": "photo"}},
+		},
+	}
+	created, err := repo.Create(in)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(created.StepParams) != len(in.Steps) {
+		t.Fatalf("Create: StepParams len = %d, want %d", len(created.StepParams), len(in.Steps))
+	}
+
+	fetched, err := repo.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	want := []apperr.StepParams{
+		{When: []apperr.StepCondition{{Subject: "input", Check: "minWords", Value: "400"}}},
+		{TargetModel: "SDXL", Goal: "restore", Params: map[string]string{"style": "photo"}},
+		{},
+	}
+	if !reflect.DeepEqual(fetched.StepParams, want) {
+		t.Errorf("Get: StepParams = %+v, want %+v", fetched.StepParams, want)
+	}
+	if !fetched.StrictOrder {
+		t.Error("Get: StrictOrder should round-trip as true")
+	}
+
+	dupe, err := repo.Duplicate(created.ID)
+	if err != nil {
+		t.Fatalf("Duplicate: %v", err)
+	}
+	if !reflect.DeepEqual(dupe.StepParams, want) {
+		t.Errorf("Duplicate: StepParams = %+v, want %+v", dupe.StepParams, want)
+	}
+	if !dupe.StrictOrder {
+		t.Error("Duplicate: StrictOrder should be copied")
+	}
+}
+
+func TestSqliteStackRepository_FanOutRoundTrip(t *testing.T) {
+	repo := newStackRepo(t)
+
+	in := apperr.SavedStack{
+		Name:  "Meeting Fan-out",
+		Icon:  "share",
+		Kind:  apperr.StackKindFanOut,
+		Steps: []string{"rewrite.proofread.basic"},
+		Branches: []apperr.StackBranch{
+			{Name: "summary", Steps: []string{"summarize.summary"}, StepParams: []apperr.StepParams{{Params: map[string]string{"word_count": "80"}}}},
+			{Name: "email", Steps: []string{"rewrite.tone.professional", "structure.doc.email"}},
+		},
+	}
+	created, err := repo.Create(in)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	fetched, err := repo.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	want := []apperr.StackBranch{
+		{Name: "summary", Steps: []string{"summarize.summary"}, StepParams: []apperr.StepParams{{Params: map[string]string{"word_count": "80"}}}},
+		{Name: "email", Steps: []string{"rewrite.tone.professional", "structure.doc.email"}, StepParams: []apperr.StepParams{{}, {}}},
+	}
+	if fetched.Kind != apperr.StackKindFanOut {
+		t.Errorf("Get: Kind = %q, want %q", fetched.Kind, apperr.StackKindFanOut)
+	}
+	if !reflect.DeepEqual(fetched.Steps, in.Steps) {
+		t.Errorf("Get: Steps = %v, want %v", fetched.Steps, in.Steps)
+	}
+	if !reflect.DeepEqual(fetched.Branches, want) {
+		t.Errorf("Get: Branches = %+v, want %+v", fetched.Branches, want)
+	}
+	if !reflect.DeepEqual(created.Branches, want) {
+		t.Errorf("Create: Branches = %+v, want %+v", created.Branches, want)
+	}
+
+	dupe, err := repo.Duplicate(created.ID)
+	if err != nil {
+		t.Fatalf("Duplicate: %v", err)
+	}
+	if dupe.Kind != apperr.StackKindFanOut || !reflect.DeepEqual(dupe.Branches, want) {
+		t.Errorf("Duplicate: Kind = %q, Branches = %+v", dupe.Kind, dupe.Branches)
+	}
+
+	linear, err := repo.Create(apperr.SavedStack{Name: "Plain", Steps: []string{"rewrite.proofread.basic"}})
+	if err != nil {
+		t.Fatalf("Create linear: %v", err)
+	}
+	if linear.Kind != apperr.StackKindLinear || linear.Branches != nil {
+		t.Errorf("Create linear: Kind = %q, Branches = %+v", linear.Kind, linear.Branches)
+	}
+}
+
 func TestSqliteStackRepository_StepsOrderedByPosition(t *testing.T) {
 	repo := newStackRepo(t)
 
@@ -32,6 +32,15 @@ type TaskLogEntry struct {
 	InputLanguage  string `json:"inputLanguage,omitempty"`
 	OutputLanguage string `json:"outputLanguage,omitempty"`
 	RunID          string `json:"runId,omitempty"`
+	// Stripped lists what output cleanup removed from the model's reply, in order.
+	Stripped []StrippedText `json:"stripped,omitempty"`
+}
+
+// StrippedText is one piece of model chatter removed by output cleanup: the rule
+// that matched and the exact text it removed.
+type StrippedText struct {
+	Rule string `json:"rule"`
+	Text string `json:"text"`
 }
 
 // TaskLogServiceAPI is the contract for appending task log entries to disk.
@@ -140,6 +140,9 @@ func (m *mockFileUtilsService) GetAppDatabaseFilePath() (string, error)   { retu
 func (m *mockFileUtilsService) ResolveAppLogsFolderPath(_ string) (string, error) {
 	return "", nil
 }
+func (m *mockFileUtilsService) WriteExportFile(string, string, []byte) (string, error) {
+	return "", nil
+}
 
 // makeEntry returns a fully-populated TaskLogEntry for use across test cases.
 func makeEntry() TaskLogEntry {
@@ -239,6 +242,7 @@ func TestNewTaskLogService(t *testing.T) {
 				GetAppDatabaseFilePath() (string, error)
 				ResolveAppLogsFolderPath(string) (string, error)
 				EnsureAppLogsFolderExists(string) (string, error)
+				WriteExportFile(string, string, []byte) (string, error)
 			}
 
 			if tt.log != nil {
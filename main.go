@@ -44,6 +44,8 @@ var allErrorCodes = []struct {
 	{apperr.CodeContextWindow, "CodeContextWindow"},
 	{apperr.CodeStepFailed, "CodeStepFailed"},
 	{apperr.CodeCancelled, "CodeCancelled"},
+	{apperr.CodeLanguageMismatch, "CodeLanguageMismatch"},
+	{apperr.CodeInvalidEdits, "CodeInvalidEdits"},
 	{apperr.CodeInternal, "CodeInternal"},
 }
 
@@ -103,7 +105,7 @@ func main() {
 			}
 		},
 		Bind: []any{
-			app, app.ActionHandler, app.SettingsHandler, app.StackHandler, app.HistoryHandler,
+			app, app.ActionHandler, app.SettingsHandler, app.StackHandler, app.HistoryHandler, app.DiffHandler, app.BatchHandler, app.DocumentHandler, app.ExportHandler, app.RetentionHandler, app.AnalyticsHandler,
 		},
 		EnumBind: []any{
 			allErrorCodes,